@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/chat"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/config"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
@@ -20,6 +21,8 @@ import (
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/supabase"
 	mainport "github.com/boddenberg/pj-assistant-bfa-go/internal/port"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/webhook"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/worker"
 
 	"go.uber.org/zap"
 )
@@ -35,11 +38,21 @@ func main() {
 	logger := observability.NewLogger(cfg.LogLevel, cfg.AxiomToken, cfg.AxiomDataset)
 	defer logger.Sync()
 
+	build := buildinfo.Get()
+	logger.Info("starting bfa",
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("build_time", build.BuildTime),
+		zap.String("go_version", build.GoVersion),
+	)
+
 	logger.Info("configuration loaded",
 		zap.Int("port", cfg.Port),
 		zap.String("log_level", cfg.LogLevel),
 		zap.Bool("use_supabase", cfg.UseSupabase),
 		zap.Duration("http_timeout", cfg.HTTPTimeout),
+		zap.Duration("supabase_timeout", cfg.SupabaseTimeout),
+		zap.Duration("agent_timeout", cfg.AgentTimeout),
 		zap.Duration("cache_ttl", cfg.CacheTTL),
 		zap.Int("max_retries", cfg.MaxRetries),
 		zap.Duration("initial_backoff", cfg.InitialBackoff),
@@ -69,7 +82,13 @@ func main() {
 	cb := resilience.NewCircuitBreaker("external-apis")
 
 	/* Clients */
-	httpClient := &http.Client{Timeout: cfg.HTTPTimeout}
+	// One pool for every upstream, with a timeout per upstream
+	transport := client.NewTransport(client.PoolConfig{
+		MaxIdleConns:        cfg.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.HTTPIdleConnTimeout,
+	})
+	httpClient := client.NewHTTPClient(transport, cfg.HTTPTimeout)
 
 	var profileClient mainport.ProfileFetcher
 	var transactionsClient mainport.TransactionsFetcher
@@ -79,8 +98,9 @@ func main() {
 		logger.Info("using Supabase as data backend",
 			zap.String("supabase_url", cfg.SupabaseURL),
 		)
+		// Bounded per call through the context, see SetCallTimeout
 		supabaseClient = supabase.NewClient(
-			httpClient,
+			client.NewHTTPClient(transport, 0),
 			cfg.SupabaseURL,
 			cfg.SupabaseAnonKey,
 			cfg.SupabaseServiceKey,
@@ -88,6 +108,7 @@ func main() {
 			resilienceCfg,
 			logger,
 		)
+		supabaseClient.SetCallTimeout(cfg.SupabaseTimeout)
 		profileClient = supabaseClient
 		transactionsClient = supabaseClient
 	} else {
@@ -96,7 +117,7 @@ func main() {
 		transactionsClient = client.NewTransactionsClient(httpClient, cfg.TransactionsAPIURL, cb, resilienceCfg)
 	}
 
-	agentClient := client.NewAgentClient(httpClient, cfg.AgentAPIURL, cb, resilienceCfg)
+	agentClient := client.NewAgentClient(client.NewHTTPClient(transport, cfg.AgentTimeout), cfg.AgentAPIURL, cb, resilienceCfg)
 
 	/* Services */
 	assistantSvc := service.NewAssistant(
@@ -107,15 +128,57 @@ func main() {
 		metrics,
 		logger,
 	)
+	assistantSvc.SetAgentFallback(cfg.AgentFallbackEnabled)
+	assistantSvc.SetAssistantLimits(cfg.AssistantMaxInputLength, cfg.AssistantDailyTokenBudget)
+	if supabaseClient != nil {
+		assistantSvc.SetTokenUsageStore(supabaseClient)
+	}
 
 	// Banking service (uses Supabase as store)
 	var bankSvc *service.BankingService
 	var authSvc *service.AuthService
+	var webhookDispatcher *webhook.Dispatcher
 	if supabaseClient != nil {
 		bankSvc = service.NewBankingService(supabaseClient, metrics, logger)
+		bankSvc.SetCardAutoCategorization(cfg.CardAutoCategorize)
+		bankSvc.SetInvoiceLateCharges(cfg.InvoiceLateFeeRate, cfg.InvoiceLateInterestRate)
+		bankSvc.SetReceiptSharing(cfg.JWTSecret, cfg.ReceiptShareTTL)
+		bankSvc.SetPixKeyLimit(cfg.PixKeyMaxPerCustomer)
+		bankSvc.SetPIIMasking(cfg.PixMaskPII)
+		bankSvc.SetPixNewRecipientThreshold(cfg.PixNewRecipientThreshold)
+		bankSvc.SetPixConfirmation(cfg.PixConfirmationThreshold, cfg.PixConfirmationTTL)
+		bankSvc.SetPixCreditFeeRate(cfg.PixCreditFeeRate)
+		bankSvc.SetBalanceNotificationMinAmount(cfg.BalanceNotificationMinAmount)
+		bankSvc.SetMaxOverdraftLimit(cfg.OverdraftMaxLimit)
+		bankSvc.SetDefaultTransactionLimit("pix", cfg.PixDefaultSingleLimit, cfg.PixDefaultDailyLimit, cfg.PixDefaultMonthlyLimit)
+		bankSvc.SetDefaultTransactionLimit("bill_payment", cfg.BillPaymentDefaultSingleLimit, cfg.BillPaymentDefaultDailyLimit, cfg.BillPaymentDefaultMonthlyLimit)
+		bankSvc.SetDashboardBudget(cfg.DashboardTimeout)
+		bankSvc.SetSpendingSummaryMaxAge(cfg.SpendingSummaryMaxAge)
+		bankSvc.SetDevTools(cfg.DevToolsEnabled, cfg.DevToolsSecret)
+		bankSvc.SetReadOnly(cfg.ReadOnlyMode)
+		if cfg.ReadOnlyMode {
+			logger.Warn("read-only mode enabled: writes to banking data are rejected with 503")
+		}
+		switch {
+		case bankSvc.DevToolsEnabled():
+			logger.Warn("DEV TOOLS ENABLED: /v1/dev routes can add balance, change limits and delete customer data — never enable in production")
+		case cfg.DevToolsEnabled:
+			logger.Error("DEV_TOOLS_ENABLED is set without DEV_TOOLS_SECRET; dev tools stay disabled")
+		}
+
+		webhookDispatcher = webhook.NewDispatcher(
+			supabaseClient,
+			webhook.NewHTTPClient(cfg.WebhookTimeout),
+			resilience.Config{MaxRetries: cfg.WebhookMaxRetries, InitialBackoff: cfg.WebhookInitialBackoff},
+			logger,
+		)
+		bankSvc.SetWebhookDispatcher(webhookDispatcher)
+		assistantSvc.SetBankingTools(bankSvc)
 		logger.Info("banking service enabled with Supabase store")
 
 		authSvc = service.NewAuthService(supabaseClient, cfg.JWTSecret, cfg.JWTAccessTTL, cfg.JWTRefreshTTL, cfg.DevAuth, logger)
+		authSvc.SetSigningKeys(cfg.JWTKeyID, cfg.JWTPreviousKeys)
+		authSvc.SetRefreshIdleTimeout(cfg.AuthRefreshIdleTimeout)
 		if cfg.DevAuth {
 			logger.Warn("⚠️  DEV_AUTH=true — plain-text password fallback enabled, NEVER use in production")
 		}
@@ -156,7 +219,18 @@ func main() {
 	)
 
 	/* Router */
+	if _, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); !ok {
+		logger.Warn("CORS_ALLOWED_ORIGINS not set: only localhost origins are allowed, set the frontend URL in production",
+			zap.Strings("allowed_origins", cfg.CORSAllowedOrigins))
+	}
 	router := handler.NewRouter(assistantSvc, bankSvc, authSvc, chatSvc, chatMetrics, metrics, logger)
+	router = handler.MaxBodySizeMiddleware(cfg.MaxRequestBodyBytes, cfg.MaxUploadBodyBytes, logger)(router)
+	router = handler.CORSMiddleware(handler.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+	})(router)
 
 	/* Server */
 	srv := &http.Server{
@@ -167,6 +241,20 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	/* Background workers */
+	workers := worker.NewManager(logger)
+	if bankSvc != nil {
+		workers.Register("scheduled-transfers", cfg.PixScheduleInterval, bankSvc.ExecuteDueScheduledTransfers)
+		workers.Register("scheduled-pix-transfers", cfg.PixScheduleInterval, bankSvc.ExecuteDuePixTransfers)
+		workers.Register("doc-settlements", cfg.PixScheduleInterval, bankSvc.ExecuteDueDOCTransfers)
+		workers.Register("spending-digests", cfg.SpendingDigestInterval, bankSvc.SendSpendingDigests)
+		workers.Register("statement-exports", cfg.StatementExportInterval, bankSvc.ProcessStatementExports)
+	}
+	if authSvc != nil && cfg.AuthRefreshIdleTimeout > 0 {
+		workers.Register("idle-refresh-tokens", cfg.AuthIdleSweepInterval, authSvc.RevokeIdleRefreshTokens)
+	}
+	workers.Start(context.Background())
+
 	/* Start listener (validates port before serving) */
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
 	if err != nil {
@@ -196,5 +284,14 @@ func main() {
 		logger.Fatal("server forced shutdown", zap.Error(err))
 	}
 
+	// Stop scheduling worker iterations and let the running ones finish
+	if err := workers.Stop(ctx); err != nil {
+		logger.Error("workers forced shutdown", zap.Error(err))
+	}
+
+	if webhookDispatcher != nil {
+		webhookDispatcher.Wait()
+	}
+
 	logger.Info("server stopped")
 }
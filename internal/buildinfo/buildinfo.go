@@ -0,0 +1,54 @@
+// Package buildinfo tells which build is running. Version, Commit and
+// BuildTime are set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo.Version=1.4.0 \
+//	  -X github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/bfa
+//
+// A binary built without them reports "dev" and, when the Go toolchain
+// stamped VCS data, the commit and time of that checkout.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set via -ldflags -X.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is returned by GET /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the running build's info.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if info.Commit == "" || info.BuildTime == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch {
+				case s.Key == "vcs.revision" && info.Commit == "":
+					info.Commit = s.Value
+				case s.Key == "vcs.time" && info.BuildTime == "":
+					info.BuildTime = s.Value
+				}
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
@@ -354,7 +354,7 @@ func fetchBillingContext(ctx context.Context, store port.BillingStore, customerI
 	bc := &BillingContext{}
 
 	// Boletos recentes (page 1, 10 itens)
-	bills, err := store.ListBillPayments(ctx, customerID, 1, 10)
+	bills, _, err := store.ListBillPayments(ctx, customerID, 1, 10)
 	if err != nil {
 		logger.Warn("financial context: bills fetch failed",
 			zap.String("customer_id", customerID),
@@ -373,7 +373,7 @@ func fetchBillingContext(ctx context.Context, store port.BillingStore, customerI
 	}
 
 	// Compras no débito recentes (page 1, 10 itens)
-	debits, err := store.ListDebitPurchases(ctx, customerID, 1, 10)
+	debits, _, err := store.ListDebitPurchases(ctx, customerID, 1, 10)
 	if err != nil {
 		logger.Warn("financial context: debit purchases fetch failed",
 			zap.String("customer_id", customerID),
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/supabase"
@@ -125,7 +126,11 @@ func (r *SupabaseTranscriptRepository) MarkTranscriptsEvaluated(ctx context.Cont
 
 /* In-memory stub (para testes) */
 
+// InMemoryTranscriptRepository guarda os transcripts em memória. Os turnos
+// são salvos em goroutines (saveTranscriptAsync), por isso o acesso é
+// protegido por mutex.
 type InMemoryTranscriptRepository struct {
+	mu      sync.Mutex
 	Entries []Transcript
 	logger  *zap.Logger
 }
@@ -142,7 +147,9 @@ func (r *InMemoryTranscriptRepository) MarkTranscriptsEvaluated(_ context.Contex
 }
 
 func (r *InMemoryTranscriptRepository) SaveTranscript(_ context.Context, t Transcript) error {
+	r.mu.Lock()
 	r.Entries = append(r.Entries, t)
+	r.mu.Unlock()
 	r.logger.Debug("stub: transcript saved",
 		zap.String("customer_id", t.CustomerID),
 		zap.String("query", t.Query),
@@ -151,6 +158,8 @@ func (r *InMemoryTranscriptRepository) SaveTranscript(_ context.Context, t Trans
 }
 
 func (r *InMemoryTranscriptRepository) ListTranscripts(_ context.Context, customerID string) ([]Transcript, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	var result []Transcript
 	for _, t := range r.Entries {
 		if t.CustomerID == customerID {
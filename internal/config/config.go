@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,7 +23,12 @@ type Config struct {
 	ChatRetryDelay     time.Duration // delay entre retries ao agente
 
 	// HTTP client
-	HTTPTimeout time.Duration
+	HTTPTimeout             time.Duration // timeout das APIs de perfil e transações
+	SupabaseTimeout         time.Duration // prazo de cada chamada ao Supabase; estourado, a API responde 504 (padrão: HTTP_TIMEOUT)
+	AgentTimeout            time.Duration // timeout das chamadas ao agente de IA (respostas de LLM são lentas)
+	HTTPMaxIdleConns        int           // conexões ociosas mantidas no pool, somando todos os hosts
+	HTTPMaxIdleConnsPerHost int           // conexões ociosas mantidas por host (o padrão do Go é 2)
+	HTTPIdleConnTimeout     time.Duration // por quanto tempo uma conexão ociosa fica no pool
 
 	// Resilience
 	MaxRetries     int
@@ -46,19 +52,99 @@ type Config struct {
 	UseSupabase        bool
 
 	// JWT / Auth
-	JWTSecret     string
-	JWTAccessTTL  time.Duration
-	JWTRefreshTTL time.Duration
+	JWTSecret              string
+	JWTKeyID               string            // JWT_KEY_ID — kid do JWT_SECRET, enviado no header dos tokens
+	JWTPreviousKeys        map[string]string // JWT_PREVIOUS_KEYS — "kid=secret,..." aceitos só na verificação (rotação)
+	JWTAccessTTL           time.Duration
+	JWTRefreshTTL          time.Duration
+	AuthRefreshIdleTimeout time.Duration // AUTH_REFRESH_IDLE_TIMEOUT — refresh token sem uso por esse tempo é revogado; 0 desativa
+	AuthIdleSweepInterval  time.Duration // AUTH_IDLE_SWEEP_INTERVAL — intervalo do worker que revoga sessões ociosas
+
+	// CORS
+	CORSAllowedOrigins   []string // CORS_ALLOWED_ORIGINS, separado por vírgula; aceita um "*" por origem (ex.: http://localhost:*)
+	CORSAllowedMethods   []string // CORS_ALLOWED_METHODS
+	CORSAllowedHeaders   []string // CORS_ALLOWED_HEADERS
+	CORSAllowCredentials bool     // CORS_ALLOW_CREDENTIALS=true → envia cookies/Authorization cross-origin
+
+	// Request bodies
+	MaxRequestBodyBytes int64 // MAX_REQUEST_BODY_BYTES — corpo máximo das requisições (413 acima); 0 desliga
+	MaxUploadBodyBytes  int64 // MAX_UPLOAD_BODY_BYTES — corpo máximo das rotas com imagem (camera_scan em /v1/bills/validate)
+
+	// Maintenance
+	ReadOnlyMode bool // READ_ONLY_MODE=true → rejeita (503) operações que movimentam dinheiro; leituras seguem disponíveis
 
 	// Dev mode
-	DevAuth bool // DEV_AUTH=true bypasses bcrypt, uses dev_logins table
+	DevAuth         bool   // DEV_AUTH=true bypasses bcrypt, uses dev_logins table
+	DevToolsEnabled bool   // DEV_TOOLS_ENABLED=true → registra as rotas /v1/dev (saldo, limites, massa de dados, reset)
+	DevToolsSecret  string // DEV_TOOLS_SECRET — valor exigido no header X-Dev-Secret das rotas /v1/dev
 
 	// Chat behavior
 	ChatHistoryAnonymousOnly bool // CHAT_HISTORY_ANONYMOUS_ONLY=true → só envia history se não estiver logado
+
+	// Assistant
+	AgentFallbackEnabled      bool // AGENT_FALLBACK_ENABLED=true → responde com resumo local quando o agente está indisponível
+	AssistantMaxInputLength   int  // maior mensagem (em caracteres) enviada ao agente; 0 desativa
+	AssistantDailyTokenBudget int  // tokens do agente por cliente por dia; 0 desativa
+
+	// Credit cards
+	CardAutoCategorize      bool    // CARD_AUTO_CATEGORIZE=true → infere categoria da transação pelo nome do estabelecimento
+	InvoiceLateFeeRate      float64 // multa sobre o total da fatura paga após o vencimento (0.02 = 2%)
+	InvoiceLateInterestRate float64 // juros de mora ao mês, cobrados pro rata por dia de atraso (0.01 = 1% a.m.)
+
+	// Dashboard
+	DashboardTimeout time.Duration // orçamento total da agregação do dashboard (seções lentas voltam como timed_out)
+
+	// Analytics
+	SpendingSummaryMaxAge time.Duration // por quanto tempo o resumo financeiro pré-calculado é reutilizado
+
+	// PIX keys
+	PixKeyMaxPerCustomer int  // máximo de chaves Pix ativas por cliente (DICT: 20 para PJ)
+	PixMaskPII           bool // PIX_MASK_PII=false → expõe documento/chave completos da contraparte (só para debug interno)
+
+	// PIX risk
+	PixNewRecipientThreshold float64       // valor acima do qual um Pix para destinatário novo volta com o aviso new_recipient_high_value
+	PixConfirmationThreshold float64       // valor acima do qual o Pix exige token de confirmação (0 desativa)
+	PixConfirmationTTL       time.Duration // validade do token de confirmação de Pix
+
+	// PIX via credit card
+	PixCreditFeeRate float64 // juros por parcela além da primeira no Pix via cartão (0.02 = 2%)
+
+	// Limites padrão, gravados para o cliente sem limite configurado no primeiro uso (tudo 0 desativa o tipo)
+	PixDefaultSingleLimit          float64 // PIX_DEFAULT_SINGLE_LIMIT — por transação
+	PixDefaultDailyLimit           float64 // PIX_DEFAULT_DAILY_LIMIT
+	PixDefaultMonthlyLimit         float64 // PIX_DEFAULT_MONTHLY_LIMIT
+	BillPaymentDefaultSingleLimit  float64 // BILL_PAYMENT_DEFAULT_SINGLE_LIMIT — por boleto
+	BillPaymentDefaultDailyLimit   float64 // BILL_PAYMENT_DEFAULT_DAILY_LIMIT
+	BillPaymentDefaultMonthlyLimit float64 // BILL_PAYMENT_DEFAULT_MONTHLY_LIMIT
+
+	// Cheque especial
+	OverdraftMaxLimit float64 // maior limite de cheque especial aprovável (score alto); scores menores aprovam uma fração
+
+	// Notificações de movimentação de saldo
+	BalanceNotificationMinAmount float64 // menor valor de débito/crédito notificado ao cliente (0 notifica todos)
+
+	// PIX scheduling
+	PixScheduleInterval time.Duration // intervalo do worker que executa os Pix agendados vencidos
+
+	// Resumos de gastos
+	SpendingDigestInterval time.Duration // intervalo do worker que envia os resumos semanais (segunda) e mensais (dia 1º)
+
+	// Exportação de extrato
+	StatementExportInterval time.Duration // intervalo do worker que gera os extratos pedidos em /statements/export
+
+	// PIX receipts
+	ReceiptShareTTL time.Duration // validade do link público de comprovante
+
+	// Webhooks
+	WebhookTimeout        time.Duration // timeout de cada POST ao endpoint do cliente
+	WebhookMaxRetries     int           // retentativas antes de ir para o dead-letter log
+	WebhookInitialBackoff time.Duration // backoff inicial entre retentativas
 }
 
 // Load reads configuration from environment variables with defaults.
 func Load() *Config {
+	httpTimeout := getEnvDuration("HTTP_TIMEOUT", 10*time.Second)
+
 	return &Config{
 		Port:     getEnvInt("PORT", 8080),
 		LogLevel: getEnv("LOG_LEVEL", "info"),
@@ -70,7 +156,12 @@ func Load() *Config {
 		ChatMaxRetries:     getEnvInt("CHAT_MAX_RETRIES", 3),
 		ChatRetryDelay:     getEnvDuration("CHAT_RETRY_DELAY", 500*time.Millisecond),
 
-		HTTPTimeout: getEnvDuration("HTTP_TIMEOUT", 10*time.Second),
+		HTTPTimeout:             httpTimeout,
+		SupabaseTimeout:         getEnvDuration("SUPABASE_TIMEOUT", httpTimeout),
+		AgentTimeout:            getEnvDuration("AGENT_TIMEOUT", 60*time.Second),
+		HTTPMaxIdleConns:        getEnvInt("HTTP_MAX_IDLE_CONNS", 100),
+		HTTPMaxIdleConnsPerHost: getEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", 50),
+		HTTPIdleConnTimeout:     getEnvDuration("HTTP_IDLE_CONN_TIMEOUT", 90*time.Second),
 
 		MaxRetries:     getEnvInt("MAX_RETRIES", 3),
 		InitialBackoff: getEnvDuration("INITIAL_BACKOFF", 100*time.Millisecond),
@@ -88,13 +179,73 @@ func Load() *Config {
 		SupabaseServiceKey: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
 		UseSupabase:        getEnv("USE_SUPABASE", "true") == "true",
 
-		JWTSecret:     getEnv("JWT_SECRET", "bfa-default-dev-secret-change-me"),
-		JWTAccessTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
-		JWTRefreshTTL: getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+		JWTSecret:              getEnv("JWT_SECRET", "bfa-default-dev-secret-change-me"),
+		JWTKeyID:               getEnv("JWT_KEY_ID", ""),
+		JWTPreviousKeys:        getEnvKeyMap("JWT_PREVIOUS_KEYS"),
+		JWTAccessTTL:           getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+		JWTRefreshTTL:          getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+		AuthRefreshIdleTimeout: getEnvDuration("AUTH_REFRESH_IDLE_TIMEOUT", 0),
+		AuthIdleSweepInterval:  getEnvDuration("AUTH_IDLE_SWEEP_INTERVAL", 10*time.Minute),
+
+		CORSAllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", "http://localhost:*,http://127.0.0.1:*"),
+		CORSAllowedMethods:   getEnvList("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"),
+		CORSAllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", "*"),
+		CORSAllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "true") == "true",
 
-		DevAuth: getEnv("DEV_AUTH", "false") == "true",
+		MaxRequestBodyBytes: int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		MaxUploadBodyBytes:  int64(getEnvInt("MAX_UPLOAD_BODY_BYTES", 10<<20)),
+
+		ReadOnlyMode: getEnv("READ_ONLY_MODE", "false") == "true",
+
+		DevAuth:         getEnv("DEV_AUTH", "false") == "true",
+		DevToolsEnabled: getEnv("DEV_TOOLS_ENABLED", "false") == "true",
+		DevToolsSecret:  getEnv("DEV_TOOLS_SECRET", ""),
 
 		ChatHistoryAnonymousOnly: getEnv("CHAT_HISTORY_ANONYMOUS_ONLY", "true") == "true",
+
+		AgentFallbackEnabled:      getEnv("AGENT_FALLBACK_ENABLED", "true") == "true",
+		AssistantMaxInputLength:   getEnvInt("ASSISTANT_MAX_INPUT_LENGTH", 4000),
+		AssistantDailyTokenBudget: getEnvInt("ASSISTANT_DAILY_TOKEN_BUDGET", 100000),
+
+		CardAutoCategorize:      getEnv("CARD_AUTO_CATEGORIZE", "true") == "true",
+		InvoiceLateFeeRate:      getEnvFloat("INVOICE_LATE_FEE_RATE", 0.02),
+		InvoiceLateInterestRate: getEnvFloat("INVOICE_LATE_INTEREST_RATE", 0.01),
+
+		DashboardTimeout: getEnvDuration("DASHBOARD_TIMEOUT", 2*time.Second),
+
+		SpendingSummaryMaxAge: getEnvDuration("SPENDING_SUMMARY_MAX_AGE", 15*time.Minute),
+
+		PixKeyMaxPerCustomer: getEnvInt("PIX_KEY_MAX_PER_CUSTOMER", 20),
+		PixMaskPII:           getEnv("PIX_MASK_PII", "true") == "true",
+
+		PixNewRecipientThreshold: getEnvFloat("PIX_NEW_RECIPIENT_THRESHOLD", 5000),
+		PixConfirmationThreshold: getEnvFloat("PIX_CONFIRMATION_THRESHOLD", 0),
+		PixConfirmationTTL:       getEnvDuration("PIX_CONFIRMATION_TTL", 5*time.Minute),
+
+		PixCreditFeeRate: getEnvFloat("PIX_CREDIT_FEE_RATE", 0.02),
+
+		PixDefaultSingleLimit:          getEnvFloat("PIX_DEFAULT_SINGLE_LIMIT", 10000),
+		PixDefaultDailyLimit:           getEnvFloat("PIX_DEFAULT_DAILY_LIMIT", 20000),
+		PixDefaultMonthlyLimit:         getEnvFloat("PIX_DEFAULT_MONTHLY_LIMIT", 200000),
+		BillPaymentDefaultSingleLimit:  getEnvFloat("BILL_PAYMENT_DEFAULT_SINGLE_LIMIT", 50000),
+		BillPaymentDefaultDailyLimit:   getEnvFloat("BILL_PAYMENT_DEFAULT_DAILY_LIMIT", 100000),
+		BillPaymentDefaultMonthlyLimit: getEnvFloat("BILL_PAYMENT_DEFAULT_MONTHLY_LIMIT", 500000),
+
+		OverdraftMaxLimit: getEnvFloat("OVERDRAFT_MAX_LIMIT", 50000),
+
+		BalanceNotificationMinAmount: getEnvFloat("BALANCE_NOTIFICATION_MIN_AMOUNT", 100),
+
+		PixScheduleInterval: getEnvDuration("PIX_SCHEDULE_INTERVAL", time.Minute),
+
+		SpendingDigestInterval: getEnvDuration("SPENDING_DIGEST_INTERVAL", time.Hour),
+
+		StatementExportInterval: getEnvDuration("STATEMENT_EXPORT_INTERVAL", 5*time.Second),
+
+		ReceiptShareTTL: getEnvDuration("RECEIPT_SHARE_TTL", 15*time.Minute),
+
+		WebhookTimeout:        getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		WebhookMaxRetries:     getEnvInt("WEBHOOK_MAX_RETRIES", 5),
+		WebhookInitialBackoff: getEnvDuration("WEBHOOK_INITIAL_BACKOFF", 500*time.Millisecond),
 	}
 }
 
@@ -105,6 +256,30 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key, fallback string) []string {
+	var out []string
+	for _, v := range strings.Split(getEnv(key, fallback), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// getEnvKeyMap parses a comma-separated list of "id=value" pairs. Entries
+// without "=" are skipped.
+func getEnvKeyMap(key string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range getEnvList(key, "") {
+		id, value, ok := strings.Cut(entry, "=")
+		if id = strings.TrimSpace(id); ok && id != "" {
+			out[id] = strings.TrimSpace(value)
+		}
+	}
+	return out
+}
+
 func getEnvInt(key string, fallback int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -114,6 +289,15 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvFloat(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
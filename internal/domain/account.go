@@ -26,6 +26,119 @@ type Account struct {
 	CreatedAt            time.Time `json:"created_at"`
 }
 
+// AccountsTotals aggregates the active accounts of a customer.
+type AccountsTotals struct {
+	TotalBalance   float64 `json:"totalBalance"`
+	TotalAvailable float64 `json:"totalAvailable"`
+	TotalOverdraft float64 `json:"totalOverdraft"`
+	AccountCount   int     `json:"accountCount"`
+}
+
+// AccountsSummary is the response of GET /v1/customers/{customerId}/accounts?summary=true.
+type AccountsSummary struct {
+	Accounts []Account      `json:"accounts"`
+	Summary  AccountsTotals `json:"summary"`
+}
+
+// Overdraft is the response of GET/PUT /v1/customers/{customerId}/accounts/{accountId}/overdraft.
+type Overdraft struct {
+	AccountID      string  `json:"account_id"`
+	OverdraftLimit float64 `json:"overdraft_limit"`
+	MaxLimit       float64 `json:"max_overdraft_limit"` // highest limit the customer's credit score approves
+	Used           float64 `json:"used"`                // overdraft in use (negative available balance)
+	Available      float64 `json:"available"`           // overdraft still available
+}
+
+// OverdraftRequest is the body for PUT /v1/customers/{customerId}/accounts/{accountId}/overdraft.
+type OverdraftRequest struct {
+	OverdraftLimit *float64 `json:"overdraft_limit"`
+}
+
+// InternalTransferRequest is the body for POST /v1/customers/{customerId}/transfers/internal.
+type InternalTransferRequest struct {
+	FromAccountID string  `json:"fromAccountId"`
+	ToAccountID   string  `json:"toAccountId"`
+	Amount        float64 `json:"amount"`
+}
+
+// InternalTransfer is a move of funds between two accounts of the same customer.
+type InternalTransfer struct {
+	ID            string    `json:"id"`
+	CustomerID    string    `json:"customerId"`
+	FromAccountID string    `json:"fromAccountId"`
+	ToAccountID   string    `json:"toAccountId"`
+	Amount        float64   `json:"amount"`
+	FromBalance   float64   `json:"fromBalance"` // balance of the source account after the move
+	ToBalance     float64   `json:"toBalance"`   // balance of the destination account after the move
+	CreatedAt     time.Time `json:"createdAt"`
+	ReceiptID     string    `json:"receiptId,omitempty"`
+}
+
+// DOCTransferRequest is the body for POST /v1/transfers/doc.
+type DOCTransferRequest struct {
+	CustomerID        string  `json:"customerId"`
+	IdempotencyKey    string  `json:"idempotencyKey,omitempty"`
+	RecipientName     string  `json:"recipientName"`
+	RecipientDocument string  `json:"recipientDocument"`
+	RecipientBank     string  `json:"recipientBank"`
+	RecipientBranch   string  `json:"recipientBranch"`
+	RecipientAccount  string  `json:"recipientAccount"`
+	Amount            float64 `json:"amount"`
+	Description       string  `json:"description,omitempty"`
+}
+
+// DOCTransfer is a DOC sent from the customer's primary account. The amount
+// is held from the available balance when the transfer is created and
+// debited on SettlementDate (YYYY-MM-DD), the next business day.
+type DOCTransfer struct {
+	ID                string    `json:"id"`
+	IdempotencyKey    string    `json:"idempotency_key,omitempty"`
+	CustomerID        string    `json:"customer_id"`
+	AccountID         string    `json:"account_id"`
+	RecipientName     string    `json:"recipient_name"`
+	RecipientDocument string    `json:"recipient_document"`
+	RecipientBank     string    `json:"recipient_bank"`
+	RecipientBranch   string    `json:"recipient_branch"`
+	RecipientAccount  string    `json:"recipient_account"`
+	Amount            float64   `json:"amount"`
+	Description       string    `json:"description,omitempty"`
+	Status            string    `json:"status"` // held, processing, completed, failed
+	SettlementDate    string    `json:"settlement_date"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Non-PIX transfer types with a TransferReceipt.
+const (
+	TransferTypeInternal = "internal"
+	TransferTypeTED      = "ted"
+	TransferTypeDOC      = "doc"
+)
+
+// TransferReceipt is the receipt (comprovante) of a non-PIX transfer; PIX
+// transfers have a PixReceipt instead.
+type TransferReceipt struct {
+	ID                string  `json:"id"`
+	TransferID        string  `json:"transfer_id"`
+	TransferType      string  `json:"transfer_type"` // internal, ted, doc
+	CustomerID        string  `json:"customer_id"`
+	Amount            float64 `json:"amount"`
+	Description       string  `json:"description,omitempty"`
+	SenderName        string  `json:"sender_name"`
+	SenderDocument    string  `json:"sender_document"`
+	SenderBank        string  `json:"sender_bank"`
+	SenderBranch      string  `json:"sender_branch"`
+	SenderAccount     string  `json:"sender_account"`
+	RecipientName     string  `json:"recipient_name"`
+	RecipientDocument string  `json:"recipient_document"`
+	RecipientBank     string  `json:"recipient_bank"`
+	RecipientBranch   string  `json:"recipient_branch"`
+	RecipientAccount  string  `json:"recipient_account"`
+	Status            string  `json:"status"`
+	ExecutedAt        string  `json:"executed_at"`
+	CreatedAt         string  `json:"created_at"`
+}
+
 /*
  * Transactions (bank statement)
  */
@@ -35,10 +148,52 @@ type Transaction struct {
 	ID           string    `json:"id"`
 	Date         time.Time `json:"date"`
 	Amount       float64   `json:"amount"`
-	Type         string    `json:"type"` // pix_sent, pix_received, debit_purchase, credit_purchase, transfer_in, transfer_out, bill_payment, credit, debit
+	Type         string    `json:"type"` // pix_sent, pix_received, debit_purchase, credit_purchase, transfer_in, transfer_out, bill_payment, refund, credit, debit
 	Category     string    `json:"category"`
 	Description  string    `json:"description"`
 	Counterparty string    `json:"counterparty,omitempty"`
+	ReferenceID  string    `json:"reference_id,omitempty"` // original purchase of a refund
+	Tags         []string  `json:"tags,omitempty"`         // set by the customer, see TransactionAnnotation
+	Note         string    `json:"note,omitempty"`
+}
+
+// TransactionAnnotation is the body of PUT
+// /v1/customers/{id}/transactions/{txId}/annotate. It replaces the note and
+// the tags of the transaction; empty values clear them.
+type TransactionAnnotation struct {
+	Note string   `json:"note"`
+	Tags []string `json:"tags"`
+}
+
+// Transaction directions accepted by the ?direction= filters.
+const (
+	TransactionDirectionIn  = "in"  // income: positive amounts
+	TransactionDirectionOut = "out" // expense: negative amounts
+)
+
+// MatchesDirection reports whether tx moves money in the given direction. An
+// empty direction matches every transaction.
+func (tx Transaction) MatchesDirection(direction string) bool {
+	switch direction {
+	case TransactionDirectionIn:
+		return tx.Amount > 0
+	case TransactionDirectionOut:
+		return tx.Amount < 0
+	default:
+		return true
+	}
+}
+
+// TransactionSearchFilter narrows GET /v1/customers/{id}/transactions/search.
+// Amounts are compared in absolute value, so a debit of -150 matches 150.
+type TransactionSearchFilter struct {
+	Query     string   // description substring (case- and accent-insensitive)
+	MinAmount *float64 // inclusive
+	MaxAmount *float64 // inclusive
+	Type      string
+	Direction string // TransactionDirectionIn, TransactionDirectionOut or empty for both
+	Page      int
+	PageSize  int
 }
 
 // TransactionSummary provides aggregated transaction data.
@@ -34,6 +34,9 @@ type SpendingSummary struct {
 	BillsPaidCount      int               `json:"bills_paid_count"`
 	IncomeVariationPct  float64           `json:"income_variation_pct"`
 	ExpenseVariationPct float64           `json:"expense_variation_pct"`
+	NetVariationPct     float64           `json:"net_cashflow_variation_pct"`
+	MonthlyTrend        []MonthlyTrend    `json:"monthly_trend,omitempty"`
+	ComputedAt          time.Time         `json:"computed_at"`
 }
 
 // CatSum is a spending breakdown per category.
@@ -43,6 +46,26 @@ type CatSum struct {
 	Pct   float64 `json:"pct,omitempty"`
 }
 
+// Spending digest periods.
+const (
+	DigestPeriodWeekly  = "weekly"  // Monday to Sunday
+	DigestPeriodMonthly = "monthly" // calendar month
+)
+
+// SpendingDigest summarizes the spending of the last closed week or month.
+type SpendingDigest struct {
+	CustomerID         string        `json:"customerId"`
+	Period             string        `json:"period"` // weekly, monthly
+	From               string        `json:"from"`   // YYYY-MM-DD, first day
+	To                 string        `json:"to"`     // YYYY-MM-DD, last day (inclusive)
+	TotalSpent         float64       `json:"totalSpent"`
+	TotalIncome        float64       `json:"totalIncome"`
+	TopCategories      []TopCategory `json:"topCategories"` // up to 3, biggest first
+	BiggestExpense     *Transaction  `json:"biggestExpense,omitempty"`
+	PreviousTotalSpent float64       `json:"previousTotalSpent"`
+	SpentChangePct     float64       `json:"spentChangePct"` // vs. the previous period, 0 when it had no spending
+}
+
 // SpendingBudget represents a monthly spending budget per category.
 type SpendingBudget struct {
 	ID                string  `json:"id"`
@@ -53,6 +76,28 @@ type SpendingBudget struct {
 	IsActive          bool    `json:"is_active"`
 }
 
+// CategoryRule recategorizes the customer's transactions whose description
+// (or card merchant) contains Pattern, ignoring case and accents.
+type CategoryRule struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	Pattern    string `json:"pattern"` // stored lower-cased and without accents
+	Category   string `json:"category"`
+}
+
+// CategoryRuleRequest is the body for PUT /v1/customers/{id}/analytics/category-rules.
+type CategoryRuleRequest struct {
+	Pattern  string `json:"pattern"`
+	Category string `json:"category"`
+	Backfill bool   `json:"backfill"` // also recategorize existing statement entries
+}
+
+// CategoryRuleResult is returned by PUT /v1/customers/{id}/analytics/category-rules.
+type CategoryRuleResult struct {
+	Rule          *CategoryRule `json:"rule"`
+	Recategorized int           `json:"recategorized"` // statement entries changed by the backfill
+}
+
 /*
  * Financial Summary & Analytics API types (matches frontend spec)
  */
@@ -163,6 +208,37 @@ type TransactionLimit struct {
 	SingleLimit        float64  `json:"single_limit"`
 	NightlySingleLimit *float64 `json:"nightly_single_limit,omitempty"`
 	NightlyDailyLimit  *float64 `json:"nightly_daily_limit,omitempty"`
+	// When the daily and monthly usage last started over; usage from an
+	// earlier day or month no longer counts.
+	LastResetDaily   *time.Time `json:"last_reset_daily,omitempty"`
+	LastResetMonthly *time.Time `json:"last_reset_monthly,omitempty"`
+}
+
+// LimitUsage is a limit with how much of it is used and what is left.
+type LimitUsage struct {
+	Limit     float64 `json:"limit"`
+	Used      float64 `json:"used"`
+	Remaining float64 `json:"remaining"`
+}
+
+// PixNightlyLimits are the reduced limits applied to PIX at night. Nightly
+// usage is not tracked apart from the daily usage, so Remaining is what the
+// nightly cap still allows within today's daily headroom.
+type PixNightlyLimits struct {
+	SingleLimit *float64 `json:"singleLimit,omitempty"`
+	DailyLimit  *float64 `json:"dailyLimit,omitempty"`
+	Remaining   *float64 `json:"remaining,omitempty"`
+}
+
+// PixLimitsSummary is the response of GET /v1/customers/{customerId}/pix/limits.
+// Configured is false when the customer has no PIX limit, i.e. no restriction.
+type PixLimitsSummary struct {
+	CustomerID  string            `json:"customerId"`
+	Configured  bool              `json:"configured"`
+	SingleLimit float64           `json:"singleLimit"`
+	Daily       LimitUsage        `json:"daily"`
+	Monthly     LimitUsage        `json:"monthly"`
+	Nightly     *PixNightlyLimits `json:"nightly,omitempty"`
 }
 
 /*
@@ -183,3 +259,126 @@ type Notification struct {
 	ReadAt     *time.Time `json:"read_at,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
+
+// Notification delivery channels, as stored in notifications.channel.
+const (
+	NotificationChannelPush  = "push"
+	NotificationChannelEmail = "email"
+	NotificationChannelInApp = "in_app"
+)
+
+// NotificationTypeTransaction notifies money leaving or entering the account.
+const NotificationTypeTransaction = "transaction"
+
+// NotificationTypeDigest delivers the weekly and monthly spending digests.
+const NotificationTypeDigest = "digest"
+
+// NotificationTypeStatementReady says a requested statement export can be
+// downloaded.
+const NotificationTypeStatementReady = "statement_ready"
+
+// NotificationTypes lists the notification types a customer can configure.
+var NotificationTypes = []string{
+	NotificationTypeTransaction, NotificationTypeDigest, NotificationTypeStatementReady,
+	"pix_sent", "pix_received",
+	"transfer_scheduled", "transfer_executed", "transfer_failed",
+	"bill_due", "bill_paid", "bill_failed",
+	"card_purchase", "card_invoice_available", "card_invoice_due", "card_limit_alert", "card_approved", "card_blocked",
+	"budget_alert", "budget_exceeded", "balance_low",
+	"security_alert", "login_alert", "general",
+}
+
+// NotificationChannelPrefs says on which channels a notification type is
+// delivered.
+type NotificationChannelPrefs struct {
+	Push  bool `json:"push"`
+	Email bool `json:"email"`
+	InApp bool `json:"inApp"`
+
+	// MinAmount is the smallest movement notified; only used by the
+	// transaction type. Nil keeps the service default.
+	MinAmount *float64 `json:"minAmount,omitempty"`
+}
+
+// Allows reports whether the given channel is enabled. Channels without a
+// preference (sms) are always allowed.
+func (p NotificationChannelPrefs) Allows(channel string) bool {
+	switch channel {
+	case NotificationChannelPush:
+		return p.Push
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelInApp:
+		return p.InApp
+	}
+	return true
+}
+
+// NotificationsReadRequest is the body for
+// POST /v1/customers/{id}/notifications/read.
+type NotificationsReadRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// NotificationsReadResponse reports how many notifications were marked read.
+type NotificationsReadResponse struct {
+	Updated int `json:"updated"`
+}
+
+// NotificationPreferences maps a notification type to its channel
+// preferences.
+type NotificationPreferences map[string]NotificationChannelPrefs
+
+/*
+ * Dashboard
+ */
+
+// Dashboard section statuses.
+const (
+	DashboardSectionOK       = "ok"
+	DashboardSectionTimedOut = "timed_out"
+	DashboardSectionError    = "error"
+)
+
+// Dashboard aggregates the home screen data. Sections that did not complete
+// within the time budget are left empty and flagged in Sections.
+type Dashboard struct {
+	CustomerID          string              `json:"customer_id"`
+	Accounts            []Account           `json:"accounts,omitempty"`
+	CreditCards         []CreditCard        `json:"credit_cards,omitempty"`
+	TransactionSummary  *TransactionSummary `json:"transaction_summary,omitempty"`
+	UnreadNotifications []Notification      `json:"unread_notifications,omitempty"`
+	Sections            map[string]string   `json:"sections"` // section → ok, timed_out, error
+	GeneratedAt         time.Time           `json:"generated_at"`
+}
+
+/*
+ * Activity feed
+ */
+
+// Activity feed item kinds.
+const (
+	ActivityKindTransaction  = "transaction"
+	ActivityKindPixReceipt   = "pix_receipt"
+	ActivityKindBillPayment  = "bill_payment"
+	ActivityKindNotification = "notification"
+)
+
+// ActivityItem is one entry of the merged activity feed. Kind tells which
+// source it came from; SourceID is the ID within that source.
+type ActivityItem struct {
+	Kind        string    `json:"kind"`
+	SourceID    string    `json:"source_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Amount      *float64  `json:"amount,omitempty"` // negative for outflows
+	Status      string    `json:"status,omitempty"`
+}
+
+// ActivityFeed is a page of the activity feed, most recent first. NextCursor
+// is empty on the last page.
+type ActivityFeed struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
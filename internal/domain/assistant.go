@@ -13,6 +13,7 @@ type AgentRequest struct {
 	Transactions []Transaction       `json:"transactions"`
 	Summary      *TransactionSummary `json:"summary,omitempty"`
 	Query        string              `json:"query,omitempty"`
+	ToolResults  []ToolResult        `json:"tool_results,omitempty"` // resultados das tools pedidas na rodada anterior
 }
 
 // AgentResponse contém a resposta estruturada do Agente IA.
@@ -23,6 +24,24 @@ type AgentResponse struct {
 	Confidence    float64    `json:"confidence"`
 	TokensUsed    TokenUsage `json:"tokens_used"`
 	ToolsExecuted []string   `json:"tools_executed,omitempty"`
+	ToolCalls     []ToolCall `json:"tool_calls,omitempty"` // ações que o agente pede para o BFA executar
+}
+
+// ToolCall é uma ação pedida pelo agente (ex.: get_balance, list_recent_pix).
+type ToolCall struct {
+	ID   string         `json:"id"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// ToolResult é o resultado da execução de uma ToolCall, devolvido ao agente
+// e registrado nos metadados da mensagem.
+type ToolResult struct {
+	CallID string         `json:"callId"`
+	Name   string         `json:"name"`
+	Args   map[string]any `json:"args,omitempty"`
+	Result any            `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
 }
 
 // TokenUsage rastreia o consumo de tokens do LLM para monitoramento de custos.
@@ -54,11 +73,13 @@ type AssistantMessage struct {
 
 // MessageMetadata enriquece a mensagem com informações de tools/RAG/tokens.
 type MessageMetadata struct {
-	ToolsUsed  []string    `json:"toolsUsed,omitempty"`
-	RAGSources []RAGSource `json:"ragSources,omitempty"`
-	TokenUsage *TokenUsage `json:"tokenUsage,omitempty"`
-	LatencyMs  int64       `json:"latencyMs,omitempty"`
-	Reasoning  string      `json:"reasoning,omitempty"`
+	ToolsUsed            []string     `json:"toolsUsed,omitempty"`
+	ToolResults          []ToolResult `json:"toolResults,omitempty"`
+	RAGSources           []RAGSource  `json:"ragSources,omitempty"`
+	TokenUsage           *TokenUsage  `json:"tokenUsage,omitempty"`
+	TokenBudgetRemaining *int         `json:"tokenBudgetRemaining,omitempty"` // saldo de tokens do cliente no dia
+	LatencyMs            int64        `json:"latencyMs,omitempty"`
+	Reasoning            string       `json:"reasoning,omitempty"`
 }
 
 // RAGSource representa uma fonte de documento usada pelo pipeline RAG.
@@ -82,5 +103,8 @@ type InternalAssistantResult struct {
 	CustomerID     string
 	Profile        *CustomerProfile
 	Recommendation *AgentResponse
+	ToolResults    []ToolResult // tools executadas pelo BFA a pedido do agente
 	ProcessedAt    time.Time
+
+	TokenBudgetRemaining *int // tokens que o cliente ainda pode usar hoje; nil sem orçamento
 }
@@ -41,6 +41,49 @@ type LoginResponse struct {
 	CustomerID   string `json:"customerId"`
 	CustomerName string `json:"customerName"`
 	CompanyName  string `json:"companyName"`
+	// LastLoginAt is the previous successful login, null on the first one.
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+}
+
+// TokenIntrospection is returned by GET /v1/auth/introspect. An invalid or
+// expired token yields only Active false.
+type TokenIntrospection struct {
+	Active     bool       `json:"active"`
+	CustomerID string     `json:"customerId,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	IssuedAt   *time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AuthCompany is a company the authenticated user can operate, as listed
+// by GET /v1/auth/companies.
+type AuthCompany struct {
+	CustomerID  string `json:"customerId"`
+	CompanyName string `json:"companyName"`
+	Document    string `json:"document"`
+	Role        string `json:"role"`
+	IsDefault   bool   `json:"isDefault"`
+	Active      bool   `json:"active"`
+}
+
+// AuthCompaniesResponse is the body for 200 from GET /v1/auth/companies.
+type AuthCompaniesResponse struct {
+	Companies []AuthCompany `json:"companies"`
+}
+
+// SwitchCompanyRequest is the body for POST /v1/auth/switch-company.
+type SwitchCompanyRequest struct {
+	CustomerID string `json:"customerId"`
+}
+
+// SwitchCompanyResponse is the body for 200 from POST /v1/auth/switch-company.
+// Only the access token is reissued; the refresh token keeps the login company.
+type SwitchCompanyResponse struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+	CustomerID  string `json:"customerId"`
+	CompanyName string `json:"companyName"`
+	Role        string `json:"role"`
 }
 
 // RefreshRequest is the body for POST /v1/auth/refresh.
@@ -129,6 +172,7 @@ type AuthRefreshToken struct {
 	TokenHash  string    `json:"token_hash"`
 	ExpiresAt  time.Time `json:"expires_at"`
 	Revoked    bool      `json:"revoked"`
+	CreatedAt  time.Time `json:"created_at"` // issue time; rotation issues a new token on every refresh
 }
 
 // AuthPasswordResetCode represents a password reset verification code.
@@ -138,4 +182,6 @@ type AuthPasswordResetCode struct {
 	Code       string    `json:"code"`
 	ExpiresAt  time.Time `json:"expires_at"`
 	Used       bool      `json:"used"`
+	Attempts   int       `json:"attempts"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -112,7 +112,7 @@ type BarcodeValidationAPIResponse struct {
 type BillPaymentAPIRequest struct {
 	CustomerID  string `json:"customerId"`
 	Barcode     string `json:"barcode"`
-	InputMethod string `json:"inputMethod"` // camera, typed, pasted
+	InputMethod string `json:"inputMethod"` // camera (camera_scan), typed, pasted, file_upload
 	PaymentDate string `json:"paymentDate,omitempty"`
 }
 
@@ -144,3 +144,17 @@ type DebitPurchaseResponse struct {
 	NewBalance    float64 `json:"newBalance"`
 	Timestamp     string  `json:"timestamp"`
 }
+
+// DebitRefundRequest is the body for POST /v1/debit/{transactionId}/refund.
+type DebitRefundRequest struct {
+	CustomerID string `json:"customerId"`
+}
+
+// DebitRefundResponse is returned by POST /v1/debit/{transactionId}/refund.
+type DebitRefundResponse struct {
+	RefundID              string  `json:"refundId"` // statement entry crediting the amount back
+	OriginalTransactionID string  `json:"originalTransactionId"`
+	Status                string  `json:"status"` // refunded
+	Amount                float64 `json:"amount"`
+	Timestamp             string  `json:"timestamp"`
+}
@@ -213,6 +213,19 @@ type CreditCardInvoiceAPIResponse struct {
 	Transactions   []InvoiceTransactionResponse `json:"transactions"`
 }
 
+// CreditCardInvoiceListItem is one entry of
+// GET /v1/customers/{id}/credit-cards/{cardId}/invoices.
+type CreditCardInvoiceListItem struct {
+	ID             string   `json:"id"`
+	CardID         string   `json:"cardId"`
+	ReferenceMonth string   `json:"referenceMonth"`
+	TotalAmount    float64  `json:"totalAmount"`
+	MinimumPayment float64  `json:"minimumPayment"`
+	PaidAmount     *float64 `json:"paidAmount,omitempty"`
+	DueDate        string   `json:"dueDate"`
+	Status         string   `json:"status"`
+}
+
 // InvoiceTransactionResponse is a transaction within an invoice.
 type InvoiceTransactionResponse struct {
 	ID                string   `json:"id"`
@@ -230,7 +243,7 @@ type InvoiceTransactionResponse struct {
 // InvoicePayRequest is the body for paying a credit card invoice.
 type InvoicePayRequest struct {
 	Amount      float64 `json:"amount"`
-	PaymentType string  `json:"paymentType"` // total, minimum, custom
+	PaymentType string  `json:"paymentType"` // total, minimum, custom, prepay
 }
 
 // InvoicePayResponse is returned after paying a credit card invoice.
@@ -240,4 +253,8 @@ type InvoicePayResponse struct {
 	Amount           float64 `json:"amount"`
 	PaidAt           string  `json:"paidAt"`
 	NewInvoiceStatus string  `json:"newInvoiceStatus"`
+	RemainingBalance float64 `json:"remainingBalance"`
+	LateFeeApplied   float64 `json:"lateFeeApplied"`           // multa + juros de mora, quando paga após o vencimento
+	NextDueDate      string  `json:"nextDueDate,omitempty"`    // vencimento do saldo remanescente
+	AvailableLimit   float64 `json:"availableLimit,omitempty"` // limite disponível após uma antecipação (prepay)
 }
@@ -53,3 +53,19 @@ type UserCompany struct {
 	IsDefault   bool     `json:"is_default"`
 	Permissions []string `json:"permissions"`
 }
+
+// Permissions carried in the access token and required by mutation routes.
+// Owners and admins hold PermissionAll; other roles hold the permissions
+// listed in user_companies.permissions.
+const (
+	PermissionAll              = "*"
+	PermissionPixTransfer      = "pix:transfer"
+	PermissionCardBlock        = "card:block"
+	PermissionBillPay          = "bill:pay"
+	PermissionInternalTransfer = "transfer:internal"
+	PermissionDOCTransfer      = "transfer:doc"
+	PermissionCardManage       = "card:manage"
+	PermissionDebitPurchase    = "debit:purchase"
+	PermissionInvoicePay       = "invoice:pay"
+	PermissionLimitsUpdate     = "limits:update"
+)
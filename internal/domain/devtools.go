@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 /*
  * Dev Tools — endpoints for development/testing
  */
@@ -8,13 +10,27 @@ package domain
 type DevAddBalanceRequest struct {
 	CustomerID string  `json:"customerId"`
 	Amount     float64 `json:"amount"`
+
+	// IdempotencyKey, when set, applies the adjustment once: a retry with
+	// the same key returns the first result without crediting again.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
 
 // DevAddBalanceResponse is returned by POST /v1/dev/add-balance.
 type DevAddBalanceResponse struct {
-	Success    bool    `json:"success"`
-	NewBalance float64 `json:"newBalance"`
-	Message    string  `json:"message"`
+	Success        bool    `json:"success"`
+	NewBalance     float64 `json:"newBalance"`
+	Message        string  `json:"message"`
+	AlreadyApplied bool    `json:"alreadyApplied,omitempty"` // the idempotency key was processed before
+}
+
+// DevBalanceAdjustment records a dev add-balance call made with an
+// idempotency key. NewBalance is nil while the adjustment is being applied.
+type DevBalanceAdjustment struct {
+	IdempotencyKey string   `json:"idempotency_key"`
+	CustomerID     string   `json:"customer_id"`
+	Amount         float64  `json:"amount"`
+	NewBalance     *float64 `json:"new_balance"`
 }
 
 // DevSetCreditLimitRequest is the body for POST /v1/dev/set-credit-limit.
@@ -38,6 +54,13 @@ type DevGenerateTransactionsRequest struct {
 	Count      int    `json:"count"`
 	Months     int    `json:"months"` // how many months back to spread transactions (default 1, max 12)
 	Period     string `json:"period"` // "current-month" or "last-12-months" (overrides months if set)
+
+	// WithReceipts also stores a pix_receipts row for each generated
+	// pix_sent/pix_received transaction, as a real transfer does.
+	WithReceipts bool `json:"withReceipts"`
+	// UpdateLimits adds the generated pix_sent amounts to the "pix" limit
+	// usage: daily_used for today's rows, monthly_used for this month's.
+	UpdateLimits bool `json:"updateLimits"`
 }
 
 // DevGenerateTransactionsResponse is returned by POST /v1/dev/generate-transactions.
@@ -48,10 +71,27 @@ type DevGenerateTransactionsResponse struct {
 	Expenses     float64       `json:"expenses"`
 	NetImpact    float64       `json:"netImpact"`
 	NewBalance   float64       `json:"newBalance"`
+	Receipts     int           `json:"receipts,omitempty"`
 	Message      string        `json:"message"`
 	Transactions []Transaction `json:"transactions"`
 }
 
+// DevResetCustomerRequest is the body for POST /v1/dev/reset-customer.
+type DevResetCustomerRequest struct {
+	CustomerID string  `json:"customerId"`
+	Balance    float64 `json:"balance"` // primary account balance after the reset (default 0)
+}
+
+// DevResetCustomerResponse is returned by POST /v1/dev/reset-customer.
+type DevResetCustomerResponse struct {
+	Success      bool           `json:"success"`
+	Cleared      map[string]int `json:"cleared"` // rows removed per table
+	TotalCleared int            `json:"totalCleared"`
+	CardsReset   int            `json:"cardsReset"`
+	NewBalance   float64        `json:"newBalance"`
+	Message      string         `json:"message"`
+}
+
 // DevAddCardPurchaseRequest is the body for POST /v1/dev/add-card-purchase.
 type DevAddCardPurchaseRequest struct {
 	CustomerID  string  `json:"customerId"`
@@ -69,3 +109,29 @@ type DevAddCardPurchaseResponse struct {
 	TotalAmount float64 `json:"totalAmount"`
 	Message     string  `json:"message"`
 }
+
+// ReconciliationReport is returned by GET /v1/dev/reconcile/{customerId}.
+// Delta is the stored balance minus the expected one (opening balance plus
+// every confirmed statement entry); anything other than zero is drift.
+type ReconciliationReport struct {
+	CustomerID          string               `json:"customerId"`
+	AccountID           string               `json:"accountId"`
+	OpeningBalance      float64              `json:"openingBalance"`
+	TransactionsTotal   float64              `json:"transactionsTotal"`
+	TransactionCount    int                  `json:"transactionCount"`
+	ExpectedBalance     float64              `json:"expectedBalance"`
+	StoredBalance       float64              `json:"storedBalance"`
+	Delta               float64              `json:"delta"`
+	Balanced            bool                 `json:"balanced"`
+	SuspiciousTransfers []SuspiciousTransfer `json:"suspiciousTransfers"`
+}
+
+// SuspiciousTransfer is a completed PIX transfer missing the records its
+// execution should have left behind.
+type SuspiciousTransfer struct {
+	TransferID string    `json:"transferId"`
+	Amount     float64   `json:"amount"`
+	FundedBy   string    `json:"fundedBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Reasons    []string  `json:"reasons"` // missing_statement_entry, missing_receipt
+}
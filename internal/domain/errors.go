@@ -1,6 +1,9 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Error types for consistent error handling across the BFA.
 
@@ -28,6 +31,22 @@ func (e *ErrExternalService) Unwrap() error {
 	return e.Err
 }
 
+// ErrMalformedResponse indicates an external service answered but its body
+// could not be decoded. Body holds the start of the raw body for logging.
+type ErrMalformedResponse struct {
+	Service string
+	Body    string
+	Err     error
+}
+
+func (e *ErrMalformedResponse) Error() string {
+	return fmt.Sprintf("malformed response from %s: %v", e.Service, e.Err)
+}
+
+func (e *ErrMalformedResponse) Unwrap() error {
+	return e.Err
+}
+
 // ErrTimeout indicates an operation exceeded its deadline.
 type ErrTimeout struct {
 	Operation string
@@ -39,7 +58,8 @@ func (e *ErrTimeout) Error() string {
 
 // ErrCircuitOpen indicates the circuit breaker is open.
 type ErrCircuitOpen struct {
-	Service string
+	Service string    // breaker name
+	ResetAt time.Time // estimated moment the breaker lets requests through again
 }
 
 func (e *ErrCircuitOpen) Error() string {
@@ -135,9 +155,56 @@ func (e *ErrConflict) Error() string {
 	return e.Message
 }
 
+// ErrInvalidState indicates an operation the resource's current status does
+// not allow (e.g. cancelling a bill that was already paid).
+type ErrInvalidState struct {
+	Resource string
+	Status   string
+	Action   string
+}
+
+func (e *ErrInvalidState) Error() string {
+	return fmt.Sprintf("cannot %s %s with status '%s'", e.Action, e.Resource, e.Status)
+}
+
 // ErrInvalidCode indicates an invalid or expired verification code.
 type ErrInvalidCode struct{}
 
 func (e *ErrInvalidCode) Error() string {
 	return "Código inválido ou expirado"
 }
+
+// ErrRateLimited indicates the operation was attempted too often; it may be
+// retried after RetryAfter.
+type ErrRateLimited struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "too many requests"
+}
+
+// ErrServiceUnavailable indicates the subsystem behind an operation is not
+// configured (e.g. Supabase credentials missing).
+type ErrServiceUnavailable struct {
+	Service string
+	Reason  string
+}
+
+func (e *ErrServiceUnavailable) Error() string {
+	return fmt.Sprintf("%s service unavailable: %s", e.Service, e.Reason)
+}
+
+// ErrConfirmationRequired indicates a high-value PIX transfer that must be
+// confirmed by re-submitting it with the returned token. It is not a failure.
+type ErrConfirmationRequired struct {
+	Confirmation *PixConfirmation
+}
+
+func (e *ErrConfirmationRequired) Error() string {
+	return "confirmation required"
+}
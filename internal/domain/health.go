@@ -6,16 +6,24 @@ package domain
 
 // HealthStatus is returned by GET /healthz.
 type HealthStatus struct {
-	Status   string          `json:"status"` // healthy, degraded, unhealthy
-	Services []ServiceHealth `json:"services"`
+	Status        string          `json:"status"` // healthy, degraded, unhealthy
+	UptimeSeconds int64           `json:"uptimeSeconds"`
+	Services      []ServiceHealth `json:"services"`
+}
+
+// ReadinessStatus is returned by GET /readyz.
+type ReadinessStatus struct {
+	Status   string          `json:"status"`           // ready, not_ready
+	Failed   []string        `json:"failed,omitempty"` // dependencies that failed the probe
+	Services []ServiceHealth `json:"services,omitempty"`
 }
 
 // ServiceHealth represents the health of an individual service.
 type ServiceHealth struct {
 	Name          string  `json:"name"`
 	Status        string  `json:"status"`
-	LatencyMs     int64   `json:"latencyMs"`
-	UptimePercent float64 `json:"uptimePercent"`
+	LatencyMs     int64   `json:"latencyMs"`     // last probe, or rolling average for bfa-api
+	UptimePercent float64 `json:"uptimePercent"` // share of successful outcomes in the rolling window
 	LastChecked   string  `json:"lastChecked"`
 }
 
@@ -31,7 +39,8 @@ type AgentMetrics struct {
 	EstimatedCostUsd    float64 `json:"estimatedCostUsd"`
 	RAGPrecision        float64 `json:"ragPrecision"`
 	CacheHitRate        float64 `json:"cacheHitRate"`
-	Period              string  `json:"period"`
+	Period              string  `json:"period"`        // counters (requests, tokens, rates)
+	LatencyPeriod       string  `json:"latencyPeriod"` // window of the latency fields, e.g. "last_1h"
 }
 
 /*
@@ -47,6 +56,21 @@ type ListResponse[T any] struct {
 	HasMore  bool `json:"has_more"`
 }
 
+// NewListResponse wraps one page of rows together with the total number of
+// rows across all pages. A nil page is returned as an empty list.
+func NewListResponse[T any](rows []T, total, page, pageSize int) *ListResponse[T] {
+	if rows == nil {
+		rows = []T{}
+	}
+	return &ListResponse[T]{
+		Data:     rows,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  page*pageSize < total,
+	}
+}
+
 // SuccessResponse wraps a successful single-entity response.
 type SuccessResponse struct {
 	Message string `json:"message"`
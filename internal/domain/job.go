@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+/*
+ * Background jobs
+ */
+
+// Job states.
+const (
+	JobStatusQueued  = "queued"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// JobTypeStatementExport generates a statement file for download.
+const JobTypeStatementExport = "statement_export"
+
+// Job is work accepted by a request and finished in the background. Clients
+// poll it through GET /v1/customers/{customerId}/jobs/{jobId}.
+type Job struct {
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	CustomerID string                  `json:"customerId"`
+	Status     string                  `json:"status"` // queued, running, done, failed
+	Request    *StatementExportRequest `json:"request,omitempty"`
+	Result     *StatementExportResult  `json:"result,omitempty"` // set once done
+	Error      string                  `json:"error,omitempty"`  // set once failed
+	CreatedAt  time.Time               `json:"createdAt"`
+	UpdatedAt  time.Time               `json:"updatedAt"`
+}
+
+// Statement export formats.
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+)
+
+// StatementExportRequest is the body for POST /v1/customers/{customerId}/statements/export.
+type StatementExportRequest struct {
+	Format string `json:"format"` // csv (default) or pdf
+	From   string `json:"from"`   // YYYY-MM-DD, default 30 days before To
+	To     string `json:"to"`     // YYYY-MM-DD inclusive, default today
+}
+
+// StatementExportResult is the outcome of a statement_export job.
+type StatementExportResult struct {
+	Rows        int       `json:"rows"` // statement entries in the file
+	DownloadURL string    `json:"downloadUrl"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
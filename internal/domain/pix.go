@@ -8,13 +8,14 @@ import "time"
 
 // PixKey represents a registered PIX key.
 type PixKey struct {
-	ID         string    `json:"id"`
-	AccountID  string    `json:"account_id"`
-	CustomerID string    `json:"customer_id"`
-	KeyType    string    `json:"key_type"` // cpf, cnpj, email, phone, random
-	KeyValue   string    `json:"key_value"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         string     `json:"id"`
+	AccountID  string     `json:"account_id"`
+	CustomerID string     `json:"customer_id"`
+	KeyType    string     `json:"key_type"` // cpf, cnpj, email, phone, random
+	KeyValue   string     `json:"key_value"`
+	Status     string     `json:"status"` // active, deleted, ...
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
 
 /*
@@ -34,9 +35,10 @@ type PixTransferRequest struct {
 	FundedBy               string  `json:"funded_by,omitempty"` // "balance" or "credit_card"
 	CreditCardID           string  `json:"credit_card_id,omitempty"`
 	CreditCardInstallments int     `json:"credit_card_installments,omitempty"`
-	FeeRate                float64 `json:"fee_rate,omitempty"`        // e.g. 0.02 for 2% per installment
+	FeeRate                float64 `json:"fee_rate,omitempty"`        // set by the service from its configured rate
 	TotalWithFees          float64 `json:"total_with_fees,omitempty"` // amount * (1 + feeRate*(installments-1))
 	ScheduledFor           string  `json:"scheduled_for,omitempty"`   // RFC3339 or empty for immediate
+	ConfirmationToken      string  `json:"confirmation_token,omitempty"`
 }
 
 // PixTransfer represents a PIX transfer record.
@@ -60,9 +62,54 @@ type PixTransfer struct {
 	ScheduledFor           *time.Time `json:"scheduled_for,omitempty"`
 	ExecutedAt             *time.Time `json:"executed_at,omitempty"`
 	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
 	ReceiptID              string     `json:"receipt_id,omitempty"` // set in memory after receipt creation
+	Warnings               []string   `json:"warnings,omitempty"`   // set in memory by the risk assessment
+}
+
+// PixTransferFilter narrows GET /v1/customers/{id}/pix/transfers. From and
+// To are inclusive dates (YYYY-MM-DD) matched against the creation date.
+type PixTransferFilter struct {
+	Status   string
+	From     string
+	To       string
+	Page     int
+	PageSize int
+}
+
+// PixTransferListItem is one transfer in GET /v1/customers/{id}/pix/transfers.
+type PixTransferListItem struct {
+	TransactionID string        `json:"transactionId"`
+	Status        string        `json:"status"`
+	Amount        float64       `json:"amount"`
+	Description   string        `json:"description,omitempty"`
+	FundedBy      string        `json:"fundedBy"`
+	Recipient     *PixRecipient `json:"recipient"`
+	E2EID         string        `json:"e2eId,omitempty"`
+	CreatedAt     string        `json:"createdAt"`
+	ScheduledFor  string        `json:"scheduledFor,omitempty"`
+	ExecutedAt    string        `json:"executedAt,omitempty"`
 }
 
+// PixRecentRecipient is one entry of GET
+// /v1/customers/{id}/pix/recent-recipients: a recipient the customer paid by
+// PIX, with the key to pay them again.
+type PixRecentRecipient struct {
+	Name         string    `json:"name"`
+	Document     string    `json:"document"` // always masked
+	KeyType      string    `json:"keyType"`
+	KeyValue     string    `json:"keyValue"`
+	LastAmount   float64   `json:"lastAmount"`
+	LastPaidAt   time.Time `json:"lastPaidAt"`
+	PaymentCount int       `json:"paymentCount"` // among the transfers scanned
+}
+
+// PIX transfer risk warnings. They flag a transfer for the client to
+// confirm with the user; they never block it.
+const (
+	PixWarningNewRecipientHighValue = "new_recipient_high_value"
+)
+
 // PixReceipt represents a Pix transfer receipt (comprovante).
 type PixReceipt struct {
 	ID                string  `json:"id"`
@@ -110,6 +157,8 @@ type ScheduledTransferRequest struct {
 	DestinationAcctType string  `json:"destination_account_type"`
 	DestinationName     string  `json:"destination_name"`
 	DestinationDocument string  `json:"destination_document"`
+	DestinationKeyType  string  `json:"destination_key_type,omitempty"`  // PIX schedules
+	DestinationKeyValue string  `json:"destination_key_value,omitempty"` // PIX schedules
 	Amount              float64 `json:"amount"`
 	Description         string  `json:"description,omitempty"`
 	ScheduleType        string  `json:"schedule_type"`  // once, daily, weekly, biweekly, monthly
@@ -131,13 +180,17 @@ type ScheduledTransfer struct {
 	DestinationAcctType string     `json:"destination_account_type"`
 	DestinationName     string     `json:"destination_name"`
 	DestinationDocument string     `json:"destination_document"`
+	DestinationKeyType  string     `json:"destination_key_type,omitempty"`
+	DestinationKeyValue string     `json:"destination_key_value,omitempty"`
 	Amount              float64    `json:"amount"`
 	Description         string     `json:"description,omitempty"`
 	ScheduleType        string     `json:"schedule_type"`
 	ScheduledDate       string     `json:"scheduled_date"`
 	NextExecutionDate   string     `json:"next_execution_date,omitempty"`
+	RecurrenceEndDate   string     `json:"recurrence_end_date,omitempty"`
 	RecurrenceCount     int        `json:"recurrence_count"`
 	MaxRecurrences      *int       `json:"max_recurrences,omitempty"`
+	SkippedDates        []string   `json:"skipped_dates,omitempty"` // occurrences skipped by the customer
 	Status              string     `json:"status"`
 	FailureReason       string     `json:"failure_reason,omitempty"`
 	LastExecutedAt      *time.Time `json:"last_executed_at,omitempty"`
@@ -168,6 +221,13 @@ type PixKeyInfo struct {
 type PixKeyLookupResponse struct {
 	Recipient *PixRecipient `json:"recipient"`
 	KeyType   string        `json:"keyType"`
+	Fees      *PixFees      `json:"fees,omitempty"`
+}
+
+// PixFees is returned by GET /v1/pix/fees. Balance-funded PIX is free.
+type PixFees struct {
+	CreditCardFeeRate         float64 `json:"creditCardFeeRate"` // per installment beyond the first (0.02 = 2%)
+	CreditCardMaxInstallments int     `json:"creditCardMaxInstallments"`
 }
 
 // PixTransferResponse is returned by POST /v1/pix/transfer.
@@ -180,6 +240,8 @@ type PixTransferResponse struct {
 	Timestamp     string        `json:"timestamp"`
 	E2EID         string        `json:"e2eId"`
 	ReceiptID     string        `json:"receiptId,omitempty"`
+	ScheduledFor  string        `json:"scheduledFor,omitempty"`
+	Warnings      []string      `json:"warnings,omitempty"`
 }
 
 // PixScheduleRequest is the body for POST /v1/pix/schedule.
@@ -195,8 +257,16 @@ type PixScheduleRequest struct {
 
 // ScheduleRecurrence specifies recurring schedule details.
 type ScheduleRecurrence struct {
-	Type    string `json:"type"` // weekly, monthly
-	EndDate string `json:"endDate,omitempty"`
+	Type           string `json:"type"` // daily, weekly, biweekly, monthly
+	EndDate        string `json:"endDate,omitempty"`
+	MaxRecurrences *int   `json:"maxRecurrences,omitempty"`
+}
+
+// SchedulePreview is returned by POST /v1/pix/schedule/preview.
+type SchedulePreview struct {
+	ScheduleType string   `json:"scheduleType"`
+	Occurrences  []string `json:"occurrences"`         // execution dates, YYYY-MM-DD
+	Truncated    bool     `json:"truncated,omitempty"` // open-ended schedule cut at the listing limit
 }
 
 // PixScheduleResponse is returned by schedule endpoints.
@@ -205,19 +275,97 @@ type PixScheduleResponse struct {
 	Status        string              `json:"status"`
 	Amount        float64             `json:"amount"`
 	ScheduledDate string              `json:"scheduledDate"`
+	NextExecution string              `json:"nextExecutionDate,omitempty"`
+	SkippedDates  []string            `json:"skippedDates,omitempty"`
 	Recipient     *PixRecipient       `json:"recipient"`
 	Recurrence    *ScheduleRecurrence `json:"recurrence,omitempty"`
 }
 
 // PixCreditCardRequest is the body for POST /v1/pix/credit-card.
 type PixCreditCardRequest struct {
-	CustomerID       string  `json:"customerId"`
-	CreditCardID     string  `json:"creditCardId"`
-	RecipientKey     string  `json:"recipientKey"`
-	RecipientKeyType string  `json:"recipientKeyType"`
-	Amount           float64 `json:"amount"`
-	Installments     int     `json:"installments"`
-	Description      string  `json:"description,omitempty"`
+	CustomerID        string  `json:"customerId"`
+	CreditCardID      string  `json:"creditCardId"`
+	RecipientKey      string  `json:"recipientKey"`
+	RecipientKeyType  string  `json:"recipientKeyType"`
+	Amount            float64 `json:"amount"`
+	Installments      int     `json:"installments"`
+	Description       string  `json:"description,omitempty"`
+	ConfirmationToken string  `json:"confirmationToken,omitempty"`
+	IdempotencyKey    string  `json:"idempotencyKey,omitempty"` // retries with the same key return the original transfer
+}
+
+// PixCreditCardQuoteRequest is the body for POST /v1/pix/credit-card/quote.
+type PixCreditCardQuoteRequest struct {
+	CustomerID   string  `json:"customerId"`
+	CreditCardID string  `json:"creditCardId"`
+	Amount       float64 `json:"amount"`
+	Installments int     `json:"installments"`
+}
+
+// PixCreditCardQuote is the fee and installment breakdown of a PIX via credit
+// card, computed as POST /v1/pix/credit-card would charge it but without
+// debiting anything.
+type PixCreditCardQuote struct {
+	CanProceed       bool                   `json:"canProceed"`
+	Reason           string                 `json:"reason,omitempty"`     // why the transfer would be refused
+	ReasonCode       string                 `json:"reasonCode,omitempty"` // validation, limit_exceeded
+	Amount           float64                `json:"amount"`
+	Installments     int                    `json:"installments"`
+	FeeRate          float64                `json:"feeRate"`
+	FeeAmount        float64                `json:"feeAmount"`
+	TotalWithFees    float64                `json:"totalWithFees"`
+	InstallmentValue float64                `json:"installmentValue"`
+	Schedule         []PixCreditInstallment `json:"schedule"`
+}
+
+// PixCreditInstallment is one installment of a PIX via credit card, charged
+// on the card invoice due on DueDate (YYYY-MM-DD).
+type PixCreditInstallment struct {
+	Number  int     `json:"number"`
+	Amount  float64 `json:"amount"`
+	DueDate string  `json:"dueDate"`
+}
+
+// PixConfirmation is returned (202) instead of executing a PIX transfer above
+// the confirmation threshold. Re-submitting the same transfer with
+// ConfirmationToken before ExpiresAt executes it; the token is single use.
+type PixConfirmation struct {
+	ConfirmationToken string        `json:"confirmationToken"`
+	ExpiresAt         string        `json:"expiresAt"`
+	Amount            float64       `json:"amount"`
+	TotalWithFees     float64       `json:"totalWithFees"`
+	FundedBy          string        `json:"fundedBy"`
+	Installments      int           `json:"installments,omitempty"`
+	Recipient         *PixRecipient `json:"recipient"`
+	Warnings          []string      `json:"warnings,omitempty"`
+}
+
+// PixConfirmationToken is an issued confirmation token and the transfer it
+// was issued for. Status moves issued → claimed while the transfer runs,
+// then to used once it commits (or back to issued when it fails).
+type PixConfirmationToken struct {
+	Token       string    `json:"token"`
+	CustomerID  string    `json:"customer_id"`
+	Fingerprint string    `json:"fingerprint"`
+	Status      string    `json:"status"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PixTransferValidation is returned by POST /v1/pix/transfer/validate, a dry
+// run of POST /v1/pix/transfer that neither persists nor debits.
+type PixTransferValidation struct {
+	CanProceed           bool          `json:"canProceed"`
+	Reason               string        `json:"reason,omitempty"`     // why the transfer would be refused
+	ReasonCode           string        `json:"reasonCode,omitempty"` // validation, insufficient_funds, limit_exceeded, account_blocked
+	Amount               float64       `json:"amount"`
+	FeeRate              float64       `json:"feeRate"`
+	Fees                 float64       `json:"fees"`
+	TotalWithFees        float64       `json:"totalWithFees"`
+	FundedBy             string        `json:"fundedBy"`
+	Installments         int           `json:"installments,omitempty"`
+	RequiresConfirmation bool          `json:"requiresConfirmation"`
+	Recipient            *PixRecipient `json:"recipient,omitempty"`
+	Warnings             []string      `json:"warnings,omitempty"`
 }
 
 // PixCreditCardResponse is returned by POST /v1/pix/credit-card.
@@ -229,6 +377,7 @@ type PixCreditCardResponse struct {
 	Recipient     *PixRecipient `json:"recipient"`
 	Timestamp     string        `json:"timestamp"`
 	ReceiptID     string        `json:"receiptId,omitempty"`
+	Warnings      []string      `json:"warnings,omitempty"`
 }
 
 // PixReceiptResponse is the formatted receipt (comprovante) returned to the frontend.
@@ -249,6 +398,15 @@ type PixReceiptResponse struct {
 	CreatedAt   string           `json:"createdAt"`
 }
 
+// ReceiptShareToken is returned by GET /v1/pix/receipts/{receiptId}/share-token.
+// The token grants unauthenticated, read-only access to a redacted receipt
+// via GET /v1/pix/receipts/shared/{token} until ExpiresAt.
+type ReceiptShareToken struct {
+	ReceiptID string    `json:"receiptId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
 // PixReceiptParty represents a sender or recipient in a receipt.
 type PixReceiptParty struct {
 	Name     string `json:"name"`
@@ -260,9 +418,10 @@ type PixReceiptParty struct {
 
 // PixKeyRegisterRequest is the body for POST /v1/pix/keys/register.
 type PixKeyRegisterRequest struct {
-	CustomerID string `json:"customerId"`
-	KeyType    string `json:"keyType"`  // cnpj, email, phone, random
-	KeyValue   string `json:"keyValue"` // empty for random
+	CustomerID       string `json:"customerId"`
+	KeyType          string `json:"keyType"`                    // cnpj, email, phone, random
+	KeyValue         string `json:"keyValue"`                   // empty for random
+	VerificationCode string `json:"verificationCode,omitempty"` // required for email/phone
 }
 
 // PixKeyRegisterResponse is returned by POST /v1/pix/keys/register.
@@ -274,3 +433,29 @@ type PixKeyRegisterResponse struct {
 	Status    string `json:"status"`
 	CreatedAt string `json:"createdAt"`
 }
+
+// PixKeyVerifyRequest is the body for POST /v1/pix/keys/verify-request.
+// It asks for an ownership code to be sent to the email/phone being registered.
+type PixKeyVerifyRequest struct {
+	CustomerID string `json:"customerId"`
+	KeyType    string `json:"keyType"` // email, phone
+	KeyValue   string `json:"keyValue"`
+}
+
+// PixKeyVerifyResponse is returned by POST /v1/pix/keys/verify-request.
+type PixKeyVerifyResponse struct {
+	Message     string `json:"message"`
+	Destination string `json:"destination"` // masked email/phone
+	ExpiresIn   int    `json:"expiresIn"`   // seconds
+}
+
+// PixKeyVerificationCode is an ownership code for an email/phone PIX key.
+type PixKeyVerificationCode struct {
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	KeyType    string    `json:"key_type"`
+	KeyValue   string    `json:"key_value"`
+	Code       string    `json:"code"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Used       bool      `json:"used"`
+}
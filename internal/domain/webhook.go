@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+/*
+ * Webhooks (notificações push para integradores)
+ */
+
+// Webhook event types.
+const (
+	EventPixTransferCompleted = "pix.transfer.completed"
+	EventBillPaid             = "bill.paid"
+	EventInvoicePaid          = "invoice.paid"
+)
+
+// WebhookEventTypes lists every event type a webhook can subscribe to.
+var WebhookEventTypes = []string{EventPixTransferCompleted, EventBillPaid, EventInvoicePaid}
+
+// Webhook is a customer-configured endpoint that receives event notifications.
+type Webhook struct {
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"` // shared HMAC secret, only returned on registration
+	Events     []string  `json:"events"`           // empty = all events
+	Status     string    `json:"status"`           // active, revoked
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook should receive the given event type.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRegisterRequest is the body for POST /v1/customers/{customerId}/webhooks.
+type WebhookRegisterRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"` // generated when empty
+}
+
+// WebhookEvent is the JSON payload POSTed to webhook endpoints.
+type WebhookEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	CustomerID string    `json:"customer_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// WebhookDelivery is the log entry of one event delivery (including retries)
+// to a webhook. Payload holds the exact signed body, so it can be resent.
+type WebhookDelivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhook_id"`
+	CustomerID   string    `json:"customer_id"`
+	EventID      string    `json:"event_id"`
+	EventType    string    `json:"event_type"`
+	Payload      string    `json:"payload"`
+	Status       string    `json:"status"`        // delivered, failed
+	ResponseCode int       `json:"response_code"` // last HTTP status (0 = no response)
+	Attempts     int       `json:"attempts"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
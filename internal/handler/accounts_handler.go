@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -18,6 +19,18 @@ func listAccountsHandler(svc *service.BankingService, logger *zap.Logger) http.H
 		ctx, span := tracer.Start(r.Context(), "GET /accounts")
 		defer span.End()
 		customerID := chi.URLParam(r, "customerId")
+
+		// ?summary=true wraps the list with totals of the active accounts
+		if r.URL.Query().Get("summary") == "true" {
+			summary, err := svc.GetAccountsSummary(ctx, customerID)
+			if err != nil {
+				handleServiceError(w, err, logger)
+				return
+			}
+			writeJSON(w, http.StatusOK, summary)
+			return
+		}
+
 		accounts, err := svc.ListAccounts(ctx, customerID)
 		if err != nil {
 			handleServiceError(w, err, logger)
@@ -62,3 +75,99 @@ func getBalanceHandler(svc *service.BankingService, logger *zap.Logger) http.Han
 		})
 	}
 }
+
+func getOverdraftHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /accounts/{accountId}/overdraft")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		accountID := chi.URLParam(r, "accountId")
+		overdraft, err := svc.GetOverdraft(ctx, customerID, accountID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, overdraft)
+	}
+}
+
+func setOverdraftHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "PUT /accounts/{accountId}/overdraft")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		accountID := chi.URLParam(r, "accountId")
+
+		var req domain.OverdraftRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.OverdraftLimit == nil {
+			writeError(w, http.StatusBadRequest, "overdraft_limit is required")
+			return
+		}
+
+		overdraft, err := svc.SetOverdraftLimit(ctx, customerID, accountID, *req.OverdraftLimit)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, overdraft)
+	}
+}
+
+func internalTransferHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/transfers/internal")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+
+		var req domain.InternalTransferRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		transfer, err := svc.CreateInternalTransfer(ctx, customerID, req.FromAccountID, req.ToAccountID, req.Amount)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusCreated, transfer)
+	}
+}
+
+// docTransferHandler sends a DOC. The amount is held right away and the
+// transfer settles on the next business day.
+func docTransferHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/transfers/doc")
+		defer span.End()
+
+		var req domain.DOCTransferRequest
+		if !decodeJSON(w, r, &req) || !authorizeCustomer(w, r, req.CustomerID, logger) {
+			return
+		}
+		req.IdempotencyKey = idempotencyKey(req.IdempotencyKey)
+
+		transfer, err := svc.CreateDOCTransfer(ctx, req.CustomerID, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusCreated, transfer)
+	}
+}
+
+func getTransferReceiptHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/transfers/{transferId}/receipt")
+		defer span.End()
+
+		receipt, err := svc.GetTransferReceiptByTransferID(ctx, chi.URLParam(r, "transferId"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, receipt)
+	}
+}
@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
@@ -35,6 +35,66 @@ func financialSummaryHandler(bankSvc *service.BankingService, logger *zap.Logger
 	}
 }
 
+func spendingDigestHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/analytics/digest")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = domain.DigestPeriodWeekly
+		}
+
+		digest, err := bankSvc.GenerateSpendingDigest(ctx, customerID, period)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, digest)
+	}
+}
+
+func dashboardHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/dashboard")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		dashboard, err := bankSvc.GetDashboard(ctx, customerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, dashboard)
+	}
+}
+
+func activityFeedHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/activity")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			limit = n
+		}
+
+		feed, err := bankSvc.GetActivityFeed(ctx, customerID, limit, r.URL.Query().Get("cursor"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, feed)
+	}
+}
+
 /*
  * Favorites
  */
@@ -59,8 +119,7 @@ func createFavoriteHandler(svc *service.BankingService, logger *zap.Logger) http
 		defer span.End()
 		customerID := chi.URLParam(r, "customerId")
 		var fav domain.Favorite
-		if err := json.NewDecoder(r.Body).Decode(&fav); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &fav) {
 			return
 		}
 		fav.CustomerID = customerID
@@ -105,6 +164,20 @@ func listLimitsHandler(svc *service.BankingService, logger *zap.Logger) http.Han
 	}
 }
 
+func pixLimitsSummaryHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/pix/limits")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		summary, err := svc.GetPixLimitsSummary(ctx, customerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, summary)
+	}
+}
+
 func updateLimitHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "PUT /limits/{limitType}")
@@ -112,8 +185,7 @@ func updateLimitHandler(svc *service.BankingService, logger *zap.Logger) http.Ha
 		customerID := chi.URLParam(r, "customerId")
 		limitType := chi.URLParam(r, "limitType")
 		var limit domain.TransactionLimit
-		if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &limit) {
 			return
 		}
 		limit.CustomerID = customerID
@@ -147,6 +219,22 @@ func listNotificationsHandler(svc *service.BankingService, logger *zap.Logger) h
 	}
 }
 
+func getNotificationHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /notifications/{notifId}")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		notifID := chi.URLParam(r, "notifId")
+		markRead := r.URL.Query().Get("markRead") == "true"
+		notification, err := svc.GetNotification(ctx, customerID, notifID, markRead)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, notification)
+	}
+}
+
 func markNotificationReadHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /notifications/{notifId}/read")
@@ -160,6 +248,56 @@ func markNotificationReadHandler(svc *service.BankingService, logger *zap.Logger
 	}
 }
 
+func markNotificationsReadHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /notifications/read")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		var req domain.NotificationsReadRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		updated, err := svc.MarkNotificationsRead(ctx, customerID, req.IDs)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, domain.NotificationsReadResponse{Updated: updated})
+	}
+}
+
+func getNotificationPreferencesHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /notifications/preferences")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		prefs, err := svc.GetNotificationPreferences(ctx, customerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, prefs)
+	}
+}
+
+func updateNotificationPreferencesHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "PUT /notifications/preferences")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		var update domain.NotificationPreferences
+		if !decodeJSON(w, r, &update) {
+			return
+		}
+		prefs, err := svc.UpdateNotificationPreferences(ctx, customerID, update)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, prefs)
+	}
+}
+
 /*
  * Budgets
  */
@@ -184,8 +322,7 @@ func createBudgetHandler(svc *service.BankingService, logger *zap.Logger) http.H
 		defer span.End()
 		customerID := chi.URLParam(r, "customerId")
 		var budget domain.SpendingBudget
-		if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &budget) {
 			return
 		}
 		budget.CustomerID = customerID
@@ -205,8 +342,7 @@ func updateBudgetHandler(svc *service.BankingService, logger *zap.Logger) http.H
 		customerID := chi.URLParam(r, "customerId")
 		budgetID := chi.URLParam(r, "budgetId")
 		var budget domain.SpendingBudget
-		if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &budget) {
 			return
 		}
 		budget.ID = budgetID
@@ -219,3 +355,38 @@ func updateBudgetHandler(svc *service.BankingService, logger *zap.Logger) http.H
 		writeJSON(w, http.StatusOK, updated)
 	}
 }
+
+func listCategoryRulesHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /analytics/category-rules")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		rules, err := svc.ListCategoryRules(ctx, customerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		if rules == nil {
+			rules = []domain.CategoryRule{}
+		}
+		writeJSON(w, http.StatusOK, rules)
+	}
+}
+
+func upsertCategoryRuleHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "PUT /analytics/category-rules")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		var req domain.CategoryRuleRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		result, err := svc.UpsertCategoryRule(ctx, customerID, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
@@ -33,8 +32,7 @@ func assistantHandler(svc *service.Assistant, logger *zap.Logger) http.HandlerFu
 		span.SetAttributes(attribute.String("customer.id", customerID))
 
 		var req domain.AssistantRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -59,14 +57,16 @@ func assistantHandler(svc *service.Assistant, logger *zap.Logger) http.HandlerFu
 				Content:   result.Recommendation.Answer,
 				Timestamp: time.Now().Format(time.RFC3339),
 				Metadata: &domain.MessageMetadata{
-					ToolsUsed: result.Recommendation.ToolsExecuted,
+					ToolsUsed:   result.Recommendation.ToolsExecuted,
+					ToolResults: result.ToolResults,
 					TokenUsage: &domain.TokenUsage{
 						PromptTokens:     result.Recommendation.TokensUsed.PromptTokens,
 						CompletionTokens: result.Recommendation.TokensUsed.CompletionTokens,
 						TotalTokens:      result.Recommendation.TokensUsed.TotalTokens,
 					},
-					LatencyMs: latencyMs,
-					Reasoning: result.Recommendation.Reasoning,
+					TokenBudgetRemaining: result.TokenBudgetRemaining,
+					LatencyMs:            latencyMs,
+					Reasoning:            result.Recommendation.Reasoning,
 				},
 			},
 			Profile: result.Profile,
@@ -127,14 +127,16 @@ func assistantGetHandler(svc *service.Assistant, logger *zap.Logger) http.Handle
 				Content:   result.Recommendation.Answer,
 				Timestamp: time.Now().Format(time.RFC3339),
 				Metadata: &domain.MessageMetadata{
-					ToolsUsed: result.Recommendation.ToolsExecuted,
+					ToolsUsed:   result.Recommendation.ToolsExecuted,
+					ToolResults: result.ToolResults,
 					TokenUsage: &domain.TokenUsage{
 						PromptTokens:     result.Recommendation.TokensUsed.PromptTokens,
 						CompletionTokens: result.Recommendation.TokensUsed.CompletionTokens,
 						TotalTokens:      result.Recommendation.TokensUsed.TotalTokens,
 					},
-					LatencyMs: latencyMs,
-					Reasoning: result.Recommendation.Reasoning,
+					TokenBudgetRemaining: result.TokenBudgetRemaining,
+					LatencyMs:            latencyMs,
+					Reasoning:            result.Recommendation.Reasoning,
 				},
 			},
 			Profile: result.Profile,
@@ -155,8 +157,7 @@ func chatHandler(svc *service.Assistant, logger *zap.Logger) http.HandlerFunc {
 			Message        string `json:"message"`
 			ConversationID string `json:"conversationId,omitempty"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		if req.CustomerID == "" {
@@ -186,14 +187,16 @@ func chatHandler(svc *service.Assistant, logger *zap.Logger) http.HandlerFunc {
 				Content:   result.Recommendation.Answer,
 				Timestamp: time.Now().Format(time.RFC3339),
 				Metadata: &domain.MessageMetadata{
-					ToolsUsed: result.Recommendation.ToolsExecuted,
+					ToolsUsed:   result.Recommendation.ToolsExecuted,
+					ToolResults: result.ToolResults,
 					TokenUsage: &domain.TokenUsage{
 						PromptTokens:     result.Recommendation.TokensUsed.PromptTokens,
 						CompletionTokens: result.Recommendation.TokensUsed.CompletionTokens,
 						TotalTokens:      result.Recommendation.TokensUsed.TotalTokens,
 					},
-					LatencyMs: latencyMs,
-					Reasoning: result.Recommendation.Reasoning,
+					TokenBudgetRemaining: result.TokenBudgetRemaining,
+					LatencyMs:            latencyMs,
+					Reasoning:            result.Recommendation.Reasoning,
 				},
 			},
 			Profile: result.Profile,
@@ -218,7 +221,7 @@ func getProfileHandler(svc *service.Assistant, logger *zap.Logger) http.HandlerF
 			handleServiceError(w, err, logger)
 			return
 		}
-		writeJSON(w, http.StatusOK, profile)
+		writeJSONWithETag(w, r, http.StatusOK, profile)
 	}
 }
 
@@ -258,11 +261,12 @@ func getTransactionsHandler(svc *service.Assistant, logger *zap.Logger) http.Han
 			}
 		}
 
-		// Filter by category if provided — e.g. ?category=pix,pix_credito
+		// Filter by category if provided — e.g. ?category=pix,pix_credito.
+		// Case and accents are ignored ("Alimentação" matches "alimentacao").
 		if catFilter := r.URL.Query().Get("category"); catFilter != "" {
 			allowedCats := make(map[string]bool)
 			for _, c := range strings.Split(catFilter, ",") {
-				c = strings.TrimSpace(c)
+				c = service.NormalizeCategory(c)
 				if c != "" {
 					allowedCats[c] = true
 				}
@@ -270,7 +274,7 @@ func getTransactionsHandler(svc *service.Assistant, logger *zap.Logger) http.Han
 			if len(allowedCats) > 0 {
 				filtered := make([]domain.Transaction, 0, len(transactions))
 				for _, tx := range transactions {
-					if allowedCats[tx.Category] {
+					if allowedCats[service.NormalizeCategory(tx.Category)] {
 						filtered = append(filtered, tx)
 					}
 				}
@@ -278,6 +282,21 @@ func getTransactionsHandler(svc *service.Assistant, logger *zap.Logger) http.Han
 			}
 		}
 
+		// Filter by direction if provided — ?direction=in (income) or out (expense)
+		if direction := r.URL.Query().Get("direction"); direction != "" {
+			if direction != domain.TransactionDirectionIn && direction != domain.TransactionDirectionOut {
+				writeError(w, http.StatusBadRequest, "direction must be in or out")
+				return
+			}
+			filtered := make([]domain.Transaction, 0, len(transactions))
+			for _, tx := range transactions {
+				if tx.MatchesDirection(direction) {
+					filtered = append(filtered, tx)
+				}
+			}
+			transactions = filtered
+		}
+
 		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 			if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit < len(transactions) {
 				transactions = transactions[:limit]
@@ -302,3 +321,56 @@ func getTransactionsSummaryHandler(bankSvc *service.BankingService, logger *zap.
 		writeJSON(w, http.StatusOK, summary)
 	}
 }
+
+func searchTransactionsHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/transactions/search")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		q := r.URL.Query()
+		filter := &domain.TransactionSearchFilter{
+			Query:     q.Get("q"),
+			Type:      q.Get("type"),
+			Direction: q.Get("direction"),
+		}
+		for param, dst := range map[string]**float64{"minAmount": &filter.MinAmount, "maxAmount": &filter.MaxAmount} {
+			if v := q.Get(param); v != "" {
+				amount, err := strconv.ParseFloat(v, 64)
+				if err != nil || amount < 0 {
+					writeError(w, http.StatusBadRequest, "invalid "+param)
+					return
+				}
+				*dst = &amount
+			}
+		}
+		filter.Page, filter.PageSize = parsePagination(r)
+
+		result, err := bankSvc.SearchTransactions(ctx, customerID, filter)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// annotateTransactionHandler sets the note and tags of a transaction.
+func annotateTransactionHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "PUT /v1/customers/{customerId}/transactions/{transactionId}/annotate")
+		defer span.End()
+
+		var req domain.TransactionAnnotation
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		tx, err := bankSvc.AnnotateTransaction(ctx, chi.URLParam(r, "customerId"), chi.URLParam(r, "transactionId"), &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, tx)
+	}
+}
@@ -0,0 +1,154 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/cache"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+type stubProfile struct{}
+
+func (stubProfile) GetProfile(_ context.Context, customerID string) (*domain.CustomerProfile, error) {
+	return &domain.CustomerProfile{CustomerID: customerID, Name: "Empresa XPTO"}, nil
+}
+
+// failingAgent fails every call the way the agent client reports an outage.
+type failingAgent struct{}
+
+func (failingAgent) Call(context.Context, *domain.AgentRequest) (*domain.AgentResponse, error) {
+	return nil, &domain.ErrExternalService{Service: "agent", Err: errors.New("agent unavailable")}
+}
+
+func TestAssistant_AgentDownServesFallback(t *testing.T) {
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, stubTransactions{}, failingAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	assistant.SetAgentFallback(true)
+	router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/assistant/cust-1?q=como+estao+minhas+financas", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 fallback, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp domain.AssistantResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Message == nil || resp.Message.Content == "" {
+		t.Fatal("expected a fallback answer")
+	}
+	if tools := resp.Message.Metadata.ToolsUsed; len(tools) != 1 || tools[0] != "fallback" {
+		t.Errorf("expected toolsUsed [fallback], got %v", tools)
+	}
+	if rate := metrics.GetAgentSnapshot().FallbackRate; rate != 1 {
+		t.Errorf("expected fallback rate 1, got %v", rate)
+	}
+}
+
+func TestAssistant_AgentDownWithoutFallback(t *testing.T) {
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, stubTransactions{}, failingAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/assistant/cust-1?q=oi", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 with fallback disabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+type statementTransactions []domain.Transaction
+
+func (s statementTransactions) GetTransactions(context.Context, string) ([]domain.Transaction, error) {
+	return s, nil
+}
+
+func TestGetTransactions_FiltersByDirection(t *testing.T) {
+	statement := statementTransactions{
+		{ID: "tx-1", Amount: -150, Type: "pix_sent", Category: "pix"},
+		{ID: "tx-2", Amount: 150, Type: "pix_received", Category: "pix"},
+		{ID: "tx-3", Amount: -80, Type: "debit_purchase", Category: "compras"},
+	}
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, statement, stubAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"direction=out", []string{"tx-1", "tx-3"}},
+		{"direction=in", []string{"tx-2"}},
+		{"direction=out&type=pix_sent,pix_received", []string{"tx-1"}},
+		{"direction=in&category=compras", []string{}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/transactions?"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Transactions []domain.Transaction `json:"transactions"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", tt.query, err)
+		}
+		got := make([]string, 0, len(resp.Transactions))
+		for _, tx := range resp.Transactions {
+			got = append(got, tx.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/transactions?direction=sideways", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid direction: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestAssistant_TokenIsOptionalButChecked(t *testing.T) {
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, stubTransactions{}, stubAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	authSvc := service.NewAuthService(nil, "test-secret", time.Minute, time.Hour, false, zap.NewNop())
+	router := handler.NewRouter(assistant, nil, authSvc, nil, nil, metrics, zap.NewNop())
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"anonymous", "", http.StatusOK},
+		{"invalid token", "Bearer not-a-jwt", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/assistant/cust-1?q=oi", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+}
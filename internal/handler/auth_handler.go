@@ -1,10 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -21,8 +22,7 @@ func authRegisterHandler(authSvc *service.AuthService, logger *zap.Logger) http.
 		defer span.End()
 
 		var req domain.RegisterRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -42,8 +42,7 @@ func authLoginHandler(authSvc *service.AuthService, logger *zap.Logger) http.Han
 		defer span.End()
 
 		var req domain.LoginRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -63,8 +62,7 @@ func authRefreshHandler(authSvc *service.AuthService, logger *zap.Logger) http.H
 		defer span.End()
 
 		var req domain.RefreshRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -98,14 +96,88 @@ func authLogoutHandler(authSvc *service.AuthService, logger *zap.Logger) http.Ha
 	}
 }
 
+// authIntrospectHandler reports whether the Bearer token is an active access
+// token. It sits outside JWTAuthMiddleware so that an expired or invalid
+// token answers active=false instead of 401.
+func authIntrospectHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/auth/introspect")
+		defer span.End()
+
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			writeError(w, http.StatusUnauthorized, i18n.T(ctx, "auth.token_missing"))
+			return
+		}
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			writeError(w, http.StatusUnauthorized, i18n.T(ctx, "auth.token_malformed"))
+			return
+		}
+
+		result := authSvc.IntrospectAccessToken(ctx, parts[1])
+		if !result.Active {
+			logger.Debug("auth: introspected inactive token",
+				zap.String("kid", service.TokenKeyID(parts[1])))
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func authCompaniesHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/auth/companies")
+		defer span.End()
+
+		claims := ClaimsFromContext(ctx)
+		if claims == nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		resp, err := authSvc.ListCompanies(ctx, claims)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func authSwitchCompanyHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/auth/switch-company")
+		defer span.End()
+
+		claims := ClaimsFromContext(ctx)
+		if claims == nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		var req domain.SwitchCompanyRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := authSvc.SwitchCompany(ctx, claims, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func authPasswordResetRequestHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/auth/password/reset-request")
 		defer span.End()
 
 		var req domain.PasswordResetRequestBody
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -119,14 +191,33 @@ func authPasswordResetRequestHandler(authSvc *service.AuthService, logger *zap.L
 	}
 }
 
+func authPasswordResetResendHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/auth/password/reset-resend")
+		defer span.End()
+
+		var req domain.PasswordResetRequestBody
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := authSvc.PasswordResetResend(ctx, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func authPasswordResetConfirmHandler(authSvc *service.AuthService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/auth/password/reset-confirm")
 		defer span.End()
 
 		var req domain.PasswordResetConfirmRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -135,7 +226,7 @@ func authPasswordResetConfirmHandler(authSvc *service.AuthService, logger *zap.L
 			return
 		}
 
-		writeJSON(w, http.StatusOK, domain.SuccessResponse{Message: "Senha redefinida com sucesso"})
+		writeJSON(w, http.StatusOK, domain.SuccessResponse{Message: i18n.T(ctx, "auth.password_reset")})
 	}
 }
 
@@ -151,8 +242,7 @@ func authChangePasswordHandler(authSvc *service.AuthService, logger *zap.Logger)
 		}
 
 		var req domain.ChangePasswordRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -161,7 +251,7 @@ func authChangePasswordHandler(authSvc *service.AuthService, logger *zap.Logger)
 			return
 		}
 
-		writeJSON(w, http.StatusOK, domain.SuccessResponse{Message: "Senha alterada com sucesso"})
+		writeJSON(w, http.StatusOK, domain.SuccessResponse{Message: i18n.T(ctx, "auth.password_changed")})
 	}
 }
 
@@ -177,8 +267,7 @@ func updateProfileHandler(authSvc *service.AuthService, logger *zap.Logger) http
 		customerID := chi.URLParam(r, "customerId")
 
 		var req domain.UpdateProfileRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -200,8 +289,7 @@ func updateRepresentativeHandler(authSvc *service.AuthService, logger *zap.Logge
 		customerID := chi.URLParam(r, "customerId")
 
 		var req domain.UpdateRepresentativeRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// recentCodeAuthStore reports a reset code issued a few seconds ago, so any
+// new code falls within the resend cooldown.
+type recentCodeAuthStore struct {
+	port.AuthStore
+}
+
+func (recentCodeAuthStore) GetCustomerByBankDetails(context.Context, string, string, string) (*domain.CustomerProfile, error) {
+	return &domain.CustomerProfile{CustomerID: "cust-1", Email: "joana@empresa.com.br"}, nil
+}
+
+func (recentCodeAuthStore) ListResetCodesSince(context.Context, string, time.Time) ([]domain.AuthPasswordResetCode, error) {
+	return []domain.AuthPasswordResetCode{{ID: "code-1", CustomerID: "cust-1", CreatedAt: time.Now().Add(-10 * time.Second)}}, nil
+}
+
+func TestRateLimited_429WithRetryAfter(t *testing.T) {
+	authSvc := service.NewAuthService(recentCodeAuthStore{}, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	router := handler.NewRouter(nil, nil, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	body := `{"document":"12345678000190","agencia":"0001","conta":"123456"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/password/reset-resend", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("unexpected Retry-After header %q, want the remaining cooldown in seconds", rec.Header().Get("Retry-After"))
+	}
+}
+
+// newCustomerAuthStore reports no customer for any document, so
+// registration goes on to validate the password.
+type newCustomerAuthStore struct {
+	port.AuthStore
+}
+
+func (newCustomerAuthStore) GetCustomerByDocument(context.Context, string) (*domain.CustomerProfile, error) {
+	return nil, nil
+}
+
+func TestRegister_ValidationMessageFollowsAcceptLanguage(t *testing.T) {
+	authSvc := service.NewAuthService(newCustomerAuthStore{}, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	router := handler.NewRouter(nil, nil, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	for _, tc := range []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "Senha deve ter 6 dígitos"},
+		{"en-US,en;q=0.9", "Password must have 6 digits"},
+	} {
+		body := `{"cnpj":"12345678000190","password":"123"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(body))
+		if tc.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Accept-Language %q: expected 400, got %d: %s", tc.acceptLanguage, rec.Code, rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("Accept-Language %q: body = %s, want %q", tc.acceptLanguage, rec.Body.String(), tc.want)
+		}
+	}
+}
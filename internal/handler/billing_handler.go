@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -16,23 +15,37 @@ import (
  * 6. Pagamento de Boletos
  */
 
+// barcodeInputMethod maps the app's input method names to the stored ones
+// (the app sends "camera" for camera_scan); empty means typed.
+func barcodeInputMethod(method string) string {
+	switch method {
+	case "":
+		return "typed"
+	case "camera":
+		return "camera_scan"
+	}
+	return method
+}
+
 func billsValidateHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/bills/validate")
 		defer span.End()
 
 		var body struct {
-			Barcode string `json:"barcode"`
+			Barcode     string `json:"barcode"`
+			InputMethod string `json:"inputMethod"`
+			ImageBase64 string `json:"imageBase64"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &body) {
 			return
 		}
 
 		valReq := &domain.BarcodeValidationRequest{
-			InputMethod:   "typed",
+			InputMethod:   barcodeInputMethod(body.InputMethod),
 			DigitableLine: body.Barcode,
 			Barcode:       body.Barcode,
+			ImageBase64:   body.ImageBase64,
 		}
 
 		result, err := bankSvc.ValidateBarcode(ctx, valReq)
@@ -76,8 +89,7 @@ func billsPayHandler(bankSvc *service.BankingService, logger *zap.Logger) http.H
 		defer span.End()
 
 		var apiReq domain.BillPaymentAPIRequest
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
 			return
 		}
 
@@ -90,7 +102,7 @@ func billsPayHandler(bankSvc *service.BankingService, logger *zap.Logger) http.H
 		req := &domain.BillPaymentRequest{
 			IdempotencyKey: uuid.New().String(),
 			AccountID:      account.ID,
-			InputMethod:    apiReq.InputMethod,
+			InputMethod:    barcodeInputMethod(apiReq.InputMethod),
 			DigitableLine:  apiReq.Barcode,
 			Barcode:        apiReq.Barcode,
 			ScheduledDate:  apiReq.PaymentDate,
@@ -105,7 +117,7 @@ func billsPayHandler(bankSvc *service.BankingService, logger *zap.Logger) http.H
 		resp := domain.BillPaymentAPIResponse{
 			TransactionID:  payment.ID,
 			Status:         payment.Status,
-			Amount:         payment.FinalAmount,
+			Amount:         service.RoundMoney(payment.FinalAmount),
 			Beneficiary:    payment.BeneficiaryName,
 			DueDate:        payment.DueDate,
 			PaymentDate:    payment.PaymentDate,
@@ -130,12 +142,12 @@ func billsHistoryHandler(bankSvc *service.BankingService, logger *zap.Logger) ht
 			return
 		}
 
-		resp := make([]domain.BillPaymentAPIResponse, 0, len(payments))
-		for _, p := range payments {
-			resp = append(resp, domain.BillPaymentAPIResponse{
+		items := make([]domain.BillPaymentAPIResponse, 0, len(payments.Data))
+		for _, p := range payments.Data {
+			items = append(items, domain.BillPaymentAPIResponse{
 				TransactionID:  p.ID,
 				Status:         p.Status,
-				Amount:         p.FinalAmount,
+				Amount:         service.RoundMoney(p.FinalAmount),
 				Beneficiary:    p.BeneficiaryName,
 				DueDate:        p.DueDate,
 				PaymentDate:    p.PaymentDate,
@@ -143,7 +155,32 @@ func billsHistoryHandler(bankSvc *service.BankingService, logger *zap.Logger) ht
 			})
 		}
 
-		writeJSON(w, http.StatusOK, resp)
+		writeJSON(w, http.StatusOK, domain.NewListResponse(items, payments.Total, payments.Page, payments.PageSize))
+	}
+}
+
+// billCancelHandler cancels a bill that was not paid yet, refunding it when
+// it had been debited.
+func billCancelHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/bills/{billId}/cancel")
+		defer span.End()
+
+		bill, err := bankSvc.CancelBillPayment(ctx, chi.URLParam(r, "customerId"), chi.URLParam(r, "billId"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, domain.BillPaymentAPIResponse{
+			TransactionID:  bill.ID,
+			Status:         bill.Status,
+			Amount:         service.RoundMoney(bill.FinalAmount),
+			Beneficiary:    bill.BeneficiaryName,
+			DueDate:        bill.DueDate,
+			PaymentDate:    bill.PaymentDate,
+			Authentication: bill.IdempotencyKey,
+		})
 	}
 }
 
@@ -151,14 +188,30 @@ func billsHistoryHandler(bankSvc *service.BankingService, logger *zap.Logger) ht
  * 8b. Débito
  */
 
+func debitPurchasesListHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/debit/purchases")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		page, pageSize := parsePagination(r)
+
+		purchases, err := bankSvc.ListDebitPurchases(ctx, customerID, page, pageSize)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, purchases)
+	}
+}
+
 func debitPurchaseHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/debit/purchase")
 		defer span.End()
 
 		var apiReq domain.DebitPurchaseRequest
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
 			return
 		}
 
@@ -170,3 +223,23 @@ func debitPurchaseHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 		writeJSON(w, http.StatusCreated, resp)
 	}
 }
+
+// debitRefundHandler refunds a debit purchase, crediting its amount back.
+func debitRefundHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/debit/{transactionId}/refund")
+		defer span.End()
+
+		var apiReq domain.DebitRefundRequest
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
+			return
+		}
+
+		resp, err := bankSvc.RefundDebitPurchase(ctx, apiReq.CustomerID, chi.URLParam(r, "transactionId"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusCreated, resp)
+	}
+}
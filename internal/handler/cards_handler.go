@@ -2,9 +2,7 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"math"
 	"net/http"
 	"time"
 
@@ -52,7 +50,7 @@ func listCardsHandler(bankSvc *service.BankingService, logger *zap.Logger) http.
 			})
 		}
 
-		writeJSON(w, http.StatusOK, map[string]any{"cards": resp})
+		writeJSONWithETag(w, r, http.StatusOK, map[string]any{"cards": resp})
 	}
 }
 
@@ -78,8 +76,7 @@ func cardRequestHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 		defer span.End()
 
 		var apiReq domain.CreditCardRequestBody
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &apiReq) {
 			return
 		}
 
@@ -118,12 +115,12 @@ func cardRequestHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 			// Validate requested limit against product boundaries
 			if apiReq.RequestedLimit < product.MinLimit {
 				writeError(w, http.StatusBadRequest, fmt.Sprintf(
-					"limite mínimo para %s é R$ %.2f", product.Name, product.MinLimit))
+					"limite mínimo para %s é %s", product.Name, service.FormatBRL(product.MinLimit)))
 				return
 			}
 			if product.MaxLimit > 0 && apiReq.RequestedLimit > product.MaxLimit {
 				writeError(w, http.StatusBadRequest, fmt.Sprintf(
-					"limite máximo para %s é R$ %.2f", product.Name, product.MaxLimit))
+					"limite máximo para %s é %s", product.Name, service.FormatBRL(product.MaxLimit)))
 				return
 			}
 		}
@@ -207,6 +204,66 @@ func cardInvoiceCurrentHandler(bankSvc *service.BankingService, logger *zap.Logg
 	}
 }
 
+func listCardInvoicesHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/credit-cards/{cardId}/invoices")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		cardID := chi.URLParam(r, "cardId")
+
+		invoices, err := bankSvc.ListCardInvoices(ctx, customerID, cardID, r.URL.Query().Get("status"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		resp := make([]domain.CreditCardInvoiceListItem, 0, len(invoices))
+		for _, inv := range invoices {
+			paidAmount := inv.PaidAmount
+			if paidAmount != nil {
+				rounded := service.RoundMoney(*paidAmount)
+				paidAmount = &rounded
+			}
+			resp = append(resp, domain.CreditCardInvoiceListItem{
+				ID:             inv.ID,
+				CardID:         inv.CardID,
+				ReferenceMonth: inv.ReferenceMonth,
+				TotalAmount:    service.RoundMoney(inv.TotalAmount),
+				MinimumPayment: service.RoundMoney(inv.MinimumPayment),
+				PaidAmount:     paidAmount,
+				DueDate:        inv.DueDate,
+				Status:         inv.Status,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"invoices": resp})
+	}
+}
+
+func listCardTransactionsHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/credit-cards/{cardId}/transactions")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		cardID := chi.URLParam(r, "cardId")
+		page, pageSize := parsePagination(r)
+
+		txns, err := bankSvc.ListCardTransactions(ctx, customerID, cardID, page, pageSize)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		items := make([]domain.InvoiceTransactionResponse, 0, len(txns.Data))
+		for _, t := range txns.Data {
+			items = append(items, buildInvoiceTransactionResponse(t))
+		}
+		writeJSON(w, http.StatusOK, domain.NewListResponse(items, txns.Total, txns.Page, txns.PageSize))
+	}
+}
+
 // respondWithInvoice is the shared logic for both invoice endpoints.
 // It fetches the invoice, filters transactions by month, and writes the JSON response.
 func respondWithInvoice(ctx context.Context, w http.ResponseWriter, bankSvc *service.BankingService, logger *zap.Logger, customerID, cardID, month string) {
@@ -223,7 +280,10 @@ func respondWithInvoice(ctx context.Context, w http.ResponseWriter, bankSvc *ser
 	}
 
 	const maxTransactions = 500
-	txns, _ := bankSvc.ListCardTransactions(ctx, txCustomerID, cardID, 1, maxTransactions)
+	var txns []domain.CreditCardTransaction
+	if list, err := bankSvc.ListCardTransactions(ctx, txCustomerID, cardID, 1, maxTransactions); err == nil {
+		txns = list.Data
+	}
 
 	// Only include transactions from the requested month
 	txnResp := make([]domain.InvoiceTransactionResponse, 0, len(txns))
@@ -237,8 +297,8 @@ func respondWithInvoice(ctx context.Context, w http.ResponseWriter, bankSvc *ser
 		ID:             invoice.ID,
 		CardID:         invoice.CardID,
 		ReferenceMonth: invoice.ReferenceMonth,
-		TotalAmount:    invoice.TotalAmount,
-		MinimumPayment: invoice.MinimumPayment,
+		TotalAmount:    service.RoundMoney(invoice.TotalAmount),
+		MinimumPayment: service.RoundMoney(invoice.MinimumPayment),
 		DueDate:        invoice.DueDate,
 		Status:         invoice.Status,
 		Transactions:   txnResp,
@@ -251,7 +311,7 @@ func cardBlockHandler(bankSvc *service.BankingService, logger *zap.Logger) http.
 		defer span.End()
 
 		cardID := chi.URLParam(r, "cardId")
-		if err := bankSvc.BlockCreditCardByID(ctx, cardID); err != nil {
+		if err := bankSvc.BlockCreditCard(ctx, CustomerIDFromContext(ctx), cardID, ""); err != nil {
 			handleServiceError(w, err, logger)
 			return
 		}
@@ -265,7 +325,7 @@ func cardUnblockHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 		defer span.End()
 
 		cardID := chi.URLParam(r, "cardId")
-		if err := bankSvc.UnblockCreditCardByID(ctx, cardID); err != nil {
+		if err := bankSvc.UnblockCreditCard(ctx, CustomerIDFromContext(ctx), cardID); err != nil {
 			handleServiceError(w, err, logger)
 			return
 		}
@@ -279,7 +339,22 @@ func cardCancelHandler(bankSvc *service.BankingService, logger *zap.Logger) http
 		defer span.End()
 
 		cardID := chi.URLParam(r, "cardId")
-		if err := bankSvc.CancelCreditCardByID(ctx, cardID); err != nil {
+		if err := bankSvc.CancelCreditCard(ctx, CustomerIDFromContext(ctx), cardID); err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func customerCardCancelHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/credit-cards/{cardId}/cancel")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		cardID := chi.URLParam(r, "cardId")
+		if err := bankSvc.CancelCreditCard(ctx, customerID, cardID); err != nil {
 			handleServiceError(w, err, logger)
 			return
 		}
@@ -300,8 +375,7 @@ func invoicePayHandler(bankSvc *service.BankingService, logger *zap.Logger) http
 		cardID := chi.URLParam(r, "cardId")
 
 		var req domain.InvoicePayRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -328,27 +402,28 @@ func buildInvoiceTransactionResponse(t domain.CreditCardTransaction) domain.Invo
 		ID:          t.ID,
 		Date:        t.TransactionDate.Format(time.RFC3339),
 		Description: t.MerchantName,
-		Amount:      t.Amount,
+		Amount:      service.RoundMoney(t.Amount),
 		Installment: installmentStr,
 		Category:    t.Category,
 	}
 
 	// If original_amount is set and differs from amount, include fee breakdown.
 	if t.OriginalAmount != nil && *t.OriginalAmount > 0 {
-		resp.OriginalAmount = t.OriginalAmount
-		feeAmount := t.Amount - *t.OriginalAmount
+		originalAmount := service.RoundMoney(*t.OriginalAmount)
+		resp.OriginalAmount = &originalAmount
+		feeAmount := service.RoundMoney(t.Amount - *t.OriginalAmount)
 		if feeAmount > 0 {
-			feeRounded := math.Round(feeAmount*100) / 100
-			resp.FeeAmount = &feeRounded
+			resp.FeeAmount = &feeAmount
 		}
-		totalWithFees := t.Amount
+		totalWithFees := service.RoundMoney(t.Amount)
 		resp.TotalWithFees = &totalWithFees
 		// Show the original PIX amount as the main "amount"
-		resp.Amount = *t.OriginalAmount
+		resp.Amount = originalAmount
 	}
 
 	if t.InstallmentAmount != nil && *t.InstallmentAmount > 0 {
-		resp.InstallmentAmount = t.InstallmentAmount
+		installmentAmount := service.RoundMoney(*t.InstallmentAmount)
+		resp.InstallmentAmount = &installmentAmount
 	}
 
 	return resp
@@ -0,0 +1,251 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// invoicesStore returns a fixed invoice history for card-1 and none for
+// any other card.
+type invoicesStore struct {
+	port.BankingStore
+	invoices []domain.CreditCardInvoice
+}
+
+func (s *invoicesStore) ListCreditCardInvoices(_ context.Context, _, cardID string) ([]domain.CreditCardInvoice, error) {
+	if cardID != "card-1" {
+		return nil, nil
+	}
+	return s.invoices, nil
+}
+
+func TestListCardInvoices_StatusFilter(t *testing.T) {
+	store := &invoicesStore{invoices: []domain.CreditCardInvoice{
+		{ID: "inv-jan", CardID: "card-1", ReferenceMonth: "2026-01", DueDate: "2026-02-10", TotalAmount: 800, Status: "paid"},
+		{ID: "inv-mar", CardID: "card-1", ReferenceMonth: "2026-03", DueDate: "2026-04-10", TotalAmount: 300, Status: "open"},
+		{ID: "inv-feb", CardID: "card-1", ReferenceMonth: "2026-02", DueDate: "2026-03-10", TotalAmount: 500, Status: "closed"},
+		{ID: "inv-dec", CardID: "card-1", ReferenceMonth: "2025-12", DueDate: "2026-01-10", TotalAmount: 650, Status: "paid"},
+	}}
+	bankSvc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{"all, due date descending", "/v1/customers/cust-1/credit-cards/card-1/invoices", http.StatusOK, []string{"inv-mar", "inv-feb", "inv-jan", "inv-dec"}},
+		{"paid only", "/v1/customers/cust-1/credit-cards/card-1/invoices?status=paid", http.StatusOK, []string{"inv-jan", "inv-dec"}},
+		{"open only", "/v1/customers/cust-1/credit-cards/card-1/invoices?status=open", http.StatusOK, []string{"inv-mar"}},
+		{"card without invoices", "/v1/customers/cust-1/credit-cards/card-2/invoices", http.StatusOK, []string{}},
+		{"unknown status", "/v1/customers/cust-1/credit-cards/card-1/invoices?status=late", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantIDs == nil {
+				return
+			}
+
+			var body struct {
+				Invoices []domain.CreditCardInvoiceListItem `json:"invoices"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Invoices == nil {
+				t.Fatal("invoices must be an empty list, not null")
+			}
+			if len(body.Invoices) != len(tt.wantIDs) {
+				t.Fatalf("got %d invoices, want %d", len(body.Invoices), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if body.Invoices[i].ID != id {
+					t.Errorf("invoices[%d] = %s, want %s", i, body.Invoices[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+// cardStatusStore holds one active card and records status changes.
+type cardStatusStore struct {
+	port.BankingStore
+	updates []string
+}
+
+func (s *cardStatusStore) GetCreditCard(_ context.Context, _, cardID string) (*domain.CreditCard, error) {
+	return &domain.CreditCard{ID: cardID, Status: "active"}, nil
+}
+
+func (s *cardStatusStore) UpdateCreditCardStatus(_ context.Context, _, status string) error {
+	s.updates = append(s.updates, status)
+	return nil
+}
+
+const testJWTSecret = "test-secret"
+
+// signAccessToken signs an access token of sub with testJWTSecret.
+func signAccessToken(t *testing.T, sub, role string, permissions ...string) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, service.JWTClaims{
+		Sub:         sub,
+		Type:        "access",
+		Role:        role,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	signed, err := token.SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestCardBlock_RequiresPermission(t *testing.T) {
+	authSvc := service.NewAuthService(nil, testJWTSecret, time.Minute, time.Hour, false, zap.NewNop())
+	sign := func(role string, permissions ...string) string {
+		return signAccessToken(t, "cust-1", role, permissions...)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantStatus  int
+		wantBlocked bool
+	}{
+		{"operator with card:block", sign("operator", domain.PermissionCardBlock), http.StatusNoContent, true},
+		{"owner", sign("owner", domain.PermissionAll), http.StatusNoContent, true},
+		{"viewer without card:block", sign("viewer", "read"), http.StatusForbidden, false},
+		{"invalid token", "not-a-jwt", http.StatusUnauthorized, false},
+		{"no token", "", http.StatusUnauthorized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &cardStatusStore{}
+			bankSvc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+			router := handler.NewRouter(nil, bankSvc, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/cards/card-1/block", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if blocked := len(store.updates) == 1; blocked != tt.wantBlocked {
+				t.Errorf("card status updates = %v, want blocked=%v", store.updates, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+// TestRequirePermission_OtherCustomerForbidden uses an owner token of cust-1
+// against cust-2, in the route and in the body: the permission is there, the
+// customer is not the token's.
+func TestRequirePermission_OtherCustomerForbidden(t *testing.T) {
+	authSvc := service.NewAuthService(nil, testJWTSecret, time.Minute, time.Hour, false, zap.NewNop())
+	token := signAccessToken(t, "cust-1", "owner", domain.PermissionAll)
+
+	tests := []struct {
+		name, method, path, body string
+	}{
+		{"route customer", http.MethodPost, "/v1/customers/cust-2/credit-cards/card-1/block", ""},
+		{"invoice pay", http.MethodPost, "/v1/customers/cust-2/credit-cards/card-1/invoice/pay", `{"paymentType":"total"}`},
+		{"limits update", http.MethodPut, "/v1/customers/cust-2/limits/pix", `{"dailyLimit":1}`},
+		{"body customer", http.MethodPost, "/v1/pix/transfer", `{"customerId":"cust-2","recipientKey":"fornecedor@example.com","amount":100,"idempotencyKey":"idem-1"}`},
+		{"debit purchase", http.MethodPost, "/v1/debit/purchase", `{"customerId":"cust-2","merchantName":"Loja","amount":10}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &cardStatusStore{}
+			bankSvc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+			router := handler.NewRouter(nil, bankSvc, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403 (body %s)", rec.Code, rec.Body.String())
+			}
+			if len(store.updates) != 0 {
+				t.Errorf("card status updates = %v, want none", store.updates)
+			}
+		})
+	}
+}
+
+func TestMoneyRoutes_RequirePermission(t *testing.T) {
+	authSvc := service.NewAuthService(nil, testJWTSecret, time.Minute, time.Hour, false, zap.NewNop())
+	token := signAccessToken(t, "cust-1", "viewer", "read")
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/v1/cards/card-1/cancel"},
+		{http.MethodPost, "/v1/customers/cust-1/credit-cards/card-1/cancel"},
+		{http.MethodPost, "/v1/debit/purchase"},
+		{http.MethodPost, "/v1/debit/tx-1/refund"},
+		{http.MethodPut, "/v1/customers/cust-1/accounts/acc-1/overdraft"},
+		{http.MethodPut, "/v1/customers/cust-1/limits/pix"},
+		{http.MethodPost, "/v1/customers/cust-1/credit-cards/card-1/invoice/pay"},
+	} {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			bankSvc := service.NewBankingService(&cardStatusStore{}, observability.NewMetrics(), zap.NewNop())
+			router := handler.NewRouter(nil, bankSvc, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader("{}"))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403 (body %s)", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCardBlock_UnavailableWithoutAuth(t *testing.T) {
+	store := &cardStatusStore{}
+	bankSvc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/cards/card-1/block", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 (body %s)", rec.Code, rec.Body.String())
+	}
+	if len(store.updates) != 0 {
+		t.Errorf("card status updates = %v, want none", store.updates)
+	}
+}
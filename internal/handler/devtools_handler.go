@@ -1,12 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
@@ -20,8 +22,7 @@ func devAddBalanceHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 		defer span.End()
 
 		var req domain.DevAddBalanceRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -41,8 +42,7 @@ func devSetCreditLimitHandler(bankSvc *service.BankingService, logger *zap.Logge
 		defer span.End()
 
 		var req domain.DevSetCreditLimitRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -62,8 +62,7 @@ func devGenerateTransactionsHandler(bankSvc *service.BankingService, logger *zap
 		defer span.End()
 
 		var req domain.DevGenerateTransactionsRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -83,8 +82,7 @@ func devAddCardPurchaseHandler(bankSvc *service.BankingService, logger *zap.Logg
 		defer span.End()
 
 		var req domain.DevAddCardPurchaseRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -97,3 +95,68 @@ func devAddCardPurchaseHandler(bankSvc *service.BankingService, logger *zap.Logg
 		writeJSON(w, http.StatusOK, resp)
 	}
 }
+
+func devResetCustomerHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/dev/reset-customer")
+		defer span.End()
+
+		var req domain.DevResetCustomerRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := bankSvc.DevResetCustomer(ctx, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// devReconcileHandler compares a customer's balance with their statement.
+// The optional openingBalance query parameter is the balance the account
+// started with (default 0).
+func devReconcileHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/dev/reconcile/{customerId}")
+		defer span.End()
+
+		var opening float64
+		if v := r.URL.Query().Get("openingBalance"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "openingBalance must be a number")
+				return
+			}
+			opening = parsed
+		}
+
+		report, err := bankSvc.ReconcileCustomer(ctx, chi.URLParam(r, "customerId"), opening)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// devCloseInvoiceHandler closes the card's invoice for the current month,
+// the manual trigger of the invoice cycle for support and testing.
+func devCloseInvoiceHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/dev/cards/{cardId}/close-invoice")
+		defer span.End()
+
+		invoice, err := bankSvc.CloseInvoice(ctx, chi.URLParam(r, "cardId"), time.Now().Format("2006-01"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, invoice)
+	}
+}
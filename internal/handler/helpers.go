@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
 
 	"go.uber.org/zap"
 )
@@ -18,7 +25,16 @@ import (
  */
 
 type errorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// circuitOpenResponse tells clients which upstream is unavailable and when
+// to retry (also sent as the Retry-After header).
+type circuitOpenResponse struct {
+	Error             string `json:"error"`
+	Upstream          string `json:"upstream"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
 }
 
 func writeError(w http.ResponseWriter, status int, msg string) {
@@ -33,9 +49,87 @@ func writeJSON(w http.ResponseWriter, status int, data any) {
 	enc.Encode(data)
 }
 
+// decodeJSON decodes the request body into dst. On failure it answers 413
+// when the body is over the MaxBodySizeMiddleware limit and 400 otherwise,
+// and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return checkDecode(w, json.NewDecoder(r.Body).Decode(dst))
+}
+
+// decodeOptionalJSON is decodeJSON for bodies that may be left out: an
+// empty body leaves dst untouched.
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	return checkDecode(w, err)
+}
+
+func checkDecode(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large (max %d bytes)", tooLarge.Limit))
+		return false
+	}
+	writeError(w, http.StatusBadRequest, "invalid request body")
+	return false
+}
+
+// writeJSONWithETag writes data like writeJSON but tags the body with a
+// strong ETag (hash of the serialized body). When the request's
+// If-None-Match already matches, it answers 304 with no body.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, status int, data any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header value (a list of
+// possibly weak tags, or "*") matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func parsePagination(r *http.Request) (page, pageSize int) {
+	return parsePaginationWithDefault(r, 20)
+}
+
+// parsePaginationWithDefault is parsePagination for listings whose page
+// size defaults to defaultPageSize when page_size is absent.
+func parsePaginationWithDefault(r *http.Request, defaultPageSize int) (page, pageSize int) {
 	page = 1
-	pageSize = 20
+	pageSize = defaultPageSize
 	if v := r.URL.Query().Get("page"); v != "" {
 		if p, err := strconv.Atoi(v); err == nil && p > 0 {
 			page = p
@@ -76,7 +170,48 @@ func formatKeyValue(keyType, value string) string {
 	return value
 }
 
-// handleServiceError maps domain errors to HTTP responses.
+// domainErrorWriter carries the code of the domain error a request was
+// answered with from handleServiceError back to CountDomainErrors.
+type domainErrorWriter struct {
+	http.ResponseWriter
+	code string
+}
+
+func (w *domainErrorWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// CountDomainErrors counts in bfa_domain_errors_total, by error code, the
+// domain errors handleServiceError answers requests with.
+func CountDomainErrors(metrics *observability.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dw := &domainErrorWriter{ResponseWriter: w}
+			next.ServeHTTP(dw, r)
+			if dw.code != "" {
+				metrics.IncrDomainError(dw.code)
+			}
+		})
+	}
+}
+
+// countDomainError records code on the request's domainErrorWriter, looking
+// through any writers wrapped around it.
+func countDomainError(w http.ResponseWriter, code string) {
+	for {
+		switch rw := w.(type) {
+		case *domainErrorWriter:
+			rw.code = code
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+// handleServiceError maps domain errors to HTTP responses, counting each by
+// error code. Circuit-open, timeout, unavailable and external service errors
+// are logged sampled per error code (see errorLogs).
 func handleServiceError(w http.ResponseWriter, err error, logger *zap.Logger) {
 	var notFound *domain.ErrNotFound
 	var circuitOpen *domain.ErrCircuitOpen
@@ -90,51 +225,119 @@ func handleServiceError(w http.ResponseWriter, err error, logger *zap.Logger) {
 	var unauthorized *domain.ErrUnauthorized
 	var accountBlocked *domain.ErrAccountBlocked
 	var conflict *domain.ErrConflict
+	var invalidState *domain.ErrInvalidState
 	var invalidCode *domain.ErrInvalidCode
+	var rateLimited *domain.ErrRateLimited
+	var unavailable *domain.ErrServiceUnavailable
+	var external *domain.ErrExternalService
 
 	switch {
 	case errors.As(err, &notFound):
+		countDomainError(w, "not_found")
 		logger.Debug("not found", zap.String("error", err.Error()))
 		writeError(w, http.StatusNotFound, err.Error())
 	case errors.As(err, &circuitOpen):
-		logger.Error("circuit breaker open", zap.Error(err))
-		writeError(w, http.StatusServiceUnavailable, err.Error())
+		countDomainError(w, "circuit_open")
+		retryAfter := int(math.Ceil(time.Until(circuitOpen.ResetAt).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		if ok, suppressed := errorLogs.allow("circuit_open"); ok {
+			logger.Error("circuit breaker open",
+				zap.String("upstream", circuitOpen.Service),
+				zap.Int("retry_after_seconds", retryAfter),
+				zap.Int("suppressed", suppressed),
+				zap.Error(err))
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeJSON(w, http.StatusServiceUnavailable, circuitOpenResponse{
+			Error:             err.Error(),
+			Upstream:          circuitOpen.Service,
+			RetryAfterSeconds: retryAfter,
+		})
 	case errors.As(err, &timeout):
-		logger.Error("request timeout", zap.Error(err))
+		countDomainError(w, "timeout")
+		if ok, suppressed := errorLogs.allow("timeout"); ok {
+			logger.Error("request timeout",
+				zap.String("operation", timeout.Operation),
+				zap.Int("suppressed", suppressed),
+				zap.Error(err))
+		}
 		writeError(w, http.StatusGatewayTimeout, err.Error())
 	case errors.As(err, &validation):
+		countDomainError(w, "validation")
 		logger.Debug("validation error", zap.String("error", err.Error()))
 		writeError(w, http.StatusBadRequest, err.Error())
 	case errors.As(err, &insufficientFunds):
+		countDomainError(w, "insufficient_funds")
 		logger.Warn("insufficient funds",
 			zap.Float64("available", insufficientFunds.Available),
 			zap.Float64("required", insufficientFunds.Required),
 		)
 		writeError(w, http.StatusUnprocessableEntity, err.Error())
 	case errors.As(err, &limitExceeded):
+		countDomainError(w, "limit_exceeded")
 		logger.Warn("limit exceeded", zap.String("error", err.Error()))
 		writeError(w, http.StatusUnprocessableEntity, err.Error())
 	case errors.As(err, &duplicate):
+		countDomainError(w, "duplicate")
 		logger.Debug("duplicate resource", zap.String("error", err.Error()))
 		writeError(w, http.StatusConflict, err.Error())
 	case errors.As(err, &forbidden):
+		countDomainError(w, "forbidden")
 		logger.Warn("forbidden access", zap.String("error", err.Error()))
 		writeError(w, http.StatusForbidden, err.Error())
 	case errors.As(err, &invalidBarcode):
+		countDomainError(w, "invalid_barcode")
 		logger.Debug("invalid barcode", zap.String("error", err.Error()))
 		writeError(w, http.StatusBadRequest, err.Error())
 	case errors.As(err, &unauthorized):
+		countDomainError(w, "unauthorized")
 		logger.Warn("unauthorized", zap.String("error", err.Error()))
 		writeError(w, http.StatusUnauthorized, err.Error())
 	case errors.As(err, &accountBlocked):
+		countDomainError(w, "account_blocked")
 		logger.Warn("account blocked", zap.String("status", accountBlocked.Status))
 		writeError(w, http.StatusForbidden, err.Error())
 	case errors.As(err, &conflict):
+		countDomainError(w, "conflict")
 		logger.Debug("conflict", zap.String("error", err.Error()))
 		writeError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &invalidState):
+		countDomainError(w, "invalid_state")
+		logger.Debug("invalid state", zap.String("error", err.Error()))
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
 	case errors.As(err, &invalidCode):
+		countDomainError(w, "invalid_code")
 		logger.Warn("invalid verification code")
 		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &rateLimited):
+		countDomainError(w, "rate_limited")
+		retryAfter := int(math.Ceil(rateLimited.RetryAfter.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		logger.Warn("rate limited", zap.String("error", err.Error()), zap.Int("retry_after_seconds", retryAfter))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.As(err, &unavailable):
+		countDomainError(w, "service_unavailable")
+		if ok, suppressed := errorLogs.allow("service_unavailable"); ok {
+			logger.Warn("service unavailable",
+				zap.String("service", unavailable.Service),
+				zap.Int("suppressed", suppressed),
+				zap.Error(err))
+		}
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.As(err, &external):
+		countDomainError(w, "external_service")
+		if ok, suppressed := errorLogs.allow("external_service"); ok {
+			logger.Error("external service error",
+				zap.String("service", external.Service),
+				zap.Int("suppressed", suppressed),
+				zap.Error(err))
+		}
+		writeError(w, http.StatusBadGateway, "upstream service error")
 	default:
 		logger.Error("unhandled error", zap.Error(err))
 		writeError(w, http.StatusInternalServerError, "internal server error")
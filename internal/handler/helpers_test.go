@@ -0,0 +1,212 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/cache"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/client"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type stubTransactions struct{}
+
+func (stubTransactions) GetTransactions(context.Context, string) ([]domain.Transaction, error) {
+	return nil, nil
+}
+
+type stubAgent struct{}
+
+func (stubAgent) Call(context.Context, *domain.AgentRequest) (*domain.AgentResponse, error) {
+	return &domain.AgentResponse{}, nil
+}
+
+func TestCircuitOpen_RetryAfterAndUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	cb := resilience.NewCircuitBreaker("profile-api")
+	profile := client.NewProfileClient(upstream.Client(), upstream.URL, cb, resilience.Config{MaxRetries: 0})
+
+	// Trip the breaker: 5 consecutive failures.
+	for i := 0; i < 5; i++ {
+		_, _ = profile.GetProfile(context.Background(), "cust-1")
+	}
+
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(profile, stubTransactions{}, stubAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/assistant/cust-1", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > int(resilience.BreakerOpenTimeout.Seconds()) {
+		t.Errorf("unexpected Retry-After header %q", rec.Header().Get("Retry-After"))
+	}
+
+	var body struct {
+		Upstream          string `json:"upstream"`
+		RetryAfterSeconds int    `json:"retry_after_seconds"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Upstream != "profile-api" {
+		t.Errorf("expected upstream profile-api, got %q", body.Upstream)
+	}
+	if body.RetryAfterSeconds != retryAfter {
+		t.Errorf("body retry_after_seconds %d does not match header %d", body.RetryAfterSeconds, retryAfter)
+	}
+}
+
+// cardsStore returns a fixed card list.
+type cardsStore struct {
+	port.BankingStore
+	cards []domain.CreditCard
+}
+
+func (s *cardsStore) ListCreditCards(context.Context, string) ([]domain.CreditCard, error) {
+	return s.cards, nil
+}
+
+func TestConditionalGet_ETag(t *testing.T) {
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, stubTransactions{}, stubAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	store := &cardsStore{cards: []domain.CreditCard{{ID: "card-1", CustomerID: "cust-1", CardNumberLast4: "1234", Status: "active", CreditLimit: 5000}}}
+	bankSvc := service.NewBankingService(store, metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, bankSvc, nil, nil, nil, metrics, zap.NewNop())
+
+	for _, path := range []string{"/v1/customers/cust-1/profile", "/v1/customers/cust-1/cards"} {
+		t.Run(path, func(t *testing.T) {
+			first := httptest.NewRecorder()
+			router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, path, nil))
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("expected 200 with ETag, got %d (ETag %q)", first.Code, etag)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("If-None-Match", etag)
+			second := httptest.NewRecorder()
+			router.ServeHTTP(second, req)
+			if second.Code != http.StatusNotModified {
+				t.Fatalf("expected 304, got %d", second.Code)
+			}
+			if second.Body.Len() != 0 {
+				t.Errorf("expected empty body on 304, got %q", second.Body.String())
+			}
+
+			req = httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("If-None-Match", `"stale"`)
+			third := httptest.NewRecorder()
+			router.ServeHTTP(third, req)
+			if third.Code != http.StatusOK || third.Body.Len() == 0 {
+				t.Errorf("expected full 200 for a stale ETag, got %d", third.Code)
+			}
+		})
+	}
+
+	// A changed card list must produce a different ETag.
+	before := httptest.NewRecorder()
+	router.ServeHTTP(before, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/cards", nil))
+	store.cards[0].UsedLimit = 100
+	after := httptest.NewRecorder()
+	router.ServeHTTP(after, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/cards", nil))
+	if before.Header().Get("ETag") == after.Header().Get("ETag") {
+		t.Error("expected the ETag to change with the body")
+	}
+}
+
+// timeoutCardsStore fails every card listing with the same timeout.
+type timeoutCardsStore struct {
+	port.BankingStore
+}
+
+func (timeoutCardsStore) ListCreditCards(context.Context, string) ([]domain.CreditCard, error) {
+	return nil, &domain.ErrTimeout{Operation: "sampled ListCreditCards"}
+}
+
+func TestHandleServiceError_SamplesRepeatedTimeouts(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(stubProfile{}, stubTransactions{}, stubAgent{}, cache.New[any](time.Minute), metrics, zap.NewNop())
+	bankSvc := service.NewBankingService(timeoutCardsStore{}, metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, bankSvc, nil, nil, nil, metrics, zap.New(core))
+
+	for i := 0; i < 10; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/cards", nil))
+		if rec.Code != http.StatusGatewayTimeout {
+			t.Fatalf("expected 504, got %d", rec.Code)
+		}
+	}
+
+	if n := logs.FilterMessage("request timeout").Len(); n != 1 {
+		t.Errorf("logged %d of 10 identical timeouts, want only the first", n)
+	}
+}
+
+// erroringCardsStore fails card listings with a not-found error for
+// cust-missing and a timeout for everyone else.
+type erroringCardsStore struct {
+	port.BankingStore
+}
+
+func (erroringCardsStore) ListCreditCards(_ context.Context, customerID string) ([]domain.CreditCard, error) {
+	if customerID == "cust-missing" {
+		return nil, &domain.ErrNotFound{Resource: "customer", ID: customerID}
+	}
+	return nil, &domain.ErrTimeout{Operation: "counted ListCreditCards"}
+}
+
+func TestHandleServiceError_CountsDomainErrors(t *testing.T) {
+	metrics := observability.NewMetrics()
+	bankSvc := service.NewBankingService(erroringCardsStore{}, metrics, zap.NewNop())
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, metrics, zap.NewNop())
+	// A router built later, with its own metrics, must not take the counts.
+	otherMetrics := observability.NewMetrics()
+	other := handler.NewRouter(nil, bankSvc, nil, nil, nil, otherMetrics, zap.NewNop())
+
+	for _, customerID := range []string{"cust-missing", "cust-missing", "cust-1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/customers/"+customerID+"/cards", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`bfa_domain_errors_total{code="not_found"} 2`,
+		`bfa_domain_errors_total{code="timeout"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics is missing %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	other.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rec.Body.String(), `bfa_domain_errors_total{code="not_found"}`) {
+		t.Error("domain errors counted on the metrics of another router")
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func statementExportHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/statements/export")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+
+		// An empty body exports the last 30 days as CSV.
+		var req domain.StatementExportRequest
+		if !decodeOptionalJSON(w, r, &req) {
+			return
+		}
+
+		job, err := svc.RequestStatementExport(ctx, customerID, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+func getJobHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/jobs/{jobId}")
+		defer span.End()
+
+		job, err := svc.GetJob(ctx, chi.URLParam(r, "customerId"), chi.URLParam(r, "jobId"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+ * Error log sampling
+ *
+ * A flapping upstream fails every request with the same circuit-open or
+ * timeout error, and logging each one floods the log pipeline. The sampler
+ * lets the first occurrence of an error key through per interval and counts
+ * the rest; the next entry logged for that key reports how many were
+ * suppressed, which doubles as a periodic summary while the error persists.
+ *
+ * Keys are error codes (circuit_open, timeout, ...), never values taken from
+ * the error such as a path or an ID, so the set of keys stays small; a key
+ * idle for a whole interval with nothing left to report is dropped anyway.
+ */
+
+// errorLogInterval is how often a repeated error is logged.
+const errorLogInterval = 30 * time.Second
+
+// errorLogs samples the high-volume errors logged by handleServiceError.
+var errorLogs = newLogSampler(errorLogInterval)
+
+type sampledKey struct {
+	next       time.Time // when the key may be logged again
+	suppressed int       // occurrences dropped since the last entry
+}
+
+// logSampler decides, per error key, whether an occurrence is logged.
+type logSampler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	keys     map[string]*sampledKey
+	now      func() time.Time
+}
+
+func newLogSampler(interval time.Duration) *logSampler {
+	return &logSampler{interval: interval, keys: make(map[string]*sampledKey), now: time.Now}
+}
+
+// allow reports whether this occurrence of key should be logged and, if so,
+// how many occurrences were suppressed since the previous entry.
+func (s *logSampler) allow(key string) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evict(now)
+	k, ok := s.keys[key]
+	if !ok {
+		s.keys[key] = &sampledKey{next: now.Add(s.interval)}
+		return true, 0
+	}
+	if now.Before(k.next) {
+		k.suppressed++
+		return false, 0
+	}
+	suppressed := k.suppressed
+	k.next = now.Add(s.interval)
+	k.suppressed = 0
+	return true, suppressed
+}
+
+// evict drops the keys whose interval is over with no occurrence left to
+// report. Must be called with s.mu held.
+func (s *logSampler) evict(now time.Time) {
+	for key, k := range s.keys {
+		if k.suppressed == 0 && !now.Before(k.next) {
+			delete(s.keys, key)
+		}
+	}
+}
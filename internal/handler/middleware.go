@@ -2,16 +2,24 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
 	"go.uber.org/zap"
 )
 
 type contextKey string
 
-const customerIDKey contextKey = "customerID"
+const (
+	customerIDKey contextKey = "customerID"
+	claimsKey     contextKey = "claims"
+)
 
 // JWTAuthMiddleware validates Bearer tokens and injects customerID into context.
 func JWTAuthMiddleware(authSvc *service.AuthService, logger *zap.Logger) func(http.Handler) http.Handler {
@@ -23,7 +31,7 @@ func JWTAuthMiddleware(authSvc *service.AuthService, logger *zap.Logger) func(ht
 					zap.String("path", r.URL.Path),
 					zap.String("remote_addr", r.RemoteAddr),
 				)
-				writeError(w, http.StatusUnauthorized, "Token de autenticação não fornecido")
+				writeError(w, http.StatusUnauthorized, i18n.T(r.Context(), "auth.token_missing"))
 				return
 			}
 
@@ -33,31 +41,275 @@ func JWTAuthMiddleware(authSvc *service.AuthService, logger *zap.Logger) func(ht
 					zap.String("path", r.URL.Path),
 					zap.String("remote_addr", r.RemoteAddr),
 				)
-				writeError(w, http.StatusUnauthorized, "Formato de token inválido")
+				writeError(w, http.StatusUnauthorized, i18n.T(r.Context(), "auth.token_malformed"))
 				return
 			}
 
+			// The verification key is selected by the token's kid header, so
+			// tokens signed before a key rotation stay valid until they expire.
 			tokenString := parts[1]
-			claims, err := authSvc.ValidateAccessToken(tokenString)
+			claims, err := authSvc.ValidateAccessToken(r.Context(), tokenString)
 			if err != nil {
 				logger.Warn("auth: invalid or expired token",
 					zap.String("path", r.URL.Path),
 					zap.String("remote_addr", r.RemoteAddr),
+					zap.String("kid", service.TokenKeyID(tokenString)),
 					zap.Error(err),
 				)
 				writeError(w, http.StatusUnauthorized, err.Error())
 				return
 			}
 
-			// Inject customerID into context
+			// Inject customerID and claims into context
 			ctx := context.WithValue(r.Context(), customerIDKey, claims.Sub)
+			ctx = context.WithValue(ctx, claimsKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePermission gates a route on a permission claim of the access token:
+// 401 without a valid token, 403 when it lacks perm or when the route's
+// {customerId} is not the token's subject. Handlers taking the customer from
+// the body check it with authorizeCustomer. When auth is not configured (nil
+// authSvc) the route answers 503 rather than running unchecked.
+func RequirePermission(authSvc *service.AuthService, perm string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if authSvc == nil {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handleServiceError(w, &domain.ErrServiceUnavailable{Service: "auth", Reason: "auth not configured"}, logger)
+			})
+		}
+		authenticated := JWTAuthMiddleware(authSvc, logger)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims := ClaimsFromContext(r.Context())
+				if claims == nil || !claims.HasPermission(perm) {
+					logger.Warn("auth: missing permission",
+						zap.String("path", r.URL.Path),
+						zap.String("customer_id", CustomerIDFromContext(r.Context())),
+						zap.String("permission", perm),
+					)
+					writeError(w, http.StatusForbidden, i18n.T(r.Context(), "auth.permission_denied"))
+					return
+				}
+				if customerID := chi.URLParam(r, "customerId"); customerID != "" && !authorizeCustomer(w, r, customerID, logger) {
+					return
+				}
+				next.ServeHTTP(w, r)
+			})).ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorizeCustomer reports whether the authenticated caller may act on
+// customerID, answering 403 when the access token belongs to another
+// customer. Unauthenticated requests pass; gating them is RequirePermission's
+// job.
+func authorizeCustomer(w http.ResponseWriter, r *http.Request, customerID string, logger *zap.Logger) bool {
+	claims := ClaimsFromContext(r.Context())
+	if claims == nil || claims.Sub == customerID {
+		return true
+	}
+	logger.Warn("auth: customer mismatch",
+		zap.String("path", r.URL.Path),
+		zap.String("token_customer_id", claims.Sub),
+		zap.String("customer_id", customerID),
+	)
+	writeError(w, http.StatusForbidden, i18n.T(r.Context(), "auth.customer_forbidden"))
+	return false
+}
+
+// OptionalJWTAuth authenticates the request when it carries an Authorization
+// header (401 when the token is invalid) and lets it through anonymous
+// otherwise. An authenticated request is marked as its subject for the
+// assistant's banking tools (see service.WithToolSubject), which do not run
+// for anonymous ones.
+func OptionalJWTAuth(authSvc *service.AuthService, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if authSvc == nil {
+			return next
+		}
+		authenticated := JWTAuthMiddleware(authSvc, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := service.WithToolSubject(r.Context(), CustomerIDFromContext(r.Context()))
 			next.ServeHTTP(w, r.WithContext(ctx))
+		}))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireService answers 503 with an ErrServiceUnavailable when the service
+// behind a route is not configured (nil when Supabase is missing), instead of
+// calling a handler that would nil-panic on it.
+func RequireService(configured bool, name string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if configured {
+			return next
+		}
+		return serviceUnavailableHandler(name, logger)
+	}
+}
+
+func serviceUnavailableHandler(name string, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleServiceError(w, &domain.ErrServiceUnavailable{Service: name, Reason: "Supabase not configured"}, logger)
+	})
+}
+
+// devSecretHeader carries the shared secret of the dev tools routes.
+const devSecretHeader = "X-Dev-Secret"
+
+// requireDevSecret rejects dev tools calls without the configured secret.
+func requireDevSecret(bankSvc *service.BankingService, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !bankSvc.CheckDevToolsSecret(r.Header.Get(devSecretHeader)) {
+				logger.Warn("dev tools: missing or invalid secret",
+					zap.String("path", r.URL.Path),
+					zap.String("remote_addr", r.RemoteAddr),
+				)
+				writeError(w, http.StatusUnauthorized, "dev tools secret required")
+				return
+			}
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// LocaleMiddleware resolves the response language from Accept-Language
+// (pt-BR unless the client prefers English) and puts it in the request
+// context for i18n.T.
+func LocaleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loc := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", string(loc))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), loc)))
+	})
+}
+
 // CustomerIDFromContext extracts the authenticated customer ID from context.
 func CustomerIDFromContext(ctx context.Context) string {
 	v, _ := ctx.Value(customerIDKey).(string)
 	return v
 }
+
+// ClaimsFromContext returns the access token claims injected by
+// JWTAuthMiddleware, or nil on unauthenticated routes.
+func ClaimsFromContext(ctx context.Context) *service.JWTClaims {
+	v, _ := ctx.Value(claimsKey).(*service.JWTClaims)
+	return v
+}
+
+// CORSConfig configures CORSMiddleware. Origins may contain one "*"
+// wildcard (e.g. "http://localhost:*"); a lone "*" allows any origin.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+}
+
+// CORSMiddleware answers preflight requests and sets the CORS response
+// headers. The request Origin is echoed only when it matches the allowlist;
+// other origins get no CORS headers at all. It must wrap the whole router so
+// OPTIONS preflights are answered before route matching.
+func CORSMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   []string{"Link", "ETag"},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           300,
+	})
+}
+
+// uploadRoutes accept bodies up to the upload limit of
+// MaxBodySizeMiddleware instead of the default one: they carry images.
+var uploadRoutes = []string{
+	"POST /v1/bills/validate", // camera_scan photo in imageBase64
+}
+
+// MaxBodySizeMiddleware caps request bodies at limit bytes, or uploadLimit
+// on uploadRoutes, answering 413 when the declared Content-Length is over
+// it. Bodies without a length are cut at the limit while read (see
+// decodeJSON). A non-positive limit disables the cap.
+func MaxBodySizeMiddleware(limit, uploadLimit int64, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			maxBytes := limit
+			if matchesRoute(uploadRoutes, r.Method, r.URL.Path) {
+				maxBytes = uploadLimit
+			}
+			if maxBytes <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				logger.Warn("request body too large",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Int64("content_length", r.ContentLength),
+					zap.Int64("limit", maxBytes),
+				)
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large (max %d bytes)", maxBytes))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ReadOnlyMiddleware rejects the route with 503. NewRouter applies it to
+// the routes that write banking data while the banking service is read-only
+// (READ_ONLY_MODE); reads, dry runs, auth and chat stay available.
+func ReadOnlyMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.Info("read-only mode: write rejected",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+			)
+			writeError(w, http.StatusServiceUnavailable, "Sistema em manutenção: operações de escrita estão temporariamente indisponíveis")
+		})
+	}
+}
+
+// matchesRoute reports whether method and path match one of routes.
+// "{param}" segments match any single non-empty segment.
+func matchesRoute(routes []string, method, path string) bool {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range routes {
+		routeMethod, pattern, _ := strings.Cut(route, " ")
+		if routeMethod != method {
+			continue
+		}
+		patSegs := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(patSegs) != len(segs) {
+			continue
+		}
+		match := true
+		for i, p := range patSegs {
+			if strings.HasPrefix(p, "{") {
+				match = segs[i] != ""
+			} else {
+				match = p == segs[i]
+			}
+			if !match {
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
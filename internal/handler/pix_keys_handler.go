@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -40,6 +40,13 @@ func pixKeyLookupHandler(bankSvc *service.BankingService, logger *zap.Logger) ht
 			recipientBank = "Itaú Unibanco"
 		}
 
+		// The payer only gets to see masked recipient data
+		displayKey := pixKey.KeyValue
+		if bankSvc.MasksPII() {
+			recipientDoc = service.MaskDocument(recipientDoc)
+			displayKey = service.MaskPixKeyValue(pixKey.KeyType, displayKey)
+		}
+
 		resp := domain.PixKeyLookupResponse{
 			KeyType: pixKey.KeyType,
 			Recipient: &domain.PixRecipient{
@@ -50,9 +57,10 @@ func pixKeyLookupHandler(bankSvc *service.BankingService, logger *zap.Logger) ht
 				Account:  recipientAcct,
 				PixKey: &domain.PixKeyInfo{
 					Type:  pixKey.KeyType,
-					Value: pixKey.KeyValue,
+					Value: displayKey,
 				},
 			},
+			Fees: bankSvc.GetPixFees(),
 		}
 
 		writeJSON(w, http.StatusOK, resp)
@@ -65,8 +73,7 @@ func pixKeyRegisterHandler(bankSvc *service.BankingService, logger *zap.Logger)
 		defer span.End()
 
 		var req domain.PixKeyRegisterRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
@@ -80,6 +87,26 @@ func pixKeyRegisterHandler(bankSvc *service.BankingService, logger *zap.Logger)
 	}
 }
 
+func pixKeyVerifyRequestHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/keys/verify-request")
+		defer span.End()
+
+		var req domain.PixKeyVerifyRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := bankSvc.RequestPixKeyVerification(ctx, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func pixKeyDeleteByValueHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "DELETE /v1/pix/keys")
@@ -90,8 +117,7 @@ func pixKeyDeleteByValueHandler(svc *service.BankingService, logger *zap.Logger)
 			KeyType    string `json:"keyType"`
 			KeyValue   string `json:"keyValue"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		if req.CustomerID == "" || req.KeyType == "" || req.KeyValue == "" {
@@ -121,21 +147,39 @@ func listPixKeysHandler(svc *service.BankingService, logger *zap.Logger) http.Ha
 		if keys == nil {
 			keys = []domain.PixKey{}
 		}
-		type pixKeyDisplay struct {
-			domain.PixKey
-			FormattedValue string `json:"formatted_value"`
-		}
 		result := make([]pixKeyDisplay, len(keys))
 		for i, k := range keys {
-			result[i] = pixKeyDisplay{
-				PixKey:         k,
-				FormattedValue: formatKeyValue(k.KeyType, k.KeyValue),
-			}
+			result[i] = newPixKeyDisplay(k)
 		}
 		writeJSON(w, http.StatusOK, result)
 	}
 }
 
+// pixKeyDisplay is a key as shown to its owner, with the value formatted.
+type pixKeyDisplay struct {
+	domain.PixKey
+	FormattedValue string `json:"formatted_value"`
+}
+
+func newPixKeyDisplay(k domain.PixKey) pixKeyDisplay {
+	return pixKeyDisplay{PixKey: k, FormattedValue: formatKeyValue(k.KeyType, k.KeyValue)}
+}
+
+func getPixKeyHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /pix/keys/{keyId}")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		keyID := chi.URLParam(r, "keyId")
+		key, err := svc.GetPixKey(ctx, customerID, keyID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPixKeyDisplay(*key))
+	}
+}
+
 func deletePixKeyHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "DELETE /pix/keys/{keyId}")
@@ -146,7 +190,22 @@ func deletePixKeyHandler(svc *service.BankingService, logger *zap.Logger) http.H
 			handleServiceError(w, err, logger)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"success": true, "message": "Chave Pix excluída com sucesso"})
+		writeJSON(w, http.StatusOK, map[string]any{"success": true, "message": i18n.T(ctx, "pix.key_deleted")})
+	}
+}
+
+func reactivatePixKeyHandler(svc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /pix/keys/{keyId}/reactivate")
+		defer span.End()
+		customerID := chi.URLParam(r, "customerId")
+		keyID := chi.URLParam(r, "keyId")
+		key, err := svc.ReactivatePixKey(ctx, customerID, keyID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, key)
 	}
 }
 
@@ -165,3 +224,12 @@ func creditLimitHandler(svc *service.BankingService, logger *zap.Logger) http.Ha
 		writeJSON(w, http.StatusOK, map[string]any{"creditLimit": limit})
 	}
 }
+
+func pixFeesHandler(bankSvc *service.BankingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "GET /v1/pix/fees")
+		defer span.End()
+
+		writeJSON(w, http.StatusOK, bankSvc.GetPixFees())
+	}
+}
@@ -0,0 +1,127 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// lookupStore resolves any key to a recipient with the given document.
+type lookupStore struct {
+	port.BankingStore
+	document string
+}
+
+func (s *lookupStore) LookupPixKey(_ context.Context, keyType, keyValue string) (*domain.PixKey, error) {
+	return &domain.PixKey{ID: "key-1", CustomerID: "cust-2", KeyType: keyType, KeyValue: keyValue, Status: "active"}, nil
+}
+
+func (s *lookupStore) GetCustomerLookupData(context.Context, string) (name, document, bank, branch, account string, err error) {
+	return "Recebedor LTDA", s.document, "Itaú Unibanco", "0001", "12345-6", nil
+}
+
+func lookupPixKey(t *testing.T, bankSvc *service.BankingService, keyType, key string) domain.PixKeyLookupResponse {
+	t.Helper()
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pix/keys/lookup?keyType="+keyType+"&key="+url.QueryEscape(key), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp domain.PixKeyLookupResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func TestPixKeyLookup_MasksRecipientData(t *testing.T) {
+	tests := []struct {
+		keyType, key, document string
+		wantKey, wantDocument  string
+	}{
+		{"cpf", "123.456.789-09", "123.456.789-09", "***.456.789-**", "***.456.789-**"},
+		{"cnpj", "12345678000190", "12.345.678/0001-90", "12**********90", "12.***.***/****-90"},
+		{"email", "financeiro@empresa.com.br", "12345678000190", "f***@empresa.com.br", "12**********90"},
+		{"phone", "+5511987654321", "12345678000190", "+55 (**) *****-4321", "12**********90"},
+		{"random", "7d9f0c3e-1a2b-4c5d-8e9f-0a1b2c3d4e5f", "12345678000190", "7d9f0c3e-1a2b-4c5d-8e9f-0a1b2c3d4e5f", "12**********90"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyType, func(t *testing.T) {
+			bankSvc := service.NewBankingService(&lookupStore{document: tt.document}, observability.NewMetrics(), zap.NewNop())
+			resp := lookupPixKey(t, bankSvc, tt.keyType, tt.key)
+
+			if got := resp.Recipient.PixKey.Value; got != tt.wantKey {
+				t.Errorf("key: expected %q, got %q", tt.wantKey, got)
+			}
+			if got := resp.Recipient.Document; got != tt.wantDocument {
+				t.Errorf("document: expected %q, got %q", tt.wantDocument, got)
+			}
+		})
+	}
+}
+
+func TestPixKeyLookup_MaskingDisabled(t *testing.T) {
+	bankSvc := service.NewBankingService(&lookupStore{document: "12345678909"}, observability.NewMetrics(), zap.NewNop())
+	bankSvc.SetPIIMasking(false)
+
+	resp := lookupPixKey(t, bankSvc, "cpf", "12345678909")
+	if resp.Recipient.PixKey.Value != "12345678909" || resp.Recipient.Document != "12345678909" {
+		t.Errorf("expected unmasked data, got %+v", resp.Recipient)
+	}
+}
+
+// ownedKeyStore holds a single key owned by cust-1 and returns it by ID
+// whoever asks, leaving the ownership check to the service.
+type ownedKeyStore struct {
+	port.BankingStore
+}
+
+func (s *ownedKeyStore) GetPixKey(_ context.Context, _, keyID string) (*domain.PixKey, error) {
+	if keyID != "key-1" {
+		return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyID}
+	}
+	return &domain.PixKey{ID: "key-1", CustomerID: "cust-1", KeyType: "cnpj", KeyValue: "12345678000190", Status: "active"}, nil
+}
+
+func TestGetPixKey_OnlyForItsOwner(t *testing.T) {
+	bankSvc := service.NewBankingService(&ownedKeyStore{}, observability.NewMetrics(), zap.NewNop())
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-1/pix/keys/key-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("owner: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var key struct {
+		ID             string `json:"id"`
+		Status         string `json:"status"`
+		FormattedValue string `json:"formatted_value"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&key); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if key.ID != "key-1" || key.Status != "active" || key.FormattedValue != "12.345.678/0001-90" {
+		t.Errorf("unexpected key: %+v", key)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/cust-2/pix/keys/key-1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("other customer: expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
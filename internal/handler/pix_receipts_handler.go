@@ -14,34 +14,46 @@ import (
  * PIX Receipts (Comprovantes)
  */
 
-func formatReceiptResponse(r *domain.PixReceipt) *domain.PixReceiptResponse {
+// formatReceiptResponse renders a receipt for its owner. With mask set, the
+// counterparty's document (and key, on sent receipts) is masked; the owner's
+// own data is always shown in full.
+func formatReceiptResponse(r *domain.PixReceipt, mask bool) *domain.PixReceiptResponse {
 	// Comprovante shows ONLY the PIX amount transferred.
 	// Fee/installment details are returned exclusively in the fatura do cartão.
+	senderDoc, recipientDoc, keyValue := r.SenderDocument, r.RecipientDocument, r.RecipientKeyValue
+	if mask {
+		if r.Direction == "received" {
+			senderDoc = service.MaskDocument(senderDoc)
+		} else {
+			recipientDoc = service.MaskDocument(recipientDoc)
+			keyValue = service.MaskPixKeyValue(r.RecipientKeyType, keyValue)
+		}
+	}
 	return &domain.PixReceiptResponse{
 		ID:          r.ID,
 		TransferID:  r.TransferID,
 		Direction:   r.Direction,
-		Amount:      r.Amount,
+		Amount:      service.RoundMoney(r.Amount),
 		Description: r.Description,
 		E2EID:       r.EndToEndID,
 		FundedBy:    r.FundedBy,
 		Sender: &domain.PixReceiptParty{
 			Name:     r.SenderName,
-			Document: r.SenderDocument,
+			Document: senderDoc,
 			Bank:     r.SenderBank,
 			Branch:   r.SenderBranch,
 			Account:  r.SenderAccount,
 		},
 		Recipient: &domain.PixReceiptParty{
 			Name:     r.RecipientName,
-			Document: r.RecipientDocument,
+			Document: recipientDoc,
 			Bank:     r.RecipientBank,
 			Branch:   r.RecipientBranch,
 			Account:  r.RecipientAccount,
 		},
 		PixKey: &domain.PixKeyInfo{
 			Type:  r.RecipientKeyType,
-			Value: r.RecipientKeyValue,
+			Value: keyValue,
 		},
 		Status:     r.Status,
 		ExecutedAt: r.ExecutedAt,
@@ -66,7 +78,7 @@ func getPixReceiptHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 			return
 		}
 
-		writeJSON(w, http.StatusOK, formatReceiptResponse(receipt))
+		writeJSON(w, http.StatusOK, formatReceiptResponse(receipt, bankSvc.MasksPII()))
 	}
 }
 
@@ -87,7 +99,7 @@ func getPixReceiptByTransferHandler(bankSvc *service.BankingService, logger *zap
 			return
 		}
 
-		writeJSON(w, http.StatusOK, formatReceiptResponse(receipt))
+		writeJSON(w, http.StatusOK, formatReceiptResponse(receipt, bankSvc.MasksPII()))
 	}
 }
 
@@ -102,17 +114,68 @@ func listPixReceiptsHandler(bankSvc *service.BankingService, logger *zap.Logger)
 			return
 		}
 
-		receipts, err := bankSvc.ListPixReceipts(ctx, customerID)
+		// Before pagination the listing returned up to 100 receipts
+		page, pageSize := parsePaginationWithDefault(r, 100)
+
+		receipts, err := bankSvc.ListPixReceipts(ctx, customerID, page, pageSize)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		result := make([]*domain.PixReceiptResponse, 0, len(receipts.Data))
+		for i := range receipts.Data {
+			result = append(result, formatReceiptResponse(&receipts.Data[i], bankSvc.MasksPII()))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"receipts":  result,
+			"total":     receipts.Total,
+			"page":      receipts.Page,
+			"page_size": receipts.PageSize,
+			"has_more":  receipts.HasMore,
+		})
+	}
+}
+
+func pixReceiptShareTokenHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/pix/receipts/{receiptId}/share-token")
+		defer span.End()
+
+		receiptID := chi.URLParam(r, "receiptId")
+		if receiptID == "" {
+			writeError(w, http.StatusBadRequest, "receiptId is required")
+			return
+		}
+
+		shareToken, err := bankSvc.CreateReceiptShareToken(ctx, receiptID)
 		if err != nil {
 			handleServiceError(w, err, logger)
 			return
 		}
 
-		result := make([]*domain.PixReceiptResponse, 0, len(receipts))
-		for i := range receipts {
-			result = append(result, formatReceiptResponse(&receipts[i]))
+		writeJSON(w, http.StatusOK, shareToken)
+	}
+}
+
+func getSharedPixReceiptHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/pix/receipts/shared/{token}")
+		defer span.End()
+
+		token := chi.URLParam(r, "token")
+		if token == "" {
+			writeError(w, http.StatusBadRequest, "token is required")
+			return
+		}
+
+		receipt, err := bankSvc.GetSharedPixReceipt(ctx, token)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
 		}
 
-		writeJSON(w, http.StatusOK, map[string]any{"receipts": result})
+		writeJSON(w, http.StatusOK, formatReceiptResponse(receipt, bankSvc.MasksPII()))
 	}
 }
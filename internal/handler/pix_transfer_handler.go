@@ -1,13 +1,16 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -16,31 +19,60 @@ import (
  * PIX Transfer — single transfer + credit-card transfer
  */
 
-const (
-	// PixCreditFeeRate is the fee charged per installment on PIX via credit card (2%).
-	PixCreditFeeRate = 0.02
+// idempotencyKey returns the key sent by the client, so a retried request
+// replays the original transfer, or a fresh one when none was sent.
+func idempotencyKey(clientKey string) string {
+	if clientKey = strings.TrimSpace(clientKey); clientKey != "" {
+		return clientKey
+	}
+	return uuid.New().String()
+}
 
-	// PixCreditMaxInstallments is the maximum number of installments allowed.
-	PixCreditMaxInstallments = 12
-)
+// pixTransferBody is the body of POST /v1/pix/transfer and its dry run,
+// POST /v1/pix/transfer/validate.
+type pixTransferBody struct {
+	CustomerID             string  `json:"customerId"`
+	RecipientKey           string  `json:"recipientKey"`
+	RecipientKeyType       string  `json:"recipientKeyType"`
+	Amount                 float64 `json:"amount"`
+	Description            string  `json:"description,omitempty"`
+	FundedBy               string  `json:"fundedBy,omitempty"`
+	CreditCardID           string  `json:"creditCardId,omitempty"`
+	CreditCardInstallments int     `json:"installments,omitempty"`
+	ConfirmationToken      string  `json:"confirmationToken,omitempty"`
+	ScheduledFor           string  `json:"scheduledFor,omitempty"` // RFC 3339; empty executes now
+	IdempotencyKey         string  `json:"idempotencyKey,omitempty"`
+}
+
+// transferRequest builds the service request, debiting the customer's
+// primary account.
+func (b *pixTransferBody) transferRequest(sourceAccountID string) *domain.PixTransferRequest {
+	fundedBy := b.FundedBy
+	if fundedBy == "" {
+		fundedBy = "balance"
+	}
+	return &domain.PixTransferRequest{
+		IdempotencyKey:         idempotencyKey(b.IdempotencyKey),
+		SourceAccountID:        sourceAccountID,
+		DestinationKeyType:     b.RecipientKeyType,
+		DestinationKeyValue:    b.RecipientKey,
+		Amount:                 b.Amount,
+		Description:            b.Description,
+		FundedBy:               fundedBy,
+		CreditCardID:           b.CreditCardID,
+		CreditCardInstallments: b.CreditCardInstallments,
+		ConfirmationToken:      b.ConfirmationToken,
+		ScheduledFor:           b.ScheduledFor,
+	}
+}
 
 func pixTransferHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/transfer")
 		defer span.End()
 
-		var apiReq struct {
-			CustomerID             string  `json:"customerId"`
-			RecipientKey           string  `json:"recipientKey"`
-			RecipientKeyType       string  `json:"recipientKeyType"`
-			Amount                 float64 `json:"amount"`
-			Description            string  `json:"description,omitempty"`
-			FundedBy               string  `json:"fundedBy,omitempty"`
-			CreditCardID           string  `json:"creditCardId,omitempty"`
-			CreditCardInstallments int     `json:"installments,omitempty"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		var apiReq pixTransferBody
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
 			return
 		}
 
@@ -50,25 +82,13 @@ func pixTransferHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 			return
 		}
 
-		fundedBy := apiReq.FundedBy
-		if fundedBy == "" {
-			fundedBy = "balance"
-		}
-
-		req := &domain.PixTransferRequest{
-			IdempotencyKey:         uuid.New().String(),
-			SourceAccountID:        account.ID,
-			DestinationKeyType:     apiReq.RecipientKeyType,
-			DestinationKeyValue:    apiReq.RecipientKey,
-			Amount:                 apiReq.Amount,
-			Description:            apiReq.Description,
-			FundedBy:               fundedBy,
-			CreditCardID:           apiReq.CreditCardID,
-			CreditCardInstallments: apiReq.CreditCardInstallments,
-		}
+		req := apiReq.transferRequest(account.ID)
 
 		transfer, err := bankSvc.CreatePixTransfer(ctx, apiReq.CustomerID, req)
 		if err != nil {
+			if writePixConfirmation(w, err, bankSvc.MasksPII()) {
+				return
+			}
 			handleServiceError(w, err, logger)
 			return
 		}
@@ -82,11 +102,12 @@ func pixTransferHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 		resp := domain.PixTransferResponse{
 			TransactionID: transfer.ID,
 			Status:        transfer.Status,
-			Amount:        transfer.Amount,
-			NewBalance:    newBalance,
+			Amount:        service.RoundMoney(transfer.Amount),
+			NewBalance:    service.RoundMoney(newBalance),
 			Timestamp:     transfer.CreatedAt.Format(time.RFC3339),
 			E2EID:         transfer.EndToEndID,
 			ReceiptID:     transfer.ReceiptID,
+			Warnings:      transfer.Warnings,
 			Recipient: &domain.PixRecipient{
 				Name:     transfer.DestinationName,
 				Document: transfer.DestinationDocument,
@@ -97,26 +118,61 @@ func pixTransferHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 				},
 			},
 		}
+		if transfer.ScheduledFor != nil {
+			resp.ScheduledFor = transfer.ScheduledFor.Format(time.RFC3339)
+		}
 
 		writeJSON(w, http.StatusCreated, resp)
 	}
 }
 
+// pixTransferValidateHandler runs the checks of POST /v1/pix/transfer
+// without persisting or debiting. A refused transfer is still a 200, with
+// canProceed false and the blocking reason.
+func pixTransferValidateHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/transfer/validate")
+		defer span.End()
+
+		var apiReq pixTransferBody
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
+			return
+		}
+
+		account, err := bankSvc.GetPrimaryAccount(ctx, apiReq.CustomerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		result, err := bankSvc.ValidatePixTransfer(ctx, apiReq.CustomerID, apiReq.transferRequest(account.ID))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		if bankSvc.MasksPII() && result.Recipient != nil {
+			recipient := *result.Recipient
+			recipient.Document = service.MaskDocument(recipient.Document)
+			result.Recipient = &recipient
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
 func pixCreditCardHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/credit-card")
 		defer span.End()
 
 		var apiReq domain.PixCreditCardRequest
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
 			return
 		}
 
 		if apiReq.Installments <= 0 {
 			apiReq.Installments = 1
 		}
-		if apiReq.Installments > PixCreditMaxInstallments {
+		if apiReq.Installments > service.PixCreditMaxInstallments {
 			writeError(w, http.StatusBadRequest, "installments must be between 1 and 12")
 			return
 		}
@@ -139,10 +195,8 @@ func pixCreditCardHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 			return
 		}
 
-		totalWithFees := apiReq.Amount * (1 + PixCreditFeeRate*float64(apiReq.Installments-1))
-
 		req := &domain.PixTransferRequest{
-			IdempotencyKey:         uuid.New().String(),
+			IdempotencyKey:         idempotencyKey(apiReq.IdempotencyKey),
 			SourceAccountID:        account.ID,
 			DestinationKeyType:     apiReq.RecipientKeyType,
 			DestinationKeyValue:    apiReq.RecipientKey,
@@ -151,12 +205,14 @@ func pixCreditCardHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 			FundedBy:               "credit_card",
 			CreditCardID:           apiReq.CreditCardID,
 			CreditCardInstallments: apiReq.Installments,
-			FeeRate:                PixCreditFeeRate,
-			TotalWithFees:          totalWithFees,
+			ConfirmationToken:      apiReq.ConfirmationToken,
 		}
 
 		transfer, err := bankSvc.CreatePixTransfer(ctx, apiReq.CustomerID, req)
 		if err != nil {
+			if writePixConfirmation(w, err, bankSvc.MasksPII()) {
+				return
+			}
 			handleServiceError(w, err, logger)
 			return
 		}
@@ -164,7 +220,7 @@ func pixCreditCardHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 		resp := domain.PixCreditCardResponse{
 			TransactionID: transfer.ID,
 			Status:        transfer.Status,
-			Amount:        apiReq.Amount,
+			Amount:        service.RoundMoney(apiReq.Amount),
 			Recipient: &domain.PixRecipient{
 				Name: transfer.DestinationName,
 				PixKey: &domain.PixKeyInfo{
@@ -174,8 +230,132 @@ func pixCreditCardHandler(bankSvc *service.BankingService, logger *zap.Logger) h
 			},
 			Timestamp: transfer.CreatedAt.Format(time.RFC3339),
 			ReceiptID: transfer.ReceiptID,
+			Warnings:  transfer.Warnings,
 		}
 
 		writeJSON(w, http.StatusCreated, resp)
 	}
 }
+
+// pixCreditCardQuoteHandler prices a PIX via credit card without executing
+// it. A transfer the card or PIX limits would refuse is still a 200, with
+// canProceed false and the blocking reason.
+func pixCreditCardQuoteHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/credit-card/quote")
+		defer span.End()
+
+		var apiReq domain.PixCreditCardQuoteRequest
+		if !decodeJSON(w, r, &apiReq) {
+			return
+		}
+
+		quote, err := bankSvc.QuotePixCreditCard(ctx, apiReq.CustomerID, &apiReq)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, quote)
+	}
+}
+
+// writePixConfirmation answers 202 with the confirmation token when the
+// transfer was held for confirmation. It reports whether it wrote a response.
+func writePixConfirmation(w http.ResponseWriter, err error, maskPII bool) bool {
+	var confirm *domain.ErrConfirmationRequired
+	if !errors.As(err, &confirm) {
+		return false
+	}
+	c := *confirm.Confirmation
+	if maskPII && c.Recipient != nil {
+		recipient := *c.Recipient
+		recipient.Document = service.MaskDocument(recipient.Document)
+		c.Recipient = &recipient
+	}
+	writeJSON(w, http.StatusAccepted, c)
+	return true
+}
+
+func listPixTransfersHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/pix/transfers")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		page, pageSize := parsePagination(r)
+		q := r.URL.Query()
+
+		transfers, err := bankSvc.ListPixTransfersPage(ctx, customerID, &domain.PixTransferFilter{
+			Status:   q.Get("status"),
+			From:     q.Get("from"),
+			To:       q.Get("to"),
+			Page:     page,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		items := make([]domain.PixTransferListItem, 0, len(transfers.Data))
+		for _, t := range transfers.Data {
+			document := t.DestinationDocument
+			if bankSvc.MasksPII() {
+				document = service.MaskDocument(document)
+			}
+			item := domain.PixTransferListItem{
+				TransactionID: t.ID,
+				Status:        t.Status,
+				Amount:        service.RoundMoney(t.Amount),
+				Description:   t.Description,
+				FundedBy:      t.FundedBy,
+				E2EID:         t.EndToEndID,
+				CreatedAt:     t.CreatedAt.Format(time.RFC3339),
+				Recipient: &domain.PixRecipient{
+					Name:     t.DestinationName,
+					Document: document,
+					PixKey: &domain.PixKeyInfo{
+						Type:  t.DestinationKeyType,
+						Value: t.DestinationKeyValue,
+					},
+				},
+			}
+			if t.ScheduledFor != nil {
+				item.ScheduledFor = t.ScheduledFor.Format(time.RFC3339)
+			}
+			if t.ExecutedAt != nil {
+				item.ExecutedAt = t.ExecutedAt.Format(time.RFC3339)
+			}
+			items = append(items, item)
+		}
+
+		writeJSON(w, http.StatusOK, domain.NewListResponse(items, transfers.Total, transfers.Page, transfers.PageSize))
+	}
+}
+
+// pixRecentRecipientsHandler lists the recipients the customer paid lately
+// by PIX, for a quick repeat transfer.
+func pixRecentRecipientsHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/pix/recent-recipients")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			limit = n
+		}
+
+		recipients, err := bankSvc.GetRecentPixRecipients(ctx, customerID, limit)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"recipients": recipients})
+	}
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/chat"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
@@ -12,7 +14,6 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
@@ -22,51 +23,65 @@ var tracer = otel.Tracer("handler")
 
 // NewRouter creates the HTTP router with all routes and middleware.
 // Routes follow the API contract defined for the PJ Assistant frontend.
+// CORS is not included; wrap the router with CORSMiddleware.
 func NewRouter(svc *service.Assistant, bankSvc *service.BankingService, authSvc *service.AuthService, chatSvc *chat.Service, chatMetrics chat.MetricsRepository, metrics *observability.Metrics, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
 
 	/* Middleware */
-	allowedLocalOrigins := make(map[string]bool)
-	for port := 8080; port <= 8090; port++ {
-		allowedLocalOrigins[fmt.Sprintf("http://localhost:%d", port)] = true
-		allowedLocalOrigins[fmt.Sprintf("http://127.0.0.1:%d", port)] = true
-	}
-	r.Use(cors.Handler(cors.Options{
-		AllowOriginFunc: func(r *http.Request, origin string) bool {
-			if allowedLocalOrigins[origin] {
-				return true
-			}
-			// Produção: qualquer HTTPS
-			return len(origin) > 8 && origin[:8] == "https://"
-		},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(LocaleMiddleware)
 	r.Use(observability.ZapLoggerMiddleware(logger))
+	r.Use(observability.UptimeMiddleware(metrics.Uptime, "bfa-api"))
 	r.Use(observability.TracingMiddleware)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Heartbeat("/ping"))
+	r.Use(CountDomainErrors(metrics))
+
+	// Registered before the /v1 subrouter so it inherits them on mount
+	r.NotFound(routeErrorHandler(http.StatusNotFound, "route not found"))
+	r.MethodNotAllowed(routeErrorHandler(http.StatusMethodNotAllowed, "method not allowed"))
 
 	/* Operational endpoints */
-	r.Get("/healthz", healthzHandler(bankSvc, logger))
-	r.Get("/readyz", readyzHandler())
+	r.Get("/healthz", healthzHandler(svc, bankSvc, metrics, logger))
+	r.Get("/readyz", newReadinessProbe(svc, bankSvc, metrics, logger).handler())
 	r.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	r.Get("/version", versionHandler)
 
 	/* API v1 */
 	r.Route("/v1", func(r chi.Router) {
+		// Mutation routes require an access token with the matching
+		// permission claim (see RequirePermission)
+		pixTransfer := RequirePermission(authSvc, domain.PermissionPixTransfer, logger)
+		cardBlock := RequirePermission(authSvc, domain.PermissionCardBlock, logger)
+		billPay := RequirePermission(authSvc, domain.PermissionBillPay, logger)
+		internalTransfer := RequirePermission(authSvc, domain.PermissionInternalTransfer, logger)
+		docTransfer := RequirePermission(authSvc, domain.PermissionDOCTransfer, logger)
+		cardManage := RequirePermission(authSvc, domain.PermissionCardManage, logger)
+		debitPurchase := RequirePermission(authSvc, domain.PermissionDebitPurchase, logger)
+		invoicePay := RequirePermission(authSvc, domain.PermissionInvoicePay, logger)
+		limitsUpdate := RequirePermission(authSvc, domain.PermissionLimitsUpdate, logger)
+
+		// Banking routes answer 503 when Supabase is not configured
+		bank := r.With(RequireService(bankSvc != nil, "banking", logger))
+
+		// Writes to banking data are registered on write, which answers 503
+		// in read-only mode; dry runs (validate, preview, quote) stay on bank
+		readOnly := bankSvc != nil && bankSvc.ReadOnly()
+		write := bank
+		if readOnly {
+			write = bank.With(ReadOnlyMiddleware(logger))
+		}
 
 		/*
 		 * 1. Assistente IA
 		 */
 		// GET  — rota do case: busca profile+transactions+agent via query param ?q=
 		// POST — mesma lógica mas recebe message via body JSON
-		r.Get("/assistant/{customerId}", assistantGetHandler(svc, logger))
-		r.Post("/assistant/{customerId}", assistantHandler(svc, logger))
+		// O token é opcional; sem ele o agente não executa ferramentas bancárias
+		assistant := r.With(OptionalJWTAuth(authSvc, logger))
+		assistant.Get("/assistant/{customerId}", assistantGetHandler(svc, logger))
+		assistant.Post("/assistant/{customerId}", assistantHandler(svc, logger))
 
 		/*
 		 * 2. Cliente
@@ -77,7 +92,11 @@ func NewRouter(svc *service.Assistant, bankSvc *service.BankingService, authSvc
 		 * 3. Transações
 		 */
 		r.Get("/customers/{customerId}/transactions", getTransactionsHandler(svc, logger))
-		r.Get("/customers/{customerId}/transactions/summary", getTransactionsSummaryHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/transactions/summary", getTransactionsSummaryHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/transactions/search", searchTransactionsHandler(bankSvc, logger))
+		write.Put("/customers/{customerId}/transactions/{transactionId}/annotate", annotateTransactionHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/statements/export", statementExportHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/jobs/{jobId}", getJobHandler(bankSvc, logger))
 
 		/*
 		 * 4. Métricas
@@ -87,106 +106,158 @@ func NewRouter(svc *service.Assistant, bankSvc *service.BankingService, authSvc
 		/*
 		 * 5. Pix
 		 */
-		r.Get("/pix/keys/lookup", pixKeyLookupHandler(bankSvc, logger))
-		r.Get("/pix/lookup", pixKeyLookupHandler(bankSvc, logger))
-		r.Post("/pix/transfer", pixTransferHandler(bankSvc, logger))
-		r.Post("/pix/schedule", pixScheduleHandler(bankSvc, logger))
-		r.Delete("/pix/schedule/{scheduleId}", pixScheduleDeleteHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/pix/scheduled", pixScheduledListHandler(bankSvc, logger))
-		r.Get("/pix/scheduled/{customerId}", pixScheduledListByParamHandler(bankSvc, logger))
-		r.Post("/pix/credit-card", pixCreditCardHandler(bankSvc, logger))
-		r.Post("/pix/credit", pixCreditCardHandler(bankSvc, logger))
-		r.Delete("/pix/keys", pixKeyDeleteByValueHandler(bankSvc, logger))
-		r.Get("/pix/receipts/{receiptId}", getPixReceiptHandler(bankSvc, logger))
-		r.Get("/pix/transfers/{transferId}/receipt", getPixReceiptByTransferHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/pix/receipts", listPixReceiptsHandler(bankSvc, logger))
+		bank.Get("/pix/keys/lookup", pixKeyLookupHandler(bankSvc, logger))
+		bank.Get("/pix/fees", pixFeesHandler(bankSvc))
+		bank.Get("/pix/lookup", pixKeyLookupHandler(bankSvc, logger))
+		write.With(pixTransfer).Post("/pix/transfer", pixTransferHandler(bankSvc, logger))
+		bank.Post("/pix/transfer/validate", pixTransferValidateHandler(bankSvc, logger))
+		write.With(pixTransfer).Post("/pix/schedule", pixScheduleHandler(bankSvc, logger))
+		bank.Post("/pix/schedule/preview", pixSchedulePreviewHandler(bankSvc, logger))
+		write.Delete("/pix/schedule/{scheduleId}", pixScheduleDeleteHandler(bankSvc, logger))
+		write.Post("/pix/schedule/{scheduleId}/skip-next", pixScheduleSkipNextHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/scheduled", pixScheduledListHandler(bankSvc, logger))
+		bank.Get("/pix/scheduled/{customerId}", pixScheduledListByParamHandler(bankSvc, logger))
+		write.With(pixTransfer).Post("/pix/credit-card", pixCreditCardHandler(bankSvc, logger))
+		bank.Post("/pix/credit-card/quote", pixCreditCardQuoteHandler(bankSvc, logger))
+		write.With(pixTransfer).Post("/pix/credit", pixCreditCardHandler(bankSvc, logger))
+		write.Delete("/pix/keys", pixKeyDeleteByValueHandler(bankSvc, logger))
+		bank.Get("/pix/receipts/{receiptId}", getPixReceiptHandler(bankSvc, logger))
+		bank.Get("/pix/receipts/{receiptId}/share-token", pixReceiptShareTokenHandler(bankSvc, logger))
+		bank.Get("/pix/receipts/shared/{token}", getSharedPixReceiptHandler(bankSvc, logger))
+		bank.Get("/pix/transfers/{transferId}/receipt", getPixReceiptByTransferHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/transfers", listPixTransfersHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/recent-recipients", pixRecentRecipientsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/receipts", listPixReceiptsHandler(bankSvc, logger))
 
 		/*
 		 * 6. Pagamento de Boletos
 		 */
-		r.Post("/bills/validate", billsValidateHandler(bankSvc, logger))
-		r.Post("/bills/pay", billsPayHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/bills/history", billsHistoryHandler(bankSvc, logger))
+		bank.Post("/bills/validate", billsValidateHandler(bankSvc, logger))
+		write.With(billPay).Post("/bills/pay", billsPayHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/bills/history", billsHistoryHandler(bankSvc, logger))
+		write.With(billPay).Post("/customers/{customerId}/bills/{billId}/cancel", billCancelHandler(bankSvc, logger))
 
 		/*
 		 * 7. Cartão de Crédito
 		 */
-		r.Get("/customers/{customerId}/cards", listCardsHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/credit-cards", listCardsHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/cards/available", availableCardsHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/credit-cards/available", availableCardsHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/credit-limit", creditLimitHandler(bankSvc, logger))
-		r.Post("/cards/request", cardRequestHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/credit-cards/request", cardRequestHandler(bankSvc, logger))
-		r.Get("/cards/{cardId}/invoices/{month}", cardInvoiceByMonthHandler(bankSvc, logger))
-		r.Post("/cards/{cardId}/block", cardBlockHandler(bankSvc, logger))
-		r.Post("/cards/{cardId}/unblock", cardUnblockHandler(bankSvc, logger))
-		r.Post("/cards/{cardId}/cancel", cardCancelHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/credit-cards/{cardId}/block", cardBlockHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/credit-cards/{cardId}/unblock", cardUnblockHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/credit-cards/{cardId}/cancel", cardCancelHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/credit-cards/{cardId}/invoice", cardInvoiceCurrentHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/cards", listCardsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-cards", listCardsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/cards/available", availableCardsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-cards/available", availableCardsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-limit", creditLimitHandler(bankSvc, logger))
+		write.Post("/cards/request", cardRequestHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/credit-cards/request", cardRequestHandler(bankSvc, logger))
+		bank.Get("/cards/{cardId}/invoices/{month}", cardInvoiceByMonthHandler(bankSvc, logger))
+		write.With(cardBlock).Post("/cards/{cardId}/block", cardBlockHandler(bankSvc, logger))
+		write.With(cardBlock).Post("/cards/{cardId}/unblock", cardUnblockHandler(bankSvc, logger))
+		write.With(cardManage).Post("/cards/{cardId}/cancel", cardCancelHandler(bankSvc, logger))
+		write.With(cardBlock).Post("/customers/{customerId}/credit-cards/{cardId}/block", cardBlockHandler(bankSvc, logger))
+		write.With(cardBlock).Post("/customers/{customerId}/credit-cards/{cardId}/unblock", cardUnblockHandler(bankSvc, logger))
+		write.With(cardManage).Post("/customers/{customerId}/credit-cards/{cardId}/cancel", customerCardCancelHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-cards/{cardId}/invoice", cardInvoiceCurrentHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-cards/{cardId}/invoices", listCardInvoicesHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/credit-cards/{cardId}/transactions", listCardTransactionsHandler(bankSvc, logger))
 
 		/*
 		 * 8. Análise Financeira & Débito
 		 */
-		r.Get("/customers/{customerId}/financial/summary", financialSummaryHandler(bankSvc, logger))
-		r.Post("/debit/purchase", debitPurchaseHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/financial/summary", financialSummaryHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/dashboard", dashboardHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/activity", activityFeedHandler(bankSvc, logger))
+		write.With(debitPurchase).Post("/debit/purchase", debitPurchaseHandler(bankSvc, logger))
+		write.With(debitPurchase).Post("/debit/{transactionId}/refund", debitRefundHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/debit/purchases", debitPurchasesListHandler(bankSvc, logger))
 
 		/*
 		 * Extra internal endpoints
 		 */
-		r.Get("/customers/{customerId}/accounts", listAccountsHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/accounts/{accountId}", getAccountHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/accounts/{accountId}/balance", getBalanceHandler(bankSvc, logger))
-		r.Get("/customers/{customerId}/pix/keys", listPixKeysHandler(bankSvc, logger))
-		r.Delete("/customers/{customerId}/pix/keys/{keyId}", deletePixKeyHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/accounts", listAccountsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/accounts/{accountId}", getAccountHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/accounts/{accountId}/balance", getBalanceHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/accounts/{accountId}/overdraft", getOverdraftHandler(bankSvc, logger))
+		write.With(limitsUpdate).Put("/customers/{customerId}/accounts/{accountId}/overdraft", setOverdraftHandler(bankSvc, logger))
+		write.With(internalTransfer).Post("/customers/{customerId}/transfers/internal", internalTransferHandler(bankSvc, logger))
+		write.With(docTransfer).Post("/transfers/doc", docTransferHandler(bankSvc, logger))
+		bank.Get("/transfers/{transferId}/receipt", getTransferReceiptHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/keys", listPixKeysHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/keys/{keyId}", getPixKeyHandler(bankSvc, logger))
+		write.Delete("/customers/{customerId}/pix/keys/{keyId}", deletePixKeyHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/pix/keys/{keyId}/reactivate", reactivatePixKeyHandler(bankSvc, logger))
 
 		// Favorites
-		r.Get("/customers/{customerId}/favorites", listFavoritesHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/favorites", createFavoriteHandler(bankSvc, logger))
-		r.Delete("/customers/{customerId}/favorites/{favoriteId}", deleteFavoriteHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/favorites", listFavoritesHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/favorites", createFavoriteHandler(bankSvc, logger))
+		write.Delete("/customers/{customerId}/favorites/{favoriteId}", deleteFavoriteHandler(bankSvc, logger))
 
 		// Transaction Limits
-		r.Get("/customers/{customerId}/limits", listLimitsHandler(bankSvc, logger))
-		r.Put("/customers/{customerId}/limits/{limitType}", updateLimitHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/limits", listLimitsHandler(bankSvc, logger))
+		write.With(limitsUpdate).Put("/customers/{customerId}/limits/{limitType}", updateLimitHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/pix/limits", pixLimitsSummaryHandler(bankSvc, logger))
 
 		// Notifications
-		r.Get("/customers/{customerId}/notifications", listNotificationsHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/notifications/{notifId}/read", markNotificationReadHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/notifications", listNotificationsHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/notifications/preferences", getNotificationPreferencesHandler(bankSvc, logger))
+		write.Put("/customers/{customerId}/notifications/preferences", updateNotificationPreferencesHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/notifications/read", markNotificationsReadHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/notifications/{notifId}", getNotificationHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/notifications/{notifId}/read", markNotificationReadHandler(bankSvc, logger))
 
 		// Budgets
-		r.Get("/customers/{customerId}/analytics/budgets", listBudgetsHandler(bankSvc, logger))
-		r.Post("/customers/{customerId}/analytics/budgets", createBudgetHandler(bankSvc, logger))
-		r.Put("/customers/{customerId}/analytics/budgets/{budgetId}", updateBudgetHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/analytics/budgets", listBudgetsHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/analytics/budgets", createBudgetHandler(bankSvc, logger))
+		write.Put("/customers/{customerId}/analytics/budgets/{budgetId}", updateBudgetHandler(bankSvc, logger))
+
+		// Category rules
+		bank.Get("/customers/{customerId}/analytics/category-rules", listCategoryRulesHandler(bankSvc, logger))
+		write.Put("/customers/{customerId}/analytics/category-rules", upsertCategoryRuleHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/analytics/digest", spendingDigestHandler(bankSvc, logger))
+
+		// Webhooks
+		write.Post("/customers/{customerId}/webhooks", registerWebhookHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/webhooks", listWebhooksHandler(bankSvc, logger))
+		write.Delete("/customers/{customerId}/webhooks/{webhookId}", revokeWebhookHandler(bankSvc, logger))
+		bank.Get("/customers/{customerId}/webhooks/{webhookId}/deliveries", listWebhookDeliveriesHandler(bankSvc, logger))
+		write.Post("/customers/{customerId}/webhooks/{webhookId}/deliveries/{deliveryId}/resend", resendWebhookDeliveryHandler(bankSvc, logger))
 
 		/*
 		 * Pix Key Registration
 		 */
-		r.Post("/pix/keys/register", pixKeyRegisterHandler(bankSvc, logger))
+		write.Post("/pix/keys/verify-request", pixKeyVerifyRequestHandler(bankSvc, logger))
+		write.Post("/pix/keys/register", pixKeyRegisterHandler(bankSvc, logger))
 
 		/*
 		 * Invoice Payment
 		 */
-		r.Post("/customers/{customerId}/credit-cards/{cardId}/invoice/pay", invoicePayHandler(bankSvc, logger))
+		write.With(invoicePay).Post("/customers/{customerId}/credit-cards/{cardId}/invoice/pay", invoicePayHandler(bankSvc, logger))
 
 		/*
-		 * Dev Tools (testing helpers)
+		 * Dev Tools (testing helpers) — only registered when enabled, and
+		 * every call must carry the shared dev secret
 		 */
-		r.Post("/dev/add-balance", devAddBalanceHandler(bankSvc, logger))
-		r.Post("/dev/set-credit-limit", devSetCreditLimitHandler(bankSvc, logger))
-		r.Post("/dev/generate-transactions", devGenerateTransactionsHandler(bankSvc, logger))
-		r.Post("/dev/add-card-purchase", devAddCardPurchaseHandler(bankSvc, logger))
-		r.Post("/dev/card-purchase", devAddCardPurchaseHandler(bankSvc, logger))
+		if bankSvc != nil && bankSvc.DevToolsEnabled() {
+			r.Route("/dev", func(r chi.Router) {
+				r.Use(requireDevSecret(bankSvc, logger))
+				devWrite := r
+				if readOnly {
+					devWrite = r.With(ReadOnlyMiddleware(logger))
+				}
+				devWrite.Post("/add-balance", devAddBalanceHandler(bankSvc, logger))
+				devWrite.Post("/set-credit-limit", devSetCreditLimitHandler(bankSvc, logger))
+				devWrite.Post("/generate-transactions", devGenerateTransactionsHandler(bankSvc, logger))
+				devWrite.Post("/add-card-purchase", devAddCardPurchaseHandler(bankSvc, logger))
+				devWrite.Post("/card-purchase", devAddCardPurchaseHandler(bankSvc, logger))
+				devWrite.Post("/reset-customer", devResetCustomerHandler(bankSvc, logger))
+				r.Get("/reconcile/{customerId}", devReconcileHandler(bankSvc, logger))
+				devWrite.Post("/cards/{cardId}/close-invoice", devCloseInvoiceHandler(bankSvc, logger))
+			})
+		}
 
 		/*
 		 * 9. Autenticação
 		 */
 		r.Route("/auth", func(r chi.Router) {
 			if authSvc == nil {
-				r.Handle("/*", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					writeError(w, http.StatusServiceUnavailable, "auth service unavailable: Supabase not configured")
-				}))
+				r.Handle("/*", serviceUnavailableHandler("auth", logger))
 				return
 			}
 			// Public routes
@@ -194,13 +265,18 @@ func NewRouter(svc *service.Assistant, bankSvc *service.BankingService, authSvc
 			r.Post("/login", authLoginHandler(authSvc, logger))
 			r.Post("/refresh", authRefreshHandler(authSvc, logger))
 			r.Post("/password/reset-request", authPasswordResetRequestHandler(authSvc, logger))
+			r.Post("/password/reset-resend", authPasswordResetResendHandler(authSvc, logger))
 			r.Post("/password/reset-confirm", authPasswordResetConfirmHandler(authSvc, logger))
+			// Validates the Bearer token itself; expired tokens are inactive, not 401
+			r.Get("/introspect", authIntrospectHandler(authSvc, logger))
 
 			// Protected routes
 			r.Group(func(r chi.Router) {
 				r.Use(JWTAuthMiddleware(authSvc, logger))
 				r.Post("/logout", authLogoutHandler(authSvc, logger))
 				r.Put("/password", authChangePasswordHandler(authSvc, logger))
+				r.Get("/companies", authCompaniesHandler(authSvc, logger))
+				r.Post("/switch-company", authSwitchCompanyHandler(authSvc, logger))
 			})
 		})
 
@@ -231,27 +307,51 @@ func NewRouter(svc *service.Assistant, bankSvc *service.BankingService, authSvc
  * Operational handlers (healthz, readyz, agent metrics)
  */
 
-func healthzHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+// routeErrorHandler answers unknown routes and methods with the JSON error
+// envelope instead of chi's plain-text default.
+func routeErrorHandler(status int, msg string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, status, errorResponse{Error: msg, RequestID: middleware.GetReqID(r.Context())})
+	}
+}
+
+// versionHandler reports the running build, to tell environments apart
+// during incident triage.
+func versionHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+func healthzHandler(assistant *service.Assistant, bankSvc *service.BankingService, metrics *observability.Metrics, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		now := time.Now().Format(time.RFC3339)
+		uptime := metrics.Uptime
 
 		services := []domain.ServiceHealth{
-			{Name: "bfa-api", Status: "healthy", LatencyMs: 0, UptimePercent: 99.99, LastChecked: now},
+			{
+				Name:          "bfa-api",
+				Status:        "healthy",
+				LatencyMs:     uptime.AvgLatency("bfa-api").Milliseconds(),
+				UptimePercent: uptime.UptimePercent("bfa-api"),
+				LastChecked:   now,
+			},
 		}
 
 		if bankSvc != nil {
-			start := time.Now()
-			_, err := bankSvc.ListAccounts(ctx, "health-check")
-			latency := time.Since(start).Milliseconds()
-			status := "healthy"
-			if err != nil {
-				status = "degraded"
+			health, _ := probeSupabase(ctx, bankSvc, uptime)
+			services = append(services, health)
+		}
+
+		if assistant != nil {
+			agentCtx, cancel := context.WithTimeout(ctx, agentHealthTimeout)
+			health, checked, err := probeAgent(agentCtx, assistant, uptime)
+			cancel()
+			if checked {
+				if err != nil {
+					logger.Warn("agent health probe failed", zap.Error(err))
+				}
+				services = append(services, health)
 			}
-			services = append(services, domain.ServiceHealth{
-				Name: "supabase", Status: status, LatencyMs: latency,
-				UptimePercent: 99.9, LastChecked: now,
-			})
 		}
 
 		overallStatus := "healthy"
@@ -266,8 +366,9 @@ func healthzHandler(bankSvc *service.BankingService, logger *zap.Logger) http.Ha
 		}
 
 		writeJSON(w, http.StatusOK, domain.HealthStatus{
-			Status:   overallStatus,
-			Services: services,
+			Status:        overallStatus,
+			UptimeSeconds: int64(uptime.Uptime().Seconds()),
+			Services:      services,
 		})
 	}
 }
@@ -279,8 +380,118 @@ func agentMetricsHandler(metrics *observability.Metrics, logger *zap.Logger) htt
 	}
 }
 
-func readyzHandler() http.HandlerFunc {
+// probeSupabase runs a lightweight Supabase query, records the outcome in
+// the uptime tracker and reports its health.
+func probeSupabase(ctx context.Context, bankSvc *service.BankingService, uptime *observability.UptimeTracker) (domain.ServiceHealth, error) {
+	start := time.Now()
+	_, err := bankSvc.ListAccounts(ctx, "health-check")
+	latency := time.Since(start)
+	uptime.Record("supabase", err == nil, latency)
+
+	status := "healthy"
+	if err != nil {
+		status = "degraded"
+	}
+	return domain.ServiceHealth{
+		Name: "supabase", Status: status, LatencyMs: latency.Milliseconds(),
+		UptimePercent: uptime.UptimePercent("supabase"), LastChecked: time.Now().Format(time.RFC3339),
+	}, err
+}
+
+// probeAgent pings the agent API, records the outcome in the uptime tracker
+// and reports its health. checked is false when the agent client has no
+// health check. An open circuit breaker is reported as degraded without
+// calling the API.
+func probeAgent(ctx context.Context, assistant *service.Assistant, uptime *observability.UptimeTracker) (health domain.ServiceHealth, checked bool, err error) {
+	start := time.Now()
+	checked, err = assistant.PingAgent(ctx)
+	if !checked {
+		return domain.ServiceHealth{}, false, nil
+	}
+	latency := time.Since(start)
+	uptime.Record("agent", err == nil, latency)
+
+	status := "healthy"
+	if err != nil {
+		status = "degraded"
+	}
+	return domain.ServiceHealth{
+		Name: "agent", Status: status, LatencyMs: latency.Milliseconds(),
+		UptimePercent: uptime.UptimePercent("agent"), LastChecked: time.Now().Format(time.RFC3339),
+	}, true, err
+}
+
+// agentHealthTimeout bounds the agent ping of /healthz, which must stay fast
+// even when the agent hangs.
+const agentHealthTimeout = 1 * time.Second
+
+const (
+	readinessTimeout  = 2 * time.Second // per probe run
+	readinessCacheTTL = 5 * time.Second // reuse the last result to avoid hammering dependencies
+)
+
+// readinessProbe checks Supabase and, when configured, the agent API.
+// Results are cached for readinessCacheTTL.
+type readinessProbe struct {
+	assistant *service.Assistant
+	bankSvc   *service.BankingService
+	metrics   *observability.Metrics
+	logger    *zap.Logger
+
+	mu        sync.Mutex
+	last      domain.ReadinessStatus
+	checkedAt time.Time
+}
+
+func newReadinessProbe(assistant *service.Assistant, bankSvc *service.BankingService, metrics *observability.Metrics, logger *zap.Logger) *readinessProbe {
+	return &readinessProbe{assistant: assistant, bankSvc: bankSvc, metrics: metrics, logger: logger}
+}
+
+func (p *readinessProbe) handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		status := p.check(r.Context())
+		code := http.StatusOK
+		if status.Status != "ready" {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, status)
+	}
+}
+
+func (p *readinessProbe) check(ctx context.Context) domain.ReadinessStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < readinessCacheTTL {
+		return p.last
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	status := domain.ReadinessStatus{Status: "ready"}
+	fail := func(name string, err error) {
+		status.Status = "not_ready"
+		status.Failed = append(status.Failed, name)
+		p.logger.Warn("readiness probe failed", zap.String("dependency", name), zap.Error(err))
+	}
+
+	if p.bankSvc != nil {
+		health, err := probeSupabase(ctx, p.bankSvc, p.metrics.Uptime)
+		status.Services = append(status.Services, health)
+		if err != nil {
+			fail("supabase", err)
+		}
+	}
+
+	if p.assistant != nil {
+		if health, checked, err := probeAgent(ctx, p.assistant, p.metrics.Uptime); checked {
+			if err != nil {
+				fail("agent", err)
+			}
+			status.Services = append(status.Services, health)
+		}
+	}
+
+	p.last, p.checkedAt = status, time.Now()
+	return status
 }
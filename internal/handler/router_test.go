@@ -1,12 +1,26 @@
 package handler_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/buildinfo"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/handler"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/cache"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/client"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
 	"go.uber.org/zap"
 )
@@ -24,6 +38,28 @@ func TestHealthz(t *testing.T) {
 	}
 }
 
+func TestVersion_ReturnsInjectedBuildInfo(t *testing.T) {
+	defer func(v, c, b string) { buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime = v, c, b }(buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime)
+	buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime = "1.4.0", "abc1234", "2026-10-01T12:00:00Z"
+	router := handler.NewRouter(nil, nil, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var got buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	want := buildinfo.Info{Version: "1.4.0", Commit: "abc1234", BuildTime: "2026-10-01T12:00:00Z", GoVersion: runtime.Version()}
+	if got != want {
+		t.Errorf("version = %+v, want %+v", got, want)
+	}
+}
+
 func TestReadyz(t *testing.T) {
 	router := handler.NewRouter(nil, nil, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
 
@@ -49,3 +85,371 @@ func TestMetrics(t *testing.T) {
 		t.Errorf("expected 200, got %d", rec.Code)
 	}
 }
+
+// failingAccountsStore makes the Supabase readiness probe fail.
+type failingAccountsStore struct {
+	port.BankingStore
+	calls atomic.Int32
+}
+
+func (s *failingAccountsStore) ListAccounts(context.Context, string) ([]domain.Account, error) {
+	s.calls.Add(1)
+	return nil, errors.New("connection refused")
+}
+
+func TestReadyz_DependencyDown(t *testing.T) {
+	store := &failingAccountsStore{}
+	bankSvc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	var body domain.ReadinessStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Status != "not_ready" || len(body.Failed) != 1 || body.Failed[0] != "supabase" {
+		t.Errorf("unexpected readiness body: %+v", body)
+	}
+
+	// A second probe within the cache window does not hit Supabase again.
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected cached 503, got %d", rec.Code)
+	}
+	if store.calls.Load() != 1 {
+		t.Errorf("expected 1 Supabase probe, got %d", store.calls.Load())
+	}
+}
+
+func TestReadyz_AgentDown(t *testing.T) {
+	agentAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer agentAPI.Close()
+
+	agent := client.NewAgentClient(agentAPI.Client(), agentAPI.URL, resilience.NewCircuitBreaker("agent"), resilience.Config{})
+	metrics := observability.NewMetrics()
+	assistant := service.NewAssistant(nil, nil, agent, cache.New[any](time.Minute), metrics, zap.NewNop())
+	router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	var body domain.ReadinessStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Failed) != 1 || body.Failed[0] != "agent" {
+		t.Errorf("expected agent to be reported as failed, got %+v", body)
+	}
+}
+
+func TestHealthz_ReportsAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		agentStatus int
+		openBreaker bool
+		wantStatus  string
+		wantCalls   int32
+	}{
+		{"agent up", http.StatusOK, false, "healthy", 1},
+		{"agent down", http.StatusBadGateway, false, "degraded", 1},
+		{"breaker open", http.StatusOK, true, "degraded", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			agentAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.agentStatus)
+			}))
+			defer agentAPI.Close()
+
+			cb := resilience.NewCircuitBreaker("agent-healthz-" + tt.name)
+			if tt.openBreaker {
+				for i := 0; i < 5; i++ {
+					_, _ = cb.Execute(func() (any, error) { return nil, errors.New("agent timeout") })
+				}
+			}
+			agent := client.NewAgentClient(agentAPI.Client(), agentAPI.URL, cb, resilience.Config{})
+			metrics := observability.NewMetrics()
+			assistant := service.NewAssistant(nil, nil, agent, cache.New[any](time.Minute), metrics, zap.NewNop())
+			router := handler.NewRouter(assistant, nil, nil, nil, nil, metrics, zap.NewNop())
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+
+			var body domain.HealthStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			var agentHealth *domain.ServiceHealth
+			for i := range body.Services {
+				if body.Services[i].Name == "agent" {
+					agentHealth = &body.Services[i]
+				}
+			}
+			if agentHealth == nil {
+				t.Fatalf("agent missing from services: %+v", body.Services)
+			}
+			if agentHealth.Status != tt.wantStatus {
+				t.Errorf("agent status = %q, want %q", agentHealth.Status, tt.wantStatus)
+			}
+			if body.Status != tt.wantStatus {
+				t.Errorf("overall status = %q, want %q", body.Status, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("agent API called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_Allowlist(t *testing.T) {
+	router := handler.CORSMiddleware(handler.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "http://localhost:*"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+	})(handler.NewRouter(nil, nil, nil, nil, nil, observability.NewMetrics(), zap.NewNop()))
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"exact match", "https://app.example.com", true},
+		{"wildcard port", "http://localhost:5173", true},
+		{"other https origin", "https://evil.example.com", false},
+		{"scheme mismatch", "http://app.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Preflight
+			req := httptest.NewRequest(http.MethodOptions, "/healthz", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", "Authorization")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			gotOrigin := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed {
+				if gotOrigin != tt.origin {
+					t.Errorf("preflight Allow-Origin = %q, want %q", gotOrigin, tt.origin)
+				}
+				if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+					t.Error("preflight missing Allow-Credentials")
+				}
+			} else if gotOrigin != "" || rec.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("disallowed origin got CORS headers: %v", rec.Header())
+			}
+
+			// Actual request
+			req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			gotOrigin = rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && gotOrigin != tt.origin {
+				t.Errorf("Allow-Origin = %q, want %q", gotOrigin, tt.origin)
+			}
+			if !tt.allowed && gotOrigin != "" {
+				t.Errorf("disallowed origin echoed: %q", gotOrigin)
+			}
+		})
+	}
+}
+
+// accountStore serves a single account with a fixed balance.
+type accountStore struct {
+	port.BankingStore
+}
+
+func (accountStore) GetAccount(_ context.Context, customerID, accountID string) (*domain.Account, error) {
+	return &domain.Account{ID: accountID, CustomerID: customerID, Balance: 1500, AvailableBalance: 1500, Currency: "BRL"}, nil
+}
+
+func TestMaxBodySizeMiddleware_RejectsOversizedBody(t *testing.T) {
+	authSvc := service.NewAuthService(newCustomerAuthStore{}, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	router := handler.MaxBodySizeMiddleware(64, 1024, zap.NewNop())(
+		handler.NewRouter(nil, nil, authSvc, nil, nil, observability.NewMetrics(), zap.NewNop()))
+
+	oversized := `{"cnpj":"12345678000190","password":"123456","name":"` + strings.Repeat("x", 100) + `"}`
+
+	// Declared length over the limit is refused before reaching the handler
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(oversized))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("with Content-Length: expected 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Without a length the body is cut while being decoded
+	req = httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(oversized))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked: expected 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Upload routes get the larger limit (503 here: no banking service)
+	req = httptest.NewRequest(http.MethodPost, "/v1/bills/validate", strings.NewReader(oversized))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code == http.StatusRequestEntityTooLarge {
+		t.Errorf("upload route: got 413, want the upload limit to apply")
+	}
+}
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	bankSvc := service.NewBankingService(accountStore{}, observability.NewMetrics(), zap.NewNop())
+	bankSvc.SetReadOnly(true)
+	bankSvc.SetDevTools(true, "dev-secret")
+	router := handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"pix transfer blocked", http.MethodPost, "/v1/pix/transfer", http.StatusServiceUnavailable},
+		{"invoice payment blocked", http.MethodPost, "/v1/customers/cust-1/credit-cards/card-1/invoice/pay", http.StatusServiceUnavailable},
+		{"card block blocked", http.MethodPost, "/v1/cards/card-1/block", http.StatusServiceUnavailable},
+		{"bill cancel blocked", http.MethodPost, "/v1/customers/cust-1/bills/bill-1/cancel", http.StatusServiceUnavailable},
+		{"debit refund blocked", http.MethodPost, "/v1/debit/tx-1/refund", http.StatusServiceUnavailable},
+		{"overdraft change blocked", http.MethodPut, "/v1/customers/cust-1/accounts/acct-1/overdraft", http.StatusServiceUnavailable},
+		{"schedule skip blocked", http.MethodPost, "/v1/pix/schedule/sched-1/skip-next", http.StatusServiceUnavailable},
+		{"annotation blocked", http.MethodPut, "/v1/customers/cust-1/transactions/tx-1/annotate", http.StatusServiceUnavailable},
+		{"dev close invoice blocked", http.MethodPost, "/v1/dev/cards/card-1/close-invoice", http.StatusServiceUnavailable},
+		{"balance read allowed", http.MethodGet, "/v1/customers/cust-1/accounts/acct-1/balance", http.StatusOK},
+		{"bill validation allowed", http.MethodPost, "/v1/bills/validate", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Dev-Secret", "dev-secret")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDevRoutes_GatedByFlagAndSecret(t *testing.T) {
+	newRouter := func(enabled bool) http.Handler {
+		bankSvc := service.NewBankingService(nil, observability.NewMetrics(), zap.NewNop())
+		bankSvc.SetDevTools(enabled, "dev-secret")
+		return handler.NewRouter(nil, bankSvc, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+	}
+	post := func(router http.Handler, path, secret string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("not json"))
+		if secret != "" {
+			req.Header.Set("X-Dev-Secret", secret)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	off := newRouter(false)
+	for _, path := range []string{"/v1/dev/add-balance", "/v1/dev/set-credit-limit", "/v1/dev/generate-transactions", "/v1/dev/reset-customer"} {
+		if code := post(off, path, "dev-secret"); code != http.StatusNotFound {
+			t.Errorf("flag off: POST %s = %d, want 404", path, code)
+		}
+	}
+
+	on := newRouter(true)
+	if code := post(on, "/v1/dev/add-balance", ""); code != http.StatusUnauthorized {
+		t.Errorf("flag on, no secret: got %d, want 401", code)
+	}
+	if code := post(on, "/v1/dev/add-balance", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("flag on, wrong secret: got %d, want 401", code)
+	}
+	// With the secret the request reaches the handler, which rejects the body.
+	if code := post(on, "/v1/dev/add-balance", "dev-secret"); code != http.StatusBadRequest {
+		t.Errorf("flag on, valid secret: got %d, want 400 from the handler", code)
+	}
+}
+
+func TestRouter_UnknownRouteAndMethodReturnJSON(t *testing.T) {
+	router := handler.NewRouter(nil, nil, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"unknown path", http.MethodGet, "/v1/does-not-exist", http.StatusNotFound},
+		{"unknown top-level path", http.MethodGet, "/nope", http.StatusNotFound},
+		{"wrong method", http.MethodPost, "/healthz", http.StatusMethodNotAllowed},
+		{"wrong method under /v1", http.MethodDelete, "/v1/bills/validate", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			var body struct {
+				Error     string `json:"error"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v (%s)", err, rec.Body.String())
+			}
+			if body.Error == "" || body.RequestID == "" {
+				t.Errorf("body = %+v, want error and request_id", body)
+			}
+		})
+	}
+}
+
+func TestRouter_RoutesWithoutSupabaseReturn503(t *testing.T) {
+	router := handler.NewRouter(nil, nil, nil, nil, nil, observability.NewMetrics(), zap.NewNop())
+
+	for _, path := range []string{"/v1/customers/cust-1/accounts", "/v1/pix/fees", "/v1/auth/login"} {
+		method := http.MethodGet
+		if strings.HasPrefix(path, "/v1/auth") {
+			method = http.MethodPost
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), "Supabase not configured") {
+			t.Errorf("%s: body = %s, want the unavailable service error", path, rec.Body.String())
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -13,7 +12,7 @@ import (
 )
 
 /*
- * Scheduled Transfers — create, delete, list
+ * Scheduled Transfers — create, delete, skip, list
  */
 
 func pixScheduleHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
@@ -22,8 +21,7 @@ func pixScheduleHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 		defer span.End()
 
 		var apiReq domain.PixScheduleRequest
-		if err := json.NewDecoder(r.Body).Decode(&apiReq); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		if !decodeJSON(w, r, &apiReq) || !authorizeCustomer(w, r, apiReq.CustomerID, logger) {
 			return
 		}
 
@@ -33,23 +31,9 @@ func pixScheduleHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 			return
 		}
 
-		schedType := "once"
-		recEndDate := ""
-		if apiReq.Recurrence != nil {
-			schedType = apiReq.Recurrence.Type
-			recEndDate = apiReq.Recurrence.EndDate
-		}
-
-		req := &domain.ScheduledTransferRequest{
-			IdempotencyKey:    uuid.New().String(),
-			SourceAccountID:   account.ID,
-			TransferType:      "pix",
-			Amount:            apiReq.Amount,
-			Description:       apiReq.Description,
-			ScheduleType:      schedType,
-			ScheduledDate:     apiReq.ScheduledDate,
-			RecurrenceEndDate: recEndDate,
-		}
+		req := scheduledTransferRequest(&apiReq)
+		req.IdempotencyKey = uuid.New().String()
+		req.SourceAccountID = account.ID
 
 		transfer, err := bankSvc.CreateScheduledTransfer(ctx, apiReq.CustomerID, req)
 		if err != nil {
@@ -60,7 +44,7 @@ func pixScheduleHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 		resp := domain.PixScheduleResponse{
 			ScheduleID:    transfer.ID,
 			Status:        transfer.Status,
-			Amount:        transfer.Amount,
+			Amount:        service.RoundMoney(transfer.Amount),
 			ScheduledDate: transfer.ScheduledDate,
 			Recipient: &domain.PixRecipient{
 				Name: transfer.DestinationName,
@@ -72,6 +56,47 @@ func pixScheduleHandler(bankSvc *service.BankingService, logger *zap.Logger) htt
 	}
 }
 
+// pixSchedulePreviewHandler lists the execution dates of a schedule without
+// creating it. It takes the same body as POST /v1/pix/schedule.
+func pixSchedulePreviewHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/schedule/preview")
+		defer span.End()
+
+		var apiReq domain.PixScheduleRequest
+		if !decodeJSON(w, r, &apiReq) {
+			return
+		}
+
+		preview, err := bankSvc.PreviewScheduledTransfer(ctx, scheduledTransferRequest(&apiReq))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+		writeJSON(w, http.StatusOK, preview)
+	}
+}
+
+// scheduledTransferRequest maps the PIX schedule body to the scheduled
+// transfer fields; the caller fills in the idempotency key and source account.
+func scheduledTransferRequest(apiReq *domain.PixScheduleRequest) *domain.ScheduledTransferRequest {
+	req := &domain.ScheduledTransferRequest{
+		TransferType:        "pix",
+		DestinationKeyType:  apiReq.RecipientKeyType,
+		DestinationKeyValue: apiReq.RecipientKey,
+		Amount:              apiReq.Amount,
+		Description:         apiReq.Description,
+		ScheduleType:        "once",
+		ScheduledDate:       apiReq.ScheduledDate,
+	}
+	if apiReq.Recurrence != nil {
+		req.ScheduleType = apiReq.Recurrence.Type
+		req.RecurrenceEndDate = apiReq.Recurrence.EndDate
+		req.MaxRecurrences = apiReq.Recurrence.MaxRecurrences
+	}
+	return req
+}
+
 func pixScheduleDeleteHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "DELETE /v1/pix/schedule/{scheduleId}")
@@ -87,26 +112,57 @@ func pixScheduleDeleteHandler(bankSvc *service.BankingService, logger *zap.Logge
 	}
 }
 
+// pixScheduleSkipNextHandler skips the next occurrence of a recurring
+// schedule without cancelling the series.
+func pixScheduleSkipNextHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/pix/schedule/{scheduleId}/skip-next")
+		defer span.End()
+
+		transfer, err := bankSvc.SkipNextScheduledTransfer(ctx, chi.URLParam(r, "scheduleId"))
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, domain.PixScheduleResponse{
+			ScheduleID:    transfer.ID,
+			Status:        transfer.Status,
+			Amount:        service.RoundMoney(transfer.Amount),
+			ScheduledDate: transfer.ScheduledDate,
+			NextExecution: transfer.NextExecutionDate,
+			SkippedDates:  transfer.SkippedDates,
+			Recipient: &domain.PixRecipient{
+				Name: transfer.DestinationName,
+			},
+			Recurrence: &domain.ScheduleRecurrence{Type: transfer.ScheduleType},
+		})
+	}
+}
+
 func pixScheduledListHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/pix/scheduled")
 		defer span.End()
 
 		customerID := chi.URLParam(r, "customerId")
+		page, pageSize := parsePagination(r)
 
-		transfers, err := bankSvc.ListScheduledTransfers(ctx, customerID)
+		transfers, err := bankSvc.ListScheduledTransfersPage(ctx, customerID, r.URL.Query().Get("status"), page, pageSize)
 		if err != nil {
 			handleServiceError(w, err, logger)
 			return
 		}
 
-		resp := make([]domain.PixScheduleResponse, 0, len(transfers))
-		for _, t := range transfers {
+		resp := make([]domain.PixScheduleResponse, 0, len(transfers.Data))
+		for _, t := range transfers.Data {
 			item := domain.PixScheduleResponse{
 				ScheduleID:    t.ID,
 				Status:        t.Status,
-				Amount:        t.Amount,
+				Amount:        service.RoundMoney(t.Amount),
 				ScheduledDate: t.ScheduledDate,
+				NextExecution: t.NextExecutionDate,
+				SkippedDates:  t.SkippedDates,
 				Recipient: &domain.PixRecipient{
 					Name:     t.DestinationName,
 					Document: t.DestinationDocument,
@@ -121,7 +177,13 @@ func pixScheduledListHandler(bankSvc *service.BankingService, logger *zap.Logger
 			resp = append(resp, item)
 		}
 
-		writeJSON(w, http.StatusOK, map[string]any{"schedules": resp})
+		writeJSON(w, http.StatusOK, map[string]any{
+			"schedules": resp,
+			"total":     transfers.Total,
+			"page":      transfers.Page,
+			"page_size": transfers.PageSize,
+			"has_more":  transfers.HasMore,
+		})
 	}
 }
 
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+/*
+ * Webhooks
+ */
+
+func registerWebhookHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/webhooks")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		var req domain.WebhookRegisterRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		hook, err := bankSvc.RegisterWebhook(ctx, customerID, &req)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, hook)
+	}
+}
+
+func listWebhooksHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/webhooks")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		hooks, err := bankSvc.ListWebhooks(ctx, customerID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, hooks)
+	}
+}
+
+func revokeWebhookHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "DELETE /v1/customers/{customerId}/webhooks/{webhookId}")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		webhookID := chi.URLParam(r, "webhookId")
+		if err := bankSvc.RevokeWebhook(ctx, customerID, webhookID); err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func listWebhookDeliveriesHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "GET /v1/customers/{customerId}/webhooks/{webhookId}/deliveries")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		webhookID := chi.URLParam(r, "webhookId")
+		deliveries, err := bankSvc.ListWebhookDeliveries(ctx, customerID, webhookID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, deliveries)
+	}
+}
+
+func resendWebhookDeliveryHandler(bankSvc *service.BankingService, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "POST /v1/customers/{customerId}/webhooks/{webhookId}/deliveries/{deliveryId}/resend")
+		defer span.End()
+
+		customerID := chi.URLParam(r, "customerId")
+		webhookID := chi.URLParam(r, "webhookId")
+		deliveryID := chi.URLParam(r, "deliveryId")
+		delivery, err := bankSvc.ResendWebhookDelivery(ctx, customerID, webhookID, deliveryID)
+		if err != nil {
+			handleServiceError(w, err, logger)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, delivery)
+	}
+}
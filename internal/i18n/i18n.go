@@ -0,0 +1,92 @@
+// Package i18n picks the language of the messages shown to customers. The
+// locale is resolved once per request from Accept-Language and travels in
+// the context, so services localize with T(ctx, key) wherever they build a
+// message. Error codes and field names never change with the locale.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a supported response language.
+type Locale string
+
+const (
+	PtBR Locale = "pt-BR"
+	En   Locale = "en"
+)
+
+// Default is used when the client asks for no supported language.
+const Default = PtBR
+
+type localeKey struct{}
+
+// WithLocale returns ctx carrying loc.
+func WithLocale(ctx context.Context, loc Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, loc)
+}
+
+// FromContext returns the locale carried by ctx, or Default.
+func FromContext(ctx context.Context) Locale {
+	if loc, ok := ctx.Value(localeKey{}).(Locale); ok {
+		return loc
+	}
+	return Default
+}
+
+// ParseAcceptLanguage returns the supported locale the client prefers most,
+// honouring q-values. Any pt-* tag maps to pt-BR and any en-* tag to en;
+// a header without either yields Default.
+func ParseAcceptLanguage(header string) Locale {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if lang != "" && q > 0 {
+			tags = append(tags, tag{strings.ToLower(lang), q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		primary, _, _ := strings.Cut(t.lang, "-")
+		switch primary {
+		case "pt", "*":
+			return PtBR
+		case "en":
+			return En
+		}
+	}
+	return Default
+}
+
+// T returns the message for key in the locale of ctx, formatted with args.
+// A message missing in that locale falls back to Default; an unknown key
+// is returned as is.
+func T(ctx context.Context, key string, args ...any) string {
+	variants, ok := messages[key]
+	if !ok {
+		return key
+	}
+	msg, ok := variants[FromContext(ctx)]
+	if !ok {
+		msg = variants[Default]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,43 @@
+package i18n_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   i18n.Locale
+	}{
+		{"", i18n.PtBR},
+		{"en", i18n.En},
+		{"en-US,en;q=0.9", i18n.En},
+		{"pt-BR,pt;q=0.9,en;q=0.8", i18n.PtBR},
+		{"pt;q=0.5, en-GB;q=0.8", i18n.En},
+		{"fr-FR,de;q=0.9", i18n.PtBR},
+		{"fr-FR,en;q=0.5", i18n.En},
+		{"en;q=0", i18n.PtBR},
+	}
+	for _, tt := range tests {
+		if got := i18n.ParseAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	en := i18n.WithLocale(context.Background(), i18n.En)
+
+	if got := i18n.T(context.Background(), "auth.password_length"); got != "Senha deve ter 6 dígitos" {
+		t.Errorf("default locale = %q, want the Portuguese message", got)
+	}
+	if got := i18n.T(en, "auth.invalid_credentials_remaining", 2); got != "Invalid credentials. 2 attempt(s) left" {
+		t.Errorf("en = %q, want the formatted English message", got)
+	}
+	if got := i18n.T(en, "unknown.key"); got != "unknown.key" {
+		t.Errorf("unknown key = %q, want the key itself", got)
+	}
+}
@@ -0,0 +1,142 @@
+package i18n
+
+// messages holds every localized message by key and locale. Keys are
+// prefixed with the area they belong to; format verbs must match across
+// locales.
+var messages = map[string]map[Locale]string{
+	/*
+	 * Auth
+	 */
+	"auth.invalid_credentials": {
+		PtBR: "Credenciais inválidas",
+		En:   "Invalid credentials",
+	},
+	"auth.invalid_credentials_remaining": {
+		PtBR: "Credenciais inválidas. %d tentativa(s) restante(s)",
+		En:   "Invalid credentials. %d attempt(s) left",
+	},
+	"auth.account_locked": {
+		PtBR: "Conta bloqueada por %d minutos após %d tentativas",
+		En:   "Account locked for %d minutes after %d attempts",
+	},
+	"auth.account_temporarily_locked": {
+		PtBR: "Conta temporariamente bloqueada. Tente novamente em %.0f minutos",
+		En:   "Account temporarily locked. Try again in %.0f minutes",
+	},
+	"auth.document_registered": {
+		PtBR: "CNPJ já cadastrado",
+		En:   "CNPJ already registered",
+	},
+	"auth.password_length": {
+		PtBR: "Senha deve ter 6 dígitos",
+		En:   "Password must have 6 digits",
+	},
+	"auth.password_digits_only": {
+		PtBR: "Senha deve conter apenas dígitos",
+		En:   "Password must contain digits only",
+	},
+	"auth.wrong_current_password": {
+		PtBR: "Senha atual incorreta",
+		En:   "Current password is incorrect",
+	},
+	"auth.password_reset": {
+		PtBR: "Senha redefinida com sucesso",
+		En:   "Password reset successfully",
+	},
+	"auth.password_changed": {
+		PtBR: "Senha alterada com sucesso",
+		En:   "Password changed successfully",
+	},
+	"auth.reset_code_requested": {
+		PtBR: "Se os dados estiverem corretos, enviaremos o código de verificação",
+		En:   "If the details are correct, we will send a verification code",
+	},
+	"auth.code_sent": {
+		PtBR: "Código de verificação enviado",
+		En:   "Verification code sent",
+	},
+	"auth.code_request_limit": {
+		PtBR: "Limite de solicitações de código atingido. Tente novamente mais tarde",
+		En:   "Code request limit reached. Try again later",
+	},
+	"auth.code_request_wait": {
+		PtBR: "Aguarde antes de solicitar um novo código",
+		En:   "Wait before requesting a new code",
+	},
+	"auth.nothing_to_update": {
+		PtBR: "Nenhum campo para atualizar",
+		En:   "No fields to update",
+	},
+	"auth.representative_updated": {
+		PtBR: "Dados do representante atualizados com sucesso",
+		En:   "Representative details updated successfully",
+	},
+	"auth.token_missing": {
+		PtBR: "Token de autenticação não fornecido",
+		En:   "Authentication token not provided",
+	},
+	"auth.token_malformed": {
+		PtBR: "Formato de token inválido",
+		En:   "Invalid token format",
+	},
+	"auth.token_invalid": {
+		PtBR: "Token inválido",
+		En:   "Invalid token",
+	},
+	"auth.token_invalid_or_expired": {
+		PtBR: "Token inválido ou expirado",
+		En:   "Invalid or expired token",
+	},
+	"auth.token_type_invalid": {
+		PtBR: "Tipo de token inválido",
+		En:   "Invalid token type",
+	},
+	"auth.refresh_token_invalid": {
+		PtBR: "Token de atualização inválido",
+		En:   "Invalid refresh token",
+	},
+	"auth.refresh_token_expired": {
+		PtBR: "Token de atualização expirado",
+		En:   "Refresh token expired",
+	},
+	"auth.permission_denied": {
+		PtBR: "Permissão insuficiente para esta operação",
+		En:   "Insufficient permission for this operation",
+	},
+	"auth.customer_forbidden": {
+		PtBR: "Este acesso não permite operar outro cliente",
+		En:   "This access does not allow acting on another customer",
+	},
+
+	/*
+	 * PIX
+	 */
+	"pix.self_transfer": {
+		PtBR: "Não é possível transferir para você mesmo",
+		En:   "You cannot transfer to yourself",
+	},
+	"pix.confirmation_token_invalid": {
+		PtBR: "Token de confirmação inválido ou expirado",
+		En:   "Invalid or expired confirmation token",
+	},
+	"pix.key_type_invalid": {
+		PtBR: "deve ser cnpj, email, phone ou random",
+		En:   "must be cnpj, email, phone or random",
+	},
+	"pix.key_verification_type": {
+		PtBR: "verificação disponível apenas para email ou phone",
+		En:   "verification is only available for email or phone",
+	},
+	"pix.key_deleted": {
+		PtBR: "Chave Pix excluída com sucesso",
+		En:   "Pix key deleted successfully",
+	},
+	"pix.receipt_link_expired": {
+		PtBR: "Link de comprovante expirado",
+		En:   "Receipt link expired",
+	},
+	"pix.receipt_link_invalid": {
+		PtBR: "Link de comprovante inválido",
+		En:   "Invalid receipt link",
+	},
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
@@ -14,6 +16,10 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// malformedBodyLimit is how much of an undecodable agent body is kept in
+// ErrMalformedResponse for logging.
+const malformedBodyLimit = 512
+
 // AgentClient calls the AI Agent service (Python/LangGraph).
 type AgentClient struct {
 	httpClient *http.Client
@@ -32,6 +38,29 @@ func NewAgentClient(httpClient *http.Client, baseURL string, cb *gobreaker.Circu
 	}
 }
 
+// Ping checks that the agent API is reachable (GET /health). It bypasses the
+// circuit breaker so probes never trip it, but while the breaker is open it
+// reports ErrCircuitOpen without calling the API.
+func (c *AgentClient) Ping(ctx context.Context) error {
+	if c.cb.State() == gobreaker.StateOpen {
+		return resilience.BreakerError(c.cb, gobreaker.ErrOpenState)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("agent API health returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // Call invokes the AI agent with customer context and returns its response.
 func (c *AgentClient) Call(ctx context.Context, req *domain.AgentRequest) (*domain.AgentResponse, error) {
 	ctx, span := tracer.Start(ctx, "AgentClient.Call")
@@ -41,8 +70,11 @@ func (c *AgentClient) Call(ctx context.Context, req *domain.AgentRequest) (*doma
 	var agentResp domain.AgentResponse
 
 	result, err := c.cb.Execute(func() (any, error) {
-		var innerErr error
-		innerErr = resilience.RetryWithBackoff(ctx, c.cfg, func() error {
+		// A body that does not decode is not retried: the agent answered and
+		// would most likely answer the same way again. It still counts as a
+		// failure for the breaker.
+		var malformed *domain.ErrMalformedResponse
+		innerErr := resilience.RetryWithBackoff(ctx, c.cfg, func() error {
 			body, err := json.Marshal(req)
 			if err != nil {
 				return err
@@ -65,17 +97,35 @@ func (c *AgentClient) Call(ctx context.Context, req *domain.AgentRequest) (*doma
 				return fmt.Errorf("agent API returned status %d", resp.StatusCode)
 			}
 
-			return json.NewDecoder(resp.Body).Decode(&agentResp)
+			raw, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return fmt.Errorf("read agent response: %w", err)
+			}
+			if err := json.Unmarshal(raw, &agentResp); err != nil {
+				malformed = &domain.ErrMalformedResponse{Service: "agent", Body: truncateBody(raw), Err: err}
+			}
+			return nil
 		})
 		if innerErr != nil {
 			return nil, innerErr
 		}
+		if malformed != nil {
+			return nil, malformed
+		}
 		return &agentResp, nil
 	})
 
 	if err != nil {
-		return nil, &domain.ErrExternalService{Service: "agent", Err: err}
+		return nil, &domain.ErrExternalService{Service: "agent", Err: resilience.BreakerError(c.cb, err)}
 	}
 
 	return result.(*domain.AgentResponse), nil
 }
+
+// truncateBody returns at most malformedBodyLimit bytes of raw as valid UTF-8.
+func truncateBody(raw []byte) string {
+	if len(raw) <= malformedBodyLimit {
+		return strings.ToValidUTF8(string(raw), "")
+	}
+	return strings.ToValidUTF8(string(raw[:malformedBodyLimit]), "") + "…"
+}
@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/client"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+)
+
+// agentServer answers every invoke with status and body, counting calls.
+func agentServer(t *testing.T, status int, body string) (*client.AgentClient, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	agent := client.NewAgentClient(srv.Client(), srv.URL, resilience.NewCircuitBreaker(t.Name()),
+		resilience.Config{MaxRetries: 2, InitialBackoff: time.Millisecond})
+	return agent, &calls
+}
+
+func TestAgentClient_MalformedBody(t *testing.T) {
+	agent, calls := agentServer(t, http.StatusOK, `{"answer": "Seu caixa est`)
+
+	_, err := agent.Call(context.Background(), &domain.AgentRequest{CustomerID: "cust-123"})
+	var malformed *domain.ErrMalformedResponse
+	if !errors.As(err, &malformed) {
+		t.Fatalf("err = %v, want ErrMalformedResponse", err)
+	}
+	if !strings.HasPrefix(malformed.Body, `{"answer"`) {
+		t.Errorf("body = %q, want the raw body", malformed.Body)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("agent called %d times, want 1 (decode errors are not retried)", got)
+	}
+}
+
+func TestAgentClient_TransportErrorIsNotMalformed(t *testing.T) {
+	agent, calls := agentServer(t, http.StatusBadGateway, "<html>bad gateway</html>")
+
+	_, err := agent.Call(context.Background(), &domain.AgentRequest{CustomerID: "cust-123"})
+	var malformed *domain.ErrMalformedResponse
+	if err == nil || errors.As(err, &malformed) {
+		t.Fatalf("err = %v, want a transport error", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("agent called %d times, want 3 (retried)", got)
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// PoolConfig sizes the connection pool shared by the upstream clients.
+type PoolConfig struct {
+	MaxIdleConns        int           // idle connections kept across all hosts
+	MaxIdleConnsPerHost int           // idle connections kept per host
+	IdleConnTimeout     time.Duration // how long an idle connection is kept
+}
+
+// NewTransport returns a transport with the default dialer, proxy and TLS
+// settings and the given pool size. The stdlib default keeps only two idle
+// connections per host, so concurrent requests to the same upstream keep
+// opening new connections.
+func NewTransport(cfg PoolConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	return t
+}
+
+// NewHTTPClient returns a client with its own timeout over a shared
+// transport, so upstreams with different latencies share one pool.
+func NewHTTPClient(transport http.RoundTripper, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
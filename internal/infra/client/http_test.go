@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/client"
+)
+
+// countNewConns runs waves of concurrent requests against one host and
+// returns how many connections the server accepted in total. Each wave
+// holds every request open until all of them arrived, so a wave needs as
+// many connections as it has requests.
+func countNewConns(t *testing.T, httpClient *http.Client, waves, perWave int) int64 {
+	t.Helper()
+	var newConns atomic.Int64
+	var arrived sync.WaitGroup
+	var release chan struct{}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	for wave := 0; wave < waves; wave++ {
+		release = make(chan struct{})
+		arrived.Add(perWave)
+		var done sync.WaitGroup
+		for i := 0; i < perWave; i++ {
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				resp, err := httpClient.Get(srv.URL)
+				if err != nil {
+					t.Errorf("request failed: %v", err)
+					return
+				}
+				resp.Body.Close()
+			}()
+		}
+
+		waitCh := make(chan struct{})
+		go func() { arrived.Wait(); close(waitCh) }()
+		select {
+		case <-waitCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests did not arrive concurrently")
+		}
+		close(release)
+		done.Wait()
+	}
+	return newConns.Load()
+}
+
+func TestNewTransport_ReusesConnectionsUnderConcurrency(t *testing.T) {
+	const perWave = 20
+	transport := client.NewTransport(client.PoolConfig{MaxIdleConns: 100, MaxIdleConnsPerHost: perWave, IdleConnTimeout: time.Minute})
+	defer transport.CloseIdleConnections()
+
+	got := countNewConns(t, client.NewHTTPClient(transport, 5*time.Second), 3, perWave)
+	if got != perWave {
+		t.Errorf("opened %d connections over 3 waves, want %d reused across waves", got, perWave)
+	}
+}
+
+func TestNewTransport_DefaultsKeepStdlibPool(t *testing.T) {
+	transport := client.NewTransport(client.PoolConfig{})
+	def := http.DefaultTransport.(*http.Transport)
+	if transport.MaxIdleConns != def.MaxIdleConns || transport.MaxIdleConnsPerHost != def.MaxIdleConnsPerHost || transport.IdleConnTimeout != def.IdleConnTimeout {
+		t.Errorf("zero config changed the pool: %d/%d/%v", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport == def {
+		t.Error("expected a clone, got the shared default transport")
+	}
+}
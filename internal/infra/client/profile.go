@@ -73,7 +73,7 @@ func (c *ProfileClient) GetProfile(ctx context.Context, customerID string) (*dom
 	})
 
 	if err != nil {
-		return nil, &domain.ErrExternalService{Service: "profile", Err: err}
+		return nil, &domain.ErrExternalService{Service: "profile", Err: resilience.BreakerError(c.cb, err)}
 	}
 
 	return result.(*domain.CustomerProfile), nil
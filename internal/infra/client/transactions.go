@@ -70,7 +70,7 @@ func (c *TransactionsClient) GetTransactions(ctx context.Context, customerID str
 	})
 
 	if err != nil {
-		return nil, &domain.ErrExternalService{Service: "transactions", Err: err}
+		return nil, &domain.ErrExternalService{Service: "transactions", Err: resilience.BreakerError(c.cb, err)}
 	}
 
 	return result.([]domain.Transaction), nil
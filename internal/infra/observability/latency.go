@@ -0,0 +1,121 @@
+package observability
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for the assistant latency reservoir.
+const (
+	DefaultLatencyReservoirSize = 2048
+	DefaultLatencyWindow        = time.Hour
+)
+
+// LatencyReservoir keeps the most recent latency samples (bounded by size)
+// and reports statistics over those inside the time window.
+type LatencyReservoir struct {
+	size   int
+	window time.Duration
+
+	mu      sync.Mutex
+	samples []latencySample
+	next    int
+	full    bool
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// LatencyStats summarizes the samples of a reservoir.
+type LatencyStats struct {
+	Count int
+	Avg   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// NewLatencyReservoir creates a reservoir of at most size samples, only
+// considering those recorded within window.
+func NewLatencyReservoir(size int, window time.Duration) *LatencyReservoir {
+	if size <= 0 {
+		size = DefaultLatencyReservoirSize
+	}
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	return &LatencyReservoir{
+		size:    size,
+		window:  window,
+		samples: make([]latencySample, size),
+	}
+}
+
+// Record adds a sample, overwriting the oldest one when the reservoir is full.
+func (r *LatencyReservoir) Record(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples[r.next] = latencySample{at: time.Now(), latency: d}
+	r.next = (r.next + 1) % r.size
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Stats returns the average and nearest-rank p95/p99 of the samples in the
+// window. All values are zero when there are no samples.
+func (r *LatencyReservoir) Stats() LatencyStats {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = r.size
+	}
+	cutoff := time.Now().Add(-r.window)
+	latencies := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		if r.samples[i].at.After(cutoff) {
+			latencies = append(latencies, r.samples[i].latency)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	return LatencyStats{
+		Count: len(latencies),
+		Avg:   total / time.Duration(len(latencies)),
+		P95:   percentile(latencies, 95),
+		P99:   percentile(latencies, 99),
+	}
+}
+
+// Period describes the window, e.g. "last_1h", for AgentMetrics.
+func (r *LatencyReservoir) Period() string {
+	switch {
+	case r.window%time.Hour == 0:
+		return fmt.Sprintf("last_%dh", r.window/time.Hour)
+	case r.window%time.Minute == 0:
+		return fmt.Sprintf("last_%dm", r.window/time.Minute)
+	}
+	return "last_" + r.window.String()
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
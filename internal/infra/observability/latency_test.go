@@ -0,0 +1,66 @@
+package observability_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+)
+
+func TestLatencyReservoir_Percentiles(t *testing.T) {
+	r := observability.NewLatencyReservoir(1000, time.Hour)
+
+	// 1ms..1000ms in random order: p95 ≈ 950ms, p99 ≈ 990ms, avg 500.5ms.
+	for _, i := range rand.Perm(1000) {
+		r.Record(time.Duration(i+1) * time.Millisecond)
+	}
+
+	stats := r.Stats()
+	if stats.Count != 1000 {
+		t.Fatalf("expected 1000 samples, got %d", stats.Count)
+	}
+	within := func(name string, got, want time.Duration) {
+		if math.Abs(float64(got-want)) > float64(5*time.Millisecond) {
+			t.Errorf("%s: expected ~%s, got %s", name, want, got)
+		}
+	}
+	within("avg", stats.Avg, 500500*time.Microsecond)
+	within("p95", stats.P95, 950*time.Millisecond)
+	within("p99", stats.P99, 990*time.Millisecond)
+}
+
+func TestLatencyReservoir_BoundedAndWindowed(t *testing.T) {
+	r := observability.NewLatencyReservoir(10, 50*time.Millisecond)
+
+	for i := 0; i < 25; i++ {
+		r.Record(time.Second)
+	}
+	if got := r.Stats().Count; got != 10 {
+		t.Errorf("expected the reservoir to keep 10 samples, got %d", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := r.Stats(); got.Count != 0 || got.P99 != 0 {
+		t.Errorf("expected samples outside the window to be ignored, got %+v", got)
+	}
+	if got := r.Period(); got != "last_50ms" {
+		t.Errorf("unexpected period %q", got)
+	}
+}
+
+func TestGetAgentSnapshot_Latency(t *testing.T) {
+	m := observability.NewMetrics()
+	for i := 1; i <= 100; i++ {
+		m.RecordAssistantLatency(time.Duration(i) * time.Millisecond)
+	}
+
+	snap := m.GetAgentSnapshot()
+	if snap.P95LatencyMs != 95 || snap.P99LatencyMs != 99 || snap.AvgLatencyMs != 50.5 {
+		t.Errorf("unexpected latency snapshot: avg=%v p95=%v p99=%v", snap.AvgLatencyMs, snap.P95LatencyMs, snap.P99LatencyMs)
+	}
+	if snap.LatencyPeriod != "last_1h" {
+		t.Errorf("unexpected latency period %q", snap.LatencyPeriod)
+	}
+}
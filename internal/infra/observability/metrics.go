@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"sync"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -9,18 +10,36 @@ import (
 	dto "github.com/prometheus/client_model/go"
 )
 
-// Metrics holds all Prometheus metrics for the BFA.
+// Metrics holds all Prometheus metrics for the BFA. It is safe for
+// concurrent use: the Prometheus vectors and the latency reservoir
+// synchronize themselves, and snapshotMu keeps GetAgentSnapshot from
+// observing a recording halfway through.
 type Metrics struct {
 	// Registry is the Prometheus registry that owns these metrics.
 	// Exposed so the /metrics endpoint can use it.
 	Registry *prometheus.Registry
 
+	// Uptime tracks process uptime and rolling success rates for /healthz.
+	Uptime *UptimeTracker
+
+	// AssistantLatency holds recent assistant call latencies for the
+	// percentiles of GET /v1/metrics/agent.
+	AssistantLatency *LatencyReservoir
+
 	requestDuration *prometheus.HistogramVec
 	externalErrors  *prometheus.CounterVec
 	cacheHits       *prometheus.CounterVec
 	cacheMisses     *prometheus.CounterVec
 	tokensUsed      *prometheus.CounterVec
 	requestsTotal   *prometheus.CounterVec
+	fallbacks       *prometheus.CounterVec
+	domainErrors    *prometheus.CounterVec
+	malformed       *prometheus.CounterVec
+
+	// snapshotMu is held for reading while the counters behind
+	// GetAgentSnapshot are updated, so recordings run concurrently with each
+	// other, and for writing while the snapshot reads them.
+	snapshotMu sync.RWMutex
 }
 
 // NewMetrics creates a dedicated Prometheus registry and registers all
@@ -32,6 +51,9 @@ func NewMetrics() *Metrics {
 
 	return &Metrics{
 		Registry: reg,
+		Uptime:   NewUptimeTracker(DefaultUptimeWindow),
+
+		AssistantLatency: NewLatencyReservoir(DefaultLatencyReservoirSize, DefaultLatencyWindow),
 
 		requestDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -76,6 +98,27 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"status"},
 		),
+		fallbacks: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bfa_fallbacks_total",
+				Help: "Total responses served by a fallback instead of the upstream service.",
+			},
+			[]string{"service"},
+		),
+		domainErrors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bfa_domain_errors_total",
+				Help: "Total business errors returned to clients, by error code.",
+			},
+			[]string{"code"},
+		),
+		malformed: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bfa_malformed_responses_total",
+				Help: "Total responses from external services whose body could not be decoded.",
+			},
+			[]string{"service"},
+		),
 	}
 }
 
@@ -91,46 +134,86 @@ func (m *Metrics) IncrExternalError(service string) {
 
 // IncrCacheHit increments the cache hit counter.
 func (m *Metrics) IncrCacheHit(cache string) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
 	m.cacheHits.WithLabelValues(cache).Inc()
 }
 
 // IncrCacheMiss increments the cache miss counter.
 func (m *Metrics) IncrCacheMiss(cache string) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
 	m.cacheMisses.WithLabelValues(cache).Inc()
 }
 
 // RecordTokens records prompt and completion token usage.
 func (m *Metrics) RecordTokens(prompt, completion int) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
 	m.tokensUsed.WithLabelValues("prompt").Add(float64(prompt))
 	m.tokensUsed.WithLabelValues("completion").Add(float64(completion))
 }
 
 // IncrRequest increments the request counter with a status label.
 func (m *Metrics) IncrRequest(status string) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
 	m.requestsTotal.WithLabelValues(status).Inc()
 }
 
+// RecordAssistantLatency records the end-to-end latency of an assistant call.
+func (m *Metrics) RecordAssistantLatency(d time.Duration) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
+	m.AssistantLatency.Record(d)
+}
+
+// IncrFallback increments the fallback counter for a service.
+func (m *Metrics) IncrFallback(service string) {
+	m.snapshotMu.RLock()
+	defer m.snapshotMu.RUnlock()
+	m.fallbacks.WithLabelValues(service).Inc()
+}
+
+// IncrMalformedResponse increments the counter of undecodable responses
+// from service.
+func (m *Metrics) IncrMalformedResponse(service string) {
+	m.malformed.WithLabelValues(service).Inc()
+}
+
+// IncrDomainError increments the domain error counter. code must come from
+// a fixed set (see handleServiceError) to keep the label bounded.
+func (m *Metrics) IncrDomainError(code string) {
+	m.domainErrors.WithLabelValues(code).Inc()
+}
+
 // GetAgentSnapshot returns a snapshot of agent-related metrics suitable for the
 // GET /v1/metrics/agent endpoint.
 func (m *Metrics) GetAgentSnapshot() *domain.AgentMetrics {
-	// Gather current values from Prometheus counters.
+	// Gather current values from Prometheus counters, all under the same
+	// lock so the rates below are computed from one consistent state.
 	// Note: Prometheus counters expose cumulative values.
+	m.snapshotMu.Lock()
 	promptTokens := getCounterValue(m.tokensUsed, "prompt")
 	completionTokens := getCounterValue(m.tokensUsed, "completion")
-	totalRequests := getCounterValue(m.requestsTotal, "success") +
-		getCounterValue(m.requestsTotal, "error")
 	errorCount := getCounterValue(m.requestsTotal, "error")
+	totalRequests := getCounterValue(m.requestsTotal, "success") + errorCount
+	fallbackCount := getCounterValue(m.fallbacks, "agent")
 	cacheHits := getCounterValue(m.cacheHits, "profile")
 	cacheMisses := getCounterValue(m.cacheMisses, "profile")
+	latency := m.AssistantLatency.Stats()
+	m.snapshotMu.Unlock()
 
 	totalTokens := promptTokens + completionTokens
 	avgTokens := float64(0)
 	errorRate := float64(0)
+	fallbackRate := float64(0)
 	cacheHitRate := float64(0)
 
 	if totalRequests > 0 {
 		avgTokens = totalTokens / totalRequests
 		errorRate = errorCount / totalRequests
+		fallbackRate = fallbackCount / totalRequests
 	}
 	if cacheHits+cacheMisses > 0 {
 		cacheHitRate = cacheHits / (cacheHits + cacheMisses)
@@ -141,19 +224,24 @@ func (m *Metrics) GetAgentSnapshot() *domain.AgentMetrics {
 
 	return &domain.AgentMetrics{
 		TotalRequests:       int64(totalRequests),
-		AvgLatencyMs:        0, // Would need histogram observation; stub for now
-		P95LatencyMs:        0,
-		P99LatencyMs:        0,
+		AvgLatencyMs:        durationMs(latency.Avg),
+		P95LatencyMs:        durationMs(latency.P95),
+		P99LatencyMs:        durationMs(latency.P99),
 		ErrorRate:           errorRate,
-		FallbackRate:        0,
+		FallbackRate:        fallbackRate,
 		AvgTokensPerRequest: avgTokens,
 		EstimatedCostUsd:    estimatedCost,
 		RAGPrecision:        0,
 		CacheHitRate:        cacheHitRate,
 		Period:              "all_time",
+		LatencyPeriod:       m.AssistantLatency.Period(),
 	}
 }
 
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // getCounterValue extracts the current float64 value from a CounterVec for a given label.
 func getCounterValue(cv *prometheus.CounterVec, label string) float64 {
 	counter := cv.WithLabelValues(label)
@@ -0,0 +1,76 @@
+package observability_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+)
+
+// TestMetrics_ConcurrentRecording hammers the recorders and the snapshot
+// from many goroutines. Run it with -race to catch unsynchronized state.
+func TestMetrics_ConcurrentRecording(t *testing.T) {
+	m := observability.NewMetrics()
+
+	const writers, perWriter = 16, 200
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				m.RecordRequestDuration("chat", time.Millisecond)
+				m.RecordTokens(10, 5)
+				m.RecordAssistantLatency(time.Duration(i+1) * time.Millisecond)
+				if i%4 == 0 {
+					m.IncrRequest("error")
+				} else {
+					m.IncrRequest("success")
+				}
+				m.IncrCacheHit("profile")
+				m.Uptime.Record("agent", w%2 == 0, time.Millisecond)
+			}
+		}(w)
+	}
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				snap := m.GetAgentSnapshot()
+				if snap.ErrorRate < 0 || snap.ErrorRate > 1 {
+					t.Errorf("inconsistent snapshot: error rate %v", snap.ErrorRate)
+					return
+				}
+				if snap.CacheHitRate != 0 && snap.CacheHitRate != 1 {
+					t.Errorf("inconsistent snapshot: cache hit rate %v", snap.CacheHitRate)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	snap := m.GetAgentSnapshot()
+	if snap.TotalRequests != writers*perWriter {
+		t.Errorf("total requests = %d, want %d", snap.TotalRequests, writers*perWriter)
+	}
+	if snap.AvgTokensPerRequest != 15 {
+		t.Errorf("avg tokens per request = %v, want 15", snap.AvgTokensPerRequest)
+	}
+	if snap.ErrorRate != 0.25 {
+		t.Errorf("error rate = %v, want 0.25", snap.ErrorRate)
+	}
+}
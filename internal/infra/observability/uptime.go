@@ -0,0 +1,131 @@
+package observability
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultUptimeWindow is how many recent outcomes are kept per service.
+const DefaultUptimeWindow = 1000
+
+// UptimeTracker records the process start time and a rolling window of
+// success/failure outcomes (with latency) per service, feeding /healthz.
+type UptimeTracker struct {
+	startedAt time.Time
+	window    int
+
+	mu       sync.Mutex
+	services map[string]*outcomeRing
+}
+
+// outcomeRing is a fixed-size ring buffer of the most recent outcomes.
+type outcomeRing struct {
+	ok        []bool
+	latencies []time.Duration
+	next      int
+	full      bool
+}
+
+// NewUptimeTracker creates a tracker keeping the last window outcomes per service.
+func NewUptimeTracker(window int) *UptimeTracker {
+	if window <= 0 {
+		window = DefaultUptimeWindow
+	}
+	return &UptimeTracker{
+		startedAt: time.Now(),
+		window:    window,
+		services:  make(map[string]*outcomeRing),
+	}
+}
+
+// Record adds one outcome for service.
+func (u *UptimeTracker) Record(service string, ok bool, latency time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	r, exists := u.services[service]
+	if !exists {
+		r = &outcomeRing{ok: make([]bool, u.window), latencies: make([]time.Duration, u.window)}
+		u.services[service] = r
+	}
+	r.ok[r.next] = ok
+	r.latencies[r.next] = latency
+	r.next = (r.next + 1) % u.window
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// UptimePercent returns the share of successful outcomes in the window,
+// rounded to 2 decimals. A service with no outcomes yet reports 100.
+func (u *UptimeTracker) UptimePercent(service string) float64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	r, n := u.ring(service)
+	if n == 0 {
+		return 100
+	}
+	success := 0
+	for i := 0; i < n; i++ {
+		if r.ok[i] {
+			success++
+		}
+	}
+	return math.Round(float64(success)/float64(n)*10000) / 100
+}
+
+// AvgLatency returns the mean latency of the outcomes in the window.
+func (u *UptimeTracker) AvgLatency(service string) time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	r, n := u.ring(service)
+	if n == 0 {
+		return 0
+	}
+	var total time.Duration
+	for i := 0; i < n; i++ {
+		total += r.latencies[i]
+	}
+	return total / time.Duration(n)
+}
+
+// Uptime returns how long the process has been running.
+func (u *UptimeTracker) Uptime() time.Duration {
+	return time.Since(u.startedAt)
+}
+
+// ring returns the service's buffer and how many slots hold data.
+// Callers must hold u.mu.
+func (u *UptimeTracker) ring(service string) (*outcomeRing, int) {
+	r, ok := u.services[service]
+	if !ok {
+		return nil, 0
+	}
+	if r.full {
+		return r, u.window
+	}
+	return r, r.next
+}
+
+// UptimeMiddleware records every HTTP request as an outcome of service;
+// 5xx responses count as failures.
+func UptimeMiddleware(tracker *UptimeTracker, service string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			tracker.Record(service, status < 500, time.Since(start))
+		})
+	}
+}
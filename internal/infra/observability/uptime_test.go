@@ -0,0 +1,75 @@
+package observability_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+)
+
+func TestUptimeTracker_ReflectsOutcomes(t *testing.T) {
+	u := observability.NewUptimeTracker(10)
+
+	if got := u.UptimePercent("supabase"); got != 100 {
+		t.Errorf("expected 100%% without samples, got %v", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		u.Record("supabase", true, 10*time.Millisecond)
+	}
+	u.Record("supabase", false, 50*time.Millisecond)
+
+	if got := u.UptimePercent("supabase"); got != 75 {
+		t.Errorf("expected 75%%, got %v", got)
+	}
+	if got := u.AvgLatency("supabase"); got != 20*time.Millisecond {
+		t.Errorf("expected 20ms average latency, got %s", got)
+	}
+	if got := u.UptimePercent("agent"); got != 100 {
+		t.Errorf("services must be tracked independently, got %v", got)
+	}
+}
+
+func TestUptimeTracker_RollingWindow(t *testing.T) {
+	u := observability.NewUptimeTracker(4)
+
+	for i := 0; i < 4; i++ {
+		u.Record("bfa-api", false, 0)
+	}
+	if got := u.UptimePercent("bfa-api"); got != 0 {
+		t.Fatalf("expected 0%%, got %v", got)
+	}
+
+	// Older failures roll out of the window.
+	for i := 0; i < 3; i++ {
+		u.Record("bfa-api", true, 0)
+	}
+	if got := u.UptimePercent("bfa-api"); got != 75 {
+		t.Errorf("expected 75%% after 3 of 4 slots succeeded, got %v", got)
+	}
+}
+
+func TestUptimeMiddleware_CountsServerErrors(t *testing.T) {
+	u := observability.NewUptimeTracker(10)
+	h := observability.UptimeMiddleware(u, "bfa-api")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, path := range []string{"/ok", "/missing", "/fail", "/ok"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := u.UptimePercent("bfa-api"); got != 75 {
+		t.Errorf("expected 75%% (only 5xx count as failures), got %v", got)
+	}
+}
@@ -4,10 +4,14 @@ package resilience
 
 import (
 	"context"
+	"errors"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
 	"github.com/sony/gobreaker"
 )
 
@@ -47,20 +51,46 @@ func RetryWithBackoff(ctx context.Context, cfg Config, fn func() error) error {
 	return lastErr
 }
 
+// BreakerOpenTimeout is how long a breaker stays open before half-opening.
+const BreakerOpenTimeout = 10 * time.Second
+
+// breakerOpenedAt records when each breaker (by name) last opened, so the
+// reset time can be reported to clients.
+var breakerOpenedAt sync.Map
+
 // NewCircuitBreaker creates a circuit breaker with sensible defaults.
 func NewCircuitBreaker(name string) *gobreaker.CircuitBreaker {
 	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
 		Name:        name,
-		MaxRequests: 3,                // half-open: allow 3 requests
-		Interval:    30 * time.Second, // closed: reset counters every 30s
-		Timeout:     10 * time.Second, // open -> half-open after 10s
+		MaxRequests: 3,                  // half-open: allow 3 requests
+		Interval:    30 * time.Second,   // closed: reset counters every 30s
+		Timeout:     BreakerOpenTimeout, // open -> half-open after 10s
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
 			return counts.Requests >= 5 && failureRatio >= 0.6
 		},
+		OnStateChange: func(name string, _, to gobreaker.State) {
+			if to == gobreaker.StateOpen {
+				breakerOpenedAt.Store(name, time.Now())
+			}
+		},
 	})
 }
 
+// BreakerError converts a rejection by cb (open, or half-open and saturated)
+// into a domain.ErrCircuitOpen carrying the breaker name and estimated reset
+// time. Any other error is returned unchanged.
+func BreakerError(cb *gobreaker.CircuitBreaker, err error) error {
+	if !errors.Is(err, gobreaker.ErrOpenState) && !errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return err
+	}
+	resetAt := time.Now()
+	if openedAt, ok := breakerOpenedAt.Load(cb.Name()); ok {
+		resetAt = openedAt.(time.Time).Add(BreakerOpenTimeout)
+	}
+	return &domain.ErrCircuitOpen{Service: cb.Name(), ResetAt: resetAt}
+}
+
 // Bulkhead limits concurrent access to a resource.
 type Bulkhead struct {
 	sem chan struct{}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 
@@ -109,6 +110,112 @@ func (c *Client) UpdateAccountBalance(ctx context.Context, customerID string, de
 	return updated, nil
 }
 
+// SetAccountBalance sets the primary account's balance and available
+// balance to balance.
+func (c *Client) SetAccountBalance(ctx context.Context, customerID string, balance float64) (*domain.Account, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.SetAccountBalance")
+	defer span.End()
+
+	acct, err := c.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.doPatch(ctx, fmt.Sprintf("accounts?id=eq.%s", acct.ID), map[string]any{
+		"balance":           balance,
+		"available_balance": balance,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := c.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("re-fetch after balance update: %w", err)
+	}
+
+	c.logger.Info("supabase: balance set",
+		zap.String("account_id", updated.ID),
+		zap.Float64("old_balance", acct.Balance),
+		zap.Float64("new_balance", updated.Balance),
+	)
+
+	return updated, nil
+}
+
+// AdjustAvailableBalance adjusts only the primary account's available
+// balance by a delta, holding (negative) or releasing (positive) funds
+// without touching the ledger balance.
+func (c *Client) AdjustAvailableBalance(ctx context.Context, customerID string, delta float64) (*domain.Account, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.AdjustAvailableBalance")
+	defer span.End()
+
+	acct, err := c.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.doPatch(ctx, fmt.Sprintf("accounts?id=eq.%s", acct.ID), map[string]any{
+		"available_balance": acct.AvailableBalance + delta,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The adjustment has applied: a failed re-fetch must not be reported as
+	// a failed adjustment, or the caller would hold or release it twice
+	updated, err := c.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		c.logger.Warn("supabase: re-fetch after available balance update failed",
+			zap.String("account_id", acct.ID),
+			zap.Error(err),
+		)
+		updated = acct
+		updated.AvailableBalance += delta
+	}
+
+	c.logger.Info("supabase: available balance updated",
+		zap.String("account_id", updated.ID),
+		zap.Float64("old_available", acct.AvailableBalance),
+		zap.Float64("new_available", updated.AvailableBalance),
+	)
+
+	return updated, nil
+}
+
+// TransferBetweenAccounts moves funds between two accounts of the customer
+// in a single database transaction (RPC transfer_between_accounts), so a
+// failure never leaves one side debited without the other credited.
+func (c *Client) TransferBetweenAccounts(ctx context.Context, customerID, fromAccountID, toAccountID string, amount float64) (float64, float64, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.TransferBetweenAccounts")
+	defer span.End()
+
+	body, err := c.doRPC(ctx, "transfer_between_accounts", map[string]any{
+		"p_customer_id":     customerID,
+		"p_from_account_id": fromAccountID,
+		"p_to_account_id":   toAccountID,
+		"p_amount":          amount,
+	})
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "insufficient_funds"):
+			return 0, 0, &domain.ErrInsufficientFunds{Required: amount}
+		case strings.Contains(err.Error(), "account_not_found"):
+			return 0, 0, &domain.ErrNotFound{Resource: "account", ID: fromAccountID + "," + toAccountID}
+		}
+		return 0, 0, err
+	}
+
+	var result struct {
+		FromBalance float64 `json:"from_balance"`
+		ToBalance   float64 `json:"to_balance"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, 0, fmt.Errorf("decode transfer_between_accounts: %w", err)
+	}
+	return result.FromBalance, result.ToBalance, nil
+}
+
 // UpdateAccountCreditLimit sets the pre-approved credit limit on the primary account.
 // It recalculates available_credit_limit as newLimit minus the sum of all existing card limits.
 func (c *Client) UpdateAccountCreditLimit(ctx context.Context, customerID string, newLimit float64) (*domain.Account, error) {
@@ -159,3 +266,53 @@ func (c *Client) UpdateAccountCreditLimit(ctx context.Context, customerID string
 
 	return updated, nil
 }
+
+// UpdateAccountOverdraftLimit sets the overdraft limit of one of the
+// customer's accounts.
+func (c *Client) UpdateAccountOverdraftLimit(ctx context.Context, customerID, accountID string, limit float64) (*domain.Account, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.UpdateAccountOverdraftLimit")
+	defer span.End()
+
+	path := fmt.Sprintf("accounts?customer_id=eq.%s&id=eq.%s", customerID, accountID)
+	n, err := c.doPatchCount(ctx, path, map[string]any{"overdraft_limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: accountID}
+	}
+	return c.GetAccount(ctx, customerID, accountID)
+}
+
+// ListActiveCustomerIDs pages through the customers with an active account,
+// keyed by customer_id so concurrent inserts do not shift the pages.
+func (c *Client) ListActiveCustomerIDs(ctx context.Context, afterID string, limit int) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListActiveCustomerIDs")
+	defer span.End()
+
+	path := fmt.Sprintf("accounts?select=customer_id&status=eq.active&order=customer_id.asc&limit=%d", limit)
+	if afterID != "" {
+		path += "&customer_id=gt." + afterID
+	}
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode active customers: %w", err)
+		}
+	}
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		// A customer with several active accounts comes once per account
+		if n := len(ids); n == 0 || ids[n-1] != row.CustomerID {
+			ids = append(ids, row.CustomerID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,98 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * Activity feed store — keyset pages of each feed source
+ */
+
+// keysetBefore filters column to the rows before the (before, beforeID) key,
+// in (column, id) descending order, and orders them that way.
+func keysetBefore(column string, before time.Time, beforeID string, limit int) string {
+	ts := before.UTC().Format("2006-01-02T15:04:05.999999Z")
+	filter := fmt.Sprintf("%s=lt.%s", column, ts)
+	if beforeID != "" {
+		filter = fmt.Sprintf("or=(%s.lt.%s,and(%s.eq.%s,id.lt.%s))", column, ts, column, ts, beforeID)
+	}
+	return fmt.Sprintf("%s&order=%s.desc,id.desc&limit=%d", filter, column, limit)
+}
+
+func (c *Client) ListTransactionsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListTransactionsBefore")
+	defer span.End()
+
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&status=eq.confirmed&%s",
+		customerID, keysetBefore("date", before, beforeID, limit))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Transaction
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode transactions: %w", err)
+	}
+	return rows, nil
+}
+
+func (c *Client) ListPixReceiptsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.PixReceipt, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListPixReceiptsBefore")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_receipts?customer_id=eq.%s&%s",
+		customerID, keysetBefore("executed_at", before, beforeID, limit))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.PixReceipt
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode pix_receipts: %w", err)
+	}
+	return rows, nil
+}
+
+func (c *Client) ListBillPaymentsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.BillPayment, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListBillPaymentsBefore")
+	defer span.End()
+
+	path := fmt.Sprintf("bill_payments?customer_id=eq.%s&%s",
+		customerID, keysetBefore("created_at", before, beforeID, limit))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.BillPayment
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode bill_payments: %w", err)
+	}
+	return rows, nil
+}
+
+func (c *Client) ListNotificationsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.Notification, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListNotificationsBefore")
+	defer span.End()
+
+	path := fmt.Sprintf("notifications?customer_id=eq.%s&%s",
+		customerID, keysetBefore("created_at", before, beforeID, limit))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Notification
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode notifications: %w", err)
+	}
+	return rows, nil
+}
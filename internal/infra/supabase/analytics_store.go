@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -20,7 +22,7 @@ func (c *Client) GetTransactionSummary(ctx context.Context, customerID string) (
 	ctx, span := tracer.Start(ctx, "Supabase.GetTransactionSummary")
 	defer span.End()
 
-	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&order=date.desc", customerID)
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&status=eq.confirmed&order=date.desc", customerID)
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
 		return nil, err
@@ -80,12 +82,49 @@ func (c *Client) InsertTransaction(ctx context.Context, data map[string]any) err
 	return err
 }
 
+// UpdateTransactionStatus sets the status (pending, confirmed, failed) of a
+// statement entry. Only confirmed entries are listed.
+func (c *Client) UpdateTransactionStatus(ctx context.Context, txID, status string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.UpdateTransactionStatus")
+	defer span.End()
+
+	return c.doPatch(ctx, fmt.Sprintf("customer_transactions?id=eq.%s", txID), map[string]any{"status": status})
+}
+
+// UpdateTransactionAnnotation replaces the note and tags of a statement
+// entry of the customer and returns the updated entry.
+func (c *Client) UpdateTransactionAnnotation(ctx context.Context, customerID, txID string, annotation *domain.TransactionAnnotation) (*domain.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.UpdateTransactionAnnotation")
+	defer span.End()
+
+	tags := annotation.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&id=eq.%s", customerID, url.QueryEscape(txID))
+	body, err := c.patch(ctx, path, map[string]any{"note": annotation.Note, "tags": tags}, "return=representation")
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Transaction
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode customer_transactions: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "transaction", ID: txID}
+	}
+	return &rows[0], nil
+}
+
 // ListTransactions returns transactions for a customer within a date range.
 func (c *Client) ListTransactions(ctx context.Context, customerID string, from, to string) ([]domain.Transaction, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListTransactions")
 	defer span.End()
 
-	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&date=gte.%s&date=lt.%s&order=date.desc&limit=1000",
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&status=eq.confirmed&date=gte.%s&date=lt.%s&order=date.desc&limit=1000",
 		customerID, from, to)
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
@@ -99,6 +138,66 @@ func (c *Client) ListTransactions(ctx context.Context, customerID string, from,
 	return txns, nil
 }
 
+// SearchTransactions returns candidate transactions for a search. Type,
+// direction and a loose description pattern are pushed to PostgREST;
+// accent-insensitive text and amount matching are left to the caller.
+func (c *Client) SearchTransactions(ctx context.Context, customerID string, filter *domain.TransactionSearchFilter) ([]domain.Transaction, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.SearchTransactions")
+	defer span.End()
+
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&status=eq.confirmed", customerID)
+	if filter.Type != "" {
+		path += "&type=eq." + url.QueryEscape(filter.Type)
+	}
+	switch filter.Direction {
+	case domain.TransactionDirectionIn:
+		path += "&amount=gt.0"
+	case domain.TransactionDirectionOut:
+		path += "&amount=lt.0"
+	}
+	if pattern := searchPattern(filter.Query); pattern != "" {
+		path += "&description=ilike." + url.QueryEscape(pattern)
+	}
+	path += "&order=date.desc&limit=1000"
+
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var txns []domain.Transaction
+	if body != nil {
+		if err := json.Unmarshal(body, &txns); err != nil {
+			return nil, fmt.Errorf("decode transactions search: %w", err)
+		}
+	}
+	return txns, nil
+}
+
+// searchPattern builds an ilike pattern that over-matches accent variants:
+// letters that have accented forms in Portuguese (vowels, c, n) become the
+// single-character wildcard, e.g. "pão de açúcar" → "*p__ d_ _____r*".
+func searchPattern(query string) string {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('*')
+	for _, r := range strings.ToLower(query) {
+		switch {
+		case strings.ContainsRune("aeioucnáàâãäéèêëíìîïóòôõöúùûüçñ", r):
+			b.WriteByte('_')
+		case r == '*' || r == '%' || r == '_' || r == '\\':
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('*')
+	return b.String()
+}
+
 /* Spending Analytics */
 
 func (c *Client) GetSpendingSummary(ctx context.Context, customerID, periodType string) (*domain.SpendingSummary, error) {
@@ -125,6 +224,202 @@ func (c *Client) GetSpendingSummary(ctx context.Context, customerID, periodType
 	return &rows[0], nil
 }
 
+// FindSpendingSummary returns the stored summary covering exactly
+// [periodStart, periodEnd] (inclusive dates), or nil when none was generated.
+func (c *Client) FindSpendingSummary(ctx context.Context, customerID, periodStart, periodEnd string) (*domain.SpendingSummary, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.FindSpendingSummary")
+	defer span.End()
+
+	path := fmt.Sprintf("spending_summaries?customer_id=eq.%s&period_start=eq.%s&period_end=eq.%s&limit=1",
+		customerID, periodStart, periodEnd)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.SpendingSummary
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode spending_summary lookup: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// UpsertSpendingSummary stores a precomputed summary, replacing the row of
+// the same customer and window if there is one.
+func (c *Client) UpsertSpendingSummary(ctx context.Context, summary *domain.SpendingSummary) error {
+	ctx, span := tracer.Start(ctx, "Supabase.UpsertSpendingSummary")
+	defer span.End()
+
+	row := map[string]any{
+		"customer_id":                summary.CustomerID,
+		"period_type":                summary.PeriodType,
+		"period_start":               summary.PeriodStart,
+		"period_end":                 summary.PeriodEnd,
+		"total_income":               summary.TotalIncome,
+		"total_expenses":             summary.TotalExpenses,
+		"net_cashflow":               summary.NetCashflow,
+		"transaction_count":          summary.TransactionCount,
+		"income_count":               summary.IncomeCount,
+		"expense_count":              summary.ExpenseCount,
+		"avg_income":                 summary.AvgIncome,
+		"avg_expense":                summary.AvgExpense,
+		"largest_income":             summary.LargestIncome,
+		"largest_expense":            summary.LargestExpense,
+		"category_breakdown":         summary.CategoryBreakdown,
+		"pix_sent_total":             summary.PixSentTotal,
+		"pix_sent_count":             summary.PixSentCount,
+		"pix_received_total":         summary.PixReceivedTotal,
+		"pix_received_count":         summary.PixReceivedCount,
+		"credit_card_total":          summary.CreditCardTotal,
+		"debit_card_total":           summary.DebitCardTotal,
+		"bills_paid_total":           summary.BillsPaidTotal,
+		"bills_paid_count":           summary.BillsPaidCount,
+		"income_variation_pct":       summary.IncomeVariationPct,
+		"expense_variation_pct":      summary.ExpenseVariationPct,
+		"net_cashflow_variation_pct": summary.NetVariationPct,
+		"monthly_trend":              summary.MonthlyTrend,
+		"computed_at":                summary.ComputedAt.Format(time.RFC3339),
+	}
+
+	existing, err := c.FindSpendingSummary(ctx, summary.CustomerID, summary.PeriodStart, summary.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return c.doPatch(ctx, fmt.Sprintf("spending_summaries?id=eq.%s", existing.ID), row)
+	}
+	_, err = c.doPost(ctx, "spending_summaries", row)
+	return err
+}
+
+// InsertSpendingDigest stores a digest sent to the customer. The table's key
+// (customer, period, first day) turns a second insert into ErrDuplicate.
+func (c *Client) InsertSpendingDigest(ctx context.Context, digest *domain.SpendingDigest) error {
+	ctx, span := tracer.Start(ctx, "Supabase.InsertSpendingDigest")
+	defer span.End()
+
+	_, err := c.doPost(ctx, "spending_digests", map[string]any{
+		"customer_id":  digest.CustomerID,
+		"period":       digest.Period,
+		"period_start": digest.From,
+		"period_end":   digest.To,
+		"digest":       digest,
+	})
+	return err
+}
+
+// SpendingDigestExists reports whether the customer's digest of the period
+// starting on periodStart is stored.
+func (c *Client) SpendingDigestExists(ctx context.Context, customerID, period, periodStart string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.SpendingDigestExists")
+	defer span.End()
+
+	path := fmt.Sprintf("spending_digests?customer_id=eq.%s&period=eq.%s&period_start=eq.%s&select=period_start&limit=1",
+		customerID, period, periodStart)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return false, err
+	}
+
+	var rows []struct {
+		PeriodStart string `json:"period_start"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return false, fmt.Errorf("decode spending_digests: %w", err)
+	}
+	return len(rows) > 0, nil
+}
+
+// DeleteSpendingSummaries removes the customer's stored summaries whose
+// window ends on or after since (YYYY-MM-DD).
+func (c *Client) DeleteSpendingSummaries(ctx context.Context, customerID, since string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.DeleteSpendingSummaries")
+	defer span.End()
+
+	return c.doDelete(ctx, fmt.Sprintf("spending_summaries?customer_id=eq.%s&period_end=gte.%s", customerID, since))
+}
+
+/* Category Rules */
+
+func (c *Client) ListCategoryRules(ctx context.Context, customerID string) ([]domain.CategoryRule, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListCategoryRules")
+	defer span.End()
+
+	body, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("category_rules?customer_id=eq.%s&order=pattern.asc", customerID))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.CategoryRule
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode category_rules: %w", err)
+		}
+	}
+	return rows, nil
+}
+
+func (c *Client) UpsertCategoryRule(ctx context.Context, rule *domain.CategoryRule) (*domain.CategoryRule, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.UpsertCategoryRule")
+	defer span.End()
+
+	existing, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("category_rules?customer_id=eq.%s&pattern=eq.%s&limit=1",
+		rule.CustomerID, url.QueryEscape(rule.Pattern)))
+	if err != nil {
+		return nil, err
+	}
+	var rows []domain.CategoryRule
+	if existing != nil {
+		if err := json.Unmarshal(existing, &rows); err != nil {
+			return nil, fmt.Errorf("decode category_rules: %w", err)
+		}
+	}
+	if len(rows) > 0 {
+		updated := rows[0]
+		updated.Category = rule.Category
+		err := c.doPatch(ctx, fmt.Sprintf("category_rules?id=eq.%s", updated.ID), map[string]any{
+			"category":   rule.Category,
+			"updated_at": time.Now().Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+
+	body, err := c.doPost(ctx, "category_rules", map[string]any{
+		"customer_id": rule.CustomerID,
+		"pattern":     rule.Pattern,
+		"category":    rule.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode category_rule: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no result from category_rules insert")
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) RecategorizeTransactions(ctx context.Context, customerID string, txIDs []string, category string) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.RecategorizeTransactions")
+	defer span.End()
+
+	if len(txIDs) == 0 {
+		return 0, nil
+	}
+	path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&id=in.(%s)", customerID, strings.Join(txIDs, ","))
+	return c.doPatchCount(ctx, path, map[string]any{"category": category})
+}
+
 /* Budgets */
 
 func (c *Client) ListBudgets(ctx context.Context, customerID string) ([]domain.SpendingBudget, error) {
@@ -206,6 +501,37 @@ func (c *Client) ListFavorites(ctx context.Context, customerID string) ([]domain
 	return rows, nil
 }
 
+// FindFavorite returns the customer's favorite pointing to the same destination
+// as fav (same destination_type and PIX key or bank account), or nil if none.
+func (c *Client) FindFavorite(ctx context.Context, fav *domain.Favorite) (*domain.Favorite, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.FindFavorite")
+	defer span.End()
+
+	path := fmt.Sprintf("favorites?customer_id=eq.%s&destination_type=eq.%s", fav.CustomerID, fav.DestinationType)
+	if fav.PixKeyValue != "" {
+		path += fmt.Sprintf("&pix_key_value=eq.%s", url.QueryEscape(fav.PixKeyValue))
+	} else {
+		path += fmt.Sprintf("&bank_code=eq.%s&branch=eq.%s&account_number=eq.%s", fav.BankCode, fav.Branch, fav.AccountNumber)
+	}
+	path += "&limit=1"
+
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Favorite
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode favorite lookup: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
 func (c *Client) CreateFavorite(ctx context.Context, fav *domain.Favorite) (*domain.Favorite, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.CreateFavorite")
 	defer span.End()
@@ -306,9 +632,51 @@ func (c *Client) UpdateTransactionLimit(ctx context.Context, limit *domain.Trans
 	return limit, nil
 }
 
+func (c *Client) CreateTransactionLimit(ctx context.Context, limit *domain.TransactionLimit) (*domain.TransactionLimit, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.CreateTransactionLimit")
+	defer span.End()
+
+	body, err := c.doPost(ctx, "transaction_limits", map[string]any{
+		"customer_id":      limit.CustomerID,
+		"transaction_type": limit.TransactionType,
+		"single_limit":     limit.SingleLimit,
+		"daily_limit":      limit.DailyLimit,
+		"monthly_limit":    limit.MonthlyLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.TransactionLimit
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode transaction_limit: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no result from transaction_limits insert")
+	}
+	return &rows[0], nil
+}
+
+// AddTransactionLimitUsage runs the add_transaction_limit_usage RPC, which
+// adds to the daily and monthly usage of the customer's limit for txType in
+// one statement, first zeroing the usage of a past day or month. Without a
+// configured limit it does nothing.
+func (c *Client) AddTransactionLimitUsage(ctx context.Context, customerID, txType string, daily, monthly float64) error {
+	ctx, span := tracer.Start(ctx, "Supabase.AddTransactionLimitUsage")
+	defer span.End()
+
+	_, err := c.doRPC(ctx, "add_transaction_limit_usage", map[string]any{
+		"p_customer_id":      customerID,
+		"p_transaction_type": txType,
+		"p_daily":            daily,
+		"p_monthly":          monthly,
+	})
+	return err
+}
+
 /* Notifications */
 
-func (c *Client) ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, error) {
+func (c *Client) ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, int, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListNotifications")
 	defer span.End()
 
@@ -319,16 +687,40 @@ func (c *Client) ListNotifications(ctx context.Context, customerID string, unrea
 		path += "&is_read=eq.false"
 	}
 
+	body, total, err := c.doRequestWithCount(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []domain.Notification
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode notifications: %w", err)
+		}
+	}
+	return rows, total, nil
+}
+
+func (c *Client) GetNotification(ctx context.Context, customerID, notifID string) (*domain.Notification, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetNotification")
+	defer span.End()
+
+	path := fmt.Sprintf("notifications?customer_id=eq.%s&id=eq.%s&limit=1", customerID, notifID)
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var rows []domain.Notification
-	if err := json.Unmarshal(body, &rows); err != nil {
-		return nil, fmt.Errorf("decode notifications: %w", err)
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode notification: %w", err)
+		}
 	}
-	return rows, nil
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "notification", ID: notifID}
+	}
+	return &rows[0], nil
 }
 
 func (c *Client) MarkNotificationRead(ctx context.Context, notifID string) error {
@@ -340,3 +732,90 @@ func (c *Client) MarkNotificationRead(ctx context.Context, notifID string) error
 		"read_at": time.Now().Format(time.RFC3339),
 	})
 }
+
+func (c *Client) MarkNotificationsRead(ctx context.Context, customerID string, ids []string) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.MarkNotificationsRead")
+	defer span.End()
+
+	path := fmt.Sprintf("notifications?customer_id=eq.%s&id=in.(%s)&is_read=is.false", customerID, strings.Join(ids, ","))
+	return c.doPatchCount(ctx, path, map[string]any{
+		"is_read": true,
+		"read_at": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (c *Client) InsertNotification(ctx context.Context, notif *domain.Notification) (*domain.Notification, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.InsertNotification")
+	defer span.End()
+
+	row := map[string]any{
+		"customer_id": notif.CustomerID,
+		"type":        notif.Type,
+		"title":       notif.Title,
+		"body":        notif.Body,
+		"channel":     notif.Channel,
+		"priority":    notif.Priority,
+	}
+	if notif.UserID != "" {
+		row["user_id"] = notif.UserID
+	}
+	body, err := c.doPost(ctx, "notifications", row)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Notification
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode notification: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("insert notification returned no rows")
+	}
+	return &rows[0], nil
+}
+
+// GetNotificationPreferences returns the preferences the customer changed,
+// or nil when none were stored.
+func (c *Client) GetNotificationPreferences(ctx context.Context, customerID string) (domain.NotificationPreferences, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetNotificationPreferences")
+	defer span.End()
+
+	path := fmt.Sprintf("notification_preferences?customer_id=eq.%s&select=preferences&limit=1", customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Preferences domain.NotificationPreferences `json:"preferences"`
+	}
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode notification preferences: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0].Preferences, nil
+}
+
+func (c *Client) SaveNotificationPreferences(ctx context.Context, customerID string, prefs domain.NotificationPreferences) error {
+	ctx, span := tracer.Start(ctx, "Supabase.SaveNotificationPreferences")
+	defer span.End()
+
+	existing, err := c.GetNotificationPreferences(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	row := map[string]any{
+		"preferences": prefs,
+		"updated_at":  time.Now().Format(time.RFC3339),
+	}
+	if existing != nil {
+		return c.doPatch(ctx, fmt.Sprintf("notification_preferences?customer_id=eq.%s", customerID), row)
+	}
+	row["customer_id"] = customerID
+	_, err = c.doPost(ctx, "notification_preferences", row)
+	return err
+}
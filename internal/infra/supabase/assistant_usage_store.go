@@ -0,0 +1,64 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+/*
+ * Assistant token usage — agent tokens per customer per day
+ */
+
+// AddTokenUsage runs the add_assistant_token_usage RPC, which adds tokens to
+// the customer's row for day in one statement and returns the new total, so
+// concurrent instances never lose an increment.
+func (c *Client) AddTokenUsage(ctx context.Context, customerID, day string, tokens int) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.AddTokenUsage")
+	defer span.End()
+
+	body, err := c.doRPC(ctx, "add_assistant_token_usage", map[string]any{
+		"p_customer_id": customerID,
+		"p_day":         day,
+		"p_tokens":      tokens,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	if err := json.Unmarshal(body, &total); err != nil {
+		return 0, fmt.Errorf("decode add_assistant_token_usage: %w", err)
+	}
+	return total, nil
+}
+
+func (c *Client) GetTokenUsage(ctx context.Context, customerID, day string) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetTokenUsage")
+	defer span.End()
+
+	path := fmt.Sprintf("assistant_token_usage?customer_id=eq.%s&day=eq.%s&select=tokens", customerID, day)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []struct {
+		Tokens int `json:"tokens"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return 0, fmt.Errorf("decode assistant_token_usage: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Tokens, nil
+}
+
+func (c *Client) ResetTokenUsage(ctx context.Context, customerID, day string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.ResetTokenUsage")
+	defer span.End()
+
+	return c.doDelete(ctx, fmt.Sprintf("assistant_token_usage?customer_id=eq.%s&day=eq.%s", customerID, day))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -88,6 +89,51 @@ func (c *Client) GetCustomerByCPF(ctx context.Context, cpf string) (*domain.Cust
 	return &rows[0], nil
 }
 
+func (c *Client) GetUserCompany(ctx context.Context, customerID, cpf string) (*domain.UserCompany, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetUserCompany")
+	defer span.End()
+
+	path := fmt.Sprintf("user_companies?customer_id=eq.%s&users.cpf=eq.%s&select=id,user_id,customer_id,role,is_default,permissions,users!inner(cpf)&limit=1",
+		customerID, cpf)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil || string(body) == "[]" {
+		return nil, nil
+	}
+
+	var rows []domain.UserCompany
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode user_companies: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) ListUserCompanies(ctx context.Context, cpf string) ([]domain.UserCompany, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListUserCompanies")
+	defer span.End()
+
+	path := fmt.Sprintf("user_companies?users.cpf=eq.%s&select=id,user_id,customer_id,role,is_default,permissions,users!inner(cpf)&order=is_default.desc,created_at.asc",
+		cpf)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	var rows []domain.UserCompany
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode user_companies: %w", err)
+	}
+	return rows, nil
+}
+
 func (c *Client) GetCustomerByBankDetails(ctx context.Context, document, agencia, conta string) (*domain.CustomerProfile, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.GetCustomerByBankDetails")
 	defer span.End()
@@ -288,6 +334,22 @@ func (c *Client) UpdateCredentials(ctx context.Context, customerID string, updat
 	return c.doPatch(ctx, path, updates)
 }
 
+// UpdateLastLogin records a successful login on the credentials and on the
+// users row of the representative, when there is one.
+func (c *Client) UpdateLastLogin(ctx context.Context, customerID, cpf string, at time.Time) error {
+	ctx, span := tracer.Start(ctx, "Supabase.UpdateLastLogin")
+	defer span.End()
+
+	update := map[string]any{"last_login_at": at.UTC().Format(time.RFC3339)}
+	if err := c.doPatch(ctx, fmt.Sprintf("auth_credentials?customer_id=eq.%s", customerID), update); err != nil {
+		return err
+	}
+	if cpf == "" {
+		return nil
+	}
+	return c.doPatch(ctx, fmt.Sprintf("users?cpf=eq.%s", cpf), update)
+}
+
 /* Refresh tokens */
 
 func (c *Client) StoreRefreshToken(ctx context.Context, customerID, tokenHash string, expiresAt time.Time) error {
@@ -345,6 +407,21 @@ func (c *Client) RevokeAllRefreshTokens(ctx context.Context, customerID string)
 	return c.doPatch(ctx, path, map[string]any{"revoked": true})
 }
 
+// RevokeIdleRefreshTokens revokes the tokens nobody refreshed since
+// idleSince; rotation issues a new token on every refresh, so created_at is
+// the last time the session was used.
+func (c *Client) RevokeIdleRefreshTokens(ctx context.Context, idleSince time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.RevokeIdleRefreshTokens")
+	defer span.End()
+
+	path := fmt.Sprintf("auth_refresh_tokens?revoked=eq.false&created_at=lt.%s",
+		url.QueryEscape(idleSince.UTC().Format(time.RFC3339)))
+	return c.doPatchCount(ctx, path, map[string]any{
+		"revoked":    true,
+		"revoked_at": time.Now().Format(time.RFC3339),
+	})
+}
+
 /* Password reset codes */
 
 func (c *Client) StoreResetCode(ctx context.Context, customerID, code string, expiresAt time.Time) error {
@@ -363,13 +440,13 @@ func (c *Client) StoreResetCode(ctx context.Context, customerID, code string, ex
 	return err
 }
 
-func (c *Client) GetValidResetCode(ctx context.Context, customerID, code string) (*domain.AuthPasswordResetCode, error) {
-	ctx, span := tracer.Start(ctx, "Supabase.GetValidResetCode")
+func (c *Client) GetActiveResetCode(ctx context.Context, customerID string) (*domain.AuthPasswordResetCode, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetActiveResetCode")
 	defer span.End()
 
 	now := time.Now().UTC().Format(time.RFC3339)
-	path := fmt.Sprintf("auth_password_reset_codes?customer_id=eq.%s&code=eq.%s&used=eq.false&expires_at=gt.%s&order=created_at.desc&limit=1",
-		customerID, code, now)
+	path := fmt.Sprintf("auth_password_reset_codes?customer_id=eq.%s&used=eq.false&expires_at=gt.%s&order=created_at.desc&limit=1",
+		customerID, now)
 	body, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
 		return nil, err
@@ -396,6 +473,46 @@ func (c *Client) MarkResetCodeUsed(ctx context.Context, codeID string) error {
 	return c.doPatch(ctx, path, map[string]any{"used": true})
 }
 
+// ClaimResetCodeAttempt increments attempts only while it still holds the
+// value the caller read, so concurrent guesses are each counted once.
+func (c *Client) ClaimResetCodeAttempt(ctx context.Context, codeID string, attempts int) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimResetCodeAttempt")
+	defer span.End()
+
+	path := fmt.Sprintf("auth_password_reset_codes?id=eq.%s&used=eq.false&attempts=eq.%d", codeID, attempts)
+	n, err := c.doPatchCount(ctx, path, map[string]any{"attempts": attempts + 1})
+	return n > 0, err
+}
+
+func (c *Client) ListResetCodesSince(ctx context.Context, customerID string, since time.Time) ([]domain.AuthPasswordResetCode, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListResetCodesSince")
+	defer span.End()
+
+	path := fmt.Sprintf("auth_password_reset_codes?customer_id=eq.%s&created_at=gte.%s&order=created_at.desc",
+		customerID, url.QueryEscape(since.UTC().Format(time.RFC3339)))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	var rows []domain.AuthPasswordResetCode
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode auth_password_reset_codes: %w", err)
+	}
+	return rows, nil
+}
+
+func (c *Client) InvalidateResetCodes(ctx context.Context, customerID string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.InvalidateResetCodes")
+	defer span.End()
+
+	path := fmt.Sprintf("auth_password_reset_codes?customer_id=eq.%s&used=eq.false", customerID)
+	return c.doPatch(ctx, path, map[string]any{"used": true, "used_at": time.Now().UTC().Format(time.RFC3339)})
+}
+
 /* Profile updates */
 
 func (c *Client) UpdateCustomerProfile(ctx context.Context, customerID string, updates map[string]any) (*domain.CustomerProfile, error) {
@@ -63,23 +63,25 @@ func (c *Client) CreateBillPayment(ctx context.Context, customerID string, req *
 	return &results[0], nil
 }
 
-func (c *Client) ListBillPayments(ctx context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, error) {
+func (c *Client) ListBillPayments(ctx context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, int, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListBillPayments")
 	defer span.End()
 
 	offset := (page - 1) * pageSize
 	path := fmt.Sprintf("bill_payments?customer_id=eq.%s&order=created_at.desc&limit=%d&offset=%d",
 		customerID, pageSize, offset)
-	body, err := c.doRequest(ctx, http.MethodGet, path)
+	body, total, err := c.doRequestWithCount(ctx, path)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var rows []domain.BillPayment
-	if err := json.Unmarshal(body, &rows); err != nil {
-		return nil, fmt.Errorf("decode bill_payments: %w", err)
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode bill_payments: %w", err)
+		}
 	}
-	return rows, nil
+	return rows, total, nil
 }
 
 func (c *Client) GetBillPayment(ctx context.Context, customerID, billID string) (*domain.BillPayment, error) {
@@ -114,23 +116,25 @@ func (c *Client) UpdateBillPaymentStatus(ctx context.Context, billID, status str
 
 /* Debit Purchases */
 
-func (c *Client) ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) ([]domain.DebitPurchase, error) {
+func (c *Client) ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) ([]domain.DebitPurchase, int, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListDebitPurchases")
 	defer span.End()
 
 	offset := (page - 1) * pageSize
 	path := fmt.Sprintf("debit_purchases?customer_id=eq.%s&order=transaction_date.desc&limit=%d&offset=%d",
 		customerID, pageSize, offset)
-	body, err := c.doRequest(ctx, http.MethodGet, path)
+	body, total, err := c.doRequestWithCount(ctx, path)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var rows []domain.DebitPurchase
-	if err := json.Unmarshal(body, &rows); err != nil {
-		return nil, fmt.Errorf("decode debit_purchases: %w", err)
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode debit_purchases: %w", err)
+		}
 	}
-	return rows, nil
+	return rows, total, nil
 }
 
 func (c *Client) CreateDebitPurchase(ctx context.Context, customerID string, req *domain.DebitPurchaseRequest) (*domain.DebitPurchase, error) {
@@ -173,3 +177,62 @@ func (c *Client) CreateDebitPurchase(ctx context.Context, customerID string, req
 	}
 	return &results[0], nil
 }
+
+func (c *Client) GetDebitPurchase(ctx context.Context, customerID, purchaseID string) (*domain.DebitPurchase, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetDebitPurchase")
+	defer span.End()
+
+	path := fmt.Sprintf("debit_purchases?customer_id=eq.%s&id=eq.%s&limit=1", customerID, purchaseID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.DebitPurchase
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode debit_purchase: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "debit_purchase", ID: purchaseID}
+	}
+	return &rows[0], nil
+}
+
+// ClaimDebitPurchaseRefund marks a confirmed purchase as refunded. It reports
+// false when the purchase was already refunded, cancelled or disputed,
+// including by a concurrent request.
+// ClaimBillPaymentCancel marks an unpaid bill as cancelled. It reports false
+// when no row changed, i.e. the bill was paid or cancelled concurrently.
+func (c *Client) ClaimBillPaymentCancel(ctx context.Context, billID string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimBillPaymentCancel")
+	defer span.End()
+
+	n, err := c.doPatchCount(ctx, fmt.Sprintf("bill_payments?id=eq.%s&status=in.(pending,scheduled,validated)", billID), map[string]any{
+		"status":     "cancelled",
+		"updated_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (c *Client) ClaimDebitPurchaseRefund(ctx context.Context, purchaseID string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimDebitPurchaseRefund")
+	defer span.End()
+
+	n, err := c.doPatchCount(ctx, fmt.Sprintf("debit_purchases?id=eq.%s&status=in.(confirmed,completed)", purchaseID), map[string]any{
+		"status": "refunded",
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (c *Client) UpdateDebitPurchaseStatus(ctx context.Context, purchaseID, status string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.UpdateDebitPurchaseStatus")
+	defer span.End()
+
+	return c.doPatch(ctx, fmt.Sprintf("debit_purchases?id=eq.%s", purchaseID), map[string]any{"status": status})
+}
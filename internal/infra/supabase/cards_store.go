@@ -121,29 +121,34 @@ func (c *Client) UpdateCreditCardStatus(ctx context.Context, cardID, status stri
 		patch["issued_at"] = time.Now().Format(time.RFC3339)
 		patch["expires_at"] = time.Now().AddDate(5, 0, 0).Format(time.RFC3339)
 	}
+	if status == "cancelled" {
+		patch["pix_credit_enabled"] = false
+	}
 
 	return c.doPatch(ctx, fmt.Sprintf("credit_cards?id=eq.%s", cardID), patch)
 }
 
 /* Credit Card Transactions */
 
-func (c *Client) ListCreditCardTransactions(ctx context.Context, customerID, cardID string, page, pageSize int) ([]domain.CreditCardTransaction, error) {
+func (c *Client) ListCreditCardTransactions(ctx context.Context, customerID, cardID string, page, pageSize int) ([]domain.CreditCardTransaction, int, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListCreditCardTransactions")
 	defer span.End()
 
 	offset := (page - 1) * pageSize
 	path := fmt.Sprintf("credit_card_transactions?%s&order=transaction_date.desc,created_at.desc&limit=%d&offset=%d",
 		cardFilter(customerID, cardID), pageSize, offset)
-	body, err := c.doRequest(ctx, http.MethodGet, path)
+	body, total, err := c.doRequestWithCount(ctx, path)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var rows []domain.CreditCardTransaction
-	if err := json.Unmarshal(body, &rows); err != nil {
-		return nil, fmt.Errorf("decode cc_transactions: %w", err)
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode cc_transactions: %w", err)
+		}
 	}
-	return rows, nil
+	return rows, total, nil
 }
 
 /* Credit Card Invoices */
@@ -5,9 +5,13 @@ package supabase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -30,6 +34,7 @@ type Client struct {
 	cb             *gobreaker.CircuitBreaker
 	cfg            resilience.Config
 	logger         *zap.Logger
+	callTimeout    time.Duration // deadline of each PostgREST call (0 = caller's context only)
 }
 
 // NewClient creates a Supabase client.
@@ -45,9 +50,78 @@ func NewClient(httpClient *http.Client, baseURL, apiKey, serviceRoleKey string,
 	}
 }
 
+// SetCallTimeout bounds every PostgREST call (each retry attempt of reads)
+// by d. A call that hits the deadline fails with domain.ErrTimeout.
+// Non-positive values leave only the caller's context deadline.
+func (c *Client) SetCallTimeout(d time.Duration) {
+	c.callTimeout = d
+}
+
+// withCallTimeout derives the context of one call from the caller's: the
+// earlier of the two deadlines applies.
+func (c *Client) withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.callTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.callTimeout)
+}
+
+// timeoutError maps a deadline hit during a call to domain.ErrTimeout, so
+// handlers answer 504 instead of a generic 500. Other errors pass through.
+// The operation is named after the method and the table (or RPC) alone: the
+// query string carries IDs, which belong in the logs, not in the error sent
+// to the client nor in the key errors are sampled by.
+func timeoutError(method, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		table, _, _ := strings.Cut(path, "?")
+		return &domain.ErrTimeout{Operation: "supabase " + method + " " + table}
+	}
+	return err
+}
+
 // doRequest executes an authenticated request to Supabase PostgREST.
 // Includes automatic retry (up to 2 retries) with exponential backoff for transient errors.
 func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, error) {
+	body, _, err := c.send(ctx, method, path, "return=representation")
+	return body, err
+}
+
+// doRequestWithCount executes a GET that also asks PostgREST for the number
+// of rows matching the filters, ignoring limit/offset. The total is parsed
+// from the Content-Range header ("0-19/57"); it is 0 when there is no data.
+func (c *Client) doRequestWithCount(ctx context.Context, path string) ([]byte, int, error) {
+	body, header, err := c.send(ctx, http.MethodGet, path, "count=exact")
+	if err != nil || header == nil {
+		return body, 0, err
+	}
+	total, err := parseContentRangeTotal(header.Get("Content-Range"))
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, total, nil
+}
+
+// parseContentRangeTotal extracts the total from a PostgREST Content-Range
+// header such as "0-19/57" or "*/0".
+func parseContentRangeTotal(contentRange string) (int, error) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok || total == "*" {
+		return 0, fmt.Errorf("supabase: no exact count in Content-Range %q", contentRange)
+	}
+	n, err := strconv.Atoi(total)
+	if err != nil {
+		return 0, fmt.Errorf("supabase: invalid Content-Range %q: %w", contentRange, err)
+	}
+	return n, nil
+}
+
+// send performs the request with retries and returns the body and headers
+// of the response. prefer is sent as the Prefer header.
+func (c *Client) send(ctx context.Context, method, path, prefer string) ([]byte, http.Header, error) {
 	url := fmt.Sprintf("%s/rest/v1/%s", c.baseURL, path)
 
 	const maxRetries = 2
@@ -67,26 +141,11 @@ func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, er
 			case <-time.After(backoff):
 				backoff *= 2 // exponential backoff
 			case <-ctx.Done():
-				return nil, fmt.Errorf("supabase: context cancelled during retry: %w", ctx.Err())
+				return nil, nil, timeoutError(method, path, fmt.Errorf("supabase: context cancelled during retry: %w", ctx.Err()))
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, url, nil)
-		if err != nil {
-			c.logger.Error("supabase: failed to create request",
-				zap.String("method", method),
-				zap.String("path", path),
-				zap.Error(err),
-			)
-			return nil, err // not retryable
-		}
-
-		req.Header.Set("apikey", c.apiKey)
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.serviceRoleKey))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Prefer", "return=representation")
-
-		resp, err := c.httpClient.Do(req)
+		body, resp, err := c.attempt(ctx, method, url, prefer)
 		if err != nil {
 			lastErr = err
 			c.logger.Error("supabase: request failed",
@@ -98,15 +157,13 @@ func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, er
 			continue // retry on network error / timeout
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			lastErr = fmt.Errorf("read body: %w", err)
-			continue // retry
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent {
+			return nil, nil, nil // no data
 		}
 
-		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent {
-			return nil, nil // no data
+		// A page past the end: no rows, but Content-Range still carries the total
+		if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return []byte("[]"), resp.Header, nil
 		}
 
 		// Retry on 5xx (server error) or 429 (rate limit)
@@ -128,7 +185,16 @@ func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, er
 				zap.Int("status", resp.StatusCode),
 				zap.String("body", string(body)),
 			)
-			return nil, fmt.Errorf("supabase returned status %d: %s", resp.StatusCode, string(body))
+			// A unique constraint violation (e.g. a reused idempotency key);
+			// any other 409 (a foreign key, ...) is a generic conflict
+			if resp.StatusCode == http.StatusConflict {
+				table, _, _ := strings.Cut(path, "?")
+				if dup := uniqueViolation(table, resp.StatusCode, body); dup != nil {
+					return nil, nil, dup
+				}
+				return nil, nil, &domain.ErrConflict{Message: "conflicting write on " + table}
+			}
+			return nil, nil, fmt.Errorf("supabase returned status %d: %s", resp.StatusCode, string(body))
 		}
 
 		c.logger.Debug("supabase: request OK",
@@ -137,10 +203,39 @@ func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, er
 			zap.Int("status", resp.StatusCode),
 		)
 
-		return body, nil
+		return body, resp.Header, nil
 	}
 
-	return nil, fmt.Errorf("supabase: request failed after %d attempts: %w", maxRetries+1, lastErr)
+	return nil, nil, timeoutError(method, path, fmt.Errorf("supabase: request failed after %d attempts: %w", maxRetries+1, lastErr))
+}
+
+// attempt performs one try of send under the per-call timeout and returns
+// the read body along with the (closed) response.
+func (c *Client) attempt(ctx context.Context, method, url, prefer string) ([]byte, *http.Response, error) {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("apikey", c.apiKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.serviceRoleKey))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", prefer)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, resp, nil
 }
 
 /* Profile API (implements port.ProfileFetcher) */
@@ -201,7 +296,7 @@ func (c *Client) GetProfile(ctx context.Context, customerID string) (*domain.Cus
 	})
 
 	if err != nil {
-		return nil, &domain.ErrExternalService{Service: "supabase/profile", Err: err}
+		return nil, &domain.ErrExternalService{Service: "supabase/profile", Err: resilience.BreakerError(c.cb, err)}
 	}
 
 	return profile, nil
@@ -211,14 +306,16 @@ func (c *Client) GetProfile(ctx context.Context, customerID string) (*domain.Cus
 
 // supabaseTransaction maps Supabase table columns.
 type supabaseTransaction struct {
-	ID           string  `json:"id"`
-	CustomerID   string  `json:"customer_id"`
-	Date         string  `json:"date"`
-	Amount       float64 `json:"amount"`
-	Type         string  `json:"type"`
-	Category     string  `json:"category"`
-	Description  string  `json:"description"`
-	Counterparty string  `json:"counterparty"`
+	ID           string   `json:"id"`
+	CustomerID   string   `json:"customer_id"`
+	Date         string   `json:"date"`
+	Amount       float64  `json:"amount"`
+	Type         string   `json:"type"`
+	Category     string   `json:"category"`
+	Description  string   `json:"description"`
+	Counterparty string   `json:"counterparty"`
+	Tags         []string `json:"tags"`
+	Note         string   `json:"note"`
 }
 
 // GetTransactions fetches customer transactions from Supabase.
@@ -231,7 +328,7 @@ func (c *Client) GetTransactions(ctx context.Context, customerID string) ([]doma
 
 	_, err := c.cb.Execute(func() (any, error) {
 		return nil, resilience.RetryWithBackoff(ctx, c.cfg, func() error {
-			path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&order=date.desc&limit=500", customerID)
+			path := fmt.Sprintf("customer_transactions?customer_id=eq.%s&status=eq.confirmed&order=date.desc&limit=500", customerID)
 			body, err := c.doRequest(ctx, http.MethodGet, path)
 			if err != nil {
 				return err
@@ -261,6 +358,8 @@ func (c *Client) GetTransactions(ctx context.Context, customerID string) ([]doma
 					Category:     r.Category,
 					Description:  r.Description,
 					Counterparty: r.Counterparty,
+					Tags:         r.Tags,
+					Note:         r.Note,
 				})
 			}
 			return nil
@@ -268,7 +367,7 @@ func (c *Client) GetTransactions(ctx context.Context, customerID string) ([]doma
 	})
 
 	if err != nil {
-		return nil, &domain.ErrExternalService{Service: "supabase/transactions", Err: err}
+		return nil, &domain.ErrExternalService{Service: "supabase/transactions", Err: resilience.BreakerError(c.cb, err)}
 	}
 
 	return transactions, nil
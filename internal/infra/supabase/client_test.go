@@ -0,0 +1,247 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+
+	"go.uber.org/zap"
+)
+
+// TestListBillPayments_TotalFromContentRange serves 45 bill payments the way
+// PostgREST does: the requested page in the body and the exact count in
+// Content-Range when asked for with Prefer: count=exact.
+func TestListBillPayments_TotalFromContentRange(t *testing.T) {
+	const totalRows = 45
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Prefer"); got != "count=exact" {
+			t.Errorf("Prefer = %q, want count=exact", got)
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset >= totalRows {
+			w.Header().Set("Content-Range", fmt.Sprintf("*/%d", totalRows))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		end := min(offset+limit, totalRows)
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", offset, end-1, totalRows))
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, "[")
+		for i := offset; i < end; i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":"bill-%d","customer_id":"cust-1"}`, i)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), srv.URL, "anon", "service", resilience.NewCircuitBreaker("supabase-test"), resilience.Config{}, zap.NewNop())
+
+	tests := []struct {
+		page, wantRows int
+	}{
+		{1, 20},
+		{3, 5},
+		{4, 0},
+	}
+	for _, tt := range tests {
+		rows, total, err := c.ListBillPayments(context.Background(), "cust-1", tt.page, 20)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", tt.page, err)
+		}
+		if len(rows) != tt.wantRows || total != totalRows {
+			t.Errorf("page %d: got %d rows, total %d; want %d rows, total %d", tt.page, len(rows), total, tt.wantRows, totalRows)
+		}
+	}
+}
+
+func TestParseContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{"0-19/57", 57, false},
+		{"*/0", 0, false},
+		{"0-19/*", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseContentRangeTotal(tt.header)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseContentRangeTotal(%q) = %d, %v; want %d, err=%v", tt.header, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestCallTimeout_SlowServerReturnsErrTimeout serves PostgREST requests that
+// never answer in time: both reads (retried) and writes must give up at the
+// per-call deadline with domain.ErrTimeout.
+func TestCallTimeout_SlowServerReturnsErrTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewClient(srv.Client(), srv.URL, "anon", "service", resilience.NewCircuitBreaker("supabase-test"), resilience.Config{}, zap.NewNop())
+	c.SetCallTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := c.doRequest(context.Background(), http.MethodGet, "accounts?customer_id=eq.cust-1")
+	var timeout *domain.ErrTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("read: expected ErrTimeout, got %v", err)
+	}
+	if timeout.Operation != "supabase GET accounts" {
+		t.Errorf("operation = %q, want the table without the query string", timeout.Operation)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("read: %d attempts, want each of the 3 attempts bounded by the timeout", calls.Load())
+	}
+
+	_, err = c.doPost(context.Background(), "pix_transfers", map[string]any{"amount": 10})
+	if !errors.As(err, &timeout) {
+		t.Fatalf("write: expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("calls took %v, want them cut at the per-call deadline", elapsed)
+	}
+
+	// Without a per-call timeout the caller's deadline still applies.
+	c.SetCallTimeout(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.doDelete(ctx, "pix_transfers?id=eq.1"); !errors.As(err, &timeout) {
+		t.Errorf("caller deadline: expected ErrTimeout, got %v", err)
+	}
+}
+
+// TestCreatePixKey_UniqueViolationIsDuplicate answers inserts the way
+// PostgREST does when a constraint is violated: 409 with the SQLSTATE in the
+// body. Only unique violations (23505) are duplicates.
+func TestCreatePixKey_UniqueViolationIsDuplicate(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantDuplicate bool
+		wantKey       string
+	}{
+		{
+			name:          "unique violation",
+			body:          `{"code":"23505","details":"Key (key_type, key_value)=(email, financeiro@empresa.com.br) already exists.","hint":null,"message":"duplicate key value violates unique constraint \"pix_keys_key_type_key_value_key\""}`,
+			wantDuplicate: true,
+			wantKey:       "pix_keys (key_type, key_value)",
+		},
+		{
+			name:          "unique violation without details",
+			body:          `{"code":"23505","details":null,"hint":null,"message":"duplicate key value violates unique constraint"}`,
+			wantDuplicate: true,
+			wantKey:       "pix_keys",
+		},
+		{
+			name: "foreign key violation",
+			body: `{"code":"23503","details":"Key (customer_id)=(cust-9) is not present in table \"customer_profiles\".","hint":null,"message":"insert or update on table \"pix_keys\" violates foreign key constraint"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			c := NewClient(srv.Client(), srv.URL, "anon", "service", resilience.NewCircuitBreaker("supabase-test"), resilience.Config{}, zap.NewNop())
+
+			_, err := c.CreatePixKey(context.Background(), &domain.PixKey{
+				ID: "key-1", CustomerID: "cust-1", KeyType: "email", KeyValue: "financeiro@empresa.com.br",
+			})
+			var duplicate *domain.ErrDuplicate
+			if got := errors.As(err, &duplicate); got != tt.wantDuplicate {
+				t.Fatalf("ErrDuplicate = %v, want %v (err: %v)", got, tt.wantDuplicate, err)
+			}
+			if !tt.wantDuplicate {
+				return
+			}
+			if duplicate.Key != tt.wantKey {
+				t.Errorf("key = %q, want %q", duplicate.Key, tt.wantKey)
+			}
+			if strings.Contains(err.Error(), "financeiro@empresa.com.br") {
+				t.Errorf("error %q exposes the conflicting value", err)
+			}
+		})
+	}
+}
+
+// TestGetPixKey_FiltersByOwner serves a pix_keys table holding key-1 of
+// cust-1 and applies the id and customer_id filters of the query, the way
+// PostgREST does: another customer asking for key-1 gets no row.
+func TestGetPixKey_FiltersByOwner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("customer_id") == "" {
+			t.Errorf("query %q does not filter by customer_id", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("id") == "eq.key-1" && q.Get("customer_id") == "eq.cust-1" {
+			fmt.Fprint(w, `[{"id":"key-1","customer_id":"cust-1","key_type":"cnpj","key_value":"12345678000190","status":"active"}]`)
+			return
+		}
+		fmt.Fprint(w, "[]")
+	}))
+	defer srv.Close()
+	c := NewClient(srv.Client(), srv.URL, "anon", "service", resilience.NewCircuitBreaker("supabase-test"), resilience.Config{}, zap.NewNop())
+
+	key, err := c.GetPixKey(context.Background(), "cust-1", "key-1")
+	if err != nil || key.CustomerID != "cust-1" {
+		t.Fatalf("owner: got %+v, %v; want key-1", key, err)
+	}
+
+	_, err = c.GetPixKey(context.Background(), "cust-2", "key-1")
+	var notFound *domain.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("other customer: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestSend_ForeignKeyConflictIsGenericConflict answers a delete with the 409
+// PostgREST gives for a foreign key violation: it is a conflict, not a
+// duplicate, and the error does not carry the request path.
+func TestSend_ForeignKeyConflictIsGenericConflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"code":"23503","details":"Key (id)=(key-1) is still referenced from table \"favorites\".","hint":null,"message":"update or delete on table \"pix_keys\" violates foreign key constraint"}`)
+	}))
+	defer srv.Close()
+	c := NewClient(srv.Client(), srv.URL, "anon", "service", resilience.NewCircuitBreaker("supabase-test"), resilience.Config{}, zap.NewNop())
+
+	_, err := c.doRequest(context.Background(), http.MethodDelete, "pix_keys?id=eq.key-1")
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if strings.Contains(err.Error(), "key-1") {
+		t.Errorf("error %q exposes the request path", err)
+	}
+}
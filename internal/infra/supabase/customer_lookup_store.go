@@ -105,3 +105,27 @@ func (c *Client) GetCustomerLookupData(ctx context.Context, customerID string) (
 
 	return
 }
+
+// GetCustomerCreditScore returns the credit score stored on the customer's
+// profile.
+func (c *Client) GetCustomerCreditScore(ctx context.Context, customerID string) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetCustomerCreditScore")
+	defer span.End()
+
+	path := fmt.Sprintf("customer_profiles?customer_id=eq.%s&select=credit_score&limit=1", customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []struct {
+		CreditScore int `json:"credit_score"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return 0, fmt.Errorf("decode customer_profiles: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, &domain.ErrNotFound{Resource: "profile", ID: customerID}
+	}
+	return rows[0].CreditScore, nil
+}
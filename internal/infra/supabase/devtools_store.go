@@ -0,0 +1,98 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * Dev Tools
+ */
+
+// customerDataTables lists what ClearCustomerData deletes, with the column
+// holding the customer ID.
+var customerDataTables = []struct {
+	table  string
+	column string
+}{
+	{"customer_transactions", "customer_id"},
+	{"pix_receipts", "customer_id"},
+	{"transfer_receipts", "customer_id"},
+	{"pix_transfers", "source_customer_id"},
+	{"scheduled_transfers", "source_customer_id"},
+	{"doc_transfers", "customer_id"},
+	{"bill_payments", "customer_id"},
+	{"debit_purchases", "customer_id"},
+	{"credit_card_transactions", "customer_id"},
+	{"credit_card_invoices", "customer_id"},
+	{"spending_summaries", "customer_id"},
+	{"dev_balance_adjustments", "customer_id"},
+}
+
+func (c *Client) ClearCustomerData(ctx context.Context, customerID string) (map[string]int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClearCustomerData")
+	defer span.End()
+
+	cleared := make(map[string]int, len(customerDataTables))
+	for _, t := range customerDataTables {
+		n, err := c.doDeleteCount(ctx, fmt.Sprintf("%s?%s=eq.%s", t.table, t.column, customerID))
+		if err != nil {
+			return cleared, fmt.Errorf("clear %s: %w", t.table, err)
+		}
+		cleared[t.table] = n
+	}
+	return cleared, nil
+}
+
+func (c *Client) GetDevBalanceAdjustment(ctx context.Context, idempotencyKey string) (*domain.DevBalanceAdjustment, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetDevBalanceAdjustment")
+	defer span.End()
+
+	path := fmt.Sprintf("dev_balance_adjustments?idempotency_key=eq.%s&limit=1", url.QueryEscape(idempotencyKey))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var rows []domain.DevBalanceAdjustment
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode dev_balance_adjustments: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) SaveDevBalanceAdjustment(ctx context.Context, adj *domain.DevBalanceAdjustment) error {
+	ctx, span := tracer.Start(ctx, "Supabase.SaveDevBalanceAdjustment")
+	defer span.End()
+
+	_, err := c.doPost(ctx, "dev_balance_adjustments", map[string]any{
+		"idempotency_key": adj.IdempotencyKey,
+		"customer_id":     adj.CustomerID,
+		"amount":          adj.Amount,
+		"new_balance":     adj.NewBalance,
+	})
+	return err
+}
+
+func (c *Client) SetDevBalanceAdjustmentResult(ctx context.Context, idempotencyKey string, newBalance float64) error {
+	ctx, span := tracer.Start(ctx, "Supabase.SetDevBalanceAdjustmentResult")
+	defer span.End()
+
+	return c.doPatch(ctx, fmt.Sprintf("dev_balance_adjustments?idempotency_key=eq.%s", url.QueryEscape(idempotencyKey)),
+		map[string]any{"new_balance": newBalance})
+}
+
+func (c *Client) DeleteDevBalanceAdjustment(ctx context.Context, idempotencyKey string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.DeleteDevBalanceAdjustment")
+	defer span.End()
+
+	return c.doDelete(ctx, fmt.Sprintf("dev_balance_adjustments?idempotency_key=eq.%s", url.QueryEscape(idempotencyKey)))
+}
@@ -0,0 +1,152 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * DOC Transfers store — DOCs held until their settlement date
+ */
+
+func (c *Client) CreateDOCTransfer(ctx context.Context, transfer *domain.DOCTransfer) (*domain.DOCTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.CreateDOCTransfer")
+	defer span.End()
+
+	body, err := c.doPost(ctx, "doc_transfers", map[string]any{
+		"id":                 transfer.ID,
+		"idempotency_key":    transfer.IdempotencyKey,
+		"customer_id":        transfer.CustomerID,
+		"account_id":         transfer.AccountID,
+		"recipient_name":     transfer.RecipientName,
+		"recipient_document": transfer.RecipientDocument,
+		"recipient_bank":     transfer.RecipientBank,
+		"recipient_branch":   transfer.RecipientBranch,
+		"recipient_account":  transfer.RecipientAccount,
+		"amount":             transfer.Amount,
+		"description":        transfer.Description,
+		"status":             transfer.Status,
+		"settlement_date":    transfer.SettlementDate,
+		"created_at":         transfer.CreatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.DOCTransfer
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("decode doc_transfer: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no result returned from doc_transfers insert")
+	}
+	return &results[0], nil
+}
+
+func (c *Client) GetDOCTransferByIdempotencyKey(ctx context.Context, customerID, key string) (*domain.DOCTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetDOCTransferByIdempotencyKey")
+	defer span.End()
+
+	path := fmt.Sprintf("doc_transfers?customer_id=eq.%s&idempotency_key=eq.%s&limit=1", customerID, url.QueryEscape(key))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, &domain.ErrNotFound{Resource: "doc_transfer", ID: key}
+	}
+
+	var rows []domain.DOCTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode doc_transfer: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "doc_transfer", ID: key}
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) ListDueDOCTransfers(ctx context.Context, date string, limit int) ([]domain.DOCTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListDueDOCTransfers")
+	defer span.End()
+
+	path := fmt.Sprintf("doc_transfers?status=eq.held&settlement_date=lte.%s&order=settlement_date.asc,created_at.asc&limit=%d", date, limit)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.DOCTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode doc_transfers: %w", err)
+	}
+	return rows, nil
+}
+
+// ClaimDOCTransfer moves a held transfer to processing. It reports false
+// when the transfer is no longer held, e.g. claimed by another instance.
+func (c *Client) ClaimDOCTransfer(ctx context.Context, transferID string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimDOCTransfer")
+	defer span.End()
+
+	n, err := c.doPatchCount(ctx, fmt.Sprintf("doc_transfers?id=eq.%s&status=eq.held", transferID), map[string]any{
+		"status":     "processing",
+		"updated_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SettleDOCTransfer runs the settle_doc_transfer RPC, which debits the
+// claimed transfer, consumes its hold and records entry in one database
+// transaction (or releases the hold and fails it when the account is no
+// longer active).
+func (c *Client) SettleDOCTransfer(ctx context.Context, transferID string, entry map[string]any) (string, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.SettleDOCTransfer")
+	defer span.End()
+
+	body, err := c.doRPC(ctx, "settle_doc_transfer", map[string]any{
+		"p_transfer_id":    transferID,
+		"p_transaction_id": entry["id"],
+		"p_description":    entry["description"],
+		"p_category":       entry["category"],
+		"p_date":           entry["date"],
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "transfer_not_claimed") {
+			return "", &domain.ErrInvalidState{Resource: "doc_transfer", Status: "not processing", Action: "settle"}
+		}
+		return "", err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decode settle_doc_transfer: %w", err)
+	}
+	return result.Status, nil
+}
+
+// ReleaseStaleDOCTransfers moves back to held the transfers left processing
+// since before since. Settlement is atomic, so such a transfer never moved
+// any money and can be claimed again.
+func (c *Client) ReleaseStaleDOCTransfers(ctx context.Context, since time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ReleaseStaleDOCTransfers")
+	defer span.End()
+
+	return c.doPatchCount(ctx, fmt.Sprintf("doc_transfers?status=eq.processing&updated_at=lt.%s",
+		url.QueryEscape(since.UTC().Format(time.RFC3339))), map[string]any{
+		"status":     "held",
+		"updated_at": time.Now().Format(time.RFC3339),
+	})
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 
 	"go.uber.org/zap"
 )
@@ -15,6 +18,9 @@ import (
  */
 
 func (c *Client) doPost(ctx context.Context, table string, data map[string]any) ([]byte, error) {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/rest/v1/%s", c.baseURL, table)
 	jsonBody, err := json.Marshal(data)
 	if err != nil {
@@ -37,14 +43,14 @@ func (c *Client) doPost(ctx context.Context, table string, data map[string]any)
 			zap.String("table", table),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, timeoutError(http.MethodPost, table, err)
 	}
 	defer resp.Body.Close()
 
 	body := make([]byte, 0)
 	body, err = readBody(resp)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(http.MethodPost, table, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -53,6 +59,9 @@ func (c *Client) doPost(ctx context.Context, table string, data map[string]any)
 			zap.Int("status", resp.StatusCode),
 			zap.String("body", string(body)),
 		)
+		if dup := uniqueViolation(table, resp.StatusCode, body); dup != nil {
+			return nil, dup
+		}
 		return nil, fmt.Errorf("supabase POST %s returned %d: %s", table, resp.StatusCode, string(body))
 	}
 
@@ -61,21 +70,43 @@ func (c *Client) doPost(ctx context.Context, table string, data map[string]any)
 }
 
 func (c *Client) doPatch(ctx context.Context, path string, data map[string]any) error {
+	_, err := c.patch(ctx, path, data, "return=minimal")
+	return err
+}
+
+// doPatchCount patches the rows matching path and returns how many were
+// updated, so filters can act as a compare-and-set.
+func (c *Client) doPatchCount(ctx context.Context, path string, data map[string]any) (int, error) {
+	body, err := c.patch(ctx, path, data, "return=representation")
+	if err != nil || len(body) == 0 {
+		return 0, err
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return 0, fmt.Errorf("decode patched rows: %w", err)
+	}
+	return len(rows), nil
+}
+
+func (c *Client) patch(ctx context.Context, path string, data map[string]any, prefer string) ([]byte, error) {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/rest/v1/%s", c.baseURL, path)
 	jsonBody, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(jsonBody))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("apikey", c.apiKey)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.serviceRoleKey))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Prefer", "return=minimal")
+	req.Header.Set("Prefer", prefer)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -83,25 +114,36 @@ func (c *Client) doPatch(ctx context.Context, path string, data map[string]any)
 			zap.String("path", path),
 			zap.Error(err),
 		)
-		return err
+		return nil, timeoutError(http.MethodPatch, path, err)
 	}
 	defer resp.Body.Close()
 
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, timeoutError(http.MethodPatch, path, err)
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := readBody(resp)
 		c.logger.Warn("supabase: PATCH non-2xx",
 			zap.String("path", path),
 			zap.Int("status", resp.StatusCode),
 			zap.String("body", string(body)),
 		)
-		return fmt.Errorf("supabase PATCH returned %d: %s", resp.StatusCode, string(body))
+		table, _, _ := strings.Cut(path, "?")
+		if dup := uniqueViolation(table, resp.StatusCode, body); dup != nil {
+			return nil, dup
+		}
+		return nil, fmt.Errorf("supabase PATCH returned %d: %s", resp.StatusCode, string(body))
 	}
 
 	c.logger.Debug("supabase: PATCH OK", zap.String("path", path))
-	return nil
+	return body, nil
 }
 
 func (c *Client) doDelete(ctx context.Context, path string) error {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/rest/v1/%s", c.baseURL, path)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
@@ -119,7 +161,7 @@ func (c *Client) doDelete(ctx context.Context, path string) error {
 			zap.String("path", path),
 			zap.Error(err),
 		)
-		return err
+		return timeoutError(http.MethodDelete, path, err)
 	}
 	defer resp.Body.Close()
 
@@ -137,6 +179,20 @@ func (c *Client) doDelete(ctx context.Context, path string) error {
 	return nil
 }
 
+// doDeleteCount deletes the rows matching path and returns how many were
+// removed.
+func (c *Client) doDeleteCount(ctx context.Context, path string) (int, error) {
+	body, _, err := c.send(ctx, http.MethodDelete, path, "return=representation")
+	if err != nil || body == nil {
+		return 0, err
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return 0, fmt.Errorf("decode deleted rows: %w", err)
+	}
+	return len(rows), nil
+}
+
 func readBody(resp *http.Response) ([]byte, error) {
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(resp.Body); err != nil {
@@ -148,6 +204,9 @@ func readBody(resp *http.Response) ([]byte, error) {
 // doPostAny é como doPost, mas aceita qualquer tipo (slice, struct, etc).
 // Retorna o body com Prefer: return=representation.
 func (c *Client) doPostAny(ctx context.Context, table string, data any) ([]byte, error) {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/rest/v1/%s", c.baseURL, table)
 	jsonBody, err := json.Marshal(data)
 	if err != nil {
@@ -170,13 +229,13 @@ func (c *Client) doPostAny(ctx context.Context, table string, data any) ([]byte,
 			zap.String("table", table),
 			zap.Error(err),
 		)
-		return nil, err
+		return nil, timeoutError(http.MethodPost, table, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := readBody(resp)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(http.MethodPost, table, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -185,6 +244,9 @@ func (c *Client) doPostAny(ctx context.Context, table string, data any) ([]byte,
 			zap.Int("status", resp.StatusCode),
 			zap.String("body", string(body)),
 		)
+		if dup := uniqueViolation(table, resp.StatusCode, body); dup != nil {
+			return nil, dup
+		}
 		return nil, fmt.Errorf("supabase POST %s returned %d: %s", table, resp.StatusCode, string(body))
 	}
 
@@ -192,6 +254,40 @@ func (c *Client) doPostAny(ctx context.Context, table string, data any) ([]byte,
 	return body, nil
 }
 
+// postgrestError is the body PostgREST answers failed requests with.
+type postgrestError struct {
+	Code    string `json:"code"` // Postgres SQLSTATE, e.g. 23505
+	Message string `json:"message"`
+	Details string `json:"details"` // e.g. Key (customer_id, key_value)=(...) already exists.
+}
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint violation.
+const pgUniqueViolation = "23505"
+
+// uniqueViolation returns a domain.ErrDuplicate when a failed write broke a
+// unique constraint, nil otherwise. PostgREST answers 409 for foreign key
+// violations too, so the SQLSTATE decides; a bare 409 without a readable
+// body is still taken as a duplicate. The key names the table and the
+// conflicting columns, never their values.
+func uniqueViolation(table string, status int, body []byte) *domain.ErrDuplicate {
+	var pgErr postgrestError
+	if err := json.Unmarshal(body, &pgErr); err != nil || pgErr.Code == "" {
+		if status == http.StatusConflict {
+			return &domain.ErrDuplicate{Key: table}
+		}
+		return nil
+	}
+	if pgErr.Code != pgUniqueViolation {
+		return nil
+	}
+	if _, rest, ok := strings.Cut(pgErr.Details, "Key ("); ok {
+		if columns, _, ok := strings.Cut(rest, ")="); ok {
+			return &domain.ErrDuplicate{Key: fmt.Sprintf("%s (%s)", table, columns)}
+		}
+	}
+	return &domain.ErrDuplicate{Key: table}
+}
+
 // extractIDFromResponse extrai o campo "id" do primeiro elemento de um array JSON
 // retornado pelo PostgREST com Prefer: return=representation.
 func extractIDFromResponse(body []byte) (string, error) {
@@ -1,9 +1,11 @@
 package supabase
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -69,7 +71,7 @@ type ImprovementStatRow struct {
 // GetChatMetrics chama a função RPC get_chat_metrics() no Supabase.
 func (c *Client) GetChatMetrics(ctx context.Context) (*ChatMetricsRow, error) {
 	// PostgREST RPC: POST /rest/v1/rpc/get_chat_metrics
-	body, err := c.doRPC(ctx, "get_chat_metrics")
+	body, err := c.doRPC(ctx, "get_chat_metrics", nil)
 	if err != nil {
 		return nil, fmt.Errorf("get chat metrics: %w", err)
 	}
@@ -82,10 +84,23 @@ func (c *Client) GetChatMetrics(ctx context.Context) (*ChatMetricsRow, error) {
 }
 
 // doRPC chama uma função PostgreSQL via PostgREST RPC (POST /rest/v1/rpc/{function}).
-func (c *Client) doRPC(ctx context.Context, functionName string) ([]byte, error) {
+// params (opcional) vira o corpo JSON com os argumentos nomeados da função.
+func (c *Client) doRPC(ctx context.Context, functionName string, params map[string]any) ([]byte, error) {
+	ctx, cancel := c.withCallTimeout(ctx)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/rest/v1/rpc/%s", c.baseURL, functionName)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	var reqBody io.Reader
+	if params != nil {
+		jsonBody, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("rpc %s: marshal params: %w", functionName, err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -96,13 +111,13 @@ func (c *Client) doRPC(ctx context.Context, functionName string) ([]byte, error)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("rpc %s: request failed: %w", functionName, err)
+		return nil, timeoutError("rpc", functionName, fmt.Errorf("rpc %s: request failed: %w", functionName, err))
 	}
 	defer resp.Body.Close()
 
 	body, err := readBody(resp)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError("rpc", functionName, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
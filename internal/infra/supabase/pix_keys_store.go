@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 /*
- * PIX Keys store — list, lookup, create, delete
+ * PIX Keys store — list, lookup, create, delete, ownership codes
  */
 
 func (c *Client) ListPixKeys(ctx context.Context, customerID string) ([]domain.PixKey, error) {
@@ -71,6 +75,28 @@ func (c *Client) LookupPixKeyByValue(ctx context.Context, keyValue string) (*dom
 	return &rows[0], nil
 }
 
+// CountPixKeys returns how many active PIX keys the customer has.
+func (c *Client) CountPixKeys(ctx context.Context, customerID string) (int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.CountPixKeys")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_keys?customer_id=eq.%s&status=eq.active&select=id", customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return 0, fmt.Errorf("decode pix_keys count: %w", err)
+		}
+	}
+	return len(rows), nil
+}
+
 func (c *Client) CreatePixKey(ctx context.Context, key *domain.PixKey) (*domain.PixKey, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.CreatePixKey")
 	defer span.End()
@@ -99,13 +125,103 @@ func (c *Client) CreatePixKey(ctx context.Context, key *domain.PixKey) (*domain.
 	return &rows[0], nil
 }
 
+// GetPixKey returns a key of the customer regardless of its status.
+func (c *Client) GetPixKey(ctx context.Context, customerID, keyID string) (*domain.PixKey, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetPixKey")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_keys?id=eq.%s&customer_id=eq.%s&limit=1", keyID, customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.PixKey
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode pix_key: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyID}
+	}
+	return &rows[0], nil
+}
+
+// DeletePixKey soft-deletes the key: the row is kept (receipts reference it)
+// but it no longer shows up in lists or lookups.
 func (c *Client) DeletePixKey(ctx context.Context, customerID, keyID string) error {
 	ctx, span := tracer.Start(ctx, "Supabase.DeletePixKey")
 	defer span.End()
 
 	path := fmt.Sprintf("pix_keys?id=eq.%s&customer_id=eq.%s", keyID, customerID)
-	if err := c.doDelete(ctx, path); err != nil {
-		return err
+	return c.doPatch(ctx, path, map[string]any{
+		"status":     "deleted",
+		"deleted_at": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// ReactivatePixKey restores a soft-deleted key.
+func (c *Client) ReactivatePixKey(ctx context.Context, customerID, keyID string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.ReactivatePixKey")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_keys?id=eq.%s&customer_id=eq.%s&status=eq.deleted", keyID, customerID)
+	return c.doPatch(ctx, path, map[string]any{
+		"status":     "active",
+		"deleted_at": nil,
+	})
+}
+
+/* PIX key ownership verification codes */
+
+func (c *Client) StorePixKeyVerificationCode(ctx context.Context, code *domain.PixKeyVerificationCode) error {
+	ctx, span := tracer.Start(ctx, "Supabase.StorePixKeyVerificationCode")
+	defer span.End()
+
+	data := map[string]any{
+		"id":          uuid.New().String(),
+		"customer_id": code.CustomerID,
+		"key_type":    code.KeyType,
+		"key_value":   code.KeyValue,
+		"code":        code.Code,
+		"expires_at":  code.ExpiresAt.Format(time.RFC3339),
+		"used":        false,
 	}
-	return nil
+
+	_, err := c.doPost(ctx, "pix_key_verification_codes", data)
+	return err
+}
+
+func (c *Client) GetValidPixKeyVerificationCode(ctx context.Context, customerID, keyType, keyValue, code string) (*domain.PixKeyVerificationCode, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetValidPixKeyVerificationCode")
+	defer span.End()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	path := fmt.Sprintf("pix_key_verification_codes?customer_id=eq.%s&key_type=eq.%s&key_value=eq.%s&code=eq.%s&used=eq.false&expires_at=gt.%s&order=created_at.desc&limit=1",
+		customerID, keyType, url.QueryEscape(keyValue), code, now)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	var rows []domain.PixKeyVerificationCode
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode pix_key_verification_codes: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) MarkPixKeyVerificationCodeUsed(ctx context.Context, codeID string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.MarkPixKeyVerificationCodeUsed")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_key_verification_codes?id=eq.%s", codeID)
+	return c.doPatch(ctx, path, map[string]any{"used": true})
 }
@@ -110,19 +110,25 @@ func (c *Client) GetPixReceiptByTransferID(ctx context.Context, transferID strin
 	return &rows[0], nil
 }
 
-func (c *Client) ListPixReceipts(ctx context.Context, customerID string) ([]domain.PixReceipt, error) {
+// ListPixReceipts returns one page of a customer's receipts, newest first.
+// The id tie-break keeps pages stable when receipts share a created_at.
+func (c *Client) ListPixReceipts(ctx context.Context, customerID string, page, pageSize int) ([]domain.PixReceipt, int, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.ListPixReceipts")
 	defer span.End()
 
-	path := fmt.Sprintf("pix_receipts?customer_id=eq.%s&order=created_at.desc&limit=100", customerID)
-	body, err := c.doRequest(ctx, http.MethodGet, path)
+	offset := (page - 1) * pageSize
+	path := fmt.Sprintf("pix_receipts?customer_id=eq.%s&order=created_at.desc,id.desc&limit=%d&offset=%d",
+		customerID, pageSize, offset)
+	body, total, err := c.doRequestWithCount(ctx, path)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var rows []domain.PixReceipt
-	if err := json.Unmarshal(body, &rows); err != nil {
-		return nil, fmt.Errorf("decode pix_receipts: %w", err)
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode pix_receipts: %w", err)
+		}
 	}
-	return rows, nil
+	return rows, total, nil
 }
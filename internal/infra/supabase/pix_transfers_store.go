@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -14,7 +15,7 @@ import (
 )
 
 /*
- * PIX Transfers store — create, list, get, update status
+ * PIX Transfers store — create, list, get, update status, scheduled
  */
 
 func (c *Client) CreatePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransfer, error) {
@@ -78,6 +79,38 @@ func (c *Client) ListPixTransfers(ctx context.Context, customerID string, page,
 	return rows, nil
 }
 
+func (c *Client) ListPixTransfersFiltered(ctx context.Context, customerID string, filter *domain.PixTransferFilter) ([]domain.PixTransfer, int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListPixTransfersFiltered")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_transfers?source_customer_id=eq.%s", customerID)
+	if filter.Status != "" {
+		path += fmt.Sprintf("&status=eq.%s", filter.Status)
+	}
+	if filter.From != "" {
+		path += fmt.Sprintf("&created_at=gte.%s", filter.From)
+	}
+	if filter.To != "" {
+		// To is inclusive: keep everything created before the next day.
+		if to, err := time.Parse("2006-01-02", filter.To); err == nil {
+			path += fmt.Sprintf("&created_at=lt.%s", to.AddDate(0, 0, 1).Format("2006-01-02"))
+		}
+	}
+	path += fmt.Sprintf("&order=created_at.desc,id.desc&limit=%d&offset=%d", filter.PageSize, (filter.Page-1)*filter.PageSize)
+	body, total, err := c.doRequestWithCount(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []domain.PixTransfer
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode pix_transfers: %w", err)
+		}
+	}
+	return rows, total, nil
+}
+
 func (c *Client) GetPixTransfer(ctx context.Context, customerID, transferID string) (*domain.PixTransfer, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.GetPixTransfer")
 	defer span.End()
@@ -98,6 +131,29 @@ func (c *Client) GetPixTransfer(ctx context.Context, customerID, transferID stri
 	return &rows[0], nil
 }
 
+func (c *Client) GetPixTransferByIdempotencyKey(ctx context.Context, customerID, key string) (*domain.PixTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetPixTransferByIdempotencyKey")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_transfers?source_customer_id=eq.%s&idempotency_key=eq.%s&limit=1", customerID, url.QueryEscape(key))
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, &domain.ErrNotFound{Resource: "pix_transfer", ID: key}
+	}
+
+	var rows []domain.PixTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode pix_transfer: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "pix_transfer", ID: key}
+	}
+	return &rows[0], nil
+}
+
 func (c *Client) UpdatePixTransferStatus(ctx context.Context, transferID, status string) error {
 	ctx, span := tracer.Start(ctx, "Supabase.UpdatePixTransferStatus")
 	defer span.End()
@@ -107,3 +163,101 @@ func (c *Client) UpdatePixTransferStatus(ctx context.Context, transferID, status
 		"updated_at": time.Now().Format(time.RFC3339),
 	})
 }
+
+// ListDuePixTransfers returns scheduled transfers whose scheduled_for is at
+// or before the given time, oldest first.
+func (c *Client) ListDuePixTransfers(ctx context.Context, before time.Time, limit int) ([]domain.PixTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListDuePixTransfers")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_transfers?status=eq.scheduled&scheduled_for=lte.%s&order=scheduled_for.asc&limit=%d",
+		url.QueryEscape(before.UTC().Format(time.RFC3339)), limit)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.PixTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode pix_transfers: %w", err)
+	}
+	return rows, nil
+}
+
+// ClaimScheduledPixTransfer moves a scheduled transfer to processing. It
+// reports false when the transfer is no longer scheduled, e.g. cancelled or
+// claimed by another instance.
+func (c *Client) ClaimScheduledPixTransfer(ctx context.Context, transferID string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimScheduledPixTransfer")
+	defer span.End()
+
+	n, err := c.doPatchCount(ctx, fmt.Sprintf("pix_transfers?id=eq.%s&status=eq.scheduled", transferID), map[string]any{
+		"status":     "processing",
+		"updated_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// FailStalePixTransfers fails the transfers left processing since before
+// since and returns them.
+func (c *Client) FailStalePixTransfers(ctx context.Context, since time.Time, reason string) ([]domain.PixTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.FailStalePixTransfers")
+	defer span.End()
+
+	body, err := c.patch(ctx, fmt.Sprintf("pix_transfers?status=eq.processing&updated_at=lt.%s",
+		url.QueryEscape(since.UTC().Format(time.RFC3339))), map[string]any{
+		"status":         "failed",
+		"failure_reason": reason,
+		"updated_at":     time.Now().Format(time.RFC3339),
+	}, "return=representation")
+	if err != nil || len(body) == 0 {
+		return nil, err
+	}
+
+	var rows []domain.PixTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode pix_transfers: %w", err)
+	}
+	return rows, nil
+}
+
+/* Confirmation tokens */
+
+func (c *Client) StorePixConfirmation(ctx context.Context, token *domain.PixConfirmationToken) error {
+	ctx, span := tracer.Start(ctx, "Supabase.StorePixConfirmation")
+	defer span.End()
+
+	_, err := c.doPost(ctx, "pix_confirmations", map[string]any{
+		"token":       token.Token,
+		"customer_id": token.CustomerID,
+		"fingerprint": token.Fingerprint,
+		"status":      token.Status,
+		"expires_at":  token.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+	return err
+}
+
+func (c *Client) ClaimPixConfirmation(ctx context.Context, token, customerID, fingerprint string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ClaimPixConfirmation")
+	defer span.End()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	path := fmt.Sprintf("pix_confirmations?token=eq.%s&customer_id=eq.%s&fingerprint=eq.%s&status=eq.issued&expires_at=gt.%s",
+		url.QueryEscape(token), customerID, url.QueryEscape(fingerprint), now)
+	n, err := c.doPatchCount(ctx, path, map[string]any{"status": "claimed"})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (c *Client) SetPixConfirmationStatus(ctx context.Context, token, status string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.SetPixConfirmationStatus")
+	defer span.End()
+
+	path := fmt.Sprintf("pix_confirmations?token=eq.%s&status=eq.claimed", url.QueryEscape(token))
+	return c.doPatch(ctx, path, map[string]any{"status": status})
+}
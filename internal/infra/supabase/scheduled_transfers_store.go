@@ -11,7 +11,7 @@ import (
 )
 
 /*
- * Scheduled Transfers store — create, list, get, update status
+ * Scheduled Transfers store — create, list, get, update status, advance
  */
 
 func (c *Client) CreateScheduledTransfer(ctx context.Context, customerID string, req *domain.ScheduledTransferRequest) (*domain.ScheduledTransfer, error) {
@@ -29,6 +29,8 @@ func (c *Client) CreateScheduledTransfer(ctx context.Context, customerID string,
 		"destination_account_type": req.DestinationAcctType,
 		"destination_name":         req.DestinationName,
 		"destination_document":     req.DestinationDocument,
+		"destination_key_type":     req.DestinationKeyType,
+		"destination_key_value":    req.DestinationKeyValue,
 		"amount":                   req.Amount,
 		"description":              req.Description,
 		"schedule_type":            req.ScheduleType,
@@ -75,6 +77,29 @@ func (c *Client) ListScheduledTransfers(ctx context.Context, customerID string)
 	return rows, nil
 }
 
+func (c *Client) ListScheduledTransfersFiltered(ctx context.Context, customerID, status string, page, pageSize int) ([]domain.ScheduledTransfer, int, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListScheduledTransfersFiltered")
+	defer span.End()
+
+	path := fmt.Sprintf("scheduled_transfers?source_customer_id=eq.%s", customerID)
+	if status != "" {
+		path += fmt.Sprintf("&status=eq.%s", status)
+	}
+	path += fmt.Sprintf("&order=scheduled_date.asc&limit=%d&offset=%d", pageSize, (page-1)*pageSize)
+	body, total, err := c.doRequestWithCount(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []domain.ScheduledTransfer
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, 0, fmt.Errorf("decode scheduled_transfers: %w", err)
+		}
+	}
+	return rows, total, nil
+}
+
 func (c *Client) GetScheduledTransfer(ctx context.Context, customerID, transferID string) (*domain.ScheduledTransfer, error) {
 	ctx, span := tracer.Start(ctx, "Supabase.GetScheduledTransfer")
 	defer span.End()
@@ -107,3 +132,50 @@ func (c *Client) UpdateScheduledTransferStatus(ctx context.Context, transferID,
 		"updated_at": time.Now().Format(time.RFC3339),
 	})
 }
+
+// ListDueScheduledTransfers returns active schedules whose next execution
+// date is on or before date (YYYY-MM-DD), oldest first.
+func (c *Client) ListDueScheduledTransfers(ctx context.Context, date string, limit int) ([]domain.ScheduledTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListDueScheduledTransfers")
+	defer span.End()
+
+	path := fmt.Sprintf("scheduled_transfers?status=eq.scheduled&next_execution_date=lte.%s&order=next_execution_date.asc&limit=%d", date, limit)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.ScheduledTransfer
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode scheduled_transfers: %w", err)
+	}
+	return rows, nil
+}
+
+// AdvanceScheduledTransfer saves the schedule progress of a transfer. The
+// update is conditional on next_execution_date still being fromDate, so an
+// occurrence is run or skipped once even with several instances.
+func (c *Client) AdvanceScheduledTransfer(ctx context.Context, transfer *domain.ScheduledTransfer, fromDate string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.AdvanceScheduledTransfer")
+	defer span.End()
+
+	row := map[string]any{
+		"next_execution_date": nil,
+		"recurrence_count":    transfer.RecurrenceCount,
+		"skipped_dates":       transfer.SkippedDates,
+		"status":              transfer.Status,
+		"updated_at":          time.Now().Format(time.RFC3339),
+	}
+	if transfer.NextExecutionDate != "" {
+		row["next_execution_date"] = transfer.NextExecutionDate
+	}
+	if transfer.LastExecutedAt != nil {
+		row["last_executed_at"] = transfer.LastExecutedAt.Format(time.RFC3339)
+	}
+
+	n, err := c.doPatchCount(ctx, fmt.Sprintf("scheduled_transfers?id=eq.%s&next_execution_date=eq.%s", transfer.ID, fromDate), row)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
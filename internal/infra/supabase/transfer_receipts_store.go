@@ -0,0 +1,73 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * Transfer Receipts store — receipts of internal, TED and DOC transfers
+ */
+
+func (c *Client) SaveTransferReceipt(ctx context.Context, receipt *domain.TransferReceipt) (*domain.TransferReceipt, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.SaveTransferReceipt")
+	defer span.End()
+
+	body, err := c.doPost(ctx, "transfer_receipts", map[string]any{
+		"id":                 receipt.ID,
+		"transfer_id":        receipt.TransferID,
+		"transfer_type":      receipt.TransferType,
+		"customer_id":        receipt.CustomerID,
+		"amount":             receipt.Amount,
+		"description":        receipt.Description,
+		"sender_name":        receipt.SenderName,
+		"sender_document":    receipt.SenderDocument,
+		"sender_bank":        receipt.SenderBank,
+		"sender_branch":      receipt.SenderBranch,
+		"sender_account":     receipt.SenderAccount,
+		"recipient_name":     receipt.RecipientName,
+		"recipient_document": receipt.RecipientDocument,
+		"recipient_bank":     receipt.RecipientBank,
+		"recipient_branch":   receipt.RecipientBranch,
+		"recipient_account":  receipt.RecipientAccount,
+		"status":             receipt.Status,
+		"executed_at":        receipt.ExecutedAt,
+		"created_at":         receipt.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.TransferReceipt
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("decode transfer_receipt: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no result returned from transfer_receipts insert")
+	}
+	return &results[0], nil
+}
+
+func (c *Client) GetTransferReceiptByTransferID(ctx context.Context, transferID string) (*domain.TransferReceipt, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetTransferReceiptByTransferID")
+	defer span.End()
+
+	path := fmt.Sprintf("transfer_receipts?transfer_id=eq.%s&limit=1", transferID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.TransferReceipt
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("decode transfer_receipt by transfer_id: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "transfer_receipt", ID: transferID}
+	}
+	return &rows[0], nil
+}
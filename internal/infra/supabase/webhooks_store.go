@@ -0,0 +1,164 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * Webhooks store — registration, lookup, revocation and delivery logs
+ */
+
+func (c *Client) CreateWebhook(ctx context.Context, hook *domain.Webhook) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.CreateWebhook")
+	defer span.End()
+
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+	row := map[string]any{
+		"customer_id": hook.CustomerID,
+		"url":         hook.URL,
+		"secret":      hook.Secret,
+		"events":      events,
+		"status":      "active",
+	}
+
+	body, err := c.doPost(ctx, "webhooks", row)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Webhook
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("decode webhook: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no result from webhooks insert")
+	}
+	return &results[0], nil
+}
+
+// ListWebhooks returns all of the customer's webhooks, including their secrets.
+func (c *Client) ListWebhooks(ctx context.Context, customerID string) ([]domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListWebhooks")
+	defer span.End()
+
+	path := fmt.Sprintf("webhooks?customer_id=eq.%s&order=created_at.desc", customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Webhook
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode webhooks: %w", err)
+		}
+	}
+	return rows, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, customerID, webhookID string) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetWebhook")
+	defer span.End()
+
+	path := fmt.Sprintf("webhooks?id=eq.%s&customer_id=eq.%s&limit=1", webhookID, customerID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.Webhook
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode webhook: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "webhook", ID: webhookID}
+	}
+	return &rows[0], nil
+}
+
+func (c *Client) RevokeWebhook(ctx context.Context, customerID, webhookID string) error {
+	ctx, span := tracer.Start(ctx, "Supabase.RevokeWebhook")
+	defer span.End()
+
+	path := fmt.Sprintf("webhooks?id=eq.%s&customer_id=eq.%s", webhookID, customerID)
+	return c.doPatch(ctx, path, map[string]any{
+		"status":     "revoked",
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+/* Delivery logs */
+
+func (c *Client) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	ctx, span := tracer.Start(ctx, "Supabase.SaveWebhookDelivery")
+	defer span.End()
+
+	row := map[string]any{
+		"id":            delivery.ID,
+		"webhook_id":    delivery.WebhookID,
+		"customer_id":   delivery.CustomerID,
+		"event_id":      delivery.EventID,
+		"event_type":    delivery.EventType,
+		"payload":       delivery.Payload,
+		"status":        delivery.Status,
+		"response_code": delivery.ResponseCode,
+		"attempts":      delivery.Attempts,
+		"error":         delivery.Error,
+		"created_at":    delivery.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	_, err := c.doPost(ctx, "webhook_deliveries", row)
+	return err
+}
+
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.ListWebhookDeliveries")
+	defer span.End()
+
+	path := fmt.Sprintf("webhook_deliveries?webhook_id=eq.%s&order=created_at.desc&limit=%d", webhookID, limit)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.WebhookDelivery
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode webhook_deliveries: %w", err)
+		}
+	}
+	return rows, nil
+}
+
+func (c *Client) GetWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "Supabase.GetWebhookDelivery")
+	defer span.End()
+
+	path := fmt.Sprintf("webhook_deliveries?id=eq.%s&webhook_id=eq.%s&limit=1", deliveryID, webhookID)
+	body, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []domain.WebhookDelivery
+	if body != nil {
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("decode webhook_delivery: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, &domain.ErrNotFound{Resource: "webhook_delivery", ID: deliveryID}
+	}
+	return &rows[0], nil
+}
@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 )
@@ -12,5 +13,50 @@ type AccountStore interface {
 	GetAccount(ctx context.Context, customerID, accountID string) (*domain.Account, error)
 	GetPrimaryAccount(ctx context.Context, customerID string) (*domain.Account, error)
 	UpdateAccountBalance(ctx context.Context, customerID string, delta float64) (*domain.Account, error)
+	// SetAccountBalance sets the balance and the available balance of the
+	// primary account to balance.
+	SetAccountBalance(ctx context.Context, customerID string, balance float64) (*domain.Account, error)
+	// AdjustAvailableBalance moves only the available balance of the
+	// primary account: a negative delta holds funds, a positive one
+	// releases them.
+	AdjustAvailableBalance(ctx context.Context, customerID string, delta float64) (*domain.Account, error)
+	// TransferBetweenAccounts atomically debits one account of the customer
+	// and credits another, returning both balances after the move.
+	TransferBetweenAccounts(ctx context.Context, customerID, fromAccountID, toAccountID string, amount float64) (fromBalance, toBalance float64, err error)
 	UpdateAccountCreditLimit(ctx context.Context, customerID string, newLimit float64) (*domain.Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, customerID, accountID string, limit float64) (*domain.Account, error)
+	// ListActiveCustomerIDs returns up to limit customers with an active
+	// account, ordered by ID and after afterID ("" for the first page).
+	ListActiveCustomerIDs(ctx context.Context, afterID string, limit int) ([]string, error)
+}
+
+// TransferReceiptStore handles receipts of non-PIX transfers.
+type TransferReceiptStore interface {
+	SaveTransferReceipt(ctx context.Context, receipt *domain.TransferReceipt) (*domain.TransferReceipt, error)
+	GetTransferReceiptByTransferID(ctx context.Context, transferID string) (*domain.TransferReceipt, error)
+}
+
+// DOCTransferStore handles DOC transfers awaiting or past settlement.
+type DOCTransferStore interface {
+	// CreateDOCTransfer returns ErrDuplicate when the customer already has a
+	// transfer with its idempotency key.
+	CreateDOCTransfer(ctx context.Context, transfer *domain.DOCTransfer) (*domain.DOCTransfer, error)
+	// GetDOCTransferByIdempotencyKey returns ErrNotFound when the customer has
+	// no transfer created with key.
+	GetDOCTransferByIdempotencyKey(ctx context.Context, customerID, key string) (*domain.DOCTransfer, error)
+	// ListDueDOCTransfers returns up to limit held transfers settling on or
+	// before date (YYYY-MM-DD).
+	ListDueDOCTransfers(ctx context.Context, date string, limit int) ([]domain.DOCTransfer, error)
+	// ClaimDOCTransfer moves a held transfer to processing, reporting false
+	// when it is no longer held (e.g. claimed by another instance).
+	ClaimDOCTransfer(ctx context.Context, transferID string) (bool, error)
+	// SettleDOCTransfer settles a claimed transfer in one database
+	// transaction: the ledger balance is debited, the hold consumed and entry
+	// recorded as its statement entry. When the account can no longer be
+	// debited the hold is released and the transfer fails instead. It
+	// returns the final status (completed or failed).
+	SettleDOCTransfer(ctx context.Context, transferID string, entry map[string]any) (string, error)
+	// ReleaseStaleDOCTransfers puts back to held the transfers claimed before
+	// since and never settled, returning how many.
+	ReleaseStaleDOCTransfers(ctx context.Context, since time.Time) (int, error)
 }
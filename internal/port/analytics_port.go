@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 )
@@ -11,26 +12,73 @@ import (
 type AnalyticsStore interface {
 	// Spending Analytics
 	GetSpendingSummary(ctx context.Context, customerID, periodType string) (*domain.SpendingSummary, error)
+	FindSpendingSummary(ctx context.Context, customerID, periodStart, periodEnd string) (*domain.SpendingSummary, error)
+	UpsertSpendingSummary(ctx context.Context, summary *domain.SpendingSummary) error
+	DeleteSpendingSummaries(ctx context.Context, customerID, since string) error
+	// InsertSpendingDigest records a digest sent to the customer. It returns
+	// *domain.ErrDuplicate when the digest of that period was already stored.
+	InsertSpendingDigest(ctx context.Context, digest *domain.SpendingDigest) error
+	// SpendingDigestExists reports whether the customer's digest of the
+	// period starting on periodStart (YYYY-MM-DD) is already stored.
+	SpendingDigestExists(ctx context.Context, customerID, period, periodStart string) (bool, error)
 	ListBudgets(ctx context.Context, customerID string) ([]domain.SpendingBudget, error)
 	CreateBudget(ctx context.Context, budget *domain.SpendingBudget) (*domain.SpendingBudget, error)
 	UpdateBudget(ctx context.Context, budget *domain.SpendingBudget) (*domain.SpendingBudget, error)
 
+	// Category Rules
+	ListCategoryRules(ctx context.Context, customerID string) ([]domain.CategoryRule, error)
+	// UpsertCategoryRule creates the rule or, when the customer already has
+	// one for the pattern, changes its category.
+	UpsertCategoryRule(ctx context.Context, rule *domain.CategoryRule) (*domain.CategoryRule, error)
+	// RecategorizeTransactions sets the category of the customer's statement
+	// entries with the given IDs and returns how many were updated.
+	RecategorizeTransactions(ctx context.Context, customerID string, txIDs []string, category string) (int, error)
+
 	// Favorites
 	ListFavorites(ctx context.Context, customerID string) ([]domain.Favorite, error)
 	CreateFavorite(ctx context.Context, fav *domain.Favorite) (*domain.Favorite, error)
+	FindFavorite(ctx context.Context, fav *domain.Favorite) (*domain.Favorite, error)
 	DeleteFavorite(ctx context.Context, customerID, favoriteID string) error
 
 	// Transaction Limits
 	ListTransactionLimits(ctx context.Context, customerID string) ([]domain.TransactionLimit, error)
 	GetTransactionLimit(ctx context.Context, customerID, txType string) (*domain.TransactionLimit, error)
 	UpdateTransactionLimit(ctx context.Context, limit *domain.TransactionLimit) (*domain.TransactionLimit, error)
+	// CreateTransactionLimit stores a limit for a customer without one for
+	// the type, returning ErrDuplicate when it already exists.
+	CreateTransactionLimit(ctx context.Context, limit *domain.TransactionLimit) (*domain.TransactionLimit, error)
+	AddTransactionLimitUsage(ctx context.Context, customerID, txType string, daily, monthly float64) error
 
 	// Notifications
-	ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, error)
+	ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, int, error)
+	GetNotification(ctx context.Context, customerID, notifID string) (*domain.Notification, error)
 	MarkNotificationRead(ctx context.Context, notifID string) error
+	// MarkNotificationsRead marks the customer's unread notifications among
+	// ids read and returns how many it changed. Ids of other customers are
+	// left untouched.
+	MarkNotificationsRead(ctx context.Context, customerID string, ids []string) (int, error)
+	InsertNotification(ctx context.Context, notif *domain.Notification) (*domain.Notification, error)
+	GetNotificationPreferences(ctx context.Context, customerID string) (domain.NotificationPreferences, error)
+	SaveNotificationPreferences(ctx context.Context, customerID string, prefs domain.NotificationPreferences) error
 
 	// Transaction History
 	GetTransactionSummary(ctx context.Context, customerID string) (*domain.TransactionSummary, error)
 	ListTransactions(ctx context.Context, customerID string, from, to string) ([]domain.Transaction, error)
+	SearchTransactions(ctx context.Context, customerID string, filter *domain.TransactionSearchFilter) ([]domain.Transaction, error)
 	InsertTransaction(ctx context.Context, data map[string]any) error
+	UpdateTransactionStatus(ctx context.Context, txID, status string) error
+	// UpdateTransactionAnnotation replaces the note and tags of one of the
+	// customer's transactions and returns it, or ErrNotFound.
+	UpdateTransactionAnnotation(ctx context.Context, customerID, txID string, annotation *domain.TransactionAnnotation) (*domain.Transaction, error)
+}
+
+// ActivityStore lists the sources of the activity feed by keyset, most
+// recent first (timestamp, then id, descending). Each method returns at most
+// limit rows older than before, plus the rows at exactly before whose id
+// sorts below beforeID; an empty beforeID excludes every row at before.
+type ActivityStore interface {
+	ListTransactionsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.Transaction, error)
+	ListPixReceiptsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.PixReceipt, error)
+	ListBillPaymentsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.BillPayment, error)
+	ListNotificationsBefore(ctx context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.Notification, error)
 }
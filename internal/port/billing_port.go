@@ -7,11 +7,25 @@ import (
 )
 
 // BillingStore handles bill payment and debit purchase data operations.
+// List methods return one page and the total number of matching rows.
 type BillingStore interface {
 	CreateBillPayment(ctx context.Context, customerID string, req *domain.BillPaymentRequest, validation *domain.BarcodeValidationResponse) (*domain.BillPayment, error)
-	ListBillPayments(ctx context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, error)
+	ListBillPayments(ctx context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, int, error)
 	GetBillPayment(ctx context.Context, customerID, billID string) (*domain.BillPayment, error)
 	UpdateBillPaymentStatus(ctx context.Context, billID, status string) error
-	ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) ([]domain.DebitPurchase, error)
+	// ClaimBillPaymentCancel moves an unpaid bill to cancelled and reports
+	// false when it no longer was unpaid, so a bill is refunded once.
+	ClaimBillPaymentCancel(ctx context.Context, billID string) (bool, error)
+	ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) ([]domain.DebitPurchase, int, error)
 	CreateDebitPurchase(ctx context.Context, customerID string, req *domain.DebitPurchaseRequest) (*domain.DebitPurchase, error)
+	GetDebitPurchase(ctx context.Context, customerID, purchaseID string) (*domain.DebitPurchase, error)
+	// ClaimDebitPurchaseRefund moves a refundable purchase to refunded and
+	// reports false when it no longer was, so a purchase is refunded once.
+	ClaimDebitPurchaseRefund(ctx context.Context, purchaseID string) (bool, error)
+	UpdateDebitPurchaseStatus(ctx context.Context, purchaseID, status string) error
+}
+
+// BarcodeDecoder reads the barcode or digitable line of a bill from a photo.
+type BarcodeDecoder interface {
+	DecodeBarcode(ctx context.Context, image []byte) (string, error)
 }
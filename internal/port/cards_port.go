@@ -19,7 +19,7 @@ type CreditCardStore interface {
 
 // CreditCardTransactionStore handles credit card transaction data operations.
 type CreditCardTransactionStore interface {
-	ListCreditCardTransactions(ctx context.Context, customerID, cardID string, page, pageSize int) ([]domain.CreditCardTransaction, error)
+	ListCreditCardTransactions(ctx context.Context, customerID, cardID string, page, pageSize int) ([]domain.CreditCardTransaction, int, error)
 	InsertCreditCardTransaction(ctx context.Context, data map[string]any) error
 }
 
@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+// DevToolsStore handles data operations only exposed through the dev tools.
+type DevToolsStore interface {
+	// ClearCustomerData deletes the customer's statement, PIX, scheduled
+	// transfer, bill, debit and credit card rows and returns how many rows
+	// were removed per table.
+	ClearCustomerData(ctx context.Context, customerID string) (map[string]int, error)
+	// GetDevBalanceAdjustment returns the adjustment made with an
+	// idempotency key, or nil when the key was not used yet.
+	GetDevBalanceAdjustment(ctx context.Context, idempotencyKey string) (*domain.DevBalanceAdjustment, error)
+	// SaveDevBalanceAdjustment claims the idempotency key before the
+	// balance moves. It returns *domain.ErrDuplicate when the key was
+	// already used.
+	SaveDevBalanceAdjustment(ctx context.Context, adj *domain.DevBalanceAdjustment) error
+	// SetDevBalanceAdjustmentResult records the balance an adjustment left.
+	SetDevBalanceAdjustmentResult(ctx context.Context, idempotencyKey string, newBalance float64) error
+	// DeleteDevBalanceAdjustment releases the key of an adjustment that
+	// was not applied.
+	DeleteDevBalanceAdjustment(ctx context.Context, idempotencyKey string) error
+}
@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 )
@@ -12,15 +13,46 @@ type PixKeyStore interface {
 	LookupPixKey(ctx context.Context, keyType, keyValue string) (*domain.PixKey, error)
 	LookupPixKeyByValue(ctx context.Context, keyValue string) (*domain.PixKey, error)
 	CreatePixKey(ctx context.Context, key *domain.PixKey) (*domain.PixKey, error)
+	CountPixKeys(ctx context.Context, customerID string) (int, error)
+	GetPixKey(ctx context.Context, customerID, keyID string) (*domain.PixKey, error)
 	DeletePixKey(ctx context.Context, customerID, keyID string) error
+	ReactivatePixKey(ctx context.Context, customerID, keyID string) error
+
+	// Ownership verification codes (email/phone keys)
+	StorePixKeyVerificationCode(ctx context.Context, code *domain.PixKeyVerificationCode) error
+	GetValidPixKeyVerificationCode(ctx context.Context, customerID, keyType, keyValue, code string) (*domain.PixKeyVerificationCode, error)
+	MarkPixKeyVerificationCodeUsed(ctx context.Context, codeID string) error
 }
 
 // PixTransferStore handles PIX transfer data operations.
 type PixTransferStore interface {
 	CreatePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransfer, error)
 	ListPixTransfers(ctx context.Context, customerID string, page, pageSize int) ([]domain.PixTransfer, error)
+	// ListPixTransfersFiltered returns one page of the customer's transfers
+	// matching filter, newest first, and the total number of matches.
+	ListPixTransfersFiltered(ctx context.Context, customerID string, filter *domain.PixTransferFilter) ([]domain.PixTransfer, int, error)
 	GetPixTransfer(ctx context.Context, customerID, transferID string) (*domain.PixTransfer, error)
+	// GetPixTransferByIdempotencyKey returns ErrNotFound when the customer has
+	// no transfer created with key.
+	GetPixTransferByIdempotencyKey(ctx context.Context, customerID, key string) (*domain.PixTransfer, error)
 	UpdatePixTransferStatus(ctx context.Context, transferID, status string) error
+
+	// Scheduled transfers (executed by the worker once due)
+	ListDuePixTransfers(ctx context.Context, before time.Time, limit int) ([]domain.PixTransfer, error)
+	ClaimScheduledPixTransfer(ctx context.Context, transferID string) (bool, error)
+	// FailStalePixTransfers moves to failed, with reason, the transfers
+	// claimed before since and never finished, returning them.
+	FailStalePixTransfers(ctx context.Context, since time.Time, reason string) ([]domain.PixTransfer, error)
+
+	// Confirmation tokens of high-value transfers
+	StorePixConfirmation(ctx context.Context, token *domain.PixConfirmationToken) error
+	// ClaimPixConfirmation moves an unexpired issued token bound to
+	// customerID and fingerprint to claimed, reporting false when there is
+	// none (unknown, expired, already claimed or used, another transfer).
+	ClaimPixConfirmation(ctx context.Context, token, customerID, fingerprint string) (bool, error)
+	// SetPixConfirmationStatus moves a claimed token to status: used once
+	// its transfer committed, issued again when it failed.
+	SetPixConfirmationStatus(ctx context.Context, token, status string) error
 }
 
 // PixReceiptStore handles PIX receipt data operations.
@@ -28,19 +60,29 @@ type PixReceiptStore interface {
 	SavePixReceipt(ctx context.Context, receipt *domain.PixReceipt) (*domain.PixReceipt, error)
 	GetPixReceipt(ctx context.Context, receiptID string) (*domain.PixReceipt, error)
 	GetPixReceiptByTransferID(ctx context.Context, transferID string) (*domain.PixReceipt, error)
-	ListPixReceipts(ctx context.Context, customerID string) ([]domain.PixReceipt, error)
+	ListPixReceipts(ctx context.Context, customerID string, page, pageSize int) ([]domain.PixReceipt, int, error)
 }
 
 // CustomerLookupStore resolves customer identity for PIX operations.
 type CustomerLookupStore interface {
 	GetCustomerName(ctx context.Context, customerID string) (string, error)
 	GetCustomerLookupData(ctx context.Context, customerID string) (name, document, bank, branch, account string, err error)
+	GetCustomerCreditScore(ctx context.Context, customerID string) (int, error)
 }
 
 // ScheduledTransferStore handles scheduled transfer data operations.
 type ScheduledTransferStore interface {
 	CreateScheduledTransfer(ctx context.Context, customerID string, req *domain.ScheduledTransferRequest) (*domain.ScheduledTransfer, error)
 	ListScheduledTransfers(ctx context.Context, customerID string) ([]domain.ScheduledTransfer, error)
+	// ListScheduledTransfersFiltered returns one page of the customer's
+	// schedules, only those with status when set, and the total matching.
+	ListScheduledTransfersFiltered(ctx context.Context, customerID, status string, page, pageSize int) ([]domain.ScheduledTransfer, int, error)
 	GetScheduledTransfer(ctx context.Context, customerID, transferID string) (*domain.ScheduledTransfer, error)
 	UpdateScheduledTransferStatus(ctx context.Context, transferID, status string) error
+	// ListDueScheduledTransfers returns the active schedules whose next
+	// execution date is on or before date (YYYY-MM-DD), oldest first.
+	ListDueScheduledTransfers(ctx context.Context, date string, limit int) ([]domain.ScheduledTransfer, error)
+	// AdvanceScheduledTransfer saves the schedule progress of transfer only
+	// while its next execution date is still fromDate, reporting whether it did.
+	AdvanceScheduledTransfer(ctx context.Context, transfer *domain.ScheduledTransfer, fromDate string) (bool, error)
 }
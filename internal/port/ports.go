@@ -3,13 +3,14 @@
 // layer from concrete implementations.
 //
 // Individual store interfaces are defined in separate files:
-//   - account_port.go  → AccountStore
+//   - account_port.go  → AccountStore, TransferReceiptStore
 //   - pix_port.go      → PixKeyStore, PixTransferStore, PixReceiptStore,
 //     CustomerLookupStore, ScheduledTransferStore
 //   - cards_port.go    → CreditCardStore, CreditCardTransactionStore,
 //     CreditCardInvoiceStore
-//   - billing_port.go  → BillingStore
+//   - billing_port.go  → BillingStore, BarcodeDecoder
 //   - analytics_port.go→ AnalyticsStore
+//   - webhook_port.go  → WebhookStore, EventPublisher, WebhookDispatcher
 package port
 
 import (
@@ -34,6 +35,12 @@ type AgentCaller interface {
 	Call(ctx context.Context, req *domain.AgentRequest) (*domain.AgentResponse, error)
 }
 
+// Pinger is implemented by dependencies that support a lightweight
+// connectivity check (used by the readiness probe).
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 // Cache provides generic caching with TTL.
 type Cache[T any] interface {
 	Get(key string) (T, bool)
@@ -41,11 +48,24 @@ type Cache[T any] interface {
 	Delete(key string)
 }
 
+// TokenUsageStore keeps the agent tokens each customer used per day. It is
+// shared by every instance, so the daily budget holds across replicas and
+// restarts. day is the date in the bank's time zone (YYYY-MM-DD).
+type TokenUsageStore interface {
+	// AddTokenUsage adds tokens to the customer's usage on day and returns
+	// the new total.
+	AddTokenUsage(ctx context.Context, customerID, day string, tokens int) (int, error)
+	GetTokenUsage(ctx context.Context, customerID, day string) (int, error)
+	ResetTokenUsage(ctx context.Context, customerID, day string) error
+}
+
 // BankingStore composes all domain-specific store interfaces into a single
 // aggregate interface. This is consumed by BankingService which orchestrates
 // cross-domain operations. The Supabase Client satisfies all sub-interfaces.
 type BankingStore interface {
 	AccountStore
+	TransferReceiptStore
+	DOCTransferStore
 	PixKeyStore
 	PixTransferStore
 	PixReceiptStore
@@ -56,6 +76,9 @@ type BankingStore interface {
 	CreditCardInvoiceStore
 	BillingStore
 	AnalyticsStore
+	ActivityStore
+	WebhookStore
+	DevToolsStore
 }
 
 // AuthStore defines all data operations for the authentication system.
@@ -72,17 +95,30 @@ type AuthStore interface {
 	// Credentials
 	GetCredentials(ctx context.Context, customerID string) (*domain.AuthCredential, error)
 	UpdateCredentials(ctx context.Context, customerID string, updates map[string]any) error
+	UpdateLastLogin(ctx context.Context, customerID, cpf string, at time.Time) error
+
+	// Roles — the user_companies row of the representative, nil when absent
+	GetUserCompany(ctx context.Context, customerID, cpf string) (*domain.UserCompany, error)
+	ListUserCompanies(ctx context.Context, cpf string) ([]domain.UserCompany, error)
 
 	// Refresh tokens
 	StoreRefreshToken(ctx context.Context, customerID, tokenHash string, expiresAt time.Time) error
 	GetRefreshToken(ctx context.Context, tokenHash string) (*domain.AuthRefreshToken, error)
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
 	RevokeAllRefreshTokens(ctx context.Context, customerID string) error
+	// RevokeIdleRefreshTokens revokes the unrevoked tokens issued before
+	// idleSince and returns how many it revoked.
+	RevokeIdleRefreshTokens(ctx context.Context, idleSince time.Time) (int, error)
 
 	// Password reset codes
 	StoreResetCode(ctx context.Context, customerID, code string, expiresAt time.Time) error
-	GetValidResetCode(ctx context.Context, customerID, code string) (*domain.AuthPasswordResetCode, error)
+	GetActiveResetCode(ctx context.Context, customerID string) (*domain.AuthPasswordResetCode, error)
 	MarkResetCodeUsed(ctx context.Context, codeID string) error
+	// ClaimResetCodeAttempt moves the code's attempts from attempts to
+	// attempts+1 and reports false when another request counted one first.
+	ClaimResetCodeAttempt(ctx context.Context, codeID string, attempts int) (bool, error)
+	ListResetCodesSince(ctx context.Context, customerID string, since time.Time) ([]domain.AuthPasswordResetCode, error)
+	InvalidateResetCodes(ctx context.Context, customerID string) error
 
 	// Profile updates
 	UpdateCustomerProfile(ctx context.Context, customerID string, updates map[string]any) (*domain.CustomerProfile, error)
@@ -0,0 +1,33 @@
+package port
+
+import (
+	"context"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+// WebhookStore handles webhook registration and delivery log data operations.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, hook *domain.Webhook) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context, customerID string) ([]domain.Webhook, error)
+	GetWebhook(ctx context.Context, customerID, webhookID string) (*domain.Webhook, error)
+	RevokeWebhook(ctx context.Context, customerID, webhookID string) error
+
+	// Delivery logs
+	SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+// EventPublisher delivers domain events to interested parties (e.g. webhooks).
+// Publish must not block the caller on delivery.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *domain.WebhookEvent)
+}
+
+// WebhookDispatcher publishes events to webhooks and can synchronously
+// resend a previously logged delivery.
+type WebhookDispatcher interface {
+	EventPublisher
+	Resend(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error)
+}
@@ -5,6 +5,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
@@ -22,11 +23,89 @@ type BankingService struct {
 	store   port.BankingStore
 	metrics *observability.Metrics
 	logger  *zap.Logger
+
+	cardAutoCategorize bool // infer card transaction category from merchant name
+
+	invoiceLateFeeRate      float64 // fine on invoices paid after the due date
+	invoiceLateInterestRate float64 // monthly late interest, charged pro rata per day
+
+	pixKeyLimit int  // max active PIX keys per customer
+	maskPII     bool // mask counterparty documents/keys in lookups and receipts
+
+	pixNewRecipientThreshold float64 // flag transfers to new recipients above this amount
+
+	pixCreditFeeRate float64 // fee per installment beyond the first on PIX via credit card
+
+	pixPurposeRules []PixPurposeRule // infer the category of PIX statement entries
+
+	pixConfirmThreshold float64       // require a confirmation token above this amount; 0 disables
+	pixConfirmTTL       time.Duration // validity of confirmation tokens
+
+	dashboardBudget time.Duration // overall time budget of GetDashboard
+
+	spendingSummaryMaxAge time.Duration // how long a precomputed spending summary is served
+
+	receiptShareSecret []byte        // HMAC key for receipt share tokens
+	receiptShareTTL    time.Duration // validity of receipt share tokens
+
+	events port.WebhookDispatcher // optional; notifies webhooks
+
+	barcodeDecoder port.BarcodeDecoder // reads camera_scan images of bills
+
+	balanceNotificationMinAmount float64 // smallest movement notified, unless the customer set one
+
+	maxOverdraftLimit float64 // highest overdraft limit an account can be given
+
+	defaultLimits map[string]domain.TransactionLimit // applied to customers without a configured limit, by type
+
+	jobs *jobQueue // background jobs (statement exports) accepted by this instance
+
+	categoryRules *categoryRuleCache // customers' category rules, kept for categoryRulesTTL
+
+	devToolsEnabled bool   // expose the dev tools (balance, limits, generated data, reset)
+	devToolsSecret  []byte // shared secret dev tools callers must present
+
+	readOnly bool // maintenance mode: the router rejects writes to banking data
 }
 
 // NewBankingService creates a new banking service.
 func NewBankingService(store port.BankingStore, metrics *observability.Metrics, logger *zap.Logger) *BankingService {
-	return &BankingService{store: store, metrics: metrics, logger: logger}
+	return &BankingService{
+		store:                    store,
+		metrics:                  metrics,
+		logger:                   logger,
+		cardAutoCategorize:       true,
+		invoiceLateFeeRate:       DefaultInvoiceLateFeeRate,
+		invoiceLateInterestRate:  DefaultInvoiceLateInterestRate,
+		pixKeyLimit:              DefaultPixKeyLimit,
+		pixNewRecipientThreshold: DefaultPixNewRecipientThreshold,
+		pixCreditFeeRate:         DefaultPixCreditFeeRate,
+		barcodeDecoder:           noBarcodeDecoder{},
+		pixPurposeRules:          DefaultPixPurposeRules,
+		maskPII:                  true,
+		dashboardBudget:          DefaultDashboardBudget,
+		spendingSummaryMaxAge:    DefaultSpendingSummaryMaxAge,
+		receiptShareTTL:          DefaultReceiptShareTTL,
+
+		balanceNotificationMinAmount: DefaultBalanceNotificationMinAmount,
+
+		maxOverdraftLimit: DefaultMaxOverdraftLimit,
+
+		defaultLimits: make(map[string]domain.TransactionLimit),
+		jobs:          newJobQueue(),
+		categoryRules: newCategoryRuleCache(),
+	}
+}
+
+// SetReadOnly turns the maintenance mode on or off. While on, the router
+// answers 503 to every write to banking data (e.g. during data migrations).
+func (s *BankingService) SetReadOnly(enabled bool) {
+	s.readOnly = enabled
+}
+
+// ReadOnly reports whether the maintenance mode is on.
+func (s *BankingService) ReadOnly() bool {
+	return s.readOnly
 }
 
 /*
@@ -41,6 +120,34 @@ func (s *BankingService) ListAccounts(ctx context.Context, customerID string) ([
 	return s.store.ListAccounts(ctx, customerID)
 }
 
+// GetAccountsSummary lists the customer's accounts together with the
+// balance, available balance and overdraft totals of the active ones.
+func (s *BankingService) GetAccountsSummary(ctx context.Context, customerID string) (*domain.AccountsSummary, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetAccountsSummary")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	accounts, err := s.store.ListAccounts(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if accounts == nil {
+		accounts = []domain.Account{}
+	}
+
+	summary := &domain.AccountsSummary{Accounts: accounts}
+	for _, acct := range accounts {
+		if acct.Status != "active" {
+			continue
+		}
+		summary.Summary.TotalBalance += acct.Balance
+		summary.Summary.TotalAvailable += acct.AvailableBalance
+		summary.Summary.TotalOverdraft += acct.OverdraftLimit
+		summary.Summary.AccountCount++
+	}
+	return summary, nil
+}
+
 func (s *BankingService) GetAccount(ctx context.Context, customerID, accountID string) (*domain.Account, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.GetAccount")
 	defer span.End()
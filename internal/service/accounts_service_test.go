@@ -0,0 +1,36 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestGetAccountsSummary_TotalsActiveAccounts(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.accounts[testCustomerID].AvailableBalance = 900
+	store.accounts[testCustomerID].OverdraftLimit = 500
+	store.otherAccounts = append(store.otherAccounts,
+		&domain.Account{ID: "acct-002", CustomerID: testCustomerID, Balance: 250.75, AvailableBalance: 250.75, OverdraftLimit: 100, Status: "active"},
+		&domain.Account{ID: "acct-003", CustomerID: testCustomerID, Balance: 5000, AvailableBalance: 5000, OverdraftLimit: 2000, Status: "blocked"},
+	)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetAccountsSummary(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summary.Accounts) != 3 {
+		t.Errorf("listed %d accounts, want all 3", len(summary.Accounts))
+	}
+	want := domain.AccountsTotals{TotalBalance: 1250.75, TotalAvailable: 1150.75, TotalOverdraft: 600, AccountCount: 2}
+	if summary.Summary != want {
+		t.Errorf("totals = %+v, want %+v (blocked account ignored)", summary.Summary, want)
+	}
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+ * Activity feed — transactions, PIX receipts, bill payments and
+ * notifications merged into one chronological list
+ */
+
+const (
+	// DefaultActivityFeedLimit is the page size when none is requested.
+	DefaultActivityFeedLimit = 20
+
+	// maxActivityFeedLimit is the largest page size served.
+	maxActivityFeedLimit = 50
+)
+
+// Feed sources, in tie-break order for items at the same timestamp.
+var activitySources = []string{
+	domain.ActivityKindTransaction,
+	domain.ActivityKindPixReceipt,
+	domain.ActivityKindBillPayment,
+	domain.ActivityKindNotification,
+}
+
+// activityKey is the position of an item in the feed: most recent first,
+// then by source, then by ID descending. The cursor encodes the key of the
+// last item served.
+type activityKey struct {
+	ts     time.Time
+	source int
+	id     string
+}
+
+// precedes reports whether k comes before o in the feed.
+func (k activityKey) precedes(o activityKey) bool {
+	if !k.ts.Equal(o.ts) {
+		return k.ts.After(o.ts)
+	}
+	if k.source != o.source {
+		return k.source < o.source
+	}
+	return k.id > o.id
+}
+
+func (k activityKey) cursor() string {
+	raw := k.ts.Format(time.RFC3339Nano) + "|" + activitySources[k.source] + "|" + k.id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseActivityCursor(cursor string) (activityKey, error) {
+	invalid := &domain.ErrValidation{Field: "cursor", Message: "invalid cursor"}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return activityKey{}, invalid
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return activityKey{}, invalid
+	}
+	ts, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return activityKey{}, invalid
+	}
+	for i, kind := range activitySources {
+		if kind == parts[1] {
+			return activityKey{ts: ts, source: i, id: parts[2]}, nil
+		}
+	}
+	return activityKey{}, invalid
+}
+
+// activityPage is what one source returned for a page: its items, and the
+// key of the last row fetched when the source may have more.
+type activityPage struct {
+	items []domain.ActivityItem
+	keys  []activityKey
+	more  *activityKey
+}
+
+func (p *activityPage) add(item domain.ActivityItem, source int) {
+	p.items = append(p.items, item)
+	p.keys = append(p.keys, activityKey{ts: item.Timestamp, source: source, id: item.SourceID})
+}
+
+// GetActivityFeed returns the customer's recent activity, most recent first.
+// cursor is the NextCursor of the previous page (empty for the first page);
+// only items after it are returned. Every source is queried by keyset from
+// the cursor, so a page costs the same however deep it is.
+//
+// PIX and boleto transactions are listed through their receipts and bill
+// payments, which carry more detail, so the matching customer_transactions
+// rows are skipped.
+func (s *BankingService) GetActivityFeed(ctx context.Context, customerID string, limit int, cursor string) (*domain.ActivityFeed, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetActivityFeed")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if limit <= 0 {
+		limit = DefaultActivityFeedLimit
+	}
+	if limit > maxActivityFeedLimit {
+		limit = maxActivityFeedLimit
+	}
+
+	after := activityKey{ts: time.Now().Add(time.Second), source: len(activitySources)}
+	if cursor != "" {
+		key, err := parseActivityCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = key
+	}
+
+	// bound returns the keyset bound of source past the cursor: sources
+	// earlier in the tie-break order already served the items at the cursor
+	// timestamp, later ones served none of them. Timestamps are stored with
+	// microsecond precision.
+	bound := func(source int) (time.Time, string) {
+		switch {
+		case source < after.source:
+			return after.ts, ""
+		case source == after.source:
+			return after.ts, after.id
+		default:
+			return after.ts.Add(time.Microsecond), ""
+		}
+	}
+
+	fetch := limit + 1
+	pages := make([]activityPage, len(activitySources))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		const source = 0
+		before, beforeID := bound(source)
+		txns, err := s.store.ListTransactionsBefore(gctx, customerID, before, beforeID, fetch)
+		if err != nil {
+			return err
+		}
+		page := &pages[source]
+		for _, tx := range txns {
+			if tx.Type == "pix_sent" || tx.Type == "pix_received" || (tx.Type == "bill_payment" && tx.Category == "contas") {
+				continue
+			}
+			amount := tx.Amount
+			page.add(domain.ActivityItem{
+				Kind:        domain.ActivityKindTransaction,
+				SourceID:    tx.ID,
+				Timestamp:   tx.Date,
+				Title:       tx.Description,
+				Description: tx.Category,
+				Amount:      &amount,
+			}, source)
+		}
+		if len(txns) == fetch {
+			last := txns[len(txns)-1]
+			page.more = &activityKey{ts: last.Date, source: source, id: last.ID}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		const source = 1
+		before, beforeID := bound(source)
+		receipts, err := s.store.ListPixReceiptsBefore(gctx, customerID, before, beforeID, fetch)
+		if err != nil {
+			return err
+		}
+		page := &pages[source]
+		var lastTS time.Time
+		for _, r := range receipts {
+			ts, err := time.Parse(time.RFC3339, r.ExecutedAt)
+			if err != nil {
+				continue
+			}
+			lastTS = ts
+			item := domain.ActivityItem{
+				Kind:      domain.ActivityKindPixReceipt,
+				SourceID:  r.ID,
+				Timestamp: ts,
+				Status:    r.Status,
+			}
+			amount := r.Amount
+			if r.Direction == "received" {
+				item.Title, item.Description = "Pix recebido", r.SenderName
+			} else {
+				item.Title, item.Description = "Pix enviado", r.RecipientName
+				amount = -amount
+			}
+			item.Amount = &amount
+			page.add(item, source)
+		}
+		if len(receipts) == fetch {
+			page.more = &activityKey{ts: lastTS, source: source, id: receipts[len(receipts)-1].ID}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		const source = 2
+		before, beforeID := bound(source)
+		bills, err := s.store.ListBillPaymentsBefore(gctx, customerID, before, beforeID, fetch)
+		if err != nil {
+			return err
+		}
+		page := &pages[source]
+		for _, b := range bills {
+			amount := -b.FinalAmount
+			page.add(domain.ActivityItem{
+				Kind:        domain.ActivityKindBillPayment,
+				SourceID:    b.ID,
+				Timestamp:   b.CreatedAt,
+				Title:       "Pagamento de boleto",
+				Description: b.BeneficiaryName,
+				Amount:      &amount,
+				Status:      b.Status,
+			}, source)
+		}
+		if len(bills) == fetch {
+			last := bills[len(bills)-1]
+			page.more = &activityKey{ts: last.CreatedAt, source: source, id: last.ID}
+		}
+		return nil
+	})
+	g.Go(func() error {
+		const source = 3
+		before, beforeID := bound(source)
+		notifs, err := s.store.ListNotificationsBefore(gctx, customerID, before, beforeID, fetch)
+		if err != nil {
+			return err
+		}
+		page := &pages[source]
+		for _, n := range notifs {
+			page.add(domain.ActivityItem{
+				Kind:        domain.ActivityKindNotification,
+				SourceID:    n.ID,
+				Timestamp:   n.CreatedAt,
+				Title:       n.Title,
+				Description: n.Body,
+			}, source)
+		}
+		if len(notifs) == fetch {
+			last := notifs[len(notifs)-1]
+			page.more = &activityKey{ts: last.CreatedAt, source: source, id: last.ID}
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	items, next := mergeActivity(pages, limit)
+	feed := &domain.ActivityFeed{Items: items}
+	if next != nil {
+		feed.NextCursor = next.cursor()
+	}
+	span.SetAttributes(attribute.Int("activity.items", len(feed.Items)))
+	return feed, nil
+}
+
+// mergeActivity merges the source pages into one page of at most limit items
+// and returns the key to continue from, nil on the last page. A source that
+// may have more rows bounds the page at its last row fetched: items past it
+// could be preceded by rows of that source not fetched yet.
+func mergeActivity(pages []activityPage, limit int) ([]domain.ActivityItem, *activityKey) {
+	var horizon *activityKey
+	for i := range pages {
+		if more := pages[i].more; more != nil && (horizon == nil || more.precedes(*horizon)) {
+			horizon = more
+		}
+	}
+
+	type entry struct {
+		item domain.ActivityItem
+		key  activityKey
+	}
+	var merged []entry
+	for _, p := range pages {
+		for i, key := range p.keys {
+			if horizon != nil && horizon.precedes(key) {
+				continue
+			}
+			merged = append(merged, entry{p.items[i], key})
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].key.precedes(merged[j].key) })
+
+	next := horizon
+	if len(merged) > limit {
+		merged = merged[:limit]
+		next = &merged[limit-1].key
+	}
+	items := make([]domain.ActivityItem, len(merged))
+	for i, e := range merged {
+		items[i] = e.item
+	}
+	return items, next
+}
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// seedActivity registers one item per source, interleaved in time, plus a
+// PIX transaction that is represented by its receipt.
+func seedActivity(store *fakeBankingStore, now time.Time) {
+	at := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: at(1), Amount: -80, Type: "debit_purchase", Category: "compras", Description: "Compra débito - Kalunga"},
+		{ID: "tx-2", Date: at(6), Amount: 1500, Type: "transfer_in", Category: "transferencia", Description: "Transferência recebida"},
+		{ID: "tx-pix", Date: at(2), Amount: -250, Type: "pix_sent", Description: "Pix enviado"},
+	}
+	store.pixReceipts = []domain.PixReceipt{
+		{ID: "rcpt-1", CustomerID: testCustomerID, Direction: "sent", Amount: 250, RecipientName: "Fornecedor LTDA", Status: "completed", ExecutedAt: at(2).Format(time.RFC3339)},
+	}
+	store.bills = []domain.BillPayment{
+		{ID: "bill-1", CustomerID: testCustomerID, FinalAmount: 320, BeneficiaryName: "Copel", Status: "completed", CreatedAt: at(4)},
+	}
+	store.notifs = []domain.Notification{
+		{ID: "notif-1", CustomerID: testCustomerID, Title: "Fatura fechada", CreatedAt: at(3)},
+		{ID: "notif-2", CustomerID: testCustomerID, Title: "Bem-vindo", CreatedAt: at(5)},
+	}
+}
+
+func TestGetActivityFeed_MergesSourcesByTime(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedActivity(store, time.Now().Truncate(time.Second))
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	feed, err := svc.GetActivityFeed(context.Background(), testCustomerID, 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct{ kind, id string }{
+		{domain.ActivityKindTransaction, "tx-1"},
+		{domain.ActivityKindPixReceipt, "rcpt-1"},
+		{domain.ActivityKindNotification, "notif-1"},
+		{domain.ActivityKindBillPayment, "bill-1"},
+		{domain.ActivityKindNotification, "notif-2"},
+		{domain.ActivityKindTransaction, "tx-2"},
+	}
+	if len(feed.Items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(feed.Items), len(want), feed.Items)
+	}
+	for i, w := range want {
+		if got := feed.Items[i]; got.Kind != w.kind || got.SourceID != w.id {
+			t.Errorf("items[%d] = %s/%s, want %s/%s", i, got.Kind, got.SourceID, w.kind, w.id)
+		}
+	}
+	if feed.NextCursor != "" {
+		t.Errorf("next cursor = %q, want empty on the last page", feed.NextCursor)
+	}
+	if amount := feed.Items[1].Amount; amount == nil || *amount != -250 {
+		t.Errorf("sent PIX amount = %v, want -250", amount)
+	}
+}
+
+func TestGetActivityFeed_CursorPagination(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedActivity(store, time.Now().Truncate(time.Second))
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	var ids []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		feed, err := svc.GetActivityFeed(context.Background(), testCustomerID, 4, cursor)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", page, err)
+		}
+		for _, it := range feed.Items {
+			ids = append(ids, it.SourceID)
+		}
+		if feed.NextCursor == "" {
+			break
+		}
+		cursor = feed.NextCursor
+	}
+
+	want := []string{"tx-1", "rcpt-1", "notif-1", "bill-1", "notif-2", "tx-2"}
+	if len(ids) != len(want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("item %d = %s, want %s", i, ids[i], want[i])
+		}
+	}
+
+	var validation *domain.ErrValidation
+	if _, err := svc.GetActivityFeed(context.Background(), testCustomerID, 4, "yesterday"); !errors.As(err, &validation) {
+		t.Errorf("expected ErrValidation for a malformed cursor, got %v", err)
+	}
+}
+
+func TestGetActivityFeed_PagesThroughTiesAndDeepSources(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	now := time.Now().Truncate(time.Second)
+	same := now.Add(-time.Hour)
+
+	want := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("notif-%02d", i)
+		store.notifs = append(store.notifs, domain.Notification{ID: id, CustomerID: testCustomerID, Title: "Aviso", CreatedAt: same})
+		want[id] = true
+	}
+	for i := 0; i < 25; i++ {
+		id := fmt.Sprintf("bill-%02d", i)
+		store.bills = append(store.bills, domain.BillPayment{ID: id, CustomerID: testCustomerID, FinalAmount: 10, CreatedAt: same})
+		want[id] = true
+	}
+	// Represented by receipts, so skipped, but still fetched from the store
+	for i := 0; i < 40; i++ {
+		store.statement = append(store.statement, domain.Transaction{
+			ID: fmt.Sprintf("tx-pix-%02d", i), Date: now.Add(-time.Duration(i) * time.Minute), Amount: -5, Type: "pix_sent",
+		})
+	}
+	store.statement = append(store.statement, domain.Transaction{ID: "tx-old", Date: now.Add(-48 * time.Hour), Amount: 100, Type: "transfer_in"})
+	want["tx-old"] = true
+
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	seen := map[string]bool{}
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 50 {
+			t.Fatal("pagination did not terminate")
+		}
+		feed, err := svc.GetActivityFeed(context.Background(), testCustomerID, 7, cursor)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", page, err)
+		}
+		for _, it := range feed.Items {
+			if seen[it.SourceID] {
+				t.Fatalf("page %d: %s served twice", page, it.SourceID)
+			}
+			seen[it.SourceID] = true
+		}
+		if feed.NextCursor == "" {
+			break
+		}
+		cursor = feed.NextCursor
+	}
+
+	if len(seen) != len(want) {
+		t.Errorf("served %d items, want %d", len(seen), len(want))
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("%s never served", id)
+		}
+	}
+}
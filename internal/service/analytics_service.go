@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -88,7 +94,37 @@ func (s *BankingService) CreateFavorite(ctx context.Context, fav *domain.Favorit
 		return nil, &domain.ErrValidation{Field: "recipient_name", Message: "required"}
 	}
 
-	return s.store.CreateFavorite(ctx, fav)
+	// Dedup: the same recipient (PIX key or bank account) is only saved once
+	existing, err := s.store.FindFavorite(ctx, fav)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		s.logger.Info("favorite already exists, returning existing",
+			zap.String("customer_id", fav.CustomerID),
+			zap.String("favorite_id", existing.ID))
+		return existing, nil
+	}
+
+	// A concurrent create of the same recipient can land between the lookup
+	// and the insert; the unique index turns it into ErrDuplicate and the
+	// winner's row is returned instead.
+	created, err := s.store.CreateFavorite(ctx, fav)
+	var duplicate *domain.ErrDuplicate
+	if !errors.As(err, &duplicate) {
+		return created, err
+	}
+	existing, findErr := s.store.FindFavorite(ctx, fav)
+	if findErr != nil {
+		return nil, findErr
+	}
+	if existing == nil {
+		return nil, err
+	}
+	s.logger.Info("favorite created concurrently, returning existing",
+		zap.String("customer_id", fav.CustomerID),
+		zap.String("favorite_id", existing.ID))
+	return existing, nil
 }
 
 func (s *BankingService) DeleteFavorite(ctx context.Context, customerID, favoriteID string) error {
@@ -106,7 +142,65 @@ func (s *BankingService) ListLimits(ctx context.Context, customerID string) ([]d
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListLimits")
 	defer span.End()
 
-	return s.store.ListTransactionLimits(ctx, customerID)
+	limits, err := s.store.ListTransactionLimits(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range limits {
+		currentLimitUsage(&limits[i], now)
+	}
+	types := make([]string, 0, len(s.defaultLimits))
+	for txType := range s.defaultLimits {
+		types = append(types, txType)
+	}
+	slices.Sort(types)
+	for _, txType := range types {
+		if slices.ContainsFunc(limits, func(l domain.TransactionLimit) bool { return l.TransactionType == txType }) {
+			continue
+		}
+		limits = append(limits, *s.defaultTransactionLimit(customerID, txType))
+	}
+	return limits, nil
+}
+
+// GetPixLimitsSummary returns the customer's PIX limits with what is used
+// and left today and this month. Remaining amounts never go below zero.
+func (s *BankingService) GetPixLimitsSummary(ctx context.Context, customerID string) (*domain.PixLimitsSummary, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetPixLimitsSummary")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	limit, err := s.transactionLimit(ctx, customerID, "pix")
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.PixLimitsSummary{CustomerID: customerID}
+	if limit == nil {
+		return summary, nil
+	}
+	summary.Configured = true
+	summary.SingleLimit = limit.SingleLimit
+	summary.Daily = limitUsage(limit.DailyLimit, limit.DailyUsed)
+	summary.Monthly = limitUsage(limit.MonthlyLimit, limit.MonthlyUsed)
+
+	if limit.NightlySingleLimit != nil || limit.NightlyDailyLimit != nil {
+		summary.Nightly = &domain.PixNightlyLimits{
+			SingleLimit: limit.NightlySingleLimit,
+			DailyLimit:  limit.NightlyDailyLimit,
+		}
+		if limit.NightlyDailyLimit != nil {
+			remaining := math.Min(*limit.NightlyDailyLimit, summary.Daily.Remaining)
+			summary.Nightly.Remaining = &remaining
+		}
+	}
+	return summary, nil
+}
+
+// limitUsage computes what is left of limit, clamped at zero.
+func limitUsage(limit, used float64) domain.LimitUsage {
+	return domain.LimitUsage{Limit: limit, Used: used, Remaining: math.Max(limit-used, 0)}
 }
 
 func (s *BankingService) UpdateLimit(ctx context.Context, limit *domain.TransactionLimit) (*domain.TransactionLimit, error) {
@@ -120,11 +214,38 @@ func (s *BankingService) UpdateLimit(ctx context.Context, limit *domain.Transact
  * Notifications
  */
 
-func (s *BankingService) ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, error) {
+func (s *BankingService) ListNotifications(ctx context.Context, customerID string, unreadOnly bool, page, pageSize int) (*domain.ListResponse[domain.Notification], error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListNotifications")
 	defer span.End()
 
-	return s.store.ListNotifications(ctx, customerID, unreadOnly, page, pageSize)
+	rows, total, err := s.store.ListNotifications(ctx, customerID, unreadOnly, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, page, pageSize), nil
+}
+
+// GetNotification returns one of the customer's notifications; another
+// customer's notification is reported as not found. With markRead an unread
+// notification is marked read and returned as such.
+func (s *BankingService) GetNotification(ctx context.Context, customerID, notifID string, markRead bool) (*domain.Notification, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetNotification")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	notif, err := s.store.GetNotification(ctx, customerID, notifID)
+	if err != nil {
+		return nil, err
+	}
+	if markRead && !notif.IsRead {
+		if err := s.store.MarkNotificationRead(ctx, notifID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		notif.IsRead = true
+		notif.ReadAt = &now
+	}
+	return notif, nil
 }
 
 func (s *BankingService) MarkNotificationRead(ctx context.Context, notifID string) error {
@@ -134,6 +255,145 @@ func (s *BankingService) MarkNotificationRead(ctx context.Context, notifID strin
 	return s.store.MarkNotificationRead(ctx, notifID)
 }
 
+// maxNotificationsReadBatch caps how many ids MarkNotificationsRead takes.
+const maxNotificationsReadBatch = 100
+
+// MarkNotificationsRead marks the given notifications of the customer read
+// in a single update and returns how many were unread. Ids that are not the
+// customer's are ignored, so they cannot be used to touch another inbox.
+func (s *BankingService) MarkNotificationsRead(ctx context.Context, customerID string, ids []string) (int, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.MarkNotificationsRead")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Int("notification.count", len(ids)))
+
+	if len(ids) == 0 {
+		return 0, &domain.ErrValidation{Field: "ids", Message: "must not be empty"}
+	}
+	if len(ids) > maxNotificationsReadBatch {
+		return 0, &domain.ErrValidation{Field: "ids", Message: fmt.Sprintf("must have at most %d ids", maxNotificationsReadBatch)}
+	}
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			return 0, &domain.ErrValidation{Field: "ids", Message: "must not contain empty ids"}
+		}
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	return s.store.MarkNotificationsRead(ctx, customerID, unique)
+}
+
+// CreateNotification delivers notif on each of the given channels the
+// customer has not switched off for its type, storing one notification per
+// channel. Without channels it goes to the in-app inbox only. It returns the
+// stored notifications, which is empty when every channel is suppressed.
+func (s *BankingService) CreateNotification(ctx context.Context, notif *domain.Notification, channels ...string) ([]domain.Notification, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.CreateNotification")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", notif.CustomerID), attribute.String("notification.type", notif.Type))
+
+	if !slices.Contains(domain.NotificationTypes, notif.Type) {
+		return nil, &domain.ErrValidation{Field: "type", Message: "unknown notification type"}
+	}
+	if len(channels) == 0 {
+		channels = []string{domain.NotificationChannelInApp}
+	}
+
+	prefs, err := s.GetNotificationPreferences(ctx, notif.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if notif.Priority == "" {
+		notif.Priority = "normal"
+	}
+
+	created := make([]domain.Notification, 0, len(channels))
+	for _, channel := range channels {
+		if !prefs[notif.Type].Allows(channel) {
+			s.logger.Debug("notification suppressed by customer preferences",
+				zap.String("customer_id", notif.CustomerID),
+				zap.String("type", notif.Type),
+				zap.String("channel", channel),
+			)
+			continue
+		}
+		n := *notif
+		n.Channel = channel
+		stored, err := s.store.InsertNotification(ctx, &n)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, *stored)
+	}
+	span.SetAttributes(attribute.Int("notifications.created", len(created)))
+	return created, nil
+}
+
+// GetNotificationPreferences returns the customer's preferences for every
+// notification type. Types the customer never changed have every channel on.
+func (s *BankingService) GetNotificationPreferences(ctx context.Context, customerID string) (domain.NotificationPreferences, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetNotificationPreferences")
+	defer span.End()
+
+	stored, err := s.store.GetNotificationPreferences(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultPreferences(stored), nil
+}
+
+// UpdateNotificationPreferences replaces the preferences of the types present
+// in update; the other types keep their current setting. It returns the
+// resulting preferences for every type.
+func (s *BankingService) UpdateNotificationPreferences(ctx context.Context, customerID string, update domain.NotificationPreferences) (domain.NotificationPreferences, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.UpdateNotificationPreferences")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if len(update) == 0 {
+		return nil, &domain.ErrValidation{Field: "preferences", Message: "at least one notification type is required"}
+	}
+	for t, p := range update {
+		if !slices.Contains(domain.NotificationTypes, t) {
+			return nil, &domain.ErrValidation{Field: t, Message: "unknown notification type"}
+		}
+		if p.MinAmount != nil && (t != domain.NotificationTypeTransaction || *p.MinAmount < 0) {
+			return nil, &domain.ErrValidation{Field: t + ".minAmount", Message: "only a non-negative amount on the transaction type"}
+		}
+	}
+
+	stored, err := s.store.GetNotificationPreferences(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		stored = make(domain.NotificationPreferences, len(update))
+	}
+	for t, p := range update {
+		stored[t] = p
+	}
+	if err := s.store.SaveNotificationPreferences(ctx, customerID, stored); err != nil {
+		return nil, err
+	}
+	return withDefaultPreferences(stored), nil
+}
+
+// withDefaultPreferences completes the stored preferences with every channel
+// on for the types the customer never changed.
+func withDefaultPreferences(stored domain.NotificationPreferences) domain.NotificationPreferences {
+	prefs := make(domain.NotificationPreferences, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		p, ok := stored[t]
+		if !ok {
+			p = domain.NotificationChannelPrefs{Push: true, Email: true, InApp: true}
+		}
+		prefs[t] = p
+	}
+	return prefs
+}
+
 /*
  * Financial Summary (aggregated view for the frontend spec)
  */
@@ -170,95 +430,55 @@ func (s *BankingService) GetFinancialSummary(ctx context.Context, customerID, pe
 		periodLabel = "Últimos 30 dias"
 		periodDays = 30
 	}
-	fromDate := now.AddDate(0, 0, -periodDays).Format("2006-01-02")
-	toDate := now.AddDate(0, 0, 1).Format("2006-01-02") // next day so we include all of today
+	fromDate, toDate := spendingSummaryWindow(now, periodDays) // to is the next day so we include all of today
 
-	// Fetch actual transactions from customer_transactions
-	txns, txErr := s.store.ListTransactions(ctx, customerID, fromDate, toDate)
-	if txErr != nil {
-		s.logger.Warn("could not list transactions for financial summary", zap.Error(txErr))
-		txns = nil
-	}
-
-	// Compute income, expenses, and category breakdown from real transactions
-	var totalIncome, totalExpenses float64
-	categoryMap := make(map[string]struct {
-		Total float64
-		Count int
-	})
-
-	// Monthly breakdown for trend
-	monthlyIncome := make(map[string]float64)
-	monthlyExpenses := make(map[string]float64)
-
-	for _, tx := range txns {
-		monthKey := tx.Date.Format("2006-01")
-		if tx.Amount >= 0 {
-			totalIncome += tx.Amount
-			monthlyIncome[monthKey] += tx.Amount
-		} else {
-			totalExpenses += -tx.Amount // store as positive for display
-			monthlyExpenses[monthKey] += -tx.Amount
+	// Prefer the precomputed summary; otherwise aggregate the transactions
+	// and store the result for the next calls
+	summary := s.freshSpendingSummary(ctx, customerID, now, periodDays)
+	span.SetAttributes(attribute.Bool("summary.precomputed", summary != nil))
+	if summary == nil {
+		txns, txErr := s.store.ListTransactions(ctx, customerID, fromDate, toDate)
+		if txErr != nil {
+			s.logger.Warn("could not list transactions for financial summary", zap.Error(txErr))
+			txns = nil
 		}
-		if tx.Category != "" {
-			entry := categoryMap[tx.Category]
-			entry.Total += -tx.Amount // positive value for expense categories
-			if tx.Amount < 0 {
-				entry.Count++
+		summary = s.buildSpendingSummary(ctx, customerID, now, periodDays, txns)
+		if periodType := spendingSummaryPeriodType(periodDays); periodType != "" && txErr == nil {
+			summary.PeriodType = periodType
+			if err := s.store.UpsertSpendingSummary(ctx, summary); err != nil {
+				s.logger.Warn("could not store spending summary",
+					zap.String("customer_id", customerID), zap.Error(err))
 			}
-			categoryMap[tx.Category] = entry
 		}
 	}
 
 	// Build top categories
 	topCategories := make([]domain.TopCategory, 0)
-	for cat, info := range categoryMap {
+	for cat, info := range summary.CategoryBreakdown {
 		if info.Total <= 0 {
 			continue // skip income categories
 		}
-		pct := float64(0)
-		if totalExpenses > 0 {
-			pct = (info.Total / totalExpenses) * 100
-		}
 		topCategories = append(topCategories, domain.TopCategory{
 			Category:         cat,
-			Amount:           info.Total,
-			Percentage:       pct,
+			Amount:           RoundMoney(info.Total),
+			Percentage:       info.Pct,
 			TransactionCount: info.Count,
 			Trend:            "stable",
 		})
 	}
 
-	// Build monthly trend
-	monthlyTrend := make([]domain.MonthlyTrend, 0)
-	monthSet := make(map[string]bool)
-	for m := range monthlyIncome {
-		monthSet[m] = true
-	}
-	for m := range monthlyExpenses {
-		monthSet[m] = true
-	}
-	for m := range monthSet {
-		inc := monthlyIncome[m]
-		exp := monthlyExpenses[m]
+	// The stored summary may be cached, so the trend is rounded on a copy.
+	monthlyTrend := make([]domain.MonthlyTrend, 0, len(summary.MonthlyTrend))
+	for _, m := range summary.MonthlyTrend {
 		monthlyTrend = append(monthlyTrend, domain.MonthlyTrend{
-			Month:    m,
-			Income:   inc,
-			Expenses: exp,
-			Balance:  inc - exp,
+			Month:    m.Month,
+			Income:   RoundMoney(m.Income),
+			Expenses: RoundMoney(m.Expenses),
+			Balance:  RoundMoney(m.Balance),
 		})
 	}
 
-	// Sort monthly trend by month ascending
-	for i := 0; i < len(monthlyTrend); i++ {
-		for j := i + 1; j < len(monthlyTrend); j++ {
-			if monthlyTrend[i].Month > monthlyTrend[j].Month {
-				monthlyTrend[i], monthlyTrend[j] = monthlyTrend[j], monthlyTrend[i]
-			}
-		}
-	}
-
-	netCashFlow := totalIncome - totalExpenses
+	totalIncome, totalExpenses := summary.TotalIncome, summary.TotalExpenses
 	avgDaily := float64(0)
 	if periodDays > 0 && totalExpenses > 0 {
 		avgDaily = totalExpenses / float64(periodDays)
@@ -272,27 +492,68 @@ func (s *BankingService) GetFinancialSummary(ctx context.Context, customerID, pe
 			Label: periodLabel,
 		},
 		Balance: &domain.BalanceSummary{
-			Current:   account.Balance,
-			Available: account.AvailableBalance,
-			Blocked:   account.Balance - account.AvailableBalance,
+			Current:   RoundMoney(account.Balance),
+			Available: RoundMoney(account.AvailableBalance),
+			Blocked:   RoundMoney(account.Balance - account.AvailableBalance),
 			Invested:  0,
 		},
 		CashFlow: &domain.CashFlowSummary{
-			TotalIncome:              totalIncome,
-			TotalExpenses:            totalExpenses,
-			NetCashFlow:              netCashFlow,
-			ComparedToPreviousPeriod: 0,
+			TotalIncome:              RoundMoney(totalIncome),
+			TotalExpenses:            RoundMoney(totalExpenses),
+			NetCashFlow:              RoundMoney(summary.NetCashflow),
+			ComparedToPreviousPeriod: summary.NetVariationPct,
 		},
 		Spending: &domain.SpendingDetail{
-			TotalSpent:               totalExpenses,
-			AverageDaily:             avgDaily,
-			ComparedToPreviousPeriod: 0,
+			TotalSpent:               RoundMoney(totalExpenses),
+			AverageDaily:             RoundMoney(avgDaily),
+			ComparedToPreviousPeriod: summary.ExpenseVariationPct,
 		},
 		TopCategories: topCategories,
 		MonthlyTrend:  monthlyTrend,
 	}, nil
 }
 
+// previousPeriodTotals returns income and expenses of the periodDays window
+// preceding the current one. A stored spending_summaries row for exactly that
+// window is preferred; otherwise the totals are computed from transactions.
+func (s *BankingService) previousPeriodTotals(ctx context.Context, customerID string, now time.Time, periodDays int) (income, expenses float64) {
+	prevStart := now.AddDate(0, 0, -2*periodDays).Format("2006-01-02")
+	prevEnd := now.AddDate(0, 0, -periodDays-1).Format("2006-01-02") // inclusive
+	curStart := now.AddDate(0, 0, -periodDays).Format("2006-01-02")
+
+	stored, err := s.store.FindSpendingSummary(ctx, customerID, prevStart, prevEnd)
+	if err != nil {
+		s.logger.Warn("could not load stored spending summary, computing from transactions",
+			zap.String("customer_id", customerID), zap.Error(err))
+	}
+	if stored != nil {
+		return stored.TotalIncome, stored.TotalExpenses
+	}
+
+	txns, err := s.store.ListTransactions(ctx, customerID, prevStart, curStart)
+	if err != nil {
+		s.logger.Warn("could not list previous-period transactions", zap.Error(err))
+		return 0, 0
+	}
+	for _, tx := range txns {
+		if tx.Amount >= 0 {
+			income += tx.Amount
+		} else {
+			expenses += -tx.Amount
+		}
+	}
+	return income, expenses
+}
+
+// percentChange returns the variation from previous to current in percent,
+// rounded to 2 decimals. It is 0 when there is no previous value to compare.
+func percentChange(current, previous float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return math.Round((current-previous)/math.Abs(previous)*10000) / 100
+}
+
 // GetTransactionSummary computes an aggregated summary of customer transactions.
 // Balance reflects the real account balance, not just sum of transactions.
 func (s *BankingService) GetTransactionSummary(ctx context.Context, customerID string) (*domain.TransactionSummary, error) {
@@ -310,5 +571,12 @@ func (s *BankingService) GetTransactionSummary(ctx context.Context, customerID s
 		summary.Balance = account.Balance
 	}
 
+	summary.TotalCredits = RoundMoney(summary.TotalCredits)
+	summary.TotalDebits = RoundMoney(summary.TotalDebits)
+	summary.Balance = RoundMoney(summary.Balance)
+	summary.TopCategories = groupCategoryTotals(summary.TopCategories)
+	for i := range summary.TopCategories {
+		summary.TopCategories[i].Total = RoundMoney(summary.TopCategories[i].Total)
+	}
 	return summary, nil
 }
@@ -0,0 +1,487 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestCreateFavorite_DedupsSamePixRecipient(t *testing.T) {
+	store := newFakeBankingStore()
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	newFav := func(nickname string) *domain.Favorite {
+		return &domain.Favorite{
+			CustomerID:      testCustomerID,
+			Nickname:        nickname,
+			DestinationType: "pix",
+			PixKeyType:      "email",
+			PixKeyValue:     "fornecedor@example.com",
+			RecipientName:   "Fornecedor LTDA",
+		}
+	}
+
+	first, err := svc.CreateFavorite(context.Background(), newFav("Fornecedor"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := svc.CreateFavorite(context.Background(), newFav("Fornecedor de novo"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(store.favorites) != 1 {
+		t.Fatalf("expected 1 favorite row, got %d", len(store.favorites))
+	}
+	if second.ID != first.ID {
+		t.Errorf("expected existing favorite %q to be returned, got %q", first.ID, second.ID)
+	}
+}
+
+// staleFavoriteLookup misses the first lookups, as when concurrent creates
+// all check for the favorite before any of them has inserted it.
+type staleFavoriteLookup struct {
+	*fakeBankingStore
+	misses atomic.Int32
+}
+
+func (s *staleFavoriteLookup) FindFavorite(ctx context.Context, fav *domain.Favorite) (*domain.Favorite, error) {
+	if s.misses.Add(-1) >= 0 {
+		return nil, nil
+	}
+	return s.fakeBankingStore.FindFavorite(ctx, fav)
+}
+
+func TestCreateFavorite_ConcurrentCreatesReturnSameRow(t *testing.T) {
+	const callers = 2
+	store := &staleFavoriteLookup{fakeBankingStore: newFakeBankingStore()}
+	store.misses.Store(callers)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	results := make([]*domain.Favorite, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fav, err := svc.CreateFavorite(context.Background(), &domain.Favorite{
+				CustomerID:      testCustomerID,
+				Nickname:        "Fornecedor",
+				DestinationType: "pix",
+				PixKeyType:      "email",
+				PixKeyValue:     "fornecedor@example.com",
+				RecipientName:   "Fornecedor LTDA",
+			})
+			if err != nil {
+				t.Errorf("caller %d: unexpected error: %v", i, err)
+				return
+			}
+			results[i] = fav
+		}(i)
+	}
+	wg.Wait()
+
+	if len(store.favorites) != 1 {
+		t.Fatalf("expected 1 favorite row, got %d", len(store.favorites))
+	}
+	for i, fav := range results {
+		if fav == nil || fav.ID != store.favorites[0].ID {
+			t.Errorf("caller %d got %+v, want favorite %q", i, fav, store.favorites[0].ID)
+		}
+	}
+}
+
+func seedFinancialSummaryData(store *fakeBankingStore) {
+	seedCustomer(store, 5000)
+	now := time.Now()
+	store.statement = []domain.Transaction{
+		// current 30d window
+		{ID: "tx-1", Date: now.AddDate(0, 0, -2), Amount: 3000, Category: "sales"},
+		{ID: "tx-2", Date: now.AddDate(0, 0, -3), Amount: -1500, Category: "supplies"},
+		// previous 30d window
+		{ID: "tx-3", Date: now.AddDate(0, 0, -40), Amount: 2000, Category: "sales"},
+		{ID: "tx-4", Date: now.AddDate(0, 0, -45), Amount: -1000, Category: "supplies"},
+	}
+}
+
+func TestGetFinancialSummary_ComparisonUsesStoredSummary(t *testing.T) {
+	store := newFakeBankingStore()
+	seedFinancialSummaryData(store)
+	now := time.Now()
+	store.summaries = append(store.summaries, domain.SpendingSummary{
+		CustomerID:    testCustomerID,
+		PeriodType:    "monthly",
+		PeriodStart:   now.AddDate(0, 0, -60).Format("2006-01-02"),
+		PeriodEnd:     now.AddDate(0, 0, -31).Format("2006-01-02"),
+		TotalIncome:   1000,
+		TotalExpenses: 750,
+	})
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listTxCalls != 1 {
+		t.Errorf("expected only the current period to be listed, got %d ListTransactions calls", store.listTxCalls)
+	}
+	// expenses 1500 vs stored 750 → +100%; net 1500 vs stored 250 → +500%
+	if summary.Spending.ComparedToPreviousPeriod != 100 {
+		t.Errorf("expected spending +100%%, got %v", summary.Spending.ComparedToPreviousPeriod)
+	}
+	if summary.CashFlow.ComparedToPreviousPeriod != 500 {
+		t.Errorf("expected cash flow +500%%, got %v", summary.CashFlow.ComparedToPreviousPeriod)
+	}
+}
+
+func TestGetFinancialSummary_ComparisonComputedWithoutStoredSummary(t *testing.T) {
+	store := newFakeBankingStore()
+	seedFinancialSummaryData(store)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listTxCalls != 2 {
+		t.Errorf("expected the previous period to be computed from transactions, got %d ListTransactions calls", store.listTxCalls)
+	}
+	// expenses 1500 vs 1000 → +50%; net 1500 vs 1000 → +50%
+	if summary.Spending.ComparedToPreviousPeriod != 50 {
+		t.Errorf("expected spending +50%%, got %v", summary.Spending.ComparedToPreviousPeriod)
+	}
+	if summary.CashFlow.ComparedToPreviousPeriod != 50 {
+		t.Errorf("expected cash flow +50%%, got %v", summary.CashFlow.ComparedToPreviousPeriod)
+	}
+}
+
+func TestGetFinancialSummary_UsesPrecomputedSummary(t *testing.T) {
+	store := newFakeBankingStore()
+	seedFinancialSummaryData(store)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	refreshed, err := svc.RefreshSpendingSummary(context.Background(), testCustomerID, "monthly")
+	if err != nil {
+		t.Fatalf("refresh: unexpected error: %v", err)
+	}
+	if refreshed.TotalIncome != 3000 || refreshed.TotalExpenses != 1500 || refreshed.TransactionCount != 2 {
+		t.Errorf("refreshed summary = %+v, want income 3000, expenses 1500, 2 transactions", refreshed)
+	}
+	if len(store.summaries) != 1 {
+		t.Fatalf("expected the summary to be stored, got %d rows", len(store.summaries))
+	}
+
+	store.listTxCalls = 0
+	summary, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listTxCalls != 0 {
+		t.Errorf("expected the precomputed summary to be served, got %d ListTransactions calls", store.listTxCalls)
+	}
+	if summary.CashFlow.TotalIncome != 3000 || summary.Spending.TotalSpent != 1500 {
+		t.Errorf("cash flow = %+v, want income 3000 and expenses 1500", summary.CashFlow)
+	}
+	if summary.Spending.ComparedToPreviousPeriod != 50 || summary.CashFlow.ComparedToPreviousPeriod != 50 {
+		t.Errorf("comparisons = %v / %v, want +50%% / +50%%",
+			summary.Spending.ComparedToPreviousPeriod, summary.CashFlow.ComparedToPreviousPeriod)
+	}
+
+	if _, err := svc.RefreshSpendingSummary(context.Background(), testCustomerID, "quarterly"); err == nil {
+		t.Error("expected an error for an unknown period type")
+	}
+}
+
+func TestGetFinancialSummary_NewTransactionInvalidatesSummary(t *testing.T) {
+	store := newFakeBankingStore()
+	seedFinancialSummaryData(store)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	// The first call computes and stores the summary, the second reuses it
+	if _, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.listTxCalls = 0
+	if _, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listTxCalls != 0 {
+		t.Fatalf("expected the stored summary to be reused, got %d ListTransactions calls", store.listTxCalls)
+	}
+
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+	}); err != nil {
+		t.Fatalf("pix: unexpected error: %v", err)
+	}
+	if len(store.summaries) != 0 {
+		t.Fatalf("expected the summary to be dropped after a new transaction, got %d rows", len(store.summaries))
+	}
+
+	if _, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.listTxCalls == 0 {
+		t.Error("expected the summary to be recomputed from transactions")
+	}
+}
+
+func TestGetFinancialSummary_GroupsCategorySpellings(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	now := time.Now()
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: now, Amount: -120, Category: "Alimentação"},
+		{ID: "tx-2", Date: now, Amount: -80, Category: "alimentacao"},
+		{ID: "tx-3", Date: now, Amount: -50, Category: " ALIMENTACAO "},
+		{ID: "tx-4", Date: now, Amount: -40, Category: "Compras"},
+		{ID: "tx-5", Date: now, Amount: -10, Category: "compras"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetFinancialSummary(context.Background(), testCustomerID, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]domain.TopCategory)
+	for _, c := range summary.TopCategories {
+		got[c.Category] = c
+	}
+	if len(got) != 2 {
+		t.Fatalf("top categories = %+v, want alimentação and compras only", summary.TopCategories)
+	}
+	if food := got["alimentação"]; food.Amount != 250 || food.TransactionCount != 3 {
+		t.Errorf("alimentação = %+v, want 250 over 3 transactions", food)
+	}
+	if shopping := got["compras"]; shopping.Amount != 50 || shopping.TransactionCount != 2 {
+		t.Errorf("compras = %+v, want 50 over 2 transactions", shopping)
+	}
+}
+
+func TestGetPixLimitsSummary_RemainingWithPartialDailyUsage(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	nightlySingle, nightlyDaily := 1000.0, 2000.0
+	store.limits["pix"] = &domain.TransactionLimit{
+		CustomerID:         testCustomerID,
+		TransactionType:    "pix",
+		SingleLimit:        5000,
+		DailyLimit:         10000,
+		DailyUsed:          8750.50,
+		MonthlyLimit:       50000,
+		MonthlyUsed:        52000,
+		NightlySingleLimit: &nightlySingle,
+		NightlyDailyLimit:  &nightlyDaily,
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetPixLimitsSummary(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !summary.Configured || summary.SingleLimit != 5000 {
+		t.Errorf("summary = %+v, want configured with single limit 5000", summary)
+	}
+	if summary.Daily != (domain.LimitUsage{Limit: 10000, Used: 8750.50, Remaining: 1249.50}) {
+		t.Errorf("daily = %+v, want 1249.50 remaining", summary.Daily)
+	}
+	if summary.Monthly.Remaining != 0 {
+		t.Errorf("monthly remaining = %v, want clamped at 0 when over the limit", summary.Monthly.Remaining)
+	}
+	if summary.Nightly == nil || summary.Nightly.Remaining == nil || *summary.Nightly.Remaining != 1249.50 {
+		t.Errorf("nightly = %+v, want remaining capped by the daily headroom", summary.Nightly)
+	}
+
+	delete(store.limits, "pix")
+	svc.SetDefaultTransactionLimit("pix", 0, 0, 0)
+	summary, err = svc.GetPixLimitsSummary(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("without limits: unexpected error: %v", err)
+	}
+	if summary.Configured {
+		t.Errorf("summary = %+v, want configured=false without a PIX limit", summary)
+	}
+}
+
+func TestGetTransactionSummary_GroupsCategorySpellings(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	for i, tx := range []struct {
+		category string
+		amount   float64
+	}{{"Alimentação", -120}, {"alimentacao", -80}, {"Compras", -300}} {
+		store.transactions = append(store.transactions, map[string]any{
+			"id": fmt.Sprintf("tx-%d", i), "customer_id": testCustomerID, "amount": tx.amount, "category": tx.category,
+		})
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	summary, err := svc.GetTransactionSummary(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.CategoryTotal{{Category: "compras", Total: 300}, {Category: "alimentação", Total: 200}}
+	if len(summary.TopCategories) != len(want) {
+		t.Fatalf("top categories = %+v, want %+v", summary.TopCategories, want)
+	}
+	for i := range want {
+		if summary.TopCategories[i] != want[i] {
+			t.Errorf("top category %d = %+v, want %+v", i, summary.TopCategories[i], want[i])
+		}
+	}
+}
+
+func TestGetNotification_OwnershipAndMarkRead(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.notifs = []domain.Notification{
+		{ID: "notif-own", CustomerID: testCustomerID, Title: "Pix recebido", Body: "Você recebeu R$ 150,00"},
+		{ID: "notif-other", CustomerID: "cust-999", Title: "Fatura fechada"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	var notFound *domain.ErrNotFound
+	if _, err := svc.GetNotification(context.Background(), testCustomerID, "notif-other", true); !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrNotFound for another customer's notification, got %v", err)
+	}
+	if store.notifs[1].IsRead {
+		t.Error("another customer's notification was marked read")
+	}
+
+	notif, err := svc.GetNotification(context.Background(), testCustomerID, "notif-own", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notif.Body != "Você recebeu R$ 150,00" || notif.IsRead {
+		t.Errorf("notification = %+v, want full body, still unread", notif)
+	}
+
+	notif, err = svc.GetNotification(context.Background(), testCustomerID, "notif-own", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !notif.IsRead || notif.ReadAt == nil || !store.notifs[0].IsRead {
+		t.Errorf("markRead: returned read=%v, stored read=%v; want both read", notif.IsRead, store.notifs[0].IsRead)
+	}
+}
+
+func TestMarkNotificationsRead_OnlyTheGivenOwnedIDs(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.notifs = []domain.Notification{
+		{ID: "notif-1", CustomerID: testCustomerID, Title: "Pix recebido"},
+		{ID: "notif-2", CustomerID: testCustomerID, Title: "Fatura fechada"},
+		{ID: "notif-3", CustomerID: testCustomerID, Title: "Boleto vence amanhã"},
+		{ID: "notif-other", CustomerID: "cust-999", Title: "Pix enviado"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	updated, err := svc.MarkNotificationsRead(context.Background(), testCustomerID, []string{"notif-1", "notif-3", "notif-3", "notif-other"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("updated = %d, want 2", updated)
+	}
+	if !store.notifs[0].IsRead || !store.notifs[2].IsRead {
+		t.Errorf("notif-1 read=%v, notif-3 read=%v; want both read", store.notifs[0].IsRead, store.notifs[2].IsRead)
+	}
+	if store.notifs[1].IsRead {
+		t.Error("notif-2 was not in the batch but was marked read")
+	}
+	if store.notifs[3].IsRead {
+		t.Error("another customer's notification was marked read")
+	}
+
+	var verr *domain.ErrValidation
+	if _, err := svc.MarkNotificationsRead(context.Background(), testCustomerID, nil); !errors.As(err, &verr) {
+		t.Errorf("empty ids: err = %v, want ErrValidation", err)
+	}
+}
+
+func TestCreateNotification_RespectsPreferences(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	prefs, err := svc.GetNotificationPreferences(ctx, testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := prefs["card_purchase"]; !p.Push || !p.Email || !p.InApp {
+		t.Errorf("default preferences = %+v, want every channel on", p)
+	}
+
+	_, err = svc.UpdateNotificationPreferences(ctx, testCustomerID, domain.NotificationPreferences{
+		"card_purchase": {Push: false, Email: false, InApp: false},
+		"pix_received":  {Push: true, Email: false, InApp: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created, err := svc.CreateNotification(ctx, &domain.Notification{CustomerID: testCustomerID, Type: "card_purchase", Title: "Compra aprovada"},
+		domain.NotificationChannelPush, domain.NotificationChannelInApp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("suppressed type created %d notifications, want none", len(created))
+	}
+
+	created, err = svc.CreateNotification(ctx, &domain.Notification{CustomerID: testCustomerID, Type: "pix_received", Title: "Pix recebido"},
+		domain.NotificationChannelPush, domain.NotificationChannelEmail, domain.NotificationChannelInApp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var channels []string
+	for _, n := range created {
+		channels = append(channels, n.Channel)
+	}
+	if len(channels) != 2 || channels[0] != domain.NotificationChannelPush || channels[1] != domain.NotificationChannelInApp {
+		t.Errorf("pix_received channels = %v, want [push in_app] (email suppressed)", channels)
+	}
+
+	if _, err := svc.CreateNotification(ctx, &domain.Notification{CustomerID: testCustomerID, Type: "bill_due", Title: "Boleto vence amanhã"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.notifs) != 3 {
+		t.Errorf("stored %d notifications, want 3", len(store.notifs))
+	}
+
+	prefs, err = svc.GetNotificationPreferences(ctx, testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prefs["pix_received"].Email || !prefs["bill_due"].Email {
+		t.Errorf("preferences after update = %+v / %+v", prefs["pix_received"], prefs["bill_due"])
+	}
+}
+
+func TestUpdateNotificationPreferences_RejectsUnknownType(t *testing.T) {
+	store := newFakeBankingStore()
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	var validation *domain.ErrValidation
+	_, err := svc.UpdateNotificationPreferences(context.Background(), testCustomerID, domain.NotificationPreferences{"marketing": {}})
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	if store.notifPrefs != nil {
+		t.Error("preferences stored despite the validation error")
+	}
+}
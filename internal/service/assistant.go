@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -25,6 +26,14 @@ type Assistant struct {
 	cache              port.Cache[any]
 	metrics            *observability.Metrics
 	logger             *zap.Logger
+
+	bank *BankingService // optional; backs the agent's tool calls
+
+	fallbackEnabled bool // answer from local data when the agent is unavailable
+
+	maxInputLength int                  // longest message forwarded to the agent; 0 disables
+	tokenBudget    int                  // agent tokens per customer per day; 0 disables
+	tokenUsage     port.TokenUsageStore // agent tokens used per customer per day
 }
 
 // NewAssistant creates the assistant service with all dependencies injected.
@@ -43,7 +52,20 @@ func NewAssistant(
 		cache:              cache,
 		metrics:            metrics,
 		logger:             logger,
+		maxInputLength:     DefaultAssistantMaxInputLength,
+		tokenBudget:        DefaultAssistantDailyTokenBudget,
+		tokenUsage:         newMemoryTokenUsage(),
+	}
+}
+
+// PingAgent probes the agent API. ok is false when the agent client does
+// not support probing, in which case err is always nil.
+func (a *Assistant) PingAgent(ctx context.Context) (ok bool, err error) {
+	pinger, ok := a.agentClient.(port.Pinger)
+	if !ok {
+		return false, nil
 	}
+	return true, pinger.Ping(ctx)
 }
 
 // GetProfile fetches the customer profile (used by the dedicated /profile route).
@@ -88,9 +110,17 @@ func (a *Assistant) GetAssistantResponse(ctx context.Context, customerID string,
 	defer span.End()
 	span.SetAttributes(attribute.String("customer.id", customerID))
 
+	if err := a.checkInputLength(ctx, message); err != nil {
+		return nil, err
+	}
+	if err := a.checkTokenBudget(ctx, customerID); err != nil {
+		return nil, err
+	}
+
 	start := time.Now()
 	defer func() {
 		a.metrics.RecordRequestDuration("assistant", time.Since(start))
+		a.metrics.RecordAssistantLatency(time.Since(start))
 	}()
 
 	/* Step 1: Fetch profile + transactions concurrently */
@@ -153,26 +183,79 @@ func (a *Assistant) GetAssistantResponse(ctx context.Context, customerID string,
 		Query:        message,
 	}
 
-	agentStart := time.Now()
-	agentResp, err := a.agentClient.Call(ctx, agentReq)
-	a.metrics.RecordRequestDuration("agent", time.Since(agentStart))
-
+	agentResp, err := a.callAgent(ctx, agentReq)
 	if err != nil {
-		a.logger.Error("agent call failed",
-			zap.String("customer_id", customerID),
-			zap.Error(err),
-		)
-		a.metrics.IncrExternalError("agent")
-		return nil, fmt.Errorf("agent call: %w", err)
+		a.metrics.IncrRequest("error")
+		if !a.canFallback(ctx) {
+			return nil, err
+		}
+		return a.fallbackResult(ctx, customerID, profile, transactions, err), nil
 	}
+	a.metrics.IncrRequest("success")
 
-	/* Step 3: Record token metrics */
-	a.metrics.RecordTokens(agentResp.TokensUsed.PromptTokens, agentResp.TokensUsed.CompletionTokens)
+	/* Step 3: Run the tools the agent asked for and hand the results back */
+	var toolResults []domain.ToolResult
+	for round := 0; len(agentResp.ToolCalls) > 0 && round < maxToolRounds; round++ {
+		for _, call := range agentResp.ToolCalls {
+			toolResults = append(toolResults, a.executeTool(ctx, customerID, call))
+		}
+		agentReq.ToolResults = toolResults
+		if err := a.checkTokenBudget(ctx, customerID); err != nil {
+			return nil, err
+		}
+		if agentResp, err = a.callAgent(ctx, agentReq); err != nil {
+			if !a.canFallback(ctx) {
+				return nil, err
+			}
+			return a.fallbackResult(ctx, customerID, profile, transactions, err), nil
+		}
+	}
+	if len(agentResp.ToolCalls) > 0 {
+		err := &domain.ErrExternalService{Service: "agent", Err: fmt.Errorf("still requesting tools after %d rounds", maxToolRounds)}
+		if !a.canFallback(ctx) {
+			return nil, err
+		}
+		return a.fallbackResult(ctx, customerID, profile, transactions, err), nil
+	}
+	agentResp.ToolsExecuted = mergeToolNames(agentResp.ToolsExecuted, toolResults)
 
 	return &domain.InternalAssistantResult{
 		CustomerID:     customerID,
 		Profile:        profile,
 		Recommendation: agentResp,
+		ToolResults:    toolResults,
 		ProcessedAt:    time.Now(),
+
+		TokenBudgetRemaining: a.remainingTokens(ctx, customerID),
 	}, nil
 }
+
+// callAgent calls the agent once, recording latency, errors and tokens.
+func (a *Assistant) callAgent(ctx context.Context, req *domain.AgentRequest) (*domain.AgentResponse, error) {
+	agentStart := time.Now()
+	resp, err := a.agentClient.Call(ctx, req)
+	a.metrics.RecordRequestDuration("agent", time.Since(agentStart))
+
+	if err != nil {
+		var malformed *domain.ErrMalformedResponse
+		if errors.As(err, &malformed) {
+			a.logger.Error("agent returned a malformed response",
+				zap.String("customer_id", req.CustomerID),
+				zap.String("body", malformed.Body),
+				zap.Error(malformed.Err),
+			)
+			a.metrics.IncrMalformedResponse("agent")
+		} else {
+			a.logger.Error("agent call failed",
+				zap.String("customer_id", req.CustomerID),
+				zap.Error(err),
+			)
+		}
+		a.metrics.IncrExternalError("agent")
+		return nil, fmt.Errorf("agent call: %w", err)
+	}
+
+	a.metrics.RecordTokens(resp.TokensUsed.PromptTokens, resp.TokensUsed.CompletionTokens)
+	a.recordTokens(ctx, req.CustomerID, resp.TokensUsed)
+	return resp, nil
+}
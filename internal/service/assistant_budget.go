@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+/*
+ * Assistant — input length and daily token budget
+ *
+ * Every message costs agent tokens, so the assistant refuses messages longer
+ * than the configured maximum and stops calling the agent for a customer
+ * once the tokens used today reach the daily budget. The budget is checked
+ * before every agent call, tool rounds included. Usage lives in a
+ * TokenUsageStore shared by every instance (in memory when none is set) and
+ * starts over at midnight in the bank's time zone.
+ */
+
+// DefaultAssistantMaxInputLength is the longest message, in characters,
+// forwarded to the agent when none is configured.
+const DefaultAssistantMaxInputLength = 4000
+
+// DefaultAssistantDailyTokenBudget is how many agent tokens a customer may
+// use per day when no budget is configured.
+const DefaultAssistantDailyTokenBudget = 100000
+
+// SetAssistantLimits overrides the maximum message length and the daily
+// token budget per customer. Zero disables the respective check; negative
+// values keep the current one.
+func (a *Assistant) SetAssistantLimits(maxInputLength, dailyTokenBudget int) {
+	if maxInputLength >= 0 {
+		a.maxInputLength = maxInputLength
+	}
+	if dailyTokenBudget >= 0 {
+		a.tokenBudget = dailyTokenBudget
+	}
+}
+
+// SetTokenUsageStore keeps token usage in store instead of in memory, so the
+// daily budget holds across instances and restarts.
+func (a *Assistant) SetTokenUsageStore(store port.TokenUsageStore) {
+	a.tokenUsage = store
+}
+
+// ResetTokenBudget clears the tokens the customer used today, lifting a
+// budget block before midnight.
+func (a *Assistant) ResetTokenBudget(ctx context.Context, customerID string) error {
+	return a.tokenUsage.ResetTokenUsage(ctx, customerID, tokenDay(time.Now()))
+}
+
+// checkInputLength refuses a message longer than the configured maximum.
+func (a *Assistant) checkInputLength(ctx context.Context, message string) error {
+	length := utf8.RuneCountInString(message)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("assistant.input_length", length))
+
+	if a.maxInputLength > 0 && length > a.maxInputLength {
+		return &domain.ErrValidation{Field: "message", Message: fmt.Sprintf("must be at most %d characters", a.maxInputLength)}
+	}
+	return nil
+}
+
+// checkTokenBudget refuses another agent call for a customer whose budget
+// for today is spent. A usage store failure lets the call through.
+func (a *Assistant) checkTokenBudget(ctx context.Context, customerID string) error {
+	remaining := a.remainingTokens(ctx, customerID)
+	if remaining == nil || *remaining > 0 {
+		return nil
+	}
+	a.metrics.IncrRequest("token_budget_exceeded")
+	trace.SpanFromContext(ctx).AddEvent("assistant.token_budget_exceeded")
+	a.logger.Warn("assistant token budget exceeded", zap.String("customer_id", customerID))
+	now := time.Now().In(bankLocation)
+	return &domain.ErrRateLimited{
+		Message:    "Limite diário de uso do assistente atingido. Tente novamente amanhã.",
+		RetryAfter: nextMidnight(now).Sub(now),
+	}
+}
+
+// recordTokens adds an agent call's tokens to the customer's usage today.
+func (a *Assistant) recordTokens(ctx context.Context, customerID string, usage domain.TokenUsage) {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	used, err := a.tokenUsage.AddTokenUsage(ctx, customerID, tokenDay(time.Now()), total)
+	if err != nil {
+		a.logger.Error("failed to record agent tokens",
+			zap.String("customer_id", customerID),
+			zap.Int("tokens", total),
+			zap.Error(err),
+		)
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("assistant.tokens_used_today", used))
+	a.logger.Info("agent tokens used",
+		zap.String("customer_id", customerID),
+		zap.Int("prompt_tokens", usage.PromptTokens),
+		zap.Int("completion_tokens", usage.CompletionTokens),
+		zap.Int("tokens_used_today", used),
+	)
+}
+
+// remainingTokens is the customer's budget left today, or nil when no
+// budget is enforced or the usage cannot be read.
+func (a *Assistant) remainingTokens(ctx context.Context, customerID string) *int {
+	if a.tokenBudget == 0 {
+		return nil
+	}
+	used, err := a.tokenUsage.GetTokenUsage(ctx, customerID, tokenDay(time.Now()))
+	if err != nil {
+		a.logger.Warn("failed to read agent token usage",
+			zap.String("customer_id", customerID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	remaining := max(0, a.tokenBudget-used)
+	return &remaining
+}
+
+// tokenDay is the bank-time date token usage is counted under.
+func tokenDay(now time.Time) string {
+	return now.In(bankLocation).Format("2006-01-02")
+}
+
+func nextMidnight(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// memoryTokenUsage is the per-instance TokenUsageStore used when none is
+// set. It only keeps the current day.
+type memoryTokenUsage struct {
+	mu   sync.Mutex
+	day  string
+	used map[string]int // by customer ID
+}
+
+func newMemoryTokenUsage() *memoryTokenUsage {
+	return &memoryTokenUsage{used: make(map[string]int)}
+}
+
+// rollover must be called with m.mu held; it drops other days' counts.
+func (m *memoryTokenUsage) rollover(day string) {
+	if day != m.day {
+		m.day = day
+		clear(m.used)
+	}
+}
+
+func (m *memoryTokenUsage) AddTokenUsage(_ context.Context, customerID, day string, tokens int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover(day)
+	m.used[customerID] += tokens
+	return m.used[customerID], nil
+}
+
+func (m *memoryTokenUsage) GetTokenUsage(_ context.Context, customerID, day string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover(day)
+	return m.used[customerID], nil
+}
+
+func (m *memoryTokenUsage) ResetTokenUsage(_ context.Context, customerID, day string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover(day)
+	delete(m.used, customerID)
+	return nil
+}
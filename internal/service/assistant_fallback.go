@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+/*
+ * Assistant fallback — deterministic answer when the agent is unavailable
+ */
+
+// fallbackTopCategories is how many spending categories the fallback lists.
+const fallbackTopCategories = 3
+
+// SetAgentFallback enables or disables the deterministic answer served when
+// the agent call fails (error, open circuit or malformed response). When
+// disabled the error is returned to the caller.
+func (a *Assistant) SetAgentFallback(enabled bool) {
+	a.fallbackEnabled = enabled
+}
+
+// canFallback reports whether a failed agent call is answered with the
+// fallback: only when it is enabled and the caller is still waiting.
+func (a *Assistant) canFallback(ctx context.Context) bool {
+	return a.fallbackEnabled && ctx.Err() == nil
+}
+
+// fallbackResult wraps the fallback answer for a failed agent call.
+func (a *Assistant) fallbackResult(ctx context.Context, customerID string, profile *domain.CustomerProfile, transactions []domain.Transaction, err error) *domain.InternalAssistantResult {
+	a.metrics.IncrFallback("agent")
+	a.logger.Warn("agent unavailable, serving fallback answer",
+		zap.String("customer_id", customerID),
+		zap.Error(err))
+	return &domain.InternalAssistantResult{
+		CustomerID:     customerID,
+		Profile:        profile,
+		Recommendation: a.fallbackResponse(ctx, customerID, profile, transactions),
+		ProcessedAt:    time.Now(),
+
+		TokenBudgetRemaining: a.remainingTokens(ctx, customerID),
+	}
+}
+
+// fallbackResponse builds an answer from the data already fetched for the
+// agent: the account balance (when banking tools are configured) or the net
+// of the transactions, plus the top spending categories.
+func (a *Assistant) fallbackResponse(ctx context.Context, customerID string, profile *domain.CustomerProfile, transactions []domain.Transaction) *domain.AgentResponse {
+	var credits, debits float64
+	byCategory := make(map[string]float64)
+	for _, tx := range transactions {
+		if tx.Amount >= 0 {
+			credits += tx.Amount
+			continue
+		}
+		debits += -tx.Amount
+		category := tx.Category
+		if category == "" {
+			category = "outros"
+		}
+		byCategory[category] += -tx.Amount
+	}
+
+	top := make([]domain.CategoryTotal, 0, len(byCategory))
+	for category, total := range byCategory {
+		top = append(top, domain.CategoryTotal{Category: category, Total: total})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Total != top[j].Total {
+			return top[i].Total > top[j].Total
+		}
+		return top[i].Category < top[j].Category
+	})
+	if len(top) > fallbackTopCategories {
+		top = top[:fallbackTopCategories]
+	}
+
+	var b strings.Builder
+	b.WriteString("No momento não consigo gerar uma análise completa, mas aqui vai um resumo da sua conta")
+	if profile != nil && profile.Name != "" {
+		fmt.Fprintf(&b, ", %s", profile.Name)
+	}
+	b.WriteString(".\n")
+
+	if a.bank != nil {
+		if account, err := a.bank.GetPrimaryAccount(ctx, customerID); err == nil {
+			fmt.Fprintf(&b, "Saldo atual: %s (disponível: %s).\n", FormatBRL(account.Balance), FormatBRL(account.AvailableBalance))
+		}
+	}
+	fmt.Fprintf(&b, "Movimentação recente: %s em entradas e %s em saídas (saldo do período: %s).\n", FormatBRL(credits), FormatBRL(debits), FormatBRL(credits-debits))
+
+	if len(top) > 0 {
+		b.WriteString("Maiores categorias de gasto:")
+		for i, c := range top {
+			fmt.Fprintf(&b, " %d. %s (%s)", i+1, c.Category, FormatBRL(c.Total))
+		}
+		b.WriteString(".\n")
+	}
+	b.WriteString("Tente novamente em alguns instantes para recomendações personalizadas.")
+
+	return &domain.AgentResponse{
+		Answer:        b.String(),
+		Reasoning:     "Agente indisponível; resposta gerada a partir do perfil e das transações.",
+		Confidence:    0,
+		ToolsExecuted: []string{"fallback"},
+	}
+}
@@ -3,12 +3,19 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/cache"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/client"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
 
 	"go.uber.org/zap"
@@ -156,3 +163,372 @@ func TestGetAssistantResponse_ContextCancelled(t *testing.T) {
 		t.Fatal("expected error for cancelled context, got nil")
 	}
 }
+
+// toolCallingAgent asks for the given tool on the first call and answers
+// with whatever it received on the second.
+type toolCallingAgent struct {
+	call     domain.ToolCall
+	requests []domain.AgentRequest
+}
+
+func (m *toolCallingAgent) Call(_ context.Context, req *domain.AgentRequest) (*domain.AgentResponse, error) {
+	m.requests = append(m.requests, *req)
+	if len(req.ToolResults) == 0 {
+		return &domain.AgentResponse{ToolCalls: []domain.ToolCall{m.call}}, nil
+	}
+	return &domain.AgentResponse{Answer: "Seu saldo foi consultado."}, nil
+}
+
+func newToolAssistant(agent *toolCallingAgent) *service.Assistant {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1234.56)
+
+	svc := service.NewAssistant(
+		&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: testCustomerID}},
+		&mockTransactionsClient{},
+		agent,
+		cache.New[any](5*time.Minute),
+		observability.NewMetrics(),
+		zap.NewNop(),
+	)
+	svc.SetBankingTools(service.NewBankingService(store, observability.NewMetrics(), zap.NewNop()))
+	return svc
+}
+
+func TestGetAssistantResponse_ExecutesGetBalanceTool(t *testing.T) {
+	agent := &toolCallingAgent{call: domain.ToolCall{ID: "call-1", Name: "get_balance"}}
+	svc := newToolAssistant(agent)
+
+	ctx := service.WithToolSubject(context.Background(), testCustomerID)
+	result, err := svc.GetAssistantResponse(ctx, testCustomerID, "Qual meu saldo?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(agent.requests) != 2 {
+		t.Fatalf("expected the agent to be called twice, got %d", len(agent.requests))
+	}
+	fed := agent.requests[1].ToolResults
+	if len(fed) != 1 || fed[0].CallID != "call-1" || fed[0].Error != "" {
+		t.Fatalf("unexpected tool results sent to agent: %+v", fed)
+	}
+	balance, _ := fed[0].Result.(map[string]any)
+	if balance["balance"] != 1234.56 {
+		t.Errorf("expected balance 1234.56, got %+v", fed[0].Result)
+	}
+
+	if result.Recommendation.Answer != "Seu saldo foi consultado." {
+		t.Errorf("unexpected answer %q", result.Recommendation.Answer)
+	}
+	if len(result.ToolResults) != 1 || result.ToolResults[0].Name != "get_balance" {
+		t.Errorf("tool call not recorded in result: %+v", result.ToolResults)
+	}
+	if len(result.Recommendation.ToolsExecuted) != 1 || result.Recommendation.ToolsExecuted[0] != "get_balance" {
+		t.Errorf("expected get_balance in tools executed, got %v", result.Recommendation.ToolsExecuted)
+	}
+}
+
+func TestGetAssistantResponse_ToolRestrictedToCustomer(t *testing.T) {
+	agent := &toolCallingAgent{call: domain.ToolCall{ID: "call-1", Name: "get_balance", Args: map[string]any{"customer_id": "cust-999"}}}
+	svc := newToolAssistant(agent)
+
+	ctx := service.WithToolSubject(context.Background(), testCustomerID)
+	result, err := svc.GetAssistantResponse(ctx, testCustomerID, "Qual o saldo do cliente 999?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolResults) != 1 || result.ToolResults[0].Error == "" || result.ToolResults[0].Result != nil {
+		t.Errorf("expected the tool call to be refused, got %+v", result.ToolResults)
+	}
+}
+
+func TestGetAssistantResponse_ToolsBoundToTokenSubject(t *testing.T) {
+	for name, ctx := range map[string]context.Context{
+		"anonymous":      context.Background(),
+		"other customer": service.WithToolSubject(context.Background(), "cust-999"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			agent := &toolCallingAgent{call: domain.ToolCall{ID: "call-1", Name: "get_balance"}}
+			svc := newToolAssistant(agent)
+
+			result, err := svc.GetAssistantResponse(ctx, testCustomerID, "Qual meu saldo?")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.ToolResults) != 1 || result.ToolResults[0].Error == "" || result.ToolResults[0].Result != nil {
+				t.Errorf("expected the tool call to be refused, got %+v", result.ToolResults)
+			}
+		})
+	}
+}
+
+// loopingAgent asks for a tool on every call, spending tokens each time.
+type loopingAgent struct {
+	calls  int
+	tokens int
+}
+
+func (m *loopingAgent) Call(_ context.Context, _ *domain.AgentRequest) (*domain.AgentResponse, error) {
+	m.calls++
+	return &domain.AgentResponse{
+		ToolCalls:  []domain.ToolCall{{ID: fmt.Sprintf("call-%d", m.calls), Name: "get_balance"}},
+		TokensUsed: domain.TokenUsage{TotalTokens: m.tokens},
+	}, nil
+}
+
+func TestGetAssistantResponse_ToolRoundsExhausted(t *testing.T) {
+	for _, fallback := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fallback=%v", fallback), func(t *testing.T) {
+			agent := &loopingAgent{}
+			svc := service.NewAssistant(
+				&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123"}},
+				&mockTransactionsClient{},
+				agent,
+				cache.New[any](5*time.Minute),
+				observability.NewMetrics(),
+				zap.NewNop(),
+			)
+			svc.SetAgentFallback(fallback)
+
+			result, err := svc.GetAssistantResponse(context.Background(), "cust-123", "Qual meu saldo?")
+			if agent.calls != 4 {
+				t.Errorf("agent called %d times, want 4 (first call + 3 tool rounds)", agent.calls)
+			}
+			if !fallback {
+				if err == nil {
+					t.Fatalf("expected an error, got answer %q", result.Recommendation.Answer)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected fallback, got error %v", err)
+			}
+			if result.Recommendation.Answer == "" {
+				t.Error("expected the fallback answer, got an empty one")
+			}
+		})
+	}
+}
+
+func TestGetAssistantResponse_FallbackSummarizesTransactions(t *testing.T) {
+	svc := service.NewAssistant(
+		&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123", Name: "Empresa XPTO"}},
+		&mockTransactionsClient{transactions: []domain.Transaction{
+			{ID: "tx-1", Amount: 5000, Category: "revenue"},
+			{ID: "tx-2", Amount: -1200, Category: "aluguel"},
+			{ID: "tx-3", Amount: -300, Category: "fornecedores"},
+			{ID: "tx-4", Amount: -200, Category: "fornecedores"},
+		}},
+		&mockAgentClient{err: errors.New("circuit open")},
+		cache.New[any](5*time.Minute),
+		observability.NewMetrics(),
+		zap.NewNop(),
+	)
+	svc.SetAgentFallback(true)
+
+	result, err := svc.GetAssistantResponse(context.Background(), "cust-123", "test")
+	if err != nil {
+		t.Fatalf("expected fallback, got error %v", err)
+	}
+	answer := result.Recommendation.Answer
+	for _, want := range []string{"R$ 5.000,00 em entradas", "R$ 1.700,00 em saídas", "1. aluguel (R$ 1.200,00)", "2. fornecedores (R$ 500,00)"} {
+		if !strings.Contains(answer, want) {
+			t.Errorf("fallback answer missing %q:\n%s", want, answer)
+		}
+	}
+}
+
+func TestGetAssistantResponse_TokenBudget(t *testing.T) {
+	agent := &mockAgentClient{response: &domain.AgentResponse{
+		Answer:     "Tudo certo.",
+		TokensUsed: domain.TokenUsage{PromptTokens: 400, CompletionTokens: 200, TotalTokens: 600},
+	}}
+	svc := service.NewAssistant(
+		&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123"}},
+		&mockTransactionsClient{},
+		agent,
+		cache.New[any](5*time.Minute),
+		observability.NewMetrics(),
+		zap.NewNop(),
+	)
+	svc.SetAssistantLimits(-1, 1000)
+	ctx := context.Background()
+
+	first, err := svc.GetAssistantResponse(ctx, "cust-123", "Como está meu caixa?")
+	if err != nil {
+		t.Fatalf("first call: unexpected error: %v", err)
+	}
+	if first.TokenBudgetRemaining == nil || *first.TokenBudgetRemaining != 400 {
+		t.Errorf("remaining after first call = %v, want 400", first.TokenBudgetRemaining)
+	}
+
+	// The second call starts within budget and spends past it
+	second, err := svc.GetAssistantResponse(ctx, "cust-123", "E as despesas?")
+	if err != nil {
+		t.Fatalf("second call: unexpected error: %v", err)
+	}
+	if *second.TokenBudgetRemaining != 0 {
+		t.Errorf("remaining after second call = %d, want 0", *second.TokenBudgetRemaining)
+	}
+
+	_, err = svc.GetAssistantResponse(ctx, "cust-123", "E agora?")
+	var rateLimited *domain.ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("third call: expected ErrRateLimited, got %v", err)
+	}
+	if rateLimited.RetryAfter <= 0 || rateLimited.RetryAfter > 24*time.Hour {
+		t.Errorf("retry after = %v, want until midnight", rateLimited.RetryAfter)
+	}
+
+	// Other customers keep their own budget
+	if _, err := svc.GetAssistantResponse(ctx, "cust-456", "Oi"); err != nil {
+		t.Errorf("other customer: unexpected error: %v", err)
+	}
+
+	if err := svc.ResetTokenBudget(ctx, "cust-123"); err != nil {
+		t.Fatalf("reset: unexpected error: %v", err)
+	}
+	if _, err := svc.GetAssistantResponse(ctx, "cust-123", "E agora?"); err != nil {
+		t.Errorf("after reset: unexpected error: %v", err)
+	}
+}
+
+// mapTokenUsage is a TokenUsageStore two assistants can share, standing in
+// for the database.
+type mapTokenUsage struct {
+	mu   sync.Mutex
+	used map[string]int // by customer ID and day
+}
+
+func (m *mapTokenUsage) AddTokenUsage(_ context.Context, customerID, day string, tokens int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.used[customerID+"/"+day] += tokens
+	return m.used[customerID+"/"+day], nil
+}
+
+func (m *mapTokenUsage) GetTokenUsage(_ context.Context, customerID, day string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.used[customerID+"/"+day], nil
+}
+
+func (m *mapTokenUsage) ResetTokenUsage(_ context.Context, customerID, day string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.used, customerID+"/"+day)
+	return nil
+}
+
+func TestGetAssistantResponse_TokenBudgetSharedAcrossInstances(t *testing.T) {
+	usage := &mapTokenUsage{used: make(map[string]int)}
+	newInstance := func() *service.Assistant {
+		svc := service.NewAssistant(
+			&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123"}},
+			&mockTransactionsClient{},
+			&mockAgentClient{response: &domain.AgentResponse{Answer: "Ok.", TokensUsed: domain.TokenUsage{TotalTokens: 600}}},
+			cache.New[any](5*time.Minute),
+			observability.NewMetrics(),
+			zap.NewNop(),
+		)
+		svc.SetAssistantLimits(-1, 1000)
+		svc.SetTokenUsageStore(usage)
+		return svc
+	}
+	first, second := newInstance(), newInstance()
+	ctx := context.Background()
+
+	if _, err := first.GetAssistantResponse(ctx, "cust-123", "Oi"); err != nil {
+		t.Fatalf("first instance: unexpected error: %v", err)
+	}
+	if _, err := second.GetAssistantResponse(ctx, "cust-123", "Oi"); err != nil {
+		t.Fatalf("second instance: unexpected error: %v", err)
+	}
+
+	// Both instances spent the same budget, so neither calls the agent again
+	for name, svc := range map[string]*service.Assistant{"first": first, "second": second} {
+		_, err := svc.GetAssistantResponse(ctx, "cust-123", "E agora?")
+		var rateLimited *domain.ErrRateLimited
+		if !errors.As(err, &rateLimited) {
+			t.Errorf("%s instance: expected ErrRateLimited, got %v", name, err)
+		}
+	}
+}
+
+func TestGetAssistantResponse_TokenBudgetCheckedBeforeToolRounds(t *testing.T) {
+	agent := &loopingAgent{tokens: 600}
+	svc := service.NewAssistant(
+		&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123"}},
+		&mockTransactionsClient{},
+		agent,
+		cache.New[any](5*time.Minute),
+		observability.NewMetrics(),
+		zap.NewNop(),
+	)
+	svc.SetAssistantLimits(-1, 1000)
+
+	_, err := svc.GetAssistantResponse(context.Background(), "cust-123", "Qual meu saldo?")
+	var rateLimited *domain.ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if agent.calls != 2 {
+		t.Errorf("agent called %d times, want 2 (stopped once the budget was spent)", agent.calls)
+	}
+}
+
+func TestGetAssistantResponse_RejectsLongInput(t *testing.T) {
+	agent := &mockAgentClient{err: errors.New("agent must not be called")}
+	svc := service.NewAssistant(
+		&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123"}},
+		&mockTransactionsClient{},
+		agent,
+		cache.New[any](5*time.Minute),
+		observability.NewMetrics(),
+		zap.NewNop(),
+	)
+	svc.SetAssistantLimits(10, -1)
+
+	_, err := svc.GetAssistantResponse(context.Background(), "cust-123", strings.Repeat("á", 11))
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "message" {
+		t.Fatalf("expected ErrValidation on message, got %v", err)
+	}
+}
+
+func TestGetAssistantResponse_MalformedAgentResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"answer": "Seu caixa est`))
+	}))
+	defer srv.Close()
+
+	for _, fallback := range []bool{true, false} {
+		t.Run(fmt.Sprintf("fallback=%v", fallback), func(t *testing.T) {
+			svc := service.NewAssistant(
+				&mockProfileClient{profile: &domain.CustomerProfile{CustomerID: "cust-123", Name: "Empresa XPTO"}},
+				&mockTransactionsClient{transactions: []domain.Transaction{{ID: "tx-1", Amount: 5000, Category: "revenue"}}},
+				client.NewAgentClient(srv.Client(), srv.URL, resilience.NewCircuitBreaker(t.Name()), resilience.Config{}),
+				cache.New[any](5*time.Minute),
+				observability.NewMetrics(),
+				zap.NewNop(),
+			)
+			svc.SetAgentFallback(fallback)
+
+			result, err := svc.GetAssistantResponse(context.Background(), "cust-123", "Como está meu caixa?")
+			if !fallback {
+				var external *domain.ErrExternalService
+				if !errors.As(err, &external) {
+					t.Fatalf("expected ErrExternalService with the fallback off, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected fallback, got error %v", err)
+			}
+			if tools := result.Recommendation.ToolsExecuted; len(tools) != 1 || tools[0] != "fallback" {
+				t.Errorf("tools executed = %v, want the fallback answer", tools)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Assistant tools — actions the agent can ask the BFA to perform
+ */
+
+// maxToolRounds bounds how many times the agent can ask for more tools. An
+// agent still asking after that gets the fallback answer (or an error).
+const maxToolRounds = 3
+
+// defaultRecentPixLimit is used by list_recent_pix when no limit is given.
+const defaultRecentPixLimit = 5
+
+// assistantTool runs one action for the authenticated customer.
+type assistantTool func(ctx context.Context, bank *BankingService, customerID string, args map[string]any) (any, error)
+
+// assistantTools maps the tool names the agent knows to their implementation.
+var assistantTools = map[string]assistantTool{
+	"get_balance":     getBalanceTool,
+	"list_recent_pix": listRecentPixTool,
+}
+
+// toolSubjectKey carries the customer authenticated by the access token.
+type toolSubjectKey struct{}
+
+// WithToolSubject returns a copy of ctx authenticated as customerID, the
+// subject of the request's access token. Banking tools only run for the
+// customer a request is authenticated as.
+func WithToolSubject(ctx context.Context, customerID string) context.Context {
+	return context.WithValue(ctx, toolSubjectKey{}, customerID)
+}
+
+func toolSubject(ctx context.Context) string {
+	sub, _ := ctx.Value(toolSubjectKey{}).(string)
+	return sub
+}
+
+// SetBankingTools lets the agent execute banking tools (balance, recent
+// PIX, ...) on behalf of the customer. Without it tool calls fail.
+func (a *Assistant) SetBankingTools(bank *BankingService) {
+	a.bank = bank
+}
+
+// executeTool runs a tool call and never fails: errors are reported to the
+// agent in the result. Tools act on the customer of the request only when
+// the request is authenticated as that customer (see WithToolSubject); a
+// call that names another customer is refused.
+func (a *Assistant) executeTool(ctx context.Context, customerID string, call domain.ToolCall) domain.ToolResult {
+	ctx, span := tracer.Start(ctx, "Assistant.ExecuteTool")
+	defer span.End()
+	span.SetAttributes(attribute.String("tool.name", call.Name), attribute.String("customer.id", customerID))
+
+	result := domain.ToolResult{CallID: call.ID, Name: call.Name, Args: call.Args}
+
+	tool, ok := assistantTools[call.Name]
+	switch {
+	case !ok:
+		result.Error = fmt.Sprintf("unknown tool %q", call.Name)
+	case a.bank == nil:
+		result.Error = "banking tools are not available"
+	case toolSubject(ctx) == "":
+		result.Error = "banking tools require an authenticated customer"
+	case toolSubject(ctx) != customerID:
+		result.Error = "tool calls are restricted to the authenticated customer"
+	case call.Args["customer_id"] != nil && call.Args["customer_id"] != customerID:
+		result.Error = "tool calls are restricted to the authenticated customer"
+	default:
+		out, err := tool(ctx, a.bank, customerID, call.Args)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Result = out
+		}
+	}
+
+	if result.Error != "" {
+		a.logger.Warn("assistant tool failed",
+			zap.String("customer_id", customerID),
+			zap.String("tool", call.Name),
+			zap.String("error", result.Error))
+	}
+	return result
+}
+
+// mergeToolNames appends the executed tools to the names reported by the
+// agent, without duplicates.
+func mergeToolNames(names []string, results []domain.ToolResult) []string {
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		seen[n] = true
+	}
+	for _, r := range results {
+		if !seen[r.Name] {
+			seen[r.Name] = true
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func getBalanceTool(ctx context.Context, bank *BankingService, customerID string, _ map[string]any) (any, error) {
+	account, err := bank.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"account_id":        account.ID,
+		"balance":           account.Balance,
+		"available_balance": account.AvailableBalance,
+		"currency":          account.Currency,
+	}, nil
+}
+
+func listRecentPixTool(ctx context.Context, bank *BankingService, customerID string, args map[string]any) (any, error) {
+	limit := defaultRecentPixLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 && v <= 50 { // JSON numbers decode as float64
+		limit = int(v)
+	}
+	return bank.ListPixTransfers(ctx, customerID, 1, limit)
+}
@@ -4,8 +4,9 @@
 //   - auth_registration.go — Register
 //   - auth_login.go        — Login, devLoginFallback
 //   - auth_tokens.go       — Refresh, Logout, ValidateAccessToken, JWT helpers
-//   - auth_password.go     — PasswordResetRequest, PasswordResetConfirm, ChangePassword
+//   - auth_password.go     — PasswordResetRequest, PasswordResetResend, PasswordResetConfirm, ChangePassword
 //   - auth_profile.go      — UpdateProfile, UpdateRepresentative
+//   - auth_companies.go    — ListCompanies, SwitchCompany
 package service
 
 import (
@@ -30,8 +31,11 @@ const (
 type AuthService struct {
 	store      port.AuthStore
 	jwtSecret  []byte
+	jwtKeyID   string            // kid of jwtSecret; empty → tokens carry no kid
+	jwtOldKeys map[string][]byte // kid → secret, accepted for verification only
 	accessTTL  time.Duration
 	refreshTTL time.Duration
+	idleTTL    time.Duration // refresh tokens unused this long are revoked; 0 disables
 	devAuth    bool
 	logger     *zap.Logger
 }
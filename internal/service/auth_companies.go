@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Companies — GET /v1/auth/companies, POST /v1/auth/switch-company
+ *
+ * A user (identified by CPF) operates the company they represent plus any
+ * company linked to them in user_companies. The access token is scoped to
+ * one of them at a time (its sub); switching reissues the access token for
+ * another company after checking membership.
+ */
+
+func (s *AuthService) ListCompanies(ctx context.Context, claims *JWTClaims) (*domain.AuthCompaniesResponse, error) {
+	ctx, span := authTracer.Start(ctx, "AuthService.ListCompanies")
+	defer span.End()
+
+	cpf, err := s.claimsCPF(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	companies, err := s.userCompanies(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range companies {
+		companies[i].Active = companies[i].CustomerID == claims.Sub
+	}
+	return &domain.AuthCompaniesResponse{Companies: companies}, nil
+}
+
+func (s *AuthService) SwitchCompany(ctx context.Context, claims *JWTClaims, req *domain.SwitchCompanyRequest) (*domain.SwitchCompanyResponse, error) {
+	ctx, span := authTracer.Start(ctx, "AuthService.SwitchCompany")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", claims.Sub), attribute.String("target_customer.id", req.CustomerID))
+
+	if req.CustomerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+
+	cpf, err := s.claimsCPF(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	companies, err := s.userCompanies(ctx, cpf)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *domain.AuthCompany
+	for i := range companies {
+		if companies[i].CustomerID == req.CustomerID {
+			target = &companies[i]
+			break
+		}
+	}
+	if target == nil {
+		s.logger.Warn("switch company: not a member",
+			zap.String("customer_id", claims.Sub),
+			zap.String("target_customer_id", req.CustomerID),
+		)
+		return nil, &domain.ErrForbidden{Action: "switch to company " + req.CustomerID}
+	}
+
+	role, permissions, err := s.accessGrants(ctx, target.CustomerID, cpf)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.signAccessToken(target.CustomerID, target.Document, cpf, role, permissions)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	s.logger.Info("switched active company",
+		zap.String("customer_id", claims.Sub),
+		zap.String("target_customer_id", target.CustomerID),
+		zap.String("role", role),
+	)
+	return &domain.SwitchCompanyResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   int(s.accessTTL.Seconds()),
+		CustomerID:  target.CustomerID,
+		CompanyName: target.CompanyName,
+		Role:        role,
+	}, nil
+}
+
+// claimsCPF returns the user's CPF. Tokens issued before the claim existed
+// fall back to the representative of the token's company.
+func (s *AuthService) claimsCPF(ctx context.Context, claims *JWTClaims) (string, error) {
+	if claims.CPF != "" {
+		return claims.CPF, nil
+	}
+	profile, err := s.store.GetCustomerByID(ctx, claims.Sub)
+	if err != nil {
+		return "", fmt.Errorf("get customer profile: %w", err)
+	}
+	if profile == nil || profile.RepresentanteCPF == "" {
+		return "", &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.token_invalid")}
+	}
+	return profile.RepresentanteCPF, nil
+}
+
+// userCompanies lists the companies the user can operate: the one they
+// represent first, then their user_companies links.
+func (s *AuthService) userCompanies(ctx context.Context, cpf string) ([]domain.AuthCompany, error) {
+	links, err := s.store.ListUserCompanies(ctx, cpf)
+	if err != nil {
+		return nil, fmt.Errorf("list user companies: %w", err)
+	}
+	roles := make(map[string]domain.UserCompany, len(links))
+	for _, l := range links {
+		roles[l.CustomerID] = l
+	}
+
+	var companies []domain.AuthCompany
+	seen := make(map[string]bool)
+
+	own, err := s.store.GetCustomerByCPF(ctx, cpf)
+	if err != nil {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+	if own != nil {
+		c := domain.AuthCompany{CustomerID: own.CustomerID, CompanyName: own.CompanyName, Document: own.Document, Role: "owner", IsDefault: true}
+		if l, ok := roles[own.CustomerID]; ok {
+			c.Role = l.Role
+		}
+		companies = append(companies, c)
+		seen[own.CustomerID] = true
+	}
+
+	for _, l := range links {
+		if seen[l.CustomerID] {
+			continue
+		}
+		profile, err := s.store.GetCustomerByID(ctx, l.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("get customer profile: %w", err)
+		}
+		if profile == nil {
+			continue
+		}
+		companies = append(companies, domain.AuthCompany{
+			CustomerID:  l.CustomerID,
+			CompanyName: profile.CompanyName,
+			Document:    profile.Document,
+			Role:        l.Role,
+			IsDefault:   l.IsDefault && own == nil,
+		})
+		seen[l.CustomerID] = true
+	}
+	return companies, nil
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const testUserCPF = "12345678901"
+
+// newCompaniesFixture registers a user who represents cust-001 and operates
+// cust-002 as an operator; cust-003 belongs to someone else.
+func newCompaniesFixture() *service.AuthService {
+	store := &fakeAuthStore{
+		profiles: map[string]*domain.CustomerProfile{
+			"cust-001": {CustomerID: "cust-001", CompanyName: "Padaria Central", Document: "11111111000111", RepresentanteCPF: testUserCPF},
+			"cust-002": {CustomerID: "cust-002", CompanyName: "Café do Porto", Document: "22222222000122", RepresentanteCPF: "99999999999"},
+			"cust-003": {CustomerID: "cust-003", CompanyName: "Outra Empresa", Document: "33333333000133", RepresentanteCPF: "88888888888"},
+		},
+		links: []domain.UserCompany{
+			{UserID: testUserCPF, CustomerID: "cust-002", Role: "operator", Permissions: []string{domain.PermissionPixTransfer}},
+		},
+	}
+	return service.NewAuthService(store, "secret", time.Minute, time.Hour, false, zap.NewNop())
+}
+
+func TestSwitchCompany_ScopesTokenToTargetCompany(t *testing.T) {
+	svc := newCompaniesFixture()
+	claims := &service.JWTClaims{Sub: "cust-001", CPF: testUserCPF, Type: "access"}
+
+	list, err := svc.ListCompanies(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Companies) != 2 || list.Companies[0].CustomerID != "cust-001" || !list.Companies[0].Active || list.Companies[1].CustomerID != "cust-002" {
+		t.Fatalf("companies = %+v, want cust-001 (active) and cust-002", list.Companies)
+	}
+
+	resp, err := svc.SwitchCompany(context.Background(), claims, &domain.SwitchCompanyRequest{CustomerID: "cust-002"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	switched, err := svc.ValidateAccessToken(context.Background(), resp.AccessToken)
+	if err != nil {
+		t.Fatalf("switched token rejected: %v", err)
+	}
+	if switched.Sub != "cust-002" || switched.CNPJ != "22222222000122" || switched.CPF != testUserCPF {
+		t.Errorf("switched claims = %+v, want scoped to cust-002 for the same user", switched)
+	}
+	if switched.Role != "operator" || !switched.HasPermission(domain.PermissionPixTransfer) || switched.HasPermission(domain.PermissionCardBlock) {
+		t.Errorf("switched role/permissions = %s/%v, want operator with pix:transfer only", switched.Role, switched.Permissions)
+	}
+}
+
+func TestSwitchCompany_RejectsNonMember(t *testing.T) {
+	svc := newCompaniesFixture()
+	claims := &service.JWTClaims{Sub: "cust-001", CPF: testUserCPF, Type: "access"}
+
+	_, err := svc.SwitchCompany(context.Background(), claims, &domain.SwitchCompanyRequest{CustomerID: "cust-003"})
+	var forbidden *domain.ErrForbidden
+	if !errors.As(err, &forbidden) {
+		t.Fatalf("expected ErrForbidden for a company the user is not a member of, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+)
+
+// fakeAuthStore is an in-memory AuthStore for service tests. Methods not
+// implemented here panic through the embedded nil interface.
+type fakeAuthStore struct {
+	port.AuthStore
+
+	mu         sync.Mutex
+	profile    *domain.CustomerProfile // returned by GetCustomerByBankDetails
+	profiles   map[string]*domain.CustomerProfile
+	links      []domain.UserCompany // UserID holds the user CPF
+	creds      map[string]*domain.AuthCredential
+	codes      []domain.AuthPasswordResetCode
+	credUpdate map[string]any
+}
+
+func (f *fakeAuthStore) GetCustomerByID(_ context.Context, customerID string) (*domain.CustomerProfile, error) {
+	return f.profiles[customerID], nil
+}
+
+func (f *fakeAuthStore) GetCustomerByCPF(_ context.Context, cpf string) (*domain.CustomerProfile, error) {
+	for _, p := range f.profiles {
+		if p.RepresentanteCPF == cpf {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAuthStore) ListUserCompanies(_ context.Context, cpf string) ([]domain.UserCompany, error) {
+	var out []domain.UserCompany
+	for _, l := range f.links {
+		if l.UserID == cpf {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAuthStore) GetUserCompany(_ context.Context, customerID, cpf string) (*domain.UserCompany, error) {
+	for _, l := range f.links {
+		if l.CustomerID == customerID && l.UserID == cpf {
+			return &l, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAuthStore) GetCustomerByBankDetails(_ context.Context, _, _, _ string) (*domain.CustomerProfile, error) {
+	return f.profile, nil
+}
+
+func (f *fakeAuthStore) StoreResetCode(_ context.Context, customerID, code string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes = append(f.codes, domain.AuthPasswordResetCode{
+		ID:         code,
+		CustomerID: customerID,
+		Code:       code,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now(),
+	})
+	return nil
+}
+
+func (f *fakeAuthStore) ListResetCodesSince(_ context.Context, customerID string, since time.Time) ([]domain.AuthPasswordResetCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.AuthPasswordResetCode
+	for _, c := range f.codes {
+		if c.CustomerID == customerID && !c.CreatedAt.Before(since) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAuthStore) InvalidateResetCodes(_ context.Context, customerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.codes {
+		if f.codes[i].CustomerID == customerID {
+			f.codes[i].Used = true
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuthStore) GetActiveResetCode(_ context.Context, customerID string) (*domain.AuthPasswordResetCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.codes) - 1; i >= 0; i-- {
+		c := f.codes[i]
+		if c.CustomerID == customerID && !c.Used && c.ExpiresAt.After(time.Now()) {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeAuthStore) MarkResetCodeUsed(_ context.Context, codeID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.codes {
+		if f.codes[i].ID == codeID {
+			f.codes[i].Used = true
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuthStore) ClaimResetCodeAttempt(_ context.Context, codeID string, attempts int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.codes {
+		if f.codes[i].ID == codeID && !f.codes[i].Used && f.codes[i].Attempts == attempts {
+			f.codes[i].Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAuthStore) UpdateCredentials(_ context.Context, _ string, updates map[string]any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.credUpdate = updates
+	return nil
+}
+
+func (f *fakeAuthStore) GetCredentials(_ context.Context, customerID string) (*domain.AuthCredential, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cred, ok := f.creds[customerID]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "credentials", ID: customerID}
+	}
+	c := *cred
+	return &c, nil
+}
+
+func (f *fakeAuthStore) UpdateLastLogin(_ context.Context, customerID, _ string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cred, ok := f.creds[customerID]; ok {
+		cred.LastLoginAt = &at
+	}
+	return nil
+}
+
+func (f *fakeAuthStore) StoreRefreshToken(context.Context, string, string, time.Time) error {
+	return nil
+}
+
+func (f *fakeAuthStore) RevokeAllRefreshTokens(context.Context, string) error { return nil }
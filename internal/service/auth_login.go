@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
@@ -34,7 +35,7 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		return nil, fmt.Errorf("get customer: %w", err)
 	}
 	if profile == nil {
-		return nil, &domain.ErrUnauthorized{Message: "Credenciais inválidas"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.invalid_credentials")}
 	}
 
 	// Check account status
@@ -61,7 +62,7 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 				zap.String("customer_id", profile.CustomerID),
 				zap.String("cpf", req.CPF),
 			)
-			return nil, &domain.ErrUnauthorized{Message: "Credenciais inválidas"}
+			return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.invalid_credentials")}
 		}
 		return nil, fmt.Errorf("get credentials: %w", err)
 	}
@@ -74,7 +75,7 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 			zap.Float64("remaining_minutes", remaining),
 		)
 		return nil, &domain.ErrUnauthorized{
-			Message: fmt.Sprintf("Conta temporariamente bloqueada. Tente novamente em %.0f minutos", remaining),
+			Message: i18n.T(ctx, "auth.account_temporarily_locked", remaining),
 		}
 	}
 
@@ -103,11 +104,11 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		remaining := maxFailedAttempts - newAttempts
 		if remaining <= 0 {
 			return nil, &domain.ErrUnauthorized{
-				Message: fmt.Sprintf("Conta bloqueada por %d minutos após %d tentativas", int(lockDuration.Minutes()), maxFailedAttempts),
+				Message: i18n.T(ctx, "auth.account_locked", int(lockDuration.Minutes()), maxFailedAttempts),
 			}
 		}
 		return nil, &domain.ErrUnauthorized{
-			Message: fmt.Sprintf("Credenciais inválidas. %d tentativa(s) restante(s)", remaining),
+			Message: i18n.T(ctx, "auth.invalid_credentials_remaining", remaining),
 		}
 	}
 
@@ -115,11 +116,23 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 	_ = s.store.UpdateCredentials(ctx, profile.CustomerID, map[string]any{
 		"failed_attempts": 0,
 		"locked_until":    nil,
-		"last_login_at":   time.Now().Format(time.RFC3339),
 	})
 
+	// The previous login is returned so the user can spot one they didn't make
+	previousLogin := cred.LastLoginAt
+	if err := s.store.UpdateLastLogin(ctx, profile.CustomerID, profile.RepresentanteCPF, time.Now()); err != nil {
+		s.logger.Warn("login: could not record last login",
+			zap.String("customer_id", profile.CustomerID),
+			zap.Error(err),
+		)
+	}
+
 	// Generate tokens
-	accessToken, err := s.signAccessToken(profile.CustomerID, profile.Document)
+	role, permissions, err := s.accessGrants(ctx, profile.CustomerID, profile.RepresentanteCPF)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.signAccessToken(profile.CustomerID, profile.Document, profile.RepresentanteCPF, role, permissions)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
@@ -151,6 +164,7 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		CustomerID:   profile.CustomerID,
 		CustomerName: profile.Name,
 		CompanyName:  profile.CompanyName,
+		LastLoginAt:  previousLogin,
 	}, nil
 }
 
@@ -167,10 +181,14 @@ func (s *AuthService) devLoginFallback(ctx context.Context, profile *domain.Cust
 		return nil, fmt.Errorf("dev login lookup: %w", err)
 	}
 	if devProfile == nil {
-		return nil, &domain.ErrUnauthorized{Message: "Credenciais inválidas"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.invalid_credentials")}
 	}
 
-	accessToken, err := s.signAccessToken(devProfile.CustomerID, devProfile.Document)
+	role, permissions, err := s.accessGrants(ctx, devProfile.CustomerID, devProfile.RepresentanteCPF)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.signAccessToken(devProfile.CustomerID, devProfile.Document, devProfile.RepresentanteCPF, role, permissions)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
@@ -0,0 +1,51 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLogin_ReportsPreviousLogin(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	store := &fakeAuthStore{
+		profiles: map[string]*domain.CustomerProfile{
+			testCustomerID: {CustomerID: testCustomerID, CompanyName: "Padaria Central", RepresentanteCPF: testUserCPF},
+		},
+		creds: map[string]*domain.AuthCredential{
+			testCustomerID: {CustomerID: testCustomerID, PasswordHash: string(hash)},
+		},
+	}
+	svc := service.NewAuthService(store, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	login := func() *domain.LoginResponse {
+		t.Helper()
+		resp, err := svc.Login(context.Background(), &domain.LoginRequest{CPF: testUserCPF, Password: "123456"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	first := login()
+	if first.LastLoginAt != nil {
+		t.Errorf("first login reported a previous login at %v", first.LastLoginAt)
+	}
+	recorded := store.creds[testCustomerID].LastLoginAt
+	if recorded == nil {
+		t.Fatal("last login was not recorded")
+	}
+
+	second := login()
+	if second.LastLoginAt == nil || !second.LastLoginAt.Equal(*recorded) {
+		t.Errorf("second login reported %v, want the first login at %v", second.LastLoginAt, recorded)
+	}
+}
@@ -3,12 +3,14 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
 	"math/big"
 	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
@@ -16,12 +18,40 @@ import (
 
 /*
  * PasswordResetRequest — POST /v1/auth/password/reset-request
+ * PasswordResetResend  — POST /v1/auth/password/reset-resend
+ *
+ * Issuing a code invalidates the customer's previous unused codes, so only
+ * the latest one is accepted. A new code is refused within
+ * passwordResetCooldown of the last one, and at most passwordResetMaxPerHour
+ * codes are issued per hour.
  */
 
+const (
+	passwordResetCodeTTL    = 10 * time.Minute
+	passwordResetCooldown   = 60 * time.Second
+	passwordResetMaxPerHour = 5
+
+	// passwordResetMaxAttempts is how many wrong guesses invalidate a code.
+	passwordResetMaxAttempts = 5
+)
+
 func (s *AuthService) PasswordResetRequest(ctx context.Context, req *domain.PasswordResetRequestBody) (*domain.PasswordResetRequestResponse, error) {
 	ctx, span := authTracer.Start(ctx, "AuthService.PasswordResetRequest")
 	defer span.End()
 
+	return s.issuePasswordResetCode(ctx, req)
+}
+
+// PasswordResetResend regenerates the reset code, replacing the one sent
+// before. It is subject to the same cooldown and hourly cap.
+func (s *AuthService) PasswordResetResend(ctx context.Context, req *domain.PasswordResetRequestBody) (*domain.PasswordResetRequestResponse, error) {
+	ctx, span := authTracer.Start(ctx, "AuthService.PasswordResetResend")
+	defer span.End()
+
+	return s.issuePasswordResetCode(ctx, req)
+}
+
+func (s *AuthService) issuePasswordResetCode(ctx context.Context, req *domain.PasswordResetRequestBody) (*domain.PasswordResetRequestResponse, error) {
 	profile, err := s.store.GetCustomerByBankDetails(ctx, req.Document, req.Agencia, req.Conta)
 	if err != nil {
 		return nil, fmt.Errorf("get customer: %w", err)
@@ -29,15 +59,32 @@ func (s *AuthService) PasswordResetRequest(ctx context.Context, req *domain.Pass
 	if profile == nil {
 		// Return success anyway (don't leak whether account exists)
 		return &domain.PasswordResetRequestResponse{
-			Message:     "Se os dados estiverem corretos, enviaremos o código de verificação",
-			MaskedEmail: "***@***.com",
-			ExpiresIn:   600,
+			Message:     i18n.T(ctx, "auth.reset_code_requested"),
+			MaskedEmail: maskEmail(""),
+			ExpiresIn:   int(passwordResetCodeTTL.Seconds()),
 		}, nil
 	}
 
+	now := time.Now()
+	recent, err := s.store.ListResetCodesSince(ctx, profile.CustomerID, now.Add(-time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("list reset codes: %w", err)
+	}
+	if err := checkPasswordResetRate(ctx, recent, now); err != nil {
+		s.logger.Warn("password reset code refused",
+			zap.String("customer_id", profile.CustomerID),
+			zap.Int("codes_last_hour", len(recent)),
+		)
+		return nil, err
+	}
+
+	if err := s.store.InvalidateResetCodes(ctx, profile.CustomerID); err != nil {
+		return nil, fmt.Errorf("invalidate reset codes: %w", err)
+	}
+
 	// Generate 6-digit code
 	code := generateVerificationCode()
-	expiresAt := time.Now().Add(10 * time.Minute)
+	expiresAt := now.Add(passwordResetCodeTTL)
 
 	if err := s.store.StoreResetCode(ctx, profile.CustomerID, code, expiresAt); err != nil {
 		return nil, fmt.Errorf("store reset code: %w", err)
@@ -50,12 +97,43 @@ func (s *AuthService) PasswordResetRequest(ctx context.Context, req *domain.Pass
 	)
 
 	return &domain.PasswordResetRequestResponse{
-		Message:     "Código de verificação enviado",
+		Message:     i18n.T(ctx, "auth.code_sent"),
 		MaskedEmail: maskEmail(profile.Email),
-		ExpiresIn:   600,
+		ExpiresIn:   int(passwordResetCodeTTL.Seconds()),
 	}, nil
 }
 
+// checkPasswordResetRate applies the cooldown and the hourly cap to the
+// codes issued in the last hour.
+func checkPasswordResetRate(ctx context.Context, recent []domain.AuthPasswordResetCode, now time.Time) error {
+	if len(recent) >= passwordResetMaxPerHour {
+		oldest := recent[0].CreatedAt
+		for _, c := range recent[1:] {
+			if c.CreatedAt.Before(oldest) {
+				oldest = c.CreatedAt
+			}
+		}
+		return &domain.ErrRateLimited{
+			Message:    i18n.T(ctx, "auth.code_request_limit"),
+			RetryAfter: oldest.Add(time.Hour).Sub(now),
+		}
+	}
+
+	var latest time.Time
+	for _, c := range recent {
+		if c.CreatedAt.After(latest) {
+			latest = c.CreatedAt
+		}
+	}
+	if wait := latest.Add(passwordResetCooldown).Sub(now); wait > 0 {
+		return &domain.ErrRateLimited{
+			Message:    i18n.T(ctx, "auth.code_request_wait"),
+			RetryAfter: wait,
+		}
+	}
+	return nil
+}
+
 /*
  * PasswordResetConfirm — POST /v1/auth/password/reset-confirm
  */
@@ -69,21 +147,18 @@ func (s *AuthService) PasswordResetConfirm(ctx context.Context, req *domain.Pass
 		return fmt.Errorf("get customer: %w", err)
 	}
 	if profile == nil {
-		return &domain.ErrUnauthorized{Message: "Credenciais inválidas"}
+		return &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.invalid_credentials")}
 	}
 
 	// Validate code
-	resetCode, err := s.store.GetValidResetCode(ctx, profile.CustomerID, req.VerificationCode)
+	resetCode, err := s.checkResetCode(ctx, profile.CustomerID, req.VerificationCode)
 	if err != nil {
-		return fmt.Errorf("get reset code: %w", err)
-	}
-	if resetCode == nil {
-		return &domain.ErrInvalidCode{}
+		return err
 	}
 
 	// Validate new password
 	if len(req.NewPassword) != 6 {
-		return &domain.ErrValidation{Field: "newPassword", Message: "Senha deve ter 6 dígitos"}
+		return &domain.ErrValidation{Field: "newPassword", Message: i18n.T(ctx, "auth.password_length")}
 	}
 
 	// Hash new password
@@ -112,6 +187,58 @@ func (s *AuthService) PasswordResetConfirm(ctx context.Context, req *domain.Pass
 	return nil
 }
 
+// checkResetCode verifies the code against the customer's active reset code.
+// Every guess is counted, and the code is invalidated once
+// passwordResetMaxAttempts wrong guesses are reached; the caller only ever
+// sees ErrInvalidCode, so the remaining attempts are not revealed.
+//
+// The attempt is claimed with a compare-and-set before the code is
+// compared, so guesses sent in parallel cannot all be checked against the
+// same count: at most passwordResetMaxAttempts of them are ever compared.
+func (s *AuthService) checkResetCode(ctx context.Context, customerID, code string) (*domain.AuthPasswordResetCode, error) {
+	// Each lost claim means another guess raised the count, so the loop ends
+	// once the limit is reached.
+	for i := 0; i <= passwordResetMaxAttempts; i++ {
+		resetCode, err := s.store.GetActiveResetCode(ctx, customerID)
+		if err != nil {
+			return nil, fmt.Errorf("get reset code: %w", err)
+		}
+		if resetCode == nil || resetCode.Used || !time.Now().Before(resetCode.ExpiresAt) ||
+			resetCode.Attempts >= passwordResetMaxAttempts {
+			return nil, &domain.ErrInvalidCode{}
+		}
+
+		claimed, err := s.store.ClaimResetCodeAttempt(ctx, resetCode.ID, resetCode.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("count reset code attempt: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(resetCode.Code), []byte(code)) == 1 {
+			return resetCode, nil
+		}
+		return nil, s.rejectResetCode(ctx, customerID, resetCode.ID, resetCode.Attempts+1)
+	}
+	return nil, &domain.ErrInvalidCode{}
+}
+
+// rejectResetCode handles a wrong guess that brought the code to attempts,
+// invalidating it at passwordResetMaxAttempts.
+func (s *AuthService) rejectResetCode(ctx context.Context, customerID, codeID string, attempts int) error {
+	if attempts >= passwordResetMaxAttempts {
+		if err := s.store.MarkResetCodeUsed(ctx, codeID); err != nil {
+			return fmt.Errorf("invalidate reset code: %w", err)
+		}
+		s.logger.Warn("password reset code invalidated after too many wrong attempts",
+			zap.String("customer_id", customerID),
+			zap.Int("attempts", attempts),
+		)
+	}
+	return &domain.ErrInvalidCode{}
+}
+
 /*
  * ChangePassword — PUT /v1/auth/password
  */
@@ -130,12 +257,12 @@ func (s *AuthService) ChangePassword(ctx context.Context, customerID string, req
 		s.logger.Warn("password change: wrong current password",
 			zap.String("customer_id", customerID),
 		)
-		return &domain.ErrUnauthorized{Message: "Senha atual incorreta"}
+		return &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.wrong_current_password")}
 	}
 
 	// Validate new password
 	if len(req.NewPassword) != 6 {
-		return &domain.ErrValidation{Field: "newPassword", Message: "Senha deve ter 6 dígitos"}
+		return &domain.ErrValidation{Field: "newPassword", Message: i18n.T(ctx, "auth.password_length")}
 	}
 
 	// Hash new password
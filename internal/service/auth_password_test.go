@@ -0,0 +1,196 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// backdate moves every stored code into the past, as if issued earlier.
+func (f *fakeAuthStore) backdate(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.codes {
+		f.codes[i].CreatedAt = f.codes[i].CreatedAt.Add(-d)
+	}
+}
+
+func newPasswordResetFixture() (*fakeAuthStore, *service.AuthService) {
+	store := &fakeAuthStore{profile: &domain.CustomerProfile{CustomerID: testCustomerID, Email: "joana@empresa.com.br"}}
+	svc := service.NewAuthService(store, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	return store, svc
+}
+
+func TestPasswordResetResend_Cooldown(t *testing.T) {
+	store, svc := newPasswordResetFixture()
+	req := &domain.PasswordResetRequestBody{Document: "12345678000190", Agencia: "0001", Conta: "123456"}
+
+	resp, err := svc.PasswordResetRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MaskedEmail != "j***a@empresa.com.br" || resp.ExpiresIn != 600 {
+		t.Errorf("response = %+v, want masked email and expiresIn 600", resp)
+	}
+
+	_, err = svc.PasswordResetResend(context.Background(), req)
+	var rateLimited *domain.ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected ErrRateLimited within the cooldown, got %v", err)
+	}
+	if rateLimited.RetryAfter <= 0 || rateLimited.RetryAfter > time.Minute {
+		t.Errorf("retry after = %v, want within the 60s cooldown", rateLimited.RetryAfter)
+	}
+	if len(store.codes) != 1 {
+		t.Errorf("stored %d codes, want 1", len(store.codes))
+	}
+
+	store.backdate(61 * time.Second)
+	if _, err := svc.PasswordResetResend(context.Background(), req); err != nil {
+		t.Fatalf("resend after the cooldown: unexpected error: %v", err)
+	}
+}
+
+func TestPasswordResetResend_SingleActiveCode(t *testing.T) {
+	store, svc := newPasswordResetFixture()
+	req := &domain.PasswordResetRequestBody{Document: "12345678000190", Agencia: "0001", Conta: "123456"}
+
+	for i := 0; i < 5; i++ {
+		if _, err := svc.PasswordResetResend(context.Background(), req); err != nil {
+			t.Fatalf("code %d: unexpected error: %v", i, err)
+		}
+		store.backdate(2 * time.Minute)
+
+		active := 0
+		for _, c := range store.codes {
+			if !c.Used {
+				active++
+			}
+		}
+		if active != 1 {
+			t.Fatalf("after code %d: %d unused codes, want exactly 1", i, active)
+		}
+		if store.codes[len(store.codes)-1].Used {
+			t.Fatalf("after code %d: the latest code was invalidated", i)
+		}
+	}
+
+	var rateLimited *domain.ErrRateLimited
+	if _, err := svc.PasswordResetResend(context.Background(), req); !errors.As(err, &rateLimited) {
+		t.Errorf("expected ErrRateLimited past the hourly cap, got %v", err)
+	}
+}
+
+func TestPasswordResetConfirm_WrongAttemptsInvalidateCode(t *testing.T) {
+	store, svc := newPasswordResetFixture()
+	if _, err := svc.PasswordResetRequest(context.Background(), &domain.PasswordResetRequestBody{Document: "12345678000190"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code := store.codes[0].Code
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	confirm := func(c string) error {
+		return svc.PasswordResetConfirm(context.Background(), &domain.PasswordResetConfirmRequest{
+			Document:         "12345678000190",
+			VerificationCode: c,
+			NewPassword:      "654321",
+		})
+	}
+
+	var invalid *domain.ErrInvalidCode
+	for i := 0; i < 5; i++ {
+		if err := confirm(wrong); !errors.As(err, &invalid) {
+			t.Fatalf("attempt %d: expected ErrInvalidCode, got %v", i, err)
+		}
+	}
+	if !store.codes[0].Used {
+		t.Fatal("code still active after 5 wrong attempts")
+	}
+	if time.Now().After(store.codes[0].ExpiresAt) {
+		t.Fatal("code expired during the test; the check is meaningless")
+	}
+
+	if err := confirm(code); !errors.As(err, &invalid) {
+		t.Errorf("correct code after lockout: expected ErrInvalidCode, got %v", err)
+	}
+	if store.credUpdate != nil {
+		t.Errorf("credentials updated with an invalidated code: %v", store.credUpdate)
+	}
+}
+
+func TestPasswordResetConfirm_AcceptsCodeWithinAttempts(t *testing.T) {
+	store, svc := newPasswordResetFixture()
+	if _, err := svc.PasswordResetRequest(context.Background(), &domain.PasswordResetRequestBody{Document: "12345678000190"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code := store.codes[0].Code
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	req := &domain.PasswordResetConfirmRequest{Document: "12345678000190", VerificationCode: wrong, NewPassword: "654321"}
+	if err := svc.PasswordResetConfirm(context.Background(), req); err == nil {
+		t.Fatal("expected an error for a wrong code")
+	}
+	req.VerificationCode = code
+	if err := svc.PasswordResetConfirm(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !store.codes[0].Used || store.credUpdate == nil {
+		t.Errorf("code used = %v, credentials updated = %v; want both", store.codes[0].Used, store.credUpdate != nil)
+	}
+}
+
+func TestPasswordResetConfirm_ParallelWrongGuessesAreAllCounted(t *testing.T) {
+	store, svc := newPasswordResetFixture()
+	if _, err := svc.PasswordResetRequest(context.Background(), &domain.PasswordResetRequestBody{Document: "12345678000190"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code := store.codes[0].Code
+
+	// Many distinct wrong guesses at once, as a brute force would send them
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		guess := fmt.Sprintf("%06d", i)
+		if guess == code {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = svc.PasswordResetConfirm(context.Background(), &domain.PasswordResetConfirmRequest{
+				Document:         "12345678000190",
+				VerificationCode: guess,
+				NewPassword:      "654321",
+			})
+		}()
+	}
+	wg.Wait()
+
+	store.mu.Lock()
+	attempts, used := store.codes[0].Attempts, store.codes[0].Used
+	store.mu.Unlock()
+	if attempts != 5 || !used {
+		t.Fatalf("attempts = %d, used = %v; want exactly 5 counted and the code invalidated", attempts, used)
+	}
+
+	err := svc.PasswordResetConfirm(context.Background(), &domain.PasswordResetConfirmRequest{
+		Document: "12345678000190", VerificationCode: code, NewPassword: "654321",
+	})
+	var invalid *domain.ErrInvalidCode
+	if !errors.As(err, &invalid) {
+		t.Errorf("correct code after parallel lockout: expected ErrInvalidCode, got %v", err)
+	}
+}
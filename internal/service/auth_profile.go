@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 )
 
 /*
@@ -27,7 +28,7 @@ func (s *AuthService) UpdateProfile(ctx context.Context, customerID string, req
 	}
 
 	if len(updates) == 0 {
-		return nil, &domain.ErrValidation{Field: "body", Message: "Nenhum campo para atualizar"}
+		return nil, &domain.ErrValidation{Field: "body", Message: i18n.T(ctx, "auth.nothing_to_update")}
 	}
 
 	profile, err := s.store.UpdateCustomerProfile(ctx, customerID, updates)
@@ -64,7 +65,7 @@ func (s *AuthService) UpdateRepresentative(ctx context.Context, customerID strin
 	}
 
 	if len(updates) == 0 {
-		return nil, &domain.ErrValidation{Field: "body", Message: "Nenhum campo para atualizar"}
+		return nil, &domain.ErrValidation{Field: "body", Message: i18n.T(ctx, "auth.nothing_to_update")}
 	}
 
 	profile, err := s.store.UpdateRepresentative(ctx, customerID, updates)
@@ -73,7 +74,7 @@ func (s *AuthService) UpdateRepresentative(ctx context.Context, customerID strin
 	}
 
 	return &domain.UpdateRepresentativeResponse{
-		Message:                "Dados do representante atualizados com sucesso",
+		Message:                i18n.T(ctx, "auth.representative_updated"),
 		RepresentanteName:      profile.RepresentanteName,
 		RepresentanteCPF:       profile.RepresentanteCPF,
 		RepresentantePhone:     profile.RepresentantePhone,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
@@ -28,16 +29,16 @@ func (s *AuthService) Register(ctx context.Context, req *domain.RegisterRequest)
 		return nil, fmt.Errorf("check existing customer: %w", err)
 	}
 	if existing != nil {
-		return nil, &domain.ErrConflict{Message: "CNPJ já cadastrado"}
+		return nil, &domain.ErrConflict{Message: i18n.T(ctx, "auth.document_registered")}
 	}
 
 	// Validate 6-digit password
 	if len(req.Password) != 6 {
-		return nil, &domain.ErrValidation{Field: "password", Message: "Senha deve ter 6 dígitos"}
+		return nil, &domain.ErrValidation{Field: "password", Message: i18n.T(ctx, "auth.password_length")}
 	}
 	for _, c := range req.Password {
 		if c < '0' || c > '9' {
-			return nil, &domain.ErrValidation{Field: "password", Message: "Senha deve conter apenas dígitos"}
+			return nil, &domain.ErrValidation{Field: "password", Message: i18n.T(ctx, "auth.password_digits_only")}
 		}
 	}
 
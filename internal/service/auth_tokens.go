@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
@@ -30,16 +31,16 @@ func (s *AuthService) Refresh(ctx context.Context, req *domain.RefreshRequest) (
 		return nil, fmt.Errorf("get refresh token: %w", err)
 	}
 	if stored == nil {
-		return nil, &domain.ErrUnauthorized{Message: "Token de atualização inválido"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.refresh_token_invalid")}
 	}
 
-	// Check expiry
-	if stored.ExpiresAt.Before(time.Now()) {
+	// Check expiry, absolute and idle
+	if stored.ExpiresAt.Before(time.Now()) || s.isIdle(stored) {
 		s.logger.Warn("refresh: expired token used",
 			zap.String("customer_id", stored.CustomerID),
 		)
 		_ = s.store.RevokeRefreshToken(ctx, tokenHash)
-		return nil, &domain.ErrUnauthorized{Message: "Token de atualização expirado"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.refresh_token_expired")}
 	}
 
 	// Revoke old token (rotation)
@@ -55,14 +56,22 @@ func (s *AuthService) Refresh(ctx context.Context, req *domain.RefreshRequest) (
 	document := ""
 	customerName := ""
 	companyName := ""
+	cpf := ""
 	if profile != nil {
 		document = profile.Document
 		customerName = profile.Name
 		companyName = profile.CompanyName
+		cpf = profile.RepresentanteCPF
+	}
+
+	// Roles may have changed since login, so they are looked up again
+	role, permissions, err := s.accessGrants(ctx, customerID, cpf)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate new tokens
-	accessToken, err := s.signAccessToken(customerID, document)
+	accessToken, err := s.signAccessToken(customerID, document, cpf, role, permissions)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
@@ -102,51 +111,216 @@ func (s *AuthService) Logout(ctx context.Context, customerID string) error {
 	return nil
 }
 
+/*
+ * Idle sessions
+ *
+ * Rotation issues a new refresh token on every refresh, so a token's issue
+ * time is the last time its session was used. With an idle timeout set, a
+ * token older than the timeout no longer refreshes and the idle-session
+ * worker revokes it.
+ */
+
+// SetRefreshIdleTimeout sets how long a refresh token may go unused before
+// it is revoked. Zero disables the idle timeout; negative values keep the
+// current one.
+func (s *AuthService) SetRefreshIdleTimeout(d time.Duration) {
+	if d >= 0 {
+		s.idleTTL = d
+	}
+}
+
+func (s *AuthService) isIdle(token *domain.AuthRefreshToken) bool {
+	return s.idleTTL > 0 && !token.CreatedAt.IsZero() && time.Since(token.CreatedAt) > s.idleTTL
+}
+
+// RevokeIdleRefreshTokens revokes every refresh token unused for longer
+// than the idle timeout. It does nothing when the timeout is disabled.
+func (s *AuthService) RevokeIdleRefreshTokens(ctx context.Context) error {
+	if s.idleTTL <= 0 {
+		return nil
+	}
+	ctx, span := authTracer.Start(ctx, "AuthService.RevokeIdleRefreshTokens")
+	defer span.End()
+
+	n, err := s.store.RevokeIdleRefreshTokens(ctx, time.Now().Add(-s.idleTTL))
+	if err != nil {
+		return fmt.Errorf("revoke idle refresh tokens: %w", err)
+	}
+	if n > 0 {
+		s.logger.Info("idle refresh tokens revoked", zap.Int("count", n))
+	}
+	return nil
+}
+
 /*
  * ValidateToken — used by middleware
  */
 
 // JWTClaims represents the custom claims in access tokens.
+// Sub is the active company; CPF identifies the user operating it.
 type JWTClaims struct {
-	Sub  string `json:"sub"`
-	CNPJ string `json:"cnpj"`
-	Type string `json:"type"`
+	Sub         string   `json:"sub"`
+	CNPJ        string   `json:"cnpj"`
+	CPF         string   `json:"cpf,omitempty"`
+	Type        string   `json:"type"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+// HasPermission reports whether the token grants perm, directly or through
+// domain.PermissionAll.
+func (c *JWTClaims) HasPermission(perm string) bool {
+	for _, p := range c.Permissions {
+		if p == perm || p == domain.PermissionAll {
+			return true
 		}
-		return s.jwtSecret, nil
-	})
+	}
+	return false
+}
+
+func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.verificationKey)
 	if err != nil {
-		return nil, &domain.ErrUnauthorized{Message: "Token inválido ou expirado"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.token_invalid_or_expired")}
 	}
 
 	claims, ok := token.Claims.(*JWTClaims)
 	if !ok || !token.Valid {
-		return nil, &domain.ErrUnauthorized{Message: "Token inválido"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.token_invalid")}
 	}
 
 	if claims.Type != "access" {
-		return nil, &domain.ErrUnauthorized{Message: "Tipo de token inválido"}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "auth.token_type_invalid")}
 	}
 
 	return claims, nil
 }
 
+// IntrospectAccessToken reports whether tokenString is a valid access token
+// and, when it is, whom it was issued to and for how long. Invalid and
+// expired tokens are inactive rather than an error.
+func (s *AuthService) IntrospectAccessToken(ctx context.Context, tokenString string) *domain.TokenIntrospection {
+	claims, err := s.ValidateAccessToken(ctx, tokenString)
+	if err != nil {
+		return &domain.TokenIntrospection{Active: false}
+	}
+	result := &domain.TokenIntrospection{
+		Active:     true,
+		CustomerID: claims.Sub,
+		Role:       claims.Role,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = &claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = &claims.ExpiresAt.Time
+	}
+	return result
+}
+
+/*
+ * Signing keys — rotation by kid
+ *
+ * Access tokens are signed with the current secret and carry its kid in the
+ * header. Secrets retired by a rotation stay configured under their kid until
+ * the tokens they signed have expired, so rotating does not log everyone out.
+ */
+
+// SetSigningKeys names the current secret keyID and accepts tokens signed
+// with the previous secrets, keyed by kid. A previous key reusing the
+// current kid is ignored.
+func (s *AuthService) SetSigningKeys(keyID string, previous map[string]string) {
+	s.jwtKeyID = keyID
+	s.jwtOldKeys = make(map[string][]byte, len(previous))
+	for kid, secret := range previous {
+		if kid == "" || kid == keyID || secret == "" {
+			continue
+		}
+		s.jwtOldKeys[kid] = []byte(secret)
+	}
+}
+
+// verificationKey selects the secret a token is verified with by its kid.
+// Tokens without a kid predate key IDs and are tried against every key.
+func (s *AuthService) verificationKey(t *jwt.Token) (any, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		keys := []jwt.VerificationKey{s.jwtSecret}
+		for _, secret := range s.jwtOldKeys {
+			keys = append(keys, secret)
+		}
+		return jwt.VerificationKeySet{Keys: keys}, nil
+	}
+	if kid == s.jwtKeyID {
+		return s.jwtSecret, nil
+	}
+	if secret, ok := s.jwtOldKeys[kid]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// TokenKeyID returns the kid header of a token without verifying it, for
+// logging. It returns "" when the token cannot be parsed or has no kid.
+func TokenKeyID(tokenString string) string {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &JWTClaims{})
+	if err != nil {
+		return ""
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
 /*
  * Internal JWT helpers
  */
 
-func (s *AuthService) signAccessToken(customerID, cnpj string) (string, error) {
+// accessGrants returns the role and permissions of the user (cpf) on the
+// company. Without a user_companies row only the company's own legal
+// representative is linked to it, as its owner; anyone else — and a token
+// without a CPF — gets no role and no permissions.
+func (s *AuthService) accessGrants(ctx context.Context, customerID, cpf string) (string, []string, error) {
+	if cpf == "" {
+		return "", nil, nil
+	}
+	uc, err := s.store.GetUserCompany(ctx, customerID, cpf)
+	if err != nil {
+		return "", nil, fmt.Errorf("get user company: %w", err)
+	}
+	if uc == nil {
+		profile, err := s.store.GetCustomerByID(ctx, customerID)
+		if err != nil {
+			return "", nil, fmt.Errorf("get customer profile: %w", err)
+		}
+		if profile == nil || profile.RepresentanteCPF != cpf {
+			return "", nil, nil
+		}
+		return "owner", []string{domain.PermissionAll}, nil
+	}
+	switch uc.Role {
+	case "owner", "admin":
+		return uc.Role, []string{domain.PermissionAll}, nil
+	default:
+		return uc.Role, uc.Permissions, nil
+	}
+}
+
+// signAccessToken issues an access token for the user identified by cpf,
+// scoped to the company customerID.
+func (s *AuthService) signAccessToken(customerID, cnpj, cpf, role string, permissions []string) (string, error) {
 	now := time.Now()
 	claims := JWTClaims{
-		Sub:  customerID,
-		CNPJ: cnpj,
-		Type: "access",
+		Sub:         customerID,
+		CNPJ:        cnpj,
+		CPF:         cpf,
+		Type:        "access",
+		Role:        role,
+		Permissions: permissions,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
@@ -154,6 +328,9 @@ func (s *AuthService) signAccessToken(customerID, cnpj string) (string, error) {
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if s.jwtKeyID != "" {
+		token.Header["kid"] = s.jwtKeyID
+	}
 	return token.SignedString(s.jwtSecret)
 }
 
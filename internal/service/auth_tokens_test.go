@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// signTestToken signs an access token the way AuthService does, with an
+// optional kid header.
+func signTestToken(t *testing.T, secret, kid string) string {
+	t.Helper()
+	return signTestTokenExpiring(t, secret, kid, time.Now().Add(time.Minute))
+}
+
+// signTestTokenExpiring is signTestToken with an explicit expiry.
+func signTestTokenExpiring(t *testing.T, secret, kid string, expiresAt time.Time) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, service.JWTClaims{
+		Sub:  testCustomerID,
+		Type: "access",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    "bfa-api",
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestValidateAccessToken_KeyRotation(t *testing.T) {
+	svc := service.NewAuthService(nil, "secret-2026-10", time.Minute, time.Hour, false, zap.NewNop())
+	svc.SetSigningKeys("2026-10", map[string]string{"2026-09": "secret-2026-09"})
+
+	cases := []struct {
+		name   string
+		token  string
+		wantOK bool
+	}{
+		{"current key", signTestToken(t, "secret-2026-10", "2026-10"), true},
+		{"previous key", signTestToken(t, "secret-2026-09", "2026-09"), true},
+		{"legacy token without kid", signTestToken(t, "secret-2026-09", ""), true},
+		{"unknown kid", signTestToken(t, "secret-2026-08", "2026-08"), false},
+		{"kid of another key", signTestToken(t, "secret-2026-09", "2026-10"), false},
+	}
+	for _, tc := range cases {
+		claims, err := svc.ValidateAccessToken(context.Background(), tc.token)
+		if tc.wantOK && (err != nil || claims.Sub != testCustomerID) {
+			t.Errorf("%s: got claims=%v err=%v, want valid", tc.name, claims, err)
+		}
+		if !tc.wantOK && err == nil {
+			t.Errorf("%s: expected the token to be rejected", tc.name)
+		}
+	}
+
+	// Once the rotation window ends the previous key is dropped.
+	svc.SetSigningKeys("2026-10", nil)
+	if _, err := svc.ValidateAccessToken(context.Background(), signTestToken(t, "secret-2026-09", "2026-09")); err == nil {
+		t.Error("token signed with a retired key was accepted")
+	}
+	if got := service.TokenKeyID(signTestToken(t, "secret-2026-10", "2026-10")); got != "2026-10" {
+		t.Errorf("TokenKeyID = %q, want 2026-10", got)
+	}
+}
+
+func TestIntrospectAccessToken(t *testing.T) {
+	svc := service.NewAuthService(nil, "secret", time.Minute, time.Hour, false, zap.NewNop())
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	result := svc.IntrospectAccessToken(ctx, signTestTokenExpiring(t, "secret", "", expiresAt))
+	if !result.Active || result.CustomerID != testCustomerID {
+		t.Fatalf("valid token: got %+v, want active for %s", result, testCustomerID)
+	}
+	if result.ExpiresAt == nil || !result.ExpiresAt.Equal(expiresAt) || result.IssuedAt == nil {
+		t.Errorf("valid token: issuedAt = %v, expiresAt = %v, want expiresAt %v", result.IssuedAt, result.ExpiresAt, expiresAt)
+	}
+
+	expired := svc.IntrospectAccessToken(ctx, signTestTokenExpiring(t, "secret", "", time.Now().Add(-time.Minute)))
+	if expired.Active || expired.CustomerID != "" || expired.ExpiresAt != nil {
+		t.Errorf("expired token: got %+v, want inactive with no claims", expired)
+	}
+}
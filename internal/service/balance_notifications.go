@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+/*
+ * Balance-change notifications
+ *
+ * Money leaving or entering the account through PIX, bill payments and debit
+ * purchases is notified (push and in-app) with the new balance. Movements
+ * below a minimum amount are not, to avoid spam; customers can set their own
+ * minimum in the transaction notification preferences.
+ */
+
+// DefaultBalanceNotificationMinAmount is the smallest movement notified when
+// the customer has not set a minimum.
+const DefaultBalanceNotificationMinAmount = 100.0
+
+// SetBalanceNotificationMinAmount overrides the default minimum movement
+// notified. Zero notifies every movement; negative values are ignored.
+func (s *BankingService) SetBalanceNotificationMinAmount(amount float64) {
+	if amount >= 0 {
+		s.balanceNotificationMinAmount = amount
+	}
+}
+
+// notifyBalanceChange notifies a movement of delta (negative for debits) on
+// the customer's account. what describes the operation. Failures are only
+// logged: the operation already succeeded.
+func (s *BankingService) notifyBalanceChange(ctx context.Context, customerID string, delta float64, what string) {
+	prefs, err := s.GetNotificationPreferences(ctx, customerID)
+	if err != nil {
+		s.logger.Warn("balance notification skipped, preferences unavailable",
+			zap.String("customer_id", customerID), zap.Error(err))
+		return
+	}
+	minAmount := s.balanceNotificationMinAmount
+	if p := prefs[domain.NotificationTypeTransaction].MinAmount; p != nil {
+		minAmount = *p
+	}
+	if math.Abs(delta) < minAmount {
+		return
+	}
+
+	title := fmt.Sprintf("Saída de %s", FormatBRL(-delta))
+	if delta > 0 {
+		title = fmt.Sprintf("Entrada de %s", FormatBRL(delta))
+	}
+	body := what
+	if account, err := s.store.GetPrimaryAccount(ctx, customerID); err == nil {
+		body = fmt.Sprintf("%s. Saldo atual: %s", what, FormatBRL(account.Balance))
+	}
+
+	_, err = s.CreateNotification(ctx, &domain.Notification{
+		CustomerID: customerID,
+		Type:       domain.NotificationTypeTransaction,
+		Title:      title,
+		Body:       body,
+	}, domain.NotificationChannelPush, domain.NotificationChannelInApp)
+	if err != nil {
+		s.logger.Error("failed to create balance notification",
+			zap.String("customer_id", customerID), zap.Error(err))
+	}
+}
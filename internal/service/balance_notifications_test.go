@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func sendPix(t *testing.T, svc *service.BankingService, amount float64) {
+	t.Helper()
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      fmt.Sprintf("idem-%v", amount),
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              amount,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreatePixTransfer_NotifiesSignificantDebits(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 5000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	sendPix(t, svc, 5)
+	if len(store.notifs) != 0 {
+		t.Fatalf("got %d notifications for a tiny debit, want none", len(store.notifs))
+	}
+
+	sendPix(t, svc, 1500)
+	if len(store.notifs) != 2 {
+		t.Fatalf("got %d notifications for a large debit, want push and in-app", len(store.notifs))
+	}
+	n := store.notifs[0]
+	if n.Type != domain.NotificationTypeTransaction {
+		t.Errorf("type = %q, want %q", n.Type, domain.NotificationTypeTransaction)
+	}
+	if !strings.Contains(n.Title, "R$ 1.500,00") || !strings.Contains(n.Body, "Saldo atual: R$ 3.495,00") {
+		t.Errorf("notification = %q / %q, want the amount and the new balance", n.Title, n.Body)
+	}
+}
+
+func TestCreatePixTransfer_CustomerMinAmountOverridesDefault(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 5000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	minAmount := 2000.0
+	_, err := svc.UpdateNotificationPreferences(context.Background(), testCustomerID, domain.NotificationPreferences{
+		domain.NotificationTypeTransaction: {Push: true, InApp: true, MinAmount: &minAmount},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sendPix(t, svc, 1500)
+	if len(store.notifs) != 0 {
+		t.Fatalf("got %d notifications below the customer's minimum, want none", len(store.notifs))
+	}
+}
@@ -0,0 +1,1639 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+)
+
+/* Fake banking store */
+
+// fakeBankingStore is an in-memory port.BankingStore for BankingService tests.
+// Only the methods exercised by the tests are implemented; calling any other
+// method panics through the nil embedded interface.
+type fakeBankingStore struct {
+	port.BankingStore
+
+	mu sync.Mutex
+
+	accounts      map[string]*domain.Account    // primary account, by customer ID
+	otherAccounts []*domain.Account             // secondary accounts of any customer
+	cards         map[string]*domain.CreditCard // by card ID
+	invoices      []domain.CreditCardInvoice
+	pixKeys       []domain.PixKey
+	customerNames map[string]string
+	creditScores  map[string]int                      // by customer ID
+	limits        map[string]*domain.TransactionLimit // by tx type
+
+	pixTransfers []domain.PixTransfer
+	schedules    []domain.ScheduledTransfer
+	pixReceipts  []domain.PixReceipt
+	txReceipts   []domain.TransferReceipt
+	docTransfers []domain.DOCTransfer
+	bills        []domain.BillPayment
+	debits       []domain.DebitPurchase
+	transactions []map[string]any
+	cardTxs      []map[string]any
+	favorites    []domain.Favorite
+	webhooks     []domain.Webhook
+	deliveries   []domain.WebhookDelivery
+	pixKeyCodes  []domain.PixKeyVerificationCode
+	notifs       []domain.Notification
+	notifPrefs   map[string]domain.NotificationPreferences // by customer ID
+
+	statement   []domain.Transaction // returned by ListTransactions
+	summaries   []domain.SpendingSummary
+	digests     []domain.SpendingDigest
+	devBalance  map[string]domain.DevBalanceAdjustment // by idempotency key
+	pixConfirms map[string]domain.PixConfirmationToken // by token
+	catRules    []domain.CategoryRule
+	listTxCalls int
+	ruleLoads   int // ListCategoryRules calls
+
+	insertTxErr  error // returned by InsertTransaction when set
+	confirmTxErr error // returned by UpdateTransactionStatus(confirmed) when set
+	receiptErr   error // returned by SavePixReceipt when set
+	cardTxErr    error // returned by InsertCreditCardTransaction when set
+}
+
+func newFakeBankingStore() *fakeBankingStore {
+	return &fakeBankingStore{
+		accounts:      make(map[string]*domain.Account),
+		cards:         make(map[string]*domain.CreditCard),
+		customerNames: make(map[string]string),
+		creditScores:  make(map[string]int),
+		limits:        make(map[string]*domain.TransactionLimit),
+		pixConfirms:   make(map[string]domain.PixConfirmationToken),
+	}
+}
+
+// findAccount must be called with f.mu held. An empty accountID selects the
+// primary account.
+func (f *fakeBankingStore) findAccount(customerID, accountID string) *domain.Account {
+	if acct, ok := f.accounts[customerID]; ok && (accountID == "" || acct.ID == accountID) {
+		return acct
+	}
+	for _, acct := range f.otherAccounts {
+		if acct.CustomerID == customerID && acct.ID == accountID && accountID != "" {
+			return acct
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) GetAccount(_ context.Context, customerID, accountID string) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct := f.findAccount(customerID, accountID)
+	if acct == nil {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: accountID}
+	}
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) ListAccounts(_ context.Context, customerID string) ([]domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.Account
+	if acct, ok := f.accounts[customerID]; ok {
+		out = append(out, *acct)
+	}
+	for _, acct := range f.otherAccounts {
+		if acct.CustomerID == customerID {
+			out = append(out, *acct)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) TransferBetweenAccounts(_ context.Context, customerID, fromAccountID, toAccountID string, amount float64) (float64, float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	from, to := f.findAccount(customerID, fromAccountID), f.findAccount(customerID, toAccountID)
+	if from == nil || to == nil {
+		return 0, 0, &domain.ErrNotFound{Resource: "account", ID: fromAccountID}
+	}
+	if from.AvailableBalance < amount {
+		return 0, 0, &domain.ErrInsufficientFunds{Available: from.AvailableBalance, Required: amount}
+	}
+	from.Balance -= amount
+	from.AvailableBalance -= amount
+	to.Balance += amount
+	to.AvailableBalance += amount
+	return from.Balance, to.Balance, nil
+}
+
+func (f *fakeBankingStore) GetPrimaryAccount(ctx context.Context, customerID string) (*domain.Account, error) {
+	return f.GetAccount(ctx, customerID, "")
+}
+
+func (f *fakeBankingStore) ListActiveCustomerIDs(_ context.Context, afterID string, limit int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for id, acct := range f.accounts {
+		if acct.Status == "active" && id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (f *fakeBankingStore) UpdateAccountOverdraftLimit(_ context.Context, customerID, accountID string, limit float64) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct := f.findAccount(customerID, accountID)
+	if acct == nil {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: accountID}
+	}
+	acct.OverdraftLimit = limit
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) UpdateAccountCreditLimit(_ context.Context, customerID string, newLimit float64) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct, ok := f.accounts[customerID]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: customerID}
+	}
+	var usedByCards float64
+	for _, c := range f.cards {
+		if c.CustomerID == customerID && (c.Status == "active" || c.Status == "blocked") {
+			usedByCards += c.CreditLimit
+		}
+	}
+	acct.CreditLimit = newLimit
+	acct.AvailableCreditLimit = max(newLimit-usedByCards, 0)
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) UpdateAccountBalance(_ context.Context, customerID string, delta float64) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct, ok := f.accounts[customerID]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: customerID}
+	}
+	acct.Balance += delta
+	acct.AvailableBalance += delta
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) SetAccountBalance(_ context.Context, customerID string, balance float64) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct, ok := f.accounts[customerID]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: customerID}
+	}
+	acct.Balance = balance
+	acct.AvailableBalance = balance
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) AdjustAvailableBalance(_ context.Context, customerID string, delta float64) (*domain.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acct, ok := f.accounts[customerID]
+	if !ok {
+		return nil, &domain.ErrNotFound{Resource: "account", ID: customerID}
+	}
+	acct.AvailableBalance += delta
+	cp := *acct
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) ListCreditCards(_ context.Context, customerID string) ([]domain.CreditCard, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.CreditCard
+	for _, c := range f.cards {
+		if c.CustomerID == customerID {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) GetCreditCard(_ context.Context, customerID, cardID string) (*domain.CreditCard, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	card, ok := f.cards[cardID]
+	if !ok || (customerID != "" && card.CustomerID != customerID) {
+		return nil, &domain.ErrNotFound{Resource: "credit_card", ID: cardID}
+	}
+	cp := *card
+	return &cp, nil
+}
+
+// UpdateCreditCardStatus mirrors the Supabase store, which also disables
+// PIX via credit card when a card is cancelled.
+func (f *fakeBankingStore) UpdateCreditCardStatus(_ context.Context, cardID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	card, ok := f.cards[cardID]
+	if !ok {
+		return &domain.ErrNotFound{Resource: "credit_card", ID: cardID}
+	}
+	card.Status = status
+	if status == "cancelled" {
+		card.PixCreditEnabled = false
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) UpdateCreditCardUsedLimit(_ context.Context, cardID string, usedLimit, availableLimit float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if card, ok := f.cards[cardID]; ok {
+		card.UsedLimit = usedLimit
+		card.AvailableLimit = availableLimit
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) UpdateCreditCardPixCreditUsed(_ context.Context, cardID string, pixCreditUsed float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if card, ok := f.cards[cardID]; ok {
+		card.PixCreditUsed = pixCreditUsed
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) ListCreditCardInvoices(_ context.Context, customerID, cardID string) ([]domain.CreditCardInvoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.CreditCardInvoice
+	for _, inv := range f.invoices {
+		if inv.CustomerID == customerID && inv.CardID == cardID {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) UpdateCreditCardInvoiceStatus(_ context.Context, invoiceID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.invoices {
+		if f.invoices[i].ID == invoiceID {
+			f.invoices[i].Status = status
+			return nil
+		}
+	}
+	return &domain.ErrNotFound{Resource: "invoice", ID: invoiceID}
+}
+
+func (f *fakeBankingStore) GetCreditCardInvoiceByMonth(_ context.Context, customerID, cardID, month string) (*domain.CreditCardInvoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, inv := range f.invoices {
+		if inv.CardID == cardID && inv.ReferenceMonth == month && (customerID == "" || inv.CustomerID == customerID) {
+			return &inv, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "invoice", ID: month}
+}
+
+func (f *fakeBankingStore) CreateCreditCardInvoice(_ context.Context, data map[string]any) (*domain.CreditCardInvoice, error) {
+	var inv domain.CreditCardInvoice
+	if err := fakeDecodeRow(data, &inv); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invoices = append(f.invoices, inv)
+	return &inv, nil
+}
+
+func (f *fakeBankingStore) UpdateCreditCardInvoiceTotals(_ context.Context, invoiceID string, totalAmount, minimumPayment float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.invoices {
+		if f.invoices[i].ID == invoiceID {
+			f.invoices[i].TotalAmount = totalAmount
+			f.invoices[i].MinimumPayment = minimumPayment
+			return nil
+		}
+	}
+	return &domain.ErrNotFound{Resource: "invoice", ID: invoiceID}
+}
+
+func (f *fakeBankingStore) ListCreditCardTransactions(_ context.Context, customerID, cardID string, page, pageSize int) ([]domain.CreditCardTransaction, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.CreditCardTransaction
+	for _, data := range f.cardTxs {
+		if data["card_id"] != cardID || (customerID != "" && data["customer_id"] != customerID) {
+			continue
+		}
+		var tx domain.CreditCardTransaction
+		if err := fakeDecodeRow(data, &tx); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, tx)
+	}
+	return fakePage(out, page, pageSize), len(out), nil
+}
+
+// fakeDecodeRow converts an inserted row into its domain type the way the
+// Supabase store decodes what PostgREST returns.
+func fakeDecodeRow(data map[string]any, dst any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+func (f *fakeBankingStore) InsertCreditCardTransaction(_ context.Context, data map[string]any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cardTxErr != nil {
+		return f.cardTxErr
+	}
+	f.cardTxs = append(f.cardTxs, data)
+	return nil
+}
+
+func (f *fakeBankingStore) InsertTransaction(_ context.Context, data map[string]any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.insertTxErr != nil {
+		return f.insertTxErr
+	}
+	f.transactions = append(f.transactions, data)
+	return nil
+}
+
+func (f *fakeBankingStore) UpdateTransactionStatus(_ context.Context, txID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if status == "confirmed" && f.confirmTxErr != nil {
+		return f.confirmTxErr
+	}
+	for _, tx := range f.transactions {
+		if tx["id"] == txID {
+			tx["status"] = status
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) GetTransactionSummary(_ context.Context, customerID string) (*domain.TransactionSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	summary := &domain.TransactionSummary{}
+	categoryTotals := make(map[string]float64)
+	for _, tx := range f.transactions {
+		if tx["customer_id"] != customerID {
+			continue
+		}
+		amount, _ := tx["amount"].(float64)
+		if amount >= 0 {
+			summary.TotalCredits += amount
+		} else {
+			summary.TotalDebits += -amount
+			if category, _ := tx["category"].(string); category != "" {
+				categoryTotals[category] += -amount
+			}
+		}
+		summary.Count++
+	}
+	for category, total := range categoryTotals {
+		summary.TopCategories = append(summary.TopCategories, domain.CategoryTotal{Category: category, Total: total})
+	}
+	return summary, nil
+}
+
+func (f *fakeBankingStore) ListTransactions(_ context.Context, _ string, from, to string) ([]domain.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listTxCalls++
+	var out []domain.Transaction
+	for _, tx := range f.statement {
+		day := tx.Date.Format("2006-01-02")
+		if day >= from && day < to {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) UpdateTransactionAnnotation(_ context.Context, _ string, txID string, annotation *domain.TransactionAnnotation) (*domain.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.statement {
+		if f.statement[i].ID == txID {
+			f.statement[i].Note = annotation.Note
+			f.statement[i].Tags = annotation.Tags
+			tx := f.statement[i]
+			return &tx, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "transaction", ID: txID}
+}
+
+func (f *fakeBankingStore) SearchTransactions(_ context.Context, _ string, filter *domain.TransactionSearchFilter) ([]domain.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.Transaction
+	for _, tx := range f.statement {
+		if filter.Type == "" || tx.Type == filter.Type {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) FindSpendingSummary(_ context.Context, customerID, periodStart, periodEnd string) (*domain.SpendingSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, sum := range f.summaries {
+		if sum.CustomerID == customerID && sum.PeriodStart == periodStart && sum.PeriodEnd == periodEnd {
+			return &sum, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeBankingStore) UpsertSpendingSummary(_ context.Context, summary *domain.SpendingSummary) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, sum := range f.summaries {
+		if sum.CustomerID == summary.CustomerID && sum.PeriodStart == summary.PeriodStart && sum.PeriodEnd == summary.PeriodEnd {
+			f.summaries[i] = *summary
+			return nil
+		}
+	}
+	f.summaries = append(f.summaries, *summary)
+	return nil
+}
+
+func (f *fakeBankingStore) ListCategoryRules(_ context.Context, customerID string) ([]domain.CategoryRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ruleLoads++
+	var out []domain.CategoryRule
+	for _, r := range f.catRules {
+		if r.CustomerID == customerID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) UpsertCategoryRule(_ context.Context, rule *domain.CategoryRule) (*domain.CategoryRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.catRules {
+		if r := &f.catRules[i]; r.CustomerID == rule.CustomerID && r.Pattern == rule.Pattern {
+			r.Category = rule.Category
+			out := *r
+			return &out, nil
+		}
+	}
+	r := *rule
+	r.ID = fmt.Sprintf("rule-%d", len(f.catRules)+1)
+	f.catRules = append(f.catRules, r)
+	return &r, nil
+}
+
+func (f *fakeBankingStore) RecategorizeTransactions(_ context.Context, _ string, txIDs []string, category string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for i := range f.statement {
+		if slices.Contains(txIDs, f.statement[i].ID) {
+			f.statement[i].Category = category
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeBankingStore) InsertSpendingDigest(_ context.Context, digest *domain.SpendingDigest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.digests {
+		if d.CustomerID == digest.CustomerID && d.Period == digest.Period && d.From == digest.From {
+			return &domain.ErrDuplicate{Key: "spending_digests"}
+		}
+	}
+	f.digests = append(f.digests, *digest)
+	return nil
+}
+
+func (f *fakeBankingStore) SpendingDigestExists(_ context.Context, customerID, period, periodStart string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.digests {
+		if d.CustomerID == customerID && d.Period == period && d.From == periodStart {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) DeleteSpendingSummaries(_ context.Context, customerID, since string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kept := f.summaries[:0]
+	for _, sum := range f.summaries {
+		if sum.CustomerID != customerID || sum.PeriodEnd < since {
+			kept = append(kept, sum)
+		}
+	}
+	f.summaries = kept
+	return nil
+}
+
+func (f *fakeBankingStore) ListNotifications(_ context.Context, customerID string, unreadOnly bool, page, pageSize int) ([]domain.Notification, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []domain.Notification
+	for _, n := range f.notifs {
+		if n.CustomerID == customerID && (!unreadOnly || !n.IsRead) {
+			matched = append(matched, n)
+		}
+	}
+	return fakePage(matched, page, pageSize), len(matched), nil
+}
+
+func (f *fakeBankingStore) GetNotification(_ context.Context, customerID, notifID string) (*domain.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, n := range f.notifs {
+		if n.ID == notifID && n.CustomerID == customerID {
+			return &n, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "notification", ID: notifID}
+}
+
+func (f *fakeBankingStore) MarkNotificationRead(_ context.Context, notifID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.notifs {
+		if f.notifs[i].ID == notifID {
+			f.notifs[i].IsRead = true
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) MarkNotificationsRead(_ context.Context, customerID string, ids []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	updated := 0
+	for i := range f.notifs {
+		n := &f.notifs[i]
+		if n.CustomerID == customerID && !n.IsRead && slices.Contains(ids, n.ID) {
+			now := time.Now()
+			n.IsRead, n.ReadAt = true, &now
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (f *fakeBankingStore) InsertNotification(_ context.Context, notif *domain.Notification) (*domain.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := *notif
+	n.ID = fmt.Sprintf("notif-%d", len(f.notifs)+1)
+	n.CreatedAt = time.Now()
+	f.notifs = append(f.notifs, n)
+	return &n, nil
+}
+
+func (f *fakeBankingStore) GetNotificationPreferences(_ context.Context, customerID string) (domain.NotificationPreferences, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored, ok := f.notifPrefs[customerID]
+	if !ok {
+		return nil, nil
+	}
+	cp := make(domain.NotificationPreferences, len(stored))
+	for t, p := range stored {
+		cp[t] = p
+	}
+	return cp, nil
+}
+
+func (f *fakeBankingStore) SaveNotificationPreferences(_ context.Context, customerID string, prefs domain.NotificationPreferences) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.notifPrefs == nil {
+		f.notifPrefs = make(map[string]domain.NotificationPreferences)
+	}
+	f.notifPrefs[customerID] = prefs
+	return nil
+}
+
+// fakePage returns the rows of a 1-based page, as limit/offset would.
+func fakePage[T any](rows []T, page, pageSize int) []T {
+	start := min(max(page-1, 0)*pageSize, len(rows))
+	return rows[start:min(start+pageSize, len(rows))]
+}
+
+func (f *fakeBankingStore) LookupPixKey(_ context.Context, keyType, keyValue string) (*domain.PixKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixKeys {
+		k := f.pixKeys[i]
+		if k.KeyType == keyType && k.KeyValue == keyValue && k.Status == "active" {
+			return &k, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyValue}
+}
+
+func (f *fakeBankingStore) LookupPixKeyByValue(_ context.Context, keyValue string) (*domain.PixKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixKeys {
+		k := f.pixKeys[i]
+		if k.KeyValue == keyValue && k.Status == "active" {
+			return &k, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyValue}
+}
+
+func (f *fakeBankingStore) GetCustomerName(_ context.Context, customerID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.customerNames[customerID], nil
+}
+
+func (f *fakeBankingStore) GetCustomerCreditScore(_ context.Context, customerID string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	score, ok := f.creditScores[customerID]
+	if !ok {
+		return 0, &domain.ErrNotFound{Resource: "profile", ID: customerID}
+	}
+	return score, nil
+}
+
+func (f *fakeBankingStore) GetCustomerLookupData(_ context.Context, customerID string) (name, document, bank, branch, account string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name, ok := f.customerNames[customerID]
+	if !ok {
+		return "", "", "", "", "", &domain.ErrNotFound{Resource: "customer", ID: customerID}
+	}
+	return name, "12345678000190", "Itaú Unibanco", "0001", "12345-6", nil
+}
+
+func (f *fakeBankingStore) GetTransactionLimit(_ context.Context, _, txType string) (*domain.TransactionLimit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if l, ok := f.limits[txType]; ok {
+		cp := *l
+		return &cp, nil
+	}
+	return nil, &domain.ErrNotFound{Resource: "transaction_limit", ID: txType}
+}
+
+func (f *fakeBankingStore) ListTransactionLimits(_ context.Context, customerID string) ([]domain.TransactionLimit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.TransactionLimit
+	for _, l := range f.limits {
+		if l.CustomerID == customerID {
+			out = append(out, *l)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) CreateTransactionLimit(_ context.Context, limit *domain.TransactionLimit) (*domain.TransactionLimit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.limits[limit.TransactionType]; ok {
+		return nil, &domain.ErrDuplicate{Key: "transaction_limits"}
+	}
+	cp := *limit
+	f.limits[limit.TransactionType] = &cp
+	return limit, nil
+}
+
+func (f *fakeBankingStore) AddTransactionLimitUsage(_ context.Context, _, txType string, daily, monthly float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if l, ok := f.limits[txType]; ok {
+		l.DailyUsed += daily
+		l.MonthlyUsed += monthly
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) CreatePixTransfer(_ context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.pixTransfers {
+		if t.IdempotencyKey == req.IdempotencyKey {
+			return nil, &domain.ErrDuplicate{Key: req.IdempotencyKey}
+		}
+	}
+	transfer := domain.PixTransfer{
+		ID:                  fmt.Sprintf("pix-%d", len(f.pixTransfers)+1),
+		IdempotencyKey:      req.IdempotencyKey,
+		SourceCustomerID:    customerID,
+		SourceAccountID:     req.SourceAccountID,
+		DestinationKeyType:  req.DestinationKeyType,
+		DestinationKeyValue: req.DestinationKeyValue,
+		DestinationName:     req.DestinationName,
+		DestinationDocument: req.DestinationDocument,
+		Amount:              req.Amount,
+		Description:         req.Description,
+		Status:              "pending",
+		FundedBy:            req.FundedBy,
+		CreditCardID:        req.CreditCardID,
+	}
+	if req.ScheduledFor != "" {
+		at, _ := time.Parse(time.RFC3339, req.ScheduledFor)
+		transfer.ScheduledFor = &at
+		transfer.Status = "scheduled"
+	}
+	f.pixTransfers = append(f.pixTransfers, transfer)
+	return &transfer, nil
+}
+
+func (f *fakeBankingStore) GetPixTransferByIdempotencyKey(_ context.Context, customerID, key string) (*domain.PixTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.pixTransfers {
+		if t.SourceCustomerID == customerID && t.IdempotencyKey == key {
+			return &t, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_transfer", ID: key}
+}
+
+func (f *fakeBankingStore) ListPixTransfers(_ context.Context, customerID string, _, pageSize int) ([]domain.PixTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.PixTransfer
+	for i := len(f.pixTransfers) - 1; i >= 0 && len(out) < pageSize; i-- {
+		if f.pixTransfers[i].SourceCustomerID == customerID {
+			out = append(out, f.pixTransfers[i])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) ListPixTransfersFiltered(_ context.Context, customerID string, filter *domain.PixTransferFilter) ([]domain.PixTransfer, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []domain.PixTransfer
+	for i := len(f.pixTransfers) - 1; i >= 0; i-- {
+		t := f.pixTransfers[i]
+		day := t.CreatedAt.Format("2006-01-02")
+		if t.SourceCustomerID != customerID ||
+			(filter.Status != "" && t.Status != filter.Status) ||
+			(filter.From != "" && day < filter.From) ||
+			(filter.To != "" && day > filter.To) {
+			continue
+		}
+		matched = append(matched, t)
+	}
+	return fakePage(matched, filter.Page, filter.PageSize), len(matched), nil
+}
+
+func (f *fakeBankingStore) UpdatePixTransferStatus(_ context.Context, transferID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixTransfers {
+		if f.pixTransfers[i].ID == transferID {
+			f.pixTransfers[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) ListDuePixTransfers(_ context.Context, before time.Time, limit int) ([]domain.PixTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.PixTransfer
+	for _, t := range f.pixTransfers {
+		if t.Status == "scheduled" && t.ScheduledFor != nil && !t.ScheduledFor.After(before) && len(out) < limit {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) ClaimScheduledPixTransfer(_ context.Context, transferID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixTransfers {
+		if f.pixTransfers[i].ID == transferID && f.pixTransfers[i].Status == "scheduled" {
+			f.pixTransfers[i].Status = "processing"
+			f.pixTransfers[i].UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) FailStalePixTransfers(_ context.Context, since time.Time, reason string) ([]domain.PixTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var failed []domain.PixTransfer
+	for i := range f.pixTransfers {
+		if t := &f.pixTransfers[i]; t.Status == "processing" && t.UpdatedAt.Before(since) {
+			t.Status, t.FailureReason, t.UpdatedAt = "failed", reason, time.Now()
+			failed = append(failed, *t)
+		}
+	}
+	return failed, nil
+}
+
+func (f *fakeBankingStore) StorePixConfirmation(_ context.Context, token *domain.PixConfirmationToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pixConfirms[token.Token] = *token
+	return nil
+}
+
+func (f *fakeBankingStore) ClaimPixConfirmation(_ context.Context, token, customerID, fingerprint string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.pixConfirms[token]
+	if !ok || c.CustomerID != customerID || c.Fingerprint != fingerprint || c.Status != "issued" || !time.Now().Before(c.ExpiresAt) {
+		return false, nil
+	}
+	c.Status = "claimed"
+	f.pixConfirms[token] = c
+	return true, nil
+}
+
+func (f *fakeBankingStore) SetPixConfirmationStatus(_ context.Context, token, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.pixConfirms[token]; ok && c.Status == "claimed" {
+		c.Status = status
+		f.pixConfirms[token] = c
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) SavePixReceipt(_ context.Context, receipt *domain.PixReceipt) (*domain.PixReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.receiptErr != nil {
+		return nil, f.receiptErr
+	}
+	f.pixReceipts = append(f.pixReceipts, *receipt)
+	return receipt, nil
+}
+
+func (f *fakeBankingStore) FindFavorite(_ context.Context, fav *domain.Favorite) (*domain.Favorite, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.findFavoriteLocked(fav), nil
+}
+
+// findFavoriteLocked must be called with f.mu held.
+func (f *fakeBankingStore) findFavoriteLocked(fav *domain.Favorite) *domain.Favorite {
+	for i := range f.favorites {
+		e := f.favorites[i]
+		if e.CustomerID != fav.CustomerID || e.DestinationType != fav.DestinationType {
+			continue
+		}
+		if fav.PixKeyValue != "" && e.PixKeyValue == fav.PixKeyValue {
+			return &e
+		}
+		if fav.PixKeyValue == "" && e.BankCode == fav.BankCode && e.Branch == fav.Branch && e.AccountNumber == fav.AccountNumber {
+			return &e
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) CreateFavorite(_ context.Context, fav *domain.Favorite) (*domain.Favorite, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.findFavoriteLocked(fav) != nil {
+		return nil, &domain.ErrDuplicate{Key: "favorites"}
+	}
+	created := *fav
+	created.ID = fmt.Sprintf("fav-%d", len(f.favorites)+1)
+	f.favorites = append(f.favorites, created)
+	return &created, nil
+}
+
+func (f *fakeBankingStore) GetPixReceiptByTransferID(_ context.Context, transferID string) (*domain.PixReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.pixReceipts {
+		if r.TransferID == transferID {
+			return &r, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_receipt", ID: transferID}
+}
+
+func (f *fakeBankingStore) CreateDOCTransfer(_ context.Context, transfer *domain.DOCTransfer) (*domain.DOCTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.docTransfers {
+		if t.CustomerID == transfer.CustomerID && t.IdempotencyKey == transfer.IdempotencyKey {
+			return nil, &domain.ErrDuplicate{Key: transfer.IdempotencyKey}
+		}
+	}
+	f.docTransfers = append(f.docTransfers, *transfer)
+	cp := *transfer
+	return &cp, nil
+}
+
+func (f *fakeBankingStore) GetDOCTransferByIdempotencyKey(_ context.Context, customerID, key string) (*domain.DOCTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.docTransfers {
+		if t.CustomerID == customerID && t.IdempotencyKey == key {
+			return &t, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "doc_transfer", ID: key}
+}
+
+func (f *fakeBankingStore) ListDueDOCTransfers(_ context.Context, date string, limit int) ([]domain.DOCTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.DOCTransfer
+	for _, t := range f.docTransfers {
+		if t.Status == "held" && t.SettlementDate <= date && len(out) < limit {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) ClaimDOCTransfer(_ context.Context, transferID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.docTransfers {
+		if f.docTransfers[i].ID == transferID && f.docTransfers[i].Status == "held" {
+			f.docTransfers[i].Status = "processing"
+			f.docTransfers[i].UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) SettleDOCTransfer(_ context.Context, transferID string, entry map[string]any) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.docTransfers {
+		t := &f.docTransfers[i]
+		if t.ID != transferID || t.Status != "processing" {
+			continue
+		}
+		acct := f.accounts[t.CustomerID]
+		if acct == nil || acct.Status != "active" {
+			if acct != nil {
+				acct.AvailableBalance += t.Amount
+			}
+			t.Status = "failed"
+			return t.Status, nil
+		}
+		acct.Balance -= t.Amount
+		tx := map[string]any{"customer_id": t.CustomerID, "amount": -t.Amount, "type": "transfer_out", "status": "confirmed"}
+		for k, v := range entry {
+			tx[k] = v
+		}
+		f.transactions = append(f.transactions, tx)
+		t.Status = "completed"
+		return t.Status, nil
+	}
+	return "", &domain.ErrInvalidState{Resource: "doc_transfer", Status: "not processing", Action: "settle"}
+}
+
+func (f *fakeBankingStore) ReleaseStaleDOCTransfers(_ context.Context, since time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for i := range f.docTransfers {
+		if t := &f.docTransfers[i]; t.Status == "processing" && t.UpdatedAt.Before(since) {
+			t.Status = "held"
+			t.UpdatedAt = time.Now()
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeBankingStore) SaveTransferReceipt(_ context.Context, receipt *domain.TransferReceipt) (*domain.TransferReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txReceipts = append(f.txReceipts, *receipt)
+	return receipt, nil
+}
+
+func (f *fakeBankingStore) GetTransferReceiptByTransferID(_ context.Context, transferID string) (*domain.TransferReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.txReceipts {
+		if r.TransferID == transferID {
+			return &r, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "transfer_receipt", ID: transferID}
+}
+
+func (f *fakeBankingStore) ListPixReceipts(_ context.Context, customerID string, page, pageSize int) ([]domain.PixReceipt, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.PixReceipt
+	for _, r := range f.pixReceipts {
+		if r.CustomerID == customerID {
+			out = append(out, r)
+		}
+	}
+	// created_at desc, id desc — the order the store asks PostgREST for
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt > out[j].CreatedAt
+		}
+		return out[i].ID > out[j].ID
+	})
+	return fakePage(out, page, pageSize), len(out), nil
+}
+
+func (f *fakeBankingStore) ListBillPayments(_ context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []domain.BillPayment
+	for _, b := range f.bills {
+		if b.CustomerID == customerID {
+			matched = append(matched, b)
+		}
+	}
+	return fakePage(matched, page, pageSize), len(matched), nil
+}
+
+func (f *fakeBankingStore) GetBillPayment(_ context.Context, customerID, billID string) (*domain.BillPayment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, b := range f.bills {
+		if b.ID == billID && b.CustomerID == customerID {
+			return &b, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "bill_payment", ID: billID}
+}
+
+func (f *fakeBankingStore) UpdateBillPaymentStatus(_ context.Context, billID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.bills {
+		if f.bills[i].ID == billID {
+			f.bills[i].Status = status
+			return nil
+		}
+	}
+	return &domain.ErrNotFound{Resource: "bill_payment", ID: billID}
+}
+
+func (f *fakeBankingStore) ClaimBillPaymentCancel(_ context.Context, billID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.bills {
+		switch f.bills[i].Status {
+		case "pending", "scheduled", "validated":
+			if f.bills[i].ID == billID {
+				f.bills[i].Status = "cancelled"
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) CreateDebitPurchase(_ context.Context, customerID string, req *domain.DebitPurchaseRequest) (*domain.DebitPurchase, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	purchase := domain.DebitPurchase{
+		ID:              fmt.Sprintf("debit-%d", len(f.debits)+1),
+		CustomerID:      customerID,
+		TransactionDate: time.Now(),
+		Amount:          req.Amount,
+		MerchantName:    req.MerchantName,
+		Category:        req.Category,
+		Status:          "completed",
+	}
+	f.debits = append(f.debits, purchase)
+	return &purchase, nil
+}
+
+func (f *fakeBankingStore) GetDebitPurchase(_ context.Context, customerID, purchaseID string) (*domain.DebitPurchase, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.debits {
+		if d.ID == purchaseID && d.CustomerID == customerID {
+			return &d, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "debit_purchase", ID: purchaseID}
+}
+
+func (f *fakeBankingStore) ClaimDebitPurchaseRefund(_ context.Context, purchaseID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.debits {
+		if f.debits[i].ID == purchaseID && (f.debits[i].Status == "confirmed" || f.debits[i].Status == "completed") {
+			f.debits[i].Status = "refunded"
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) UpdateDebitPurchaseStatus(_ context.Context, purchaseID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.debits {
+		if f.debits[i].ID == purchaseID {
+			f.debits[i].Status = status
+			return nil
+		}
+	}
+	return &domain.ErrNotFound{Resource: "debit_purchase", ID: purchaseID}
+}
+
+func (f *fakeBankingStore) GetPixReceipt(_ context.Context, receiptID string) (*domain.PixReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixReceipts {
+		if f.pixReceipts[i].ID == receiptID {
+			r := f.pixReceipts[i]
+			return &r, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_receipt", ID: receiptID}
+}
+
+func (f *fakeBankingStore) CreateWebhook(_ context.Context, hook *domain.Webhook) (*domain.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := *hook
+	created.ID = fmt.Sprintf("wh-%d", len(f.webhooks)+1)
+	f.webhooks = append(f.webhooks, created)
+	return &created, nil
+}
+
+func (f *fakeBankingStore) ListWebhooks(_ context.Context, customerID string) ([]domain.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.Webhook
+	for _, h := range f.webhooks {
+		if h.CustomerID == customerID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) GetWebhook(_ context.Context, customerID, webhookID string) (*domain.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, h := range f.webhooks {
+		if h.ID == webhookID && h.CustomerID == customerID {
+			return &h, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "webhook", ID: webhookID}
+}
+
+func (f *fakeBankingStore) RevokeWebhook(_ context.Context, customerID, webhookID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.webhooks {
+		if f.webhooks[i].ID == webhookID && f.webhooks[i].CustomerID == customerID {
+			f.webhooks[i].Status = "revoked"
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) SaveWebhookDelivery(_ context.Context, delivery *domain.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, *delivery)
+	return nil
+}
+
+func (f *fakeBankingStore) ListWebhookDeliveries(_ context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.WebhookDelivery
+	for i := len(f.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if f.deliveries[i].WebhookID == webhookID {
+			out = append(out, f.deliveries[i])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) GetWebhookDelivery(_ context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.deliveries {
+		if d.ID == deliveryID && d.WebhookID == webhookID {
+			return &d, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "webhook_delivery", ID: deliveryID}
+}
+
+func (f *fakeBankingStore) CreatePixKey(_ context.Context, key *domain.PixKey) (*domain.PixKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pixKeys = append(f.pixKeys, *key)
+	return key, nil
+}
+
+func (f *fakeBankingStore) ListPixKeys(_ context.Context, customerID string) ([]domain.PixKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.PixKey
+	for _, k := range f.pixKeys {
+		if k.CustomerID == customerID && k.Status == "active" {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) GetPixKey(_ context.Context, customerID, keyID string) (*domain.PixKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range f.pixKeys {
+		if k.ID == keyID && k.CustomerID == customerID {
+			return &k, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyID}
+}
+
+func (f *fakeBankingStore) DeletePixKey(_ context.Context, customerID, keyID string) error {
+	return f.setPixKeyStatus(customerID, keyID, "deleted")
+}
+
+func (f *fakeBankingStore) ReactivatePixKey(_ context.Context, customerID, keyID string) error {
+	return f.setPixKeyStatus(customerID, keyID, "active")
+}
+
+func (f *fakeBankingStore) setPixKeyStatus(customerID, keyID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixKeys {
+		if f.pixKeys[i].ID == keyID && f.pixKeys[i].CustomerID == customerID {
+			f.pixKeys[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (f *fakeBankingStore) CountPixKeys(_ context.Context, customerID string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, k := range f.pixKeys {
+		if k.CustomerID == customerID && k.Status == "active" {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeBankingStore) StorePixKeyVerificationCode(_ context.Context, code *domain.PixKeyVerificationCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *code
+	stored.ID = fmt.Sprintf("code-%d", len(f.pixKeyCodes)+1)
+	f.pixKeyCodes = append(f.pixKeyCodes, stored)
+	return nil
+}
+
+func (f *fakeBankingStore) GetValidPixKeyVerificationCode(_ context.Context, customerID, keyType, keyValue, code string) (*domain.PixKeyVerificationCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.pixKeyCodes) - 1; i >= 0; i-- {
+		c := f.pixKeyCodes[i]
+		if c.CustomerID == customerID && c.KeyType == keyType && c.KeyValue == keyValue &&
+			c.Code == code && !c.Used && c.ExpiresAt.After(time.Now()) {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeBankingStore) MarkPixKeyVerificationCodeUsed(_ context.Context, codeID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.pixKeyCodes {
+		if f.pixKeyCodes[i].ID == codeID {
+			f.pixKeyCodes[i].Used = true
+		}
+	}
+	return nil
+}
+
+/* Fake webhook dispatcher */
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	events  []*domain.WebhookEvent
+	resends []*domain.WebhookDelivery
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event *domain.WebhookEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *recordingPublisher) Resend(_ context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resends = append(p.resends, delivery)
+	resent := *delivery
+	resent.ID = fmt.Sprintf("%s-resend-%d", delivery.ID, len(p.resends))
+	resent.WebhookID = hook.ID
+	resent.Status = "delivered"
+	resent.ResponseCode = 200
+	resent.Attempts = 1
+	return &resent, nil
+}
+
+/* Helpers */
+
+const (
+	testCustomerID = "cust-001"
+	testAccountID  = "acct-001"
+	testCardID     = "card-001"
+)
+
+// seedCustomer registers a customer with an active account and a
+// PIX-enabled credit card.
+func seedCustomer(store *fakeBankingStore, balance float64) {
+	store.customerNames[testCustomerID] = "Empresa Teste LTDA"
+	store.accounts[testCustomerID] = &domain.Account{
+		ID:               testAccountID,
+		CustomerID:       testCustomerID,
+		Balance:          balance,
+		AvailableBalance: balance,
+		Status:           "active",
+	}
+	store.cards[testCardID] = &domain.CreditCard{
+		ID:               testCardID,
+		CustomerID:       testCustomerID,
+		CreditLimit:      10000,
+		AvailableLimit:   10000,
+		Status:           "active",
+		PixCreditEnabled: true,
+		PixCreditLimit:   10000,
+	}
+}
+
+// seedPixRecipient registers another customer reachable through an email key.
+func seedPixRecipient(store *fakeBankingStore, customerID, name, email string) {
+	store.customerNames[customerID] = name
+	store.accounts[customerID] = &domain.Account{ID: "acct-" + customerID, CustomerID: customerID, Status: "active"}
+	store.pixKeys = append(store.pixKeys, domain.PixKey{
+		ID:         "key-" + customerID,
+		CustomerID: customerID,
+		KeyType:    "email",
+		KeyValue:   email,
+		Status:     "active",
+	})
+}
+
+func (f *fakeBankingStore) ClearCustomerData(_ context.Context, customerID string) (map[string]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cleared := make(map[string]int)
+	f.transactions, cleared["customer_transactions"] = dropRows(f.transactions, func(tx map[string]any) bool { return tx["customer_id"] == customerID })
+	f.cardTxs, cleared["credit_card_transactions"] = dropRows(f.cardTxs, func(tx map[string]any) bool { return tx["customer_id"] == customerID })
+	f.pixReceipts, cleared["pix_receipts"] = dropRows(f.pixReceipts, func(r domain.PixReceipt) bool { return r.CustomerID == customerID })
+	f.txReceipts, cleared["transfer_receipts"] = dropRows(f.txReceipts, func(r domain.TransferReceipt) bool { return r.CustomerID == customerID })
+	f.docTransfers, cleared["doc_transfers"] = dropRows(f.docTransfers, func(t domain.DOCTransfer) bool { return t.CustomerID == customerID })
+	f.pixTransfers, cleared["pix_transfers"] = dropRows(f.pixTransfers, func(t domain.PixTransfer) bool { return t.SourceCustomerID == customerID })
+	f.bills, cleared["bill_payments"] = dropRows(f.bills, func(b domain.BillPayment) bool { return b.CustomerID == customerID })
+	f.invoices, cleared["credit_card_invoices"] = dropRows(f.invoices, func(inv domain.CreditCardInvoice) bool { return inv.CustomerID == customerID })
+	for key, adj := range f.devBalance {
+		if adj.CustomerID == customerID {
+			delete(f.devBalance, key)
+			cleared["dev_balance_adjustments"]++
+		}
+	}
+	return cleared, nil
+}
+
+// dropRows removes the rows matching drop and reports how many were removed.
+func dropRows[T any](rows []T, drop func(T) bool) ([]T, int) {
+	kept := rows[:0]
+	for _, r := range rows {
+		if !drop(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept, len(rows) - len(kept)
+}
+
+/* Scheduled transfers */
+
+func (f *fakeBankingStore) GetScheduledTransfer(_ context.Context, customerID, transferID string) (*domain.ScheduledTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.schedules {
+		if t.ID == transferID && (customerID == "" || t.SourceCustomerID == customerID) {
+			t.SkippedDates = append([]string(nil), t.SkippedDates...)
+			return &t, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "scheduled_transfer", ID: transferID}
+}
+
+func (f *fakeBankingStore) ListScheduledTransfersFiltered(_ context.Context, customerID, status string, page, pageSize int) ([]domain.ScheduledTransfer, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []domain.ScheduledTransfer
+	for _, t := range f.schedules {
+		if t.SourceCustomerID == customerID && (status == "" || t.Status == status) {
+			matched = append(matched, t)
+		}
+	}
+	return fakePage(matched, page, pageSize), len(matched), nil
+}
+
+func (f *fakeBankingStore) ListDueScheduledTransfers(_ context.Context, date string, limit int) ([]domain.ScheduledTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.ScheduledTransfer
+	for _, t := range f.schedules {
+		if t.Status == "scheduled" && t.NextExecutionDate != "" && t.NextExecutionDate <= date && len(out) < limit {
+			t.SkippedDates = append([]string(nil), t.SkippedDates...)
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBankingStore) AdvanceScheduledTransfer(_ context.Context, transfer *domain.ScheduledTransfer, fromDate string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.schedules {
+		if f.schedules[i].ID == transfer.ID && f.schedules[i].NextExecutionDate == fromDate {
+			f.schedules[i] = *transfer
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeBankingStore) UpdateScheduledTransferStatus(_ context.Context, transferID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.schedules {
+		if f.schedules[i].ID == transferID {
+			f.schedules[i].Status = status
+			return nil
+		}
+	}
+	return &domain.ErrNotFound{Resource: "scheduled_transfer", ID: transferID}
+}
+
+func (f *fakeBankingStore) GetDevBalanceAdjustment(_ context.Context, key string) (*domain.DevBalanceAdjustment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adj, ok := f.devBalance[key]
+	if !ok {
+		return nil, nil
+	}
+	return &adj, nil
+}
+
+func (f *fakeBankingStore) SaveDevBalanceAdjustment(_ context.Context, adj *domain.DevBalanceAdjustment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.devBalance[adj.IdempotencyKey]; ok {
+		return &domain.ErrDuplicate{Key: adj.IdempotencyKey}
+	}
+	if f.devBalance == nil {
+		f.devBalance = make(map[string]domain.DevBalanceAdjustment)
+	}
+	f.devBalance[adj.IdempotencyKey] = *adj
+	return nil
+}
+
+func (f *fakeBankingStore) SetDevBalanceAdjustmentResult(_ context.Context, key string, newBalance float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adj, ok := f.devBalance[key]
+	if !ok {
+		return &domain.ErrNotFound{Resource: "dev_balance_adjustment", ID: key}
+	}
+	adj.NewBalance = &newBalance
+	f.devBalance[key] = adj
+	return nil
+}
+
+func (f *fakeBankingStore) DeleteDevBalanceAdjustment(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.devBalance, key)
+	return nil
+}
+
+/* Activity feed */
+
+// fakeKeyset returns at most limit rows before the (before, beforeID) key,
+// most recent first, as the store's keyset queries do.
+func fakeKeyset[T any](rows []T, key func(T) (time.Time, string), before time.Time, beforeID string, limit int) []T {
+	var out []T
+	for _, r := range rows {
+		ts, id := key(r)
+		if ts.Before(before) || (ts.Equal(before) && id < beforeID) {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ti, idi := key(out[i])
+		tj, idj := key(out[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return idi > idj
+	})
+	return out[:min(limit, len(out))]
+}
+
+func (f *fakeBankingStore) ListTransactionsBefore(_ context.Context, _ string, before time.Time, beforeID string, limit int) ([]domain.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fakeKeyset(f.statement, func(tx domain.Transaction) (time.Time, string) { return tx.Date, tx.ID }, before, beforeID, limit), nil
+}
+
+func (f *fakeBankingStore) ListPixReceiptsBefore(_ context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.PixReceipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var own []domain.PixReceipt
+	for _, r := range f.pixReceipts {
+		if r.CustomerID == customerID {
+			own = append(own, r)
+		}
+	}
+	return fakeKeyset(own, func(r domain.PixReceipt) (time.Time, string) {
+		ts, _ := time.Parse(time.RFC3339, r.ExecutedAt)
+		return ts, r.ID
+	}, before, beforeID, limit), nil
+}
+
+func (f *fakeBankingStore) ListBillPaymentsBefore(_ context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.BillPayment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var own []domain.BillPayment
+	for _, b := range f.bills {
+		if b.CustomerID == customerID {
+			own = append(own, b)
+		}
+	}
+	return fakeKeyset(own, func(b domain.BillPayment) (time.Time, string) { return b.CreatedAt, b.ID }, before, beforeID, limit), nil
+}
+
+func (f *fakeBankingStore) ListNotificationsBefore(_ context.Context, customerID string, before time.Time, beforeID string, limit int) ([]domain.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var own []domain.Notification
+	for _, n := range f.notifs {
+		if n.CustomerID == customerID {
+			own = append(own, n)
+		}
+	}
+	return fakeKeyset(own, func(n domain.Notification) (time.Time, string) { return n.CreatedAt, n.ID }, before, beforeID, limit), nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+
+	"go.uber.org/zap"
+)
+
+/*
+ * Bill Payments — barcode input methods
+ *
+ * Typed, pasted and uploaded input carries the digits already. A camera_scan
+ * may carry only the photo (image_base64); it goes through the configured
+ * BarcodeDecoder to extract the digits, which are then validated like typed
+ * input. No decoder is configured by default.
+ */
+
+// barcodeInputMethods are the input methods a bill validation accepts.
+var barcodeInputMethods = map[string]bool{"typed": true, "pasted": true, "camera_scan": true, "file_upload": true}
+
+// ErrNoBarcodeDecoder is returned for a camera_scan image when no barcode
+// decoder is configured.
+var ErrNoBarcodeDecoder = &domain.ErrValidation{Field: "input_method", Message: "camera_scan images are not supported, type or paste the digitable line"}
+
+// noBarcodeDecoder is the decoder used until SetBarcodeDecoder is called.
+type noBarcodeDecoder struct{}
+
+func (noBarcodeDecoder) DecodeBarcode(context.Context, []byte) (string, error) {
+	return "", ErrNoBarcodeDecoder
+}
+
+// SetBarcodeDecoder configures the decoder that reads camera_scan images.
+// A nil decoder is ignored.
+func (s *BankingService) SetBarcodeDecoder(d port.BarcodeDecoder) {
+	if d != nil {
+		s.barcodeDecoder = d
+	}
+}
+
+// barcodeInput validates the input method of req and returns the barcode or
+// digitable line to validate, decoding the camera_scan image when there is one.
+func (s *BankingService) barcodeInput(ctx context.Context, req *domain.BarcodeValidationRequest) (string, error) {
+	if req.InputMethod == "" {
+		req.InputMethod = "typed"
+	}
+	if !barcodeInputMethods[req.InputMethod] {
+		return "", &domain.ErrValidation{Field: "input_method", Message: "must be typed, pasted, camera_scan or file_upload"}
+	}
+
+	if req.InputMethod == "camera_scan" && req.ImageBase64 != "" {
+		return s.decodeBarcodeImage(ctx, req.ImageBase64)
+	}
+
+	input := req.DigitableLine
+	if input == "" {
+		input = req.Barcode
+	}
+	if input == "" {
+		if req.InputMethod == "camera_scan" {
+			return "", &domain.ErrValidation{Field: "image_base64", Message: "required for camera_scan without a digitable line"}
+		}
+		return "", &domain.ErrValidation{Field: "digitable_line|barcode", Message: "at least one is required"}
+	}
+	return input, nil
+}
+
+func (s *BankingService) decodeBarcodeImage(ctx context.Context, imageBase64 string) (string, error) {
+	// Accept data URLs as sent by browsers (data:image/jpeg;base64,...)
+	if i := strings.Index(imageBase64, ","); strings.HasPrefix(imageBase64, "data:") && i >= 0 {
+		imageBase64 = imageBase64[i+1:]
+	}
+	image, err := base64.StdEncoding.DecodeString(imageBase64)
+	if err != nil {
+		return "", &domain.ErrValidation{Field: "image_base64", Message: "invalid base64"}
+	}
+
+	digits, err := s.barcodeDecoder.DecodeBarcode(ctx, image)
+	if errors.Is(err, ErrNoBarcodeDecoder) {
+		return "", err
+	}
+	if err != nil || digitOnlyRegex.ReplaceAllString(digits, "") == "" {
+		s.logger.Warn("could not decode bill barcode image", zap.Int("image_bytes", len(image)), zap.Error(err))
+		return "", &domain.ErrInvalidBarcode{Reason: "no barcode could be read from the image, type or paste the digitable line"}
+	}
+	return digits, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -28,12 +29,9 @@ func (s *BankingService) ValidateBarcode(ctx context.Context, req *domain.Barcod
 	resp := &domain.BarcodeValidationResponse{}
 
 	// Determine what we're validating
-	input := req.DigitableLine
-	if input == "" {
-		input = req.Barcode
-	}
-	if input == "" {
-		return nil, &domain.ErrValidation{Field: "digitable_line|barcode", Message: "at least one is required"}
+	input, err := s.barcodeInput(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
 	// Clean: keep only digits
@@ -92,9 +90,11 @@ func (s *BankingService) ValidateBarcode(ctx context.Context, req *domain.Barcod
 	return resp, nil
 }
 
-func (s *BankingService) PayBill(ctx context.Context, customerID string, req *domain.BillPaymentRequest) (*domain.BillPayment, error) {
+func (s *BankingService) PayBill(ctx context.Context, customerID string, req *domain.BillPaymentRequest) (bill *domain.BillPayment, err error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.PayBill")
 	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.String("funded_by", "balance"))
+	defer func() { endMoneySpan(span, err) }()
 
 	start := time.Now()
 	defer func() { s.metrics.RecordRequestDuration("bill_payment", time.Since(start)) }()
@@ -133,20 +133,28 @@ func (s *BankingService) PayBill(ctx context.Context, customerID string, req *do
 	if amount == 0 {
 		amount = valResult.Amount
 	}
+	span.SetAttributes(attribute.Float64("amount", amount))
 
-	if account.AvailableBalance < amount {
-		return nil, &domain.ErrInsufficientFunds{Available: account.AvailableBalance, Required: amount}
+	if spendableBalance(account) < amount {
+		return nil, &domain.ErrInsufficientFunds{Available: spendableBalance(account), Required: amount}
 	}
 
 	// Check limit
-	limit, err := s.store.GetTransactionLimit(ctx, customerID, "bill_payment")
+	limit, err := s.transactionLimit(ctx, customerID, "bill_payment")
 	if err == nil && limit != nil {
 		if amount > limit.SingleLimit {
 			return nil, &domain.ErrLimitExceeded{LimitType: "single_bill", Limit: limit.SingleLimit, Current: amount}
 		}
+		if limit.DailyUsed+amount > limit.DailyLimit {
+			return nil, &domain.ErrLimitExceeded{LimitType: "daily_bill", Limit: limit.DailyLimit, Current: limit.DailyUsed + amount}
+		}
+		if limit.MonthlyUsed+amount > limit.MonthlyLimit {
+			return nil, &domain.ErrLimitExceeded{LimitType: "monthly_bill", Limit: limit.MonthlyLimit, Current: limit.MonthlyUsed + amount}
+		}
 	}
 
-	bill, err := s.store.CreateBillPayment(ctx, customerID, req, valResult)
+	s.seedTransactionLimit(ctx, customerID, "bill_payment")
+	bill, err = s.store.CreateBillPayment(ctx, customerID, req, valResult)
 	if err != nil {
 		s.logger.Error("failed to create bill payment", zap.String("customer_id", customerID), zap.Error(err))
 		return nil, err
@@ -159,6 +167,7 @@ func (s *BankingService) PayBill(ctx context.Context, customerID string, req *do
 			zap.Error(balErr),
 		)
 	}
+	s.recordLimitUsage(ctx, customerID, "bill_payment", amount)
 
 	// Record in customer_transactions
 	now := time.Now()
@@ -175,7 +184,7 @@ func (s *BankingService) PayBill(ctx context.Context, customerID string, req *do
 		"type":        "bill_payment",
 		"category":    "contas",
 	}
-	if txErr := s.store.InsertTransaction(ctx, txRec); txErr != nil {
+	if txErr := s.insertTransaction(ctx, txRec); txErr != nil {
 		s.logger.Error("failed to record bill transaction",
 			zap.String("customer_id", customerID),
 			zap.Error(txErr),
@@ -189,14 +198,21 @@ func (s *BankingService) PayBill(ctx context.Context, customerID string, req *do
 		zap.String("bill_type", valResult.BillType),
 	)
 
+	s.notifyBalanceChange(ctx, customerID, -amount, desc)
+	s.publishEvent(ctx, customerID, domain.EventBillPaid, bill)
+
 	return bill, nil
 }
 
-func (s *BankingService) ListBillPayments(ctx context.Context, customerID string, page, pageSize int) ([]domain.BillPayment, error) {
+func (s *BankingService) ListBillPayments(ctx context.Context, customerID string, page, pageSize int) (*domain.ListResponse[domain.BillPayment], error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListBillPayments")
 	defer span.End()
 
-	return s.store.ListBillPayments(ctx, customerID, page, pageSize)
+	rows, total, err := s.store.ListBillPayments(ctx, customerID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, page, pageSize), nil
 }
 
 func (s *BankingService) GetBillPayment(ctx context.Context, customerID, billID string) (*domain.BillPayment, error) {
@@ -206,35 +222,98 @@ func (s *BankingService) GetBillPayment(ctx context.Context, customerID, billID
 	return s.store.GetBillPayment(ctx, customerID, billID)
 }
 
-func (s *BankingService) CancelBillPayment(ctx context.Context, customerID, billID string) error {
+// CancelBillPayment cancels a bill that was not paid yet. Pending and
+// scheduled bills were debited when created (see PayBill), so their amount
+// is refunded with a reversal statement entry.
+func (s *BankingService) CancelBillPayment(ctx context.Context, customerID, billID string) (*domain.BillPayment, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.CancelBillPayment")
 	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
 
 	bill, err := s.store.GetBillPayment(ctx, customerID, billID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if bill.Status != "pending" && bill.Status != "scheduled" && bill.Status != "validated" {
-		return &domain.ErrValidation{Field: "status", Message: fmt.Sprintf("cannot cancel bill with status '%s'", bill.Status)}
+		return nil, &domain.ErrInvalidState{Resource: "bill", Status: bill.Status, Action: "cancel"}
 	}
 
-	return s.store.UpdateBillPaymentStatus(ctx, billID, "cancelled")
+	claimed, err := s.store.ClaimBillPaymentCancel(ctx, billID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, &domain.ErrInvalidState{Resource: "bill", Status: "cancelled", Action: "cancel"}
+	}
+
+	if bill.Status != "validated" && bill.FinalAmount > 0 {
+		desc := "Estorno de boleto"
+		if bill.BeneficiaryName != "" {
+			desc = fmt.Sprintf("Estorno de boleto - %s", bill.BeneficiaryName)
+		}
+		tx := map[string]any{
+			"id":          uuid.New().String(),
+			"customer_id": customerID,
+			"date":        time.Now().Format(time.RFC3339),
+			"description": desc,
+			"amount":      bill.FinalAmount,
+			"type":        "credit",
+			"category":    "contas",
+		}
+		if err := s.moveBalance(ctx, customerID, bill.FinalAmount, tx); err != nil {
+			if revErr := s.store.UpdateBillPaymentStatus(ctx, billID, bill.Status); revErr != nil {
+				s.logger.Error("failed to restore bill status after failed refund",
+					zap.String("bill_id", billID), zap.Error(revErr))
+			}
+			return nil, fmt.Errorf("refund bill payment: %w", err)
+		}
+	}
+
+	s.logger.Info("bill payment cancelled",
+		zap.String("customer_id", customerID),
+		zap.String("bill_id", billID),
+		zap.String("previous_status", bill.Status),
+		zap.Float64("amount", bill.FinalAmount),
+	)
+
+	bill.Status = "cancelled"
+	return bill, nil
 }
 
 /*
  * Debit Purchases
  */
 
-func (s *BankingService) ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) ([]domain.DebitPurchase, error) {
+func (s *BankingService) ListDebitPurchases(ctx context.Context, customerID string, page, pageSize int) (*domain.ListResponse[domain.DebitPurchase], error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListDebitPurchases")
 	defer span.End()
 
-	return s.store.ListDebitPurchases(ctx, customerID, page, pageSize)
+	rows, total, err := s.store.ListDebitPurchases(ctx, customerID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, page, pageSize), nil
 }
 
-func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID string, req *domain.DebitPurchaseRequest) (*domain.DebitPurchaseResponse, error) {
+func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID string, req *domain.DebitPurchaseRequest) (resp *domain.DebitPurchaseResponse, err error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.CreateDebitPurchase")
 	defer span.End()
+	span.SetAttributes(
+		attribute.String("customer.id", customerID),
+		attribute.Float64("amount", req.Amount),
+		attribute.String("funded_by", "balance"),
+	)
+	defer func() {
+		// Insufficient funds is answered with a 200 and a status, not an error.
+		if err == nil && resp != nil && resp.Status == "insufficient_funds" {
+			span.SetAttributes(
+				attribute.String("result", spanResultDeclined),
+				attribute.String("error_code", resp.Status),
+			)
+			return
+		}
+		endMoneySpan(span, err)
+	}()
 
 	if req.Amount <= 0 {
 		return nil, &domain.ErrValidation{Field: "amount", Message: "must be positive"}
@@ -254,7 +333,7 @@ func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID str
 		return nil, err
 	}
 
-	if account.AvailableBalance < req.Amount {
+	if spendableBalance(account) < req.Amount {
 		return &domain.DebitPurchaseResponse{
 			Status:    "insufficient_funds",
 			Amount:    req.Amount,
@@ -291,7 +370,7 @@ func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID str
 		"type":        "debit_purchase",
 		"category":    "compras",
 	}
-	if txErr := s.store.InsertTransaction(ctx, txRec); txErr != nil {
+	if txErr := s.insertTransaction(ctx, txRec); txErr != nil {
 		s.logger.Error("failed to record debit purchase transaction",
 			zap.String("customer_id", customerID),
 			zap.Error(txErr),
@@ -304,6 +383,7 @@ func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID str
 		zap.Float64("amount", purchase.Amount),
 		zap.String("merchant", req.MerchantName),
 	)
+	s.notifyBalanceChange(ctx, customerID, -purchase.Amount, txRec["description"].(string))
 
 	return &domain.DebitPurchaseResponse{
 		TransactionID: purchase.ID,
@@ -313,3 +393,70 @@ func (s *BankingService) CreateDebitPurchase(ctx context.Context, customerID str
 		Timestamp:     purchase.TransactionDate.Format(time.RFC3339),
 	}, nil
 }
+
+// RefundDebitPurchase reverses a debit purchase after a merchant refund: the
+// amount goes back to the balance with a refund statement entry that
+// references the purchase. The purchase is claimed as refunded first, so a
+// second refund is rejected with ErrInvalidState.
+func (s *BankingService) RefundDebitPurchase(ctx context.Context, customerID, purchaseID string) (*domain.DebitRefundResponse, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RefundDebitPurchase")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+
+	purchase, err := s.store.GetDebitPurchase(ctx, customerID, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	if purchase.Status != "confirmed" && purchase.Status != "completed" {
+		return nil, &domain.ErrInvalidState{Resource: "debit_purchase", Status: purchase.Status, Action: "refund"}
+	}
+
+	claimed, err := s.store.ClaimDebitPurchaseRefund(ctx, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, &domain.ErrInvalidState{Resource: "debit_purchase", Status: "refunded", Action: "refund"}
+	}
+
+	now := time.Now()
+	refundID := uuid.New().String()
+	desc := fmt.Sprintf("Estorno compra débito - %s", purchase.MerchantName)
+	tx := map[string]any{
+		"id":           refundID,
+		"customer_id":  customerID,
+		"date":         now.Format(time.RFC3339),
+		"description":  desc,
+		"amount":       purchase.Amount,
+		"type":         "refund",
+		"category":     "compras",
+		"reference_id": purchaseID,
+	}
+	if err := s.moveBalance(ctx, customerID, purchase.Amount, tx); err != nil {
+		if revErr := s.store.UpdateDebitPurchaseStatus(ctx, purchaseID, purchase.Status); revErr != nil {
+			s.logger.Error("failed to restore debit purchase status after failed refund",
+				zap.String("purchase_id", purchaseID), zap.Error(revErr))
+		}
+		return nil, fmt.Errorf("refund debit purchase: %w", err)
+	}
+
+	s.logger.Info("debit purchase refunded",
+		zap.String("customer_id", customerID),
+		zap.String("purchase_id", purchaseID),
+		zap.String("refund_id", refundID),
+		zap.Float64("amount", purchase.Amount),
+	)
+	s.notifyBalanceChange(ctx, customerID, purchase.Amount, desc)
+
+	return &domain.DebitRefundResponse{
+		RefundID:              refundID,
+		OriginalTransactionID: purchaseID,
+		Status:                "refunded",
+		Amount:                purchase.Amount,
+		Timestamp:             now.Format(time.RFC3339),
+	}, nil
+}
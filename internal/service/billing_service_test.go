@@ -0,0 +1,267 @@
+package service_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const testDigitableLine = "34191.79001 01043.510047 91020.150008 8 91070000026000"
+
+// stubBarcodeDecoder reads digits from any image, or nothing when empty.
+type stubBarcodeDecoder struct{ digits string }
+
+func (d stubBarcodeDecoder) DecodeBarcode(context.Context, []byte) (string, error) {
+	if d.digits == "" {
+		return "", errors.New("no barcode found")
+	}
+	return d.digits, nil
+}
+
+var testBillImage = base64.StdEncoding.EncodeToString([]byte("fake jpeg bytes"))
+
+func TestValidateBarcode_TypedDigitableLine(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	resp, err := svc.ValidateBarcode(context.Background(), &domain.BarcodeValidationRequest{
+		InputMethod:   "typed",
+		DigitableLine: testDigitableLine,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.IsValid || resp.BillType != "bank_slip" || resp.Amount != 260 {
+		t.Errorf("got valid=%v type=%q amount=%v, want a valid bank_slip of 260", resp.IsValid, resp.BillType, resp.Amount)
+	}
+}
+
+func TestValidateBarcode_CameraScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoder *stubBarcodeDecoder // nil leaves the default (none)
+		image   string
+		check   func(t *testing.T, resp *domain.BarcodeValidationResponse, err error)
+	}{
+		{
+			name:  "no decoder configured",
+			image: testBillImage,
+			check: func(t *testing.T, _ *domain.BarcodeValidationResponse, err error) {
+				if !errors.Is(err, service.ErrNoBarcodeDecoder) {
+					t.Errorf("expected ErrNoBarcodeDecoder, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "decoded image",
+			decoder: &stubBarcodeDecoder{digits: testDigitableLine},
+			image:   testBillImage,
+			check: func(t *testing.T, resp *domain.BarcodeValidationResponse, err error) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !resp.IsValid || resp.Amount != 260 {
+					t.Errorf("got valid=%v amount=%v, want the decoded line validated", resp.IsValid, resp.Amount)
+				}
+			},
+		},
+		{
+			name:    "unreadable image",
+			decoder: &stubBarcodeDecoder{},
+			image:   testBillImage,
+			check: func(t *testing.T, _ *domain.BarcodeValidationResponse, err error) {
+				var invalid *domain.ErrInvalidBarcode
+				if !errors.As(err, &invalid) {
+					t.Errorf("expected ErrInvalidBarcode, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "invalid base64",
+			decoder: &stubBarcodeDecoder{digits: testDigitableLine},
+			image:   "not base64!",
+			check: func(t *testing.T, _ *domain.BarcodeValidationResponse, err error) {
+				var validation *domain.ErrValidation
+				if !errors.As(err, &validation) || validation.Field != "image_base64" {
+					t.Errorf("expected an image_base64 validation error, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+			if tt.decoder != nil {
+				svc.SetBarcodeDecoder(tt.decoder)
+			}
+			resp, err := svc.ValidateBarcode(context.Background(), &domain.BarcodeValidationRequest{
+				InputMethod: "camera_scan",
+				ImageBase64: tt.image,
+			})
+			tt.check(t, resp, err)
+		})
+	}
+}
+
+func TestValidateBarcode_RejectsUnknownInputMethod(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.ValidateBarcode(context.Background(), &domain.BarcodeValidationRequest{
+		InputMethod:   "fax",
+		DigitableLine: testDigitableLine,
+	})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "input_method" {
+		t.Errorf("expected an input_method validation error, got %v", err)
+	}
+}
+
+func TestCancelBillPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		wantErr     bool
+		wantBalance float64
+	}{
+		{"scheduled bill is refunded", "scheduled", false, 1250},
+		{"validated bill was never debited", "validated", false, 1000},
+		{"paid bill cannot be cancelled", "completed", true, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 1000)
+			store.bills = []domain.BillPayment{{
+				ID:              "bill-1",
+				CustomerID:      testCustomerID,
+				AccountID:       testAccountID,
+				BeneficiaryName: "Energia SA",
+				FinalAmount:     250,
+				Status:          tt.status,
+			}}
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			_, err := svc.CancelBillPayment(context.Background(), testCustomerID, "bill-1")
+			if tt.wantErr {
+				var invalidState *domain.ErrInvalidState
+				if !errors.As(err, &invalidState) {
+					t.Fatalf("expected ErrInvalidState, got %v", err)
+				}
+				if store.bills[0].Status != tt.status {
+					t.Errorf("status = %q, want it unchanged", store.bills[0].Status)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if store.bills[0].Status != "cancelled" {
+					t.Errorf("status = %q, want cancelled", store.bills[0].Status)
+				}
+			}
+
+			if got := store.accounts[testCustomerID].Balance; got != tt.wantBalance {
+				t.Errorf("balance = %v, want %v", got, tt.wantBalance)
+			}
+			if refunded := tt.wantBalance > 1000; refunded != (len(store.transactions) == 1) {
+				t.Errorf("booked %d statement entries, want a reversal only when refunded", len(store.transactions))
+			}
+		})
+	}
+}
+
+func TestCancelBillPayment_ConcurrentCancelsRefundOnce(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.bills = []domain.BillPayment{{
+		ID:          "bill-1",
+		CustomerID:  testCustomerID,
+		AccountID:   testAccountID,
+		FinalAmount: 250,
+		Status:      "pending",
+	}}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	var cancelled atomic.Int32
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.CancelBillPayment(context.Background(), testCustomerID, "bill-1"); err == nil {
+				cancelled.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := cancelled.Load(); got != 1 {
+		t.Errorf("%d cancels succeeded, want 1", got)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1250 {
+		t.Errorf("balance = %v, want 1250 (refunded once)", got)
+	}
+	if len(store.transactions) != 1 {
+		t.Errorf("booked %d reversals, want 1", len(store.transactions))
+	}
+}
+
+func TestRefundDebitPurchase(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	purchase, err := svc.CreateDebitPurchase(ctx, testCustomerID, &domain.DebitPurchaseRequest{
+		MerchantName: "Papelaria Central",
+		Amount:       180,
+	})
+	if err != nil {
+		t.Fatalf("purchase: unexpected error: %v", err)
+	}
+
+	refund, err := svc.RefundDebitPurchase(ctx, testCustomerID, purchase.TransactionID)
+	if err != nil {
+		t.Fatalf("refund: unexpected error: %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance = %v, want 1000 restored", got)
+	}
+	if refund.OriginalTransactionID != purchase.TransactionID || refund.Amount != 180 {
+		t.Errorf("refund = %+v, want 180 linked to %s", refund, purchase.TransactionID)
+	}
+	var linked bool
+	for _, tx := range store.transactions {
+		if tx["type"] == "refund" && tx["reference_id"] == purchase.TransactionID && tx["amount"] == 180.0 {
+			linked = true
+		}
+	}
+	if !linked {
+		t.Errorf("no refund statement entry referencing %s in %v", purchase.TransactionID, store.transactions)
+	}
+
+	_, err = svc.RefundDebitPurchase(ctx, testCustomerID, purchase.TransactionID)
+	var invalidState *domain.ErrInvalidState
+	if !errors.As(err, &invalidState) {
+		t.Fatalf("second refund: expected ErrInvalidState, got %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance after second refund = %v, want 1000", got)
+	}
+
+	_, err = svc.RefundDebitPurchase(ctx, "other-customer", purchase.TransactionID)
+	var notFound *domain.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("other customer's refund: expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+)
+
+/*
+ * Credit Cards — merchant categorization
+ */
+
+// DefaultCardCategory is used when a card transaction has no category
+// and the merchant name does not match any known rule.
+const DefaultCardCategory = "other"
+
+// merchantCategoryRule maps merchant name keywords to a spending
+// category and its representative MCC (Merchant Category Code).
+type merchantCategoryRule struct {
+	Keywords []string
+	Category string
+	MCC      string
+}
+
+// merchantCategoryRules is evaluated in order; the first rule whose keyword
+// is contained in the (lower-cased) merchant name wins.
+var merchantCategoryRules = []merchantCategoryRule{
+	{Keywords: []string{"restaurante", "ifood", "lanchonete", "padaria", "pizzaria", "burger", "café", "cafe"}, Category: "food", MCC: "5812"},
+	{Keywords: []string{"posto", "shell", "ipiranga", "petrobras", "combustível", "combustivel"}, Category: "fuel", MCC: "5541"},
+	{Keywords: []string{"uber", "99 táxi", "99 taxi", "cabify", "táxi", "taxi", "estacionamento"}, Category: "transport", MCC: "4121"},
+	{Keywords: []string{"netflix", "spotify", "assinatura", "creative cloud", "microsoft 365"}, Category: "subscription", MCC: "5968"},
+	{Keywords: []string{"aws", "google cloud", "azure", "digitalocean", "github", "software", "tecnologia"}, Category: "technology", MCC: "5734"},
+	{Keywords: []string{"google ads", "meta ads", "facebook ads", "marketing", "publicidade"}, Category: "marketing", MCC: "7311"},
+	{Keywords: []string{"kalunga", "papelaria", "escritório", "escritorio"}, Category: "office_supplies", MCC: "5111"},
+	{Keywords: []string{"hotel", "pousada", "airbnb", "latam", "gol linhas", "azul linhas", "booking"}, Category: "travel", MCC: "7011"},
+	{Keywords: []string{"seguro", "seguradora"}, Category: "insurance", MCC: "6300"},
+	{Keywords: []string{"energia", "copel", "enel", "sabesp", "telefonia", "internet"}, Category: "utilities", MCC: "4900"},
+	{Keywords: []string{"contabilidade", "advocacia", "consultoria"}, Category: "professional_services", MCC: "8931"},
+	{Keywords: []string{"das simples", "darf", "imposto", "tributo"}, Category: "tax", MCC: "9311"},
+	{Keywords: []string{"limpeza", "manutenção", "manutencao"}, Category: "maintenance", MCC: "7349"},
+}
+
+// CategorizeMerchant returns the category and MCC inferred from a merchant
+// name. Unknown merchants fall back to DefaultCardCategory with an empty MCC.
+func CategorizeMerchant(merchantName string) (category, mcc string) {
+	name := strings.ToLower(strings.TrimSpace(merchantName))
+	if name == "" {
+		return DefaultCardCategory, ""
+	}
+	for _, rule := range merchantCategoryRules {
+		for _, kw := range rule.Keywords {
+			if strings.Contains(name, kw) {
+				return rule.Category, rule.MCC
+			}
+		}
+	}
+	return DefaultCardCategory, ""
+}
+
+// SetCardAutoCategorization enables or disables merchant-based
+// categorization of credit card transactions without a category.
+func (s *BankingService) SetCardAutoCategorization(enabled bool) {
+	s.cardAutoCategorize = enabled
+}
+
+// insertCreditCardTransaction persists a card transaction, filling in the
+// category (and MCC) from the merchant name when none was provided. The
+// customer's category rules take precedence over either.
+func (s *BankingService) insertCreditCardTransaction(ctx context.Context, data map[string]any) error {
+	if category, _ := data["category"].(string); category == "" {
+		category, mcc := DefaultCardCategory, ""
+		if s.cardAutoCategorize {
+			merchant, _ := data["merchant_name"].(string)
+			category, mcc = CategorizeMerchant(merchant)
+		}
+		data["category"] = category
+		if mcc != "" {
+			data["merchant_category_code"] = mcc
+		}
+	}
+	merchant, _ := data["merchant_name"].(string)
+	s.applyCategoryRules(ctx, data, merchant)
+	return s.store.InsertCreditCardTransaction(ctx, data)
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestCategorizeMerchant(t *testing.T) {
+	tests := []struct {
+		merchant     string
+		wantCategory string
+		wantMCC      string
+	}{
+		{"Posto Shell BR-101", "fuel", "5541"},
+		{"iFood Corporativo", "food", "5812"},
+		{"Uber Business", "transport", "4121"},
+		{"Amazon AWS", "technology", "5734"},
+		{"Google Ads", "marketing", "7311"},
+		{"KALUNGA PAPELARIA", "office_supplies", "5111"},
+		{"Hotel Ibis Business", "travel", "7011"},
+		{"DAS Simples Nacional", "tax", "9311"},
+		{"Loja Desconhecida", "other", ""},
+		{"", "other", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.merchant, func(t *testing.T) {
+			category, mcc := service.CategorizeMerchant(tt.merchant)
+			if category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", category, tt.wantCategory)
+			}
+			if mcc != tt.wantMCC {
+				t.Errorf("mcc = %q, want %q", mcc, tt.wantMCC)
+			}
+		})
+	}
+}
+
+func TestCreatePixTransfer_CreditCard_CategorizesCardTransaction(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		wantCategory string
+		wantMCC      any
+	}{
+		{"enabled", true, "fuel", "5541"},
+		{"disabled", false, "other", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 0)
+			seedPixRecipient(store, "cust-posto", "Posto Shell BR-101", "financeiro@postoshell.com.br")
+
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+			svc.SetCardAutoCategorization(tt.enabled)
+
+			_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+				IdempotencyKey:      "idem-1",
+				SourceAccountID:     testAccountID,
+				DestinationKeyValue: "financeiro@postoshell.com.br",
+				Amount:              250,
+				FundedBy:            "credit_card",
+				CreditCardID:        testCardID,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(store.cardTxs) != 1 {
+				t.Fatalf("expected 1 card transaction, got %d", len(store.cardTxs))
+			}
+			tx := store.cardTxs[0]
+			if tx["category"] != tt.wantCategory {
+				t.Errorf("category = %v, want %q", tx["category"], tt.wantCategory)
+			}
+			if tx["merchant_category_code"] != tt.wantMCC {
+				t.Errorf("merchant_category_code = %v, want %v", tx["merchant_category_code"], tt.wantMCC)
+			}
+		})
+	}
+}
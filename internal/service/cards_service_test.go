@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// seedInvoice registers an open invoice of the test card due daysFromNow
+// days from today (negative means overdue).
+func seedInvoice(store *fakeBankingStore, total, minimum float64, daysFromNow int) string {
+	due := time.Now().AddDate(0, 0, daysFromNow).Format("2006-01-02")
+	store.invoices = append(store.invoices, domain.CreditCardInvoice{
+		ID:             "inv-001",
+		CardID:         testCardID,
+		CustomerID:     testCustomerID,
+		DueDate:        due,
+		TotalAmount:    total,
+		MinimumPayment: minimum,
+		Status:         "closed",
+	})
+	return due
+}
+
+func TestPayInvoice_Charges(t *testing.T) {
+	tests := []struct {
+		name          string
+		daysFromNow   int
+		req           domain.InvoicePayRequest
+		wantAmount    float64
+		wantLateFee   float64
+		wantRemaining float64
+		wantStatus    string
+		wantNextDue   bool
+		wantUsed      float64 // card limit used after the payment, from 3000
+	}{
+		{
+			name:        "on-time full payment",
+			daysFromNow: 5,
+			req:         domain.InvoicePayRequest{PaymentType: "total"},
+			wantAmount:  1000,
+			wantStatus:  "paid",
+			wantUsed:    2000,
+		},
+		{
+			// Late charges are paid but give no card limit back
+			name:        "late full payment",
+			daysFromNow: -10,
+			req:         domain.InvoicePayRequest{PaymentType: "total"},
+			wantAmount:  1023.33,
+			wantLateFee: 23.33,
+			wantStatus:  "paid",
+			wantUsed:    2000,
+		},
+		{
+			// 2% fine on 1000 + 1% a.m. for 10 days = 20 + 3.33
+			name:          "late minimum payment",
+			daysFromNow:   -10,
+			req:           domain.InvoicePayRequest{PaymentType: "minimum"},
+			wantAmount:    173.33,
+			wantLateFee:   23.33,
+			wantRemaining: 850,
+			wantStatus:    "partially_paid",
+			wantNextDue:   true,
+			wantUsed:      2850,
+		},
+		{
+			name:          "custom partial payment",
+			daysFromNow:   3,
+			req:           domain.InvoicePayRequest{PaymentType: "custom", Amount: 400},
+			wantAmount:    400,
+			wantRemaining: 600,
+			wantStatus:    "partially_paid",
+			wantNextDue:   true,
+			wantUsed:      2600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 5000)
+			due := seedInvoice(store, 1000, 150, tt.daysFromNow)
+			card := store.cards[testCardID]
+			card.UsedLimit, card.AvailableLimit = 3000, 7000
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			req := tt.req
+			resp, err := svc.PayInvoice(context.Background(), testCustomerID, testCardID, &req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Amount != tt.wantAmount {
+				t.Errorf("amount = %v, want %v", resp.Amount, tt.wantAmount)
+			}
+			if resp.LateFeeApplied != tt.wantLateFee {
+				t.Errorf("lateFeeApplied = %v, want %v", resp.LateFeeApplied, tt.wantLateFee)
+			}
+			if resp.RemainingBalance != tt.wantRemaining {
+				t.Errorf("remainingBalance = %v, want %v", resp.RemainingBalance, tt.wantRemaining)
+			}
+			if resp.NewInvoiceStatus != tt.wantStatus {
+				t.Errorf("newInvoiceStatus = %q, want %q", resp.NewInvoiceStatus, tt.wantStatus)
+			}
+			if store.invoices[0].Status != tt.wantStatus {
+				t.Errorf("stored invoice status = %q, want %q", store.invoices[0].Status, tt.wantStatus)
+			}
+
+			wantNext := ""
+			if tt.wantNextDue {
+				d, _ := time.Parse("2006-01-02", due)
+				wantNext = d.AddDate(0, 1, 0).Format("2006-01-02")
+			}
+			if resp.NextDueDate != wantNext {
+				t.Errorf("nextDueDate = %q, want %q", resp.NextDueDate, wantNext)
+			}
+			if got := store.accounts[testCustomerID].Balance; got != 5000-tt.wantAmount {
+				t.Errorf("balance = %v, want %v", got, 5000-tt.wantAmount)
+			}
+			if card.UsedLimit != tt.wantUsed || card.AvailableLimit != 10000-tt.wantUsed {
+				t.Errorf("card used/available = %v/%v, want %v/%v", card.UsedLimit, card.AvailableLimit, tt.wantUsed, 10000-tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestPayInvoice_PrepayWithoutOpenInvoice(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 5000)
+	card := store.cards[testCardID]
+	card.UsedLimit, card.AvailableLimit = 800, 9200
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	resp, err := svc.PayInvoice(context.Background(), testCustomerID, testCardID, &domain.InvoicePayRequest{PaymentType: "prepay", Amount: 300})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Amount != 300 || resp.AvailableLimit != 9500 {
+		t.Errorf("response = amount %v, available %v; want 300, 9500", resp.Amount, resp.AvailableLimit)
+	}
+	if card.UsedLimit != 500 || card.AvailableLimit != 9500 {
+		t.Errorf("card = used %v, available %v; want 500, 9500", card.UsedLimit, card.AvailableLimit)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 4700 {
+		t.Errorf("balance = %v, want 4700", got)
+	}
+	if len(store.cardTxs) != 1 || store.cardTxs[0]["amount"] != -300.0 || store.cardTxs[0]["transaction_type"] != "payment" {
+		t.Errorf("card transactions = %v, want one payment credit of -300", store.cardTxs)
+	}
+
+	// More than the used limit only frees what is used
+	resp, err = svc.PayInvoice(context.Background(), testCustomerID, testCardID, &domain.InvoicePayRequest{PaymentType: "prepay", Amount: 2000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Amount != 500 || card.UsedLimit != 0 || card.AvailableLimit != card.CreditLimit {
+		t.Errorf("capped prepay = amount %v, used %v, available %v; want 500, 0, %v", resp.Amount, card.UsedLimit, card.AvailableLimit, card.CreditLimit)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 4200 {
+		t.Errorf("balance = %v, want 4200", got)
+	}
+
+	_, err = svc.PayInvoice(context.Background(), testCustomerID, testCardID, &domain.InvoicePayRequest{PaymentType: "prepay", Amount: 100})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Errorf("prepay with nothing used: expected ErrValidation, got %v", err)
+	}
+}
+
+func TestCancelCreditCard_RefusesOpenInvoiceBalance(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 5000)
+	seedInvoice(store, 1200, 180, 10)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	err := svc.CancelCreditCard(context.Background(), testCustomerID, testCardID)
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if got := store.cards[testCardID].Status; got != "active" {
+		t.Errorf("card status = %q, want active", got)
+	}
+}
+
+func TestCancelCreditCard_Success(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 5000)
+	store.accounts[testCustomerID].CreditLimit = 20000
+	store.accounts[testCustomerID].AvailableCreditLimit = 10000
+	seedInvoice(store, 1200, 180, -20)
+	store.invoices[0].Status = "paid"
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if err := svc.CancelCreditCard(context.Background(), testCustomerID, testCardID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	card := store.cards[testCardID]
+	if card.Status != "cancelled" {
+		t.Errorf("card status = %q, want cancelled", card.Status)
+	}
+	if card.PixCreditEnabled {
+		t.Error("PIX via credit card still enabled after cancellation")
+	}
+	if got := store.accounts[testCustomerID].AvailableCreditLimit; got != 20000 {
+		t.Errorf("available credit limit = %v, want 20000 (card limit released)", got)
+	}
+
+	// The cancelled card no longer counts towards the credit limit
+	var notFound *domain.ErrNotFound
+	if _, err := svc.GetCreditLimit(context.Background(), testCustomerID); !errors.As(err, &notFound) {
+		t.Errorf("GetCreditLimit: expected ErrNotFound without usable cards, got %v", err)
+	}
+
+	// Cancelling twice is a validation error
+	var validation *domain.ErrValidation
+	if err := svc.CancelCreditCard(context.Background(), testCustomerID, testCardID); !errors.As(err, &validation) {
+		t.Errorf("second cancel: expected ErrValidation, got %v", err)
+	}
+}
+
+func TestCloseInvoice_SumsTheCycleTransactions(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	now := time.Now()
+	for i, tx := range []struct {
+		date   time.Time
+		amount float64
+	}{
+		{now, 150.10},
+		{now, 49.90},
+		{now.AddDate(0, -1, 0), 999}, // previous cycle
+	} {
+		store.cardTxs = append(store.cardTxs, map[string]any{
+			"id":               fmt.Sprintf("cctx-%d", i),
+			"card_id":          testCardID,
+			"customer_id":      testCustomerID,
+			"transaction_date": tx.date.Format(time.RFC3339),
+			"amount":           tx.amount,
+		})
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	month := now.Format("2006-01")
+	invoice, err := svc.CloseInvoice(context.Background(), testCardID, month)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invoice.Status != "closed" || invoice.ReferenceMonth != month {
+		t.Errorf("invoice = %s %s, want closed %s", invoice.Status, invoice.ReferenceMonth, month)
+	}
+	if service.RoundMoney(invoice.TotalAmount) != 200 {
+		t.Errorf("total = %v, want the 200 of this cycle", invoice.TotalAmount)
+	}
+	if len(store.invoices) != 1 || store.invoices[0].Status != "closed" {
+		t.Errorf("stored invoices = %+v, want one closed invoice", store.invoices)
+	}
+
+	// Closing again keeps the same invoice.
+	again, err := svc.CloseInvoice(context.Background(), testCardID, month)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.ID != invoice.ID || len(store.invoices) != 1 {
+		t.Errorf("second close returned %s with %d invoices, want %s alone", again.ID, len(store.invoices), invoice.ID)
+	}
+}
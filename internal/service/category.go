@@ -0,0 +1,63 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+/*
+ * Transaction categories
+ */
+
+// categoryDisplayNames maps normalized categories to the spelling shown in
+// responses. Categories not listed are shown as their normalized key.
+var categoryDisplayNames = map[string]string{
+	"alimentacao":   "alimentação",
+	"cartao":        "cartão",
+	"educacao":      "educação",
+	"manutencao":    "manutenção",
+	"saude":         "saúde",
+	"servicos":      "serviços",
+	"transferencia": "transferência",
+}
+
+// NormalizeCategory returns the key a transaction category is filtered and
+// grouped by: trimmed, lower-cased and without diacritics, so "Alimentação"
+// and "alimentacao" are the same category.
+func NormalizeCategory(category string) string {
+	return foldText(strings.TrimSpace(category))
+}
+
+// categoryDisplayName returns the spelling shown for a category, accepting
+// either a raw or a normalized category.
+func categoryDisplayName(category string) string {
+	key := NormalizeCategory(category)
+	if name, ok := categoryDisplayNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+// groupCategoryTotals merges the totals of spelling variants of a category
+// under its display name, biggest total first.
+func groupCategoryTotals(totals []domain.CategoryTotal) []domain.CategoryTotal {
+	byName := make(map[string]float64, len(totals))
+	for _, t := range totals {
+		if name := categoryDisplayName(t.Category); name != "" {
+			byName[name] += t.Total
+		}
+	}
+	grouped := make([]domain.CategoryTotal, 0, len(byName))
+	for name, total := range byName {
+		grouped = append(grouped, domain.CategoryTotal{Category: name, Total: total})
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		if grouped[i].Total != grouped[j].Total {
+			return grouped[i].Total > grouped[j].Total
+		}
+		return grouped[i].Category < grouped[j].Category
+	})
+	return grouped
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Category rules — customer categorization overrides
+ *
+ * Automatic categorization (PIX purposes, card merchants) is a guess. A
+ * customer rule maps a description or merchant pattern to a category and
+ * wins over the automatic one for every transaction recorded afterwards;
+ * the existing statement can be backfilled when the rule is saved. When
+ * several rules match, the longest pattern is the most specific one.
+ *
+ * Every recorded transaction consults the rules, so each instance keeps a
+ * customer's rules in memory for categoryRulesTTL. Saving a rule drops the
+ * customer's entry on the instance that saved it; other instances pick the
+ * rule up when their entry expires.
+ */
+
+const (
+	maxCategoryRulePattern    = 100         // pattern length
+	categoryRulesTTL          = time.Minute // how long an instance serves a customer's rules from memory
+	categoryRulesBackfillPage = 500         // statement entries read (and recategorized) per backfill page
+)
+
+// categoryRuleCache holds the rules of recently active customers.
+type categoryRuleCache struct {
+	mu        sync.Mutex
+	entries   map[string]categoryRuleEntry // by customer ID
+	lastSweep time.Time
+}
+
+type categoryRuleEntry struct {
+	rules    []domain.CategoryRule
+	loadedAt time.Time
+}
+
+func newCategoryRuleCache() *categoryRuleCache {
+	return &categoryRuleCache{entries: make(map[string]categoryRuleEntry)}
+}
+
+func (c *categoryRuleCache) get(customerID string, now time.Time) ([]domain.CategoryRule, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[customerID]
+	if !ok || now.Sub(e.loadedAt) >= categoryRulesTTL {
+		return nil, false
+	}
+	return e.rules, true
+}
+
+// set stores the customer's rules, dropping expired entries at most once
+// per TTL so customers who went idle do not pile up.
+func (c *categoryRuleCache) set(customerID string, rules []domain.CategoryRule, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) >= categoryRulesTTL {
+		for id, e := range c.entries {
+			if now.Sub(e.loadedAt) >= categoryRulesTTL {
+				delete(c.entries, id)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[customerID] = categoryRuleEntry{rules: rules, loadedAt: now}
+}
+
+func (c *categoryRuleCache) invalidate(customerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, customerID)
+}
+
+// ListCategoryRules returns the customer's categorization rules.
+func (s *BankingService) ListCategoryRules(ctx context.Context, customerID string) ([]domain.CategoryRule, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ListCategoryRules")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	return s.store.ListCategoryRules(ctx, customerID)
+}
+
+// UpsertCategoryRule saves a rule recategorizing the customer's transactions
+// whose description contains the pattern, replacing the category of an
+// existing rule for the same pattern. With Backfill, the confirmed statement
+// entries already matching are recategorized too.
+func (s *BankingService) UpsertCategoryRule(ctx context.Context, customerID string, req *domain.CategoryRuleRequest) (*domain.CategoryRuleResult, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.UpsertCategoryRule")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Bool("backfill", req.Backfill))
+
+	pattern := foldText(strings.TrimSpace(req.Pattern))
+	category := strings.TrimSpace(req.Category)
+	switch {
+	case pattern == "":
+		return nil, &domain.ErrValidation{Field: "pattern", Message: "required"}
+	case len(pattern) > maxCategoryRulePattern:
+		return nil, &domain.ErrValidation{Field: "pattern", Message: "must have at most 100 characters"}
+	case category == "":
+		return nil, &domain.ErrValidation{Field: "category", Message: "required"}
+	}
+
+	rule, err := s.store.UpsertCategoryRule(ctx, &domain.CategoryRule{
+		CustomerID: customerID,
+		Pattern:    pattern,
+		Category:   category,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.categoryRules.invalidate(customerID)
+	result := &domain.CategoryRuleResult{Rule: rule}
+	if !req.Backfill {
+		return result, nil
+	}
+
+	// The whole statement is read newest first, a keyset page at a time,
+	// and each page's matches are recategorized before the next is read.
+	before, beforeID := time.Now().AddDate(0, 0, 1), ""
+	for {
+		page, err := s.store.ListTransactionsBefore(ctx, customerID, before, beforeID, categoryRulesBackfillPage)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, tx := range page {
+			if tx.Category != category && strings.Contains(foldText(tx.Description), pattern) {
+				ids = append(ids, tx.ID)
+			}
+		}
+		n, err := s.store.RecategorizeTransactions(ctx, customerID, ids, category)
+		if err != nil {
+			return nil, err
+		}
+		result.Recategorized += n
+		if len(page) < categoryRulesBackfillPage {
+			break
+		}
+		last := page[len(page)-1]
+		before, beforeID = last.Date, last.ID
+	}
+	if result.Recategorized > 0 {
+		s.invalidateSpendingSummaries(ctx, customerID, time.Time{})
+	}
+	return result, nil
+}
+
+// applyCategoryRules overrides tx["category"] with the customer's most
+// specific rule matching text. A failure to load the rules keeps the
+// automatic category.
+func (s *BankingService) applyCategoryRules(ctx context.Context, tx map[string]any, text string) {
+	customerID, _ := tx["customer_id"].(string)
+	if customerID == "" || text == "" {
+		return
+	}
+	now := time.Now()
+	rules, ok := s.categoryRules.get(customerID, now)
+	if !ok {
+		var err error
+		if rules, err = s.store.ListCategoryRules(ctx, customerID); err != nil {
+			s.logger.Warn("could not load category rules, keeping the automatic category",
+				zap.String("customer_id", customerID), zap.Error(err))
+			return
+		}
+		s.categoryRules.set(customerID, rules, now)
+	}
+
+	folded := foldText(text)
+	var best *domain.CategoryRule
+	for i := range rules {
+		if r := &rules[i]; strings.Contains(folded, r.Pattern) && (best == nil || len(r.Pattern) > len(best.Pattern)) {
+			best = r
+		}
+	}
+	if best != nil {
+		tx["category"] = best.Category
+	}
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// sentPixCategoryTo sends a PIX to key and returns the category of the
+// sender's statement entry.
+func sentPixCategoryTo(t *testing.T, svc *service.BankingService, store *fakeBankingStore, key, description string) string {
+	t.Helper()
+	store.transactions = nil
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-" + key + description,
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: key,
+		Amount:              10,
+		Description:         description,
+	})
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %v", description, err)
+	}
+	for _, tx := range store.transactions {
+		if tx["type"] == "pix_sent" {
+			category, _ := tx["category"].(string)
+			return category
+		}
+	}
+	t.Fatalf("%q: no pix_sent statement entry", description)
+	return ""
+}
+
+func TestUpsertCategoryRule_RecategorizesLaterTransactions(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedPixRecipient(store, "cust-uber", "Uber do Brasil", "uber@example.com")
+	seedPixRecipient(store, "cust-padaria", "Padaria Central", "padaria@example.com")
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if got := sentPixCategoryTo(t, svc, store, "uber@example.com", "corrida"); got != "pix" {
+		t.Fatalf("category before the rule = %q, want pix", got)
+	}
+
+	_, err := svc.UpsertCategoryRule(context.Background(), testCustomerID, &domain.CategoryRuleRequest{Pattern: "Uber", Category: "transporte"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := sentPixCategoryTo(t, svc, store, "uber@example.com", "viagem aeroporto"); got != "transporte" {
+		t.Errorf("category = %q, want the rule's transporte", got)
+	}
+	if got := sentPixCategoryTo(t, svc, store, "padaria@example.com", "café"); got != "pix" {
+		t.Errorf("category = %q, want unmatched transfers untouched", got)
+	}
+	// Loaded once per customer (sender and both recipients), plus the
+	// sender's again after saving the rule; not once per transaction
+	if store.ruleLoads != 4 {
+		t.Errorf("rules loaded %d times, want 4", store.ruleLoads)
+	}
+}
+
+func TestUpsertCategoryRule_Backfill(t *testing.T) {
+	store := newFakeBankingStore()
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: time.Now(), Amount: -30, Category: "other", Description: "Compra débito - Uber Trip"},
+		{ID: "tx-2", Date: time.Now(), Amount: -12, Category: "food", Description: "Compra débito - Padaria"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	res, err := svc.UpsertCategoryRule(context.Background(), testCustomerID, &domain.CategoryRuleRequest{Pattern: "úber", Category: "transporte", Backfill: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Recategorized != 1 || store.statement[0].Category != "transporte" || store.statement[1].Category != "food" {
+		t.Errorf("recategorized = %d, statement = %+v, want only tx-1 moved", res.Recategorized, store.statement)
+	}
+	if res.Rule.Pattern != "uber" {
+		t.Errorf("pattern = %q, want it stored folded", res.Rule.Pattern)
+	}
+}
+
+func TestUpsertCategoryRule_BackfillPagesThroughTheStatement(t *testing.T) {
+	store := newFakeBankingStore()
+	start := time.Now().AddDate(-2, 0, 0)
+	const entries = 1234 // more than two backfill pages
+	for i := 0; i < entries; i++ {
+		store.statement = append(store.statement, domain.Transaction{
+			ID: fmt.Sprintf("tx-%04d", i), Date: start.Add(time.Duration(i) * time.Hour),
+			Amount: -10, Category: "other", Description: "Uber Trip",
+		})
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	res, err := svc.UpsertCategoryRule(context.Background(), testCustomerID, &domain.CategoryRuleRequest{Pattern: "uber", Category: "transporte", Backfill: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Recategorized != entries {
+		t.Errorf("recategorized = %d, want all %d entries", res.Recategorized, entries)
+	}
+	for _, tx := range store.statement {
+		if tx.Category != "transporte" {
+			t.Fatalf("%s kept category %q", tx.ID, tx.Category)
+		}
+	}
+}
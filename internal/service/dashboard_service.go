@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+ * Dashboard — concurrent aggregation with an overall time budget
+ */
+
+// DefaultDashboardBudget is the overall time budget of GetDashboard.
+const DefaultDashboardBudget = 2 * time.Second
+
+// Dashboard section names.
+const (
+	dashboardAccounts      = "accounts"
+	dashboardCreditCards   = "credit_cards"
+	dashboardTransactions  = "transaction_summary"
+	dashboardNotifications = "unread_notifications"
+)
+
+// dashboardNotificationsLimit caps the unread notifications shown.
+const dashboardNotificationsLimit = 5
+
+// SetDashboardBudget overrides the overall time budget of GetDashboard.
+// Non-positive values keep the current budget.
+func (s *BankingService) SetDashboardBudget(budget time.Duration) {
+	if budget > 0 {
+		s.dashboardBudget = budget
+	}
+}
+
+// GetDashboard fetches all dashboard sections concurrently and returns
+// within the configured budget. Sections still running when the budget
+// expires are marked timed_out; failed sections are marked error. Partial
+// results are never an error.
+func (s *BankingService) GetDashboard(ctx context.Context, customerID string) (*domain.Dashboard, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetDashboard")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.dashboardBudget)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		closed bool // set once the budget expired; late results are dropped
+	)
+	dash := &domain.Dashboard{
+		CustomerID: customerID,
+		Sections: map[string]string{
+			dashboardAccounts:      domain.DashboardSectionTimedOut,
+			dashboardCreditCards:   domain.DashboardSectionTimedOut,
+			dashboardTransactions:  domain.DashboardSectionTimedOut,
+			dashboardNotifications: domain.DashboardSectionTimedOut,
+		},
+	}
+
+	// section runs fetch and, if the budget has not expired, stores its result.
+	section := func(name string, fetch func(context.Context) (func(), error)) func() error {
+		return func() error {
+			apply, err := fetch(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if closed {
+				return nil
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil // budget expired mid-fetch: stays timed_out
+				}
+				s.logger.Warn("dashboard section failed",
+					zap.String("customer_id", customerID),
+					zap.String("section", name),
+					zap.Error(err))
+				dash.Sections[name] = domain.DashboardSectionError
+				return nil
+			}
+			apply()
+			dash.Sections[name] = domain.DashboardSectionOK
+			return nil
+		}
+	}
+
+	var g errgroup.Group
+	g.Go(section(dashboardAccounts, func(ctx context.Context) (func(), error) {
+		accounts, err := s.store.ListAccounts(ctx, customerID)
+		return func() { dash.Accounts = accounts }, err
+	}))
+	g.Go(section(dashboardCreditCards, func(ctx context.Context) (func(), error) {
+		cards, err := s.store.ListCreditCards(ctx, customerID)
+		return func() { dash.CreditCards = cards }, err
+	}))
+	g.Go(section(dashboardTransactions, func(ctx context.Context) (func(), error) {
+		summary, err := s.store.GetTransactionSummary(ctx, customerID)
+		if err == nil {
+			summary.TopCategories = groupCategoryTotals(summary.TopCategories)
+		}
+		return func() { dash.TransactionSummary = summary }, err
+	}))
+	g.Go(section(dashboardNotifications, func(ctx context.Context) (func(), error) {
+		notifs, _, err := s.store.ListNotifications(ctx, customerID, true, 1, dashboardNotificationsLimit)
+		return func() { dash.UnreadNotifications = notifs }, err
+	}))
+
+	// Wait for all sections, but never past the budget: a store call that
+	// ignores its context must not hold the response.
+	done := make(chan struct{})
+	go func() {
+		_ = g.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	dash.GeneratedAt = time.Now()
+
+	for name, status := range dash.Sections {
+		if status == domain.DashboardSectionTimedOut {
+			s.logger.Warn("dashboard section timed out",
+				zap.String("customer_id", customerID),
+				zap.String("section", name),
+				zap.Duration("budget", s.dashboardBudget))
+		}
+	}
+
+	return dash, nil
+}
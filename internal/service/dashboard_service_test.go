@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// slowCardsStore delays ListCreditCards, optionally ignoring cancellation.
+type slowCardsStore struct {
+	*fakeBankingStore
+	delay         time.Duration
+	ignoreContext bool
+}
+
+func (s *slowCardsStore) ListCreditCards(ctx context.Context, customerID string) ([]domain.CreditCard, error) {
+	if s.ignoreContext {
+		time.Sleep(s.delay)
+		return s.fakeBankingStore.ListCreditCards(ctx, customerID)
+	}
+	select {
+	case <-time.After(s.delay):
+		return s.fakeBankingStore.ListCreditCards(ctx, customerID)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// failingNotificationsStore fails ListNotifications.
+type failingNotificationsStore struct {
+	*fakeBankingStore
+}
+
+func (s *failingNotificationsStore) ListNotifications(context.Context, string, bool, int, int) ([]domain.Notification, int, error) {
+	return nil, 0, errors.New("notifications unavailable")
+}
+
+func TestGetDashboard_AllSections(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.notifs = append(store.notifs, domain.Notification{ID: "n-1", CustomerID: testCustomerID, Title: "Pix recebido"})
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	dash, err := svc.GetDashboard(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, status := range dash.Sections {
+		if status != domain.DashboardSectionOK {
+			t.Errorf("section %s: expected ok, got %s", name, status)
+		}
+	}
+	if len(dash.Accounts) != 1 || len(dash.CreditCards) != 1 || len(dash.UnreadNotifications) != 1 || dash.TransactionSummary == nil {
+		t.Errorf("unexpected dashboard: %+v", dash)
+	}
+}
+
+func TestGetDashboard_SlowSectionTimesOut(t *testing.T) {
+	for _, ignoreContext := range []bool{false, true} {
+		store := &slowCardsStore{fakeBankingStore: newFakeBankingStore(), delay: 2 * time.Second, ignoreContext: ignoreContext}
+		seedCustomer(store.fakeBankingStore, 1000)
+		svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+		svc.SetDashboardBudget(50 * time.Millisecond)
+
+		start := time.Now()
+		dash, err := svc.GetDashboard(context.Background(), testCustomerID)
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed > 500*time.Millisecond {
+			t.Errorf("ignoreContext=%v: dashboard took %s, expected to return within the budget", ignoreContext, elapsed)
+		}
+		if got := dash.Sections["credit_cards"]; got != domain.DashboardSectionTimedOut {
+			t.Errorf("ignoreContext=%v: expected credit_cards timed_out, got %s", ignoreContext, got)
+		}
+		if dash.CreditCards != nil {
+			t.Errorf("ignoreContext=%v: timed-out section should be empty", ignoreContext)
+		}
+		if got := dash.Sections["accounts"]; got != domain.DashboardSectionOK || len(dash.Accounts) != 1 {
+			t.Errorf("ignoreContext=%v: expected accounts to complete, got %s", ignoreContext, got)
+		}
+	}
+}
+
+func TestGetDashboard_FailedSectionMarkedError(t *testing.T) {
+	store := &failingNotificationsStore{fakeBankingStore: newFakeBankingStore()}
+	seedCustomer(store.fakeBankingStore, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	dash, err := svc.GetDashboard(context.Background(), testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := dash.Sections["unread_notifications"]; got != domain.DashboardSectionError {
+		t.Errorf("expected unread_notifications error, got %s", got)
+	}
+	if got := dash.Sections["accounts"]; got != domain.DashboardSectionOK {
+		t.Errorf("expected accounts ok, got %s", got)
+	}
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
@@ -16,7 +20,9 @@ import (
  * Dev Tools
  */
 
-// DevAddBalance adds the given amount to the customer's primary account balance.
+// DevAddBalance adds the given amount to the customer's primary account
+// balance. With an idempotency key the adjustment is applied once; a retry
+// returns the first result.
 func (s *BankingService) DevAddBalance(ctx context.Context, req *domain.DevAddBalanceRequest) (*domain.DevAddBalanceResponse, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.DevAddBalance")
 	defer span.End()
@@ -28,18 +34,52 @@ func (s *BankingService) DevAddBalance(ctx context.Context, req *domain.DevAddBa
 		return nil, &domain.ErrValidation{Field: "amount", Message: "não pode ser zero"}
 	}
 
+	// The key is claimed before the balance moves, so of two calls with
+	// the same key only the one that inserted it credits.
+	if req.IdempotencyKey != "" {
+		err := s.store.SaveDevBalanceAdjustment(ctx, &domain.DevBalanceAdjustment{
+			IdempotencyKey: req.IdempotencyKey,
+			CustomerID:     req.CustomerID,
+			Amount:         req.Amount,
+		})
+		var duplicate *domain.ErrDuplicate
+		if errors.As(err, &duplicate) {
+			return s.replayDevBalanceAdjustment(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	acct, err := s.store.UpdateAccountBalance(ctx, req.CustomerID, req.Amount)
 	if err != nil {
+		if req.IdempotencyKey != "" {
+			if delErr := s.store.DeleteDevBalanceAdjustment(ctx, req.IdempotencyKey); delErr != nil {
+				s.logger.Error("DEV: failed to release balance idempotency key, retries will conflict",
+					zap.String("customer_id", req.CustomerID),
+					zap.String("idempotency_key", req.IdempotencyKey),
+					zap.Error(delErr))
+			}
+		}
 		return nil, err
 	}
 
+	if req.IdempotencyKey != "" {
+		if err := s.store.SetDevBalanceAdjustmentResult(ctx, req.IdempotencyKey, acct.Balance); err != nil {
+			s.logger.Error("DEV: failed to record balance adjustment result, retries will conflict",
+				zap.String("customer_id", req.CustomerID),
+				zap.String("idempotency_key", req.IdempotencyKey),
+				zap.Error(err))
+		}
+	}
+
 	// Record the transaction for extrato/fatura
 	now := time.Now()
 	txType := "transfer_in"
-	txDesc := fmt.Sprintf("DevTools — Crédito de saldo R$ %.2f", req.Amount)
+	txDesc := fmt.Sprintf("DevTools — Crédito de saldo %s", FormatBRL(req.Amount))
 	if req.Amount < 0 {
 		txType = "transfer_out"
-		txDesc = fmt.Sprintf("DevTools — Débito de saldo R$ %.2f", -req.Amount)
+		txDesc = fmt.Sprintf("DevTools — Débito de saldo %s", FormatBRL(-req.Amount))
 	}
 	tx := map[string]any{
 		"id":          uuid.New().String(),
@@ -50,7 +90,7 @@ func (s *BankingService) DevAddBalance(ctx context.Context, req *domain.DevAddBa
 		"type":        txType,
 		"category":    "devtools",
 	}
-	if txErr := s.store.InsertTransaction(ctx, tx); txErr != nil {
+	if txErr := s.insertTransaction(ctx, tx); txErr != nil {
 		s.logger.Error("DEV: failed to record balance transaction",
 			zap.String("customer_id", req.CustomerID),
 			zap.Error(txErr),
@@ -64,17 +104,41 @@ func (s *BankingService) DevAddBalance(ctx context.Context, req *domain.DevAddBa
 		zap.Float64("new_balance", acct.Balance),
 	)
 
-	msg := fmt.Sprintf("R$ %.2f adicionados ao saldo", req.Amount)
-	if req.Amount < 0 {
-		msg = fmt.Sprintf("R$ %.2f debitados do saldo", -req.Amount)
-	}
 	return &domain.DevAddBalanceResponse{
 		Success:    true,
 		NewBalance: acct.Balance,
-		Message:    msg,
+		Message:    devBalanceMessage(req.Amount),
 	}, nil
 }
 
+// replayDevBalanceAdjustment answers a retry of an add-balance call whose
+// idempotency key is already claimed with the result of the first call.
+func (s *BankingService) replayDevBalanceAdjustment(ctx context.Context, req *domain.DevAddBalanceRequest) (*domain.DevAddBalanceResponse, error) {
+	prev, err := s.store.GetDevBalanceAdjustment(ctx, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil && (prev.CustomerID != req.CustomerID || prev.Amount != req.Amount) {
+		return nil, &domain.ErrConflict{Message: "idempotencyKey already used for a different adjustment"}
+	}
+	if prev == nil || prev.NewBalance == nil {
+		return nil, &domain.ErrConflict{Message: "adjustment with this idempotencyKey is still being applied"}
+	}
+	return &domain.DevAddBalanceResponse{
+		Success:        true,
+		NewBalance:     *prev.NewBalance,
+		Message:        devBalanceMessage(prev.Amount),
+		AlreadyApplied: true,
+	}, nil
+}
+
+func devBalanceMessage(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("%s debitados do saldo", FormatBRL(-amount))
+	}
+	return fmt.Sprintf("%s adicionados ao saldo", FormatBRL(amount))
+}
+
 // DevSetCreditLimit sets the pre-approved credit limit on the customer's primary account.
 // This limit is consumed when the customer requests credit cards.
 func (s *BankingService) DevSetCreditLimit(ctx context.Context, req *domain.DevSetCreditLimitRequest) (*domain.DevSetCreditLimitResponse, error) {
@@ -100,12 +164,12 @@ func (s *BankingService) DevSetCreditLimit(ctx context.Context, req *domain.DevS
 		"id":          uuid.New().String(),
 		"customer_id": req.CustomerID,
 		"date":        now.Format(time.RFC3339),
-		"description": fmt.Sprintf("DevTools — Limite de crédito da conta ajustado para R$ %.2f", req.CreditLimit),
+		"description": fmt.Sprintf("DevTools — Limite de crédito da conta ajustado para %s", FormatBRL(req.CreditLimit)),
 		"amount":      0,
 		"type":        "credit",
 		"category":    "devtools",
 	}
-	if txErr := s.store.InsertTransaction(ctx, tx); txErr != nil {
+	if txErr := s.insertTransaction(ctx, tx); txErr != nil {
 		s.logger.Error("DEV: failed to record credit limit transaction",
 			zap.String("customer_id", req.CustomerID),
 			zap.Error(txErr),
@@ -122,7 +186,7 @@ func (s *BankingService) DevSetCreditLimit(ctx context.Context, req *domain.DevS
 		Success:              true,
 		NewLimit:             acct.CreditLimit,
 		AvailableCreditLimit: acct.AvailableCreditLimit,
-		Message:              fmt.Sprintf("Limite de crédito da conta atualizado para R$ %.2f (disponível: R$ %.2f)", acct.CreditLimit, acct.AvailableCreditLimit),
+		Message:              fmt.Sprintf("Limite de crédito da conta atualizado para %s (disponível: %s)", FormatBRL(acct.CreditLimit), FormatBRL(acct.AvailableCreditLimit)),
 	}, nil
 }
 
@@ -181,6 +245,14 @@ func (s *BankingService) DevGenerateTransactions(ctx context.Context, req *domai
 	now := time.Now()
 	var generatedTxns []domain.Transaction
 
+	// Receipts carry the customer's own bank details, resolved once
+	var own domain.PixRecipient
+	if req.WithReceipts {
+		own.Name, own.Document, own.Bank, own.Branch, own.Account = s.resolveSenderData(ctx, req.CustomerID)
+	}
+	receipts := 0
+	pixDailyUsed, pixMonthlyUsed := 0.0, 0.0
+
 	for i := 0; i < req.Count; i++ {
 		txInfo := txTypes[rand.Intn(len(txTypes))]
 		idx := rand.Intn(len(txInfo.Descs))
@@ -218,6 +290,21 @@ func (s *BankingService) DevGenerateTransactions(ctx context.Context, req *domai
 			totalExpenses += -amount // store as positive value
 		}
 
+		if req.WithReceipts && (txInfo.Type == "pix_sent" || txInfo.Type == "pix_received") {
+			receipt := devPixReceipt(req.CustomerID, txID, txInfo.Type, amount, desc, counterparty, txDate, own)
+			if _, err := s.store.SavePixReceipt(ctx, receipt); err != nil {
+				s.logger.Warn("DEV: failed to save pix receipt", zap.String("transaction_id", txID), zap.Error(err))
+			} else {
+				receipts++
+			}
+		}
+		if txInfo.Type == "pix_sent" && txDate.Year() == now.Year() && txDate.Month() == now.Month() {
+			pixMonthlyUsed += -amount
+			if txDate.YearDay() == now.YearDay() {
+				pixDailyUsed += -amount
+			}
+		}
+
 		generatedTxns = append(generatedTxns, domain.Transaction{
 			ID:           txID,
 			Date:         txDate,
@@ -229,6 +316,18 @@ func (s *BankingService) DevGenerateTransactions(ctx context.Context, req *domai
 		})
 	}
 
+	// The generated rows are spread over the last daysSpan days
+	if generated > 0 {
+		s.invalidateSpendingSummaries(ctx, req.CustomerID, now.AddDate(0, 0, -daysSpan))
+	}
+
+	if req.UpdateLimits && pixMonthlyUsed > 0 {
+		if err := s.store.AddTransactionLimitUsage(ctx, req.CustomerID, "pix", pixDailyUsed, pixMonthlyUsed); err != nil {
+			s.logger.Warn("DEV: failed to update pix limit usage",
+				zap.String("customer_id", req.CustomerID), zap.Error(err))
+		}
+	}
+
 	// Always update the account balance so generated transactions are reflected
 	// in the real balance, bank statement, income and expenses consistently.
 	var newBalance float64
@@ -257,6 +356,7 @@ func (s *BankingService) DevGenerateTransactions(ctx context.Context, req *domai
 	s.logger.Info("DEV: transactions generated",
 		zap.String("customer_id", req.CustomerID),
 		zap.Int("generated", generated),
+		zap.Int("receipts", receipts),
 		zap.Float64("income", totalIncome),
 		zap.Float64("expenses", totalExpenses),
 		zap.Float64("net_impact", netImpact),
@@ -270,11 +370,136 @@ func (s *BankingService) DevGenerateTransactions(ctx context.Context, req *domai
 		Expenses:     totalExpenses,
 		NetImpact:    netImpact,
 		NewBalance:   newBalance,
-		Message:      fmt.Sprintf("%d transações geradas com sucesso (saldo atualizado: R$ %.2f)", generated, newBalance),
+		Receipts:     receipts,
+		Message:      fmt.Sprintf("%d transações geradas com sucesso (saldo atualizado: %s)", generated, FormatBRL(newBalance)),
 		Transactions: generatedTxns,
 	}, nil
 }
 
+// devPixReceipt builds the receipt of a generated PIX transaction. The
+// customer is the sender of a pix_sent row and the recipient of a
+// pix_received one; the counterparty is only known by name.
+func devPixReceipt(customerID, txID, txType string, amount float64, desc, counterparty string, date time.Time, own domain.PixRecipient) *domain.PixReceipt {
+	dateStr := date.Format(time.RFC3339)
+	value := math.Abs(amount)
+	receipt := &domain.PixReceipt{
+		ID:             uuid.New().String(),
+		TransferID:     uuid.New().String(),
+		CustomerID:     customerID,
+		Direction:      "received",
+		Amount:         value,
+		OriginalAmount: value,
+		TotalAmount:    value,
+		Description:    desc,
+		EndToEndID:     fmt.Sprintf("E%s", strings.ReplaceAll(uuid.New().String(), "-", "")[:31]),
+		FundedBy:       "balance",
+		Installments:   1,
+		TransactionID:  txID,
+		Status:         "completed",
+		ExecutedAt:     dateStr,
+		CreatedAt:      dateStr,
+	}
+	if txType == "pix_sent" {
+		receipt.Direction = "sent"
+		receipt.SenderName, receipt.SenderDocument = own.Name, own.Document
+		receipt.SenderBank, receipt.SenderBranch, receipt.SenderAccount = own.Bank, own.Branch, own.Account
+		receipt.RecipientName = counterparty
+		return receipt
+	}
+	receipt.SenderName = counterparty
+	receipt.RecipientName, receipt.RecipientDocument = own.Name, own.Document
+	receipt.RecipientBank, receipt.RecipientBranch, receipt.RecipientAccount = own.Bank, own.Branch, own.Account
+	return receipt
+}
+
+// SetDevTools enables the dev tools, which callers must authenticate with
+// secret. They stay disabled without a secret, and are disabled by default.
+func (s *BankingService) SetDevTools(enabled bool, secret string) {
+	s.devToolsEnabled = enabled && secret != ""
+	s.devToolsSecret = []byte(secret)
+}
+
+// DevToolsEnabled reports whether the dev tools are available.
+func (s *BankingService) DevToolsEnabled() bool {
+	return s.devToolsEnabled
+}
+
+// CheckDevToolsSecret reports whether secret is the configured dev tools
+// secret. It is always false while the dev tools are disabled.
+func (s *BankingService) CheckDevToolsSecret(secret string) bool {
+	return s.devToolsEnabled && subtle.ConstantTimeCompare([]byte(secret), s.devToolsSecret) == 1
+}
+
+// DevResetCustomer clears the customer's movements so end-to-end tests start
+// from a clean slate: statement entries, PIX transfers and receipts,
+// scheduled transfers, bills, debit and credit card purchases are deleted,
+// card limits are fully released and the primary account balance is set to
+// req.Balance.
+func (s *BankingService) DevResetCustomer(ctx context.Context, req *domain.DevResetCustomerRequest) (*domain.DevResetCustomerResponse, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.DevResetCustomer")
+	defer span.End()
+
+	if !s.devToolsEnabled {
+		return nil, &domain.ErrForbidden{Action: "dev tools are disabled (DEV_TOOLS_ENABLED)"}
+	}
+	if req.CustomerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	if req.Balance < 0 {
+		return nil, &domain.ErrValidation{Field: "balance", Message: "não pode ser negativo"}
+	}
+
+	if _, err := s.store.GetPrimaryAccount(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	cleared, err := s.store.ClearCustomerData(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, n := range cleared {
+		total += n
+	}
+
+	cards, err := s.store.ListCreditCards(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, card := range cards {
+		if err := s.store.UpdateCreditCardUsedLimit(ctx, card.ID, 0, card.CreditLimit); err != nil {
+			return nil, err
+		}
+		if err := s.store.UpdateCreditCardPixCreditUsed(ctx, card.ID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set, not adjusted by the difference: a delta between float balances
+	// leaves a residue of a fraction of a cent.
+	updated, err := s.store.SetAccountBalance(ctx, req.CustomerID, req.Balance)
+	if err != nil {
+		return nil, err
+	}
+	newBalance := updated.Balance
+
+	s.logger.Info("DEV: customer data reset",
+		zap.String("customer_id", req.CustomerID),
+		zap.Int("rows_cleared", total),
+		zap.Int("cards_reset", len(cards)),
+		zap.Float64("new_balance", newBalance),
+	)
+
+	return &domain.DevResetCustomerResponse{
+		Success:      true,
+		Cleared:      cleared,
+		TotalCleared: total,
+		CardsReset:   len(cards),
+		NewBalance:   newBalance,
+		Message:      fmt.Sprintf("%d registros removidos (saldo: %s)", total, FormatBRL(newBalance)),
+	}, nil
+}
+
 // DevAddCardPurchase simulates credit card purchases for testing.
 func (s *BankingService) DevAddCardPurchase(ctx context.Context, req *domain.DevAddCardPurchaseRequest) (*domain.DevAddCardPurchaseResponse, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.DevAddCardPurchase")
@@ -396,7 +621,7 @@ func (s *BankingService) DevAddCardPurchase(ctx context.Context, req *domain.Dev
 			"status":              "confirmed",
 		}
 
-		if txErr := s.store.InsertCreditCardTransaction(ctx, tx); txErr != nil {
+		if txErr := s.insertCreditCardTransaction(ctx, tx); txErr != nil {
 			s.logger.Warn("DEV: failed to insert card purchase", zap.Int("index", i), zap.Error(txErr))
 			continue
 		}
@@ -0,0 +1,209 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestDevGenerateTransactions_PixReceiptsAndLimits(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.limits["pix"] = &domain.TransactionLimit{CustomerID: testCustomerID, TransactionType: "pix", DailyLimit: 1e9, MonthlyLimit: 1e9}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	resp, err := svc.DevGenerateTransactions(context.Background(), &domain.DevGenerateTransactionsRequest{
+		CustomerID:   testCustomerID,
+		Count:        100,
+		Period:       "current-month",
+		WithReceipts: true,
+		UpdateLimits: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	receipts := make(map[string]domain.PixReceipt)
+	for _, r := range store.pixReceipts {
+		receipts[r.TransactionID] = r
+	}
+
+	now := time.Now()
+	pixCount, monthlySent, dailySent := 0, 0.0, 0.0
+	for _, tx := range resp.Transactions {
+		if tx.Type != "pix_sent" && tx.Type != "pix_received" {
+			if _, ok := receipts[tx.ID]; ok {
+				t.Errorf("%s transaction %s got a pix receipt", tx.Type, tx.ID)
+			}
+			continue
+		}
+		pixCount++
+		r, ok := receipts[tx.ID]
+		if !ok {
+			t.Errorf("%s transaction %s has no receipt", tx.Type, tx.ID)
+			continue
+		}
+		wantDirection := map[string]string{"pix_sent": "sent", "pix_received": "received"}[tx.Type]
+		if r.Direction != wantDirection || r.Amount != math.Abs(tx.Amount) || r.CustomerID != testCustomerID {
+			t.Errorf("receipt of %s = %+v, want direction %s and amount %.2f", tx.ID, r, wantDirection, math.Abs(tx.Amount))
+		}
+		if tx.Type == "pix_sent" && tx.Date.Month() == now.Month() && tx.Date.Year() == now.Year() {
+			monthlySent += -tx.Amount
+			if tx.Date.YearDay() == now.YearDay() {
+				dailySent += -tx.Amount
+			}
+		}
+	}
+	if pixCount == 0 {
+		t.Fatal("no pix transactions generated; the check is meaningless")
+	}
+	if resp.Receipts != pixCount || len(store.pixReceipts) != pixCount {
+		t.Errorf("receipts = %d (stored %d), want %d", resp.Receipts, len(store.pixReceipts), pixCount)
+	}
+
+	limit := store.limits["pix"]
+	if math.Abs(limit.MonthlyUsed-monthlySent) > 0.001 || math.Abs(limit.DailyUsed-dailySent) > 0.001 {
+		t.Errorf("pix limit usage = daily %.2f / monthly %.2f, want %.2f / %.2f", limit.DailyUsed, limit.MonthlyUsed, dailySent, monthlySent)
+	}
+}
+
+func TestDevGenerateTransactions_NoReceiptsByDefault(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.limits["pix"] = &domain.TransactionLimit{CustomerID: testCustomerID, TransactionType: "pix"}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.DevGenerateTransactions(context.Background(), &domain.DevGenerateTransactionsRequest{CustomerID: testCustomerID, Count: 50}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.pixReceipts) != 0 || store.limits["pix"].MonthlyUsed != 0 {
+		t.Errorf("got %d receipts and monthly usage %.2f without the options", len(store.pixReceipts), store.limits["pix"].MonthlyUsed)
+	}
+}
+
+func TestDevResetCustomer_ClearsLists(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.cards[testCardID].UsedLimit = 2500
+	store.cards[testCardID].AvailableLimit = 7500
+	store.bills = []domain.BillPayment{{ID: "bill-1", CustomerID: testCustomerID}, {ID: "bill-2", CustomerID: "cust-999"}}
+	store.pixTransfers = []domain.PixTransfer{{ID: "pix-1", SourceCustomerID: testCustomerID}}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetDevTools(true, "dev-secret")
+	ctx := context.Background()
+
+	if _, err := svc.DevGenerateTransactions(ctx, &domain.DevGenerateTransactionsRequest{CustomerID: testCustomerID, Count: 30, WithReceipts: true}); err != nil {
+		t.Fatalf("generate: unexpected error: %v", err)
+	}
+
+	resp, err := svc.DevResetCustomer(ctx, &domain.DevResetCustomerRequest{CustomerID: testCustomerID, Balance: 5000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Cleared["customer_transactions"] != 30 || resp.Cleared["bill_payments"] != 1 || resp.Cleared["pix_transfers"] != 1 {
+		t.Errorf("cleared = %v, want 30 transactions, 1 bill, 1 transfer", resp.Cleared)
+	}
+	if resp.NewBalance != 5000 || store.accounts[testCustomerID].Balance != 5000 {
+		t.Errorf("balance = %.2f (stored %.2f), want 5000", resp.NewBalance, store.accounts[testCustomerID].Balance)
+	}
+
+	receipts, _ := svc.ListPixReceipts(ctx, testCustomerID, 1, 20)
+	transfers, _ := svc.ListPixTransfers(ctx, testCustomerID, 1, 20)
+	bills, err := svc.ListBillPayments(ctx, testCustomerID, 1, 20)
+	if err != nil {
+		t.Fatalf("bills: unexpected error: %v", err)
+	}
+	if len(store.transactions) != 0 || receipts.Total != 0 || len(transfers) != 0 || bills.Total != 0 {
+		t.Errorf("after reset: %d transactions, %d receipts, %d transfers, %d bills; want all empty",
+			len(store.transactions), receipts.Total, len(transfers), bills.Total)
+	}
+	if card := store.cards[testCardID]; card.UsedLimit != 0 || card.AvailableLimit != card.CreditLimit {
+		t.Errorf("card limits = used %.2f / available %.2f, want fully released", card.UsedLimit, card.AvailableLimit)
+	}
+	if len(store.bills) != 1 || store.bills[0].CustomerID != "cust-999" {
+		t.Errorf("another customer's bill was cleared: %v", store.bills)
+	}
+}
+
+func TestDevResetCustomer_DisabledByDefault(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.bills = []domain.BillPayment{{ID: "bill-1", CustomerID: testCustomerID}}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	var forbidden *domain.ErrForbidden
+	if _, err := svc.DevResetCustomer(context.Background(), &domain.DevResetCustomerRequest{CustomerID: testCustomerID}); !errors.As(err, &forbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if len(store.bills) != 1 {
+		t.Error("data cleared while dev tools are disabled")
+	}
+}
+
+func TestDevAddBalance_IdempotencyKeyCreditsOnce(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 100)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	req := &domain.DevAddBalanceRequest{CustomerID: testCustomerID, Amount: 50, IdempotencyKey: "setup-1"}
+	first, err := svc.DevAddBalance(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := svc.DevAddBalance(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+
+	if got := store.accounts[testCustomerID].Balance; got != 150 {
+		t.Errorf("balance = %v, want 150 (credited once)", got)
+	}
+	if len(store.transactions) != 1 {
+		t.Errorf("got %d statement entries, want 1", len(store.transactions))
+	}
+	if first.AlreadyApplied || !second.AlreadyApplied || second.NewBalance != first.NewBalance {
+		t.Errorf("first = %+v, second = %+v, want the retry to return the first result", first, second)
+	}
+
+	_, err = svc.DevAddBalance(context.Background(), &domain.DevAddBalanceRequest{CustomerID: testCustomerID, Amount: 70, IdempotencyKey: "setup-1"})
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected a conflict reusing the key for another amount, got %v", err)
+	}
+}
+
+func TestDevAddBalance_ClaimsKeyBeforeCrediting(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 100)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	// A call that claimed the key and has not credited yet
+	store.devBalance = map[string]domain.DevBalanceAdjustment{
+		"setup-1": {IdempotencyKey: "setup-1", CustomerID: testCustomerID, Amount: 50},
+	}
+	_, err := svc.DevAddBalance(ctx, &domain.DevAddBalanceRequest{CustomerID: testCustomerID, Amount: 50, IdempotencyKey: "setup-1"})
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected a conflict while the first call is in flight, got %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 100 {
+		t.Errorf("balance = %v, want 100 (not credited by the retry)", got)
+	}
+
+	// A call whose credit fails releases the key
+	if _, err := svc.DevAddBalance(ctx, &domain.DevAddBalanceRequest{CustomerID: "cust-missing", Amount: 50, IdempotencyKey: "setup-2"}); err == nil {
+		t.Fatal("expected the credit of an unknown customer to fail")
+	}
+	if _, ok := store.devBalance["setup-2"]; ok {
+		t.Error("idempotency key kept after a failed credit")
+	}
+}
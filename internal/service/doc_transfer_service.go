@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * DOC transfers — next-business-day settlement
+ *
+ * A DOC never settles on the day it is sent. Creating one holds the amount
+ * from the available balance (the ledger balance is untouched) and sets its
+ * settlement date to the next business day on the schedule calendar. On
+ * that day the worker debits the amount, consumes the hold and records the
+ * statement entry in a single database transaction, then saves the receipt;
+ * a DOC whose account is no longer active is marked failed with the hold
+ * released, so the funds return to the customer.
+ */
+
+const (
+	// dueDOCTransferBatch bounds how many DOCs one worker iteration settles.
+	dueDOCTransferBatch = 50
+
+	// docProcessingTimeout is how long a claimed DOC may stay processing
+	// before the worker considers its instance gone and claims it again.
+	docProcessingTimeout = 10 * time.Minute
+)
+
+// CreateDOCTransfer sends a DOC from the customer's primary account. The
+// amount is held immediately and settled on the next business day by
+// ExecuteDueDOCTransfers. A retry with the idempotency key of an earlier
+// DOC returns that DOC and holds nothing.
+func (s *BankingService) CreateDOCTransfer(ctx context.Context, customerID string, req *domain.DOCTransferRequest) (*domain.DOCTransfer, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.CreateDOCTransfer")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Float64("amount", req.Amount))
+
+	if err := validateDOCTransferRequest(req); err != nil {
+		return nil, err
+	}
+	if existing, err := s.replayDOCTransfer(ctx, customerID, req); existing != nil || err != nil {
+		return existing, err
+	}
+
+	account, err := s.store.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != "active" {
+		return nil, &domain.ErrAccountBlocked{Status: account.Status}
+	}
+	if spendableBalance(account) < req.Amount {
+		return nil, &domain.ErrInsufficientFunds{Available: spendableBalance(account), Required: req.Amount}
+	}
+
+	now := time.Now()
+	transfer := &domain.DOCTransfer{
+		ID:                uuid.New().String(),
+		IdempotencyKey:    req.IdempotencyKey,
+		CustomerID:        customerID,
+		AccountID:         account.ID,
+		RecipientName:     req.RecipientName,
+		RecipientDocument: req.RecipientDocument,
+		RecipientBank:     req.RecipientBank,
+		RecipientBranch:   req.RecipientBranch,
+		RecipientAccount:  req.RecipientAccount,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		Status:            "held",
+		SettlementDate:    docSettlementDate(now).Format("2006-01-02"),
+		CreatedAt:         now,
+	}
+
+	if _, err := s.store.AdjustAvailableBalance(ctx, customerID, -req.Amount); err != nil {
+		return nil, fmt.Errorf("hold DOC amount: %w", err)
+	}
+	saved, err := s.store.CreateDOCTransfer(ctx, transfer)
+	if err != nil {
+		s.releaseDOCHold(ctx, transfer)
+		// A concurrent request with the same key won the insert; return its DOC
+		var duplicate *domain.ErrDuplicate
+		if errors.As(err, &duplicate) {
+			if existing, replayErr := s.replayDOCTransfer(ctx, customerID, req); existing != nil || replayErr != nil {
+				return existing, replayErr
+			}
+		}
+		return nil, err
+	}
+
+	s.logger.Info("DOC transfer created, amount held",
+		zap.String("customer_id", customerID),
+		zap.String("transfer_id", saved.ID),
+		zap.Float64("amount", saved.Amount),
+		zap.String("settlement_date", saved.SettlementDate))
+	return saved, nil
+}
+
+// replayDOCTransfer returns the customer's DOC created with the idempotency
+// key of req, or nil when there is none. A key reused for another DOC is a
+// conflict, and the replay of a failed DOC fails too.
+func (s *BankingService) replayDOCTransfer(ctx context.Context, customerID string, req *domain.DOCTransferRequest) (*domain.DOCTransfer, error) {
+	existing, err := s.store.GetDOCTransferByIdempotencyKey(ctx, customerID, req.IdempotencyKey)
+	if err != nil {
+		var notFound *domain.ErrNotFound
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if existing.Amount != req.Amount || existing.RecipientBank != req.RecipientBank ||
+		existing.RecipientBranch != req.RecipientBranch || existing.RecipientAccount != req.RecipientAccount {
+		return nil, &domain.ErrConflict{Message: "idempotency key already used for a different DOC transfer"}
+	}
+	if existing.Status == "failed" {
+		return nil, &domain.ErrInvalidState{Resource: "doc_transfer", Status: existing.Status, Action: "replay"}
+	}
+
+	s.logger.Info("DOC transfer replayed for idempotency key",
+		zap.String("customer_id", customerID),
+		zap.String("transfer_id", existing.ID),
+		zap.String("status", existing.Status))
+	return existing, nil
+}
+
+func validateDOCTransferRequest(req *domain.DOCTransferRequest) error {
+	if req.Amount <= 0 {
+		return &domain.ErrValidation{Field: "amount", Message: "must be positive"}
+	}
+	if req.IdempotencyKey == "" {
+		return &domain.ErrValidation{Field: "idempotencyKey", Message: "required"}
+	}
+	for _, f := range []struct{ field, value string }{
+		{"recipientName", req.RecipientName},
+		{"recipientDocument", req.RecipientDocument},
+		{"recipientBank", req.RecipientBank},
+		{"recipientBranch", req.RecipientBranch},
+		{"recipientAccount", req.RecipientAccount},
+	} {
+		if f.value == "" {
+			return &domain.ErrValidation{Field: f.field, Message: "required"}
+		}
+	}
+	return nil
+}
+
+// docSettlementDate is the business day after the day of now.
+func docSettlementDate(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return nextBusinessDay(time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()))
+}
+
+// ExecuteDueDOCTransfers settles the DOCs whose settlement date has come.
+// Each transfer is claimed first so that it settles once even with several
+// instances. Transfers left processing by an instance that died mid-way are
+// put back to held first: settlement is one database transaction, so such a
+// transfer moved no money and is safe to claim again.
+func (s *BankingService) ExecuteDueDOCTransfers(ctx context.Context) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ExecuteDueDOCTransfers")
+	defer span.End()
+
+	now := time.Now()
+	released, err := s.store.ReleaseStaleDOCTransfers(ctx, now.Add(-docProcessingTimeout))
+	if err != nil {
+		s.logger.Error("failed to release stale DOC transfers", zap.Error(err))
+	} else if released > 0 {
+		s.logger.Warn("stale DOC transfers put back to held", zap.Int("count", released))
+	}
+
+	due, err := s.store.ListDueDOCTransfers(ctx, now.Format("2006-01-02"), dueDOCTransferBatch)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("due_count", len(due)))
+
+	for i := range due {
+		transfer := &due[i]
+		claimed, err := s.store.ClaimDOCTransfer(ctx, transfer.ID)
+		if err != nil {
+			s.logger.Error("failed to claim DOC transfer",
+				zap.String("transfer_id", transfer.ID), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.settleDOCTransfer(ctx, transfer)
+	}
+	return nil
+}
+
+// settleDOCTransfer debits a claimed DOC and consumes its hold in one store
+// call. A DOC whose account is no longer active fails with the hold
+// released; a store error leaves it processing, to be put back to held once
+// docProcessingTimeout has passed.
+func (s *BankingService) settleDOCTransfer(ctx context.Context, transfer *domain.DOCTransfer) {
+	now := time.Now()
+	description := fmt.Sprintf("DOC enviado - %s", transfer.RecipientName)
+	entry := map[string]any{
+		"id":          uuid.New().String(),
+		"customer_id": transfer.CustomerID,
+		"date":        now.Format(time.RFC3339),
+		"description": description,
+		"category":    "transferencia",
+	}
+	s.applyCategoryRules(ctx, entry, description)
+
+	status, err := s.store.SettleDOCTransfer(ctx, transfer.ID, entry)
+	if err != nil {
+		s.logger.Error("failed to settle DOC transfer",
+			zap.String("customer_id", transfer.CustomerID),
+			zap.String("transfer_id", transfer.ID),
+			zap.Error(err))
+		return
+	}
+	transfer.Status = status
+	if status != "completed" {
+		s.logger.Warn("DOC transfer not settled, hold released",
+			zap.String("customer_id", transfer.CustomerID),
+			zap.String("transfer_id", transfer.ID),
+			zap.String("status", status))
+		return
+	}
+
+	s.invalidateSpendingSummaries(ctx, transfer.CustomerID, now)
+	s.saveDOCTransferReceipt(ctx, transfer, now)
+
+	s.logger.Info("DOC transfer settled",
+		zap.String("customer_id", transfer.CustomerID),
+		zap.String("transfer_id", transfer.ID),
+		zap.Float64("amount", transfer.Amount))
+}
+
+// releaseDOCHold gives the held amount back to the available balance and
+// reports whether it did.
+func (s *BankingService) releaseDOCHold(ctx context.Context, transfer *domain.DOCTransfer) bool {
+	if _, err := s.store.AdjustAvailableBalance(ctx, transfer.CustomerID, transfer.Amount); err != nil {
+		s.logger.Error("failed to release DOC hold",
+			zap.String("customer_id", transfer.CustomerID),
+			zap.String("transfer_id", transfer.ID),
+			zap.Float64("amount", transfer.Amount),
+			zap.Error(err))
+		return false
+	}
+	s.logger.Info("DOC hold released",
+		zap.String("customer_id", transfer.CustomerID),
+		zap.String("transfer_id", transfer.ID),
+		zap.Float64("amount", transfer.Amount))
+	return true
+}
+
+// saveDOCTransferReceipt generates the receipt of a settled DOC. The money
+// has already moved, so a failure is only logged.
+func (s *BankingService) saveDOCTransferReceipt(ctx context.Context, transfer *domain.DOCTransfer, settledAt time.Time) {
+	if _, err := s.GenerateTransferReceipt(ctx, &domain.TransferReceipt{
+		TransferID:        transfer.ID,
+		TransferType:      domain.TransferTypeDOC,
+		CustomerID:        transfer.CustomerID,
+		Amount:            transfer.Amount,
+		Description:       transfer.Description,
+		RecipientName:     transfer.RecipientName,
+		RecipientDocument: transfer.RecipientDocument,
+		RecipientBank:     transfer.RecipientBank,
+		RecipientBranch:   transfer.RecipientBranch,
+		RecipientAccount:  transfer.RecipientAccount,
+		ExecutedAt:        settledAt.Format(time.RFC3339),
+	}); err != nil {
+		s.logger.Error("failed to save DOC transfer receipt",
+			zap.String("transfer_id", transfer.ID), zap.Error(err))
+	}
+}
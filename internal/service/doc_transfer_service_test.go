@@ -0,0 +1,183 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestCreateDOCTransfer_HoldsUntilSettlementDay(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	transfer, err := svc.CreateDOCTransfer(ctx, testCustomerID, &domain.DOCTransferRequest{
+		IdempotencyKey:    "doc-1",
+		RecipientName:     "Fornecedor Ltda",
+		RecipientDocument: "12345678000199",
+		RecipientBank:     "001",
+		RecipientBranch:   "1234",
+		RecipientAccount:  "98765-4",
+		Amount:            300,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Held right away: available drops, the ledger balance does not.
+	acct := store.accounts[testCustomerID]
+	if acct.AvailableBalance != 700 || acct.Balance != 1000 {
+		t.Errorf("after creation available = %v, balance = %v, want 700 held from 1000", acct.AvailableBalance, acct.Balance)
+	}
+	today := time.Now().Format("2006-01-02")
+	settlement, err := time.Parse("2006-01-02", transfer.SettlementDate)
+	if err != nil || transfer.SettlementDate <= today {
+		t.Fatalf("settlement date = %q, want a day after %s", transfer.SettlementDate, today)
+	}
+	if wd := settlement.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		t.Errorf("settlement date %s falls on a %s, want a business day", transfer.SettlementDate, wd)
+	}
+
+	// Not due yet: the worker leaves it held.
+	if err := svc.ExecuteDueDOCTransfers(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.docTransfers[0].Status; got != "held" {
+		t.Fatalf("status before settlement day = %q, want held", got)
+	}
+
+	// Settlement day comes: the hold is released and the amount debited.
+	store.docTransfers[0].SettlementDate = today
+	if err := svc.ExecuteDueDOCTransfers(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.docTransfers[0].Status; got != "completed" {
+		t.Errorf("status on settlement day = %q, want completed", got)
+	}
+	if acct.AvailableBalance != 700 || acct.Balance != 700 {
+		t.Errorf("after settlement available = %v, balance = %v, want 700 both", acct.AvailableBalance, acct.Balance)
+	}
+	if len(store.transactions) != 1 || store.transactions[0]["amount"] != -300.0 {
+		t.Errorf("statement entries = %v, want one debit of 300", store.transactions)
+	}
+	if len(store.txReceipts) != 1 || store.txReceipts[0].TransferType != domain.TransferTypeDOC {
+		t.Errorf("receipts = %+v, want one DOC receipt", store.txReceipts)
+	}
+}
+
+func TestExecuteDueDOCTransfers_RecoversStaleClaim(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := svc.CreateDOCTransfer(ctx, testCustomerID, &domain.DOCTransferRequest{
+		IdempotencyKey:    "doc-stale",
+		RecipientName:     "Fornecedor Ltda",
+		RecipientDocument: "12345678000199",
+		RecipientBank:     "001",
+		RecipientBranch:   "1234",
+		RecipientAccount:  "98765-4",
+		Amount:            300,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An instance claimed it and died before settling.
+	store.docTransfers[0].SettlementDate = time.Now().Format("2006-01-02")
+	store.docTransfers[0].Status = "processing"
+	store.docTransfers[0].UpdatedAt = time.Now().Add(-time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := svc.ExecuteDueDOCTransfers(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := store.docTransfers[0].Status; got != "completed" {
+		t.Errorf("status = %q, want completed", got)
+	}
+	acct := store.accounts[testCustomerID]
+	if acct.AvailableBalance != 700 || acct.Balance != 700 {
+		t.Errorf("available = %v, balance = %v, want 700 both (debited once)", acct.AvailableBalance, acct.Balance)
+	}
+	if len(store.transactions) != 1 {
+		t.Errorf("statement entries = %d, want 1", len(store.transactions))
+	}
+}
+
+func TestCreateDOCTransfer_InsufficientFundsHoldsNothing(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 100)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.CreateDOCTransfer(context.Background(), testCustomerID, &domain.DOCTransferRequest{
+		IdempotencyKey:    "doc-poor",
+		RecipientName:     "Fornecedor Ltda",
+		RecipientDocument: "12345678000199",
+		RecipientBank:     "001",
+		RecipientBranch:   "1234",
+		RecipientAccount:  "98765-4",
+		Amount:            300,
+	})
+	var fundsErr *domain.ErrInsufficientFunds
+	if !errors.As(err, &fundsErr) {
+		t.Fatalf("err = %v, want ErrInsufficientFunds", err)
+	}
+	if got := store.accounts[testCustomerID].AvailableBalance; got != 100 {
+		t.Errorf("available = %v, want 100 untouched", got)
+	}
+	if len(store.docTransfers) != 0 {
+		t.Errorf("stored %d DOC transfers, want none", len(store.docTransfers))
+	}
+}
+
+func TestCreateDOCTransfer_ReplaysIdempotencyKey(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	req := func(amount float64) *domain.DOCTransferRequest {
+		return &domain.DOCTransferRequest{
+			IdempotencyKey:    "doc-retry",
+			RecipientName:     "Fornecedor Ltda",
+			RecipientDocument: "12345678000199",
+			RecipientBank:     "001",
+			RecipientBranch:   "1234",
+			RecipientAccount:  "98765-4",
+			Amount:            amount,
+		}
+	}
+
+	first, err := svc.CreateDOCTransfer(ctx, testCustomerID, req(300))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	retry, err := svc.CreateDOCTransfer(ctx, testCustomerID, req(300))
+	if err != nil {
+		t.Fatalf("retry: unexpected error: %v", err)
+	}
+	if retry.ID != first.ID || len(store.docTransfers) != 1 {
+		t.Errorf("retry created %d DOCs (id %s, first %s), want the original replayed", len(store.docTransfers), retry.ID, first.ID)
+	}
+	if got := store.accounts[testCustomerID].AvailableBalance; got != 700 {
+		t.Errorf("available = %v, want 700: the retry must hold nothing", got)
+	}
+
+	_, err = svc.CreateDOCTransfer(ctx, testCustomerID, req(500))
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Errorf("same key, other amount: err = %v, want ErrConflict", err)
+	}
+	if got := store.accounts[testCustomerID].AvailableBalance; got != 700 {
+		t.Errorf("available after conflict = %v, want 700", got)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Internal transfers — between accounts of the same customer
+ */
+
+// CreateInternalTransfer moves funds between two accounts owned by the
+// customer. Unlike PIX, the destination is the customer's own account, so
+// there is no self-transfer block. Both balances change in one store call;
+// the paired statement entries and the receipt are recorded afterwards.
+func (s *BankingService) CreateInternalTransfer(ctx context.Context, customerID, fromAccountID, toAccountID string, amount float64) (*domain.InternalTransfer, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.CreateInternalTransfer")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Float64("amount", amount))
+
+	if amount <= 0 {
+		return nil, &domain.ErrValidation{Field: "amount", Message: "must be positive"}
+	}
+	if fromAccountID == "" {
+		return nil, &domain.ErrValidation{Field: "fromAccountId", Message: "required"}
+	}
+	if toAccountID == "" {
+		return nil, &domain.ErrValidation{Field: "toAccountId", Message: "required"}
+	}
+	if fromAccountID == toAccountID {
+		return nil, &domain.ErrValidation{Field: "toAccountId", Message: "must be different from fromAccountId"}
+	}
+
+	// Both accounts must belong to the customer (GetAccount filters by owner)
+	from, err := s.store.GetAccount(ctx, customerID, fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.store.GetAccount(ctx, customerID, toAccountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, acct := range []*domain.Account{from, to} {
+		if acct.Status != "active" {
+			return nil, &domain.ErrAccountBlocked{Status: acct.Status}
+		}
+	}
+	if spendableBalance(from) < amount {
+		return nil, &domain.ErrInsufficientFunds{Available: spendableBalance(from), Required: amount}
+	}
+
+	fromBalance, toBalance, err := s.store.TransferBetweenAccounts(ctx, customerID, fromAccountID, toAccountID, amount)
+	if err != nil {
+		s.logger.Error("failed to transfer between accounts",
+			zap.String("customer_id", customerID),
+			zap.String("from_account_id", fromAccountID),
+			zap.String("to_account_id", toAccountID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	transfer := &domain.InternalTransfer{
+		ID:            uuid.New().String(),
+		CustomerID:    customerID,
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		FromBalance:   fromBalance,
+		ToBalance:     toBalance,
+		CreatedAt:     now,
+	}
+
+	s.recordInternalTransfer(ctx, transfer, from, to)
+	s.saveInternalTransferReceipt(ctx, transfer, from, to)
+
+	s.logger.Info("internal transfer completed",
+		zap.String("customer_id", customerID),
+		zap.String("transfer_id", transfer.ID),
+		zap.String("from_account_id", fromAccountID),
+		zap.String("to_account_id", toAccountID),
+		zap.Float64("amount", amount))
+
+	return transfer, nil
+}
+
+// recordInternalTransfer writes the debit and credit statement entries. The
+// money has already moved, so failures are logged rather than returned.
+func (s *BankingService) recordInternalTransfer(ctx context.Context, transfer *domain.InternalTransfer, from, to *domain.Account) {
+	entries := []map[string]any{
+		{
+			"id":          uuid.New().String(),
+			"customer_id": transfer.CustomerID,
+			"date":        transfer.CreatedAt.Format(time.RFC3339),
+			"description": fmt.Sprintf("Transferência para conta %s-%s", to.AccountNumber, to.Digit),
+			"amount":      -transfer.Amount,
+			"type":        "transfer_out",
+			"category":    "transferencia",
+		},
+		{
+			"id":          uuid.New().String(),
+			"customer_id": transfer.CustomerID,
+			"date":        transfer.CreatedAt.Format(time.RFC3339),
+			"description": fmt.Sprintf("Transferência da conta %s-%s", from.AccountNumber, from.Digit),
+			"amount":      transfer.Amount,
+			"type":        "transfer_in",
+			"category":    "transferencia",
+		},
+	}
+	for _, tx := range entries {
+		if err := s.insertTransaction(ctx, tx); err != nil {
+			s.logger.Error("failed to record internal transfer transaction",
+				zap.String("transfer_id", transfer.ID),
+				zap.Any("type", tx["type"]),
+				zap.Error(err))
+		}
+	}
+}
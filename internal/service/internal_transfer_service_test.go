@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const testSavingsAccountID = "acct-002"
+
+func newInternalTransferService() (*service.BankingService, *fakeBankingStore) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.otherAccounts = append(store.otherAccounts, &domain.Account{
+		ID:               testSavingsAccountID,
+		CustomerID:       testCustomerID,
+		AccountNumber:    "65432",
+		Digit:            "1",
+		Balance:          200,
+		AvailableBalance: 200,
+		Status:           "active",
+	})
+	return service.NewBankingService(store, observability.NewMetrics(), zap.NewNop()), store
+}
+
+func TestCreateInternalTransfer_MovesBetweenOwnAccounts(t *testing.T) {
+	svc, store := newInternalTransferService()
+	ctx := context.Background()
+
+	transfer, err := svc.CreateInternalTransfer(ctx, testCustomerID, testAccountID, testSavingsAccountID, 300)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.FromBalance != 700 || transfer.ToBalance != 500 {
+		t.Errorf("unexpected balances: from=%.2f to=%.2f", transfer.FromBalance, transfer.ToBalance)
+	}
+
+	accounts, _ := svc.ListAccounts(ctx, testCustomerID)
+	total := 0.0
+	for _, acct := range accounts {
+		total += acct.Balance
+	}
+	if total != 1200 {
+		t.Errorf("balance not conserved: total %.2f, want 1200", total)
+	}
+
+	if len(store.transactions) != 2 {
+		t.Fatalf("expected paired transactions, got %d", len(store.transactions))
+	}
+	if store.transactions[0]["type"] != "transfer_out" || store.transactions[0]["amount"] != -300.0 ||
+		store.transactions[1]["type"] != "transfer_in" || store.transactions[1]["amount"] != 300.0 {
+		t.Errorf("unexpected transactions: %+v", store.transactions)
+	}
+}
+
+func TestCreateInternalTransfer_Rejects(t *testing.T) {
+	svc, store := newInternalTransferService()
+	seedPixRecipient(store, "cust-002", "Outra Empresa", "outra@empresa.com")
+	ctx := context.Background()
+
+	var validation *domain.ErrValidation
+	if _, err := svc.CreateInternalTransfer(ctx, testCustomerID, testAccountID, testAccountID, 10); !errors.As(err, &validation) {
+		t.Errorf("same account: expected validation error, got %v", err)
+	}
+
+	var notFound *domain.ErrNotFound
+	if _, err := svc.CreateInternalTransfer(ctx, testCustomerID, testAccountID, "acct-cust-002", 10); !errors.As(err, &notFound) {
+		t.Errorf("foreign account: expected not found, got %v", err)
+	}
+
+	var insufficient *domain.ErrInsufficientFunds
+	if _, err := svc.CreateInternalTransfer(ctx, testCustomerID, testSavingsAccountID, testAccountID, 500); !errors.As(err, &insufficient) {
+		t.Errorf("expected insufficient funds, got %v", err)
+	}
+
+	if len(store.transactions) != 0 {
+		t.Errorf("rejected transfers must not record transactions, got %d", len(store.transactions))
+	}
+}
+
+func TestCreateInternalTransfer_GeneratesReceipt(t *testing.T) {
+	svc, _ := newInternalTransferService()
+	ctx := context.Background()
+
+	transfer, err := svc.CreateInternalTransfer(ctx, testCustomerID, testAccountID, testSavingsAccountID, 150)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	receipt, err := svc.GetTransferReceiptByTransferID(ctx, transfer.ID)
+	if err != nil {
+		t.Fatalf("expected a receipt for the transfer: %v", err)
+	}
+	if receipt.ID != transfer.ReceiptID || receipt.TransferType != domain.TransferTypeInternal || receipt.Amount != 150 {
+		t.Errorf("unexpected receipt %+v for transfer %+v", receipt, transfer)
+	}
+	if receipt.SenderName != "Empresa Teste LTDA" || receipt.RecipientName != receipt.SenderName ||
+		receipt.RecipientDocument != receipt.SenderDocument {
+		t.Errorf("expected the customer on both sides, got %+v", receipt)
+	}
+	if receipt.RecipientAccount != "65432-1" {
+		t.Errorf("recipient account = %q, want the destination account", receipt.RecipientAccount)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+/*
+ * Ledger — balance movements with their statement entry
+ *
+ * The account balance and the customer_transactions entry live in separate
+ * rows updated by separate calls. To keep them from diverging, the entry is
+ * inserted as pending before the balance moves and confirmed afterwards.
+ * Statement reads only return confirmed entries, so a failure at any step
+ * leaves either nothing or a failed entry behind — never a debit without a
+ * statement line.
+ */
+
+// Statement entry status values.
+const (
+	txStatusPending   = "pending"
+	txStatusConfirmed = "confirmed"
+	txStatusFailed    = "failed"
+)
+
+// spendableBalance is how much can be debited from acct: its available
+// balance plus its overdraft limit.
+func spendableBalance(acct *domain.Account) float64 {
+	return acct.AvailableBalance + acct.OverdraftLimit
+}
+
+// moveBalance applies delta to the customer's balance and records tx as its
+// statement entry. tx must carry an "id". If the balance update fails the
+// entry is marked failed; if the entry cannot be confirmed the balance update
+// is reversed as well. The customer's category rules apply to the entry.
+func (s *BankingService) moveBalance(ctx context.Context, customerID string, delta float64, tx map[string]any) error {
+	txID, _ := tx["id"].(string)
+	description, _ := tx["description"].(string)
+	s.applyCategoryRules(ctx, tx, description)
+	tx["status"] = txStatusPending
+	if err := s.store.InsertTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("record statement entry: %w", err)
+	}
+
+	if _, err := s.store.UpdateAccountBalance(ctx, customerID, delta); err != nil {
+		s.failStatementEntry(ctx, customerID, txID)
+		return fmt.Errorf("update balance: %w", err)
+	}
+
+	if err := s.store.UpdateTransactionStatus(ctx, txID, txStatusConfirmed); err != nil {
+		if _, revErr := s.store.UpdateAccountBalance(ctx, customerID, -delta); revErr != nil {
+			s.logger.Error("failed to reverse balance update after unconfirmed statement entry",
+				zap.String("customer_id", customerID),
+				zap.String("transaction_id", txID),
+				zap.Float64("delta", delta),
+				zap.Error(revErr),
+			)
+		}
+		s.failStatementEntry(ctx, customerID, txID)
+		return fmt.Errorf("confirm statement entry: %w", err)
+	}
+
+	s.invalidateSpendingSummaries(ctx, customerID, time.Now())
+	return nil
+}
+
+// failStatementEntry marks a pending entry as failed. A failure here is only
+// logged: the entry stays pending, which statement reads ignore as well.
+func (s *BankingService) failStatementEntry(ctx context.Context, customerID, txID string) {
+	if err := s.store.UpdateTransactionStatus(ctx, txID, txStatusFailed); err != nil {
+		s.logger.Error("failed to mark statement entry as failed",
+			zap.String("customer_id", customerID),
+			zap.String("transaction_id", txID),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RoundMoney rounds amount to cents, halves away from zero (half-up for
+// positive amounts). The float error of the multiplication is absorbed
+// first, so 1.005 rounds to 1.01 and 0.1+0.2 to 0.3. Every monetary value
+// leaving the API goes through it; amounts are still float64 internally.
+func RoundMoney(amount float64) float64 {
+	cents := math.Round(amount*100*1e6) / 1e6
+	return math.Round(cents) / 100
+}
+
+// FormatBRL formats amount the way Brazilian customers read it, with a dot
+// between thousands and a decimal comma: 1234.5 becomes "R$ 1.234,50" and
+// -80 becomes "-R$ 80,00". Use it for amounts embedded in descriptions and
+// messages; JSON amounts stay numbers.
+func FormatBRL(amount float64) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	units := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	if amount < 0 && cents > 0 {
+		b.WriteByte('-')
+	}
+	b.WriteString("R$ ")
+	for i, d := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	b.WriteByte(',')
+	frac := strconv.FormatInt(cents%100, 10)
+	if len(frac) == 1 {
+		b.WriteByte('0')
+	}
+	b.WriteString(frac)
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+)
+
+func TestFormatBRL(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "R$ 0,00"},
+		{0.5, "R$ 0,50"},
+		{999.99, "R$ 999,99"},
+		{1234.5, "R$ 1.234,50"},
+		{1000000, "R$ 1.000.000,00"},
+		{123456789.01, "R$ 123.456.789,01"},
+		{-80, "-R$ 80,00"},
+		{-1234.56, "-R$ 1.234,56"},
+		{-0.001, "R$ 0,00"},
+	}
+	for _, tt := range tests {
+		if got := service.FormatBRL(tt.amount); got != tt.want {
+			t.Errorf("FormatBRL(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   float64
+	}{
+		{0.1 + 0.2, 0.3},
+		{12.34 + 0.000000000000001, 12.34},
+		{1.005, 1.01},
+		{2.675, 2.68},
+		{1040.0 / 3, 346.67},
+		{-1.005, -1.01},
+		{12.344, 12.34},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := service.RoundMoney(tt.amount); got != tt.want {
+			t.Errorf("RoundMoney(%v) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
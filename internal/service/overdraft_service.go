@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Overdraft (cheque especial)
+ *
+ * Debits from the balance may take the available balance below zero down to
+ * the account's overdraft limit (see spendableBalance). Raising the limit is
+ * approved against the customer's credit score; lowering it is always
+ * allowed, as long as the new limit still covers the overdraft in use.
+ */
+
+// DefaultMaxOverdraftLimit is the highest overdraft limit any account can
+// have when none is configured.
+const DefaultMaxOverdraftLimit = 50000.0
+
+// overdraftScoreTiers maps the minimum credit score to the share of the
+// maximum overdraft limit it approves, highest score first.
+var overdraftScoreTiers = []struct {
+	minScore int
+	share    float64
+}{
+	{800, 1},
+	{600, 0.5},
+	{400, 0.25},
+}
+
+// SetMaxOverdraftLimit overrides the maximum overdraft limit. Non-positive
+// values keep the current maximum.
+func (s *BankingService) SetMaxOverdraftLimit(limit float64) {
+	if limit > 0 {
+		s.maxOverdraftLimit = limit
+	}
+}
+
+// approvedOverdraftLimit is the highest overdraft limit a credit score
+// approves. Scores below the lowest tier approve none.
+func (s *BankingService) approvedOverdraftLimit(score int) float64 {
+	for _, tier := range overdraftScoreTiers {
+		if score >= tier.minScore {
+			return RoundMoney(s.maxOverdraftLimit * tier.share)
+		}
+	}
+	return 0
+}
+
+// GetOverdraft returns the overdraft limit of one of the customer's accounts,
+// how much of it is in use and the highest limit the customer may set.
+func (s *BankingService) GetOverdraft(ctx context.Context, customerID, accountID string) (*domain.Overdraft, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetOverdraft")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	account, err := s.store.GetAccount(ctx, customerID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	score, err := s.store.GetCustomerCreditScore(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return newOverdraft(account, s.approvedOverdraftLimit(score)), nil
+}
+
+// SetOverdraftLimit sets the overdraft limit of one of the customer's active
+// accounts. Increases above what the credit score approves fail with
+// ErrLimitExceeded.
+func (s *BankingService) SetOverdraftLimit(ctx context.Context, customerID, accountID string, limit float64) (*domain.Overdraft, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.SetOverdraftLimit")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("customer.id", customerID),
+		attribute.Float64("overdraft_limit", limit),
+	)
+
+	if limit < 0 {
+		return nil, &domain.ErrValidation{Field: "overdraft_limit", Message: "must not be negative"}
+	}
+	if limit > s.maxOverdraftLimit {
+		return nil, &domain.ErrValidation{Field: "overdraft_limit", Message: fmt.Sprintf("must not exceed %.2f", s.maxOverdraftLimit)}
+	}
+
+	account, err := s.store.GetAccount(ctx, customerID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != "active" {
+		return nil, &domain.ErrAccountBlocked{Status: account.Status}
+	}
+	if used := math.Max(0, -account.AvailableBalance); limit < used {
+		return nil, &domain.ErrValidation{Field: "overdraft_limit", Message: fmt.Sprintf("must cover the %.2f overdraft in use", used)}
+	}
+
+	score, err := s.store.GetCustomerCreditScore(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	approved := s.approvedOverdraftLimit(score)
+	if limit > account.OverdraftLimit && limit > approved {
+		return nil, &domain.ErrLimitExceeded{LimitType: "overdraft", Limit: approved, Current: limit}
+	}
+
+	updated, err := s.store.UpdateAccountOverdraftLimit(ctx, customerID, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("overdraft limit updated",
+		zap.String("customer_id", customerID),
+		zap.String("account_id", accountID),
+		zap.Float64("old_limit", account.OverdraftLimit),
+		zap.Float64("new_limit", updated.OverdraftLimit),
+	)
+	return newOverdraft(updated, approved), nil
+}
+
+func newOverdraft(account *domain.Account, approved float64) *domain.Overdraft {
+	used := math.Max(0, -account.AvailableBalance)
+	return &domain.Overdraft{
+		AccountID:      account.ID,
+		OverdraftLimit: account.OverdraftLimit,
+		MaxLimit:       approved,
+		Used:           used,
+		Available:      math.Max(0, account.OverdraftLimit-used),
+	}
+}
@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestSetOverdraftLimit_ApprovedByScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		score   int
+		limit   float64
+		wantErr bool
+	}{
+		{"high score gets the maximum", 850, 50000, false},
+		{"mid score gets half", 650, 25000, false},
+		{"mid score cannot exceed half", 650, 25000.01, true},
+		{"low score gets none", 300, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 1000)
+			store.creditScores[testCustomerID] = tt.score
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			got, err := svc.SetOverdraftLimit(context.Background(), testCustomerID, testAccountID, tt.limit)
+			if tt.wantErr {
+				var limitErr *domain.ErrLimitExceeded
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("expected ErrLimitExceeded, got %v", err)
+				}
+				if store.accounts[testCustomerID].OverdraftLimit != 0 {
+					t.Errorf("overdraft limit = %v, want it unchanged", store.accounts[testCustomerID].OverdraftLimit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.OverdraftLimit != tt.limit || store.accounts[testCustomerID].OverdraftLimit != tt.limit {
+				t.Errorf("overdraft limit = %v (stored %v), want %v", got.OverdraftLimit, store.accounts[testCustomerID].OverdraftLimit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSetOverdraftLimit_AboveMaximum(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.creditScores[testCustomerID] = 900
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.SetOverdraftLimit(context.Background(), testCustomerID, testAccountID, service.DefaultMaxOverdraftLimit+1)
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+}
+
+// TestDebitPurchase_WithinOverdraft spends past the balance once an
+// overdraft limit covers the difference, and is declined beyond it.
+func TestDebitPurchase_WithinOverdraft(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 500)
+	store.creditScores[testCustomerID] = 700
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := svc.SetOverdraftLimit(ctx, testCustomerID, testAccountID, 1000); err != nil {
+		t.Fatalf("set overdraft: unexpected error: %v", err)
+	}
+
+	resp, err := svc.CreateDebitPurchase(ctx, testCustomerID, &domain.DebitPurchaseRequest{MerchantName: "Gráfica Rápida", Amount: 1200})
+	if err != nil {
+		t.Fatalf("purchase: unexpected error: %v", err)
+	}
+	if resp.Status != "completed" || resp.NewBalance != -700 {
+		t.Errorf("purchase = %s with balance %v, want completed with -700", resp.Status, resp.NewBalance)
+	}
+
+	overdraft, err := svc.GetOverdraft(ctx, testCustomerID, testAccountID)
+	if err != nil {
+		t.Fatalf("get overdraft: unexpected error: %v", err)
+	}
+	if overdraft.Used != 700 || overdraft.Available != 300 {
+		t.Errorf("overdraft used %v / available %v, want 700 / 300", overdraft.Used, overdraft.Available)
+	}
+
+	resp, err = svc.CreateDebitPurchase(ctx, testCustomerID, &domain.DebitPurchaseRequest{MerchantName: "Gráfica Rápida", Amount: 301})
+	if err != nil {
+		t.Fatalf("second purchase: unexpected error: %v", err)
+	}
+	if resp.Status != "insufficient_funds" {
+		t.Errorf("second purchase status = %s, want insufficient_funds", resp.Status)
+	}
+
+	if _, err := svc.SetOverdraftLimit(ctx, testCustomerID, testAccountID, 500); err == nil {
+		t.Error("expected lowering the limit below the 700 in use to fail")
+	}
+}
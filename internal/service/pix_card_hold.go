@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+/*
+ * PIX via credit card — limit hold, capture and release
+ *
+ * The card's used_limit and pix_credit_used are plain column updates, not a
+ * transaction with the rest of the transfer. The limit is therefore held
+ * first and the fatura line booked (captured) right after, before the
+ * transfer is completed or any receipt saved; a failed capture releases the
+ * hold with a compensating decrement and leaves nothing else behind.
+ */
+
+// cardHold is the card limit reserved for a transfer being executed.
+type cardHold struct {
+	cardID string
+	amount float64
+}
+
+// pixCardCharge is what a card-funded transfer costs on the card: the total
+// with installment fees when set, the transfer amount otherwise.
+func pixCardCharge(req *domain.PixTransferRequest) float64 {
+	if req.TotalWithFees > 0 {
+		return req.TotalWithFees
+	}
+	return req.Amount
+}
+
+// holdCardLimit reserves the transfer's charge on the card. It either holds
+// both counters or neither.
+func (s *BankingService) holdCardLimit(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*cardHold, error) {
+	card, err := s.store.GetCreditCard(ctx, customerID, req.CreditCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &cardHold{cardID: card.ID, amount: pixCardCharge(req)}
+	if err := s.adjustCardLimit(ctx, card, hold.amount); err != nil {
+		return nil, fmt.Errorf("hold card limit: %w", err)
+	}
+	return hold, nil
+}
+
+// releaseCardHold gives a held amount back to the card. A failure leaves the
+// limit reduced and is logged for manual correction.
+func (s *BankingService) releaseCardHold(ctx context.Context, customerID string, hold *cardHold) {
+	card, err := s.store.GetCreditCard(ctx, customerID, hold.cardID)
+	if err == nil {
+		err = s.adjustCardLimit(ctx, card, -hold.amount)
+	}
+	if err != nil {
+		s.logger.Error("failed to release card limit hold, limit needs manual correction",
+			zap.String("card_id", hold.cardID),
+			zap.Float64("amount", hold.amount),
+			zap.Error(err))
+	}
+}
+
+// adjustCardLimit adds delta to the card's used_limit and pix_credit_used,
+// reverting used_limit when pix_credit_used cannot be updated.
+func (s *BankingService) adjustCardLimit(ctx context.Context, card *domain.CreditCard, delta float64) error {
+	newUsed := math.Max(card.UsedLimit+delta, 0)
+	if err := s.store.UpdateCreditCardUsedLimit(ctx, card.ID, newUsed, math.Max(card.CreditLimit-newUsed, 0)); err != nil {
+		return err
+	}
+	if err := s.store.UpdateCreditCardPixCreditUsed(ctx, card.ID, math.Max(card.PixCreditUsed+delta, 0)); err != nil {
+		if revErr := s.store.UpdateCreditCardUsedLimit(ctx, card.ID, card.UsedLimit, card.AvailableLimit); revErr != nil {
+			s.logger.Error("failed to revert card used_limit",
+				zap.String("card_id", card.ID), zap.Error(revErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// captureCardHold books the held charge on the card's fatura.
+//
+// NOTE: PIX via credit card is NOT recorded in customer_transactions (extrato).
+// It lives exclusively in credit_card_transactions (fatura) of the selected card.
+func (s *BankingService) captureCardHold(ctx context.Context, customerID string, req *domain.PixTransferRequest, hold *cardHold, descSent string, now time.Time) error {
+	installments := req.CreditCardInstallments
+	if installments <= 0 {
+		installments = 1
+	}
+	installmentAmount := hold.amount / float64(installments)
+
+	ccTx := map[string]any{
+		"id":                  uuid.New().String(),
+		"card_id":             hold.cardID,
+		"customer_id":         customerID,
+		"transaction_date":    now.Format(time.RFC3339),
+		"amount":              hold.amount,
+		"original_amount":     req.Amount,
+		"installment_amount":  RoundMoney(installmentAmount),
+		"merchant_name":       descSent,
+		"description":         descSent,
+		"installments":        installments,
+		"current_installment": 1,
+		"transaction_type":    "pix_credit",
+		"status":              "confirmed",
+	}
+	if err := s.insertCreditCardTransaction(ctx, ccTx); err != nil {
+		return fmt.Errorf("record pix credit card transaction: %w", err)
+	}
+	return nil
+}
+
+// abortCardPixTransfer releases the hold of a card-funded transfer whose
+// capture failed, marks the transfer failed and returns cause.
+func (s *BankingService) abortCardPixTransfer(ctx context.Context, customerID string, transfer *domain.PixTransfer, hold *cardHold, cause error) error {
+	s.logger.Error("card-funded PIX transfer failed after the limit hold, releasing it",
+		zap.String("transfer_id", transfer.ID),
+		zap.String("card_id", hold.cardID),
+		zap.Error(cause))
+	s.releaseCardHold(ctx, customerID, hold)
+	s.failPixTransfer(ctx, transfer.ID)
+	transfer.Status = "failed"
+	return cause
+}
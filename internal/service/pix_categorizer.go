@@ -0,0 +1,86 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+ * PIX — purpose categorization
+ *
+ * PIX statement entries were all booked as "pix" or "recebimento". The
+ * description and the counterparty name usually tell what the transfer was
+ * for (a DARF, the rent, a supplier), so the entry is booked under that
+ * purpose instead when a rule matches. The entry type still identifies it as
+ * PIX.
+ */
+
+// PIX transfer directions a purpose rule can be limited to.
+const (
+	PixDirectionSent     = "sent"
+	PixDirectionReceived = "received"
+)
+
+// PixPurposeRule maps words found in a PIX description or counterparty name
+// to a category. Keywords match whole words ignoring case and accents;
+// Acronyms match whole words in upper case only, since codes such as "DAS"
+// are also common Portuguese words. Direction limits the rule to sent or
+// received transfers; empty applies to both.
+type PixPurposeRule struct {
+	Keywords  []string
+	Acronyms  []string
+	Direction string
+	Category  string
+}
+
+// DefaultPixPurposeRules is evaluated in order; the first matching rule wins.
+var DefaultPixPurposeRules = []PixPurposeRule{
+	{Acronyms: []string{"DAS", "DARF", "GPS", "GARE", "ISS", "ICMS", "IPTU", "IPVA", "FGTS", "INSS"}, Keywords: []string{"imposto", "tributo", "receita federal", "simples nacional", "prefeitura"}, Direction: PixDirectionSent, Category: "tax"},
+	{Keywords: []string{"salario", "folha", "pro labore", "ferias", "rescisao", "13o"}, Direction: PixDirectionSent, Category: "payroll"},
+	{Keywords: []string{"aluguel", "condominio", "locacao"}, Direction: PixDirectionSent, Category: "aluguel"},
+	{Keywords: []string{"energia", "agua", "telefonia", "internet", "copel", "enel", "sabesp"}, Direction: PixDirectionSent, Category: "utilities"},
+	{Keywords: []string{"contabilidade", "contador", "advocacia", "advogado", "consultoria", "honorarios"}, Direction: PixDirectionSent, Category: "professional_services"},
+	{Keywords: []string{"fornecedor", "fornecedores", "distribuidora", "atacadista", "atacado", "materia prima"}, Direction: PixDirectionSent, Category: "fornecedores"},
+	{Keywords: []string{"venda", "vendas", "pedido", "nota fiscal"}, Acronyms: []string{"NF", "NFE"}, Direction: PixDirectionReceived, Category: "sales"},
+}
+
+// AddPixPurposeRules adds rules evaluated before the current ones, so a
+// deployment can refine or override the defaults.
+func (s *BankingService) AddPixPurposeRules(rules ...PixPurposeRule) {
+	s.pixPurposeRules = append(append([]PixPurposeRule{}, rules...), s.pixPurposeRules...)
+}
+
+// categorizePix returns the category of the first rule matching the
+// description or counterparty name of a transfer in direction, or "" when
+// none matches.
+func (s *BankingService) categorizePix(direction, description, name string) string {
+	text := description + " " + name
+	folded := " " + strings.Join(splitWords(foldText(text)), " ") + " "
+	words := splitWords(text)
+
+	for _, rule := range s.pixPurposeRules {
+		if rule.Direction != "" && rule.Direction != direction {
+			continue
+		}
+		for _, kw := range rule.Keywords {
+			if strings.Contains(folded, " "+strings.Join(splitWords(foldText(kw)), " ")+" ") {
+				return rule.Category
+			}
+		}
+		for _, acronym := range rule.Acronyms {
+			for _, w := range words {
+				if w == acronym {
+					return rule.Category
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// splitWords splits s into runs of letters and digits.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
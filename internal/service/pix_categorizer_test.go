@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// sentPixCategory sends a PIX and returns the category of its statement entry.
+func sentPixCategory(t *testing.T, svc *service.BankingService, store *fakeBankingStore, description string) string {
+	t.Helper()
+	store.transactions = nil
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-" + description,
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              10,
+		Description:         description,
+	})
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %v", description, err)
+	}
+	if len(store.transactions) != 1 {
+		t.Fatalf("%q: got %d statement entries, want 1", description, len(store.transactions))
+	}
+	category, _ := store.transactions[0]["category"].(string)
+	return category
+}
+
+func TestCreatePixTransfer_CategorizesByPurpose(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"DAS março/2026", "tax"},
+		{"Pagamento DARF IRPJ", "tax"},
+		{"guia do imposto municipal", "tax"},
+		{"Aluguel escritório", "aluguel"},
+		{"Salário fevereiro", "payroll"},
+		{"Honorários contabilidade", "professional_services"},
+		{"Produtos das lojas", "pix"}, // "das" in lower case is a preposition
+		{"Vendas do mês", "pix"},      // sales only categorize received transfers
+		{"", "pix"},
+	}
+
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	for _, tt := range tests {
+		if got := sentPixCategory(t, svc, store, tt.description); got != tt.want {
+			t.Errorf("%q: category = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestAddPixPurposeRules_TakePrecedence(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.AddPixPurposeRules(service.PixPurposeRule{Acronyms: []string{"DAS"}, Category: "simples_nacional"})
+
+	if got := sentPixCategory(t, svc, store, "DAS março"); got != "simples_nacional" {
+		t.Errorf("category = %q, want the custom rule's simples_nacional", got)
+	}
+	if got := sentPixCategory(t, svc, store, "DARF"); got != "tax" {
+		t.Errorf("category = %q, want the default rules to still apply", got)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+/*
+ * PIX Transfer — confirmation step for high-value transfers
+ *
+ * Above the confirmation threshold the first submission of a transfer is not
+ * executed: it returns a single-use token together with the computed totals
+ * and recipient. Submitting the same transfer again with the token executes
+ * it. This guards against accidental large transfers. Tokens are stored, so
+ * they work across instances and restarts, and are only used up once the
+ * transfer they confirm has committed.
+ */
+
+// DefaultPixConfirmationTTL is how long a confirmation token stays valid.
+const DefaultPixConfirmationTTL = 5 * time.Minute
+
+// Confirmation token statuses.
+const (
+	pixConfirmationIssued = "issued"
+	pixConfirmationUsed   = "used"
+)
+
+// SetPixConfirmation requires a confirmation token for transfers above
+// threshold; tokens are valid for ttl (DefaultPixConfirmationTTL when
+// non-positive). A non-positive threshold disables the step.
+func (s *BankingService) SetPixConfirmation(threshold float64, ttl time.Duration) {
+	if threshold <= 0 {
+		s.pixConfirmThreshold = 0
+		return
+	}
+	if ttl <= 0 {
+		ttl = DefaultPixConfirmationTTL
+	}
+	s.pixConfirmThreshold = threshold
+	s.pixConfirmTTL = ttl
+}
+
+// checkPixConfirmation decides whether a validated transfer may be executed.
+// Without a token above the threshold it issues one and returns
+// ErrConfirmationRequired. With a token it claims it, which only succeeds
+// for an unexpired token issued for this customer and this exact transfer,
+// and returns it: the caller settles it with settlePixConfirmation once the
+// transfer has run.
+func (s *BankingService) checkPixConfirmation(ctx context.Context, customerID string, req *domain.PixTransferRequest, recipient *domain.PixRecipient, warnings []string) (string, error) {
+	if s.pixConfirmThreshold <= 0 {
+		return "", nil
+	}
+
+	if req.ConfirmationToken != "" {
+		claimed, err := s.store.ClaimPixConfirmation(ctx, req.ConfirmationToken, customerID, pixConfirmationFingerprint(req))
+		if err != nil {
+			return "", err
+		}
+		if !claimed {
+			return "", &domain.ErrValidation{Field: "confirmationToken", Message: i18n.T(ctx, "pix.confirmation_token_invalid")}
+		}
+		return req.ConfirmationToken, nil
+	}
+
+	if req.Amount <= s.pixConfirmThreshold {
+		return "", nil
+	}
+
+	expiresAt := time.Now().Add(s.pixConfirmTTL)
+	token := uuid.New().String()
+	if err := s.store.StorePixConfirmation(ctx, &domain.PixConfirmationToken{
+		Token:       token,
+		CustomerID:  customerID,
+		Fingerprint: pixConfirmationFingerprint(req),
+		Status:      pixConfirmationIssued,
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		return "", fmt.Errorf("store confirmation token: %w", err)
+	}
+
+	total := req.Amount
+	if req.TotalWithFees > 0 {
+		total = req.TotalWithFees
+	}
+	trace.SpanFromContext(ctx).AddEvent("pix.confirmation_required")
+	s.logger.Info("PIX transfer requires confirmation",
+		zap.String("customer_id", customerID),
+		zap.Float64("amount", req.Amount),
+		zap.String("funded_by", req.FundedBy),
+	)
+	return "", &domain.ErrConfirmationRequired{Confirmation: &domain.PixConfirmation{
+		ConfirmationToken: token,
+		ExpiresAt:         expiresAt.Format(time.RFC3339),
+		Amount:            req.Amount,
+		TotalWithFees:     total,
+		FundedBy:          req.FundedBy,
+		Installments:      req.CreditCardInstallments,
+		Recipient:         recipient,
+		Warnings:          warnings,
+	}}
+}
+
+// settlePixConfirmation marks a claimed token used once its transfer
+// committed, or gives it back when the transfer failed so the customer can
+// retry with it. A failure is only logged: a token left claimed can no
+// longer be used, which costs the customer a new confirmation.
+func (s *BankingService) settlePixConfirmation(ctx context.Context, token string, committed bool) {
+	status := pixConfirmationUsed
+	if !committed {
+		status = pixConfirmationIssued
+	}
+	if err := s.store.SetPixConfirmationStatus(ctx, token, status); err != nil {
+		s.logger.Error("failed to settle PIX confirmation token",
+			zap.String("status", status),
+			zap.Error(err))
+	}
+}
+
+// pixConfirmationFingerprint identifies the transfer a token was issued
+// for, so it cannot be replayed with another amount or recipient.
+func pixConfirmationFingerprint(req *domain.PixTransferRequest) string {
+	return fmt.Sprintf("%s|%s|%.2f|%s|%s|%d",
+		req.SourceAccountID,
+		strings.TrimSpace(req.DestinationKeyValue),
+		req.Amount,
+		req.FundedBy,
+		req.CreditCardID,
+		req.CreditCardInstallments,
+	)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+/*
+ * PIX via credit card — fees
+ *
+ * The fee rate lives only here: the handler leaves the total to the service,
+ * and the same rate is published through GET /v1/pix/fees and the key
+ * lookup so the UI shows what the transfer will cost before confirming.
+ * QuotePixCreditCard goes one step further and prices a given amount on a
+ * given card with the same computation the transfer uses.
+ */
+
+// DefaultPixCreditFeeRate is the fee per installment beyond the first on
+// PIX via credit card (0.02 = 2%).
+const DefaultPixCreditFeeRate = 0.02
+
+// PixCreditMaxInstallments is the maximum number of installments allowed on
+// PIX via credit card.
+const PixCreditMaxInstallments = 12
+
+// SetPixCreditFeeRate overrides the per-installment fee rate of PIX via
+// credit card. Zero waives the fee; negative values keep the current rate.
+func (s *BankingService) SetPixCreditFeeRate(rate float64) {
+	if rate >= 0 {
+		s.pixCreditFeeRate = rate
+	}
+}
+
+// GetPixFees returns the fees currently charged on PIX transfers.
+func (s *BankingService) GetPixFees() *domain.PixFees {
+	return &domain.PixFees{
+		CreditCardFeeRate:         s.pixCreditFeeRate,
+		CreditCardMaxInstallments: PixCreditMaxInstallments,
+	}
+}
+
+// pixCreditTotal is what a PIX of amount in installments costs on the card.
+func (s *BankingService) pixCreditTotal(amount float64, installments int) float64 {
+	if installments <= 0 {
+		installments = 1
+	}
+	return RoundMoney(amount * (1 + s.pixCreditFeeRate*float64(installments-1)))
+}
+
+// QuotePixCreditCard prices a PIX via credit card without executing it: the
+// fee, the total charged on the card and the installment schedule, computed
+// exactly as CreatePixTransfer would. The card and PIX limit checks of the
+// transfer come back as canProceed false with the reason; a malformed
+// request or an unknown card is returned as an error.
+func (s *BankingService) QuotePixCreditCard(ctx context.Context, customerID string, req *domain.PixCreditCardQuoteRequest) (*domain.PixCreditCardQuote, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.QuotePixCreditCard")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Float64("amount", req.Amount))
+
+	installments := req.Installments
+	if installments <= 0 {
+		installments = 1
+	}
+	if installments > PixCreditMaxInstallments {
+		return nil, &domain.ErrValidation{Field: "installments", Message: "must be between 1 and 12"}
+	}
+	if req.Amount <= 0 {
+		return nil, &domain.ErrValidation{Field: "amount", Message: "must be positive"}
+	}
+	if req.CreditCardID == "" {
+		return nil, &domain.ErrValidation{Field: "creditCardId", Message: "is required"}
+	}
+
+	card, err := s.store.GetCreditCard(ctx, customerID, req.CreditCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := s.pixCreditTotal(req.Amount, installments)
+	schedule := pixCreditSchedule(card, total, installments, time.Now())
+	quote := &domain.PixCreditCardQuote{
+		Amount:           req.Amount,
+		Installments:     installments,
+		FeeRate:          s.pixCreditFeeRate,
+		FeeAmount:        RoundMoney(total - req.Amount),
+		TotalWithFees:    total,
+		InstallmentValue: schedule[0].Amount,
+		Schedule:         schedule,
+	}
+
+	transfer := &domain.PixTransferRequest{
+		Amount:                 req.Amount,
+		FundedBy:               "credit_card",
+		CreditCardID:           req.CreditCardID,
+		CreditCardInstallments: installments,
+	}
+	err = s.checkPixFunding(ctx, customerID, nil, transfer)
+	if err == nil {
+		err = s.checkPixLimits(ctx, customerID, transfer)
+	}
+	if err != nil {
+		code := spanErrorCode(err)
+		if code != "validation" && code != "limit_exceeded" {
+			return nil, err
+		}
+		quote.ReasonCode, quote.Reason = code, err.Error()
+		span.SetAttributes(attribute.String("result", spanResultDeclined), attribute.String("error_code", code))
+		return quote, nil
+	}
+	quote.CanProceed = true
+	return quote, nil
+}
+
+// pixCreditSchedule splits total into installments the way the fatura books
+// them: every installment is total/installments rounded to cents, and the
+// last one absorbs the rounding so the schedule adds up to total. The first
+// installment falls on the first invoice that closes after now.
+func pixCreditSchedule(card *domain.CreditCard, total float64, installments int, now time.Time) []domain.PixCreditInstallment {
+	billingDay := card.BillingDay
+	if billingDay == 0 {
+		billingDay = 10
+	}
+	dueDay := card.DueDay
+	if dueDay == 0 {
+		dueDay = 20
+	}
+
+	now = now.UTC()
+	closing := time.Date(now.Year(), now.Month(), billingDay, 0, 0, 0, 0, time.UTC)
+	if !now.Before(closing) {
+		closing = closing.AddDate(0, 1, 0)
+	}
+	firstDue := time.Date(closing.Year(), closing.Month(), dueDay, 0, 0, 0, 0, time.UTC)
+	if !firstDue.After(closing) {
+		firstDue = firstDue.AddDate(0, 1, 0)
+	}
+
+	value := RoundMoney(total / float64(installments))
+	schedule := make([]domain.PixCreditInstallment, installments)
+	for i := range schedule {
+		amount := value
+		if i == installments-1 {
+			amount = RoundMoney(total - value*float64(installments-1))
+		}
+		schedule[i] = domain.PixCreditInstallment{
+			Number:  i + 1,
+			Amount:  amount,
+			DueDate: firstDue.AddDate(0, i, 0).Format("2006-01-02"),
+		}
+	}
+	return schedule
+}
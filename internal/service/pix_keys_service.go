@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
@@ -13,7 +16,7 @@ import (
 )
 
 /*
- * PIX Keys — lookup, registration, deletion
+ * PIX Keys — lookup, registration, deletion, reactivation
  */
 
 func (s *BankingService) ListPixKeys(ctx context.Context, customerID string) ([]domain.PixKey, error) {
@@ -23,6 +26,28 @@ func (s *BankingService) ListPixKeys(ctx context.Context, customerID string) ([]
 	return s.store.ListPixKeys(ctx, customerID)
 }
 
+// GetPixKey returns one of the customer's keys, whatever its status. A key
+// owned by someone else is reported as not found.
+func (s *BankingService) GetPixKey(ctx context.Context, customerID, keyID string) (*domain.PixKey, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetPixKey")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if customerID == "" || keyID == "" {
+		return nil, &domain.ErrValidation{Field: "keyId", Message: "required"}
+	}
+	key, err := s.store.GetPixKey(ctx, customerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	// Another customer's key is reported as missing, not forbidden, so key
+	// IDs cannot be probed.
+	if key.CustomerID != customerID {
+		return nil, &domain.ErrNotFound{Resource: "pix_key", ID: keyID}
+	}
+	return key, nil
+}
+
 func (s *BankingService) LookupPixKey(ctx context.Context, keyType, keyValue string) (*domain.PixKey, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.LookupPixKey")
 	defer span.End()
@@ -97,7 +122,8 @@ func (s *BankingService) GetCustomerLookupData(ctx context.Context, customerID s
 	return s.store.GetCustomerLookupData(ctx, customerID)
 }
 
-// DeletePixKey removes a Pix key for the given customer.
+// DeletePixKey soft-deletes a Pix key for the given customer. The key stops
+// showing up in lists and lookups but can be restored with ReactivatePixKey.
 func (s *BankingService) DeletePixKey(ctx context.Context, customerID, keyID string) error {
 	ctx, span := bankTracer.Start(ctx, "BankingService.DeletePixKey")
 	defer span.End()
@@ -123,7 +149,7 @@ func (s *BankingService) DeletePixKey(ctx context.Context, customerID, keyID str
 	return nil
 }
 
-// DeletePixKeyByValue removes a Pix key by its type and value.
+// DeletePixKeyByValue soft-deletes a Pix key by its type and value.
 func (s *BankingService) DeletePixKeyByValue(ctx context.Context, customerID, keyType, keyValue string) error {
 	ctx, span := bankTracer.Start(ctx, "BankingService.DeletePixKeyByValue")
 	defer span.End()
@@ -141,6 +167,80 @@ func (s *BankingService) DeletePixKeyByValue(ctx context.Context, customerID, ke
 	return s.store.DeletePixKey(ctx, customerID, key.ID)
 }
 
+// ReactivatePixKey restores a soft-deleted Pix key. The per-customer limit
+// and value uniqueness are checked again, since a new key may have taken
+// the slot or the value while this one was deleted.
+func (s *BankingService) ReactivatePixKey(ctx context.Context, customerID, keyID string) (*domain.PixKey, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ReactivatePixKey")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	key, err := s.store.GetPixKey(ctx, customerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.Status != "deleted" {
+		return nil, &domain.ErrValidation{Field: "status", Message: fmt.Sprintf("cannot reactivate key with status '%s'", key.Status)}
+	}
+
+	count, err := s.store.CountPixKeys(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.pixKeyLimit {
+		return nil, &domain.ErrLimitExceeded{LimitType: "pix_keys", Limit: float64(s.pixKeyLimit), Current: float64(count)}
+	}
+
+	existing, err := s.store.LookupPixKey(ctx, key.KeyType, key.KeyValue)
+	var notFound *domain.ErrNotFound
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &domain.ErrDuplicate{Key: fmt.Sprintf("pix key %s:%s", key.KeyType, key.KeyValue)}
+	}
+
+	if err := s.store.ReactivatePixKey(ctx, customerID, keyID); err != nil {
+		s.logger.Error("failed to reactivate pix key",
+			zap.String("customer_id", customerID),
+			zap.String("key_id", keyID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("pix key reactivated",
+		zap.String("customer_id", customerID),
+		zap.String("key_id", keyID),
+	)
+
+	key.Status = "active"
+	key.DeletedAt = nil
+	return key, nil
+}
+
+// DefaultPixKeyLimit is the DICT cap on active PIX keys for a PJ customer.
+const DefaultPixKeyLimit = 20
+
+// SetPixKeyLimit overrides the maximum number of active PIX keys per customer.
+// Non-positive values keep the current limit.
+func (s *BankingService) SetPixKeyLimit(limit int) {
+	if limit > 0 {
+		s.pixKeyLimit = limit
+	}
+}
+
+// SetPIIMasking enables or disables masking of counterparty documents and
+// pix keys in lookup and receipt responses. Disable only for internal debugging.
+func (s *BankingService) SetPIIMasking(enabled bool) {
+	s.maskPII = enabled
+}
+
+// MasksPII reports whether counterparty documents and keys must be masked.
+func (s *BankingService) MasksPII() bool {
+	return s.maskPII
+}
+
 // RegisterPixKey creates a new Pix key for the given customer.
 func (s *BankingService) RegisterPixKey(ctx context.Context, req *domain.PixKeyRegisterRequest) (*domain.PixKeyRegisterResponse, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.RegisterPixKey")
@@ -153,7 +253,7 @@ func (s *BankingService) RegisterPixKey(ctx context.Context, req *domain.PixKeyR
 
 	validTypes := map[string]bool{"cnpj": true, "email": true, "phone": true, "random": true}
 	if !validTypes[req.KeyType] {
-		return nil, &domain.ErrValidation{Field: "keyType", Message: "deve ser cnpj, email, phone ou random"}
+		return nil, &domain.ErrValidation{Field: "keyType", Message: i18n.T(ctx, "pix.key_type_invalid")}
 	}
 
 	// Get primary account for account_id
@@ -169,6 +269,41 @@ func (s *BankingService) RegisterPixKey(ctx context.Context, req *domain.PixKeyR
 		return nil, &domain.ErrValidation{Field: "keyValue", Message: "required for non-random key type"}
 	}
 
+	count, err := s.store.CountPixKeys(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.pixKeyLimit {
+		return nil, &domain.ErrLimitExceeded{LimitType: "pix_keys", Limit: float64(s.pixKeyLimit), Current: float64(count)}
+	}
+
+	// A key value can only be registered once (DICT uniqueness)
+	if req.KeyType != "random" {
+		existing, err := s.store.LookupPixKey(ctx, req.KeyType, keyValue)
+		var notFound *domain.ErrNotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, &domain.ErrDuplicate{Key: fmt.Sprintf("pix key %s:%s", req.KeyType, keyValue)}
+		}
+	}
+
+	// Email/phone keys require proof of ownership (code from verify-request)
+	var verification *domain.PixKeyVerificationCode
+	if requiresPixKeyVerification(req.KeyType) {
+		if req.VerificationCode == "" {
+			return nil, &domain.ErrValidation{Field: "verificationCode", Message: "required for email/phone keys"}
+		}
+		verification, err = s.store.GetValidPixKeyVerificationCode(ctx, req.CustomerID, req.KeyType, keyValue, req.VerificationCode)
+		if err != nil {
+			return nil, err
+		}
+		if verification == nil {
+			return nil, &domain.ErrInvalidCode{}
+		}
+	}
+
 	key := &domain.PixKey{
 		ID:         uuid.New().String(),
 		AccountID:  account.ID,
@@ -189,6 +324,13 @@ func (s *BankingService) RegisterPixKey(ctx context.Context, req *domain.PixKeyR
 		return nil, err
 	}
 
+	if verification != nil {
+		if err := s.store.MarkPixKeyVerificationCodeUsed(ctx, verification.ID); err != nil {
+			s.logger.Warn("failed to mark pix key verification code as used",
+				zap.String("code_id", verification.ID), zap.Error(err))
+		}
+	}
+
 	s.logger.Info("pix key registered",
 		zap.String("customer_id", req.CustomerID),
 		zap.String("key_type", req.KeyType),
@@ -205,6 +347,63 @@ func (s *BankingService) RegisterPixKey(ctx context.Context, req *domain.PixKeyR
 	}, nil
 }
 
+// PixKeyVerificationTTL is how long a PIX key ownership code stays valid.
+const PixKeyVerificationTTL = 10 * time.Minute
+
+// requiresPixKeyVerification reports whether the key type needs an ownership
+// code before registration. Random and CNPJ keys are bound to the account.
+func requiresPixKeyVerification(keyType string) bool {
+	return keyType == "email" || keyType == "phone"
+}
+
+// RequestPixKeyVerification generates an ownership code for an email/phone
+// key and sends it to that destination. The code must be supplied to
+// RegisterPixKey.
+func (s *BankingService) RequestPixKeyVerification(ctx context.Context, req *domain.PixKeyVerifyRequest) (*domain.PixKeyVerifyResponse, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RequestPixKeyVerification")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", req.CustomerID))
+
+	if req.CustomerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	if !requiresPixKeyVerification(req.KeyType) {
+		return nil, &domain.ErrValidation{Field: "keyType", Message: i18n.T(ctx, "pix.key_verification_type")}
+	}
+	if req.KeyValue == "" {
+		return nil, &domain.ErrValidation{Field: "keyValue", Message: "required"}
+	}
+
+	code := generateVerificationCode()
+	if err := s.store.StorePixKeyVerificationCode(ctx, &domain.PixKeyVerificationCode{
+		CustomerID: req.CustomerID,
+		KeyType:    req.KeyType,
+		KeyValue:   req.KeyValue,
+		Code:       code,
+		ExpiresAt:  time.Now().Add(PixKeyVerificationTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	destination := maskKeepLast(req.KeyValue, 4)
+	if req.KeyType == "email" {
+		destination = maskEmail(req.KeyValue)
+	}
+
+	// In production, send email/SMS here
+	s.logger.Info("pix key verification code generated",
+		zap.String("customer_id", req.CustomerID),
+		zap.String("key_type", req.KeyType),
+		zap.String("code", code), // ONLY in dev — remove in production
+	)
+
+	return &domain.PixKeyVerifyResponse{
+		Message:     i18n.T(ctx, "auth.code_sent"),
+		Destination: destination,
+		ExpiresIn:   int(PixKeyVerificationTTL.Seconds()),
+	}, nil
+}
+
 // GetCreditLimit returns the total credit limit for a customer's cards.
 func (s *BankingService) GetCreditLimit(ctx context.Context, customerID string) (float64, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.GetCreditLimit")
@@ -214,16 +413,21 @@ func (s *BankingService) GetCreditLimit(ctx context.Context, customerID string)
 	if err != nil {
 		return 0, err
 	}
-	if len(cards) == 0 {
-		return 0, &domain.ErrNotFound{Resource: "credit_card", ID: customerID}
-	}
 
-	// Return the highest credit limit among all cards
+	// Return the highest credit limit among the cards still in use
 	var maxLimit float64
+	found := false
 	for _, c := range cards {
+		if c.Status == "cancelled" {
+			continue
+		}
+		found = true
 		if c.CreditLimit > maxLimit {
 			maxLimit = c.CreditLimit
 		}
 	}
+	if !found {
+		return 0, &domain.ErrNotFound{Resource: "credit_card", ID: customerID}
+	}
 	return maxLimit, nil
 }
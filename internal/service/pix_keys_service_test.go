@@ -0,0 +1,221 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func newPixKeysService() (*service.BankingService, *fakeBankingStore) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	return service.NewBankingService(store, observability.NewMetrics(), zap.NewNop()), store
+}
+
+func TestRegisterPixKey_EmailVerificationFlow(t *testing.T) {
+	svc, store := newPixKeysService()
+	ctx := context.Background()
+	const email = "financeiro@empresa.com.br"
+
+	// 1. Request a code
+	resp, err := svc.RequestPixKeyVerification(ctx, &domain.PixKeyVerifyRequest{
+		CustomerID: testCustomerID, KeyType: "email", KeyValue: email,
+	})
+	if err != nil {
+		t.Fatalf("verify-request: %v", err)
+	}
+	if resp.Destination == email {
+		t.Error("destination should be masked")
+	}
+	if len(store.pixKeyCodes) != 1 {
+		t.Fatalf("expected 1 stored code, got %d", len(store.pixKeyCodes))
+	}
+	code := store.pixKeyCodes[0].Code
+
+	// 2. Wrong code is rejected
+	_, err = svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "email", KeyValue: email, VerificationCode: "000000x",
+	})
+	var invalidCode *domain.ErrInvalidCode
+	if !errors.As(err, &invalidCode) {
+		t.Fatalf("expected ErrInvalidCode for wrong code, got %v", err)
+	}
+
+	// 3. Matching code registers the key and is consumed
+	reg, err := svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "email", KeyValue: email, VerificationCode: code,
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if reg.KeyValue != email || reg.Status != "active" {
+		t.Errorf("unexpected key: %+v", reg)
+	}
+	if !store.pixKeyCodes[0].Used {
+		t.Error("expected code to be marked as used")
+	}
+
+	// 4. The same email cannot be registered again
+	_, err = svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "email", KeyValue: email, VerificationCode: code,
+	})
+	var duplicate *domain.ErrDuplicate
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("expected ErrDuplicate for an already registered email, got %v", err)
+	}
+}
+
+func TestRegisterPixKey_ExpiredCode(t *testing.T) {
+	svc, store := newPixKeysService()
+	store.pixKeyCodes = append(store.pixKeyCodes, domain.PixKeyVerificationCode{
+		ID: "code-old", CustomerID: testCustomerID, KeyType: "phone", KeyValue: "11987654321",
+		Code: "123456", ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	_, err := svc.RegisterPixKey(context.Background(), &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "phone", KeyValue: "11987654321", VerificationCode: "123456",
+	})
+	var invalidCode *domain.ErrInvalidCode
+	if !errors.As(err, &invalidCode) {
+		t.Fatalf("expected ErrInvalidCode, got %v", err)
+	}
+}
+
+func TestRegisterPixKey_RandomSkipsVerification(t *testing.T) {
+	svc, store := newPixKeysService()
+
+	reg, err := svc.RegisterPixKey(context.Background(), &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "random",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.KeyValue == "" || len(store.pixKeys) != 1 {
+		t.Errorf("expected random key to be created, got %+v", reg)
+	}
+}
+
+func TestRegisterPixKey_EmailWithoutCode(t *testing.T) {
+	svc, _ := newPixKeysService()
+
+	_, err := svc.RegisterPixKey(context.Background(), &domain.PixKeyRegisterRequest{
+		CustomerID: testCustomerID, KeyType: "email", KeyValue: "a@b.com",
+	})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "verificationCode" {
+		t.Fatalf("expected verificationCode validation error, got %v", err)
+	}
+}
+
+func TestRegisterPixKey_DuplicateValue(t *testing.T) {
+	svc, _ := newPixKeysService()
+	ctx := context.Background()
+
+	req := &domain.PixKeyRegisterRequest{CustomerID: testCustomerID, KeyType: "cnpj", KeyValue: "12345678000199"}
+	if _, err := svc.RegisterPixKey(ctx, req); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+
+	_, err := svc.RegisterPixKey(ctx, req)
+	var duplicate *domain.ErrDuplicate
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("expected ErrDuplicate for the same cnpj, got %v", err)
+	}
+}
+
+func TestRegisterPixKey_LimitPerCustomer(t *testing.T) {
+	svc, store := newPixKeysService()
+	svc.SetPixKeyLimit(3)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{CustomerID: testCustomerID, KeyType: "random"}); err != nil {
+			t.Fatalf("key %d: %v", i+1, err)
+		}
+	}
+
+	_, err := svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{CustomerID: testCustomerID, KeyType: "random"})
+	var limit *domain.ErrLimitExceeded
+	if !errors.As(err, &limit) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+	if limit.Limit != 3 || limit.Current != 3 {
+		t.Errorf("unexpected limit error: %+v", limit)
+	}
+	if len(store.pixKeys) != 3 {
+		t.Errorf("expected 3 stored keys, got %d", len(store.pixKeys))
+	}
+}
+
+func TestDeletePixKey_SoftDeleteAndReactivate(t *testing.T) {
+	svc, store := newPixKeysService()
+	ctx := context.Background()
+
+	if _, err := svc.RegisterPixKey(ctx, &domain.PixKeyRegisterRequest{CustomerID: testCustomerID, KeyType: "cnpj", KeyValue: "12345678000199"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	keyID := store.pixKeys[0].ID
+
+	if err := svc.DeletePixKey(ctx, testCustomerID, keyID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(store.pixKeys) != 1 {
+		t.Fatalf("expected the row to be kept, got %d keys", len(store.pixKeys))
+	}
+	keys, _ := svc.ListPixKeys(ctx, testCustomerID)
+	if len(keys) != 0 {
+		t.Errorf("deleted key should not be listed, got %+v", keys)
+	}
+	var notFound *domain.ErrNotFound
+	if _, err := svc.LookupPixKey(ctx, "cnpj", "12345678000199"); !errors.As(err, &notFound) {
+		t.Errorf("deleted key should not resolve for transfers, got %v", err)
+	}
+
+	key, err := svc.ReactivatePixKey(ctx, testCustomerID, keyID)
+	if err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if key.Status != "active" {
+		t.Errorf("expected active key, got %q", key.Status)
+	}
+	keys, _ = svc.ListPixKeys(ctx, testCustomerID)
+	if len(keys) != 1 || keys[0].ID != keyID {
+		t.Errorf("expected reactivated key in list, got %+v", keys)
+	}
+
+	_, err = svc.ReactivatePixKey(ctx, testCustomerID, keyID)
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Errorf("expected validation error reactivating an active key, got %v", err)
+	}
+}
+
+func TestReactivatePixKey_ValueTakenMeanwhile(t *testing.T) {
+	svc, store := newPixKeysService()
+	ctx := context.Background()
+	req := &domain.PixKeyRegisterRequest{CustomerID: testCustomerID, KeyType: "cnpj", KeyValue: "12345678000199"}
+
+	if _, err := svc.RegisterPixKey(ctx, req); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	keyID := store.pixKeys[0].ID
+	if err := svc.DeletePixKey(ctx, testCustomerID, keyID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.RegisterPixKey(ctx, req); err != nil {
+		t.Fatalf("re-register after delete: %v", err)
+	}
+
+	_, err := svc.ReactivatePixKey(ctx, testCustomerID, keyID)
+	var duplicate *domain.ErrDuplicate
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+}
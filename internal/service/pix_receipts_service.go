@@ -2,8 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 )
 
 /*
@@ -24,9 +32,188 @@ func (s *BankingService) GetPixReceiptByTransferID(ctx context.Context, transfer
 	return s.store.GetPixReceiptByTransferID(ctx, transferID)
 }
 
-func (s *BankingService) ListPixReceipts(ctx context.Context, customerID string) ([]domain.PixReceipt, error) {
+func (s *BankingService) ListPixReceipts(ctx context.Context, customerID string, page, pageSize int) (*domain.ListResponse[domain.PixReceipt], error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListPixReceipts")
 	defer span.End()
 
-	return s.store.ListPixReceipts(ctx, customerID)
+	rows, total, err := s.store.ListPixReceipts(ctx, customerID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, page, pageSize), nil
+}
+
+/*
+ * PIX Receipts — share tokens (comprovante compartilhável)
+ */
+
+// DefaultReceiptShareTTL is how long a receipt share token stays valid
+// when no TTL is configured.
+const DefaultReceiptShareTTL = 15 * time.Minute
+
+// receiptShareClaims are the claims of a receipt share token.
+type receiptShareClaims struct {
+	ReceiptID string `json:"rid"`
+	Type      string `json:"type"` // always "receipt_share"
+	jwt.RegisteredClaims
+}
+
+// SetReceiptSharing configures the secret used to sign receipt share tokens
+// and how long they remain valid.
+func (s *BankingService) SetReceiptSharing(secret string, ttl time.Duration) {
+	s.receiptShareSecret = []byte(secret)
+	if ttl != 0 {
+		s.receiptShareTTL = ttl
+	}
+}
+
+// CreateReceiptShareToken mints a short-lived signed token that allows a third
+// party to fetch a redacted version of the receipt without authentication.
+func (s *BankingService) CreateReceiptShareToken(ctx context.Context, receiptID string) (*domain.ReceiptShareToken, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.CreateReceiptShareToken")
+	defer span.End()
+
+	if len(s.receiptShareSecret) == 0 {
+		return nil, fmt.Errorf("receipt sharing not configured")
+	}
+
+	receipt, err := s.store.GetPixReceipt(ctx, receiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.receiptShareTTL)
+	claims := receiptShareClaims{
+		ReceiptID: receipt.ID,
+		Type:      "receipt_share",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    "bfa-api",
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.receiptShareSecret)
+	if err != nil {
+		return nil, fmt.Errorf("sign receipt share token: %w", err)
+	}
+
+	s.logger.Info("receipt share token created",
+		zap.String("receipt_id", receipt.ID),
+		zap.Time("expires_at", expiresAt))
+
+	return &domain.ReceiptShareToken{ReceiptID: receipt.ID, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// GetSharedPixReceipt validates a share token and returns the redacted receipt
+// it points to. Invalid or expired tokens yield ErrUnauthorized.
+func (s *BankingService) GetSharedPixReceipt(ctx context.Context, tokenStr string) (*domain.PixReceipt, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetSharedPixReceipt")
+	defer span.End()
+
+	if len(s.receiptShareSecret) == 0 {
+		return nil, fmt.Errorf("receipt sharing not configured")
+	}
+
+	claims := &receiptShareClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return s.receiptShareSecret, nil
+	})
+	if err != nil || !token.Valid || claims.Type != "receipt_share" || claims.ReceiptID == "" {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "pix.receipt_link_expired")}
+		}
+		return nil, &domain.ErrUnauthorized{Message: i18n.T(ctx, "pix.receipt_link_invalid")}
+	}
+
+	receipt, err := s.store.GetPixReceipt(ctx, claims.ReceiptID)
+	if err != nil {
+		return nil, err
+	}
+	return redactPixReceipt(receipt), nil
+}
+
+// redactPixReceipt returns a copy of the receipt safe to show to third parties:
+// documents, account numbers and the PIX key are partially masked.
+func redactPixReceipt(r *domain.PixReceipt) *domain.PixReceipt {
+	red := *r
+	red.CustomerID = ""
+	red.TransactionID = ""
+	red.SenderDocument = MaskDocument(r.SenderDocument)
+	red.RecipientDocument = MaskDocument(r.RecipientDocument)
+	red.SenderAccount = maskKeepLast(r.SenderAccount, 2)
+	red.RecipientAccount = maskKeepLast(r.RecipientAccount, 2)
+	if r.RecipientKeyType == "email" {
+		red.RecipientKeyValue = maskEmail(r.RecipientKeyValue)
+	} else {
+		red.RecipientKeyValue = maskKeepLast(r.RecipientKeyValue, 4)
+	}
+	return &red
+}
+
+// MaskDocument masks a CPF/CNPJ Bacen-style: a CPF keeps only its 6 middle
+// digits (***.456.789-**), anything else keeps the first and last 2 digits.
+// Formatting characters are kept as they are.
+func MaskDocument(doc string) string {
+	total := 0
+	for _, r := range doc {
+		if r >= '0' && r <= '9' {
+			total++
+		}
+	}
+	keep := func(i int) bool { return i < 2 || i >= total-2 }
+	if total == 11 {
+		keep = func(i int) bool { return i >= 3 && i < 9 }
+	}
+
+	var b strings.Builder
+	i := 0
+	for _, r := range doc {
+		if r >= '0' && r <= '9' {
+			if keep(i) {
+				b.WriteRune(r)
+			} else {
+				b.WriteRune('*')
+			}
+			i++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// MaskPixKeyValue hides most of a pix key value, the way the paying party
+// sees it in DICT. Random keys carry no personal data and are returned as is.
+func MaskPixKeyValue(keyType, value string) string {
+	switch keyType {
+	case "cpf", "cnpj":
+		return MaskDocument(value)
+	case "email":
+		at := strings.LastIndex(value, "@")
+		if at < 1 {
+			return value
+		}
+		return value[:1] + "***" + value[at:]
+	case "phone":
+		digits := normalizeDoc(value)
+		if len(digits) == 13 { // +55...
+			return fmt.Sprintf("+%s (**) *****-%s", digits[:2], digits[9:13])
+		} else if len(digits) >= 4 {
+			return "(**) *****-" + digits[len(digits)-4:]
+		}
+	}
+	return value
+}
+
+// maskKeepLast replaces every character but the last n with '*'.
+func maskKeepLast(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return strings.Repeat("*", len(runes)-n) + string(runes[len(runes)-n:])
 }
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func newReceiptSharingService(ttl time.Duration) (*service.BankingService, *fakeBankingStore) {
+	store := newFakeBankingStore()
+	store.pixReceipts = append(store.pixReceipts, domain.PixReceipt{
+		ID:                "rcpt-1",
+		TransferID:        "pix-1",
+		CustomerID:        testCustomerID,
+		Direction:         "sent",
+		Amount:            150,
+		SenderName:        "Empresa Teste LTDA",
+		SenderDocument:    "12.345.678/0001-90",
+		SenderAccount:     "12345-6",
+		RecipientName:     "João da Silva",
+		RecipientDocument: "123.456.789-09",
+		RecipientAccount:  "98765-4",
+		RecipientKeyType:  "email",
+		RecipientKeyValue: "joao@example.com",
+		Status:            "completed",
+	})
+
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetReceiptSharing("test-secret", ttl)
+	return svc, store
+}
+
+func TestReceiptShareToken_MintAndFetch(t *testing.T) {
+	svc, _ := newReceiptSharingService(10 * time.Minute)
+	ctx := context.Background()
+
+	share, err := svc.CreateReceiptShareToken(ctx, "rcpt-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if share.Token == "" {
+		t.Fatal("expected a token")
+	}
+	if share.ReceiptID != "rcpt-1" {
+		t.Errorf("receipt id = %q, want rcpt-1", share.ReceiptID)
+	}
+	if !share.ExpiresAt.After(time.Now()) {
+		t.Errorf("expected expiry in the future, got %v", share.ExpiresAt)
+	}
+
+	receipt, err := svc.GetSharedPixReceipt(ctx, share.Token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.ID != "rcpt-1" || receipt.Amount != 150 {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+	if receipt.RecipientDocument != "***.456.789-**" {
+		t.Errorf("recipient document not redacted: %q", receipt.RecipientDocument)
+	}
+	if receipt.SenderAccount != "*****-6" {
+		t.Errorf("sender account not redacted: %q", receipt.SenderAccount)
+	}
+	if receipt.RecipientKeyValue == "joao@example.com" {
+		t.Error("pix key not redacted")
+	}
+	if receipt.CustomerID != "" {
+		t.Errorf("customer id leaked: %q", receipt.CustomerID)
+	}
+}
+
+func TestReceiptShareToken_UnknownReceipt(t *testing.T) {
+	svc, _ := newReceiptSharingService(10 * time.Minute)
+
+	_, err := svc.CreateReceiptShareToken(context.Background(), "does-not-exist")
+	var notFound *domain.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReceiptShareToken_Expired(t *testing.T) {
+	svc, _ := newReceiptSharingService(-time.Minute)
+	ctx := context.Background()
+
+	share, err := svc.CreateReceiptShareToken(ctx, "rcpt-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = svc.GetSharedPixReceipt(ctx, share.Token)
+	var unauthorized *domain.ErrUnauthorized
+	if !errors.As(err, &unauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestReceiptShareToken_Tampered(t *testing.T) {
+	svc, _ := newReceiptSharingService(10 * time.Minute)
+	ctx := context.Background()
+
+	share, err := svc.CreateReceiptShareToken(ctx, "rcpt-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = svc.GetSharedPixReceipt(ctx, share.Token+"x")
+	var unauthorized *domain.ErrUnauthorized
+	if !errors.As(err, &unauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+// TestListPixReceipts_SecondPageIsOlder pages through five receipts, two of
+// them sharing a created_at, and checks page 2 holds distinct, older ones.
+func TestListPixReceipts_SecondPageIsOlder(t *testing.T) {
+	store := newFakeBankingStore()
+	for i, createdAt := range []string{
+		"2026-01-05T10:00:00Z",
+		"2026-01-04T10:00:00Z",
+		"2026-01-03T10:00:00Z",
+		"2026-01-03T10:00:00Z",
+		"2026-01-01T10:00:00Z",
+	} {
+		store.pixReceipts = append(store.pixReceipts, domain.PixReceipt{
+			ID:         fmt.Sprintf("rcpt-%d", i+1),
+			CustomerID: testCustomerID,
+			CreatedAt:  createdAt,
+		})
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	first, err := svc.ListPixReceipts(ctx, testCustomerID, 1, 2)
+	if err != nil {
+		t.Fatalf("page 1: unexpected error: %v", err)
+	}
+	second, err := svc.ListPixReceipts(ctx, testCustomerID, 2, 2)
+	if err != nil {
+		t.Fatalf("page 2: unexpected error: %v", err)
+	}
+
+	if second.Total != 5 || !second.HasMore || second.Page != 2 {
+		t.Errorf("page 2 meta = total %d, page %d, has_more %v; want 5, 2, true", second.Total, second.Page, second.HasMore)
+	}
+	if len(first.Data) != 2 || len(second.Data) != 2 {
+		t.Fatalf("got %d and %d receipts, want 2 per page", len(first.Data), len(second.Data))
+	}
+	seen := map[string]bool{first.Data[0].ID: true, first.Data[1].ID: true}
+	oldestFirst := first.Data[1].CreatedAt
+	for _, r := range second.Data {
+		if seen[r.ID] {
+			t.Errorf("receipt %s appears on both pages", r.ID)
+		}
+		if r.CreatedAt > oldestFirst {
+			t.Errorf("receipt %s (%s) is newer than page 1's oldest (%s)", r.ID, r.CreatedAt, oldestFirst)
+		}
+	}
+	if second.Data[0].ID != "rcpt-4" || second.Data[1].ID != "rcpt-3" {
+		t.Errorf("page 2 = %s, %s; want rcpt-4, rcpt-3 (id desc on ties)", second.Data[0].ID, second.Data[1].ID)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+/*
+ * PIX — recent recipients
+ *
+ * Recipients the customer paid lately, for a quick repeat PIX without
+ * saving them as favorites. The list is derived from the completed
+ * transfers, so returned (refunded) and failed ones never show up; a
+ * recipient paid several times appears once, with its latest payment.
+ */
+
+// DefaultRecentPixRecipientsLimit is how many recipients are returned when
+// the request sets no limit.
+const DefaultRecentPixRecipientsLimit = 10
+
+// maxRecentPixRecipients caps the limit a client may ask for.
+const maxRecentPixRecipients = 50
+
+// recentPixRecipientsScan is how many of the latest completed transfers are
+// read to build the list.
+const recentPixRecipientsScan = 200
+
+// GetRecentPixRecipients returns up to limit distinct recipients of the
+// customer's completed PIX transfers, most recently paid first. Recipients
+// are told apart by their PIX key.
+func (s *BankingService) GetRecentPixRecipients(ctx context.Context, customerID string, limit int) ([]domain.PixRecentRecipient, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetRecentPixRecipients")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if limit <= 0 {
+		limit = DefaultRecentPixRecipientsLimit
+	}
+	limit = min(limit, maxRecentPixRecipients)
+
+	transfers, _, err := s.store.ListPixTransfersFiltered(ctx, customerID, &domain.PixTransferFilter{
+		Status:   "completed",
+		Page:     1,
+		PageSize: recentPixRecipientsScan,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*domain.PixRecentRecipient)
+	for _, t := range transfers {
+		if t.SourceCustomerID != customerID || t.Status != "completed" || t.DestinationKeyValue == "" {
+			continue
+		}
+		paidAt := t.CreatedAt
+		if t.ExecutedAt != nil {
+			paidAt = *t.ExecutedAt
+		}
+		key := t.DestinationKeyType + ":" + strings.ToLower(strings.TrimSpace(t.DestinationKeyValue))
+		r, seen := byKey[key]
+		if !seen {
+			r = &domain.PixRecentRecipient{}
+			byKey[key] = r
+		}
+		r.PaymentCount++
+		if seen && !paidAt.After(r.LastPaidAt) {
+			continue
+		}
+		r.Name = t.DestinationName
+		r.Document = MaskDocument(t.DestinationDocument)
+		r.KeyType = t.DestinationKeyType
+		r.KeyValue = t.DestinationKeyValue
+		r.LastAmount = RoundMoney(t.Amount)
+		r.LastPaidAt = paidAt
+	}
+
+	recipients := make([]domain.PixRecentRecipient, 0, len(byKey))
+	for _, r := range byKey {
+		recipients = append(recipients, *r)
+	}
+	sort.Slice(recipients, func(i, j int) bool {
+		if !recipients[i].LastPaidAt.Equal(recipients[j].LastPaidAt) {
+			return recipients[i].LastPaidAt.After(recipients[j].LastPaidAt)
+		}
+		return recipients[i].KeyValue < recipients[j].KeyValue
+	})
+	if len(recipients) > limit {
+		recipients = recipients[:limit]
+	}
+	return recipients, nil
+}
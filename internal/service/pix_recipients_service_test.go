@@ -0,0 +1,63 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestGetRecentPixRecipients_DedupKeepsMostRecent(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	now := time.Now()
+	pix := func(id, key, name string, amount float64, ago time.Duration, status string) domain.PixTransfer {
+		return domain.PixTransfer{
+			ID: id, SourceCustomerID: testCustomerID, Status: status, Amount: amount,
+			DestinationKeyType: "email", DestinationKeyValue: key,
+			DestinationName: name, DestinationDocument: "12.345.678/0001-99",
+			CreatedAt: now.Add(-ago),
+		}
+	}
+	// Oldest first, as the store appends them
+	store.pixTransfers = []domain.PixTransfer{
+		pix("pix-1", "fornecedor@empresa.com", "Fornecedor Antigo", 100, 72*time.Hour, "completed"),
+		pix("pix-2", "aluguel@imob.com", "Imobiliária", 2500, 48*time.Hour, "completed"),
+		pix("pix-3", "Fornecedor@Empresa.com", "Fornecedor Ltda", 180, 24*time.Hour, "completed"),
+		pix("pix-4", "estorno@loja.com", "Loja Estornada", 90, time.Hour, "returned"),
+	}
+
+	recipients, err := svc.GetRecentPixRecipients(context.Background(), testCustomerID, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recipients) != 2 {
+		t.Fatalf("got %d recipients, want 2 (deduped, refund excluded): %+v", len(recipients), recipients)
+	}
+
+	first := recipients[0]
+	if first.Name != "Fornecedor Ltda" || first.LastAmount != 180 || first.PaymentCount != 2 {
+		t.Errorf("first recipient = %+v, want the latest payment to Fornecedor Ltda (180, 2 payments)", first)
+	}
+	if !first.LastPaidAt.Equal(store.pixTransfers[2].CreatedAt) {
+		t.Errorf("last paid at = %v, want %v", first.LastPaidAt, store.pixTransfers[2].CreatedAt)
+	}
+	if first.Document != "12.***.***/****-99" {
+		t.Errorf("document = %q, want it masked", first.Document)
+	}
+	if recipients[1].Name != "Imobiliária" {
+		t.Errorf("second recipient = %q, want Imobiliária", recipients[1].Name)
+	}
+
+	limited, err := svc.GetRecentPixRecipients(context.Background(), testCustomerID, 1)
+	if err != nil || len(limited) != 1 || limited[0].Name != "Fornecedor Ltda" {
+		t.Errorf("limit 1: got %+v (err %v), want only Fornecedor Ltda", limited, err)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+/*
+ * PIX Transfer — risk assessment
+ *
+ * A large PIX to a key the customer never paid before is a common scam
+ * pattern. Such transfers are flagged with a warning the client can use to
+ * ask the user for confirmation; they are never blocked here.
+ */
+
+// DefaultPixNewRecipientThreshold is the amount (BRL) above which a transfer
+// to a first-time recipient is flagged.
+const DefaultPixNewRecipientThreshold = 5000.0
+
+// pixRiskHistorySize is how many recent transfers are checked to decide
+// whether a recipient is new.
+const pixRiskHistorySize = 200
+
+// SetPixNewRecipientThreshold overrides the amount above which transfers to
+// first-time recipients are flagged. Non-positive values keep the current
+// threshold.
+func (s *BankingService) SetPixNewRecipientThreshold(amount float64) {
+	if amount > 0 {
+		s.pixNewRecipientThreshold = amount
+	}
+}
+
+// assessPixRisk returns the warnings for a transfer about to be created.
+// Lookup failures are logged and produce no warning.
+func (s *BankingService) assessPixRisk(ctx context.Context, customerID string, req *domain.PixTransferRequest) []string {
+	if req.Amount <= s.pixNewRecipientThreshold {
+		return nil
+	}
+
+	known, err := s.isKnownPixRecipient(ctx, customerID, req.DestinationKeyValue)
+	if err != nil {
+		s.logger.Warn("pix risk assessment: could not check recipient history",
+			zap.String("customer_id", customerID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if known {
+		return nil
+	}
+
+	warnings := []string{domain.PixWarningNewRecipientHighValue}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.StringSlice("pix.warnings", warnings))
+	s.logger.Info("pix risk assessment: high-value transfer to new recipient",
+		zap.String("customer_id", customerID),
+		zap.String("destination_key_type", req.DestinationKeyType),
+		zap.Float64("amount", req.Amount),
+	)
+	return warnings
+}
+
+// isKnownPixRecipient reports whether the key is a PIX favorite of the
+// customer or received one of their recent completed transfers.
+func (s *BankingService) isKnownPixRecipient(ctx context.Context, customerID, keyValue string) (bool, error) {
+	keyValue = strings.TrimSpace(keyValue)
+
+	fav, err := s.store.FindFavorite(ctx, &domain.Favorite{
+		CustomerID:      customerID,
+		DestinationType: "pix",
+		PixKeyValue:     keyValue,
+	})
+	if err != nil {
+		return false, err
+	}
+	if fav != nil {
+		return true, nil
+	}
+
+	transfers, err := s.store.ListPixTransfers(ctx, customerID, 1, pixRiskHistorySize)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range transfers {
+		if t.Status == "completed" && strings.EqualFold(strings.TrimSpace(t.DestinationKeyValue), keyValue) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
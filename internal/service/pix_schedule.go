@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * PIX Transfer — future-dated transfers
+ *
+ * A transfer submitted with scheduledFor is persisted as scheduled without
+ * touching the balance. ExecuteDuePixTransfers runs periodically, claims the
+ * transfers that became due and executes them like an immediate transfer;
+ * limits and funds are checked again at that point.
+ */
+
+const (
+	// duePixTransferBatch bounds how many transfers one worker iteration runs.
+	duePixTransferBatch = 50
+
+	// pixProcessingTimeout is how long a claimed transfer may stay processing
+	// before the worker considers its instance gone.
+	pixProcessingTimeout = 10 * time.Minute
+)
+
+// ExecuteDuePixTransfers executes the scheduled transfers that are due.
+// Each transfer is claimed first so that it runs once even with several
+// instances; a transfer that cannot be executed is marked failed.
+//
+// Transfers left processing by an instance that died mid-way are failed
+// first. Unlike a DOC settlement, executing a PIX takes several writes, so
+// such a transfer may already have moved money: claiming it again could
+// debit twice, and it is logged for reconciliation instead.
+func (s *BankingService) ExecuteDuePixTransfers(ctx context.Context) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ExecuteDuePixTransfers")
+	defer span.End()
+
+	now := time.Now()
+	stale, err := s.store.FailStalePixTransfers(ctx, now.Add(-pixProcessingTimeout), "processing_timeout")
+	if err != nil {
+		s.logger.Error("failed to fail stale PIX transfers", zap.Error(err))
+	}
+	for _, t := range stale {
+		s.logger.Error("stale scheduled PIX transfer failed, reconcile its balance",
+			zap.String("customer_id", t.SourceCustomerID),
+			zap.String("transfer_id", t.ID),
+			zap.Float64("amount", t.Amount))
+	}
+
+	due, err := s.store.ListDuePixTransfers(ctx, now, duePixTransferBatch)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("due_count", len(due)))
+
+	for i := range due {
+		transfer := &due[i]
+		claimed, err := s.store.ClaimScheduledPixTransfer(ctx, transfer.ID)
+		if err != nil {
+			s.logger.Error("failed to claim scheduled PIX transfer",
+				zap.String("transfer_id", transfer.ID), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.runScheduledPixTransfer(ctx, transfer); err != nil {
+			s.logger.Warn("scheduled PIX transfer not executed",
+				zap.String("customer_id", transfer.SourceCustomerID),
+				zap.String("transfer_id", transfer.ID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// runScheduledPixTransfer re-checks a claimed transfer and executes it.
+func (s *BankingService) runScheduledPixTransfer(ctx context.Context, transfer *domain.PixTransfer) error {
+	customerID := transfer.SourceCustomerID
+	req := &domain.PixTransferRequest{
+		IdempotencyKey:      transfer.IdempotencyKey,
+		SourceAccountID:     transfer.SourceAccountID,
+		DestinationKeyType:  transfer.DestinationKeyType,
+		DestinationKeyValue: transfer.DestinationKeyValue,
+		DestinationName:     transfer.DestinationName,
+		DestinationDocument: transfer.DestinationDocument,
+		Amount:              transfer.Amount,
+		Description:         transfer.Description,
+		FundedBy:            "balance",
+	}
+
+	account, err := s.store.GetAccount(ctx, customerID, req.SourceAccountID)
+	if err == nil {
+		err = s.checkPixLimits(ctx, customerID, req)
+	}
+	if err == nil {
+		err = s.checkPixFunding(ctx, customerID, account, req)
+	}
+	if err != nil {
+		s.failPixTransfer(ctx, transfer.ID)
+		return err
+	}
+
+	var destCustomerID string
+	if destKey, lookupErr := s.LookupPixKey(ctx, req.DestinationKeyType, req.DestinationKeyValue); lookupErr == nil && destKey != nil {
+		destCustomerID = destKey.CustomerID
+	}
+	return s.executePixTransfer(ctx, customerID, req, transfer, s.resolvePixParties(ctx, customerID, destCustomerID))
+}
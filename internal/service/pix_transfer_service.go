@@ -2,11 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/i18n"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
@@ -17,10 +18,18 @@ import (
  * PIX Transfer — create, list, get, cancel
  */
 
-func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransfer, error) {
+func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (transfer *domain.PixTransfer, err error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.CreatePixTransfer")
 	defer span.End()
 	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Float64("amount", req.Amount))
+	// funded_by and the key type are only final once validated/detected.
+	defer func() {
+		span.SetAttributes(
+			attribute.String("funded_by", req.FundedBy),
+			attribute.String("destination_key_type", req.DestinationKeyType),
+		)
+		endMoneySpan(span, err)
+	}()
 
 	start := time.Now()
 	defer func() { s.metrics.RecordRequestDuration("pix_transfer", time.Since(start)) }()
@@ -30,6 +39,76 @@ func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID strin
 		return nil, err
 	}
 
+	// ── Retry of a transfer already created with this key ──
+	// Checked before anything is debited: card limit updates are not
+	// transactional, so a second execution would charge the card twice.
+	if existing, err := s.replayPixTransfer(ctx, customerID, req); existing != nil || err != nil {
+		return existing, err
+	}
+
+	// ── Pre-checks: account, limits, funding; resolve the recipient ──
+	plan, err := s.preparePixTransfer(ctx, customerID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// ── Confirmation step for high-value transfers ──
+	confirmation, err := s.checkPixConfirmation(ctx, customerID, req, plan.recipient, plan.warnings)
+	if err != nil {
+		return nil, err
+	}
+	if confirmation != "" {
+		defer func() { s.settlePixConfirmation(ctx, confirmation, err == nil) }()
+	}
+
+	// ── Persist transfer ──
+	s.seedTransactionLimit(ctx, customerID, "pix")
+	transfer, err = s.store.CreatePixTransfer(ctx, customerID, req)
+	if err != nil {
+		// A concurrent request with the same key won the insert; return its transfer
+		var duplicate *domain.ErrDuplicate
+		if errors.As(err, &duplicate) {
+			if existing, replayErr := s.replayPixTransfer(ctx, customerID, req); existing != nil {
+				return existing, nil
+			} else if replayErr != nil {
+				return nil, replayErr
+			}
+		}
+		s.logger.Error("failed to create PIX transfer", zap.Error(err))
+		return nil, err
+	}
+	transfer.Warnings = plan.warnings
+
+	// ── Future-dated: left for the scheduled transfers worker ──
+	if req.ScheduledFor != "" {
+		s.logger.Info("PIX transfer scheduled",
+			zap.String("customer_id", customerID),
+			zap.String("transfer_id", transfer.ID),
+			zap.Float64("amount", req.Amount),
+			zap.String("scheduled_for", req.ScheduledFor),
+		)
+		return transfer, nil
+	}
+
+	if err := s.executePixTransfer(ctx, customerID, req, transfer, plan.parties); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// pixTransferPlan is what preparePixTransfer resolved for a transfer that
+// passed every pre-check.
+type pixTransferPlan struct {
+	warnings  []string
+	parties   pixParties
+	recipient *domain.PixRecipient
+}
+
+// preparePixTransfer runs the pre-checks of a validated request — source
+// account, self-transfer, limits and funding — and resolves the recipient.
+// It neither persists nor moves money, so CreatePixTransfer and
+// ValidatePixTransfer share it. Card funding fills req's fee fields.
+func (s *BankingService) preparePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*pixTransferPlan, error) {
 	// Check account exists and belongs to customer
 	account, err := s.store.GetAccount(ctx, customerID, req.SourceAccountID)
 	if err != nil {
@@ -39,7 +118,7 @@ func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID strin
 	// Block self-transfer
 	destKey, lookupErr := s.LookupPixKey(ctx, req.DestinationKeyType, req.DestinationKeyValue)
 	if lookupErr == nil && destKey != nil && destKey.CustomerID == customerID {
-		return nil, &domain.ErrValidation{Field: "recipientKey", Message: "Não é possível transferir para você mesmo"}
+		return nil, &domain.ErrValidation{Field: "recipientKey", Message: i18n.T(ctx, "pix.self_transfer")}
 	}
 
 	// Auto-detect destination key type if not provided
@@ -58,8 +137,11 @@ func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID strin
 	}
 
 	// ── Check funding source ──
-	if err := s.checkPixFunding(ctx, customerID, account, req); err != nil {
-		return nil, err
+	// A scheduled transfer is funded when it runs, not when it is booked.
+	if req.ScheduledFor == "" {
+		if err := s.checkPixFunding(ctx, customerID, account, req); err != nil {
+			return nil, err
+		}
 	}
 
 	// ── Resolve destination info ──
@@ -73,25 +155,140 @@ func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID strin
 		}
 	}
 
-	// ── Resolve sender & destination lookup data for receipts ──
-	senderName, senderDoc, senderBank, senderBranch, senderAcct := s.resolveSenderData(ctx, customerID)
-	destBank, destBranch, destAcct := s.resolveDestData(ctx, destCustomerID)
+	plan := &pixTransferPlan{
+		// ── Risk assessment (flags only, never blocks) ──
+		warnings: s.assessPixRisk(ctx, customerID, req),
+		// ── Resolve sender & destination lookup data for receipts ──
+		parties: s.resolvePixParties(ctx, customerID, destCustomerID),
+	}
+	plan.recipient = &domain.PixRecipient{
+		Name:     req.DestinationName,
+		Document: req.DestinationDocument,
+		Bank:     plan.parties.destBank,
+		Branch:   plan.parties.destBranch,
+		Account:  plan.parties.destAcct,
+		PixKey:   &domain.PixKeyInfo{Type: req.DestinationKeyType, Value: req.DestinationKeyValue},
+	}
+	return plan, nil
+}
+
+// ValidatePixTransfer is a dry run of CreatePixTransfer: it runs the same
+// pre-checks and reports whether the transfer could proceed, with the
+// resolved recipient and fees, without persisting or debiting anything.
+// Checks the customer can act on (validation, limits, funds) come back as a
+// blocking reason; other failures are returned as errors. No confirmation
+// token is issued; RequiresConfirmation tells the UI one will be asked for.
+func (s *BankingService) ValidatePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransferValidation, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ValidatePixTransfer")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.Float64("amount", req.Amount))
+
+	result := &domain.PixTransferValidation{
+		Amount:       req.Amount,
+		FundedBy:     req.FundedBy,
+		Installments: req.CreditCardInstallments,
+	}
+	block := func(err error) (*domain.PixTransferValidation, error) {
+		code := spanErrorCode(err)
+		switch code {
+		case "validation", "insufficient_funds", "limit_exceeded", "account_blocked":
+			result.ReasonCode, result.Reason = code, err.Error()
+			span.SetAttributes(attribute.String("result", spanResultDeclined), attribute.String("error_code", code))
+			return result, nil
+		}
+		return nil, err
+	}
+
+	if err := validatePixTransferRequest(req); err != nil {
+		return block(err)
+	}
+	result.FundedBy = req.FundedBy
 
-	// ── Persist transfer ──
-	transfer, err := s.store.CreatePixTransfer(ctx, customerID, req)
+	plan, err := s.preparePixTransfer(ctx, customerID, req)
 	if err != nil {
-		s.logger.Error("failed to create PIX transfer", zap.Error(err))
+		return block(err)
+	}
+
+	result.CanProceed = true
+	result.FeeRate = req.FeeRate
+	result.TotalWithFees = req.Amount
+	if req.TotalWithFees > 0 {
+		result.TotalWithFees = req.TotalWithFees
+	}
+	result.Fees = RoundMoney(result.TotalWithFees - req.Amount)
+	result.Recipient = plan.recipient
+	result.Warnings = plan.warnings
+	result.RequiresConfirmation = s.pixConfirmThreshold > 0 && req.ConfirmationToken == "" && req.Amount > s.pixConfirmThreshold
+	return result, nil
+}
+
+// replayPixTransfer returns the transfer the customer already created with
+// req's idempotency key, or nil when there is none. Reusing a key for a
+// different amount or recipient is a conflict, and replaying a transfer
+// that failed fails as well.
+func (s *BankingService) replayPixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest) (*domain.PixTransfer, error) {
+	existing, err := s.store.GetPixTransferByIdempotencyKey(ctx, customerID, req.IdempotencyKey)
+	if err != nil {
+		var notFound *domain.ErrNotFound
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
+	if existing.Amount != req.Amount || existing.DestinationKeyValue != req.DestinationKeyValue {
+		return nil, &domain.ErrConflict{Message: "idempotency key already used for a different PIX transfer"}
+	}
+	// The original request failed; so does its replay, rather than
+	// reporting the failed transfer as created
+	if existing.Status == "failed" {
+		return nil, &domain.ErrInvalidState{Resource: "pix_transfer", Status: existing.Status, Action: "replay"}
+	}
+
+	s.logger.Info("PIX transfer replayed for idempotency key",
+		zap.String("customer_id", customerID),
+		zap.String("transfer_id", existing.ID),
+		zap.String("status", existing.Status),
+	)
+	return existing, nil
+}
 
+// executePixTransfer moves the money of a persisted transfer: debits the
+// sender, completes the transfer, saves its receipts and credits the
+// destination. A failed debit marks the transfer failed and is returned.
+//
+// A card-funded transfer holds the card limit instead of debiting it and
+// captures the hold (books the fatura line) before the transfer is completed
+// and its receipts saved: a failed capture releases the hold and fails the
+// transfer with nothing else written, so no receipt exists for a transfer
+// that did not go through. Once captured, the transfer has happened, as
+// after the debit of a balance-funded one.
+func (s *BankingService) executePixTransfer(ctx context.Context, customerID string, req *domain.PixTransferRequest, transfer *domain.PixTransfer, parties pixParties) error {
 	now := time.Now()
 
-	// ── 1. Debit sender ──
+	// ── 1. Debit sender (or hold the card limit) ──
 	descSent := formatPixDescription("Pix enviado", transfer.DestinationName, transfer.DestinationKeyValue)
-	s.debitSender(ctx, customerID, req, descSent, now)
+	var hold *cardHold
+	var err error
+	if req.FundedBy == "credit_card" {
+		hold, err = s.holdCardLimit(ctx, customerID, req)
+	} else {
+		err = s.debitSender(ctx, customerID, req, descSent, now)
+	}
+	if err != nil {
+		s.logger.Error("failed to debit sender, PIX transfer not executed",
+			zap.String("customer_id", customerID),
+			zap.String("transfer_id", transfer.ID),
+			zap.Error(err))
+		s.failPixTransfer(ctx, transfer.ID)
+		return err
+	}
 
-	// ── 2. Credit destination ──
-	s.creditDestination(ctx, destCustomerID, senderName, req.Amount, now)
+	// ── 2. Capture the card hold ──
+	if hold != nil {
+		if capErr := s.captureCardHold(ctx, customerID, req, hold, descSent, now); capErr != nil {
+			return s.abortCardPixTransfer(ctx, customerID, transfer, hold, capErr)
+		}
+	}
 
 	// ── 3. Mark transfer as completed ──
 	if updErr := s.store.UpdatePixTransferStatus(ctx, transfer.ID, "completed"); updErr != nil {
@@ -100,19 +297,37 @@ func (s *BankingService) CreatePixTransfer(ctx context.Context, customerID strin
 	} else {
 		transfer.Status = "completed"
 	}
+	s.recordLimitUsage(ctx, customerID, "pix", s.pixLimitExposure(req))
 
 	// ── 4. Save receipts ──
-	transfer.ReceiptID = s.savePixReceipts(ctx, transfer, customerID, destCustomerID, req, senderName, senderDoc, senderBank, senderBranch, senderAcct, destBank, destBranch, destAcct, now)
+	transfer.ReceiptID = s.savePixReceipts(ctx, transfer, customerID, parties.destCustomerID, req,
+		parties.senderName, parties.senderDoc, parties.senderBank, parties.senderBranch, parties.senderAcct,
+		parties.destBank, parties.destBranch, parties.destAcct, now)
+
+	// ── 5. Credit destination ──
+	s.creditDestination(ctx, parties.destCustomerID, parties.senderName, req.Description, req.Amount, now)
 
 	s.logger.Info("PIX transfer completed",
 		zap.String("customer_id", customerID),
-		zap.String("dest_customer_id", destCustomerID),
+		zap.String("dest_customer_id", parties.destCustomerID),
 		zap.String("transfer_id", transfer.ID),
 		zap.Float64("amount", req.Amount),
 		zap.String("funded_by", req.FundedBy),
 	)
 
-	return transfer, nil
+	if hold == nil {
+		s.notifyBalanceChange(ctx, customerID, -req.Amount, descSent)
+	}
+	s.publishEvent(ctx, customerID, domain.EventPixTransferCompleted, transfer)
+	return nil
+}
+
+// failPixTransfer marks a transfer that could not be executed as failed.
+func (s *BankingService) failPixTransfer(ctx context.Context, transferID string) {
+	if updErr := s.store.UpdatePixTransferStatus(ctx, transferID, "failed"); updErr != nil {
+		s.logger.Error("failed to update pix transfer status to failed",
+			zap.String("transfer_id", transferID), zap.Error(updErr))
+	}
 }
 
 func (s *BankingService) ListPixTransfers(ctx context.Context, customerID string, page, pageSize int) ([]domain.PixTransfer, error) {
@@ -122,6 +337,42 @@ func (s *BankingService) ListPixTransfers(ctx context.Context, customerID string
 	return s.store.ListPixTransfers(ctx, customerID, page, pageSize)
 }
 
+// pixTransferStatuses are the statuses a PIX transfer can have.
+var pixTransferStatuses = map[string]bool{"pending": true, "scheduled": true, "processing": true, "completed": true, "failed": true, "cancelled": true, "returned": true}
+
+// ListPixTransfersPage returns one page of the customer's PIX transfers
+// matching filter, newest first.
+func (s *BankingService) ListPixTransfersPage(ctx context.Context, customerID string, filter *domain.PixTransferFilter) (*domain.ListResponse[domain.PixTransfer], error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ListPixTransfersPage")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.String("status", filter.Status))
+
+	if filter.Status != "" && !pixTransferStatuses[filter.Status] {
+		return nil, &domain.ErrValidation{Field: "status", Message: "must be pending, scheduled, processing, completed, failed, cancelled or returned"}
+	}
+	var from, to time.Time
+	var err error
+	if filter.From != "" {
+		if from, err = time.Parse("2006-01-02", filter.From); err != nil {
+			return nil, &domain.ErrValidation{Field: "from", Message: "must be a date (YYYY-MM-DD)"}
+		}
+	}
+	if filter.To != "" {
+		if to, err = time.Parse("2006-01-02", filter.To); err != nil {
+			return nil, &domain.ErrValidation{Field: "to", Message: "must be a date (YYYY-MM-DD)"}
+		}
+	}
+	if filter.From != "" && filter.To != "" && from.After(to) {
+		return nil, &domain.ErrValidation{Field: "from", Message: "must not be after to"}
+	}
+
+	rows, total, err := s.store.ListPixTransfersFiltered(ctx, customerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, filter.Page, filter.PageSize), nil
+}
+
 func (s *BankingService) GetPixTransfer(ctx context.Context, customerID, transferID string) (*domain.PixTransfer, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.GetPixTransfer")
 	defer span.End()
@@ -165,25 +416,66 @@ func validatePixTransferRequest(req *domain.PixTransferRequest) error {
 	if req.FundedBy == "" {
 		req.FundedBy = "balance"
 	}
+	if req.FundedBy != "balance" && req.FundedBy != "credit_card" {
+		return &domain.ErrValidation{Field: "funded_by", Message: "must be 'balance' or 'credit_card'"}
+	}
+	if req.ScheduledFor != "" {
+		scheduledFor, err := time.Parse(time.RFC3339, req.ScheduledFor)
+		if err != nil {
+			return &domain.ErrValidation{Field: "scheduledFor", Message: "invalid format, use RFC 3339"}
+		}
+		if !scheduledFor.After(time.Now()) {
+			return &domain.ErrValidation{Field: "scheduledFor", Message: "must be in the future"}
+		}
+		if req.FundedBy != "balance" {
+			return &domain.ErrValidation{Field: "scheduledFor", Message: "only balance-funded transfers can be scheduled"}
+		}
+		req.ScheduledFor = scheduledFor.UTC().Format(time.RFC3339)
+	}
+	// Card-only fields must not be combined with balance funding
+	if req.FundedBy != "credit_card" {
+		if req.CreditCardID != "" {
+			return &domain.ErrValidation{Field: "credit_card_id", Message: "only allowed when funded_by is credit_card"}
+		}
+		if req.CreditCardInstallments > 1 {
+			return &domain.ErrValidation{Field: "installments", Message: "only allowed when funded_by is credit_card"}
+		}
+	}
 	return nil
 }
 
+// checkPixLimits applies the customer's PIX single and daily limits to every
+// transfer, whatever funds it. A card-funded transfer counts with its fees,
+// the full amount the customer is exposed to.
 func (s *BankingService) checkPixLimits(ctx context.Context, customerID string, req *domain.PixTransferRequest) error {
-	limit, err := s.store.GetTransactionLimit(ctx, customerID, "pix")
+	exposure := s.pixLimitExposure(req)
+	limit, err := s.transactionLimit(ctx, customerID, "pix")
 	if err == nil && limit != nil {
-		if req.Amount > limit.SingleLimit {
-			return &domain.ErrLimitExceeded{LimitType: "single_pix", Limit: limit.SingleLimit, Current: req.Amount}
+		if exposure > limit.SingleLimit {
+			return &domain.ErrLimitExceeded{LimitType: "single_pix", Limit: limit.SingleLimit, Current: exposure}
+		}
+		if limit.DailyUsed+exposure > limit.DailyLimit {
+			return &domain.ErrLimitExceeded{LimitType: "daily_pix", Limit: limit.DailyLimit, Current: limit.DailyUsed + exposure}
 		}
-		if limit.DailyUsed+req.Amount > limit.DailyLimit {
-			return &domain.ErrLimitExceeded{LimitType: "daily_pix", Limit: limit.DailyLimit, Current: limit.DailyUsed + req.Amount}
+		if limit.MonthlyUsed+exposure > limit.MonthlyLimit {
+			return &domain.ErrLimitExceeded{LimitType: "monthly_pix", Limit: limit.MonthlyLimit, Current: limit.MonthlyUsed + exposure}
 		}
 	}
 	return nil
 }
 
+// pixLimitExposure is what a transfer counts towards the PIX limits: the
+// amount, or the total with fees when charged on the card.
+func (s *BankingService) pixLimitExposure(req *domain.PixTransferRequest) float64 {
+	if req.FundedBy == "credit_card" {
+		return s.pixCreditTotal(req.Amount, req.CreditCardInstallments)
+	}
+	return req.Amount
+}
+
 func (s *BankingService) checkPixFunding(ctx context.Context, customerID string, account *domain.Account, req *domain.PixTransferRequest) error {
-	if req.FundedBy == "balance" && account.AvailableBalance < req.Amount {
-		return &domain.ErrInsufficientFunds{Available: account.AvailableBalance, Required: req.Amount}
+	if req.FundedBy == "balance" && spendableBalance(account) < req.Amount {
+		return &domain.ErrInsufficientFunds{Available: spendableBalance(account), Required: req.Amount}
 	}
 
 	if req.FundedBy == "credit_card" {
@@ -194,20 +486,14 @@ func (s *BankingService) checkPixFunding(ctx context.Context, customerID string,
 		if err != nil {
 			return err
 		}
+		if card.Status == "cancelled" {
+			return &domain.ErrValidation{Field: "credit_card_id", Message: "card is cancelled"}
+		}
 		if !card.PixCreditEnabled {
 			return &domain.ErrValidation{Field: "credit_card_id", Message: "PIX via credit card not enabled for this card"}
 		}
-		if req.TotalWithFees <= 0 {
-			installments := req.CreditCardInstallments
-			if installments <= 0 {
-				installments = 1
-			}
-			feeRate := req.FeeRate
-			if feeRate <= 0 {
-				feeRate = 0.02
-			}
-			req.TotalWithFees = req.Amount * (1 + feeRate*float64(installments-1))
-		}
+		req.FeeRate = s.pixCreditFeeRate
+		req.TotalWithFees = s.pixCreditTotal(req.Amount, req.CreditCardInstallments)
 		if card.PixCreditUsed+req.TotalWithFees > card.PixCreditLimit {
 			return &domain.ErrLimitExceeded{LimitType: "pix_credit", Limit: card.PixCreditLimit, Current: card.PixCreditUsed + req.TotalWithFees}
 		}
@@ -215,6 +501,20 @@ func (s *BankingService) checkPixFunding(ctx context.Context, customerID string,
 	return nil
 }
 
+// pixParties is the sender and destination data recorded on receipts.
+type pixParties struct {
+	destCustomerID                                              string
+	senderName, senderDoc, senderBank, senderBranch, senderAcct string
+	destBank, destBranch, destAcct                              string
+}
+
+func (s *BankingService) resolvePixParties(ctx context.Context, customerID, destCustomerID string) pixParties {
+	p := pixParties{destCustomerID: destCustomerID}
+	p.senderName, p.senderDoc, p.senderBank, p.senderBranch, p.senderAcct = s.resolveSenderData(ctx, customerID)
+	p.destBank, p.destBranch, p.destAcct = s.resolveDestData(ctx, destCustomerID)
+	return p
+}
+
 func (s *BankingService) resolveSenderData(ctx context.Context, customerID string) (name, doc, bank, branch, acct string) {
 	name, _ = s.store.GetCustomerName(ctx, customerID)
 	if name == "" || name == "Destinatário" {
@@ -250,78 +550,16 @@ func formatPixDescription(prefix, destName, destKeyValue string) string {
 	return fmt.Sprintf("%s - %s", prefix, destKeyValue)
 }
 
-func (s *BankingService) debitSender(ctx context.Context, customerID string, req *domain.PixTransferRequest, descSent string, now time.Time) {
-	if req.FundedBy == "credit_card" {
-		s.debitSenderCreditCard(ctx, customerID, req, descSent, now)
-	} else {
-		s.debitSenderBalance(ctx, customerID, req.Amount, descSent, now)
+// debitSender debits a balance-funded transfer from the sender's account.
+func (s *BankingService) debitSender(ctx context.Context, customerID string, req *domain.PixTransferRequest, descSent string, now time.Time) error {
+	category := s.categorizePix(PixDirectionSent, req.Description, req.DestinationName)
+	if category == "" {
+		category = "pix"
 	}
+	return s.debitSenderBalance(ctx, customerID, req.Amount, descSent, category, now)
 }
 
-func (s *BankingService) debitSenderCreditCard(ctx context.Context, customerID string, req *domain.PixTransferRequest, descSent string, now time.Time) {
-	card, _ := s.store.GetCreditCard(ctx, customerID, req.CreditCardID)
-	if card != nil {
-		debitAmount := req.TotalWithFees
-		if debitAmount <= 0 {
-			debitAmount = req.Amount
-		}
-		newUsed := card.UsedLimit + debitAmount
-		newAvailable := card.CreditLimit - newUsed
-		if newAvailable < 0 {
-			newAvailable = 0
-		}
-		if ulErr := s.store.UpdateCreditCardUsedLimit(ctx, card.ID, newUsed, newAvailable); ulErr != nil {
-			s.logger.Error("failed to update card used_limit after pix credit",
-				zap.String("card_id", card.ID), zap.Error(ulErr))
-		}
-		newPixUsed := card.PixCreditUsed + debitAmount
-		if pxErr := s.store.UpdateCreditCardPixCreditUsed(ctx, card.ID, newPixUsed); pxErr != nil {
-			s.logger.Error("failed to update pix_credit_used after pix credit",
-				zap.String("card_id", card.ID), zap.Error(pxErr))
-		}
-	}
-
-	faturaAmount := req.TotalWithFees
-	if faturaAmount <= 0 {
-		faturaAmount = req.Amount
-	}
-	installments := req.CreditCardInstallments
-	if installments <= 0 {
-		installments = 1
-	}
-	installmentAmount := faturaAmount / float64(installments)
-
-	ccTx := map[string]any{
-		"id":                  uuid.New().String(),
-		"card_id":             req.CreditCardID,
-		"customer_id":         customerID,
-		"transaction_date":    now.Format(time.RFC3339),
-		"amount":              faturaAmount,
-		"original_amount":     req.Amount,
-		"installment_amount":  math.Round(installmentAmount*100) / 100,
-		"merchant_name":       descSent,
-		"category":            "other",
-		"description":         descSent,
-		"installments":        installments,
-		"current_installment": 1,
-		"transaction_type":    "pix_credit",
-		"status":              "confirmed",
-	}
-	if txErr := s.store.InsertCreditCardTransaction(ctx, ccTx); txErr != nil {
-		s.logger.Error("failed to record pix credit card transaction in fatura",
-			zap.String("customer_id", customerID), zap.Error(txErr))
-	}
-
-	// NOTE: PIX via credit card is NOT recorded in customer_transactions (extrato).
-	// It lives exclusively in credit_card_transactions (fatura) of the selected card.
-}
-
-func (s *BankingService) debitSenderBalance(ctx context.Context, customerID string, amount float64, descSent string, now time.Time) {
-	if _, balErr := s.store.UpdateAccountBalance(ctx, customerID, -amount); balErr != nil {
-		s.logger.Error("failed to debit sender balance after pix transfer",
-			zap.String("customer_id", customerID), zap.Error(balErr))
-	}
-
+func (s *BankingService) debitSenderBalance(ctx context.Context, customerID string, amount float64, descSent, category string, now time.Time) error {
 	txSent := map[string]any{
 		"id":          uuid.New().String(),
 		"customer_id": customerID,
@@ -329,26 +567,19 @@ func (s *BankingService) debitSenderBalance(ctx context.Context, customerID stri
 		"description": descSent,
 		"amount":      -amount,
 		"type":        "pix_sent",
-		"category":    "pix",
-	}
-	if txErr := s.store.InsertTransaction(ctx, txSent); txErr != nil {
-		s.logger.Error("failed to record sender pix transaction",
-			zap.String("customer_id", customerID), zap.Error(txErr))
+		"category":    category,
 	}
+	return s.moveBalance(ctx, customerID, -amount, txSent)
 }
 
-func (s *BankingService) creditDestination(ctx context.Context, destCustomerID, senderName string, amount float64, now time.Time) {
+func (s *BankingService) creditDestination(ctx context.Context, destCustomerID, senderName, description string, amount float64, now time.Time) {
 	if destCustomerID == "" {
 		return
 	}
 
-	if _, balErr := s.store.UpdateAccountBalance(ctx, destCustomerID, amount); balErr != nil {
-		s.logger.Error("failed to credit destination balance after pix transfer",
-			zap.String("dest_customer_id", destCustomerID), zap.Error(balErr))
-	} else {
-		s.logger.Info("PIX destination credited",
-			zap.String("dest_customer_id", destCustomerID),
-			zap.Float64("amount", amount))
+	category := s.categorizePix(PixDirectionReceived, description, senderName)
+	if category == "" {
+		category = "recebimento"
 	}
 
 	txReceived := map[string]any{
@@ -358,12 +589,17 @@ func (s *BankingService) creditDestination(ctx context.Context, destCustomerID,
 		"description": fmt.Sprintf("Pix recebido - %s", senderName),
 		"amount":      amount,
 		"type":        "pix_received",
-		"category":    "recebimento",
+		"category":    category,
 	}
-	if txErr := s.store.InsertTransaction(ctx, txReceived); txErr != nil {
-		s.logger.Error("failed to record destination pix_received transaction",
-			zap.String("dest_customer_id", destCustomerID), zap.Error(txErr))
+	if err := s.moveBalance(ctx, destCustomerID, amount, txReceived); err != nil {
+		s.logger.Error("failed to credit destination after pix transfer",
+			zap.String("dest_customer_id", destCustomerID), zap.Error(err))
+		return
 	}
+	s.logger.Info("PIX destination credited",
+		zap.String("dest_customer_id", destCustomerID),
+		zap.Float64("amount", amount))
+	s.notifyBalanceChange(ctx, destCustomerID, amount, txReceived["description"].(string))
 }
 
 func (s *BankingService) savePixReceipts(ctx context.Context, transfer *domain.PixTransfer, customerID, destCustomerID string, req *domain.PixTransferRequest, senderName, senderDoc, senderBank, senderBranch, senderAcct, destBank, destBranch, destAcct string, now time.Time) string {
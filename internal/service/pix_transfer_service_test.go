@@ -0,0 +1,1079 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestCreatePixTransfer_RejectsCardFieldsWithoutCardFunding(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       domain.PixTransferRequest
+		wantField string
+	}{
+		{
+			name:      "installments with balance",
+			req:       domain.PixTransferRequest{FundedBy: "balance", CreditCardInstallments: 3},
+			wantField: "installments",
+		},
+		{
+			name:      "card id with balance",
+			req:       domain.PixTransferRequest{FundedBy: "balance", CreditCardID: testCardID},
+			wantField: "credit_card_id",
+		},
+		{
+			name:      "installments with default funding",
+			req:       domain.PixTransferRequest{CreditCardInstallments: 2},
+			wantField: "installments",
+		},
+		{
+			name:      "unknown funding source",
+			req:       domain.PixTransferRequest{FundedBy: "boleto"},
+			wantField: "funded_by",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 1000)
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			req := tt.req
+			req.IdempotencyKey = "idem-1"
+			req.SourceAccountID = testAccountID
+			req.DestinationKeyValue = "fornecedor@example.com"
+			req.Amount = 100
+
+			_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &req)
+			var validation *domain.ErrValidation
+			if !errors.As(err, &validation) {
+				t.Fatalf("expected ErrValidation, got %v", err)
+			}
+			if validation.Field != tt.wantField {
+				t.Errorf("field = %q, want %q", validation.Field, tt.wantField)
+			}
+			if len(store.pixTransfers) != 0 {
+				t.Errorf("expected no transfer persisted, got %d", len(store.pixTransfers))
+			}
+		})
+	}
+}
+
+func TestCreatePixTransfer_BalanceWithSingleInstallment(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:         "idem-1",
+		SourceAccountID:        testAccountID,
+		DestinationKeyValue:    "fornecedor@example.com",
+		Amount:                 100,
+		FundedBy:               "balance",
+		CreditCardInstallments: 1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.Status != "completed" {
+		t.Errorf("status = %q, want completed", transfer.Status)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 900 {
+		t.Errorf("balance = %v, want 900", got)
+	}
+}
+
+func TestCreatePixTransfer_ConcurrentRetryDebitsCardOnce(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	submit := func() (*domain.PixTransfer, error) {
+		return svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+			IdempotencyKey:         "client-key-1",
+			SourceAccountID:        testAccountID,
+			DestinationKeyValue:    "fornecedor@example.com",
+			Amount:                 500,
+			FundedBy:               "credit_card",
+			CreditCardID:           testCardID,
+			CreditCardInstallments: 1,
+			TotalWithFees:          500,
+		})
+	}
+
+	const attempts = 5
+	results := make([]*domain.PixTransfer, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			transfer, err := submit()
+			if err != nil {
+				t.Errorf("attempt %d: unexpected error: %v", i, err)
+				return
+			}
+			results[i] = transfer
+		}(i)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r != nil && r.ID != results[0].ID {
+			t.Errorf("attempt %d returned transfer %s, want the original %s", i, r.ID, results[0].ID)
+		}
+	}
+	if len(store.pixTransfers) != 1 {
+		t.Errorf("created %d transfers, want 1", len(store.pixTransfers))
+	}
+	if got := store.cards[testCardID].UsedLimit; got != 500 {
+		t.Errorf("card used limit = %v, want a single debit of 500", got)
+	}
+	if len(store.cardTxs) != 1 {
+		t.Errorf("booked %d card transactions, want 1", len(store.cardTxs))
+	}
+
+	// A later retry replays too; reusing the key for another amount conflicts
+	if _, err := submit(); err != nil {
+		t.Fatalf("sequential retry: unexpected error: %v", err)
+	}
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "client-key-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              20,
+	})
+	var conflict *domain.ErrConflict
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected ErrConflict for a reused key, got %v", err)
+	}
+	if got := store.cards[testCardID].UsedLimit; got != 500 {
+		t.Errorf("card used limit = %v after retries, want 500", got)
+	}
+}
+
+func TestCreatePixTransfer_ReplayOfFailedTransferFails(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	req := func() *domain.PixTransferRequest {
+		return &domain.PixTransferRequest{
+			IdempotencyKey:      "client-key-1",
+			SourceAccountID:     testAccountID,
+			DestinationKeyValue: "fornecedor@example.com",
+			Amount:              100,
+		}
+	}
+
+	store.insertTxErr = errors.New("connection reset")
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, req()); err == nil {
+		t.Fatal("expected the transfer to fail")
+	}
+	store.insertTxErr = nil
+
+	transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, req())
+	var invalidState *domain.ErrInvalidState
+	if !errors.As(err, &invalidState) {
+		t.Fatalf("replay of a failed transfer: expected ErrInvalidState, got transfer %+v, err %v", transfer, err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance = %v, want 1000", got)
+	}
+}
+
+func TestCreatePixTransfer_CardFundedCountsTowardDailyPixLimit(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	store.limits["pix"] = &domain.TransactionLimit{
+		CustomerID: testCustomerID, TransactionType: "pix",
+		SingleLimit: 5000, DailyLimit: 2000, DailyUsed: 1500, MonthlyLimit: 50000,
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	// 480 fits the 500 left of the daily limit, but not with the fees of
+	// 6 installments (480 * 1.10 = 528).
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:         "idem-card-limit",
+		SourceAccountID:        testAccountID,
+		DestinationKeyValue:    "fornecedor@example.com",
+		Amount:                 480,
+		FundedBy:               "credit_card",
+		CreditCardID:           testCardID,
+		CreditCardInstallments: 6,
+	})
+	var limitErr *domain.ErrLimitExceeded
+	if !errors.As(err, &limitErr) || limitErr.LimitType != "daily_pix" {
+		t.Fatalf("err = %v, want daily_pix ErrLimitExceeded", err)
+	}
+	if limitErr.Current != 2028 {
+		t.Errorf("current = %v, want the used amount plus the total with fees (2028)", limitErr.Current)
+	}
+	if got := store.cards[testCardID].UsedLimit; got != 0 {
+		t.Errorf("card used limit = %v, want 0 untouched", got)
+	}
+}
+
+func TestCreatePixTransfer_MonthlyLimitAndUsage(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.limits["pix"] = &domain.TransactionLimit{
+		CustomerID: testCustomerID, TransactionType: "pix",
+		SingleLimit: 5000, DailyLimit: 2000, MonthlyLimit: 1000, MonthlyUsed: 950,
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-over-month",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+	})
+	var limitErr *domain.ErrLimitExceeded
+	if !errors.As(err, &limitErr) || limitErr.LimitType != "monthly_pix" || limitErr.Current != 1050 {
+		t.Fatalf("err = %v, want monthly_pix ErrLimitExceeded at 1050", err)
+	}
+
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-within-month",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              40,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.limits["pix"]; got.DailyUsed != 40 || got.MonthlyUsed != 990 {
+		t.Errorf("usage = %v daily, %v monthly; want the transfer recorded (40, 990)", got.DailyUsed, got.MonthlyUsed)
+	}
+}
+
+func TestCreatePixTransfer_YesterdaysUsageDoesNotCount(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	// Reset 40 days ago: neither today nor this month.
+	lastReset := time.Now().AddDate(0, 0, -40)
+	store.limits["pix"] = &domain.TransactionLimit{
+		CustomerID: testCustomerID, TransactionType: "pix",
+		SingleLimit: 5000, DailyLimit: 2000, DailyUsed: 2000, MonthlyLimit: 50000, MonthlyUsed: 50000,
+		LastResetDaily: &lastReset, LastResetMonthly: &lastReset,
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-new-day",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+	}); err != nil {
+		t.Fatalf("usage of an earlier period counted: %v", err)
+	}
+}
+
+func TestCreatePixTransfer_CardHoldReleasedOnFailure(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.cardTxErr = errors.New("card transaction store unavailable")
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:         "idem-1",
+		SourceAccountID:        testAccountID,
+		DestinationKeyValue:    "fornecedor@example.com",
+		Amount:                 300,
+		FundedBy:               "credit_card",
+		CreditCardID:           testCardID,
+		CreditCardInstallments: 1,
+	})
+	if err == nil {
+		t.Fatal("expected the failed capture to fail the transfer")
+	}
+
+	card := store.cards[testCardID]
+	if card.UsedLimit != 0 || card.PixCreditUsed != 0 || card.AvailableLimit != card.CreditLimit {
+		t.Errorf("card limit not released: used %v, pix used %v, available %v", card.UsedLimit, card.PixCreditUsed, card.AvailableLimit)
+	}
+	if len(store.cardTxs) != 0 {
+		t.Errorf("booked %d fatura lines for a failed transfer, want 0", len(store.cardTxs))
+	}
+	if len(store.pixReceipts) != 0 {
+		t.Errorf("saved %d receipts for a failed transfer, want 0", len(store.pixReceipts))
+	}
+	if status := store.pixTransfers[0].Status; status != "failed" {
+		t.Errorf("transfer status = %q, want failed", status)
+	}
+}
+
+func TestCreatePixTransfer_CardFeeRateIsConfigurable(t *testing.T) {
+	tests := []struct {
+		name    string
+		feeRate float64 // negative keeps the default
+		want    float64
+	}{
+		{"default rate", -1, 1040},
+		{"configured rate", 0.05, 1100},
+		{"fee waived", 0, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 0)
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+			svc.SetPixCreditFeeRate(tt.feeRate)
+
+			_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+				IdempotencyKey:         "idem-1",
+				SourceAccountID:        testAccountID,
+				DestinationKeyValue:    "fornecedor@example.com",
+				Amount:                 1000,
+				FundedBy:               "credit_card",
+				CreditCardID:           testCardID,
+				CreditCardInstallments: 3,
+				TotalWithFees:          1, // client-sent totals are ignored
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := store.cards[testCardID].UsedLimit; got != tt.want {
+				t.Errorf("card used limit = %v, want %v", got, tt.want)
+			}
+			if got := svc.GetPixFees().CreditCardFeeRate; tt.feeRate >= 0 && got != tt.feeRate {
+				t.Errorf("published fee rate = %v, want %v", got, tt.feeRate)
+			}
+		})
+	}
+}
+
+func TestQuotePixCreditCard_MatchesWhatTheTransferCharges(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	quote, err := svc.QuotePixCreditCard(context.Background(), testCustomerID, &domain.PixCreditCardQuoteRequest{
+		CreditCardID: testCardID,
+		Amount:       1000,
+		Installments: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !quote.CanProceed {
+		t.Fatalf("canProceed = false (%s), want true", quote.Reason)
+	}
+	if quote.FeeAmount != 40 || quote.TotalWithFees != 1040 || quote.InstallmentValue != 346.67 {
+		t.Errorf("quote = fee %v, total %v, installment %v, want 40, 1040, 346.67", quote.FeeAmount, quote.TotalWithFees, quote.InstallmentValue)
+	}
+	if len(quote.Schedule) != 3 {
+		t.Fatalf("schedule has %d installments, want 3", len(quote.Schedule))
+	}
+	if sum := quote.Schedule[0].Amount + quote.Schedule[1].Amount + quote.Schedule[2].Amount; math.Round(sum*100)/100 != quote.TotalWithFees {
+		t.Errorf("schedule adds up to %v, want the total %v", sum, quote.TotalWithFees)
+	}
+	if got := store.cards[testCardID].UsedLimit; got != 0 {
+		t.Fatalf("quote used %v of the card limit, want nothing", got)
+	}
+
+	_, err = svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:         "idem-1",
+		SourceAccountID:        testAccountID,
+		DestinationKeyValue:    "fornecedor@example.com",
+		Amount:                 1000,
+		FundedBy:               "credit_card",
+		CreditCardID:           testCardID,
+		CreditCardInstallments: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.cards[testCardID].UsedLimit; got != quote.TotalWithFees {
+		t.Errorf("transfer charged %v, quote said %v", got, quote.TotalWithFees)
+	}
+	if got := store.cardTxs[0]["installment_amount"]; got != quote.InstallmentValue {
+		t.Errorf("fatura installment = %v, quote said %v", got, quote.InstallmentValue)
+	}
+}
+
+func TestQuotePixCreditCard_DisabledCardCannotProceed(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	store.cards[testCardID].PixCreditEnabled = false
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	quote, err := svc.QuotePixCreditCard(context.Background(), testCustomerID, &domain.PixCreditCardQuoteRequest{
+		CreditCardID: testCardID,
+		Amount:       500,
+		Installments: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.CanProceed || quote.ReasonCode != "validation" {
+		t.Errorf("quote = canProceed %v, reason code %q, want false, validation", quote.CanProceed, quote.ReasonCode)
+	}
+	if quote.TotalWithFees != 510 {
+		t.Errorf("total = %v, want the breakdown even when refused (510)", quote.TotalWithFees)
+	}
+}
+
+func TestCreatePixTransfer_ScheduledForIsNotDebitedImmediately(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+		ScheduledFor:        time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.Status != "scheduled" {
+		t.Errorf("status = %q, want scheduled", transfer.Status)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance = %v, want 1000 until the scheduled date", got)
+	}
+	if len(store.transactions) != 0 || len(store.pixReceipts) != 0 {
+		t.Errorf("got %d statement entries and %d receipts, want none", len(store.transactions), len(store.pixReceipts))
+	}
+
+	// Not due yet: the worker leaves it alone.
+	if err := svc.ExecuteDuePixTransfers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.pixTransfers[0].Status != "scheduled" {
+		t.Fatalf("status = %q before the due date, want scheduled", store.pixTransfers[0].Status)
+	}
+
+	due := time.Now().Add(-time.Minute)
+	store.pixTransfers[0].ScheduledFor = &due
+	if err := svc.ExecuteDuePixTransfers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.pixTransfers[0].Status != "completed" {
+		t.Errorf("status = %q once due, want completed", store.pixTransfers[0].Status)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 900 {
+		t.Errorf("balance = %v after execution, want 900", got)
+	}
+}
+
+func TestExecuteDuePixTransfers_FailsStaleClaims(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	due := time.Now().Add(-time.Hour)
+	store.pixTransfers = append(store.pixTransfers,
+		domain.PixTransfer{
+			ID: "pix-stale", SourceCustomerID: testCustomerID, Amount: 100, Status: "processing",
+			ScheduledFor: &due, UpdatedAt: time.Now().Add(-30 * time.Minute),
+		},
+		domain.PixTransfer{
+			ID: "pix-running", SourceCustomerID: testCustomerID, Amount: 100, Status: "processing",
+			ScheduledFor: &due, UpdatedAt: time.Now().Add(-time.Minute),
+		},
+	)
+
+	if err := svc.ExecuteDuePixTransfers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := store.pixTransfers[0]; got.Status != "failed" || got.FailureReason != "processing_timeout" {
+		t.Errorf("stale claim = %q (%q), want failed (processing_timeout)", got.Status, got.FailureReason)
+	}
+	if got := store.pixTransfers[1].Status; got != "processing" {
+		t.Errorf("recent claim = %q, want processing while its worker may still run", got)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance = %v, want 1000: a stale claim is never executed again", got)
+	}
+}
+
+func TestCreatePixTransfer_RejectsInvalidScheduledFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheduledFor string
+		fundedBy     string
+		cardID       string
+	}{
+		{"past date", time.Now().Add(-time.Hour).Format(time.RFC3339), "", ""},
+		{"date only", time.Now().AddDate(0, 0, 2).Format("2006-01-02"), "", ""},
+		{"credit card funding", time.Now().Add(time.Hour).Format(time.RFC3339), "credit_card", testCardID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 1000)
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+				IdempotencyKey:      "idem-1",
+				SourceAccountID:     testAccountID,
+				DestinationKeyValue: "fornecedor@example.com",
+				Amount:              100,
+				FundedBy:            tt.fundedBy,
+				CreditCardID:        tt.cardID,
+				ScheduledFor:        tt.scheduledFor,
+			})
+			var validation *domain.ErrValidation
+			if !errors.As(err, &validation) || validation.Field != "scheduledFor" {
+				t.Fatalf("expected ErrValidation on scheduledFor, got %v", err)
+			}
+			if len(store.pixTransfers) != 0 {
+				t.Errorf("expected no transfer persisted, got %d", len(store.pixTransfers))
+			}
+		})
+	}
+}
+
+var (
+	spanExporterOnce sync.Once
+	spanExporter     *tracetest.InMemoryExporter
+)
+
+// installSpanExporter routes the global tracer provider to an in-memory
+// exporter. The global provider can only be delegated once per process, so
+// the exporter is shared and reset for each test.
+func installSpanExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	spanExporterOnce.Do(func() {
+		spanExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+	spanExporter.Reset()
+	return spanExporter
+}
+
+func findSpan(t *testing.T, exp *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range exp.GetSpans() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not recorded", name)
+	return tracetest.SpanStub{}
+}
+
+func spanAttrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(s.Attributes))
+	for _, kv := range s.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestCreatePixTransfer_SpanAttributes(t *testing.T) {
+	exp := installSpanExporter(t)
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              250,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	span := findSpan(t, exp, "BankingService.CreatePixTransfer")
+	attrs := spanAttrs(span)
+	if got := attrs["amount"].AsFloat64(); got != 250 {
+		t.Errorf("amount = %v, want 250", got)
+	}
+	if got := attrs["funded_by"].AsString(); got != "balance" {
+		t.Errorf("funded_by = %q, want balance", got)
+	}
+	if got := attrs["destination_key_type"].AsString(); got != "email" {
+		t.Errorf("destination_key_type = %q, want email", got)
+	}
+	if got := attrs["result"].AsString(); got != "success" {
+		t.Errorf("result = %q, want success", got)
+	}
+	if _, ok := attrs["error_code"]; ok {
+		t.Error("error_code set on a successful transfer")
+	}
+	for _, v := range attrs {
+		if v.Type() == attribute.STRING && v.AsString() == "fornecedor@example.com" {
+			t.Error("destination key value leaked into span attributes")
+		}
+	}
+}
+
+func TestCreatePixTransfer_SpanRecordsError(t *testing.T) {
+	exp := installSpanExporter(t)
+	store := newFakeBankingStore()
+	seedCustomer(store, 100)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              500,
+	})
+	if err == nil {
+		t.Fatal("expected insufficient funds error")
+	}
+
+	span := findSpan(t, exp, "BankingService.CreatePixTransfer")
+	attrs := spanAttrs(span)
+	if got := attrs["result"].AsString(); got != "error" {
+		t.Errorf("result = %q, want error", got)
+	}
+	if got := attrs["error_code"].AsString(); got != "insufficient_funds" {
+		t.Errorf("error_code = %q, want insufficient_funds", got)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want Error", span.Status.Code)
+	}
+	if len(span.Events) == 0 || span.Events[0].Name != "exception" {
+		t.Errorf("expected a recorded exception event, got %+v", span.Events)
+	}
+}
+
+func TestCreatePixTransfer_RiskWarnings(t *testing.T) {
+	const key = "fornecedor@example.com"
+
+	tests := []struct {
+		name         string
+		amount       float64
+		seed         func(store *fakeBankingStore)
+		wantWarnings []string
+	}{
+		{
+			name:         "new recipient, high value",
+			amount:       8000,
+			wantWarnings: []string{domain.PixWarningNewRecipientHighValue},
+		},
+		{
+			name:   "trusted favorite, high value",
+			amount: 8000,
+			seed: func(store *fakeBankingStore) {
+				store.favorites = append(store.favorites, domain.Favorite{
+					ID: "fav-1", CustomerID: testCustomerID, DestinationType: "pix", PixKeyValue: key, RecipientName: "Fornecedor",
+				})
+			},
+		},
+		{
+			name:   "previously paid recipient, high value",
+			amount: 8000,
+			seed: func(store *fakeBankingStore) {
+				store.pixTransfers = append(store.pixTransfers, domain.PixTransfer{
+					ID: "pix-old", SourceCustomerID: testCustomerID, DestinationKeyValue: key, Amount: 300, Status: "completed",
+				})
+			},
+		},
+		{
+			name:   "new recipient, below threshold",
+			amount: 4000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 20000)
+			if tt.seed != nil {
+				tt.seed(store)
+			}
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+				IdempotencyKey:      "idem-1",
+				SourceAccountID:     testAccountID,
+				DestinationKeyValue: key,
+				Amount:              tt.amount,
+			})
+			if err != nil {
+				t.Fatalf("a flagged transfer must still succeed: %v", err)
+			}
+			if transfer.Status != "completed" {
+				t.Errorf("status = %q, want completed", transfer.Status)
+			}
+			if len(transfer.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %v, want %v", transfer.Warnings, tt.wantWarnings)
+			}
+			for i := range tt.wantWarnings {
+				if transfer.Warnings[i] != tt.wantWarnings[i] {
+					t.Errorf("warnings[%d] = %q, want %q", i, transfer.Warnings[i], tt.wantWarnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreatePixTransfer_ConfirmationRequired(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 20000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetPixConfirmation(5000, time.Minute)
+
+	// Each amount is its own transfer; the confirmation steps of one share its key
+	newReq := func(amount float64, token string) *domain.PixTransferRequest {
+		return &domain.PixTransferRequest{
+			IdempotencyKey:      fmt.Sprintf("idem-%.0f", amount),
+			SourceAccountID:     testAccountID,
+			DestinationKeyValue: "fornecedor@example.com",
+			Amount:              amount,
+			ConfirmationToken:   token,
+		}
+	}
+
+	// Below the threshold the transfer runs straight away
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq(1000, "")); err != nil {
+		t.Fatalf("low-value transfer: unexpected error: %v", err)
+	}
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq(8000, ""))
+	var confirm *domain.ErrConfirmationRequired
+	if !errors.As(err, &confirm) {
+		t.Fatalf("expected ErrConfirmationRequired, got %v", err)
+	}
+	c := confirm.Confirmation
+	if c.ConfirmationToken == "" || c.ExpiresAt == "" {
+		t.Fatalf("confirmation without token or expiry: %+v", c)
+	}
+	if c.Amount != 8000 || c.TotalWithFees != 8000 || c.FundedBy != "balance" {
+		t.Errorf("confirmation = %+v, want amount/total 8000 funded by balance", c)
+	}
+	if c.Recipient == nil || c.Recipient.PixKey == nil || c.Recipient.PixKey.Value != "fornecedor@example.com" {
+		t.Errorf("recipient = %+v, want the destination key", c.Recipient)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 19000 {
+		t.Fatalf("balance = %v, want 19000 (nothing debited before confirmation)", got)
+	}
+
+	// A token is bound to the transfer it was issued for
+	var validation *domain.ErrValidation
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq(9000, c.ConfirmationToken)); !errors.As(err, &validation) {
+		t.Fatalf("token with another amount: expected ErrValidation, got %v", err)
+	}
+
+	_, err = svc.CreatePixTransfer(context.Background(), testCustomerID, newReq(8000, ""))
+	if !errors.As(err, &confirm) {
+		t.Fatalf("expected ErrConfirmationRequired, got %v", err)
+	}
+	token := confirm.Confirmation.ConfirmationToken
+
+	transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq(8000, token))
+	if err != nil {
+		t.Fatalf("confirmed transfer: unexpected error: %v", err)
+	}
+	if transfer.Status != "completed" {
+		t.Errorf("status = %q, want completed", transfer.Status)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 11000 {
+		t.Errorf("balance = %v, want 11000", got)
+	}
+
+	// Under the same key the request replays the transfer; a new one must
+	// not get through with the spent token
+	reuse := newReq(8000, token)
+	reuse.IdempotencyKey = "idem-8000-again"
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, reuse); !errors.As(err, &validation) {
+		t.Errorf("reused token: expected ErrValidation, got %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 11000 {
+		t.Errorf("balance = %v after reuse, want 11000", got)
+	}
+}
+
+func TestCreatePixTransfer_FailedTransferKeepsConfirmationToken(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 20000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetPixConfirmation(5000, time.Minute)
+
+	newReq := func(key, token string) *domain.PixTransferRequest {
+		return &domain.PixTransferRequest{
+			IdempotencyKey:      key,
+			SourceAccountID:     testAccountID,
+			DestinationKeyValue: "fornecedor@example.com",
+			Amount:              8000,
+			ConfirmationToken:   token,
+		}
+	}
+
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq("idem-1", ""))
+	var confirm *domain.ErrConfirmationRequired
+	if !errors.As(err, &confirm) {
+		t.Fatalf("expected ErrConfirmationRequired, got %v", err)
+	}
+	token := confirm.Confirmation.ConfirmationToken
+
+	// The transfer fails after the token was claimed: the token is given back
+	store.insertTxErr = errors.New("connection reset")
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq("idem-1", token)); err == nil {
+		t.Fatal("expected the transfer to fail")
+	}
+	store.insertTxErr = nil
+
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq("idem-2", token)); err != nil {
+		t.Fatalf("retry with the same token: unexpected error: %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 12000 {
+		t.Errorf("balance = %v, want 12000", got)
+	}
+
+	// Once the transfer committed the token is used up
+	var validation *domain.ErrValidation
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, newReq("idem-3", token)); !errors.As(err, &validation) {
+		t.Errorf("used token: expected ErrValidation, got %v", err)
+	}
+}
+
+func TestCreatePixTransfer_ConfirmationTokenExpires(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 20000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetPixConfirmation(5000, 20*time.Millisecond)
+
+	req := domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              8000,
+	}
+	first := req
+	_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &first)
+	var confirm *domain.ErrConfirmationRequired
+	if !errors.As(err, &confirm) {
+		t.Fatalf("expected ErrConfirmationRequired, got %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	confirmed := req
+	confirmed.ConfirmationToken = confirm.Confirmation.ConfirmationToken
+	var validation *domain.ErrValidation
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &confirmed); !errors.As(err, &validation) {
+		t.Fatalf("expired token: expected ErrValidation, got %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 20000 {
+		t.Errorf("balance = %v, want 20000", got)
+	}
+}
+
+func TestCreatePixTransfer_StatementEntryFailureLeavesNoDebit(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(store *fakeBankingStore)
+		wantStatus string // status of the recorded statement entry, "" when none
+	}{
+		{
+			name:  "insert fails",
+			setup: func(store *fakeBankingStore) { store.insertTxErr = errors.New("connection reset") },
+		},
+		{
+			name:       "confirmation fails",
+			setup:      func(store *fakeBankingStore) { store.confirmTxErr = errors.New("connection reset") },
+			wantStatus: "failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeBankingStore()
+			seedCustomer(store, 1000)
+			tt.setup(store)
+			svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+			_, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+				IdempotencyKey:      "idem-1",
+				SourceAccountID:     testAccountID,
+				DestinationKeyValue: "fornecedor@example.com",
+				Amount:              250,
+			})
+			if err == nil {
+				t.Fatal("expected the transfer to fail")
+			}
+
+			if got := store.accounts[testCustomerID].Balance; got != 1000 {
+				t.Errorf("balance = %v, want 1000 (no untracked debit)", got)
+			}
+			if len(store.pixTransfers) != 1 || store.pixTransfers[0].Status != "failed" {
+				t.Errorf("pix transfers = %+v, want one failed transfer", store.pixTransfers)
+			}
+			if len(store.pixReceipts) != 0 {
+				t.Errorf("expected no receipt for a failed transfer, got %d", len(store.pixReceipts))
+			}
+
+			var statuses []any
+			for _, tx := range store.transactions {
+				statuses = append(statuses, tx["status"])
+			}
+			switch {
+			case tt.wantStatus == "" && len(statuses) != 0:
+				t.Errorf("statement entries = %v, want none", statuses)
+			case tt.wantStatus != "" && (len(statuses) != 1 || statuses[0] != tt.wantStatus):
+				t.Errorf("statement entries = %v, want one %q entry", statuses, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCreatePixTransfer_ConfirmsStatementEntry(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              250,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := store.accounts[testCustomerID].Balance; got != 750 {
+		t.Errorf("balance = %v, want 750", got)
+	}
+	if len(store.transactions) != 1 || store.transactions[0]["status"] != "confirmed" {
+		t.Errorf("statement entries = %v, want one confirmed entry", store.transactions)
+	}
+}
+
+func TestValidatePixTransfer_InsufficientFundsWithoutDebit(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 100)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	result, err := svc.ValidatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              250,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CanProceed || result.ReasonCode != "insufficient_funds" || result.Reason == "" {
+		t.Errorf("result = canProceed %v, reason %q (%s); want blocked by insufficient_funds", result.CanProceed, result.Reason, result.ReasonCode)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 100 {
+		t.Errorf("balance = %v, want 100 untouched", got)
+	}
+	if len(store.pixTransfers) != 0 || len(store.transactions) != 0 {
+		t.Errorf("dry run left %d transfers and %d statement entries, want none", len(store.pixTransfers), len(store.transactions))
+	}
+}
+
+func TestValidatePixTransfer_ComputesCardFees(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	result, err := svc.ValidatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:         "idem-1",
+		SourceAccountID:        testAccountID,
+		DestinationKeyValue:    "fornecedor@example.com",
+		Amount:                 1000,
+		FundedBy:               "credit_card",
+		CreditCardID:           testCardID,
+		CreditCardInstallments: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.CanProceed || result.Fees != 40 || result.TotalWithFees != 1040 {
+		t.Errorf("result = canProceed %v, fees %v, total %v; want true, 40, 1040", result.CanProceed, result.Fees, result.TotalWithFees)
+	}
+	if result.Recipient == nil || result.Recipient.PixKey.Type != "email" {
+		t.Errorf("recipient = %+v, want the detected email key", result.Recipient)
+	}
+	if got := store.cards[testCardID].UsedLimit; got != 0 {
+		t.Errorf("card used limit = %v, want 0 untouched", got)
+	}
+}
+
+func TestListPixTransfersPage_FiltersByStatusAndPages(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 0)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		status := "completed"
+		if i == 2 {
+			status = "failed"
+		}
+		store.pixTransfers = append(store.pixTransfers, domain.PixTransfer{
+			ID:               fmt.Sprintf("pix-%d", i+1),
+			SourceCustomerID: testCustomerID,
+			Amount:           float64(100 * (i + 1)),
+			Status:           status,
+			CreatedAt:        start.AddDate(0, 0, i),
+		})
+	}
+
+	first, err := svc.ListPixTransfersPage(context.Background(), testCustomerID, &domain.PixTransferFilter{Status: "completed", Page: 1, PageSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Total != 4 || !first.HasMore || len(first.Data) != 3 {
+		t.Fatalf("first page = %d of %d (has_more %v), want 3 of 4 with more", len(first.Data), first.Total, first.HasMore)
+	}
+	if first.Data[0].ID != "pix-5" {
+		t.Errorf("first item = %s, want the newest transfer pix-5", first.Data[0].ID)
+	}
+
+	second, err := svc.ListPixTransfersPage(context.Background(), testCustomerID, &domain.PixTransferFilter{Status: "completed", Page: 2, PageSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(second.Data) != 1 || second.Data[0].ID != "pix-1" || second.HasMore {
+		t.Errorf("second page = %+v, want only pix-1 and no more", second.Data)
+	}
+
+	failed, err := svc.ListPixTransfersPage(context.Background(), testCustomerID, &domain.PixTransferFilter{Status: "failed", Page: 1, PageSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failed.Total != 1 || failed.Data[0].ID != "pix-3" {
+		t.Errorf("failed = %+v, want only pix-3", failed.Data)
+	}
+
+	ranged, err := svc.ListPixTransfersPage(context.Background(), testCustomerID, &domain.PixTransferFilter{From: "2026-03-02", To: "2026-03-03", Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ranged.Total != 2 {
+		t.Errorf("transfers from 03-02 to 03-03 = %d, want 2 (to is inclusive)", ranged.Total)
+	}
+}
+
+func TestListPixTransfersPage_RejectsUnknownStatus(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.ListPixTransfersPage(context.Background(), testCustomerID, &domain.PixTransferFilter{Status: "done", Page: 1, PageSize: 10})
+	var verr *domain.ErrValidation
+	if !errors.As(err, &verr) || verr.Field != "status" {
+		t.Errorf("err = %v, want ErrValidation on status", err)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Reconciliation — stored balance vs. statement
+ *
+ * Balance updates and statement entries are separate writes, and some
+ * follow-up steps (receipts, card limits) only log their failures. The
+ * report recomputes the balance from the confirmed statement entries and
+ * lists completed PIX transfers missing their statement entry or receipt,
+ * so operators can spot drift.
+ */
+
+// Reconciliation reasons reported for a transfer.
+const (
+	ReconcileMissingStatementEntry = "missing_statement_entry"
+	ReconcileMissingReceipt        = "missing_receipt"
+)
+
+// reconcileSince is earlier than any statement entry, including the
+// back-dated ones DevGenerateTransactions creates.
+const reconcileSince = "2000-01-01"
+
+const reconcilePageSize = 100
+
+// ReconcileCustomer compares the primary account balance with
+// openingBalance plus the sum of the customer's confirmed statement entries.
+func (s *BankingService) ReconcileCustomer(ctx context.Context, customerID string, openingBalance float64) (*domain.ReconciliationReport, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ReconcileCustomer")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	account, err := s.store.GetPrimaryAccount(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	txns, err := s.store.ListTransactions(ctx, customerID, reconcileSince, until)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalCents int64
+	sentCents := make(map[int64]int) // unmatched pix_sent entries by amount
+	for _, tx := range txns {
+		c := toCents(tx.Amount)
+		totalCents += c
+		if tx.Type == "pix_sent" {
+			sentCents[-c]++
+		}
+	}
+
+	report := &domain.ReconciliationReport{
+		CustomerID:          customerID,
+		AccountID:           account.ID,
+		OpeningBalance:      openingBalance,
+		TransactionsTotal:   fromCents(totalCents),
+		TransactionCount:    len(txns),
+		ExpectedBalance:     fromCents(toCents(openingBalance) + totalCents),
+		StoredBalance:       account.Balance,
+		SuspiciousTransfers: []domain.SuspiciousTransfer{},
+	}
+	report.Delta = fromCents(toCents(account.Balance) - toCents(report.ExpectedBalance))
+	report.Balanced = report.Delta == 0
+
+	for page := 1; ; page++ {
+		transfers, err := s.store.ListPixTransfers(ctx, customerID, page, reconcilePageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range transfers {
+			if t.Status != "completed" {
+				continue
+			}
+			var reasons []string
+			if t.FundedBy != "credit_card" {
+				if c := toCents(t.Amount); sentCents[c] > 0 {
+					sentCents[c]--
+				} else {
+					reasons = append(reasons, ReconcileMissingStatementEntry)
+				}
+			}
+			if _, err := s.store.GetPixReceiptByTransferID(ctx, t.ID); err != nil {
+				var notFound *domain.ErrNotFound
+				if !errors.As(err, &notFound) {
+					return nil, err
+				}
+				reasons = append(reasons, ReconcileMissingReceipt)
+			}
+			if len(reasons) > 0 {
+				report.SuspiciousTransfers = append(report.SuspiciousTransfers, domain.SuspiciousTransfer{
+					TransferID: t.ID,
+					Amount:     t.Amount,
+					FundedBy:   t.FundedBy,
+					CreatedAt:  t.CreatedAt,
+					Reasons:    reasons,
+				})
+			}
+		}
+		if len(transfers) < reconcilePageSize {
+			break
+		}
+	}
+
+	if !report.Balanced || len(report.SuspiciousTransfers) > 0 {
+		s.logger.Warn("reconciliation found drift",
+			zap.String("customer_id", customerID),
+			zap.Float64("delta", report.Delta),
+			zap.Int("suspicious_transfers", len(report.SuspiciousTransfers)),
+		)
+	}
+	return report, nil
+}
+
+func toCents(amount float64) int64 { return int64(math.Round(amount * 100)) }
+
+func fromCents(cents int64) float64 { return float64(cents) / 100 }
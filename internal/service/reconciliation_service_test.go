@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestReconcileCustomer_FlagsOrphanDebit(t *testing.T) {
+	store := newFakeBankingStore()
+	// Opened with 1000; a 200 PIX left its statement entry and receipt, a
+	// 100 PIX only moved the balance.
+	seedCustomer(store, 700)
+	now := time.Now()
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: now, Amount: -200, Type: "pix_sent"},
+	}
+	store.pixTransfers = []domain.PixTransfer{
+		{ID: "pix-1", SourceCustomerID: testCustomerID, Amount: 200, Status: "completed", FundedBy: "balance"},
+		{ID: "pix-2", SourceCustomerID: testCustomerID, Amount: 100, Status: "completed", FundedBy: "balance"},
+		{ID: "pix-3", SourceCustomerID: testCustomerID, Amount: 50, Status: "failed", FundedBy: "balance"},
+	}
+	store.pixReceipts = []domain.PixReceipt{{ID: "rcpt-1", TransferID: "pix-1", CustomerID: testCustomerID}}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	report, err := svc.ReconcileCustomer(context.Background(), testCustomerID, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.ExpectedBalance != 800 || report.StoredBalance != 700 || report.Delta != -100 || report.Balanced {
+		t.Errorf("report = expected %v, stored %v, delta %v, balanced %v; want 800, 700, -100, false",
+			report.ExpectedBalance, report.StoredBalance, report.Delta, report.Balanced)
+	}
+	if len(report.SuspiciousTransfers) != 1 {
+		t.Fatalf("suspicious transfers = %+v, want only pix-2", report.SuspiciousTransfers)
+	}
+	got := report.SuspiciousTransfers[0]
+	if got.TransferID != "pix-2" || len(got.Reasons) != 2 ||
+		got.Reasons[0] != service.ReconcileMissingStatementEntry || got.Reasons[1] != service.ReconcileMissingReceipt {
+		t.Errorf("suspicious transfer = %+v, want pix-2 missing its statement entry and receipt", got)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import "time"
+
+/*
+ * Scheduled transfers — occurrence calendar
+ *
+ * A schedule keeps its nominal dates (the same weekday, or the same day of
+ * the month clamped to the month's last day) and each one executes on the
+ * first business day on or after it. Business days exclude weekends and the
+ * national bank holidays, including the ones that move with Easter.
+ */
+
+// scheduleTypes are the recurrences a scheduled transfer accepts.
+var scheduleTypes = map[string]bool{"once": true, "daily": true, "weekly": true, "biweekly": true, "monthly": true}
+
+// maxScheduleOccurrences bounds open-ended schedules when listing their
+// occurrences.
+const maxScheduleOccurrences = 120
+
+// computeOccurrences returns the execution dates of a schedule starting at
+// first. until (inclusive, zero for none) ends the schedule by nominal date
+// and maxCount (<= 0 for none) caps the number of executions; at most
+// maxScheduleOccurrences dates are returned either way.
+func computeOccurrences(first time.Time, scheduleType string, until time.Time, maxCount int) []time.Time {
+	limit := maxScheduleOccurrences
+	if maxCount > 0 && maxCount < limit {
+		limit = maxCount
+	}
+
+	var dates []time.Time
+	for i := 0; len(dates) < limit; i++ {
+		nominal := nthOccurrence(first, scheduleType, i)
+		if !until.IsZero() && nominal.After(until) {
+			break
+		}
+		date := nextBusinessDay(nominal)
+		// Daily schedules roll weekends onto Monday; execute it once.
+		if len(dates) == 0 || date.After(dates[len(dates)-1]) {
+			dates = append(dates, date)
+		}
+		if scheduleType == "once" {
+			break
+		}
+	}
+	return dates
+}
+
+// occurrenceAfter returns the first execution date of a schedule later than
+// date, or false when the schedule has none (a one-off schedule, or until —
+// inclusive, zero for none — comes first).
+func occurrenceAfter(first time.Time, scheduleType string, until, date time.Time) (time.Time, bool) {
+	if scheduleType == "once" {
+		return time.Time{}, false
+	}
+	for i := 1; ; i++ {
+		nominal := nthOccurrence(first, scheduleType, i)
+		if !until.IsZero() && nominal.After(until) {
+			return time.Time{}, false
+		}
+		if next := nextBusinessDay(nominal); next.After(date) {
+			return next, true
+		}
+	}
+}
+
+// nthOccurrence returns the i-th nominal date (0-based) of a schedule.
+func nthOccurrence(first time.Time, scheduleType string, i int) time.Time {
+	switch scheduleType {
+	case "daily":
+		return first.AddDate(0, 0, i)
+	case "weekly":
+		return first.AddDate(0, 0, 7*i)
+	case "biweekly":
+		return first.AddDate(0, 0, 14*i)
+	case "monthly":
+		// AddDate normalizes Jan 31 + 1 month to Mar 3; clamp to the month's
+		// last day instead, keeping the original day for later months.
+		month := time.Date(first.Year(), first.Month()+time.Month(i), 1, 0, 0, 0, 0, first.Location())
+		day := first.Day()
+		if last := month.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return month.AddDate(0, 0, day-1)
+	default:
+		return first
+	}
+}
+
+// nextBusinessDay returns date itself when it is a business day, or the
+// first business day after it.
+func nextBusinessDay(date time.Time) time.Time {
+	for !isBusinessDay(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+func isBusinessDay(date time.Time) bool {
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !isBankHoliday(date)
+}
+
+// fixedBankHolidays are the national holidays on a fixed month and day.
+var fixedBankHolidays = map[[2]int]bool{
+	{1, 1}:   true, // Confraternização Universal
+	{4, 21}:  true, // Tiradentes
+	{5, 1}:   true, // Dia do Trabalho
+	{9, 7}:   true, // Independência
+	{10, 12}: true, // Nossa Senhora Aparecida
+	{11, 2}:  true, // Finados
+	{11, 15}: true, // Proclamação da República
+	{11, 20}: true, // Consciência Negra
+	{12, 25}: true, // Natal
+}
+
+func isBankHoliday(date time.Time) bool {
+	if fixedBankHolidays[[2]int{int(date.Month()), date.Day()}] {
+		return true
+	}
+	easter := easterSunday(date.Year(), date.Location())
+	for _, offset := range []int{-48, -47, -2, 60} { // Carnaval (Mon, Tue), Sexta-feira Santa, Corpus Christi
+		if d := easter.AddDate(0, 0, offset); d.Month() == date.Month() && d.Day() == date.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// easterSunday computes the Gregorian Easter date (anonymous algorithm).
+func easterSunday(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
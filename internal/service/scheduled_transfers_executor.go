@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Scheduled transfers — execution
+ *
+ * next_execution_date is the single source of what runs next: skipping an
+ * occurrence moves it one period without running it, and this worker runs
+ * the occurrence it points at once due, then moves it the same way. Each
+ * move is conditional on the date it started from, so an occurrence runs
+ * (or is skipped) once even with several instances.
+ *
+ * An occurrence does not move money here: it is queued as a scheduled PIX
+ * transfer with the idempotency key schedule:<id>:<date>, which the
+ * scheduled PIX worker claims and executes with the usual limit and funding
+ * checks, crediting the recipient and saving the receipts. A failed
+ * occurrence fails that transfer only; the series goes on.
+ */
+
+// dueScheduledTransferBatch bounds how many schedules one worker iteration runs.
+const dueScheduledTransferBatch = 50
+
+// ExecuteDueScheduledTransfers queues the occurrences due today, in the
+// bank's time zone.
+func (s *BankingService) ExecuteDueScheduledTransfers(ctx context.Context) error {
+	return s.ExecuteScheduledTransfersOn(ctx, time.Now().In(bankLocation))
+}
+
+// ExecuteScheduledTransfersOn queues the current occurrence of every schedule
+// due on or before day. Only the latest due occurrence of a schedule runs:
+// older ones, left behind while the worker was down, are passed over rather
+// than paid all at once.
+func (s *BankingService) ExecuteScheduledTransfersOn(ctx context.Context, day time.Time) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ExecuteScheduledTransfersOn")
+	defer span.End()
+
+	today := day.Format("2006-01-02")
+	due, err := s.store.ListDueScheduledTransfers(ctx, today, dueScheduledTransferBatch)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("due_count", len(due)))
+
+	for i := range due {
+		transfer := &due[i]
+		occurrence := transfer.NextExecutionDate
+		if transfer.DestinationKeyValue == "" {
+			s.logger.Error("scheduled transfer has no PIX key to pay, failing it",
+				zap.String("transfer_id", transfer.ID))
+			if err := s.store.UpdateScheduledTransferStatus(ctx, transfer.ID, "failed"); err != nil {
+				s.logger.Error("failed to mark scheduled transfer as failed",
+					zap.String("transfer_id", transfer.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		run, err := advanceOccurrence(transfer, today)
+		if err != nil {
+			s.logger.Error("invalid scheduled transfer dates",
+				zap.String("transfer_id", transfer.ID), zap.Error(err))
+			continue
+		}
+		if run {
+			// Queued before the schedule moves: if the move is lost, the next
+			// iteration queues the same key again and nothing is paid twice.
+			if err := s.queueScheduledOccurrence(ctx, transfer, occurrence); err != nil {
+				s.logger.Error("failed to queue scheduled transfer occurrence",
+					zap.String("transfer_id", transfer.ID),
+					zap.String("occurrence", occurrence),
+					zap.Error(err))
+				continue
+			}
+		} else {
+			s.logger.Warn("scheduled transfer occurrence missed",
+				zap.String("customer_id", transfer.SourceCustomerID),
+				zap.String("transfer_id", transfer.ID),
+				zap.String("occurrence", occurrence))
+		}
+
+		if _, err := s.store.AdvanceScheduledTransfer(ctx, transfer, occurrence); err != nil {
+			s.logger.Error("failed to advance scheduled transfer",
+				zap.String("transfer_id", transfer.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// queueScheduledOccurrence creates the scheduled PIX transfer that pays one
+// occurrence of transfer, due now. An occurrence already queued is left as is.
+func (s *BankingService) queueScheduledOccurrence(ctx context.Context, transfer *domain.ScheduledTransfer, occurrence string) error {
+	customerID := transfer.SourceCustomerID
+	req := &domain.PixTransferRequest{
+		IdempotencyKey:      fmt.Sprintf("schedule:%s:%s", transfer.ID, occurrence),
+		SourceAccountID:     transfer.SourceAccountID,
+		DestinationKeyType:  transfer.DestinationKeyType,
+		DestinationKeyValue: transfer.DestinationKeyValue,
+		DestinationName:     transfer.DestinationName,
+		DestinationDocument: transfer.DestinationDocument,
+		Amount:              transfer.Amount,
+		Description:         transfer.Description,
+		FundedBy:            "balance",
+		ScheduledFor:        time.Now().UTC().Format(time.RFC3339),
+	}
+
+	s.seedTransactionLimit(ctx, customerID, "pix")
+	if _, err := s.store.CreatePixTransfer(ctx, customerID, req); err != nil {
+		var duplicate *domain.ErrDuplicate
+		if errors.As(err, &duplicate) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// advanceOccurrence moves transfer past its current occurrence and reports
+// whether that occurrence runs: it does unless the following one is also due
+// on today. A run counts towards max_recurrences. A schedule with no
+// occurrence left is completed.
+func advanceOccurrence(transfer *domain.ScheduledTransfer, today string) (bool, error) {
+	next, ok, err := nextOccurrence(transfer)
+	if err != nil {
+		return false, err
+	}
+
+	run := !ok || next.Format("2006-01-02") > today
+	if run {
+		now := time.Now()
+		transfer.RecurrenceCount++
+		transfer.LastExecutedAt = &now
+	}
+	if ok && transfer.MaxRecurrences != nil && transfer.RecurrenceCount >= *transfer.MaxRecurrences {
+		ok = false
+	}
+	if !ok {
+		transfer.NextExecutionDate = ""
+		transfer.Status = "completed"
+		return run, nil
+	}
+	transfer.NextExecutionDate = next.Format("2006-01-02")
+	return run, nil
+}
@@ -21,25 +21,15 @@ func (s *BankingService) CreateScheduledTransfer(ctx context.Context, customerID
 	if req.Amount <= 0 {
 		return nil, &domain.ErrValidation{Field: "amount", Message: "must be positive"}
 	}
-	if req.ScheduledDate == "" {
-		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "required"}
-	}
 	if req.IdempotencyKey == "" {
 		return nil, &domain.ErrValidation{Field: "idempotency_key", Message: "required"}
 	}
-
-	// Validate date is in the future
-	schedDate, err := time.Parse("2006-01-02", req.ScheduledDate)
-	if err != nil {
-		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "invalid format, use YYYY-MM-DD"}
-	}
-	if schedDate.Before(time.Now().Truncate(24 * time.Hour)) {
-		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "must be today or in the future"}
+	if _, err := parseSchedule(req); err != nil {
+		return nil, err
 	}
 
 	// Check account
-	_, err = s.store.GetAccount(ctx, customerID, req.SourceAccountID)
-	if err != nil {
+	if _, err := s.store.GetAccount(ctx, customerID, req.SourceAccountID); err != nil {
 		return nil, err
 	}
 
@@ -59,6 +49,70 @@ func (s *BankingService) CreateScheduledTransfer(ctx context.Context, customerID
 	return transfer, nil
 }
 
+// PreviewScheduledTransfer lists the execution dates a schedule would have,
+// without creating it.
+func (s *BankingService) PreviewScheduledTransfer(ctx context.Context, req *domain.ScheduledTransferRequest) (*domain.SchedulePreview, error) {
+	_, span := bankTracer.Start(ctx, "BankingService.PreviewScheduledTransfer")
+	defer span.End()
+
+	dates, err := parseSchedule(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &domain.SchedulePreview{
+		ScheduleType: req.ScheduleType,
+		Occurrences:  make([]string, 0, len(dates)),
+		Truncated:    len(dates) == maxScheduleOccurrences && (req.MaxRecurrences == nil || *req.MaxRecurrences > maxScheduleOccurrences),
+	}
+	for _, d := range dates {
+		preview.Occurrences = append(preview.Occurrences, d.Format("2006-01-02"))
+	}
+	return preview, nil
+}
+
+// parseSchedule validates the schedule fields of req, defaulting an empty
+// schedule type to "once", and returns its execution dates.
+func parseSchedule(req *domain.ScheduledTransferRequest) ([]time.Time, error) {
+	if req.ScheduledDate == "" {
+		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "required"}
+	}
+	if req.ScheduleType == "" {
+		req.ScheduleType = "once"
+	}
+	if !scheduleTypes[req.ScheduleType] {
+		return nil, &domain.ErrValidation{Field: "schedule_type", Message: "must be once, daily, weekly, biweekly or monthly"}
+	}
+
+	// Validate date is in the future
+	schedDate, err := time.Parse("2006-01-02", req.ScheduledDate)
+	if err != nil {
+		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "invalid format, use YYYY-MM-DD"}
+	}
+	if schedDate.Before(time.Now().Truncate(24 * time.Hour)) {
+		return nil, &domain.ErrValidation{Field: "scheduled_date", Message: "must be today or in the future"}
+	}
+
+	var until time.Time
+	if req.RecurrenceEndDate != "" {
+		if until, err = time.Parse("2006-01-02", req.RecurrenceEndDate); err != nil {
+			return nil, &domain.ErrValidation{Field: "recurrence_end_date", Message: "invalid format, use YYYY-MM-DD"}
+		}
+		if until.Before(schedDate) {
+			return nil, &domain.ErrValidation{Field: "recurrence_end_date", Message: "must not be before scheduled_date"}
+		}
+	}
+	maxCount := 0
+	if req.MaxRecurrences != nil {
+		if *req.MaxRecurrences < 1 {
+			return nil, &domain.ErrValidation{Field: "max_recurrences", Message: "must be at least 1"}
+		}
+		maxCount = *req.MaxRecurrences
+	}
+
+	return computeOccurrences(schedDate, req.ScheduleType, until, maxCount), nil
+}
+
 func (s *BankingService) ListScheduledTransfers(ctx context.Context, customerID string) ([]domain.ScheduledTransfer, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.ListScheduledTransfers")
 	defer span.End()
@@ -66,6 +120,26 @@ func (s *BankingService) ListScheduledTransfers(ctx context.Context, customerID
 	return s.store.ListScheduledTransfers(ctx, customerID)
 }
 
+// scheduleStatuses are the statuses a scheduled transfer can have.
+var scheduleStatuses = map[string]bool{"scheduled": true, "processing": true, "paused": true, "completed": true, "failed": true, "cancelled": true}
+
+// ListScheduledTransfersPage returns one page of the customer's scheduled
+// transfers, only those with status when it is set.
+func (s *BankingService) ListScheduledTransfersPage(ctx context.Context, customerID, status string, page, pageSize int) (*domain.ListResponse[domain.ScheduledTransfer], error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ListScheduledTransfersPage")
+	defer span.End()
+
+	if status != "" && !scheduleStatuses[status] {
+		return nil, &domain.ErrValidation{Field: "status", Message: "must be scheduled, processing, paused, completed, failed or cancelled"}
+	}
+
+	rows, total, err := s.store.ListScheduledTransfersFiltered(ctx, customerID, status, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewListResponse(rows, total, page, pageSize), nil
+}
+
 func (s *BankingService) GetScheduledTransfer(ctx context.Context, customerID, transferID string) (*domain.ScheduledTransfer, error) {
 	ctx, span := bankTracer.Start(ctx, "BankingService.GetScheduledTransfer")
 	defer span.End()
@@ -102,3 +176,85 @@ func (s *BankingService) PauseScheduledTransfer(ctx context.Context, customerID,
 
 	return s.store.UpdateScheduledTransferStatus(ctx, transferID, "paused")
 }
+
+// SkipNextScheduledTransfer skips the next occurrence of a recurring
+// schedule, moving it to the following one without executing.
+func (s *BankingService) SkipNextScheduledTransfer(ctx context.Context, scheduleID string) (*domain.ScheduledTransfer, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.SkipNextScheduledTransfer")
+	defer span.End()
+
+	transfer, err := s.store.GetScheduledTransfer(ctx, "", scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != "scheduled" && transfer.Status != "paused" {
+		return nil, &domain.ErrValidation{Field: "status", Message: fmt.Sprintf("cannot skip an occurrence of a transfer with status '%s'", transfer.Status)}
+	}
+	if transfer.ScheduleType == "once" {
+		return nil, &domain.ErrValidation{Field: "schedule_type", Message: "a one-off transfer has no next occurrence to skip to, cancel it instead"}
+	}
+
+	skipped := transfer.NextExecutionDate
+	if err := skipOccurrence(transfer); err != nil {
+		return nil, err
+	}
+	if transfer.Status == "completed" {
+		return nil, &domain.ErrValidation{Field: "schedule", Message: "this is the last occurrence, cancel the transfer instead"}
+	}
+
+	saved, err := s.store.AdvanceScheduledTransfer(ctx, transfer, skipped)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, &domain.ErrConflict{Message: "the next occurrence changed concurrently, reload the schedule"}
+	}
+
+	s.logger.Info("scheduled transfer occurrence skipped",
+		zap.String("customer_id", transfer.SourceCustomerID),
+		zap.String("transfer_id", transfer.ID),
+		zap.String("skipped_date", skipped),
+		zap.String("next_execution_date", transfer.NextExecutionDate),
+	)
+	return transfer, nil
+}
+
+// skipOccurrence moves transfer past its current occurrence, recording it in
+// skipped_dates. A schedule with no occurrence left is completed.
+func skipOccurrence(transfer *domain.ScheduledTransfer) error {
+	next, ok, err := nextOccurrence(transfer)
+	if err != nil {
+		return err
+	}
+
+	transfer.SkippedDates = append(transfer.SkippedDates, transfer.NextExecutionDate)
+	if !ok {
+		transfer.NextExecutionDate = ""
+		transfer.Status = "completed"
+		return nil
+	}
+	transfer.NextExecutionDate = next.Format("2006-01-02")
+	return nil
+}
+
+// nextOccurrence returns the occurrence of transfer after its current one,
+// or false when the schedule has none left.
+func nextOccurrence(transfer *domain.ScheduledTransfer) (time.Time, bool, error) {
+	first, err := time.Parse("2006-01-02", transfer.ScheduledDate)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("scheduled_date: %w", err)
+	}
+	current, err := time.Parse("2006-01-02", transfer.NextExecutionDate)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("next_execution_date: %w", err)
+	}
+	var until time.Time
+	if transfer.RecurrenceEndDate != "" {
+		if until, err = time.Parse("2006-01-02", transfer.RecurrenceEndDate); err != nil {
+			return time.Time{}, false, fmt.Errorf("recurrence_end_date: %w", err)
+		}
+	}
+
+	next, ok := occurrenceAfter(first, transfer.ScheduleType, until, current)
+	return next, ok, nil
+}
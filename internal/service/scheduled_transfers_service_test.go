@@ -0,0 +1,241 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestPreviewScheduledTransfer_MonthlyMonthEnds(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+	maxRecurrences := 4
+
+	preview, err := svc.PreviewScheduledTransfer(context.Background(), &domain.ScheduledTransferRequest{
+		ScheduleType:   "monthly",
+		ScheduledDate:  "2030-01-31",
+		MaxRecurrences: &maxRecurrences,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Feb has no 31st; Mar 31 2030 is a Sunday and rolls to Monday.
+	want := []string{"2030-01-31", "2030-02-28", "2030-04-01", "2030-04-30"}
+	if !reflect.DeepEqual(preview.Occurrences, want) {
+		t.Errorf("occurrences = %v, want %v", preview.Occurrences, want)
+	}
+	if preview.Truncated {
+		t.Error("schedule capped by max recurrences reported as truncated")
+	}
+}
+
+func TestPreviewScheduledTransfer_SkipsHolidaysAndEndDate(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	// Weekly on Fridays; Good Friday 2030 is Apr 19.
+	preview, err := svc.PreviewScheduledTransfer(context.Background(), &domain.ScheduledTransferRequest{
+		ScheduleType:      "weekly",
+		ScheduledDate:     "2030-04-12",
+		RecurrenceEndDate: "2030-04-26",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"2030-04-12", "2030-04-22", "2030-04-26"}
+	if !reflect.DeepEqual(preview.Occurrences, want) {
+		t.Errorf("occurrences = %v, want %v", preview.Occurrences, want)
+	}
+}
+
+func TestPreviewScheduledTransfer_RejectsEndBeforeStart(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.PreviewScheduledTransfer(context.Background(), &domain.ScheduledTransferRequest{
+		ScheduleType:      "monthly",
+		ScheduledDate:     "2030-04-12",
+		RecurrenceEndDate: "2030-04-01",
+	})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "recurrence_end_date" {
+		t.Errorf("expected a recurrence_end_date validation error, got %v", err)
+	}
+}
+
+// seedSchedule stores a scheduled transfer of 100 from the test account
+// whose first occurrence is still pending.
+func seedSchedule(store *fakeBankingStore, scheduleType, firstDate string) {
+	store.schedules = append(store.schedules, domain.ScheduledTransfer{
+		ID:                  "sched-1",
+		SourceAccountID:     testAccountID,
+		SourceCustomerID:    testCustomerID,
+		DestinationName:     "Fornecedor LTDA",
+		DestinationKeyType:  "email",
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+		ScheduleType:        scheduleType,
+		ScheduledDate:       firstDate,
+		NextExecutionDate:   firstDate,
+		Status:              "scheduled",
+	})
+}
+
+// runSchedulesOn runs the scheduled transfers worker as of date, then the
+// scheduled PIX worker that pays what it queued.
+func runSchedulesOn(t *testing.T, svc *service.BankingService, date string) {
+	t.Helper()
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ExecuteScheduledTransfersOn(context.Background(), day); err != nil {
+		t.Fatalf("schedules on %s: unexpected error: %v", date, err)
+	}
+	if err := svc.ExecuteDuePixTransfers(context.Background()); err != nil {
+		t.Fatalf("PIX on %s: unexpected error: %v", date, err)
+	}
+}
+
+func TestSkipNextScheduledTransfer_WorkerRunsTheFollowingOccurrence(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedSchedule(store, "weekly", "2030-03-11") // Mondays
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.SkipNextScheduledTransfer(context.Background(), "sched-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runSchedulesOn(t, svc, "2030-03-11")
+	if len(store.pixTransfers) != 0 || store.accounts[testCustomerID].Balance != 1000 {
+		t.Fatalf("skipped date paid: %d transfers, balance %v", len(store.pixTransfers), store.accounts[testCustomerID].Balance)
+	}
+
+	runSchedulesOn(t, svc, "2030-03-18")
+	if len(store.pixTransfers) != 1 {
+		t.Fatalf("got %d PIX transfers, want the 2030-03-18 occurrence only", len(store.pixTransfers))
+	}
+	if got := store.pixTransfers[0]; got.IdempotencyKey != "schedule:sched-1:2030-03-18" || got.Status != "completed" {
+		t.Errorf("occurrence transfer = %q (%s), want schedule:sched-1:2030-03-18 completed", got.IdempotencyKey, got.Status)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 900 {
+		t.Errorf("balance = %v, want 900", got)
+	}
+	if schedule := store.schedules[0]; schedule.RecurrenceCount != 1 || schedule.NextExecutionDate != "2030-03-25" {
+		t.Errorf("after run: count %d, next %q; want 1 and 2030-03-25", schedule.RecurrenceCount, schedule.NextExecutionDate)
+	}
+
+	// A second pass on the same day finds nothing due.
+	runSchedulesOn(t, svc, "2030-03-18")
+	if len(store.pixTransfers) != 1 {
+		t.Errorf("got %d PIX transfers after a second pass, want 1", len(store.pixTransfers))
+	}
+}
+
+func TestExecuteScheduledTransfersOn_PassesOverMissedOccurrences(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedSchedule(store, "weekly", "2030-03-11")
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	// Three Mondays due at once, as after the worker was down: only the
+	// latest is paid.
+	for range 3 {
+		runSchedulesOn(t, svc, "2030-03-25")
+	}
+	if len(store.pixTransfers) != 1 || store.pixTransfers[0].IdempotencyKey != "schedule:sched-1:2030-03-25" {
+		t.Fatalf("paid %d occurrences (%v), want 2030-03-25 only", len(store.pixTransfers), store.pixTransfers)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 900 {
+		t.Errorf("balance = %v, want 900", got)
+	}
+	if schedule := store.schedules[0]; schedule.RecurrenceCount != 1 || schedule.NextExecutionDate != "2030-04-01" {
+		t.Errorf("count %d, next %q; want 1 and 2030-04-01", schedule.RecurrenceCount, schedule.NextExecutionDate)
+	}
+}
+
+func TestSkipNextScheduledTransfer_MovesToTheFollowingOccurrence(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedSchedule(store, "weekly", "2030-03-11") // Mondays
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	skipped, err := svc.SkipNextScheduledTransfer(context.Background(), "sched-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped.NextExecutionDate != "2030-03-18" || !reflect.DeepEqual(skipped.SkippedDates, []string{"2030-03-11"}) {
+		t.Fatalf("after skip: next %q, skipped %v; want 2030-03-18 and [2030-03-11]", skipped.NextExecutionDate, skipped.SkippedDates)
+	}
+
+	if _, err := svc.SkipNextScheduledTransfer(context.Background(), "sched-1"); err != nil {
+		t.Fatalf("second skip: unexpected error: %v", err)
+	}
+
+	schedule := store.schedules[0]
+	if schedule.NextExecutionDate != "2030-03-25" || !reflect.DeepEqual(schedule.SkippedDates, []string{"2030-03-11", "2030-03-18"}) {
+		t.Errorf("after two skips: next %q, skipped %v; want 2030-03-25 and both dates", schedule.NextExecutionDate, schedule.SkippedDates)
+	}
+	if schedule.RecurrenceCount != 0 || len(store.transactions) != 0 {
+		t.Errorf("skips executed something: count %d, %d statement entries", schedule.RecurrenceCount, len(store.transactions))
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 1000 {
+		t.Errorf("balance = %v, want 1000 untouched", got)
+	}
+}
+
+func TestSkipNextScheduledTransfer_RejectsOneOff(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	seedSchedule(store, "once", "2030-04-12")
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	_, err := svc.SkipNextScheduledTransfer(context.Background(), "sched-1")
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "schedule_type" {
+		t.Errorf("expected a schedule_type validation error, got %v", err)
+	}
+	if store.schedules[0].NextExecutionDate != "2030-04-12" {
+		t.Errorf("next execution date moved to %q", store.schedules[0].NextExecutionDate)
+	}
+}
+
+func TestListScheduledTransfersPage_FiltersByStatus(t *testing.T) {
+	store := newFakeBankingStore()
+	for i, status := range []string{"scheduled", "paused", "scheduled", "cancelled", "scheduled"} {
+		store.schedules = append(store.schedules, domain.ScheduledTransfer{
+			ID:               fmt.Sprintf("sched-%d", i),
+			SourceCustomerID: testCustomerID,
+			Status:           status,
+		})
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	page, err := svc.ListScheduledTransfersPage(context.Background(), testCustomerID, "scheduled", 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Total != 3 || len(page.Data) != 2 || !page.HasMore {
+		t.Errorf("got %d of %d (has_more %v), want 2 of 3 with more", len(page.Data), page.Total, page.HasMore)
+	}
+	for _, s := range page.Data {
+		if s.Status != "scheduled" {
+			t.Errorf("schedule %s has status %q, want only scheduled", s.ID, s.Status)
+		}
+	}
+
+	_, err = svc.ListScheduledTransfersPage(context.Background(), testCustomerID, "done", 1, 20)
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "status" {
+		t.Errorf("expected a status validation error, got %v", err)
+	}
+}
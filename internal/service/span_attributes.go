@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+ * Tracing — money flow span attributes
+ *
+ * Spans of operations that move money (PIX, bill, invoice, debit) carry the
+ * same attribute set so they can be queried together: amount, funded_by,
+ * destination_key_type, result and error_code. Key values, documents and
+ * names are never recorded.
+ */
+
+// Span result values.
+const (
+	spanResultSuccess  = "success"
+	spanResultError    = "error"
+	spanResultDeclined = "declined"
+	spanResultPending  = "pending_confirmation"
+)
+
+// endMoneySpan records the outcome of a money flow on its span. It is meant
+// to be deferred with the operation's named error result. A transfer waiting
+// for confirmation is not an error.
+func endMoneySpan(span trace.Span, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.String("result", spanResultSuccess))
+		return
+	}
+	var confirm *domain.ErrConfirmationRequired
+	if errors.As(err, &confirm) {
+		span.SetAttributes(attribute.String("result", spanResultPending))
+		return
+	}
+	code := spanErrorCode(err)
+	span.SetAttributes(
+		attribute.String("result", spanResultError),
+		attribute.String("error_code", code),
+	)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, code)
+}
+
+// spanErrorCode maps a domain error to a stable, PII-free code.
+func spanErrorCode(err error) string {
+	var (
+		notFound          *domain.ErrNotFound
+		circuitOpen       *domain.ErrCircuitOpen
+		timeout           *domain.ErrTimeout
+		validation        *domain.ErrValidation
+		insufficientFunds *domain.ErrInsufficientFunds
+		limitExceeded     *domain.ErrLimitExceeded
+		duplicate         *domain.ErrDuplicate
+		forbidden         *domain.ErrForbidden
+		invalidBarcode    *domain.ErrInvalidBarcode
+		accountBlocked    *domain.ErrAccountBlocked
+		conflict          *domain.ErrConflict
+	)
+
+	switch {
+	case errors.As(err, &notFound):
+		return "not_found"
+	case errors.As(err, &circuitOpen):
+		return "circuit_open"
+	case errors.As(err, &timeout):
+		return "timeout"
+	case errors.As(err, &validation):
+		return "validation"
+	case errors.As(err, &insufficientFunds):
+		return "insufficient_funds"
+	case errors.As(err, &limitExceeded):
+		return "limit_exceeded"
+	case errors.As(err, &duplicate):
+		return "duplicate"
+	case errors.As(err, &forbidden):
+		return "forbidden"
+	case errors.As(err, &invalidBarcode):
+		return "invalid_barcode"
+	case errors.As(err, &accountBlocked):
+		return "account_blocked"
+	case errors.As(err, &conflict):
+		return "conflict"
+	default:
+		return "internal"
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Spending digests — weekly and monthly summaries
+ *
+ * A digest covers the last closed period: the previous Monday-to-Sunday week
+ * or the previous calendar month, compared with the period before it, in
+ * the bank's time zone. The worker sends the weekly digest on Mondays and the
+ * monthly one on the 1st. It runs several times a day, so it first checks
+ * whether the period's digest is already stored and only then builds it;
+ * storing the digest before notifying makes each one go out once. Periods
+ * without movements are stored too, so later runs skip those customers.
+ */
+
+// bankLocation is the bank's time zone (Brasília, UTC-3 with no daylight
+// saving time since 2019), which decides where days, weeks and months start.
+var bankLocation = time.FixedZone("America/Sao_Paulo", -3*60*60)
+
+// digestTopCategories is how many spending categories a digest lists.
+const digestTopCategories = 3
+
+// digestCustomerBatch bounds the customers loaded per page by the worker.
+const digestCustomerBatch = 100
+
+// GenerateSpendingDigest summarizes the customer's last closed week
+// (period "weekly") or month ("monthly").
+func (s *BankingService) GenerateSpendingDigest(ctx context.Context, customerID, period string) (*domain.SpendingDigest, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GenerateSpendingDigest")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.String("period", period))
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	if period != domain.DigestPeriodWeekly && period != domain.DigestPeriodMonthly {
+		return nil, &domain.ErrValidation{Field: "period", Message: "must be weekly or monthly"}
+	}
+	return s.buildSpendingDigest(ctx, customerID, period, time.Now().In(bankLocation))
+}
+
+// buildSpendingDigest summarizes the last period of a known kind closed
+// before now.
+func (s *BankingService) buildSpendingDigest(ctx context.Context, customerID, period string, now time.Time) (*domain.SpendingDigest, error) {
+	from, to, _ := digestWindow(period, now)
+	prevFrom, _, _ := digestWindow(period, from)
+
+	txns, err := s.store.ListTransactions(ctx, customerID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	prevTxns, err := s.store.ListTransactions(ctx, customerID, prevFrom.Format("2006-01-02"), from.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	summary := summarizeTransactions(txns)
+	previous := summarizeTransactions(prevTxns)
+	digest := &domain.SpendingDigest{
+		CustomerID:         customerID,
+		Period:             period,
+		From:               from.Format("2006-01-02"),
+		To:                 to.AddDate(0, 0, -1).Format("2006-01-02"),
+		TotalSpent:         summary.TotalExpenses,
+		TotalIncome:        summary.TotalIncome,
+		TopCategories:      topSpendingCategories(summary, digestTopCategories),
+		PreviousTotalSpent: previous.TotalExpenses,
+		SpentChangePct:     percentChange(summary.TotalExpenses, previous.TotalExpenses),
+	}
+	for i := range txns {
+		if tx := &txns[i]; tx.Amount < 0 && (digest.BiggestExpense == nil || tx.Amount < digest.BiggestExpense.Amount) {
+			digest.BiggestExpense = tx
+		}
+	}
+	return digest, nil
+}
+
+// SendSpendingDigests notifies every active customer of their weekly digest
+// on Mondays and of their monthly digest on the 1st. Customers without
+// movements in the period are skipped.
+func (s *BankingService) SendSpendingDigests(ctx context.Context) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.SendSpendingDigests")
+	defer span.End()
+
+	now := time.Now().In(bankLocation)
+	var periods []string
+	if now.Weekday() == time.Monday {
+		periods = append(periods, domain.DigestPeriodWeekly)
+	}
+	if now.Day() == 1 {
+		periods = append(periods, domain.DigestPeriodMonthly)
+	}
+	if len(periods) == 0 {
+		return nil
+	}
+
+	for afterID := ""; ; {
+		ids, err := s.store.ListActiveCustomerIDs(ctx, afterID, digestCustomerBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, customerID := range ids {
+			for _, period := range periods {
+				s.sendSpendingDigest(ctx, customerID, period, now)
+			}
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// sendSpendingDigest stores and notifies one digest, unless the period's
+// digest is already stored. Failures are logged so the other customers still
+// get theirs.
+func (s *BankingService) sendSpendingDigest(ctx context.Context, customerID, period string, now time.Time) {
+	from, _, _ := digestWindow(period, now)
+	sent, err := s.store.SpendingDigestExists(ctx, customerID, period, from.Format("2006-01-02"))
+	if err != nil {
+		s.logger.Error("failed to check spending digest",
+			zap.String("customer_id", customerID), zap.String("period", period), zap.Error(err))
+		return
+	}
+	if sent {
+		return
+	}
+
+	digest, err := s.buildSpendingDigest(ctx, customerID, period, now)
+	if err != nil {
+		s.logger.Error("failed to generate spending digest",
+			zap.String("customer_id", customerID), zap.String("period", period), zap.Error(err))
+		return
+	}
+
+	if err := s.store.InsertSpendingDigest(ctx, digest); err != nil {
+		var duplicate *domain.ErrDuplicate
+		if !errors.As(err, &duplicate) {
+			s.logger.Error("failed to store spending digest",
+				zap.String("customer_id", customerID), zap.String("period", period), zap.Error(err))
+		}
+		return
+	}
+	if digest.TotalSpent == 0 && digest.TotalIncome == 0 {
+		return // recorded, but nothing to tell the customer
+	}
+
+	_, err = s.CreateNotification(ctx, &domain.Notification{
+		CustomerID: customerID,
+		Type:       domain.NotificationTypeDigest,
+		Title:      digestTitle(digest),
+		Body:       digestBody(digest),
+	}, domain.NotificationChannelEmail, domain.NotificationChannelInApp)
+	if err != nil {
+		s.logger.Error("failed to notify spending digest",
+			zap.String("customer_id", customerID), zap.String("period", period), zap.Error(err))
+	}
+}
+
+// digestWindow returns the [from, to) dates of the last period closed before
+// now, or false for an unknown period.
+func digestWindow(period string, now time.Time) (from, to time.Time, ok bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case domain.DigestPeriodWeekly:
+		to = today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // this week's Monday
+		return to.AddDate(0, 0, -7), to, true
+	case domain.DigestPeriodMonthly:
+		to = today.AddDate(0, 0, 1-today.Day()) // the 1st of this month
+		return to.AddDate(0, -1, 0), to, true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// topSpendingCategories returns the n categories with the most net spending,
+// biggest first.
+func topSpendingCategories(summary *domain.SpendingSummary, n int) []domain.TopCategory {
+	top := make([]domain.TopCategory, 0, len(summary.CategoryBreakdown))
+	for cat, info := range summary.CategoryBreakdown {
+		if info.Total <= 0 {
+			continue // income categories
+		}
+		top = append(top, domain.TopCategory{
+			Category:         cat,
+			Amount:           info.Total,
+			Percentage:       info.Pct,
+			TransactionCount: info.Count,
+			Trend:            "stable",
+		})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Amount != top[j].Amount {
+			return top[i].Amount > top[j].Amount
+		}
+		return top[i].Category < top[j].Category
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+func digestTitle(d *domain.SpendingDigest) string {
+	if d.Period == domain.DigestPeriodMonthly {
+		return "Seu resumo do mês"
+	}
+	return "Seu resumo da semana"
+}
+
+func digestBody(d *domain.SpendingDigest) string {
+	body := fmt.Sprintf("Você gastou %s de %s a %s", FormatBRL(d.TotalSpent), d.From, d.To)
+	if d.PreviousTotalSpent > 0 {
+		body += fmt.Sprintf(" (%+.1f%% em relação ao período anterior)", d.SpentChangePct)
+	}
+	if len(d.TopCategories) > 0 {
+		body += fmt.Sprintf(". Maior categoria: %s", d.TopCategories[0].Category)
+	}
+	return body + "."
+}
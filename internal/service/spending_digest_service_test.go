@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestGenerateSpendingDigest_LastClosedMonth(t *testing.T) {
+	now := time.Now().In(time.FixedZone("America/Sao_Paulo", -3*60*60)) // the bank's time zone
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, now.Location())
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+	monthBefore := thisMonth.AddDate(0, -2, 0)
+
+	store := newFakeBankingStore()
+	store.statement = []domain.Transaction{
+		// last month: the digest period
+		{ID: "tx-1", Date: lastMonth, Amount: -1200, Category: "aluguel", Description: "Aluguel escritório"},
+		{ID: "tx-2", Date: lastMonth.AddDate(0, 0, 3), Amount: -300, Category: "fornecedores"},
+		{ID: "tx-3", Date: lastMonth.AddDate(0, 0, 5), Amount: -200, Category: "fornecedores"},
+		{ID: "tx-4", Date: lastMonth.AddDate(0, 0, 9), Amount: -150, Category: "alimentacao"},
+		{ID: "tx-5", Date: lastMonth.AddDate(0, 0, 10), Amount: -50, Category: "transporte"},
+		{ID: "tx-6", Date: thisMonth.AddDate(0, 0, -1), Amount: 4000, Category: "vendas"},
+		// the month before: the comparison
+		{ID: "tx-7", Date: monthBefore.AddDate(0, 0, 2), Amount: -1000, Category: "aluguel"},
+		// this month: not closed yet
+		{ID: "tx-8", Date: thisMonth, Amount: -5000, Category: "aluguel"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	digest, err := svc.GenerateSpendingDigest(context.Background(), testCustomerID, domain.DigestPeriodMonthly)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := lastMonth.Format("2006-01-02"); digest.From != want {
+		t.Errorf("from = %s, want %s", digest.From, want)
+	}
+	if want := thisMonth.AddDate(0, 0, -1).Format("2006-01-02"); digest.To != want {
+		t.Errorf("to = %s, want %s", digest.To, want)
+	}
+	if digest.TotalSpent != 1900 || digest.TotalIncome != 4000 {
+		t.Errorf("spent/income = %v/%v, want 1900/4000", digest.TotalSpent, digest.TotalIncome)
+	}
+	if len(digest.TopCategories) != 3 {
+		t.Fatalf("got %d top categories, want 3: %+v", len(digest.TopCategories), digest.TopCategories)
+	}
+	for i, wantAmount := range []float64{1200, 500, 150} {
+		if got := digest.TopCategories[i].Amount; got != wantAmount {
+			t.Errorf("top category %d = %+v, want amount %v", i, digest.TopCategories[i], wantAmount)
+		}
+	}
+	if digest.BiggestExpense == nil || digest.BiggestExpense.ID != "tx-1" {
+		t.Errorf("biggest expense = %+v, want tx-1", digest.BiggestExpense)
+	}
+	if digest.PreviousTotalSpent != 1000 || digest.SpentChangePct != 90 {
+		t.Errorf("previous/change = %v/%v, want 1000/90", digest.PreviousTotalSpent, digest.SpentChangePct)
+	}
+}
+
+func TestGenerateSpendingDigest_RejectsUnknownPeriod(t *testing.T) {
+	svc := service.NewBankingService(newFakeBankingStore(), observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.GenerateSpendingDigest(context.Background(), testCustomerID, "daily"); err == nil {
+		t.Error("expected a validation error for an unknown period")
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Spending summaries — precomputed financial summary windows
+ *
+ * GetFinancialSummary aggregates every transaction of the requested window,
+ * which gets slow for customers with thousands of rows. The aggregate is
+ * stored in spending_summaries for the trailing window ending today and
+ * reused while fresh. Recording a transaction drops the stored windows that
+ * cover its date, so a stale summary is never served.
+ */
+
+// DefaultSpendingSummaryMaxAge is how long a precomputed summary is served
+// before it is computed again.
+const DefaultSpendingSummaryMaxAge = 15 * time.Minute
+
+// spendingSummaryPeriodDays maps the supported period types to the length of
+// their trailing window, matching the financial summary periods.
+var spendingSummaryPeriodDays = map[string]int{
+	"daily":   1,
+	"weekly":  7,
+	"monthly": 30,
+	"yearly":  365,
+}
+
+// SetSpendingSummaryMaxAge overrides how long precomputed summaries are
+// served. Non-positive values keep the current setting.
+func (s *BankingService) SetSpendingSummaryMaxAge(d time.Duration) {
+	if d > 0 {
+		s.spendingSummaryMaxAge = d
+	}
+}
+
+// RefreshSpendingSummary computes the summary of the trailing daily, weekly,
+// monthly or yearly window ending today and stores it.
+func (s *BankingService) RefreshSpendingSummary(ctx context.Context, customerID, periodType string) (*domain.SpendingSummary, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RefreshSpendingSummary")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.String("period_type", periodType))
+
+	periodDays, ok := spendingSummaryPeriodDays[periodType]
+	if !ok {
+		return nil, &domain.ErrValidation{Field: "periodType", Message: "must be daily, weekly, monthly or yearly"}
+	}
+
+	now := time.Now()
+	fromDate, toDate := spendingSummaryWindow(now, periodDays)
+	txns, err := s.store.ListTransactions(ctx, customerID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := s.buildSpendingSummary(ctx, customerID, now, periodDays, txns)
+	summary.PeriodType = periodType
+	if err := s.store.UpsertSpendingSummary(ctx, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// spendingSummaryWindow returns the [from, to) dates of the periodDays
+// window ending today, as queried from customer_transactions.
+func spendingSummaryWindow(now time.Time, periodDays int) (from, to string) {
+	return now.AddDate(0, 0, -periodDays).Format("2006-01-02"), now.AddDate(0, 0, 1).Format("2006-01-02")
+}
+
+// spendingSummaryPeriodType returns the period type stored for a window of
+// periodDays, or "" when such windows are not precomputed.
+func spendingSummaryPeriodType(periodDays int) string {
+	for periodType, days := range spendingSummaryPeriodDays {
+		if days == periodDays {
+			return periodType
+		}
+	}
+	return ""
+}
+
+// freshSpendingSummary returns the stored summary of the window ending today
+// if it was computed less than spendingSummaryMaxAge ago.
+func (s *BankingService) freshSpendingSummary(ctx context.Context, customerID string, now time.Time, periodDays int) *domain.SpendingSummary {
+	fromDate, _ := spendingSummaryWindow(now, periodDays)
+	stored, err := s.store.FindSpendingSummary(ctx, customerID, fromDate, now.Format("2006-01-02"))
+	if err != nil {
+		s.logger.Warn("could not load precomputed spending summary",
+			zap.String("customer_id", customerID), zap.Error(err))
+		return nil
+	}
+	if stored == nil || now.Sub(stored.ComputedAt) > s.spendingSummaryMaxAge {
+		return nil
+	}
+	return stored
+}
+
+// buildSpendingSummary aggregates the transactions of the periodDays window
+// ending today and compares it with the preceding window.
+func (s *BankingService) buildSpendingSummary(ctx context.Context, customerID string, now time.Time, periodDays int, txns []domain.Transaction) *domain.SpendingSummary {
+	fromDate, _ := spendingSummaryWindow(now, periodDays)
+	summary := summarizeTransactions(txns)
+	summary.CustomerID = customerID
+	summary.PeriodStart = fromDate
+	summary.PeriodEnd = now.Format("2006-01-02")
+	summary.ComputedAt = now
+
+	prevIncome, prevExpenses := s.previousPeriodTotals(ctx, customerID, now, periodDays)
+	summary.IncomeVariationPct = percentChange(summary.TotalIncome, prevIncome)
+	summary.ExpenseVariationPct = percentChange(summary.TotalExpenses, prevExpenses)
+	summary.NetVariationPct = percentChange(summary.NetCashflow, prevIncome-prevExpenses)
+	return summary
+}
+
+// summarizeTransactions aggregates a list of transactions. Expenses are
+// reported as positive values; a category total is its expenses net of the
+// income booked under it. Spelling variants of a category are grouped under
+// its display name.
+func summarizeTransactions(txns []domain.Transaction) *domain.SpendingSummary {
+	summary := &domain.SpendingSummary{CategoryBreakdown: make(map[string]domain.CatSum)}
+	monthlyIncome := make(map[string]float64)
+	monthlyExpenses := make(map[string]float64)
+
+	for _, tx := range txns {
+		monthKey := tx.Date.Format("2006-01")
+		if tx.Amount >= 0 {
+			summary.TotalIncome += tx.Amount
+			summary.IncomeCount++
+			summary.LargestIncome = math.Max(summary.LargestIncome, tx.Amount)
+			monthlyIncome[monthKey] += tx.Amount
+		} else {
+			summary.TotalExpenses += -tx.Amount
+			summary.ExpenseCount++
+			summary.LargestExpense = math.Max(summary.LargestExpense, -tx.Amount)
+			monthlyExpenses[monthKey] += -tx.Amount
+		}
+		if category := categoryDisplayName(tx.Category); category != "" {
+			entry := summary.CategoryBreakdown[category]
+			entry.Total += -tx.Amount
+			if tx.Amount < 0 {
+				entry.Count++
+			}
+			summary.CategoryBreakdown[category] = entry
+		}
+
+		switch tx.Type {
+		case "pix_sent":
+			summary.PixSentTotal += -tx.Amount
+			summary.PixSentCount++
+		case "pix_received":
+			summary.PixReceivedTotal += tx.Amount
+			summary.PixReceivedCount++
+		case "credit_purchase":
+			summary.CreditCardTotal += -tx.Amount
+		case "debit_purchase":
+			summary.DebitCardTotal += -tx.Amount
+		case "bill_payment":
+			summary.BillsPaidTotal += -tx.Amount
+			summary.BillsPaidCount++
+		}
+	}
+
+	summary.TransactionCount = len(txns)
+	summary.NetCashflow = summary.TotalIncome - summary.TotalExpenses
+	if summary.IncomeCount > 0 {
+		summary.AvgIncome = summary.TotalIncome / float64(summary.IncomeCount)
+	}
+	if summary.ExpenseCount > 0 {
+		summary.AvgExpense = summary.TotalExpenses / float64(summary.ExpenseCount)
+	}
+	for cat, entry := range summary.CategoryBreakdown {
+		if entry.Total > 0 && summary.TotalExpenses > 0 {
+			entry.Pct = (entry.Total / summary.TotalExpenses) * 100
+			summary.CategoryBreakdown[cat] = entry
+		}
+	}
+
+	months := make(map[string]bool)
+	for m := range monthlyIncome {
+		months[m] = true
+	}
+	for m := range monthlyExpenses {
+		months[m] = true
+	}
+	summary.MonthlyTrend = make([]domain.MonthlyTrend, 0, len(months))
+	for m := range months {
+		summary.MonthlyTrend = append(summary.MonthlyTrend, domain.MonthlyTrend{
+			Month:    m,
+			Income:   monthlyIncome[m],
+			Expenses: monthlyExpenses[m],
+			Balance:  monthlyIncome[m] - monthlyExpenses[m],
+		})
+	}
+	sort.Slice(summary.MonthlyTrend, func(i, j int) bool {
+		return summary.MonthlyTrend[i].Month < summary.MonthlyTrend[j].Month
+	})
+	return summary
+}
+
+// insertTransaction records a statement entry, categorized by the customer's
+// rules, and drops the precomputed summaries it makes stale.
+func (s *BankingService) insertTransaction(ctx context.Context, tx map[string]any) error {
+	description, _ := tx["description"].(string)
+	s.applyCategoryRules(ctx, tx, description)
+	if err := s.store.InsertTransaction(ctx, tx); err != nil {
+		return err
+	}
+	customerID, _ := tx["customer_id"].(string)
+	since := time.Now()
+	if date, ok := tx["date"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			since = t
+		}
+	}
+	s.invalidateSpendingSummaries(ctx, customerID, since)
+	return nil
+}
+
+// invalidateSpendingSummaries drops the customer's stored summaries whose
+// window ends on or after since. Failures are logged: the stored rows then
+// expire after spendingSummaryMaxAge.
+func (s *BankingService) invalidateSpendingSummaries(ctx context.Context, customerID string, since time.Time) {
+	if customerID == "" {
+		return
+	}
+	if err := s.store.DeleteSpendingSummaries(ctx, customerID, since.Format("2006-01-02")); err != nil {
+		s.logger.Warn("could not invalidate spending summaries",
+			zap.String("customer_id", customerID), zap.Error(err))
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Statement exports — asynchronous statement files
+ *
+ * An export request only queues a job and answers 202; the statement-exports
+ * worker builds the file and notifies the customer, while the client polls
+ * the job. Jobs live in memory on the instance that accepted them, so they
+ * do not survive a restart. File storage is not wired yet: the download
+ * link points to a placeholder host.
+ */
+
+const (
+	// defaultStatementExportDays is the period exported when From is empty.
+	defaultStatementExportDays = 30
+	// maxStatementExportDays bounds the exported period.
+	maxStatementExportDays = 366
+	// maxPendingStatementExports bounds the queued and running exports per customer.
+	maxPendingStatementExports = 3
+	// statementExportBatch bounds the jobs processed per worker run.
+	statementExportBatch = 10
+	// jobRetention is how long finished jobs can still be polled.
+	jobRetention = 24 * time.Hour
+
+	statementDownloadBaseURL = "https://files.pj-assistant.example/statements"
+	statementDownloadTTL     = 24 * time.Hour
+)
+
+// RequestStatementExport queues the export of the customer's statement for
+// the requested period.
+func (s *BankingService) RequestStatementExport(ctx context.Context, customerID string, req *domain.StatementExportRequest) (*domain.Job, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RequestStatementExport")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	normalized, err := normalizeStatementExport(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetPrimaryAccount(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	job, pending := s.jobs.enqueue(&domain.Job{
+		ID:         uuid.New().String(),
+		Type:       domain.JobTypeStatementExport,
+		CustomerID: customerID,
+		Request:    normalized,
+	}, maxPendingStatementExports)
+	if job == nil {
+		return nil, &domain.ErrLimitExceeded{LimitType: "statement_exports", Limit: maxPendingStatementExports, Current: float64(pending)}
+	}
+
+	s.logger.Info("statement export queued",
+		zap.String("customer_id", customerID),
+		zap.String("job_id", job.ID),
+		zap.String("format", normalized.Format))
+	return job, nil
+}
+
+// GetJob returns one of the customer's background jobs while it is queued,
+// running, or finished less than jobRetention ago. Another customer's job is
+// not found.
+func (s *BankingService) GetJob(ctx context.Context, customerID, jobID string) (*domain.Job, error) {
+	_, span := bankTracer.Start(ctx, "BankingService.GetJob")
+	defer span.End()
+
+	job, ok := s.jobs.get(jobID)
+	if !ok || job.CustomerID != customerID {
+		return nil, &domain.ErrNotFound{Resource: "job", ID: jobID}
+	}
+	return job, nil
+}
+
+// ProcessStatementExports runs up to statementExportBatch queued exports.
+// A failed export marks its job failed; the worker itself never fails.
+func (s *BankingService) ProcessStatementExports(ctx context.Context) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ProcessStatementExports")
+	defer span.End()
+
+	for i := 0; i < statementExportBatch; i++ {
+		job, ok := s.jobs.next()
+		if !ok {
+			return nil
+		}
+		result, err := s.exportStatement(ctx, job)
+		if err != nil {
+			s.logger.Error("statement export failed",
+				zap.String("customer_id", job.CustomerID), zap.String("job_id", job.ID), zap.Error(err))
+			s.jobs.finish(job.ID, nil, "could not generate the statement")
+			continue
+		}
+		s.jobs.finish(job.ID, result, "")
+		s.notifyStatementReady(ctx, job, result)
+	}
+	return nil
+}
+
+func (s *BankingService) exportStatement(ctx context.Context, job *domain.Job) (*domain.StatementExportResult, error) {
+	to, _ := time.Parse("2006-01-02", job.Request.To)
+	txns, err := s.store.ListTransactions(ctx, job.CustomerID, job.Request.From, to.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.StatementExportResult{
+		Rows:        len(txns),
+		DownloadURL: fmt.Sprintf("%s/%s.%s", statementDownloadBaseURL, job.ID, job.Request.Format),
+		ExpiresAt:   time.Now().Add(statementDownloadTTL),
+	}, nil
+}
+
+// notifyStatementReady tells the customer the export can be downloaded. The
+// job is done either way, so a failure is only logged.
+func (s *BankingService) notifyStatementReady(ctx context.Context, job *domain.Job, result *domain.StatementExportResult) {
+	_, err := s.CreateNotification(ctx, &domain.Notification{
+		CustomerID: job.CustomerID,
+		Type:       domain.NotificationTypeStatementReady,
+		Title:      "Seu extrato está pronto",
+		Body: fmt.Sprintf("O extrato de %s a %s (%d lançamentos) está disponível para download: %s",
+			job.Request.From, job.Request.To, result.Rows, result.DownloadURL),
+	}, domain.NotificationChannelEmail, domain.NotificationChannelInApp)
+	if err != nil {
+		s.logger.Error("failed to notify statement export",
+			zap.String("customer_id", job.CustomerID), zap.String("job_id", job.ID), zap.Error(err))
+	}
+}
+
+// normalizeStatementExport validates the request and fills in the defaults.
+func normalizeStatementExport(req *domain.StatementExportRequest, now time.Time) (*domain.StatementExportRequest, error) {
+	out := *req
+	switch out.Format {
+	case "":
+		out.Format = domain.StatementFormatCSV
+	case domain.StatementFormatCSV, domain.StatementFormatPDF:
+	default:
+		return nil, &domain.ErrValidation{Field: "format", Message: "must be csv or pdf"}
+	}
+
+	to := now
+	if out.To != "" {
+		var err error
+		if to, err = time.Parse("2006-01-02", out.To); err != nil {
+			return nil, &domain.ErrValidation{Field: "to", Message: "must be a date (YYYY-MM-DD)"}
+		}
+	}
+	from := to.AddDate(0, 0, -defaultStatementExportDays)
+	if out.From != "" {
+		var err error
+		if from, err = time.Parse("2006-01-02", out.From); err != nil {
+			return nil, &domain.ErrValidation{Field: "from", Message: "must be a date (YYYY-MM-DD)"}
+		}
+	}
+	switch {
+	case from.After(to):
+		return nil, &domain.ErrValidation{Field: "from", Message: "must not be after to"}
+	case to.Sub(from) > maxStatementExportDays*24*time.Hour:
+		return nil, &domain.ErrValidation{Field: "from", Message: fmt.Sprintf("period must have at most %d days", maxStatementExportDays)}
+	}
+	out.From, out.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+	return &out, nil
+}
+
+// jobQueue keeps background jobs in memory, in arrival order. Accessors
+// return copies so callers never race with the worker.
+type jobQueue struct {
+	mu     sync.Mutex
+	jobs   map[string]*domain.Job
+	queued []string // IDs waiting for the worker, oldest first
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{jobs: make(map[string]*domain.Job)}
+}
+
+// enqueue queues job unless its customer already has maxPending jobs queued
+// or running, in which case it returns nil and that count. Finished jobs
+// past jobRetention are dropped.
+func (q *jobQueue) enqueue(job *domain.Job, maxPending int) (*domain.Job, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	pending := 0
+	for id, j := range q.jobs {
+		switch {
+		case j.Status == domain.JobStatusQueued || j.Status == domain.JobStatusRunning:
+			if j.CustomerID == job.CustomerID && j.Type == job.Type {
+				pending++
+			}
+		case now.Sub(j.UpdatedAt) > jobRetention:
+			delete(q.jobs, id)
+		}
+	}
+	if pending >= maxPending {
+		return nil, pending
+	}
+
+	job.Status = domain.JobStatusQueued
+	job.CreatedAt, job.UpdatedAt = now, now
+	q.jobs[job.ID] = job
+	q.queued = append(q.queued, job.ID)
+	cp := *job
+	return &cp, pending
+}
+
+func (q *jobQueue) get(id string) (*domain.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	cp := *j
+	return &cp, true
+}
+
+// next marks the oldest queued job running and returns it.
+func (q *jobQueue) next() (*domain.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queued) > 0 {
+		id := q.queued[0]
+		q.queued = q.queued[1:]
+		if j, ok := q.jobs[id]; ok && j.Status == domain.JobStatusQueued {
+			j.Status = domain.JobStatusRunning
+			j.UpdatedAt = time.Now()
+			cp := *j
+			return &cp, true
+		}
+	}
+	return nil, false
+}
+
+// finish marks a job done with result, or failed with errMsg when it is set.
+func (q *jobQueue) finish(id string, result *domain.StatementExportResult, errMsg string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return
+	}
+	if errMsg != "" {
+		j.Status, j.Error = domain.JobStatusFailed, errMsg
+	} else {
+		j.Status, j.Result = domain.JobStatusDone, result
+	}
+	j.UpdatedAt = time.Now()
+}
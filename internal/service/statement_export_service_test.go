@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestStatementExport_JobRunsToDone(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), Amount: -50, Description: "Compra débito - Kalunga"},
+		{ID: "tx-2", Date: time.Date(2026, 1, 31, 18, 0, 0, 0, time.UTC), Amount: 300, Description: "Pix recebido - Cliente"},
+		{ID: "tx-3", Date: time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC), Amount: -20, Description: "Fora do período"},
+	}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	job, err := svc.RequestStatementExport(ctx, testCustomerID, &domain.StatementExportRequest{Format: "pdf", From: "2026-01-01", To: "2026-01-31"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status != domain.JobStatusQueued || job.Type != domain.JobTypeStatementExport {
+		t.Fatalf("job = %+v, want a queued statement_export", job)
+	}
+
+	if err := svc.ProcessStatementExports(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := svc.GetJob(ctx, "cust-999", job.ID); err == nil {
+		t.Error("expected another customer's job to be not found")
+	}
+	done, err := svc.GetJob(ctx, testCustomerID, job.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done.Status != domain.JobStatusDone || done.Result == nil || done.Result.Rows != 2 ||
+		!strings.HasSuffix(done.Result.DownloadURL, job.ID+".pdf") {
+		t.Fatalf("job = %+v, result = %+v, want done with a pdf link for 2 rows", done, done.Result)
+	}
+	if len(store.notifs) == 0 || store.notifs[0].Type != domain.NotificationTypeStatementReady ||
+		!strings.Contains(store.notifs[0].Body, done.Result.DownloadURL) {
+		t.Errorf("notifications = %+v, want statement_ready with the link", store.notifs)
+	}
+}
+
+func TestStatementExport_Validation(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	for _, req := range []domain.StatementExportRequest{
+		{Format: "xlsx"},
+		{From: "2026-02-01", To: "2026-01-01"},
+		{From: "2024-01-01", To: "2026-01-01"},
+		{To: "31/01/2026"},
+	} {
+		var validation *domain.ErrValidation
+		if _, err := svc.RequestStatementExport(ctx, testCustomerID, &req); !errors.As(err, &validation) {
+			t.Errorf("%+v: expected ErrValidation, got %v", req, err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.RequestStatementExport(ctx, testCustomerID, &domain.StatementExportRequest{}); err != nil {
+			t.Fatalf("export %d: unexpected error: %v", i, err)
+		}
+	}
+	var exceeded *domain.ErrLimitExceeded
+	if _, err := svc.RequestStatementExport(ctx, testCustomerID, &domain.StatementExportRequest{}); !errors.As(err, &exceeded) {
+		t.Errorf("expected ErrLimitExceeded with 3 pending exports, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+/*
+ * Transaction limits — defaults for customers without a configured limit
+ *
+ * A customer without a transaction_limits row for a type used to be
+ * unlimited. Checks and listings now fall back to the default limit of the
+ * type, set from the configuration, without writing anything; the default
+ * is stored the first time a transfer or payment of the type goes through,
+ * so its usage is tracked like any other limit and the customer can change
+ * it afterwards.
+ *
+ * Usage is recorded by PIX transfers and bill payments once the money
+ * moved (recordLimitUsage). The stored daily and monthly usage start over
+ * on the next usage recorded in a new day or month of the bank's time zone;
+ * until then, reads ignore usage left from an earlier period.
+ */
+
+// SetDefaultTransactionLimit sets the limit applied to txType for customers
+// without a configured one. All zeros remove the default, leaving those
+// customers unrestricted for that type.
+func (s *BankingService) SetDefaultTransactionLimit(txType string, single, daily, monthly float64) {
+	if single <= 0 && daily <= 0 && monthly <= 0 {
+		delete(s.defaultLimits, txType)
+		return
+	}
+	s.defaultLimits[txType] = domain.TransactionLimit{SingleLimit: single, DailyLimit: daily, MonthlyLimit: monthly}
+}
+
+// transactionLimit returns the customer's limit for txType, or the unstored
+// default one when none is configured. It returns nil only when the type
+// has no default either. It never writes.
+func (s *BankingService) transactionLimit(ctx context.Context, customerID, txType string) (*domain.TransactionLimit, error) {
+	limit, err := s.store.GetTransactionLimit(ctx, customerID, txType)
+	var notFound *domain.ErrNotFound
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+	if limit != nil {
+		currentLimitUsage(limit, time.Now())
+		return limit, nil
+	}
+	return s.defaultTransactionLimit(customerID, txType), nil
+}
+
+// currentLimitUsage zeroes the usage of limit left from a day or month
+// before now's, in the bank's time zone.
+func currentLimitUsage(limit *domain.TransactionLimit, now time.Time) {
+	now = now.In(bankLocation)
+	if reset := limit.LastResetDaily; reset != nil {
+		ry, rm, rd := reset.In(bankLocation).Date()
+		if y, m, d := now.Date(); ry != y || rm != m || rd != d {
+			limit.DailyUsed = 0
+		}
+	}
+	if reset := limit.LastResetMonthly; reset != nil {
+		ry, rm, _ := reset.In(bankLocation).Date()
+		if y, m, _ := now.Date(); ry != y || rm != m {
+			limit.MonthlyUsed = 0
+		}
+	}
+}
+
+// recordLimitUsage adds amount to the customer's daily and monthly usage of
+// txType. Called once the money moved, so a failure is only logged.
+func (s *BankingService) recordLimitUsage(ctx context.Context, customerID, txType string, amount float64) {
+	if err := s.store.AddTransactionLimitUsage(ctx, customerID, txType, amount, amount); err != nil {
+		s.logger.Error("failed to record transaction limit usage",
+			zap.String("customer_id", customerID),
+			zap.String("transaction_type", txType),
+			zap.Float64("amount", amount),
+			zap.Error(err))
+	}
+}
+
+// defaultTransactionLimit is the default limit of txType for the customer,
+// or nil when the type has none.
+func (s *BankingService) defaultTransactionLimit(customerID, txType string) *domain.TransactionLimit {
+	def, ok := s.defaultLimits[txType]
+	if !ok {
+		return nil
+	}
+	def.CustomerID = customerID
+	def.TransactionType = txType
+	return &def
+}
+
+// seedTransactionLimit stores the default limit of txType for a customer
+// without a configured one. Called by transfers and payments once their
+// checks passed; a failure is only logged, since the default was already
+// enforced.
+func (s *BankingService) seedTransactionLimit(ctx context.Context, customerID, txType string) {
+	limit, err := s.store.GetTransactionLimit(ctx, customerID, txType)
+	var notFound *domain.ErrNotFound
+	if limit != nil || (err != nil && !errors.As(err, &notFound)) {
+		return
+	}
+	def := s.defaultTransactionLimit(customerID, txType)
+	if def == nil {
+		return
+	}
+
+	_, err = s.store.CreateTransactionLimit(ctx, def)
+	var duplicate *domain.ErrDuplicate
+	switch {
+	case err == nil:
+		s.logger.Info("default transaction limit seeded",
+			zap.String("customer_id", customerID), zap.String("transaction_type", txType))
+	case errors.As(err, &duplicate):
+		// Seeded concurrently
+	default:
+		s.logger.Warn("failed to seed default transaction limit",
+			zap.String("customer_id", customerID), zap.String("transaction_type", txType), zap.Error(err))
+	}
+}
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestCreatePixTransfer_NewCustomerGetsDefaultLimits(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 100000)
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetDefaultTransactionLimit("pix", 3000, 5000, 50000)
+	svc.SetDefaultTransactionLimit("bill_payment", 50000, 100000, 500000)
+	ctx := context.Background()
+	transfer := func(key string, amount float64) *domain.PixTransferRequest {
+		return &domain.PixTransferRequest{
+			IdempotencyKey:      key,
+			SourceAccountID:     testAccountID,
+			DestinationKeyValue: "fornecedor@example.com",
+			Amount:              amount,
+		}
+	}
+
+	validation, err := svc.ValidatePixTransfer(ctx, testCustomerID, transfer("idem-dry-run", 4000))
+	if err != nil || validation.ReasonCode != "limit_exceeded" {
+		t.Fatalf("dry run = %+v, %v, want blocked by the default single limit", validation, err)
+	}
+	_, err = svc.CreatePixTransfer(ctx, testCustomerID, transfer("idem-big", 4000))
+	var exceeded *domain.ErrLimitExceeded
+	if !errors.As(err, &exceeded) || exceeded.LimitType != "single_pix" {
+		t.Fatalf("expected the default single limit to apply, got %v", err)
+	}
+	if got := store.accounts[testCustomerID].Balance; got != 100000 {
+		t.Errorf("balance = %v, want untouched", got)
+	}
+
+	limits, err := svc.ListLimits(ctx, testCustomerID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]domain.TransactionLimit{}
+	for _, l := range limits {
+		got[l.TransactionType] = l
+	}
+	if pix := got["pix"]; pix.SingleLimit != 3000 || pix.DailyLimit != 5000 || pix.MonthlyLimit != 50000 || pix.CustomerID != testCustomerID {
+		t.Errorf("pix limit = %+v, want the configured default", pix)
+	}
+	if _, ok := got["bill_payment"]; !ok || len(limits) != 2 {
+		t.Errorf("limits = %+v, want the pix and bill_payment defaults", limits)
+	}
+	if len(store.limits) != 0 {
+		t.Fatalf("stored limits = %+v, want none written by checks and listings", store.limits)
+	}
+
+	if _, err := svc.CreatePixTransfer(ctx, testCustomerID, transfer("idem-small", 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pix := store.limits["pix"]; pix == nil || pix.SingleLimit != 3000 {
+		t.Errorf("stored pix limit = %+v, want the default seeded by the transfer", pix)
+	}
+	if _, ok := store.limits["bill_payment"]; ok {
+		t.Error("bill_payment limit seeded by a PIX transfer")
+	}
+}
+
+func TestListLimits_KeepsConfiguredLimits(t *testing.T) {
+	store := newFakeBankingStore()
+	store.limits["pix"] = &domain.TransactionLimit{CustomerID: testCustomerID, TransactionType: "pix", SingleLimit: 1, DailyLimit: 1, MonthlyLimit: 1}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+
+	if _, err := svc.ListLimits(context.Background(), testCustomerID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l := store.limits["pix"]; l.SingleLimit != 1 {
+		t.Errorf("configured pix limit overwritten: %+v", l)
+	}
+}
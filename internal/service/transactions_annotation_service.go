@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Transaction History — notes and tags
+ *
+ * The customer's accounting annotates statement entries with a free-text
+ * note and a few tags (cost center, project, ...). Both are stored on the
+ * customer_transactions row and come back in every transaction listing.
+ */
+
+const (
+	maxTransactionNoteLength = 500 // characters
+	maxTransactionTags       = 10
+	maxTransactionTagLength  = 30 // characters
+)
+
+// AnnotateTransaction replaces the note and tags of one of the customer's
+// transactions and returns the updated transaction. Tags are trimmed and
+// deduplicated (case- and accent-insensitive), keeping the first spelling.
+func (s *BankingService) AnnotateTransaction(ctx context.Context, customerID, txID string, req *domain.TransactionAnnotation) (*domain.Transaction, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.AnnotateTransaction")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID), attribute.String("transaction.id", txID))
+
+	annotation, err := normalizeTransactionAnnotation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.store.UpdateTransactionAnnotation(ctx, customerID, txID, annotation)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("transaction annotated",
+		zap.String("customer_id", customerID),
+		zap.String("transaction_id", txID),
+		zap.Int("tags", len(annotation.Tags)))
+	return tx, nil
+}
+
+func normalizeTransactionAnnotation(req *domain.TransactionAnnotation) (*domain.TransactionAnnotation, error) {
+	note := strings.TrimSpace(req.Note)
+	if utf8.RuneCountInString(note) > maxTransactionNoteLength {
+		return nil, &domain.ErrValidation{Field: "note", Message: fmt.Sprintf("must be at most %d characters", maxTransactionNoteLength)}
+	}
+
+	tags := make([]string, 0, len(req.Tags))
+	seen := make(map[string]bool, len(req.Tags))
+	for _, tag := range req.Tags {
+		tag = strings.TrimSpace(tag)
+		switch {
+		case tag == "":
+			return nil, &domain.ErrValidation{Field: "tags", Message: "must not contain empty tags"}
+		case utf8.RuneCountInString(tag) > maxTransactionTagLength:
+			return nil, &domain.ErrValidation{Field: "tags", Message: fmt.Sprintf("each tag must be at most %d characters", maxTransactionTagLength)}
+		}
+		if key := foldText(tag); !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTransactionTags {
+		return nil, &domain.ErrValidation{Field: "tags", Message: fmt.Sprintf("must have at most %d tags", maxTransactionTags)}
+	}
+	return &domain.TransactionAnnotation{Note: note, Tags: tags}, nil
+}
@@ -0,0 +1,60 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+)
+
+func TestAnnotateTransaction_PersistsAndAppearsInList(t *testing.T) {
+	svc := newSearchService()
+	ctx := context.Background()
+
+	tx, err := svc.AnnotateTransaction(ctx, testCustomerID, "tx-4", &domain.TransactionAnnotation{
+		Note: "  Aluguel de março, centro de custo SP  ",
+		Tags: []string{"Aluguel", "centro-sp", "aluguel", " Escritório "},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.ID != "tx-4" || tx.Note != "Aluguel de março, centro de custo SP" {
+		t.Errorf("annotated transaction = %+v, want tx-4 with the trimmed note", tx)
+	}
+	wantTags := []string{"Aluguel", "centro-sp", "Escritório"}
+	if len(tx.Tags) != len(wantTags) {
+		t.Fatalf("tags = %q, want %q", tx.Tags, wantTags)
+	}
+	for i, tag := range wantTags {
+		if tx.Tags[i] != tag {
+			t.Errorf("tags = %q, want %q", tx.Tags, wantTags)
+			break
+		}
+	}
+
+	list, err := svc.SearchTransactions(ctx, testCustomerID, &domain.TransactionSearchFilter{Query: "aluguel"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Note != tx.Note || len(list.Data[0].Tags) != len(wantTags) {
+		t.Errorf("listed transactions = %+v, want tx-4 with its note and tags", list.Data)
+	}
+}
+
+func TestAnnotateTransaction_Validation(t *testing.T) {
+	svc := newSearchService()
+	ctx := context.Background()
+
+	_, err := svc.AnnotateTransaction(ctx, testCustomerID, "tx-1", &domain.TransactionAnnotation{Tags: []string{"ok", "  "}})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) || validation.Field != "tags" {
+		t.Errorf("empty tag: err = %v, want a tags validation error", err)
+	}
+
+	_, err = svc.AnnotateTransaction(ctx, testCustomerID, "tx-missing", &domain.TransactionAnnotation{Note: "x"})
+	var notFound *domain.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("unknown transaction: err = %v, want ErrNotFound", err)
+	}
+}
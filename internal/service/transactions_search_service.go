@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+/*
+ * Transaction History — search
+ */
+
+// SearchTransactions finds a customer's transactions by description text
+// (case- and accent-insensitive), absolute amount range, type and direction,
+// newest first, paginated.
+func (s *BankingService) SearchTransactions(ctx context.Context, customerID string, filter *domain.TransactionSearchFilter) (*domain.ListResponse[domain.Transaction], error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.SearchTransactions")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", customerID))
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, &domain.ErrValidation{Field: "minAmount", Message: "must not be greater than maxAmount"}
+	}
+	if d := filter.Direction; d != "" && d != domain.TransactionDirectionIn && d != domain.TransactionDirectionOut {
+		return nil, &domain.ErrValidation{Field: "direction", Message: "must be in or out"}
+	}
+
+	candidates, err := s.store.SearchTransactions(ctx, customerID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := foldText(strings.TrimSpace(filter.Query))
+	matches := make([]domain.Transaction, 0, len(candidates))
+	for _, tx := range candidates {
+		if filter.Type != "" && tx.Type != filter.Type {
+			continue
+		}
+		if !tx.MatchesDirection(filter.Direction) {
+			continue
+		}
+		amount := math.Abs(tx.Amount)
+		if filter.MinAmount != nil && amount < *filter.MinAmount {
+			continue
+		}
+		if filter.MaxAmount != nil && amount > *filter.MaxAmount {
+			continue
+		}
+		if query != "" && !strings.Contains(foldText(tx.Description), query) {
+			continue
+		}
+		matches = append(matches, tx)
+	}
+
+	page, pageSize := filter.Page, filter.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	start := min((page-1)*pageSize, len(matches))
+	end := min(start+pageSize, len(matches))
+
+	return &domain.ListResponse[domain.Transaction]{
+		Data:     matches[start:end],
+		Total:    len(matches),
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  end < len(matches),
+	}, nil
+}
+
+// foldText lower-cases s and strips diacritics ("Açúcar" → "acucar").
+func foldText(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func newSearchService() *service.BankingService {
+	store := newFakeBankingStore()
+	now := time.Now()
+	store.statement = []domain.Transaction{
+		{ID: "tx-1", Date: now, Amount: -89.90, Type: "debit_purchase", Description: "Açúcar União 5kg"},
+		{ID: "tx-2", Date: now, Amount: -150, Type: "pix_sent", Description: "Pix para Padaria São João"},
+		{ID: "tx-3", Date: now, Amount: 150, Type: "pix_received", Description: "PIX RECEBIDO - CAFÉ & CIA"},
+		{ID: "tx-4", Date: now, Amount: -1200, Type: "bill_payment", Description: "Aluguel escritório"},
+	}
+	return service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+}
+
+func TestSearchTransactions_AccentInsensitive(t *testing.T) {
+	svc := newSearchService()
+
+	for _, q := range []string{"acucar", "AÇÚCAR", "uniao"} {
+		res, err := svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{Query: q})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", q, err)
+		}
+		if res.Total != 1 || res.Data[0].ID != "tx-1" {
+			t.Errorf("%q: expected tx-1, got %+v", q, res.Data)
+		}
+	}
+}
+
+func TestSearchTransactions_AmountAndType(t *testing.T) {
+	svc := newSearchService()
+	min, max := 100.0, 200.0
+
+	res, err := svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{MinAmount: &min, MaxAmount: &max})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Total != 2 {
+		t.Errorf("expected debit and credit of 150, got %+v", res.Data)
+	}
+
+	res, err = svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{MinAmount: &min, MaxAmount: &max, Type: "pix_sent"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Total != 1 || res.Data[0].ID != "tx-2" {
+		t.Errorf("expected tx-2, got %+v", res.Data)
+	}
+
+	_, err = svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{MinAmount: &max, MaxAmount: &min})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Errorf("expected validation error for inverted range, got %v", err)
+	}
+}
+
+func TestSearchTransactions_Direction(t *testing.T) {
+	svc := newSearchService()
+	min := 100.0
+
+	res, err := svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{MinAmount: &min, Direction: domain.TransactionDirectionOut})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tx := range res.Data {
+		if tx.Type == "pix_received" {
+			t.Errorf("direction=out returned income %+v", tx)
+		}
+	}
+	if res.Total != 2 {
+		t.Errorf("expected the pix_sent and bill_payment debits, got %+v", res.Data)
+	}
+
+	_, err = svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{Direction: "both"})
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Errorf("expected validation error for unknown direction, got %v", err)
+	}
+}
+
+func TestSearchTransactions_Paginates(t *testing.T) {
+	svc := newSearchService()
+
+	res, err := svc.SearchTransactions(context.Background(), testCustomerID, &domain.TransactionSearchFilter{Page: 2, PageSize: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Total != 4 || len(res.Data) != 1 || res.HasMore {
+		t.Errorf("unexpected page: total=%d len=%d hasMore=%v", res.Total, len(res.Data), res.HasMore)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+/*
+ * Transfer Receipts — comprovantes of non-PIX transfers
+ *
+ * PIX transfers keep their own pix_receipts. Internal, TED and DOC transfers
+ * share transfer_receipts: the sender is filled from the customer's
+ * registration data, as on PIX receipts, and the recipient is set by the
+ * transfer (for an internal transfer, the same customer).
+ */
+
+// transferReceiptTypes are the transfer types GenerateTransferReceipt accepts.
+var transferReceiptTypes = map[string]bool{
+	domain.TransferTypeInternal: true,
+	domain.TransferTypeTED:      true,
+	domain.TransferTypeDOC:      true,
+}
+
+// GenerateTransferReceipt stores the receipt of a completed non-PIX transfer.
+// The caller sets the transfer fields and the accounts involved; the sender's
+// name, document and bank are resolved here.
+func (s *BankingService) GenerateTransferReceipt(ctx context.Context, receipt *domain.TransferReceipt) (*domain.TransferReceipt, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GenerateTransferReceipt")
+	defer span.End()
+	span.SetAttributes(attribute.String("customer.id", receipt.CustomerID), attribute.String("transfer.type", receipt.TransferType))
+
+	switch {
+	case !transferReceiptTypes[receipt.TransferType]:
+		return nil, &domain.ErrValidation{Field: "transferType", Message: "must be internal, ted or doc"}
+	case receipt.TransferID == "":
+		return nil, &domain.ErrValidation{Field: "transferId", Message: "required"}
+	case receipt.CustomerID == "":
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+
+	name, doc, bank, branch, acct := s.resolveSenderData(ctx, receipt.CustomerID)
+	receipt.SenderName, receipt.SenderDocument, receipt.SenderBank = name, doc, bank
+	if receipt.SenderAccount == "" {
+		receipt.SenderBranch, receipt.SenderAccount = branch, acct
+	}
+	if receipt.TransferType == domain.TransferTypeInternal {
+		receipt.RecipientName, receipt.RecipientDocument, receipt.RecipientBank = name, doc, bank
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	receipt.ID = uuid.New().String()
+	receipt.Status = "completed"
+	if receipt.ExecutedAt == "" {
+		receipt.ExecutedAt = now
+	}
+	receipt.CreatedAt = now
+	return s.store.SaveTransferReceipt(ctx, receipt)
+}
+
+// GetTransferReceiptByTransferID returns the receipt of a non-PIX transfer.
+func (s *BankingService) GetTransferReceiptByTransferID(ctx context.Context, transferID string) (*domain.TransferReceipt, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.GetTransferReceiptByTransferID")
+	defer span.End()
+
+	return s.store.GetTransferReceiptByTransferID(ctx, transferID)
+}
+
+// saveInternalTransferReceipt generates the receipt of an internal transfer.
+// The money has already moved, so a failure is logged and the transfer is
+// returned without a receipt ID.
+func (s *BankingService) saveInternalTransferReceipt(ctx context.Context, transfer *domain.InternalTransfer, from, to *domain.Account) {
+	receipt, err := s.GenerateTransferReceipt(ctx, &domain.TransferReceipt{
+		TransferID:       transfer.ID,
+		TransferType:     domain.TransferTypeInternal,
+		CustomerID:       transfer.CustomerID,
+		Amount:           transfer.Amount,
+		Description:      "Transferência entre contas",
+		SenderBranch:     from.Branch,
+		SenderAccount:    fmt.Sprintf("%s-%s", from.AccountNumber, from.Digit),
+		RecipientBranch:  to.Branch,
+		RecipientAccount: fmt.Sprintf("%s-%s", to.AccountNumber, to.Digit),
+		ExecutedAt:       transfer.CreatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		s.logger.Error("failed to save internal transfer receipt",
+			zap.String("transfer_id", transfer.ID), zap.Error(err))
+		return
+	}
+	transfer.ReceiptID = receipt.ID
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+/*
+ * Webhooks — registration, event publishing and delivery logs
+ */
+
+// webhookDeliveriesLimit caps how many recent deliveries are listed.
+const webhookDeliveriesLimit = 50
+
+// SetWebhookDispatcher wires the dispatcher used to notify integrators
+// (webhooks) about completed transfers and payments, and to resend deliveries.
+func (s *BankingService) SetWebhookDispatcher(d port.WebhookDispatcher) {
+	s.events = d
+}
+
+// publishEvent notifies the event publisher, if any. It never fails the
+// calling operation.
+func (s *BankingService) publishEvent(ctx context.Context, customerID, eventType string, data any) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(ctx, &domain.WebhookEvent{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		CustomerID: customerID,
+		OccurredAt: time.Now(),
+		Data:       data,
+	})
+}
+
+// validateWebhookURL accepts absolute https URLs whose host is not localhost
+// or a loopback, private or link-local IP. Hostnames are resolved and checked
+// again by the dispatcher on every delivery, where the answer can no longer
+// change under it.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return &domain.ErrValidation{Field: "url", Message: "must be an absolute https URL"}
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return &domain.ErrValidation{Field: "url", Message: "must not point to a local or private address"}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap()
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() || addr.IsMulticast() {
+			return &domain.ErrValidation{Field: "url", Message: "must not point to a local or private address"}
+		}
+	}
+	return nil
+}
+
+// RegisterWebhook stores a new webhook for the customer. When no secret is
+// provided one is generated; the secret is only returned here.
+func (s *BankingService) RegisterWebhook(ctx context.Context, customerID string, req *domain.WebhookRegisterRequest) (*domain.Webhook, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RegisterWebhook")
+	defer span.End()
+
+	if customerID == "" {
+		return nil, &domain.ErrValidation{Field: "customerId", Message: "required"}
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	for _, e := range req.Events {
+		if !slices.Contains(domain.WebhookEventTypes, e) {
+			return nil, &domain.ErrValidation{Field: "events", Message: fmt.Sprintf("unknown event type '%s'", e)}
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	hook, err := s.store.CreateWebhook(ctx, &domain.Webhook{
+		CustomerID: customerID,
+		URL:        req.URL,
+		Secret:     secret,
+		Events:     req.Events,
+		Status:     "active",
+	})
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = secret
+
+	s.logger.Info("webhook registered",
+		zap.String("customer_id", customerID),
+		zap.String("webhook_id", hook.ID),
+		zap.Strings("events", req.Events))
+
+	return hook, nil
+}
+
+// ListWebhooks returns the customer's webhooks. Secrets are never listed.
+func (s *BankingService) ListWebhooks(ctx context.Context, customerID string) ([]domain.Webhook, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ListWebhooks")
+	defer span.End()
+
+	hooks, err := s.store.ListWebhooks(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	if hooks == nil {
+		hooks = []domain.Webhook{}
+	}
+	return hooks, nil
+}
+
+// RevokeWebhook stops all future deliveries to the webhook. Its delivery
+// logs are kept.
+func (s *BankingService) RevokeWebhook(ctx context.Context, customerID, webhookID string) error {
+	ctx, span := bankTracer.Start(ctx, "BankingService.RevokeWebhook")
+	defer span.End()
+
+	hook, err := s.store.GetWebhook(ctx, customerID, webhookID)
+	if err != nil {
+		return err
+	}
+	if hook.Status == "revoked" {
+		return nil
+	}
+	if err := s.store.RevokeWebhook(ctx, customerID, webhookID); err != nil {
+		return err
+	}
+
+	s.logger.Info("webhook revoked",
+		zap.String("customer_id", customerID),
+		zap.String("webhook_id", webhookID))
+	return nil
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of one of the
+// customer's webhooks, newest first.
+func (s *BankingService) ListWebhookDeliveries(ctx context.Context, customerID, webhookID string) ([]domain.WebhookDelivery, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ListWebhookDeliveries")
+	defer span.End()
+
+	if _, err := s.store.GetWebhook(ctx, customerID, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.ListWebhookDeliveries(ctx, webhookID, webhookDeliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// ResendWebhookDelivery delivers a logged payload again, synchronously, and
+// returns the new delivery log entry. A failed resend is reported in the
+// returned delivery, not as an error.
+func (s *BankingService) ResendWebhookDelivery(ctx context.Context, customerID, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	ctx, span := bankTracer.Start(ctx, "BankingService.ResendWebhookDelivery")
+	defer span.End()
+
+	if s.events == nil {
+		return nil, fmt.Errorf("webhook dispatcher not configured")
+	}
+
+	hook, err := s.store.GetWebhook(ctx, customerID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook.Status != "active" {
+		return nil, &domain.ErrValidation{Field: "webhookId", Message: "webhook is not active"}
+	}
+	original, err := s.store.GetWebhookDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.events.Resend(ctx, hook, original)
+	if delivery == nil {
+		return nil, err
+	}
+
+	s.logger.Info("webhook delivery resent",
+		zap.String("webhook_id", webhookID),
+		zap.String("original_delivery_id", deliveryID),
+		zap.String("delivery_id", delivery.ID),
+		zap.String("status", delivery.Status))
+	return delivery, nil
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/observability"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	store := newFakeBankingStore()
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	ctx := context.Background()
+
+	hook, err := svc.RegisterWebhook(ctx, testCustomerID, &domain.WebhookRegisterRequest{
+		URL:    "https://erp.example.com/hooks/bank",
+		Events: []string{domain.EventPixTransferCompleted},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hook.Secret) != 64 {
+		t.Errorf("expected a generated 32-byte hex secret, got %q", hook.Secret)
+	}
+
+	var validation *domain.ErrValidation
+	for _, rejected := range []string{
+		"ftp://erp.example.com",
+		"http://erp.example.com/hooks/bank",
+		"https://localhost/hooks",
+		"https://127.0.0.1/hooks",
+		"https://10.0.0.5/hooks",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hooks",
+	} {
+		_, err = svc.RegisterWebhook(ctx, testCustomerID, &domain.WebhookRegisterRequest{URL: rejected})
+		if !errors.As(err, &validation) || validation.Field != "url" {
+			t.Errorf("%s: expected url validation error, got %v", rejected, err)
+		}
+	}
+
+	_, err = svc.RegisterWebhook(ctx, testCustomerID, &domain.WebhookRegisterRequest{
+		URL:    "https://erp.example.com/hooks/bank",
+		Events: []string{"pix.unknown"},
+	})
+	if !errors.As(err, &validation) || validation.Field != "events" {
+		t.Errorf("expected events validation error, got %v", err)
+	}
+}
+
+func TestCreatePixTransfer_PublishesEvent(t *testing.T) {
+	store := newFakeBankingStore()
+	seedCustomer(store, 1000)
+	publisher := &recordingPublisher{}
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	svc.SetWebhookDispatcher(publisher)
+
+	transfer, err := svc.CreatePixTransfer(context.Background(), testCustomerID, &domain.PixTransferRequest{
+		IdempotencyKey:      "idem-1",
+		SourceAccountID:     testAccountID,
+		DestinationKeyValue: "fornecedor@example.com",
+		Amount:              100,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(publisher.events))
+	}
+	event := publisher.events[0]
+	if event.Type != domain.EventPixTransferCompleted || event.CustomerID != testCustomerID {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if data, ok := event.Data.(*domain.PixTransfer); !ok || data.ID != transfer.ID {
+		t.Errorf("expected transfer payload, got %#v", event.Data)
+	}
+}
+
+func TestWebhookDeliveries_ListRevokeResend(t *testing.T) {
+	store := newFakeBankingStore()
+	svc := service.NewBankingService(store, observability.NewMetrics(), zap.NewNop())
+	publisher := &recordingPublisher{}
+	svc.SetWebhookDispatcher(publisher)
+	ctx := context.Background()
+
+	hook, err := svc.RegisterWebhook(ctx, testCustomerID, &domain.WebhookRegisterRequest{URL: "https://erp.example.com/hooks/bank"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.deliveries = append(store.deliveries, domain.WebhookDelivery{
+		ID: "dlv-1", WebhookID: hook.ID, CustomerID: testCustomerID, EventID: "evt-1",
+		EventType: domain.EventBillPaid, Payload: `{"id":"evt-1"}`, Status: "failed", ResponseCode: 500, Attempts: 3,
+	})
+
+	hooks, err := svc.ListWebhooks(ctx, testCustomerID)
+	if err != nil || len(hooks) != 1 {
+		t.Fatalf("expected 1 webhook, got %d (%v)", len(hooks), err)
+	}
+	if hooks[0].Secret != "" {
+		t.Error("listed webhook must not expose its secret")
+	}
+
+	deliveries, err := svc.ListWebhookDeliveries(ctx, testCustomerID, hook.ID)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d (%v)", len(deliveries), err)
+	}
+	if _, err := svc.ListWebhookDeliveries(ctx, "other-customer", hook.ID); err == nil {
+		t.Error("expected not found for another customer's webhook")
+	}
+
+	resent, err := svc.ResendWebhookDelivery(ctx, testCustomerID, hook.ID, "dlv-1")
+	if err != nil {
+		t.Fatalf("unexpected resend error: %v", err)
+	}
+	if resent.Status != "delivered" || len(publisher.resends) != 1 || publisher.resends[0].Payload != `{"id":"evt-1"}` {
+		t.Errorf("expected the original payload to be resent, got %+v", publisher.resends)
+	}
+
+	if err := svc.RevokeWebhook(ctx, testCustomerID, hook.ID); err != nil {
+		t.Fatalf("unexpected revoke error: %v", err)
+	}
+	_, err = svc.ResendWebhookDelivery(ctx, testCustomerID, hook.ID, "dlv-1")
+	var validation *domain.ErrValidation
+	if !errors.As(err, &validation) {
+		t.Errorf("expected resend to a revoked webhook to fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package webhook delivers signed event notifications to customer-configured
+// HTTP endpoints, with retry/backoff and a dead-letter log for failures.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/port"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var tracer = otel.Tracer("webhook")
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Signature"     // hex HMAC-SHA256 of the raw body
+	EventHeader     = "X-Webhook-Event" // event type
+	EventIDHeader   = "X-Webhook-ID"    // event ID, stable across retries
+)
+
+// Dispatcher POSTs events to every active webhook of the customer that
+// subscribes to the event type. It implements port.WebhookDispatcher.
+type Dispatcher struct {
+	store      port.WebhookStore
+	httpClient *http.Client
+	retryCfg   resilience.Config
+	logger     *zap.Logger
+
+	wg sync.WaitGroup
+}
+
+// NewDispatcher creates a webhook dispatcher.
+func NewDispatcher(store port.WebhookStore, httpClient *http.Client, retryCfg resilience.Config, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:      store,
+		httpClient: httpClient,
+		retryCfg:   retryCfg,
+		logger:     logger,
+	}
+}
+
+// Publish delivers the event asynchronously. Deliveries outlive the caller's
+// context (e.g. the HTTP request that triggered the event).
+func (d *Dispatcher) Publish(ctx context.Context, event *domain.WebhookEvent) {
+	ctx = context.WithoutCancel(ctx)
+
+	hooks, err := d.store.ListWebhooks(ctx, event.CustomerID)
+	if err != nil {
+		d.logger.Error("webhook: failed to list webhooks",
+			zap.String("customer_id", event.CustomerID),
+			zap.String("event_type", event.Type),
+			zap.Error(err))
+		return
+	}
+
+	for i := range hooks {
+		hook := hooks[i]
+		if hook.Status != "active" || !hook.Subscribes(event.Type) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			_, _ = d.Deliver(ctx, &hook, event)
+		}()
+	}
+}
+
+// Wait blocks until all in-flight deliveries have finished.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Deliver POSTs the signed event to a single webhook, retrying with backoff,
+// and logs the outcome. When all attempts fail the event is also written to
+// the dead-letter log.
+func (d *Dispatcher) Deliver(ctx context.Context, hook *domain.Webhook, event *domain.WebhookEvent) (*domain.WebhookDelivery, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook event: %w", err)
+	}
+	return d.send(ctx, hook, event.ID, event.Type, body)
+}
+
+// Resend delivers a previously logged payload again, byte for byte, so the
+// receiver can dedup on the event ID. The new attempt gets its own log entry.
+func (d *Dispatcher) Resend(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	return d.send(ctx, hook, delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook *domain.Webhook, eventID, eventType string, body []byte) (*domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "Webhook.Deliver")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("webhook.id", hook.ID),
+		attribute.String("event.type", eventType),
+	)
+
+	signature := Sign(hook.Secret, body)
+
+	// Only https endpoints are called, without retrying the ones that are
+	// not; the client checks the address each connection resolves to (see
+	// NewHTTPClient).
+	attempts, statusCode := 0, 0
+	var err error
+	if u, parseErr := url.Parse(hook.URL); parseErr != nil || u.Scheme != "https" || u.Host == "" {
+		err = fmt.Errorf("webhook URL %q is not an absolute https URL", hook.URL)
+	} else {
+		err = resilience.RetryWithBackoff(ctx, d.retryCfg, func() error {
+			attempts++
+			var postErr error
+			statusCode, postErr = d.post(ctx, hook.URL, body, signature, eventID, eventType)
+			return postErr
+		})
+	}
+
+	delivery := &domain.WebhookDelivery{
+		ID:           uuid.New().String(),
+		WebhookID:    hook.ID,
+		CustomerID:   hook.CustomerID,
+		EventID:      eventID,
+		EventType:    eventType,
+		Payload:      string(body),
+		Status:       "delivered",
+		ResponseCode: statusCode,
+		Attempts:     attempts,
+		CreatedAt:    time.Now(),
+	}
+	if err != nil {
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+	}
+	if logErr := d.store.SaveWebhookDelivery(ctx, delivery); logErr != nil {
+		d.logger.Warn("webhook: failed to save delivery log",
+			zap.String("webhook_id", hook.ID),
+			zap.String("event_id", eventID),
+			zap.Error(logErr))
+	}
+
+	if err != nil {
+		d.logger.Error("webhook dead-letter: delivery failed after retries",
+			zap.String("webhook_id", hook.ID),
+			zap.String("customer_id", hook.CustomerID),
+			zap.String("url", hook.URL),
+			zap.String("event_id", eventID),
+			zap.String("event_type", eventType),
+			zap.Int("attempts", attempts),
+			zap.Int("response_code", statusCode),
+			zap.ByteString("payload", body),
+			zap.Error(err))
+		return delivery, err
+	}
+
+	d.logger.Info("webhook delivered",
+		zap.String("webhook_id", hook.ID),
+		zap.String("event_id", eventID),
+		zap.String("event_type", eventType),
+		zap.Int("attempts", attempts))
+	return delivery, nil
+}
+
+// post sends one attempt and returns the HTTP status code (0 if no response).
+func (d *Dispatcher) post(ctx context.Context, url string, body []byte, signature, eventID, eventType string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(EventIDHeader, eventID)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body using secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid Sign(secret, body) value.
+// Receivers use it to authenticate deliveries.
+func Verify(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
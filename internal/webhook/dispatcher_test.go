@@ -0,0 +1,266 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/domain"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/infra/resilience"
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/webhook"
+
+	"go.uber.org/zap"
+)
+
+/* Mocks */
+
+type mockWebhookStore struct {
+	mu         sync.Mutex
+	hooks      []domain.Webhook
+	deliveries []domain.WebhookDelivery
+}
+
+func (m *mockWebhookStore) CreateWebhook(_ context.Context, hook *domain.Webhook) (*domain.Webhook, error) {
+	m.hooks = append(m.hooks, *hook)
+	return hook, nil
+}
+
+func (m *mockWebhookStore) ListWebhooks(_ context.Context, customerID string) ([]domain.Webhook, error) {
+	var out []domain.Webhook
+	for _, h := range m.hooks {
+		if h.CustomerID == customerID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWebhookStore) GetWebhook(_ context.Context, customerID, webhookID string) (*domain.Webhook, error) {
+	for _, h := range m.hooks {
+		if h.ID == webhookID && h.CustomerID == customerID {
+			return &h, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "webhook", ID: webhookID}
+}
+
+func (m *mockWebhookStore) RevokeWebhook(_ context.Context, _, _ string) error { return nil }
+
+func (m *mockWebhookStore) SaveWebhookDelivery(_ context.Context, delivery *domain.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, *delivery)
+	return nil
+}
+
+func (m *mockWebhookStore) ListWebhookDeliveries(_ context.Context, webhookID string, _ int) ([]domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []domain.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockWebhookStore) GetWebhookDelivery(_ context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID && d.WebhookID == webhookID {
+			return &d, nil
+		}
+	}
+	return nil, &domain.ErrNotFound{Resource: "webhook_delivery", ID: deliveryID}
+}
+
+type receivedDelivery struct {
+	body      []byte
+	signature string
+	eventType string
+}
+
+// newReceiver starts a mock webhook endpoint that fails the first failures requests.
+func newReceiver(t *testing.T, failures int32) (*httptest.Server, *[]receivedDelivery, *atomic.Int32) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []receivedDelivery
+	var calls atomic.Int32
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedDelivery{
+			body:      body,
+			signature: r.Header.Get(webhook.SignatureHeader),
+			eventType: r.Header.Get(webhook.EventHeader),
+		})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received, &calls
+}
+
+var fastRetry = resilience.Config{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+/* Tests */
+
+func TestDispatcher_PublishSignsPayload(t *testing.T) {
+	srv, received, _ := newReceiver(t, 0)
+	store := &mockWebhookStore{hooks: []domain.Webhook{
+		{ID: "wh-1", CustomerID: "cust-1", URL: srv.URL, Secret: "s3cr3t", Status: "active"},
+		{ID: "wh-2", CustomerID: "cust-1", URL: srv.URL, Secret: "other", Status: "active", Events: []string{domain.EventBillPaid}},
+	}}
+	d := webhook.NewDispatcher(store, srv.Client(), fastRetry, zap.NewNop())
+
+	d.Publish(context.Background(), &domain.WebhookEvent{
+		ID:         "evt-1",
+		Type:       domain.EventPixTransferCompleted,
+		CustomerID: "cust-1",
+		OccurredAt: time.Now(),
+		Data:       map[string]any{"amount": 100.0},
+	})
+	d.Wait()
+
+	if len(*received) != 1 {
+		t.Fatalf("expected 1 delivery (wh-2 is not subscribed), got %d", len(*received))
+	}
+	got := (*received)[0]
+	if !webhook.Verify("s3cr3t", got.body, got.signature) {
+		t.Error("signature does not verify with the shared secret")
+	}
+	if webhook.Verify("wrong-secret", got.body, got.signature) {
+		t.Error("signature verified with the wrong secret")
+	}
+	if got.eventType != domain.EventPixTransferCompleted {
+		t.Errorf("event header = %q", got.eventType)
+	}
+
+	var event domain.WebhookEvent
+	if err := json.Unmarshal(got.body, &event); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if event.ID != "evt-1" || event.CustomerID != "cust-1" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestDispatcher_RetriesUntilSuccess(t *testing.T) {
+	srv, received, calls := newReceiver(t, 2)
+	d := webhook.NewDispatcher(&mockWebhookStore{}, srv.Client(), fastRetry, zap.NewNop())
+
+	hook := &domain.Webhook{ID: "wh-1", CustomerID: "cust-1", URL: srv.URL, Secret: "s3cr3t"}
+	_, err := d.Deliver(context.Background(), hook, &domain.WebhookEvent{ID: "evt-1", Type: domain.EventBillPaid, CustomerID: "cust-1"})
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+	if len(*received) != 1 {
+		t.Errorf("expected 1 successful delivery, got %d", len(*received))
+	}
+}
+
+func TestDispatcher_DeadLetterAfterRetries(t *testing.T) {
+	srv, _, calls := newReceiver(t, 100)
+	d := webhook.NewDispatcher(&mockWebhookStore{}, srv.Client(), fastRetry, zap.NewNop())
+
+	hook := &domain.Webhook{ID: "wh-1", CustomerID: "cust-1", URL: srv.URL, Secret: "s3cr3t"}
+	_, err := d.Deliver(context.Background(), hook, &domain.WebhookEvent{ID: "evt-1", Type: domain.EventInvoicePaid, CustomerID: "cust-1"})
+	if err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if calls.Load() != int32(fastRetry.MaxRetries+1) {
+		t.Errorf("expected %d attempts, got %d", fastRetry.MaxRetries+1, calls.Load())
+	}
+}
+
+func TestDispatcher_RefusesNonHTTPSAndInternalAddresses(t *testing.T) {
+	var calls atomic.Int32
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(plain.Close)
+	event := &domain.WebhookEvent{ID: "evt-1", Type: domain.EventInvoicePaid, CustomerID: "cust-1"}
+
+	d := webhook.NewDispatcher(&mockWebhookStore{}, plain.Client(), fastRetry, zap.NewNop())
+	delivery, err := d.Deliver(context.Background(), &domain.Webhook{ID: "wh-1", CustomerID: "cust-1", URL: plain.URL}, event)
+	if err == nil || delivery.Status != "failed" || delivery.Attempts != 0 {
+		t.Errorf("http endpoint: delivery %+v, err %v; want failed without attempts", delivery, err)
+	}
+
+	// The TLS receiver listens on loopback, which the delivery client
+	// refuses once the host is resolved.
+	srv, _, tlsCalls := newReceiver(t, 0)
+	d = webhook.NewDispatcher(&mockWebhookStore{}, webhook.NewHTTPClient(time.Second), fastRetry, zap.NewNop())
+	if _, err := d.Deliver(context.Background(), &domain.Webhook{ID: "wh-2", CustomerID: "cust-1", URL: srv.URL}, event); err == nil {
+		t.Error("loopback endpoint: expected delivery error")
+	}
+	if calls.Load() != 0 || tlsCalls.Load() != 0 {
+		t.Errorf("endpoints called %d and %d times, want 0", calls.Load(), tlsCalls.Load())
+	}
+}
+
+func TestDispatcher_LogsDeliveryAndResends(t *testing.T) {
+	srv, received, _ := newReceiver(t, 100)
+	store := &mockWebhookStore{}
+	d := webhook.NewDispatcher(store, srv.Client(), fastRetry, zap.NewNop())
+
+	hook := &domain.Webhook{ID: "wh-1", CustomerID: "cust-1", URL: srv.URL, Secret: "s3cr3t"}
+	failed, err := d.Deliver(context.Background(), hook, &domain.WebhookEvent{ID: "evt-1", Type: domain.EventPixTransferCompleted, CustomerID: "cust-1"})
+	if err == nil {
+		t.Fatal("expected delivery error")
+	}
+
+	logged, _ := store.ListWebhookDeliveries(context.Background(), "wh-1", 10)
+	if len(logged) != 1 {
+		t.Fatalf("expected 1 logged delivery, got %d", len(logged))
+	}
+	if got := logged[0]; got.ID != failed.ID || got.Status != "failed" || got.ResponseCode != http.StatusInternalServerError ||
+		got.Attempts != fastRetry.MaxRetries+1 || got.EventID != "evt-1" || got.EventType != domain.EventPixTransferCompleted {
+		t.Errorf("unexpected delivery log: %+v", got)
+	}
+
+	// Endpoint recovers: resending the logged delivery posts the same payload.
+	okSrv, okReceived, _ := newReceiver(t, 0)
+	hook.URL = okSrv.URL
+	d = webhook.NewDispatcher(store, okSrv.Client(), fastRetry, zap.NewNop())
+
+	resent, err := d.Resend(context.Background(), hook, &logged[0])
+	if err != nil {
+		t.Fatalf("unexpected resend error: %v", err)
+	}
+	if resent.Status != "delivered" || resent.ResponseCode != http.StatusNoContent || resent.Attempts != 1 || resent.ID == failed.ID {
+		t.Errorf("unexpected resend log: %+v", resent)
+	}
+	if len(*okReceived) != 1 || string((*okReceived)[0].body) != logged[0].Payload {
+		t.Fatalf("expected the original payload to be resent")
+	}
+	if !webhook.Verify("s3cr3t", (*okReceived)[0].body, (*okReceived)[0].signature) {
+		t.Error("resent payload signature does not verify")
+	}
+	if len(*received) != 0 {
+		t.Errorf("failing endpoint should not have accepted deliveries, got %d", len(*received))
+	}
+
+	logged, _ = store.ListWebhookDeliveries(context.Background(), "wh-1", 10)
+	if len(logged) != 2 {
+		t.Errorf("expected resend to add a new log entry, got %d entries", len(logged))
+	}
+}
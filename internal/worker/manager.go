@@ -0,0 +1,113 @@
+// Package worker runs periodic background jobs (scheduled transfers,
+// invoice closing, ...) with a coordinated, draining shutdown.
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Func is one iteration of a worker. It should do a bounded batch of work.
+type Func func(ctx context.Context) error
+
+type registration struct {
+	name     string
+	interval time.Duration
+	fn       Func
+}
+
+// Manager starts registered workers and stops them together. On Stop no new
+// iterations are started, but an iteration already running is allowed to
+// finish (its context is not cancelled), so a batch is never interrupted
+// mid-debit.
+type Manager struct {
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	workers []registration
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewManager creates an empty worker manager.
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Register adds a worker that runs fn every interval. Workers must be
+// registered before Start.
+func (m *Manager) Register(name string, interval time.Duration, fn Func) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workers = append(m.workers, registration{name: name, interval: interval, fn: fn})
+}
+
+// Start launches every registered worker. Cancelling ctx has the same effect
+// as Stop, without waiting.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+
+	for _, w := range m.workers {
+		m.wg.Add(1)
+		go m.run(loopCtx, w)
+	}
+	m.logger.Info("workers started", zap.Int("count", len(m.workers)))
+}
+
+// Stop stops scheduling new iterations and waits for running ones to finish,
+// or until ctx is done, in which case ctx.Err() is returned.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.logger.Info("workers stopped")
+		return nil
+	case <-ctx.Done():
+		m.logger.Warn("workers did not stop in time", zap.Error(ctx.Err()))
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) run(ctx context.Context, w registration) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Iterations outlive the stop signal: only scheduling is cancelled.
+	iterCtx := context.WithoutCancel(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		if err := w.fn(iterCtx); err != nil {
+			m.logger.Error("worker iteration failed",
+				zap.String("worker", w.name),
+				zap.Duration("duration", time.Since(start)),
+				zap.Error(err))
+		}
+	}
+}
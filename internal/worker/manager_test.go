@@ -0,0 +1,100 @@
+package worker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boddenberg/pj-assistant-bfa-go/internal/worker"
+
+	"go.uber.org/zap"
+)
+
+func TestManager_StopWaitsForRunningIteration(t *testing.T) {
+	m := worker.NewManager(zap.NewNop())
+
+	started := make(chan struct{}, 1)
+	var completed, cancelledMidway atomic.Bool
+	m.Register("slow-batch", 5*time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil // only the first iteration is slow
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+			completed.Store(true)
+		case <-ctx.Done():
+			cancelledMidway.Store(true)
+		}
+		return nil
+	})
+
+	m.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Stop(ctx); err != nil {
+		t.Fatalf("unexpected stop error: %v", err)
+	}
+
+	if cancelledMidway.Load() {
+		t.Error("running iteration was cancelled on shutdown")
+	}
+	if !completed.Load() {
+		t.Error("Stop returned before the running iteration completed")
+	}
+}
+
+func TestManager_StopIsBounded(t *testing.T) {
+	m := worker.NewManager(zap.NewNop())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	m.Register("stuck", 5*time.Millisecond, func(context.Context) error {
+		select {
+		case <-started:
+		default:
+			close(started)
+		}
+		<-release
+		return nil
+	})
+
+	m.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Stop(ctx); err == nil {
+		t.Error("expected Stop to give up after its deadline")
+	}
+}
+
+func TestManager_StopsScheduling(t *testing.T) {
+	m := worker.NewManager(zap.NewNop())
+
+	var runs atomic.Int32
+	m.Register("counter", 2*time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	m.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("unexpected stop error: %v", err)
+	}
+
+	after := runs.Load()
+	if after == 0 {
+		t.Fatal("worker never ran")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != after {
+		t.Error("worker kept running after Stop")
+	}
+}
@@ -0,0 +1,29 @@
+-- ============================================================
+-- Migration: webhooks
+-- Endpoints configurados pelo cliente para receber eventos
+-- (pix.transfer.completed, bill.paid, invoice.paid) via POST assinado.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,                    -- chave HMAC-SHA256 compartilhada
+    events TEXT[] NOT NULL DEFAULT '{}',     -- vazio = todos os eventos
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'revoked')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_customer_active
+    ON webhooks (customer_id)
+    WHERE status = 'active';
+
+COMMENT ON TABLE webhooks IS 'Webhooks registrados por clientes/integradores para notificações push';
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Service role full access webhooks"
+    ON webhooks FOR ALL
+    USING (auth.role() = 'service_role');
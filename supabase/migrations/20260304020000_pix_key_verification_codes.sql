@@ -0,0 +1,26 @@
+-- ============================================================
+-- Migration: pix_key_verification_codes
+-- Códigos de verificação de posse para chaves Pix email/telefone
+-- (mesmo formato de auth_password_reset_codes).
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS pix_key_verification_codes (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    customer_id TEXT NOT NULL
+        REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    key_type TEXT NOT NULL CHECK (key_type IN ('email', 'phone')),
+    key_value TEXT NOT NULL,
+    code TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pix_key_codes_customer_key
+    ON pix_key_verification_codes(customer_id, key_type, key_value);
+
+ALTER TABLE pix_key_verification_codes ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Service role full access pix_key_verification_codes"
+    ON pix_key_verification_codes FOR ALL
+    USING (auth.role() = 'service_role');
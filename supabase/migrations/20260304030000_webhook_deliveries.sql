@@ -0,0 +1,29 @@
+-- ============================================================
+-- Migration: webhook_deliveries
+-- Log de entregas de webhooks (uma linha por entrega, incluindo
+-- retentativas) para depuração e reenvio.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    customer_id TEXT NOT NULL
+        REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,                   -- corpo JSON exato que foi assinado
+    status TEXT NOT NULL CHECK (status IN ('delivered', 'failed')),
+    response_code INTEGER DEFAULT 0,         -- último HTTP status (0 = sem resposta)
+    attempts INTEGER NOT NULL DEFAULT 1,
+    error TEXT DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+    ON webhook_deliveries (webhook_id, created_at DESC);
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Service role full access webhook_deliveries"
+    ON webhook_deliveries FOR ALL
+    USING (auth.role() = 'service_role');
@@ -0,0 +1,16 @@
+-- ============================================================
+-- Migration: pix_keys soft delete
+-- Exclusão de chave Pix passa a marcar status = 'deleted' com
+-- deleted_at, preservando o histórico referenciado pelos comprovantes.
+-- O valor só precisa ser único entre chaves não excluídas.
+-- ============================================================
+
+ALTER TABLE pix_keys ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE pix_keys DROP CONSTRAINT IF EXISTS pix_keys_status_check;
+ALTER TABLE pix_keys ADD CONSTRAINT pix_keys_status_check
+    CHECK (status IN ('active', 'pending', 'inactive', 'portability_requested', 'deleted'));
+
+ALTER TABLE pix_keys DROP CONSTRAINT IF EXISTS pix_keys_key_value_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pix_keys_value_not_deleted
+    ON pix_keys(key_value) WHERE status <> 'deleted';
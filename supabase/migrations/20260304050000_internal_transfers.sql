@@ -0,0 +1,56 @@
+-- ============================================================
+-- Migration: transfer_between_accounts
+-- Transferência entre contas do mesmo cliente. Débito e crédito
+-- acontecem na mesma transação; as linhas são travadas em ordem
+-- de id para evitar deadlock entre transferências opostas.
+-- ============================================================
+
+CREATE OR REPLACE FUNCTION transfer_between_accounts(
+    p_customer_id TEXT,
+    p_from_account_id UUID,
+    p_to_account_id UUID,
+    p_amount NUMERIC
+)
+RETURNS JSON
+LANGUAGE plpgsql
+SECURITY DEFINER
+AS $$
+DECLARE
+    locked INT;
+    from_balance NUMERIC;
+    to_balance NUMERIC;
+BEGIN
+    IF p_amount <= 0 OR p_from_account_id = p_to_account_id THEN
+        RAISE EXCEPTION 'invalid_transfer';
+    END IF;
+
+    SELECT COUNT(*) INTO locked FROM (
+        SELECT id FROM accounts
+        WHERE id IN (p_from_account_id, p_to_account_id)
+          AND customer_id = p_customer_id
+          AND status = 'active'
+        ORDER BY id
+        FOR UPDATE
+    ) l;
+    IF locked <> 2 THEN
+        RAISE EXCEPTION 'account_not_found';
+    END IF;
+
+    UPDATE accounts
+    SET balance = balance - p_amount,
+        available_balance = available_balance - p_amount
+    WHERE id = p_from_account_id AND available_balance >= p_amount
+    RETURNING balance INTO from_balance;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'insufficient_funds';
+    END IF;
+
+    UPDATE accounts
+    SET balance = balance + p_amount,
+        available_balance = available_balance + p_amount
+    WHERE id = p_to_account_id
+    RETURNING balance INTO to_balance;
+
+    RETURN json_build_object('from_balance', from_balance, 'to_balance', to_balance);
+END;
+$$;
@@ -0,0 +1,13 @@
+-- ============================================================
+-- Migration: spending_summary_cache
+-- spending_summaries passa a guardar o resumo financeiro pré-calculado
+-- pelo BFA (janelas móveis de 1/7/30/365 dias terminando hoje). As
+-- linhas são apagadas quando o cliente recebe novas transações.
+-- ============================================================
+
+ALTER TABLE spending_summaries
+    ADD COLUMN IF NOT EXISTS net_cashflow_variation_pct NUMERIC(8,2) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS monthly_trend JSONB DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS idx_spending_summaries_window
+    ON spending_summaries(customer_id, period_start, period_end);
@@ -0,0 +1,14 @@
+-- ============================================================
+-- Migration: transaction_status
+-- Lançamentos do extrato passam a ter status. O BFA grava o
+-- lançamento como 'pending' antes de mover o saldo e só então o
+-- confirma; se o saldo não puder ser movido o lançamento vira
+-- 'failed'. Leituras do extrato consideram apenas 'confirmed'.
+-- ============================================================
+
+ALTER TABLE customer_transactions
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'confirmed'
+        CHECK (status IN ('pending', 'confirmed', 'failed'));
+
+CREATE INDEX IF NOT EXISTS idx_customer_transactions_pending
+    ON customer_transactions(created_at) WHERE status = 'pending';
@@ -0,0 +1,10 @@
+-- ============================================================
+-- Migration: reset_code_attempts
+-- Conta as tentativas erradas de cada código de reset de senha.
+-- Após o limite de tentativas o código é invalidado (used = true),
+-- mesmo antes de expirar, impedindo força bruta no código de 6
+-- dígitos.
+-- ============================================================
+
+ALTER TABLE auth_password_reset_codes
+    ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1,24 @@
+-- ============================================================
+-- Migration: notification_preferences
+-- Preferências de notificação do cliente, por tipo e canal.
+-- Guarda apenas o que o cliente alterou, no formato
+-- {"<tipo>": {"push": bool, "email": bool, "inApp": bool}};
+-- tipos ausentes ficam com todos os canais ligados.
+--
+-- Notificações criadas pelo BFA pertencem à empresa (customer_id)
+-- e não a um usuário específico, por isso user_id deixa de ser
+-- obrigatório em notifications.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    customer_id TEXT PRIMARY KEY REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    preferences JSONB NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notification_preferences ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE notifications ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_customer
+    ON notifications(customer_id, created_at DESC);
@@ -0,0 +1,14 @@
+-- ============================================================
+-- Migration: pix_transfer_scheduled_status
+-- Pix agendado pelo endpoint principal de transferência: a
+-- transferência é gravada com status 'scheduled' e executada
+-- pelo worker quando scheduled_for vence.
+-- ============================================================
+
+ALTER TABLE pix_transfers DROP CONSTRAINT IF EXISTS pix_transfers_status_check;
+ALTER TABLE pix_transfers ADD CONSTRAINT pix_transfers_status_check
+    CHECK (status IN ('pending', 'scheduled', 'processing', 'completed', 'failed', 'cancelled', 'returned'));
+
+DROP INDEX IF EXISTS idx_pix_transfers_scheduled;
+CREATE INDEX IF NOT EXISTS idx_pix_transfers_scheduled ON pix_transfers(scheduled_for)
+    WHERE status = 'scheduled';
@@ -0,0 +1,9 @@
+-- ============================================================
+-- Migration: scheduled_transfer_skips
+-- Ocorrências de um agendamento recorrente puladas pelo cliente
+-- (POST /v1/pix/schedule/{id}/skip-next). O worker executa a
+-- partir de next_execution_date, que o skip avança um período.
+-- ============================================================
+
+ALTER TABLE scheduled_transfers
+    ADD COLUMN IF NOT EXISTS skipped_dates DATE[] NOT NULL DEFAULT '{}';
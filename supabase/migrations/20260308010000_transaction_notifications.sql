@@ -0,0 +1,25 @@
+-- ============================================================
+-- Migration: transaction_notifications
+-- Notificações de movimentação de saldo (Pix, boletos e compras
+-- no débito) usam o tipo 'transaction'. O valor mínimo notificado
+-- pode ser alterado pelo cliente em notification_preferences,
+-- no campo "minAmount" do tipo transaction.
+-- ============================================================
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN (
+        'transaction',
+        'pix_sent', 'pix_received',
+        'transfer_scheduled', 'transfer_executed', 'transfer_failed',
+        'bill_due', 'bill_paid', 'bill_failed',
+        'card_purchase', 'card_invoice_available', 'card_invoice_due',
+        'card_limit_alert', 'card_approved', 'card_blocked',
+        'budget_alert', 'budget_exceeded',
+        'balance_low',
+        'security_alert', 'login_alert',
+        'general'
+    ));
@@ -0,0 +1,39 @@
+-- ============================================================
+-- Migration: spending_digests
+-- Resumos de gastos enviados ao cliente: semanal (segunda a
+-- domingo) e mensal (mês civil). A chave (cliente, período,
+-- primeiro dia) garante que o worker envie cada resumo uma vez,
+-- mesmo rodando várias vezes no dia ou em mais de uma instância.
+--
+-- O resumo é notificado com o tipo 'digest'.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS spending_digests (
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    period TEXT NOT NULL CHECK (period IN ('weekly', 'monthly')),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    digest JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (customer_id, period, period_start)
+);
+
+ALTER TABLE spending_digests ENABLE ROW LEVEL SECURITY;
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN (
+        'transaction', 'digest',
+        'pix_sent', 'pix_received',
+        'transfer_scheduled', 'transfer_executed', 'transfer_failed',
+        'bill_due', 'bill_paid', 'bill_failed',
+        'card_purchase', 'card_invoice_available', 'card_invoice_due',
+        'card_limit_alert', 'card_approved', 'card_blocked',
+        'budget_alert', 'budget_exceeded',
+        'balance_low',
+        'security_alert', 'login_alert',
+        'general'
+    ));
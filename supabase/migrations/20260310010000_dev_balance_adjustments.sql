@@ -0,0 +1,20 @@
+-- ============================================================
+-- Migration: dev_balance_adjustments
+-- Chaves de idempotência do POST /v1/dev/add-balance. Um setup
+-- de teste reenviado com a mesma chave recebe o resultado do
+-- primeiro envio em vez de creditar o saldo de novo.
+-- Apagadas pelo /v1/dev/reset-customer junto com o saldo.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS dev_balance_adjustments (
+    idempotency_key TEXT PRIMARY KEY,
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    amount NUMERIC(15,2) NOT NULL,
+    new_balance NUMERIC(15,2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dev_balance_adjustments_customer
+    ON dev_balance_adjustments(customer_id);
+
+ALTER TABLE dev_balance_adjustments ENABLE ROW LEVEL SECURITY;
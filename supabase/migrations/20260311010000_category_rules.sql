@@ -0,0 +1,20 @@
+-- ============================================================
+-- Migration: category_rules
+-- Regras do cliente para categorizar transações: o padrão é
+-- comparado (sem maiúsculas e sem acentos) com a descrição do
+-- extrato ou o estabelecimento do cartão, e a categoria da regra
+-- substitui a automática. Com várias regras, vale o padrão mais
+-- longo.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS category_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL CHECK (char_length(pattern) BETWEEN 1 AND 100),
+    category TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (customer_id, pattern)
+);
+
+ALTER TABLE category_rules ENABLE ROW LEVEL SECURITY;
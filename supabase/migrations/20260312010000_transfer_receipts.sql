@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration: transfer_receipts
+-- Comprovantes das transferências que não são PIX (entre contas
+-- do próprio cliente, TED e DOC). Os comprovantes PIX continuam
+-- em pix_receipts.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS transfer_receipts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transfer_id UUID NOT NULL UNIQUE,
+    transfer_type TEXT NOT NULL CHECK (transfer_type IN ('internal', 'ted', 'doc')),
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    amount NUMERIC(15,2) NOT NULL,
+    description TEXT,
+    sender_name TEXT,
+    sender_document TEXT,
+    sender_bank TEXT,
+    sender_branch TEXT,
+    sender_account TEXT,
+    recipient_name TEXT,
+    recipient_document TEXT,
+    recipient_bank TEXT,
+    recipient_branch TEXT,
+    recipient_account TEXT,
+    status TEXT NOT NULL DEFAULT 'completed',
+    executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_receipts_customer
+    ON transfer_receipts (customer_id, created_at DESC);
+
+ALTER TABLE transfer_receipts ENABLE ROW LEVEL SECURITY;
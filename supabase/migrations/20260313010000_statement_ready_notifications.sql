@@ -0,0 +1,24 @@
+-- ============================================================
+-- Migration: statement_ready_notifications
+-- Notificação 'statement_ready', enviada quando o extrato pedido
+-- em POST /v1/customers/{customerId}/statements/export fica
+-- pronto para download.
+-- ============================================================
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN (
+        'transaction', 'digest', 'statement_ready',
+        'pix_sent', 'pix_received',
+        'transfer_scheduled', 'transfer_executed', 'transfer_failed',
+        'bill_due', 'bill_paid', 'bill_failed',
+        'card_purchase', 'card_invoice_available', 'card_invoice_due',
+        'card_limit_alert', 'card_approved', 'card_blocked',
+        'budget_alert', 'budget_exceeded',
+        'balance_low',
+        'security_alert', 'login_alert',
+        'general'
+    ));
@@ -0,0 +1,27 @@
+-- ============================================================
+-- Migration: debit_refunds
+-- Estorno de compras no débito. O estorno credita o saldo com um
+-- lançamento 'refund' cujo reference_id aponta para a compra
+-- original; a compra passa a 'refunded'. O índice único impede
+-- dois estornos para a mesma compra.
+-- ============================================================
+
+ALTER TABLE customer_transactions
+    DROP CONSTRAINT IF EXISTS customer_transactions_type_check;
+
+ALTER TABLE customer_transactions
+    ADD CONSTRAINT customer_transactions_type_check
+    CHECK (type IN (
+        'credit', 'debit',
+        'pix_sent', 'pix_received',
+        'debit_purchase', 'credit_purchase',
+        'transfer_in', 'transfer_out',
+        'bill_payment', 'refund'
+    ));
+
+ALTER TABLE customer_transactions
+    ADD COLUMN IF NOT EXISTS reference_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_transactions_refund_reference
+    ON customer_transactions(reference_id)
+    WHERE type = 'refund' AND status <> 'failed';
@@ -0,0 +1,36 @@
+-- ============================================================
+-- Migration: favorites_unique_destination
+-- Um favorito por destino e cliente: chave PIX ou conta bancária.
+-- Cadastros simultâneos do mesmo destino passam a falhar com
+-- violação de unicidade e o BFA devolve o favorito já gravado.
+-- Duplicatas existentes são removidas, mantendo o mais antigo.
+-- ============================================================
+
+-- Favoritos PIX: mesmo destino = mesma chave (dados bancários ignorados)
+DELETE FROM favorites f
+USING favorites older
+WHERE f.customer_id = older.customer_id
+  AND f.destination_type = older.destination_type
+  AND COALESCE(f.pix_key_value, '') <> ''
+  AND f.pix_key_value = older.pix_key_value
+  AND (f.created_at, f.id) > (older.created_at, older.id);
+
+-- Favoritos de conta: mesmo destino = mesmo banco, agência e conta
+DELETE FROM favorites f
+USING favorites older
+WHERE f.customer_id = older.customer_id
+  AND f.destination_type = older.destination_type
+  AND COALESCE(f.pix_key_value, '') = ''
+  AND COALESCE(older.pix_key_value, '') = ''
+  AND COALESCE(f.bank_code, '') = COALESCE(older.bank_code, '')
+  AND COALESCE(f.branch, '') = COALESCE(older.branch, '')
+  AND COALESCE(f.account_number, '') = COALESCE(older.account_number, '')
+  AND (f.created_at, f.id) > (older.created_at, older.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorites_unique_pix_key
+    ON favorites(customer_id, destination_type, pix_key_value)
+    WHERE COALESCE(pix_key_value, '') <> '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorites_unique_bank_account
+    ON favorites(customer_id, destination_type, COALESCE(bank_code, ''), COALESCE(branch, ''), COALESCE(account_number, ''))
+    WHERE COALESCE(pix_key_value, '') = '';
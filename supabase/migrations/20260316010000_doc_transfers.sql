@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration: doc_transfers
+-- DOCs liquidam no próximo dia útil: o valor fica bloqueado no
+-- saldo disponível ao criar a transferência e é debitado pelo
+-- worker na settlement_date, quando o bloqueio é liberado.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS doc_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    recipient_name TEXT NOT NULL,
+    recipient_document TEXT NOT NULL,
+    recipient_bank TEXT NOT NULL,
+    recipient_branch TEXT NOT NULL,
+    recipient_account TEXT NOT NULL,
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    description TEXT,
+    status TEXT NOT NULL DEFAULT 'held'
+        CHECK (status IN ('held', 'processing', 'completed', 'failed')),
+    settlement_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_doc_transfers_due
+    ON doc_transfers (settlement_date)
+    WHERE status = 'held';
+
+CREATE INDEX IF NOT EXISTS idx_doc_transfers_customer
+    ON doc_transfers (customer_id, created_at DESC);
+
+ALTER TABLE doc_transfers ENABLE ROW LEVEL SECURITY;
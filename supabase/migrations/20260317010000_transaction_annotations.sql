@@ -0,0 +1,13 @@
+-- ============================================================
+-- Migration: transaction_annotations
+-- Observação livre e tags que o cliente (ou a contabilidade)
+-- associa a uma transação do extrato. Definidas por
+-- PUT /v1/customers/{id}/transactions/{txId}/annotate.
+-- ============================================================
+
+ALTER TABLE customer_transactions
+    ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_customer_transactions_tags
+    ON customer_transactions USING GIN (tags);
@@ -0,0 +1,53 @@
+-- ============================================================
+-- Migration: settle_doc_transfer
+-- Liquidação de um DOC já reivindicado (status processing) em uma
+-- única transação: debita o saldo contábil, consome o bloqueio do
+-- saldo disponível, grava o lançamento do extrato e conclui o DOC.
+-- Se a conta não estiver mais ativa, o bloqueio volta ao saldo
+-- disponível e o DOC falha, também na mesma transação.
+-- ============================================================
+
+CREATE OR REPLACE FUNCTION settle_doc_transfer(
+    p_transfer_id UUID,
+    p_transaction_id UUID,
+    p_description TEXT,
+    p_category TEXT,
+    p_date TIMESTAMPTZ
+)
+RETURNS JSON
+LANGUAGE plpgsql
+SECURITY DEFINER
+AS $$
+DECLARE
+    t doc_transfers%ROWTYPE;
+    account_status TEXT;
+BEGIN
+    SELECT * INTO t FROM doc_transfers
+    WHERE id = p_transfer_id AND status = 'processing'
+    FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'transfer_not_claimed';
+    END IF;
+
+    SELECT status INTO account_status FROM accounts
+    WHERE id = t.account_id
+    FOR UPDATE;
+
+    IF account_status IS DISTINCT FROM 'active' THEN
+        UPDATE accounts
+        SET available_balance = available_balance + t.amount
+        WHERE id = t.account_id;
+        UPDATE doc_transfers SET status = 'failed', updated_at = NOW() WHERE id = t.id;
+        RETURN json_build_object('status', 'failed');
+    END IF;
+
+    -- The hold already took the amount from available_balance
+    UPDATE accounts SET balance = balance - t.amount WHERE id = t.account_id;
+
+    INSERT INTO customer_transactions (id, customer_id, date, amount, type, category, description, status)
+    VALUES (p_transaction_id, t.customer_id, p_date, -t.amount, 'transfer_out', p_category, p_description, 'confirmed');
+
+    UPDATE doc_transfers SET status = 'completed', updated_at = NOW() WHERE id = t.id;
+    RETURN json_build_object('status', 'completed');
+END;
+$$;
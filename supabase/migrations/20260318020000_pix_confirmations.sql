@@ -0,0 +1,21 @@
+-- ============================================================
+-- Migration: pix_confirmations
+-- Tokens de confirmação de Pix acima do limite de confirmação.
+-- Persistidos para valer entre instâncias e reinícios; o token
+-- só é marcado como usado depois que a transferência conclui.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS pix_confirmations (
+    token UUID PRIMARY KEY,
+    customer_id TEXT NOT NULL REFERENCES customer_profiles(customer_id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'issued'
+        CHECK (status IN ('issued', 'claimed', 'used')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pix_confirmations_expires
+    ON pix_confirmations (expires_at);
+
+ALTER TABLE pix_confirmations ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,10 @@
+-- ============================================================
+-- Migration: dev_balance_adjustments_claim
+-- A chave de idempotência do POST /v1/dev/add-balance passa a
+-- ser gravada antes de o saldo mudar: de duas chamadas com a
+-- mesma chave, só a que inseriu a linha credita. new_balance
+-- fica nulo até o crédito ser aplicado.
+-- ============================================================
+
+ALTER TABLE dev_balance_adjustments
+    ALTER COLUMN new_balance DROP NOT NULL;
@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration: assistant_token_usage
+-- Tokens do agente consumidos por cliente e por dia (fuso do
+-- banco). Compartilhado entre instâncias, o orçamento diário do
+-- assistente vale para todas as réplicas e sobrevive a reinícios.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS assistant_token_usage (
+    customer_id TEXT NOT NULL,
+    day DATE NOT NULL,
+    tokens INTEGER NOT NULL DEFAULT 0 CHECK (tokens >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (customer_id, day)
+);
+
+ALTER TABLE assistant_token_usage ENABLE ROW LEVEL SECURITY;
+
+CREATE OR REPLACE FUNCTION add_assistant_token_usage(
+    p_customer_id TEXT,
+    p_day DATE,
+    p_tokens INTEGER
+)
+RETURNS INTEGER
+LANGUAGE sql
+SECURITY DEFINER
+AS $$
+    INSERT INTO assistant_token_usage (customer_id, day, tokens)
+    VALUES (p_customer_id, p_day, p_tokens)
+    ON CONFLICT (customer_id, day)
+    DO UPDATE SET tokens = assistant_token_usage.tokens + EXCLUDED.tokens,
+                  updated_at = NOW()
+    RETURNING tokens;
+$$;
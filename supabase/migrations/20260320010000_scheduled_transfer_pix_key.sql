@@ -0,0 +1,12 @@
+-- ============================================================
+-- Migration: scheduled_transfer_pix_key
+-- Chave Pix de destino dos agendamentos criados por
+-- POST /v1/pix/schedule. Cada ocorrência vencida vira um Pix
+-- agendado para essa chave (idempotency_key
+-- schedule:<id>:<data>), executado pelo worker de Pix agendado
+-- com as mesmas verificações de limite e saldo.
+-- ============================================================
+
+ALTER TABLE scheduled_transfers
+    ADD COLUMN IF NOT EXISTS destination_key_type TEXT,
+    ADD COLUMN IF NOT EXISTS destination_key_value TEXT;
@@ -0,0 +1,40 @@
+-- ============================================================
+-- Migration: transaction_limit_usage
+-- Soma o valor de um Pix ou boleto ao uso diário e mensal do
+-- limite do cliente em um único statement, zerando antes o uso
+-- de um dia ou mês anterior (fuso America/Sao_Paulo) e movendo
+-- last_reset_daily / last_reset_monthly. Sem limite cadastrado
+-- para o tipo, não faz nada.
+-- ============================================================
+
+CREATE OR REPLACE FUNCTION add_transaction_limit_usage(
+    p_customer_id TEXT,
+    p_transaction_type TEXT,
+    p_daily NUMERIC,
+    p_monthly NUMERIC
+)
+RETURNS VOID
+LANGUAGE sql
+SECURITY DEFINER
+AS $$
+    UPDATE transaction_limits SET
+        daily_used = CASE
+            WHEN (last_reset_daily AT TIME ZONE 'America/Sao_Paulo')::date
+                 < (NOW() AT TIME ZONE 'America/Sao_Paulo')::date
+            THEN 0 ELSE COALESCE(daily_used, 0) END + p_daily,
+        last_reset_daily = CASE
+            WHEN (last_reset_daily AT TIME ZONE 'America/Sao_Paulo')::date
+                 < (NOW() AT TIME ZONE 'America/Sao_Paulo')::date
+            THEN NOW() ELSE last_reset_daily END,
+        monthly_used = CASE
+            WHEN date_trunc('month', last_reset_monthly AT TIME ZONE 'America/Sao_Paulo')
+                 < date_trunc('month', NOW() AT TIME ZONE 'America/Sao_Paulo')
+            THEN 0 ELSE COALESCE(monthly_used, 0) END + p_monthly,
+        last_reset_monthly = CASE
+            WHEN date_trunc('month', last_reset_monthly AT TIME ZONE 'America/Sao_Paulo')
+                 < date_trunc('month', NOW() AT TIME ZONE 'America/Sao_Paulo')
+            THEN NOW() ELSE last_reset_monthly END,
+        updated_at = NOW()
+    WHERE customer_id = p_customer_id
+      AND transaction_type = p_transaction_type;
+$$;
@@ -0,0 +1,12 @@
+-- ============================================================
+-- Migration: doc_transfers_idempotency
+-- Chave de idempotência do DOC: um reenvio com a mesma chave
+-- devolve o DOC original em vez de bloquear o valor de novo.
+-- DOCs anteriores ficam com a chave nula, fora da restrição.
+-- ============================================================
+
+ALTER TABLE doc_transfers
+    ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_doc_transfers_idempotency
+    ON doc_transfers (customer_id, idempotency_key);